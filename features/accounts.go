@@ -0,0 +1,201 @@
+package features
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// Account is a named AWS account reachable through an IAM role, listed under "accounts" in config.json.
+type Account struct {
+	Name    string `json:"name"`             // Short name used with -accounts, e.g. "staging".
+	RoleARN string `json:"roleArn"`          // Role to assume; empty uses the default credentials.
+	Region  string `json:"region,omitempty"` // Overrides the default region for this account.
+}
+
+// NamedClient pairs an account name with an SSM client for that account.
+type NamedClient struct {
+	Name   string
+	Client SSMClient
+}
+
+// SelectAccounts returns the configured accounts matching names, in the order given. Empty names selects all accounts.
+func SelectAccounts(accounts []Account, names []string) ([]Account, error) {
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("no accounts configured in config.json")
+	}
+	if len(names) == 0 {
+		return accounts, nil
+	}
+	byName := make(map[string]Account)
+	for _, account := range accounts {
+		byName[account.Name] = account
+	}
+	var selected []Account
+	for _, name := range names {
+		account, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("account %q is not configured in config.json", name)
+		}
+		selected = append(selected, account)
+	}
+	return selected, nil
+}
+
+// AccountClient returns an SSM client for account, assuming its role on top of the base configuration.
+// optFns apply to the client as in ssm.NewFromConfig.
+func AccountClient(base aws.Config, account Account, optFns ...func(*ssm.Options)) SSMClient {
+	cfg := base.Copy()
+	if account.Region != "" {
+		cfg.Region = account.Region
+	}
+	if account.RoleARN != "" {
+		provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(base), account.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+			o.RoleSessionName = "salter-aws"
+		})
+		cfg.Credentials = aws.NewCredentialsCache(provider)
+	}
+	return ssm.NewFromConfig(cfg, optFns...)
+}
+
+// listParameters returns every parameter under prefix with decrypted values, following pagination.
+func listParameters(client SSMClient, prefix string) ([]types.Parameter, error) {
+	return getParametersByPath(client, prefix, true)
+}
+
+// listParametersEncrypted is listParameters for callers that do not read the values: SecureString values
+// are left encrypted, so no kms:Decrypt is needed and no plaintext is fetched.
+func listParametersEncrypted(client SSMClient, prefix string) ([]types.Parameter, error) {
+	return getParametersByPath(client, prefix, false)
+}
+
+// getParametersByPath returns every parameter under prefix, following pagination.
+func getParametersByPath(client SSMClient, prefix string, decrypt bool) ([]types.Parameter, error) {
+	var params []types.Parameter
+	var nextToken *string
+	for {
+		result, err := client.GetParametersByPath(context.TODO(), &ssm.GetParametersByPathInput{
+			Path:           aws.String(prefix),
+			Recursive:      aws.Bool(true),
+			WithDecryption: aws.Bool(decrypt),
+			NextToken:      nextToken,
+			MaxResults:     aws.Int32(10), // Max allowed is 10.
+		})
+		if err != nil {
+			return nil, wrapClientError(client, "GetParametersByPath", prefix, err)
+		}
+		params = append(params, result.Parameters...)
+		if result.NextToken == nil {
+			return params, nil
+		}
+		nextToken = result.NextToken
+	}
+}
+
+// ListParameters prints the name, type and version of every parameter under prefix.
+func ListParameters(client SSMClient, prefix string) error {
+	params, err := listParametersEncrypted(client, prefix)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tVERSION")
+	for _, param := range params {
+		fmt.Fprintf(w, "%s\t%s\t%d\n", aws.ToString(param.Name), param.Type, param.Version)
+	}
+	return w.Flush()
+}
+
+// CompareParameterAcrossAccounts prints one parameter's type, version and value in every account. Only String
+// and StringList values are printed; SecureString values show a short fingerprint instead, as in
+// CompareListAcrossAccounts, so differences are visible without printing secrets.
+func CompareParameterAcrossAccounts(clients []NamedClient, name string) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACCOUNT\tTYPE\tVERSION\tVALUE")
+	for _, nc := range clients {
+		result, err := nc.Client.GetParameter(context.TODO(), &ssm.GetParameterInput{
+			Name:           aws.String(name),
+			WithDecryption: aws.Bool(true),
+		})
+		if err != nil {
+			fmt.Fprintf(w, "%s\t-\t-\terror: %v\n", nc.Name, err)
+			continue
+		}
+		value := aws.ToString(result.Parameter.Value)
+		if result.Parameter.Type == types.ParameterTypeSecureString {
+			value = maskValue(value)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", nc.Name, result.Parameter.Type, result.Parameter.Version, value)
+	}
+	return w.Flush()
+}
+
+// CompareListAcrossAccounts lists prefix in every account and prints a table with one row per key (relative to prefix).
+// Cells show the type and a short value fingerprint so differences are visible without printing secrets.
+func CompareListAcrossAccounts(clients []NamedClient, prefix string) error {
+	cells := make(map[string]map[string]string) // key -> account -> fingerprint cell.
+	for _, nc := range clients {
+		params, err := listParameters(nc.Client, prefix)
+		if err != nil {
+			return fmt.Errorf("failed to list %s in account %s: %w", prefix, nc.Name, err)
+		}
+		for _, param := range params {
+			key := strings.TrimPrefix(aws.ToString(param.Name), prefix)
+			if cells[key] == nil {
+				cells[key] = make(map[string]string)
+			}
+			cells[key][nc.Name] = fmt.Sprintf("%s %s", param.Type, maskValue(aws.ToString(param.Value)))
+		}
+	}
+	var keys []string
+	for key := range cells {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := []string{"KEY"}
+	for _, nc := range clients {
+		header = append(header, nc.Name)
+	}
+	fmt.Fprintln(w, strings.Join(append(header, "STATUS"), "\t"))
+	for _, key := range keys {
+		row := []string{key}
+		var missing []string
+		distinct := make(map[string]bool)
+		for _, nc := range clients {
+			cell, ok := cells[key][nc.Name]
+			if !ok {
+				cell = "-"
+				missing = append(missing, nc.Name)
+			} else {
+				distinct[cell] = true
+			}
+			row = append(row, cell)
+		}
+		status := "same"
+		switch {
+		case len(missing) > 0:
+			status = "missing in " + strings.Join(missing, ",")
+		case len(distinct) > 1:
+			status = "differs"
+		}
+		fmt.Fprintln(w, strings.Join(append(row, status), "\t"))
+	}
+	return w.Flush()
+}
+
+// shortHash returns the first 8 hex characters of the SHA-256 of value, for names derived from it. It is
+// not a mask: use maskValue for values.
+func shortHash(value string) string {
+	return ValueHash(value)[:8]
+}
@@ -0,0 +1,108 @@
+package features
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// captureStdout returns what fn prints to os.Stdout.
+func captureStdout(t *testing.T, fn func() error) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+	fnErr := fn()
+	w.Close()
+	out := <-done
+	if fnErr != nil {
+		t.Fatalf("unexpected error: %v", fnErr)
+	}
+	return out
+}
+
+func accountClients() []NamedClient {
+	staging, prod := newFakeSSM(), newFakeSSM()
+	staging.set("/app/DB_PASSWORD", "staging-hunter2", types.ParameterTypeSecureString)
+	staging.set("/app/LOG_LEVEL", "debug", types.ParameterTypeString)
+	prod.set("/app/DB_PASSWORD", "prod-hunter2", types.ParameterTypeSecureString)
+	prod.set("/app/LOG_LEVEL", "info", types.ParameterTypeString)
+	return []NamedClient{{Name: "staging", Client: staging}, {Name: "prod", Client: prod}}
+}
+
+func TestCompareParameterAcrossAccounts(t *testing.T) {
+	clients := accountClients()
+	out := captureStdout(t, func() error { return CompareParameterAcrossAccounts(clients, "/app/DB_PASSWORD") })
+	if strings.Contains(out, "hunter2") {
+		t.Errorf("output prints the SecureString value:\n%s", out)
+	}
+	for _, mask := range []string{maskValue("staging-hunter2"), maskValue("prod-hunter2")} {
+		if !strings.Contains(out, mask) {
+			t.Errorf("output lacks fingerprint %s:\n%s", mask, out)
+		}
+	}
+	if strings.Contains(out, shortHash("prod-hunter2")) {
+		t.Errorf("output has the unkeyed hash of a value:\n%s", out)
+	}
+	newMaskRun()
+	if again := captureStdout(t, func() error { return CompareParameterAcrossAccounts(clients, "/app/DB_PASSWORD") }); again == out {
+		t.Errorf("fingerprints are equal in two runs:\n%s", out)
+	}
+
+	out = captureStdout(t, func() error { return CompareParameterAcrossAccounts(clients, "/app/LOG_LEVEL") })
+	if !strings.Contains(out, "debug") || !strings.Contains(out, "info") {
+		t.Errorf("output lacks the String values:\n%s", out)
+	}
+}
+
+func TestCompareListAcrossAccounts(t *testing.T) {
+	out := captureStdout(t, func() error { return CompareListAcrossAccounts(accountClients(), "/app/") })
+	if strings.Contains(out, "hunter2") || strings.Contains(out, "debug") {
+		t.Errorf("output prints values:\n%s", out)
+	}
+	if !strings.Contains(out, "DB_PASSWORD") || !strings.Contains(out, "differs") {
+		t.Errorf("output lacks the differing key:\n%s", out)
+	}
+	newMaskRun()
+	if again := captureStdout(t, func() error { return CompareListAcrossAccounts(accountClients(), "/app/") }); again == out {
+		t.Errorf("fingerprints are equal in two runs:\n%s", out)
+	}
+}
+
+// decryptRecorder records the WithDecryption of every GetParametersByPath call.
+type decryptRecorder struct {
+	*fakeSSM
+	decrypt []bool
+}
+
+func (r *decryptRecorder) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	r.decrypt = append(r.decrypt, aws.ToBool(params.WithDecryption))
+	return r.fakeSSM.GetParametersByPath(ctx, params, optFns...)
+}
+
+func TestListParametersDoesNotDecrypt(t *testing.T) {
+	client := &decryptRecorder{fakeSSM: newFakeSSM()}
+	client.set("/app/DB_PASSWORD", "hunter2", types.ParameterTypeSecureString)
+	out := captureStdout(t, func() error { return ListParameters(client, "/app/") })
+	if !strings.Contains(out, "/app/DB_PASSWORD") || strings.Contains(out, "hunter2") {
+		t.Errorf("ListParameters output:\n%s", out)
+	}
+	if len(client.decrypt) != 1 || client.decrypt[0] {
+		t.Errorf("GetParametersByPath WithDecryption = %v; want [false]", client.decrypt)
+	}
+}
@@ -0,0 +1,77 @@
+package features
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultAgentSocket returns the agent socket path: $XDG_RUNTIME_DIR/salter-aws/agent.sock,
+// or the same under the user cache directory.
+func DefaultAgentSocket() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		var err error
+		if dir, err = os.UserCacheDir(); err != nil {
+			dir = os.TempDir()
+		}
+	}
+	return filepath.Join(dir, "salter-aws", "agent.sock")
+}
+
+// AgentGet fetches one parameter value from a running agent on socket.
+// Agent errors are mapped back to the same error kinds as direct SSM calls.
+func AgentGet(socket, name string) (string, error) {
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+	resp, err := client.Get("http://agent/v1/parameter?name=" + url.QueryEscape(name))
+	if err != nil {
+		return "", fmt.Errorf("agent not reachable on %s (start it with -action agent): %w", socket, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		json.Unmarshal(body, &apiErr)
+		return "", &ParameterError{Op: "agent-get", Name: name, Kind: statusKind(resp.StatusCode), Err: fmt.Errorf("%s", apiErr.Error)}
+	}
+	var param ParameterResponse
+	if err := json.Unmarshal(body, &param); err != nil {
+		return "", fmt.Errorf("invalid agent response: %w", err)
+	}
+	return param.Value, nil
+}
+
+// statusKind is the inverse of writeAPIError's status mapping.
+func statusKind(status int) error {
+	switch status {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusForbidden:
+		return ErrAccessDenied
+	case http.StatusTooManyRequests:
+		return ErrThrottled
+	case http.StatusBadRequest:
+		return ErrValidation
+	}
+	return nil
+}
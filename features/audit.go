@@ -0,0 +1,104 @@
+package features
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// AuditOptions configures audit-types.
+type AuditOptions struct {
+	Fix       bool   // Convert the flagged parameters to SecureString.
+	AssumeYes bool   // Convert without asking.
+	KeyID     string // KMS key of the converted parameters; empty uses aws/ssm.
+}
+
+// AuditParameterTypes checks the String parameters under prefix with the heuristics generate uses to type
+// new keys (see detectParameterType) and lists those that look like secrets, such as a DB_PASSWORD or a
+// token stored in plain text; values are only shown masked. Parameters with a data type such as
+// aws:ec2:image are not secrets and are skipped. Without opts.Fix a TypeAuditError (exit code ExitCheckFailed)
+// is returned when any are found. With it they are converted to SecureString after confirmation, each one
+// deleted and recreated (see recreateParameter); failures are returned as a PartialFailureError.
+func AuditParameterTypes(client SSMClient, prefix string, opts AuditOptions) error {
+	if !strings.HasPrefix(prefix, "/") {
+		return validationErrorf("audit-types needs a -prefix path starting with /, got %q", prefix)
+	}
+	params, err := listParameters(client, prefix)
+	if err != nil {
+		return err
+	}
+	var flagged []types.Parameter
+	checked := 0
+	for _, p := range params {
+		if p.Type != types.ParameterTypeString || aws.ToString(p.DataType) == DataTypeEC2Image {
+			continue
+		}
+		checked++
+		if detectParameterType(path.Base(aws.ToString(p.Name)), aws.ToString(p.Value)) == SecureStringType {
+			flagged = append(flagged, p)
+		}
+	}
+	if len(flagged) == 0 {
+		Infof("%s: none of the %d String parameters under %s look like secrets\n", green("OK"), checked, prefix)
+		return nil
+	}
+	sort.Slice(flagged, func(i, j int) bool { return aws.ToString(flagged[i].Name) < aws.ToString(flagged[j].Name) })
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tVALUE")
+	for _, p := range flagged {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", aws.ToString(p.Name), p.Type, maskValue(aws.ToString(p.Value)))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	auditErr := &TypeAuditError{Plain: len(flagged), Total: checked}
+	if !opts.Fix {
+		Infof("%s %d String parameters look like secrets; -fix converts them to SecureString\n", yellow("Found"), len(flagged))
+		return auditErr
+	}
+	if !opts.AssumeYes && !isDryRun(client) && !Confirm(fmt.Sprintf("Convert the %d parameters to SecureString? Each is deleted and recreated, so its version history starts over", len(flagged))) {
+		Infof("Nothing converted\n")
+		return auditErr
+	}
+	var changes []Change
+	for _, p := range flagged {
+		name := aws.ToString(p.Name)
+		changes = append(changes, Change{Operation: "DeleteParameter", Name: name},
+			Change{Operation: "PutParameter", Name: name, Type: string(SecureStringType)})
+	}
+	if err := planChanges(client, changes); err != nil {
+		return err
+	}
+
+	var failures []error
+	for i, p := range flagged {
+		name := aws.ToString(p.Name)
+		input := &ssm.PutParameterInput{Name: p.Name, Value: p.Value, Type: types.ParameterTypeSecureString}
+		if opts.KeyID != "" {
+			input.KeyId = aws.String(opts.KeyID)
+		}
+		progress := fmt.Sprintf("[%d/%d]", i+1, len(flagged))
+		if err := recreateParameter(client, input); err != nil {
+			Infof("%s %s %s: %s\n", progress, red("Failed to convert"), name, DescribeError(err))
+			failures = append(failures, err)
+			continue
+		}
+		if isDryRun(client) {
+			Infof("%s Would convert %s to SecureString\n", progress, name)
+		} else {
+			Infof("%s %s %s to SecureString\n", progress, green("Converted"), name)
+		}
+	}
+	if len(failures) > 0 {
+		return &PartialFailureError{Failed: failures, Total: len(flagged)}
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package features
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// BenchOptions configures bench.
+type BenchOptions struct {
+	Prefix      string // Empty path the synthetic parameters are created under, e.g. /loadtest/.
+	Count       int    // Parameters written, read and deleted.
+	Concurrency int    // Calls in flight at once.
+}
+
+// benchPhase is the measurements of one phase of a benchmark.
+type benchPhase struct {
+	name      string
+	op        string // SSM operation of the phase's calls.
+	mu        sync.Mutex
+	latencies []time.Duration // Of every call, failed ones included, with the SDK's retries.
+	failures  []error
+	attempts  int // HTTP attempts, retries included; only known for *ssm.Client.
+	throttles int // Attempts rejected by throttling, whether or not a retry then succeeded.
+	elapsed   time.Duration
+}
+
+// RunBenchmark measures the SSM throughput of the account and region of client, to tune -tps and concurrency
+// before a large migration. It puts opts.Count String parameters tagged EphemeralTag under opts.Prefix with
+// opts.Concurrency calls in flight, reads them back and deletes them, and prints each phase's latency
+// percentiles, call rate and throttled attempts. The prefix must be empty, so nothing is overwritten;
+// whatever was written is deleted even when a phase fails. Failed calls are returned as a PartialFailureError.
+func RunBenchmark(client SSMClient, opts BenchOptions) error {
+	prefix := strings.TrimSuffix(opts.Prefix, "/") + "/"
+	switch {
+	case opts.Prefix == "" || !strings.HasPrefix(prefix, "/") || prefix == "/":
+		return validationErrorf("bench needs a -prefix such as /loadtest/ for its parameters")
+	case opts.Count <= 0:
+		return validationErrorf("-count must be positive")
+	case opts.Concurrency <= 0:
+		return validationErrorf("-concurrency must be positive")
+	case isDryRun(client):
+		return validationErrorf("bench measures real calls; -dry-run is not supported")
+	}
+	existing, err := describeParameters(client, types.ParameterStringFilter{
+		Key:    aws.String("Path"),
+		Option: aws.String("Recursive"),
+		Values: []string{strings.TrimSuffix(prefix, "/")},
+	})
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return validationErrorf("%s already has %d parameters; bench only runs under an empty prefix", prefix, len(existing))
+	}
+
+	names := make([]string, opts.Count)
+	changes := make([]Change, 0, 2*opts.Count)
+	for i := range names {
+		names[i] = fmt.Sprintf("%sbench-%05d", prefix, i+1)
+		changes = append(changes, Change{Operation: "PutParameter", Name: names[i], Type: string(types.ParameterTypeString)})
+	}
+	for _, name := range names {
+		changes = append(changes, Change{Operation: "DeleteParameter", Name: name})
+	}
+	if err := planChanges(client, changes); err != nil {
+		return err
+	}
+	ephemeral, _ := parseTag(EphemeralTag)
+	value := make([]byte, 16)
+	if _, err := rand.Read(value); err != nil {
+		return err
+	}
+
+	Infof("Benchmarking %d parameters under %s with %d concurrent calls%s\n", opts.Count, prefix, opts.Concurrency, regionSuffix(client))
+	var written sync.Map
+	write := runBenchPhase("put", "PutParameter", names, opts.Concurrency, func(ctx context.Context, name string) (middleware.Metadata, error) {
+		out, err := client.PutParameter(ctx, &ssm.PutParameterInput{
+			Name:        aws.String(name),
+			Value:       aws.String(hex.EncodeToString(value)),
+			Type:        types.ParameterTypeString,
+			Description: aws.String("Synthetic parameter of salter-aws bench; safe to delete"),
+			Tags:        []types.Tag{ephemeral},
+		})
+		if err != nil {
+			return middleware.Metadata{}, err
+		}
+		written.Store(name, true)
+		return out.ResultMetadata, nil
+	})
+	var created []string
+	for _, name := range names {
+		if _, ok := written.Load(name); ok {
+			created = append(created, name)
+		}
+	}
+	read := runBenchPhase("get", "GetParameter", created, opts.Concurrency, func(ctx context.Context, name string) (middleware.Metadata, error) {
+		out, err := client.GetParameter(ctx, &ssm.GetParameterInput{Name: aws.String(name)})
+		if err != nil {
+			return middleware.Metadata{}, err
+		}
+		return out.ResultMetadata, nil
+	})
+	del := runBenchPhase("delete", "DeleteParameter", created, opts.Concurrency, func(ctx context.Context, name string) (middleware.Metadata, error) {
+		out, err := client.DeleteParameter(ctx, &ssm.DeleteParameterInput{Name: aws.String(name)})
+		if err != nil {
+			return middleware.Metadata{}, err
+		}
+		return out.ResultMetadata, nil
+	})
+
+	phases := []*benchPhase{write, read, del}
+	printBenchPhases(phases)
+	var failures []error
+	throttled := false
+	for _, phase := range phases {
+		failures = append(failures, phase.failures...)
+		throttled = throttled || phase.throttles > 0
+	}
+	if throttled {
+		Infof("%s SSM throttled some calls at %d concurrent calls; use a lower concurrency or -tps for migrations\n", yellow("Note:"), opts.Concurrency)
+	}
+	if len(del.failures) > 0 {
+		Infof("%s %d parameters could not be deleted; remove them with: salter-aws -action gc -tag %s -prefix %s -older-than 1m\n", yellow("Warning:"), len(del.failures), EphemeralTag, prefix)
+	}
+	if len(failures) > 0 {
+		return &PartialFailureError{Failed: failures, Total: len(names) + 2*len(created)}
+	}
+	return nil
+}
+
+// runBenchPhase calls call, which makes an op call, for every name, with concurrency calls in flight, and measures them.
+func runBenchPhase(name, op string, names []string, concurrency int, call func(context.Context, string) (middleware.Metadata, error)) *benchPhase {
+	phase := &benchPhase{name: name, op: op}
+	work := make(chan string)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < concurrency && i < len(names); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for param := range work {
+				began := time.Now()
+				md, err := call(context.TODO(), param)
+				phase.record(time.Since(began), md, err, param)
+			}
+		}()
+	}
+	for _, param := range names {
+		work <- param
+	}
+	close(work)
+	wg.Wait()
+	phase.elapsed = time.Since(start)
+	return phase
+}
+
+// record adds the measurements of one call. The attempts of a successful call come from its metadata; a
+// failed one counts its final error only, since the SDK returns no metadata with errors.
+func (p *benchPhase) record(latency time.Duration, md middleware.Metadata, err error, name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.latencies = append(p.latencies, latency)
+	if results, ok := retry.GetAttemptResults(md); ok {
+		p.attempts += len(results.Results)
+		for _, result := range results.Results {
+			if errors.Is(classifyAWSError(result.Err), ErrThrottled) {
+				p.throttles++
+			}
+		}
+	} else {
+		p.attempts++
+	}
+	if err != nil {
+		p.failures = append(p.failures, wrapAWSError(p.op, name, err))
+		if errors.Is(classifyAWSError(err), ErrThrottled) {
+			p.throttles++
+		}
+	}
+}
+
+// percentile returns the nearest-rank percentile q (0 < q <= 1) of sorted latencies.
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[int(math.Ceil(q*float64(len(sorted))))-1]
+}
+
+// printBenchPhases prints one row per phase.
+func printBenchPhases(phases []*benchPhase) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PHASE\tCALLS\tFAILED\tCALLS/S\tP50\tP90\tP99\tMAX\tTHROTTLED")
+	for _, p := range phases {
+		sorted := append([]time.Duration(nil), p.latencies...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		rate := 0.0
+		if p.elapsed > 0 {
+			rate = float64(len(sorted)) / p.elapsed.Seconds()
+		}
+		throttled := "0"
+		if p.attempts > 0 && p.throttles > 0 {
+			throttled = fmt.Sprintf("%d (%.1f%% of attempts)", p.throttles, 100*float64(p.throttles)/float64(p.attempts))
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.1f\t%s\t%s\t%s\t%s\t%s\n", p.name, len(sorted), len(p.failures), rate,
+			roundLatency(percentile(sorted, 0.5)), roundLatency(percentile(sorted, 0.9)), roundLatency(percentile(sorted, 0.99)),
+			roundLatency(percentile(sorted, 1)), throttled)
+	}
+	w.Flush()
+}
+
+// roundLatency rounds d for display: to 0.1ms below a second, to 1ms above.
+func roundLatency(d time.Duration) time.Duration {
+	if d < time.Second {
+		return d.Round(100 * time.Microsecond)
+	}
+	return d.Round(time.Millisecond)
+}
+
+// regionSuffix returns " in <region>" for a regional client, or "".
+func regionSuffix(client SSMClient) string {
+	if region := clientRegion(client); region != "" {
+		return " in " + region
+	}
+	return ""
+}
@@ -0,0 +1,75 @@
+package features
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/smithy-go"
+)
+
+// lockedSSM serializes the calls of concurrent workers to a fakeSSM, and throttles the puts of throttle.
+type lockedSSM struct {
+	mu sync.Mutex
+	*fakeSSM
+	throttle map[string]bool
+}
+
+func (c *lockedSSM) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.fakeSSM.GetParameter(ctx, params, optFns...)
+}
+
+func (c *lockedSSM) PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.throttle[aws.ToString(params.Name)] {
+		return nil, &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"}
+	}
+	return c.fakeSSM.PutParameter(ctx, params, optFns...)
+}
+
+func (c *lockedSSM) DeleteParameter(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.fakeSSM.DeleteParameter(ctx, params, optFns...)
+}
+
+func TestRunBenchmark(t *testing.T) {
+	fake := newFakeSSM()
+	client := &lockedSSM{fakeSSM: fake, throttle: map[string]bool{"/loadtest/bench-00003": true}}
+	err := RunBenchmark(client, BenchOptions{Prefix: "/loadtest", Count: 20, Concurrency: 4})
+	var partial *PartialFailureError
+	if !errors.As(err, &partial) || len(partial.Failed) != 1 || !errors.Is(err, ErrThrottled) {
+		t.Fatalf("RunBenchmark error = %v; want the throttled put as a partial failure", err)
+	}
+	if fake.puts != 19 || len(fake.params) != 0 {
+		t.Errorf("puts = %d, left = %d; want 19 puts, all deleted", fake.puts, len(fake.params))
+	}
+
+	fake.set("/loadtest/keep", "x", types.ParameterTypeString)
+	if err := RunBenchmark(client, BenchOptions{Prefix: "/loadtest/", Count: 5, Concurrency: 2}); !errors.Is(err, ErrValidation) {
+		t.Errorf("RunBenchmark under a used prefix = %v; want a validation error", err)
+	}
+	if err := RunBenchmark(NewDryRunClient(newFakeSSM()), BenchOptions{Prefix: "/loadtest/", Count: 5, Concurrency: 2}); !errors.Is(err, ErrValidation) {
+		t.Errorf("dry-run RunBenchmark = %v; want a validation error", err)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 10; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	for q, want := range map[float64]time.Duration{0.5: 5 * time.Millisecond, 0.9: 9 * time.Millisecond, 0.99: 10 * time.Millisecond, 1: 10 * time.Millisecond} {
+		if got := percentile(sorted, q); got != want {
+			t.Errorf("percentile(%v) = %v; want %v", q, got, want)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package features
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// BootstrapSpec declares the parameters an environment needs, read by bootstrap from YAML (or JSON):
+//
+//	prefix: /{{env}}/billing/
+//	parameters:
+//	  - name: DB_URL
+//	    type: securestring
+//	    description: Primary database
+//	    pattern: ^postgres://
+//	  - name: LOG_LEVEL
+//	    default: info
+//	    defaults: {prod: warn}
+//	    allowed: [debug, info, warn]
+//	  - name: SESSION_SECRET
+//	    type: securestring
+//	    generate: {length: 48}
+type BootstrapSpec struct {
+	// Prefix of the parameter paths, with {{name}} placeholders such as {{env}}. When empty, the names are
+	// logical names placed by the pathPattern of config.json.
+	Prefix     string                   `yaml:"prefix"`
+	Parameters []BootstrapSpecParameter `yaml:"parameters"`
+}
+
+// BootstrapSpecParameter is one required key of a BootstrapSpec.
+type BootstrapSpecParameter struct {
+	Name        string             `yaml:"name"`
+	Type        string             `yaml:"type"` // string (default), stringlist or securestring.
+	Description string             `yaml:"description"`
+	KMSKeyID    string             `yaml:"kmsKeyId"`
+	Default     string             `yaml:"default"`  // Value when the environment has none in Defaults.
+	Defaults    map[string]string  `yaml:"defaults"` // Value by environment.
+	Generate    *BootstrapGenerate `yaml:"generate"` // Random value when there is no default.
+	Optional    bool               `yaml:"optional"` // Skip instead of asking when there is no value.
+	// Validation rules, checked for new values and reported for existing ones.
+	Pattern   string   `yaml:"pattern"` // Regular expression the whole value must match.
+	Allowed   []string `yaml:"allowed"`
+	MinLength int      `yaml:"minLength"`
+	MaxLength int      `yaml:"maxLength"`
+
+	pattern   *regexp.Regexp
+	paramType ParameterType
+}
+
+// BootstrapGenerate describes a random value.
+type BootstrapGenerate struct {
+	Length  int    `yaml:"length"`  // Characters; 32 when zero.
+	Charset string `yaml:"charset"` // alphanumeric (default), hex or urlsafe (alphanumeric plus - and _).
+}
+
+// bootstrapCharsets are the characters BootstrapGenerate.Charset selects.
+var bootstrapCharsets = map[string]string{
+	"alphanumeric": "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789",
+	"hex":          "0123456789abcdef",
+	"urlsafe":      "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_",
+}
+
+// ReadBootstrapSpec reads and checks a spec file; unknown fields are errors, so typos in rules are caught.
+func ReadBootstrapSpec(filename string) (*BootstrapSpec, error) {
+	data, err := readSource(filename)
+	if err != nil {
+		return nil, err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var spec BootstrapSpec
+	if err := dec.Decode(&spec); err != nil {
+		return nil, validationErrorf("failed to parse %s: %v", filename, err)
+	}
+	if len(spec.Parameters) == 0 {
+		return nil, validationErrorf("%s declares no parameters", filename)
+	}
+	seen := make(map[string]bool)
+	for i := range spec.Parameters {
+		p := &spec.Parameters[i]
+		if p.Name == "" {
+			return nil, validationErrorf("parameter %d of %s has no name", i+1, filename)
+		}
+		if seen[p.Name] {
+			return nil, validationErrorf("%s is declared twice in %s", p.Name, filename)
+		}
+		seen[p.Name] = true
+		p.paramType = StringType
+		if p.Type != "" {
+			if p.paramType, err = ParseParameterType(p.Type); err != nil {
+				return nil, fmt.Errorf("%s: %w", p.Name, err)
+			}
+		}
+		if p.Pattern != "" {
+			if p.pattern, err = regexp.Compile("^(?:" + p.Pattern + ")$"); err != nil {
+				return nil, validationErrorf("%s: invalid pattern: %v", p.Name, err)
+			}
+		}
+		if g := p.Generate; g != nil {
+			if g.Charset == "" {
+				g.Charset = "alphanumeric"
+			}
+			if _, ok := bootstrapCharsets[g.Charset]; !ok {
+				return nil, validationErrorf("%s: unknown charset %q (use alphanumeric, hex or urlsafe)", p.Name, g.Charset)
+			}
+			if g.Length == 0 {
+				g.Length = 32
+			}
+			if g.Length < 8 {
+				return nil, validationErrorf("%s: generated values need at least 8 characters, got %d", p.Name, g.Length)
+			}
+		}
+	}
+	return &spec, nil
+}
+
+// check returns why value breaks the rules of p, or nil.
+func (p *BootstrapSpecParameter) check(value string) error {
+	switch {
+	case p.pattern != nil && !p.pattern.MatchString(value):
+		return validationErrorf("%s does not match %s", p.Name, p.Pattern)
+	case len(p.Allowed) > 0 && !contains(p.Allowed, value):
+		return validationErrorf("%s must be one of %s", p.Name, strings.Join(p.Allowed, ", "))
+	case p.MinLength > 0 && len(value) < p.MinLength:
+		return validationErrorf("%s is shorter than %d characters", p.Name, p.MinLength)
+	case p.MaxLength > 0 && len(value) > p.MaxLength:
+		return validationErrorf("%s is longer than %d characters", p.Name, p.MaxLength)
+	}
+	return nil
+}
+
+// contains reports whether list holds s.
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// generateValue returns a random value as g describes.
+func generateValue(g *BootstrapGenerate) (string, error) {
+	charset := bootstrapCharsets[g.Charset]
+	out := make([]byte, g.Length)
+	for i := range out {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+		if err != nil {
+			return "", err
+		}
+		out[i] = charset[n.Int64()]
+	}
+	return string(out), nil
+}
+
+// bootstrapItem is a parameter of the spec that bootstrap creates.
+type bootstrapItem struct {
+	spec      *BootstrapSpecParameter
+	paramName string
+	value     string
+	source    string // Where the value comes from, for the report.
+}
+
+// Bootstrap creates every parameter of the spec that is missing in the environment env (the {{env}} of
+// tmplOpts.Vars). Values come from the parameter's defaults for env, its default, a generated random value,
+// or are asked for as seed does; every value is checked against the parameter's rules before anything is
+// created, and then all are created in one pass, with their descriptions. Existing parameters are kept and
+// only checked against the rules: one that breaks them fails the run after the missing ones are created.
+func Bootstrap(client SSMClient, spec *BootstrapSpec, env string, tmplOpts TemplateOptions, opts PutOptions) error {
+	if env == "" {
+		return validationErrorf("-env is required for 'bootstrap'")
+	}
+	vars := make(map[string]string, len(tmplOpts.Vars)+1)
+	for key, value := range tmplOpts.Vars {
+		vars[key] = value
+	}
+	vars["env"] = env
+	tmplOpts.Vars = vars
+	prefix := ""
+	if spec.Prefix != "" {
+		var err error
+		if prefix, err = ExpandTemplateVars(spec.Prefix, vars); err != nil {
+			return err
+		}
+		if !strings.HasPrefix(prefix, "/") {
+			return validationErrorf("spec prefix %q must start with /", prefix)
+		}
+		prefix = strings.TrimSuffix(prefix, "/") + "/"
+	}
+
+	var create []*bootstrapItem
+	var invalid []error
+	existing := 0
+	for i := range spec.Parameters {
+		p := &spec.Parameters[i]
+		item := &bootstrapItem{spec: p, paramName: prefix + p.Name}
+		if prefix == "" {
+			var err error
+			if item.paramName, err = tmplOpts.ParameterPath(p.Name); err != nil {
+				return fmt.Errorf("%s: %w (or set \"prefix\" in the spec)", p.Name, err)
+			}
+		}
+		value, _, err := GetParameter(client, item.paramName)
+		if err == nil {
+			existing++
+			if err := p.check(value); err != nil {
+				Infof("%s %s: %v\n", red("Invalid"), item.paramName, err)
+				invalid = append(invalid, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		switch {
+		case p.Defaults[env] != "":
+			item.value, item.source = p.Defaults[env], "default for "+env
+		case p.Default != "":
+			item.value, item.source = p.Default, "default"
+		case p.Generate != nil:
+			if item.value, err = generateValue(p.Generate); err != nil {
+				return fmt.Errorf("failed to generate %s: %w", p.Name, err)
+			}
+			item.source = "generated"
+		default:
+			if p.Description != "" {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", p.Name, p.Description)
+			}
+			prompt := &seedItem{secret: ExtendedSecret{Name: p.Name}, paramName: item.paramName, paramType: p.paramType}
+			if item.value, err = promptSeedValue(prompt); err != nil {
+				return err
+			}
+			item.source = "entered"
+		}
+		if item.value == "" {
+			if p.Optional {
+				Infof("%s %s: optional, no value\n", yellow("Skipped"), item.paramName)
+				continue
+			}
+			return validationErrorf("%s is required and has no value", p.Name)
+		}
+		if err := p.check(item.value); err != nil {
+			return err
+		}
+		if err := validatePut(item.paramName, item.value, p.paramType, opts.Tier); err != nil {
+			return fmt.Errorf("%s: %w", p.Name, err)
+		}
+		create = append(create, item)
+	}
+
+	var failures []error
+	for _, item := range create {
+		itemOpts := opts
+		itemOpts.NoOverwrite = true // Never replace a value someone created since the check.
+		itemOpts.Description = item.spec.Description
+		if item.spec.KMSKeyID != "" {
+			itemOpts.KeyID = item.spec.KMSKeyID
+		}
+		err := PutParameter(client, item.paramName, item.value, item.spec.paramType, itemOpts)
+		switch {
+		case errors.Is(err, ErrOverwriteDeclined):
+			Infof("%s %s: created by someone else meanwhile\n", yellow("Skipped"), item.paramName)
+		case err != nil:
+			Infof("%s %s: %s\n", red("Failed to create"), item.paramName, DescribeError(err))
+			failures = append(failures, fmt.Errorf("%s: %w", item.paramName, err))
+		case isDryRun(client):
+			Infof("Would create %s as %s (%s)\n", item.paramName, item.spec.paramType, item.source)
+		default:
+			Infof("%s %s as %s (%s)\n", green("Created"), item.paramName, item.spec.paramType, item.source)
+		}
+	}
+	Infof("Environment %s: %d parameters existed, %d to create\n", env, existing, len(create))
+	if len(failures) > 0 {
+		return &PartialFailureError{Failed: failures, Total: len(create)}
+	}
+	if len(invalid) > 0 {
+		return validationErrorf("%d existing parameters break the rules of the spec", len(invalid))
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package features
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func writeSpec(t *testing.T, content string) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "spec.yaml")
+	if err := os.WriteFile(name, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestBootstrap(t *testing.T) {
+	spec, err := ReadBootstrapSpec(writeSpec(t, `prefix: /{{env}}/billing
+parameters:
+  - name: DB_URL
+    type: securestring
+    description: Primary database
+    pattern: ^postgres://.*
+  - name: LOG_LEVEL
+    default: info
+    defaults: {prod: warn}
+    allowed: [debug, info, warn]
+  - name: SESSION_SECRET
+    type: securestring
+    generate: {length: 40, charset: hex}
+  - name: SENTRY_DSN
+    optional: true
+  - name: REGION
+    default: eu-west-1
+`))
+	if err != nil {
+		t.Fatalf("ReadBootstrapSpec: %v", err)
+	}
+	fake := newFakeSSM()
+	fake.set("/prod/billing/REGION", "ap-southeast-3", types.ParameterTypeString)
+
+	// DB_URL is typed in and SENTRY_DSN left empty.
+	withStdin(t, "postgres://prod-db\n\n")
+	if err := Bootstrap(fake, spec, "prod", TemplateOptions{}, PutOptions{}); err != nil {
+		t.Fatalf("Bootstrap: %v", err)
+	}
+	if p := fake.params["/prod/billing/DB_URL"]; aws.ToString(p.Value) != "postgres://prod-db" || p.Type != types.ParameterTypeSecureString {
+		t.Errorf("DB_URL = %q (%s)", aws.ToString(p.Value), p.Type)
+	}
+	if d := aws.ToString(fake.meta["/prod/billing/DB_URL"].Description); d != "Primary database" {
+		t.Errorf("DB_URL description = %q", d)
+	}
+	if v := aws.ToString(fake.params["/prod/billing/LOG_LEVEL"].Value); v != "warn" {
+		t.Errorf("LOG_LEVEL = %q; want the prod default", v)
+	}
+	if v := aws.ToString(fake.params["/prod/billing/SESSION_SECRET"].Value); len(v) != 40 || strings.Trim(v, "0123456789abcdef") != "" {
+		t.Errorf("SESSION_SECRET = %q; want 40 hex characters", v)
+	}
+	if _, ok := fake.params["/prod/billing/SENTRY_DSN"]; ok {
+		t.Error("optional SENTRY_DSN without a value was created")
+	}
+	if v := aws.ToString(fake.params["/prod/billing/REGION"].Value); v != "ap-southeast-3" {
+		t.Errorf("existing REGION = %q; want it kept", v)
+	}
+	if fake.puts != 3 {
+		t.Errorf("%d puts; want 3", fake.puts)
+	}
+
+	// A second run has nothing to create; an existing value breaking a rule fails it.
+	fake.set("/prod/billing/LOG_LEVEL", "verbose", types.ParameterTypeString)
+	withStdin(t, "\n")
+	err = Bootstrap(fake, spec, "prod", TemplateOptions{}, PutOptions{})
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("invalid existing value: %v; want a validation error", err)
+	}
+	if fake.puts != 3 {
+		t.Errorf("%d puts after the second run; want 3", fake.puts)
+	}
+}
+
+func TestBootstrapRejectsBeforeCreating(t *testing.T) {
+	spec, err := ReadBootstrapSpec(writeSpec(t, `parameters:
+  - name: LOG_LEVEL
+    default: info
+  - name: PORT
+    pattern: "[0-9]+"
+`))
+	if err != nil {
+		t.Fatalf("ReadBootstrapSpec: %v", err)
+	}
+	fake := newFakeSSM()
+	withStdin(t, "80a\n")
+	tmplOpts := TemplateOptions{PathPattern: "/{{env}}/app/{{name}}"}
+	if err := Bootstrap(fake, spec, "staging", tmplOpts, PutOptions{}); !errors.Is(err, ErrValidation) {
+		t.Errorf("PORT 80a: %v; want a validation error", err)
+	}
+	if fake.puts != 0 {
+		t.Errorf("%d puts; want none before every value is valid", fake.puts)
+	}
+
+	withStdin(t, "8080\n")
+	if err := Bootstrap(fake, spec, "staging", tmplOpts, PutOptions{}); err != nil {
+		t.Fatalf("Bootstrap: %v", err)
+	}
+	if v := aws.ToString(fake.params["/staging/app/PORT"].Value); v != "8080" {
+		t.Errorf("PORT = %q; want 8080 at the pathPattern path", v)
+	}
+}
+
+func TestReadBootstrapSpecErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown field":  "parameters:\n  - name: A\n    requried: true\n",
+		"no parameters":  "prefix: /prod/\n",
+		"duplicate":      "parameters:\n  - name: A\n  - name: A\n",
+		"bad type":       "parameters:\n  - name: A\n    type: secret\n",
+		"bad pattern":    "parameters:\n  - name: A\n    pattern: '['\n",
+		"bad charset":    "parameters:\n  - name: A\n    generate: {charset: emoji}\n",
+		"short generate": "parameters:\n  - name: A\n    generate: {length: 4}\n",
+	} {
+		if _, err := ReadBootstrapSpec(writeSpec(t, content)); !errors.Is(err, ErrValidation) {
+			t.Errorf("%s: %v; want a validation error", name, err)
+		}
+	}
+}
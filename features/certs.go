@@ -0,0 +1,165 @@
+package features
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// pemCertificates returns the certificates of the PEM CERTIFICATE blocks in value, in order, and the types
+// of its other blocks, such as PRIVATE KEY. A block that does not parse is skipped and its error returned
+// with the rest.
+func pemCertificates(value string) ([]*x509.Certificate, []string, error) {
+	var certs []*x509.Certificate
+	var others []string
+	var firstErr error
+	rest := []byte(value)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return certs, others, firstErr
+		}
+		if block.Type != "CERTIFICATE" {
+			others = append(others, block.Type)
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("invalid certificate %d: %w", len(certs)+1, err)
+			}
+			continue
+		}
+		certs = append(certs, cert)
+	}
+}
+
+// InspectCertificates prints the subject, issuer, SANs and validity of the PEM certificates stored in the
+// named parameters instead of their values, then verifies them as one chain: the first certificate is the
+// leaf, the others intermediates, and self-signed ones roots (the system roots when there are none), so a
+// chain split across parameters such as /prod/tls/cert and /prod/tls/chain is checked as it is served.
+// Private keys are only named. A chain that does not verify is an ErrValidation.
+func InspectCertificates(client SSMClient, names []string) error {
+	var chain []*x509.Certificate
+	for _, name := range names {
+		value, _, err := GetParameter(client, name)
+		if err != nil {
+			return err
+		}
+		certs, others, err := pemCertificates(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if len(certs) == 0 && len(others) == 0 {
+			return validationErrorf("%s holds no PEM blocks; use -action get without -inspect", name)
+		}
+		fmt.Printf("Parameter %s:\n", name)
+		for _, cert := range certs {
+			chain = append(chain, cert)
+			printCertificate(len(chain), cert)
+		}
+		for _, other := range others {
+			fmt.Printf("  %s block (not shown)\n", other)
+		}
+	}
+	if len(chain) == 0 {
+		return nil
+	}
+	verified, err := verifyChain(chain, time.Now())
+	if err != nil {
+		fmt.Printf("%s %v\n", red("Chain invalid:"), err)
+		return validationErrorf("certificate chain does not verify: %v", err)
+	}
+	var subjects []string
+	for _, cert := range verified {
+		subjects = append(subjects, certName(cert))
+	}
+	fmt.Printf("%s %s\n", green("Chain valid:"), strings.Join(subjects, " -> "))
+	return nil
+}
+
+// printCertificate prints the details of the nth certificate of the chain.
+func printCertificate(n int, cert *x509.Certificate) {
+	now := time.Now()
+	status := green("valid for " + formatAge(cert.NotAfter.Sub(now)))
+	switch {
+	case now.After(cert.NotAfter):
+		status = red("expired " + formatAge(now.Sub(cert.NotAfter)) + " ago")
+	case now.Before(cert.NotBefore):
+		status = yellow("not valid yet")
+	case cert.NotAfter.Sub(now) < 30*24*time.Hour:
+		status = yellow("expires in " + formatAge(cert.NotAfter.Sub(now)))
+	}
+	var sans []string
+	sans = append(sans, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	sans = append(sans, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+	sum := sha256.Sum256(cert.Raw)
+	fmt.Printf("  Certificate %d\n", n)
+	fmt.Printf("    Subject:     %s\n", cert.Subject)
+	fmt.Printf("    Issuer:      %s\n", cert.Issuer)
+	if len(sans) > 0 {
+		fmt.Printf("    SANs:        %s\n", strings.Join(sans, ", "))
+	}
+	fmt.Printf("    Not before:  %s\n", cert.NotBefore.UTC().Format(time.RFC3339))
+	fmt.Printf("    Not after:   %s (%s)\n", cert.NotAfter.UTC().Format(time.RFC3339), status)
+	if cert.IsCA {
+		fmt.Printf("    CA:          yes\n")
+	}
+	fmt.Printf("    SHA-256:     %s\n", hex.EncodeToString(sum[:]))
+}
+
+// verifyChain verifies chain[0] with the other certificates as intermediates, or as roots when self-signed,
+// and returns the verified chain from the leaf to its root.
+func verifyChain(chain []*x509.Certificate, now time.Time) ([]*x509.Certificate, error) {
+	intermediates := x509.NewCertPool()
+	var roots *x509.CertPool // nil uses the system roots.
+	for _, cert := range chain[1:] {
+		if isSelfSigned(cert) {
+			if roots == nil {
+				roots = x509.NewCertPool()
+			}
+			roots.AddCert(cert)
+		} else {
+			intermediates.AddCert(cert)
+		}
+	}
+	if len(chain) == 1 && isSelfSigned(chain[0]) {
+		roots = x509.NewCertPool()
+		roots.AddCert(chain[0])
+	}
+	chains, err := chain[0].Verify(x509.VerifyOptions{
+		Intermediates: intermediates,
+		Roots:         roots,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return chains[0], nil
+}
+
+// isSelfSigned reports whether cert is issued and signed by itself, as a root is.
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignatureFrom(cert) == nil
+}
+
+// certName names cert by its common name, or its whole subject when it has none.
+func certName(cert *x509.Certificate) string {
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName
+	}
+	return cert.Subject.String()
+}
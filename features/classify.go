@@ -0,0 +1,262 @@
+package features
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"strings"
+)
+
+// Report formats of classify.
+const (
+	ReportJSON  = "json"  // The findings array of a gitleaks JSON report.
+	ReportSARIF = "sarif" // SARIF 2.1.0, as uploaded to code scanning.
+)
+
+// Detection rules of keys typed before the detection rules are consulted.
+const (
+	declaredRule = "declared-type" // The source sets the type, such as with # param: type=.
+	mappedRule   = "type-mapping"  // The type mapping file sets it (see TypeMap).
+)
+
+// ClassifyOptions configures classify.
+type ClassifyOptions struct {
+	Format      string // Input format of the source; empty picks it by extension, as generate does.
+	Arrays      string
+	Strict      bool
+	Types       string // Type mapping file, as for generate.
+	Report      string // ReportJSON or ReportSARIF.
+	Output      string // File to write the report to; empty writes it to stdout.
+	ToolVersion string // Version recorded in SARIF reports.
+}
+
+// Finding is a key classify types as SecureString, with the rule that matched and where the key is.
+type Finding struct {
+	Key       string
+	Line      int
+	Detection Detection
+	Entropy   float64 // Shannon entropy of the value, in bits per character.
+}
+
+// ClassifySource reads source as generate does and writes a report on the keys it would store as
+// SecureString and why, with the same detection rules (see classifyParameter), so security tooling can
+// consume them. Values are never included: gitleaks-style fields holding the secret read REDACTED. Lines
+// are those of the first line naming the key, or 1 when the key is not spelled out, as in nested JSON.
+func ClassifySource(source string, opts ClassifyOptions) error {
+	if opts.Report != ReportJSON && opts.Report != ReportSARIF {
+		return validationErrorf("invalid report format %q (use %s or %s)", opts.Report, ReportJSON, ReportSARIF)
+	}
+	if opts.Format == "" && InputFormatFromFile(source) == "" {
+		opts.Format = InputDotenv // As generate: .env files are often named app.env.prod.
+	}
+	params, err := readInput(source, opts.Format, InputOptions{Arrays: opts.Arrays, Strict: opts.Strict})
+	if err != nil {
+		return err
+	}
+	typeMap, err := typeMapFor(source, opts.Types)
+	if err != nil {
+		return err
+	}
+	data, _ := readSource(source) // Read again for line numbers; stdin is cached.
+	var findings []Finding
+	for _, p := range params {
+		declared := p.Type != ""
+		if p, _, err = typeMap.apply(p); err != nil {
+			return err
+		}
+		finding := Finding{Key: p.Key, Line: keyLine(data, p.Key), Entropy: shannonEntropy(p.Value)}
+		switch {
+		case p.Type == "":
+			paramType, detection := classifyParameter(p.Key, p.Value)
+			if paramType != SecureStringType {
+				continue
+			}
+			finding.Detection = *detection
+		case p.Type != SecureStringType:
+			continue
+		case declared:
+			finding.Detection = Detection{Rule: declaredRule, Reason: ruleDescription(declaredRule)}
+		default:
+			finding.Detection = Detection{Rule: mappedRule, Reason: fmt.Sprintf("%s maps the key to SecureString", typeMap.File)}
+		}
+		findings = append(findings, finding)
+	}
+
+	var report []byte
+	if opts.Report == ReportSARIF {
+		report, err = sarifReport(source, findings, opts.ToolVersion)
+	} else {
+		report, err = gitleaksReport(source, findings)
+	}
+	if err != nil {
+		return err
+	}
+	if opts.Output == "" {
+		fmt.Print(string(report))
+	} else if err := os.WriteFile(opts.Output, report, 0644); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", opts.Output, err)
+	}
+	Infof("%d of %d keys in %s classified as secrets\n", len(findings), len(params), source)
+	return nil
+}
+
+// keyLine returns the 1-based number of the first line of data naming the last level of key, or 1.
+func keyLine(data []byte, key string) int {
+	name := path.Base(key)
+	for i, line := range strings.Split(string(data), "\n") {
+		if strings.Contains(line, name) {
+			return i + 1
+		}
+	}
+	return 1
+}
+
+// gitleaksFinding is a finding of a gitleaks JSON report.
+type gitleaksFinding struct {
+	Description string
+	StartLine   int
+	EndLine     int
+	StartColumn int
+	EndColumn   int
+	Match       string
+	Secret      string
+	File        string
+	SymlinkFile string
+	Commit      string
+	Entropy     float64
+	Author      string
+	Email       string
+	Date        string
+	Message     string
+	Tags        []string
+	RuleID      string
+	Fingerprint string
+}
+
+// gitleaksReport returns findings as a gitleaks JSON report, as gitleaks --redact writes it.
+func gitleaksReport(source string, findings []Finding) ([]byte, error) {
+	out := make([]gitleaksFinding, 0, len(findings))
+	for _, f := range findings {
+		out = append(out, gitleaksFinding{
+			Description: fmt.Sprintf("%s: %s", f.Key, f.Detection.Reason),
+			StartLine:   f.Line,
+			EndLine:     f.Line,
+			StartColumn: 1,
+			EndColumn:   1,
+			Match:       f.Key + "=REDACTED",
+			Secret:      "REDACTED",
+			File:        source,
+			Entropy:     math.Round(f.Entropy*1000) / 1000,
+			Tags:        []string{string(SecureStringType)},
+			RuleID:      f.Detection.Rule,
+			Fingerprint: fmt.Sprintf("%s:%s:%d", source, f.Detection.Rule, f.Line),
+		})
+	}
+	return marshalReport(out)
+}
+
+// SARIF 2.1.0 types, limited to what classify writes.
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name    string      `json:"name"`
+		Version string      `json:"version,omitempty"`
+		Rules   []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID               string       `json:"id"`
+		ShortDescription sarifMessage `json:"shortDescription"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations"`
+	}
+	sarifLocation struct {
+		PhysicalLocation struct {
+			ArtifactLocation struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+			Region struct {
+				StartLine int `json:"startLine"`
+			} `json:"region"`
+		} `json:"physicalLocation"`
+	}
+)
+
+// sarifReport returns findings as a SARIF log with one run, whose rules are the detection rules that matched.
+func sarifReport(source string, findings []Finding, version string) ([]byte, error) {
+	run := sarifRun{
+		Tool:    sarifTool{Driver: sarifDriver{Name: "salter-aws", Version: version, Rules: []sarifRule{}}},
+		Results: []sarifResult{},
+	}
+	seen := make(map[string]bool)
+	for _, f := range findings {
+		if !seen[f.Detection.Rule] {
+			seen[f.Detection.Rule] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+				ID:               f.Detection.Rule,
+				ShortDescription: sarifMessage{Text: ruleDescription(f.Detection.Rule)},
+			})
+		}
+		result := sarifResult{
+			RuleID:  f.Detection.Rule,
+			Level:   "warning",
+			Message: sarifMessage{Text: fmt.Sprintf("%s is a secret: %s", f.Key, f.Detection.Reason)},
+		}
+		var location sarifLocation
+		location.PhysicalLocation.ArtifactLocation.URI = strings.ReplaceAll(source, `\`, "/")
+		location.PhysicalLocation.Region.StartLine = f.Line
+		result.Locations = []sarifLocation{location}
+		run.Results = append(run.Results, result)
+	}
+	return marshalReport(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+// ruleDescription describes the detection rule id, for SARIF rules.
+func ruleDescription(id string) string {
+	switch id {
+	case declaredRule:
+		return "type set to SecureString in the source"
+	case mappedRule:
+		return "type set to SecureString by the type mapping file"
+	}
+	if rule, ok := detectionRule(id); ok && rule.Description != "" {
+		return rule.Description
+	}
+	return "matches rule " + id
+}
+
+// marshalReport returns v as indented JSON with a trailing newline.
+func marshalReport(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("failed to marshal report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
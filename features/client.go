@@ -0,0 +1,54 @@
+package features
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// SSMClient is the subset of the SSM API used by the features package.
+// *ssm.Client satisfies it, and wrappers such as NewDryRunClient decorate it.
+type SSMClient interface {
+	GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+	GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error)
+	DescribeParameters(ctx context.Context, params *ssm.DescribeParametersInput, optFns ...func(*ssm.Options)) (*ssm.DescribeParametersOutput, error)
+	PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error)
+	DeleteParameter(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error)
+	ListTagsForResource(ctx context.Context, params *ssm.ListTagsForResourceInput, optFns ...func(*ssm.Options)) (*ssm.ListTagsForResourceOutput, error)
+	AddTagsToResource(ctx context.Context, params *ssm.AddTagsToResourceInput, optFns ...func(*ssm.Options)) (*ssm.AddTagsToResourceOutput, error)
+}
+
+// decorator is embedded by the clients that wrap an SSMClient, such as NewDryRunClient, in place of the
+// SSMClient itself. It adds Options, returning those of the *ssm.Client at the bottom of the chain, so
+// errors and dry-run output can still name the region however the client is decorated.
+type decorator struct {
+	SSMClient
+}
+
+func (d decorator) Options() ssm.Options {
+	if regional, ok := d.SSMClient.(interface{ Options() ssm.Options }); ok {
+		return regional.Options()
+	}
+	return ssm.Options{}
+}
+
+// writeRegions returns the regions the wrapped client writes to when it fans out (see MultiRegionClient).
+func (d decorator) writeRegions() []string {
+	return writeRegions(d.SSMClient)
+}
+
+// writeRegions returns the regions client writes every parameter to, or nil when it writes to one region.
+func writeRegions(client SSMClient) []string {
+	if fanOut, ok := client.(interface{ writeRegions() []string }); ok {
+		return fanOut.writeRegions()
+	}
+	return nil
+}
+
+// clientRegion returns the region client sends its calls to, or "" when it is not known (e.g. in tests).
+func clientRegion(client SSMClient) string {
+	if regional, ok := client.(interface{ Options() ssm.Options }); ok {
+		return regional.Options().Region
+	}
+	return ""
+}
@@ -0,0 +1,104 @@
+package features
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardTool is an external command that writes or reads the system clipboard.
+type clipboardTool struct {
+	copy  []string // Reads the new contents from stdin.
+	paste []string // Prints the contents to stdout.
+}
+
+// clipboardTools returns the clipboard commands to try for the current OS, in order of preference.
+func clipboardTools() []clipboardTool {
+	switch runtime.GOOS {
+	case "darwin":
+		return []clipboardTool{{copy: []string{"pbcopy"}, paste: []string{"pbpaste"}}}
+	case "windows":
+		return []clipboardTool{{
+			copy:  []string{"clip"},
+			paste: []string{"powershell", "-NoProfile", "-Command", "Get-Clipboard -Raw"},
+		}}
+	default:
+		return []clipboardTool{
+			{copy: []string{"wl-copy"}, paste: []string{"wl-paste", "--no-newline"}},
+			{copy: []string{"xclip", "-selection", "clipboard"}, paste: []string{"xclip", "-selection", "clipboard", "-o"}},
+			{copy: []string{"xsel", "--clipboard", "--input"}, paste: []string{"xsel", "--clipboard", "--output"}},
+		}
+	}
+}
+
+// findClipboardTool returns the first clipboard tool installed on this machine.
+func findClipboardTool() (clipboardTool, error) {
+	var names []string
+	for _, tool := range clipboardTools() {
+		if _, err := exec.LookPath(tool.copy[0]); err == nil {
+			return tool, nil
+		}
+		names = append(names, tool.copy[0])
+	}
+	return clipboardTool{}, fmt.Errorf("no clipboard tool found (install one of: %s)", strings.Join(names, ", "))
+}
+
+// CopyToClipboard places value on the system clipboard.
+func CopyToClipboard(value string) error {
+	tool, err := findClipboardTool()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(tool.copy[0], tool.copy[1:]...)
+	cmd.Stdin = strings.NewReader(value)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", tool.copy[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// ClipboardToken returns a token by which ClearClipboardIfUnchanged recognises value: a random key and the
+// HMAC-SHA256 of value under it, so the token cannot be checked against precomputed hashes of short
+// secrets. It still identifies value to whoever holds it, so pass it through stdin, never as an argument.
+func ClipboardToken(value string) (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate clipboard key: %w", err)
+	}
+	return hex.EncodeToString(key) + ":" + hmacHex(key, value), nil
+}
+
+// ClearClipboardIfUnchanged empties the clipboard only if it still holds the value token was made for by
+// ClipboardToken, so anything the user copied in the meantime is left alone.
+func ClearClipboardIfUnchanged(token string) error {
+	keyHex, want, ok := strings.Cut(strings.TrimSpace(token), ":")
+	key, err := hex.DecodeString(keyHex)
+	if !ok || err != nil || len(key) == 0 {
+		return fmt.Errorf("invalid clipboard token")
+	}
+	tool, err := findClipboardTool()
+	if err != nil {
+		return err
+	}
+	out, err := exec.Command(tool.paste[0], tool.paste[1:]...).Output()
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", tool.paste[0], err)
+	}
+	current := string(out)
+	if !hmac.Equal([]byte(hmacHex(key, current)), []byte(want)) &&
+		!hmac.Equal([]byte(hmacHex(key, strings.TrimRight(current, "\r\n"))), []byte(want)) {
+		return nil
+	}
+	return CopyToClipboard("")
+}
+
+// ValueHash returns the hex SHA-256 of value, used to recognise a value without keeping it around.
+func ValueHash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,80 @@
+package features
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeClipboard installs wl-copy and wl-paste scripts keeping the clipboard in a file, first on PATH, and
+// returns that file.
+func fakeClipboard(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("fake clipboard tools are shell scripts for the Linux tool list")
+	}
+	dir := t.TempDir()
+	contents := filepath.Join(dir, "clipboard")
+	scripts := map[string]string{
+		"wl-copy":  "#!/bin/sh\ncat > '" + contents + "'\n",
+		"wl-paste": "#!/bin/sh\ncat '" + contents + "'\n",
+	}
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return contents
+}
+
+func TestClearClipboardIfUnchanged(t *testing.T) {
+	contents := fakeClipboard(t)
+	read := func() string {
+		t.Helper()
+		data, err := os.ReadFile(contents)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	token, err := ClipboardToken("hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(token, ValueHash("hunter2")) {
+		t.Errorf("token %s contains the unsalted hash of the value", token)
+	}
+	if other, _ := ClipboardToken("hunter2"); other == token {
+		t.Errorf("two tokens of one value are equal: %s", token)
+	}
+
+	// The user copied something else in the meantime: left alone.
+	if err := CopyToClipboard("something else"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ClearClipboardIfUnchanged(token); err != nil {
+		t.Fatalf("ClearClipboardIfUnchanged: %v", err)
+	}
+	if got := read(); got != "something else" {
+		t.Errorf("clipboard after a mismatch = %q; want it unchanged", got)
+	}
+
+	// Still the value, with the newline some paste tools add: cleared.
+	if err := CopyToClipboard("hunter2\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ClearClipboardIfUnchanged(token + "\n"); err != nil {
+		t.Fatalf("ClearClipboardIfUnchanged: %v", err)
+	}
+	if got := read(); got != "" {
+		t.Errorf("clipboard after a match = %q; want it cleared", got)
+	}
+
+	if err := ClearClipboardIfUnchanged(ValueHash("hunter2")); err == nil {
+		t.Error("ClearClipboardIfUnchanged accepted a bare hash; want an invalid token error")
+	}
+}
@@ -0,0 +1,87 @@
+package features
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// pathTarget is the parameter a key of a source or template is written to, with its value and type.
+type pathTarget struct {
+	key       string
+	path      string
+	value     string
+	paramType ParameterType
+}
+
+// checkPathCollisions fails with a validation error listing every path more than one key maps to and, when
+// client is not nil, every path that already exists in SSM with another type than its key would write.
+// With shared, keys may map to the same path when they write the same value and type, as secrets of a task
+// definition reading one parameter do.
+func checkPathCollisions(client SSMClient, targets []pathTarget, shared bool) error {
+	byPath := make(map[string][]pathTarget)
+	var paths []string
+	for _, t := range targets {
+		if byPath[t.path] == nil {
+			paths = append(paths, t.path)
+		}
+		byPath[t.path] = append(byPath[t.path], t)
+	}
+	sort.Strings(paths)
+	var collisions []string
+	for _, path := range paths {
+		group := byPath[path]
+		if len(group) < 2 {
+			continue
+		}
+		same := true
+		keys := make([]string, len(group))
+		for i, t := range group {
+			keys[i] = t.key
+			same = same && t.value == group[0].value && t.paramType == group[0].paramType
+		}
+		if !shared || !same {
+			collisions = append(collisions, fmt.Sprintf("%s from keys %s", path, strings.Join(keys, ", ")))
+		}
+	}
+	if client != nil {
+		existing, err := existingTypes(client, paths)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			t := byPath[path][0]
+			if current, ok := existing[path]; ok && current != t.paramType {
+				collisions = append(collisions, fmt.Sprintf("%s exists as %s, key %s would write %s", path, current, t.key, t.paramType))
+			}
+		}
+	}
+	if len(collisions) > 0 {
+		return validationErrorf("parameter path collisions: %s", strings.Join(collisions, "; "))
+	}
+	return nil
+}
+
+// existingTypes returns the types of the parameters among names that exist, reading only metadata.
+func existingTypes(client SSMClient, names []string) (map[string]ParameterType, error) {
+	const batch = 50 // Most values a DescribeParameters filter takes.
+	found := make(map[string]ParameterType)
+	for start := 0; start < len(names); start += batch {
+		end := min(start+batch, len(names))
+		metadata, err := describeParameters(client, types.ParameterStringFilter{
+			Key:    aws.String("Name"),
+			Option: aws.String("Equals"),
+			Values: names[start:end],
+		})
+		if err != nil {
+			return nil, err
+		}
+		for name, meta := range metadata {
+			found[name] = ParameterType(meta.Type)
+		}
+	}
+	return found, nil
+}
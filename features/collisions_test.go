@@ -0,0 +1,78 @@
+package features
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestGenerateTaskDefCollisions(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "app.env")
+	if err := os.WriteFile(source, []byte("db.host=a\nDB_HOST=b\nPORT=8080\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "task.json")
+	opts := GenerateOptions{Prefix: "/app/", Names: &NameRules{Case: "upper", Replace: "."}}
+	err := GenerateTaskDef(source, out, opts)
+	if !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), "/app/DB_HOST from keys db.host, DB_HOST") {
+		t.Fatalf("GenerateTaskDef with colliding keys: %v; want the collision listed", err)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Error("task definition written despite the collision")
+	}
+
+	if err := os.WriteFile(source, []byte("db.host=a\nPORT=8080\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeSSM()
+	fake.set("/app/PORT", "80", types.ParameterTypeSecureString)
+	fake.set("/app/DB_HOST", "a", types.ParameterTypeString)
+	opts.Existing = fake
+	err = GenerateTaskDef(source, out, opts)
+	if !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), "/app/PORT exists as SecureString, key PORT would write String") {
+		t.Fatalf("GenerateTaskDef with an existing type: %v; want the type collision listed", err)
+	}
+	fake.set("/app/PORT", "80", types.ParameterTypeString)
+	if err := GenerateTaskDef(source, out, opts); err != nil {
+		t.Errorf("GenerateTaskDef with matching existing types: %v", err)
+	}
+}
+
+func TestPutFromTemplateCollisions(t *testing.T) {
+	template := filepath.Join(t.TempDir(), "task.json")
+	write := func(secrets string) {
+		t.Helper()
+		if err := os.WriteFile(template, []byte(`{"containerDefinitions": [{"secrets": [`+secrets+`]}]}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fake := newFakeSSM()
+	fake.set("/app/TOKEN", "old", types.ParameterTypeSecureString)
+
+	// Two secrets reading one parameter are fine when they agree.
+	write(`{"name": "DB_URL", "valueFrom": "/app/DB_URL", "value": "pg"},
+  {"name": "DATABASE_URL", "valueFrom": "/app/DB_URL", "value": "pg"}`)
+	if err := PutParametersFromTemplate(fake, template, TemplateOptions{}, PutOptions{AssumeYes: true}); err != nil {
+		t.Fatalf("put-from-template with a shared parameter: %v", err)
+	}
+
+	for name, secrets := range map[string]string{
+		"different values": `{"name": "A", "valueFrom": "/app/X", "value": "1"}, {"name": "B", "valueFrom": "/app/X", "value": "2"}`,
+		"existing type":    `{"name": "TOKEN", "valueFrom": "/app/TOKEN", "value": "new"}, {"name": "OTHER", "valueFrom": "/app/OTHER", "value": "o"}`,
+	} {
+		write(secrets)
+		puts := fake.puts
+		err := PutParametersFromTemplate(fake, template, TemplateOptions{}, PutOptions{AssumeYes: true})
+		if !errors.Is(err, ErrValidation) {
+			t.Errorf("%s: %v; want a validation error", name, err)
+		}
+		if fake.puts != puts {
+			t.Errorf("%s: %d parameters put despite the collision", name, fake.puts-puts)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package features
+
+import (
+	"os"
+	"runtime"
+)
+
+// ANSI colors of status lines: green for puts and additions, yellow for skips and changes, red for failures
+// and removals.
+const (
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorRed    = "\x1b[31m"
+	colorReset  = "\x1b[0m"
+)
+
+// colorOutput is set by SetColor. It is off by default, so library callers and tests get plain text.
+var colorOutput bool
+
+// SetColor turns colored status and diff output on or off.
+func SetColor(enabled bool) {
+	colorOutput = enabled
+}
+
+// ColorDefault reports whether stdout looks like a terminal that shows colors: it must be a character
+// device, NO_COLOR (https://no-color.org) must be unset and TERM must not be "dumb". On Windows, where the
+// classic console prints the escape codes literally, only Windows Terminal and terminals that set TERM qualify.
+func ColorDefault() bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	if runtime.GOOS == "windows" && os.Getenv("WT_SESSION") == "" && os.Getenv("TERM") == "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// paint wraps s in color when colored output is on.
+func paint(color, s string) string {
+	if !colorOutput || s == "" {
+		return s
+	}
+	return color + s + colorReset
+}
+
+func green(s string) string  { return paint(colorGreen, s) }
+func yellow(s string) string { return paint(colorYellow, s) }
+func red(s string) string    { return paint(colorRed, s) }
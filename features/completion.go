@@ -0,0 +1,164 @@
+package features
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// CompletionFlag describes one command-line flag for generated completion scripts.
+type CompletionFlag struct {
+	Name    string   // Flag name without the leading dash.
+	Usage   string   // One-line description (used by fish).
+	Choices []string // Fixed values to complete after the flag, if any.
+	Dynamic bool     // Complete the value with parameter names from SSM.
+}
+
+// completionNamesTTL is how long parameter names fetched for completion are cached.
+const completionNamesTTL = 5 * time.Minute
+
+// GenerateCompletion returns a completion script for shell ("bash", "zsh" or "fish") for binary.
+// Dynamic flags call back into "<binary> -action complete-names -prefix <word>".
+func GenerateCompletion(shell, binary string, actions []string, flags []CompletionFlag) (string, error) {
+	var opts []string
+	for _, f := range flags {
+		opts = append(opts, "-"+f.Name)
+	}
+	fn := "_" + strings.ReplaceAll(binary, "-", "_") + "_completion"
+	dynamic := fmt.Sprintf("%s -action complete-names -prefix", binary)
+	var b strings.Builder
+	switch shell {
+	case "bash":
+		fmt.Fprintf(&b, "%s() {\n", fn)
+		b.WriteString("    local cur prev opts actions\n")
+		b.WriteString("    COMPREPLY=()\n")
+		b.WriteString("    cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+		b.WriteString("    prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+		fmt.Fprintf(&b, "    opts=\"%s\"\n", strings.Join(opts, " "))
+		fmt.Fprintf(&b, "    actions=\"%s\"\n\n", strings.Join(actions, " "))
+		b.WriteString("    case \"$prev\" in\n")
+		b.WriteString("        -action)\n            COMPREPLY=( $(compgen -W \"$actions\" -- \"$cur\") )\n            return 0\n            ;;\n")
+		for _, f := range flags {
+			switch {
+			case f.Dynamic:
+				fmt.Fprintf(&b, "        -%s)\n            COMPREPLY=( $(compgen -W \"$(%s \"$cur\" 2>/dev/null)\" -- \"$cur\") )\n            return 0\n            ;;\n", f.Name, dynamic)
+			case len(f.Choices) > 0:
+				fmt.Fprintf(&b, "        -%s)\n            COMPREPLY=( $(compgen -W \"%s\" -- \"$cur\") )\n            return 0\n            ;;\n", f.Name, strings.Join(f.Choices, " "))
+			}
+		}
+		b.WriteString("    esac\n\n")
+		b.WriteString("    COMPREPLY=( $(compgen -W \"$opts\" -- \"$cur\") )\n")
+		b.WriteString("}\n")
+		fmt.Fprintf(&b, "complete -F %s %s\n", fn, binary)
+	case "zsh":
+		fmt.Fprintf(&b, "#compdef %s\n\n", binary)
+		fmt.Fprintf(&b, "%s() {\n", fn)
+		b.WriteString("    case \"${words[CURRENT-1]}\" in\n")
+		fmt.Fprintf(&b, "        -action) compadd -- %s; return ;;\n", strings.Join(actions, " "))
+		for _, f := range flags {
+			switch {
+			case f.Dynamic:
+				fmt.Fprintf(&b, "        -%s) compadd -- ${(f)\"$(%s \"${words[CURRENT]}\" 2>/dev/null)\"}; return ;;\n", f.Name, dynamic)
+			case len(f.Choices) > 0:
+				fmt.Fprintf(&b, "        -%s) compadd -- %s; return ;;\n", f.Name, strings.Join(f.Choices, " "))
+			}
+		}
+		b.WriteString("    esac\n")
+		fmt.Fprintf(&b, "    compadd -- %s\n", strings.Join(opts, " "))
+		b.WriteString("}\n\n")
+		fmt.Fprintf(&b, "compdef %s %s\n", fn, binary)
+	case "fish":
+		fmt.Fprintf(&b, "complete -c %s -f\n", binary)
+		fmt.Fprintf(&b, "complete -c %s -o action -xa %q -d %q\n", binary, strings.Join(actions, " "), "Action to perform")
+		for _, f := range flags {
+			if f.Name == "action" {
+				continue
+			}
+			switch {
+			case f.Dynamic:
+				fmt.Fprintf(&b, "complete -c %s -o %s -xa \"(%s (commandline -ct) 2>/dev/null)\" -d %q\n", binary, f.Name, dynamic, f.Usage)
+			case len(f.Choices) > 0:
+				fmt.Fprintf(&b, "complete -c %s -o %s -xa %q -d %q\n", binary, f.Name, strings.Join(f.Choices, " "), f.Usage)
+			default:
+				fmt.Fprintf(&b, "complete -c %s -o %s -d %q\n", binary, f.Name, f.Usage)
+			}
+		}
+	default:
+		return "", fmt.Errorf("unsupported shell %q, use 'bash', 'zsh' or 'fish'", shell)
+	}
+	return b.String(), nil
+}
+
+// CompleteParameterNames returns parameter names starting with word, for shell completion.
+// Names are listed with DescribeParameters under root (the configured prefix, or the parent path of word when
+// word lies outside it) and cached on disk for a few minutes so repeated tab presses do not call AWS.
+func CompleteParameterNames(client SSMClient, region, root, word string) ([]string, error) {
+	if strings.HasPrefix(word, "/") && !strings.HasPrefix(word, root) {
+		root = word[:strings.LastIndex(word, "/")+1]
+	}
+	names, err := cachedParameterNames(client, region, root)
+	if err != nil {
+		return nil, err
+	}
+	var matches []string
+	for _, name := range names {
+		if strings.HasPrefix(name, word) {
+			matches = append(matches, name)
+		}
+	}
+	return matches, nil
+}
+
+// cachedParameterNames returns the names under root, from the completion cache when it is fresh.
+func cachedParameterNames(client SSMClient, region, root string) ([]string, error) {
+	cacheFile := ""
+	if dir, err := os.UserCacheDir(); err == nil {
+		sum := sha256.Sum256([]byte(region + "\x00" + root))
+		cacheFile = filepath.Join(dir, "salter-aws", "names-"+hex.EncodeToString(sum[:8])+".txt")
+		if info, err := os.Stat(cacheFile); err == nil && time.Since(info.ModTime()) < completionNamesTTL {
+			if data, err := os.ReadFile(cacheFile); err == nil {
+				return strings.Fields(string(data)), nil
+			}
+		}
+	}
+
+	var names []string
+	input := &ssm.DescribeParametersInput{
+		ParameterFilters: []types.ParameterStringFilter{{
+			Key:    aws.String("Name"),
+			Option: aws.String("BeginsWith"),
+			Values: []string{root},
+		}},
+		MaxResults: aws.Int32(50),
+	}
+	for {
+		result, err := client.DescribeParameters(context.TODO(), input)
+		if err != nil {
+			return nil, err
+		}
+		for _, meta := range result.Parameters {
+			names = append(names, aws.ToString(meta.Name))
+		}
+		if result.NextToken == nil {
+			break
+		}
+		input.NextToken = result.NextToken
+	}
+
+	if cacheFile != "" {
+		// The cache is best effort; completion still works without it.
+		if err := os.MkdirAll(filepath.Dir(cacheFile), 0700); err == nil {
+			_ = os.WriteFile(cacheFile, []byte(strings.Join(names, "\n")), 0600)
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,28 @@
+package features
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateCompletion(t *testing.T) {
+	flags := []CompletionFlag{
+		{Name: "name", Usage: "Parameter name", Dynamic: true},
+		{Name: "type", Usage: "Parameter type", Choices: []string{"string", "securestring"}},
+	}
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		script, err := GenerateCompletion(shell, "salter-aws", []string{"get", "put"}, flags)
+		if err != nil {
+			t.Fatalf("GenerateCompletion(%s): %v", shell, err)
+		}
+		if !strings.Contains(script, "salter-aws -action complete-names -prefix") {
+			t.Errorf("%s script lacks dynamic -name completion", shell)
+		}
+		if !strings.Contains(script, "securestring") {
+			t.Errorf("%s script lacks -type choices", shell)
+		}
+	}
+	if _, err := GenerateCompletion("powershell", "salter-aws", nil, flags); err == nil {
+		t.Error("GenerateCompletion(powershell) succeeded; want error")
+	}
+}
@@ -0,0 +1,75 @@
+package features
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ConfigSchemaVersion is the schemaVersion of the config.json files this build writes. A file without one is
+// version 0; older files are migrated when loaded, newer ones are refused.
+const ConfigSchemaVersion = 1
+
+// configMigrations[v] upgrades config.json of schema version v, as a JSON object, to version v+1.
+var configMigrations = []func(fields map[string]json.RawMessage) error{
+	// 0 -> 1: schemaVersion is introduced; no field changed.
+	func(map[string]json.RawMessage) error { return nil },
+}
+
+// migrateConfig upgrades config.json data to ConfigSchemaVersion. It returns the data unchanged when it is
+// current, and the version it had.
+func migrateConfig(data []byte) ([]byte, int, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse config.json: %w", err)
+	}
+	version := 0
+	if raw, ok := fields["schemaVersion"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil || version < 0 {
+			return nil, 0, validationErrorf("config.json: schemaVersion %s is not a version number", raw)
+		}
+	}
+	if version > ConfigSchemaVersion {
+		return nil, version, validationErrorf("config.json has schemaVersion %d, newer than the %d this salter-aws supports; update it (-action self-update)", version, ConfigSchemaVersion)
+	}
+	if version == ConfigSchemaVersion {
+		return data, version, nil
+	}
+	for v := version; v < ConfigSchemaVersion; v++ {
+		if err := configMigrations[v](fields); err != nil {
+			return nil, version, fmt.Errorf("failed to migrate config.json from schema version %d: %w", v, err)
+		}
+	}
+	fields["schemaVersion"] = json.RawMessage(fmt.Sprint(ConfigSchemaVersion))
+	migrated, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return nil, version, err
+	}
+	return append(migrated, '\n'), version, nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it over path, so a reader, or
+// another invocation writing at the same time, sees either the old or the new file and never part of one.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // After a successful rename there is nothing left to remove.
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,75 @@
+package features
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// inTempDir runs the rest of the test in a new temporary directory, where LoadConfig finds config.json.
+func inTempDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+func TestLoadConfigMigration(t *testing.T) {
+	dir := inTempDir(t)
+	old := `{"parameterPrefix": "/prod/app/", "region": "eu-west-1", "custom": {"kept": true}}`
+	if err := os.WriteFile("config.json", []byte(old), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if config.SchemaVersion != ConfigSchemaVersion || config.ParameterPrefix != "/prod/app/" || config.Region != "eu-west-1" {
+		t.Errorf("config = %+v; want the old settings at schema version %d", config, ConfigSchemaVersion)
+	}
+	data, err := os.ReadFile("config.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written map[string]any
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatalf("migrated config.json: %v", err)
+	}
+	if written["schemaVersion"] != float64(ConfigSchemaVersion) || written["custom"] == nil {
+		t.Errorf("migrated config.json = %s; want schemaVersion added and other fields kept", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("%d files in the directory; want only config.json, no temporary files", len(entries))
+	}
+
+	if err := os.WriteFile("config.json", []byte(`{"schemaVersion": 99}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(); !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), "self-update") {
+		t.Errorf("LoadConfig of a newer schema: %v; want a validation error suggesting self-update", err)
+	}
+}
+
+func TestLoadConfigCreatesDefault(t *testing.T) {
+	inTempDir(t)
+	if _, err := LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(".", "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"schemaVersion": 1`) {
+		t.Errorf("default config.json = %s; want the schema version", data)
+	}
+}
@@ -0,0 +1,140 @@
+package features
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// AssumeYesEnv is the environment variable that answers yes to every confirmation prompt when set to a true value (for CI).
+const AssumeYesEnv = "PARAM_STORE_YES"
+
+// ErrOverwriteDeclined is returned when a put is skipped because the parameter exists and overwriting was
+// not confirmed, or because the put was create-only.
+var ErrOverwriteDeclined = errors.New("overwrite declined")
+
+// ErrVersionMismatch is returned when the live parameter version differs from PutOptions.ExpectVersion.
+var ErrVersionMismatch = errors.New("parameter version mismatch")
+
+// PutOptions controls how PutParameter treats parameters that already exist.
+type PutOptions struct {
+	AssumeYes   bool // Overwrite existing parameters without prompting.
+	NoOverwrite bool // Create-only: put with Overwrite=false and skip parameters that already exist.
+	// ExpectVersion, when non-zero, refuses the put unless the live parameter is at exactly this version.
+	ExpectVersion int64
+	// KeyID is the KMS key ID, ARN or alias for SecureString puts; empty uses the account's aws/ssm key.
+	KeyID string
+	// Tier is the parameter tier to put with; empty uses the account's default tier and is checked as Standard.
+	Tier types.ParameterTier
+	// Description is stored with the parameter when set.
+	Description string
+	// Tags are added to the parameter after the put, replacing the values of keys it already has; its other
+	// tags are kept.
+	Tags map[string]string
+	// AllowedPattern is stored with the parameter when set; template puts use it to record a typed value (see
+	// ValueNumber).
+	AllowedPattern string
+	// DataType is stored with the parameter when set: text, or aws:ec2:image for an AMI ID (see validateDataType).
+	DataType string
+	// KeepType puts an existing parameter with its current type and, for a SecureString, its KMS key; the type
+	// given to PutParameter and KeyID are only used to create a parameter.
+	KeepType bool
+	// RecreateOnTypeChange deletes and recreates a parameter that exists with another type instead of failing.
+	RecreateOnTypeChange bool
+	// KMS, when set, is used by put-from-template to check access to customer managed keys before any put.
+	KMS KMSAPI
+	// Interactive has put-from-template put only the secrets chosen from a checklist (see selectTemplatePuts),
+	// which replaces the overwrite confirmations.
+	Interactive bool
+	// ChangedSince, a git revision, has put-from-template and import put only the keys whose lines in the
+	// source file changed since it (see gitChanges); removed keys are not deleted.
+	ChangedSince string
+}
+
+// stdinReader is shared so buffered input is not lost between prompts.
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// Confirm asks a yes/no question on stderr and reads the answer from stdin.
+// Anything other than "y" or "yes", including end of input, counts as no. When standard input was the source
+// file (see StdinSource) there is nothing left to answer with, so the answer is no with a hint to use -y.
+func Confirm(prompt string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N]: ", prompt)
+	if stdinSource.read {
+		fmt.Fprintln(os.Stderr, "no (standard input was read as -s; use -y to confirm)")
+		return false
+	}
+	answer, err := stdinReader.ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Fprintln(os.Stderr)
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// AssumeYesFromEnv reports whether AssumeYesEnv is set to a true value.
+func AssumeYesFromEnv() bool {
+	yes, _ := strconv.ParseBool(os.Getenv(AssumeYesEnv))
+	return yes
+}
+
+// currentParameter returns name as it exists, or nil when it does not, with its value decrypted if decrypt.
+func currentParameter(client SSMClient, name string, decrypt bool) (*types.Parameter, error) {
+	current, err := client.GetParameter(context.TODO(), &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(decrypt),
+	})
+	var notFound *types.ParameterNotFound
+	if errors.As(err, &notFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, wrapClientError(client, "GetParameter", name, err)
+	}
+	return current.Parameter, nil
+}
+
+// confirmOverwrite asks whether current, the existing parameter with the decrypted value (nil when there is
+// none), may be overwritten. It returns ErrOverwriteDeclined when the put should be skipped.
+func confirmOverwrite(client SSMClient, current *types.Parameter, value string, paramType ParameterType, opts PutOptions) error {
+	if current == nil || opts.AssumeYes || opts.NoOverwrite {
+		return nil // Create-only puts are guarded by the API itself.
+	}
+	if aws.ToString(current.Value) == value && string(current.Type) == string(paramType) {
+		return nil // Identical, nothing to confirm.
+	}
+	if isDryRun(client) {
+		return nil // The dry-run client shows the diff instead.
+	}
+	if !Confirm(fmt.Sprintf("Parameter %s already exists with a different value. Overwrite?", aws.ToString(current.Name))) {
+		return ErrOverwriteDeclined
+	}
+	return nil
+}
+
+// checkExpectedVersion fetches name and fails with ErrVersionMismatch if its version is not expected.
+// SSM has no conditional put, so this narrows rather than closes the window for lost updates.
+func checkExpectedVersion(client SSMClient, name string, expected int64) error {
+	current, err := client.GetParameter(context.TODO(), &ssm.GetParameterInput{
+		Name: aws.String(name),
+	})
+	var notFound *types.ParameterNotFound
+	if errors.As(err, &notFound) {
+		return fmt.Errorf("%w: %s does not exist, expected version %d", ErrVersionMismatch, name, expected)
+	}
+	if err != nil {
+		return wrapClientError(client, "GetParameter", name, err)
+	}
+	if current.Parameter.Version != expected {
+		return fmt.Errorf("%w: %s is at version %d, expected %d", ErrVersionMismatch, name, current.Parameter.Version, expected)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package features
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Contract lists the keys a service expects under its prefix, read by check-contract from YAML (or JSON):
+//
+//	required:
+//	  DB_URL: securestring
+//	  PORT: string
+//	optional:
+//	  SENTRY_DSN: string
+//	  FEATURES: any
+//
+// Keys are relative to the prefix and may hold "/" for nested parameters. A type of "any" (or empty)
+// accepts every type.
+type Contract struct {
+	Required map[string]string `yaml:"required"`
+	Optional map[string]string `yaml:"optional"`
+}
+
+// contractKey is one key of a contract with its parsed type, empty for any.
+type contractKey struct {
+	key       string
+	paramType ParameterType
+	required  bool
+}
+
+// readContract reads and checks a contract file; unknown fields and types are errors.
+func readContract(filename string) ([]contractKey, error) {
+	data, err := readSource(filename)
+	if err != nil {
+		return nil, err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var contract Contract
+	if err := dec.Decode(&contract); err != nil {
+		return nil, validationErrorf("failed to parse %s: %v", filename, err)
+	}
+	var keys []contractKey
+	for _, group := range []struct {
+		types    map[string]string
+		required bool
+	}{{contract.Required, true}, {contract.Optional, false}} {
+		for key, typeName := range group.types {
+			if !group.required {
+				if _, ok := contract.Required[key]; ok {
+					return nil, validationErrorf("%s is both required and optional in %s", key, filename)
+				}
+			}
+			if key == "" || strings.HasPrefix(key, "/") {
+				return nil, validationErrorf("key %q of %s must be relative to the prefix", key, filename)
+			}
+			ck := contractKey{key: key, required: group.required}
+			if typeName != "" && !strings.EqualFold(typeName, "any") {
+				if ck.paramType, err = ParseParameterType(typeName); err != nil {
+					return nil, fmt.Errorf("%s in %s: %w", key, filename, err)
+				}
+			}
+			keys = append(keys, ck)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, validationErrorf("%s declares no keys", filename)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].key < keys[j].key })
+	return keys, nil
+}
+
+// contractViolations returns one line per way the parameters under prefix break the contract: a missing
+// required key, or a key of another type. Keys under prefix the contract does not declare are returned
+// separately. Only metadata is read, so no values are decrypted.
+func contractViolations(client SSMClient, prefix string, keys []contractKey) ([]string, []string, error) {
+	prefix = strings.TrimSuffix(prefix, "/") + "/"
+	metadata, err := describeParameters(client, types.ParameterStringFilter{
+		Key:    aws.String("Path"),
+		Option: aws.String("Recursive"),
+		Values: []string{strings.TrimSuffix(prefix, "/")},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	var violations []string
+	declared := make(map[string]bool)
+	for _, ck := range keys {
+		name := prefix + ck.key
+		declared[name] = true
+		meta, ok := metadata[name]
+		switch {
+		case !ok && ck.required:
+			violations = append(violations, fmt.Sprintf("%s: required, missing", name))
+		case ok && ck.paramType != "" && ParameterType(meta.Type) != ck.paramType:
+			violations = append(violations, fmt.Sprintf("%s: type %s, contract requires %s", name, meta.Type, ck.paramType))
+		}
+	}
+	var undeclared []string
+	for name := range metadata {
+		if !declared[name] {
+			undeclared = append(undeclared, name)
+		}
+	}
+	sort.Strings(undeclared)
+	return violations, undeclared, nil
+}
+
+// CheckContract checks the parameters under prefix against the contract in filename, for CI before a
+// deploy: every required key must exist and every declared key that exists must have the declared type.
+// Keys the contract does not declare are listed, and break it only with strict. It returns a ContractError
+// (exit code ExitCheckFailed) when the contract is broken.
+func CheckContract(client SSMClient, prefix, filename string, strict bool) error {
+	if !strings.HasPrefix(prefix, "/") {
+		return validationErrorf("-prefix must be a path starting with /, got %q", prefix)
+	}
+	keys, err := readContract(filename)
+	if err != nil {
+		return err
+	}
+	violations, undeclared, err := contractViolations(client, prefix, keys)
+	if err != nil {
+		return err
+	}
+	for _, v := range violations {
+		fmt.Printf("%s %s\n", red("!"), v)
+	}
+	for _, name := range undeclared {
+		fmt.Printf("%s %s: not in the contract\n", yellow("?"), name)
+	}
+	broken := len(violations)
+	if strict {
+		broken += len(undeclared)
+	}
+	if broken > 0 {
+		return &ContractError{Violations: broken, Total: len(keys)}
+	}
+	fmt.Printf("%s %s satisfies %s (%d keys)\n", green("OK:"), prefix, filename, len(keys))
+	return nil
+}
@@ -0,0 +1,82 @@
+package features
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestCheckContract(t *testing.T) {
+	contract := filepath.Join(t.TempDir(), "app-contract.yaml")
+	data := `required:
+  DB_URL: securestring
+  PORT: string
+  db/REPLICA: any
+optional:
+  SENTRY_DSN: string
+  HOSTS: stringlist
+`
+	if err := os.WriteFile(contract, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := readContract(contract)
+	if err != nil {
+		t.Fatalf("readContract: %v", err)
+	}
+	fake := newFakeSSM()
+	fake.set("/prod/app/DB_URL", "postgres://db", types.ParameterTypeString)
+	fake.set("/prod/app/db/REPLICA", "postgres://replica", types.ParameterTypeSecureString)
+	fake.set("/prod/app/HOSTS", "a,b", types.ParameterTypeStringList)
+	fake.set("/prod/app/LEGACY", "x", types.ParameterTypeString)
+	fake.set("/prod/other/PORT", "80", types.ParameterTypeString)
+
+	violations, undeclared, err := contractViolations(fake, "/prod/app", keys)
+	if err != nil {
+		t.Fatalf("contractViolations: %v", err)
+	}
+	wantViolations := []string{
+		"/prod/app/DB_URL: type String, contract requires SecureString",
+		"/prod/app/PORT: required, missing",
+	}
+	if !reflect.DeepEqual(violations, wantViolations) {
+		t.Errorf("violations = %q; want %q", violations, wantViolations)
+	}
+	if !reflect.DeepEqual(undeclared, []string{"/prod/app/LEGACY"}) {
+		t.Errorf("undeclared = %q; want /prod/app/LEGACY", undeclared)
+	}
+	var cerr *ContractError
+	if err := CheckContract(fake, "/prod/app/", contract, false); !errors.As(err, &cerr) || cerr.Violations != 2 {
+		t.Errorf("CheckContract: %v; want 2 violations", err)
+	}
+
+	fake.set("/prod/app/DB_URL", "postgres://db", types.ParameterTypeSecureString)
+	fake.set("/prod/app/PORT", "8080", types.ParameterTypeString)
+	if err := CheckContract(fake, "/prod/app/", contract, false); err != nil {
+		t.Errorf("CheckContract after fixing: %v", err)
+	}
+	if err := CheckContract(fake, "/prod/app/", contract, true); !errors.As(err, &cerr) || cerr.Violations != 1 {
+		t.Errorf("CheckContract with strict: %v; want the undeclared key as a violation", err)
+	}
+}
+
+func TestReadContractErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown field": "requierd:\n  A: string\n",
+		"no keys":       "required: {}\n",
+		"bad type":      "required:\n  A: secret\n",
+		"both":          "required:\n  A: string\noptional:\n  A: string\n",
+		"absolute key":  "required:\n  /prod/app/A: string\n",
+	} {
+		file := filepath.Join(t.TempDir(), "contract.yaml")
+		if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := readContract(file); !errors.Is(err, ErrValidation) {
+			t.Errorf("%s: %v; want a validation error", name, err)
+		}
+	}
+}
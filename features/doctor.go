@@ -0,0 +1,365 @@
+package features
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// DoctorOptions selects what doctor checks.
+type DoctorOptions struct {
+	ConfigFile string // Tool configuration to validate, normally config.json.
+	Region     string // Region to check; empty uses the configured region.
+	Prefix     string // Parameter prefix permissions are checked on; empty uses parameterPrefix.
+	OutputDir  string // Directory outputs will be written to.
+}
+
+// Doctor check outcomes.
+const (
+	doctorOK      = "ok"
+	doctorWarn    = "warn"
+	doctorFail    = "FAIL"
+	doctorSkipped = "skip"
+)
+
+// doctorResult is the outcome of one doctor check, with a hint on how to fix anything not ok.
+type doctorResult struct {
+	check, status, detail, hint string
+}
+
+// clockSkewWarn and clockSkewFail bound the difference between the local clock and AWS. Signed
+// requests are rejected once it exceeds 15 minutes.
+const (
+	clockSkewWarn = time.Minute
+	clockSkewFail = 15 * time.Minute
+)
+
+var (
+	regionPattern  = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+$`)
+	roleARNPattern = regexp.MustCompile(`^arn:aws[a-z-]*:iam::\d{12}:role/.+`)
+)
+
+type iamAPI interface {
+	SimulatePrincipalPolicy(ctx context.Context, params *iam.SimulatePrincipalPolicyInput, optFns ...func(*iam.Options)) (*iam.SimulatePrincipalPolicyOutput, error)
+}
+
+// RunDoctor checks the configuration file, credentials, region reachability, clock skew, the IAM permissions
+// the tool needs on the prefix and write access to the output directory, printing one line per check with
+// a hint for anything that is wrong. It fails if any check failed; warnings do not fail it.
+func RunDoctor(opts DoctorOptions) error {
+	var results []doctorResult
+	report := func(r doctorResult) {
+		results = append(results, r)
+		fmt.Printf("[%-4s] %s: %s\n", r.status, r.check, r.detail)
+		if r.hint != "" {
+			fmt.Printf("       hint: %s\n", r.hint)
+		}
+	}
+
+	cfgResult, toolConfig := checkConfigFile(opts.ConfigFile)
+	report(cfgResult)
+	if opts.Region == "" {
+		opts.Region = toolConfig.Region
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = toolConfig.ParameterPrefix
+	}
+	report(checkOutputDir(opts.OutputDir))
+	if !regionPattern.MatchString(opts.Region) {
+		report(doctorResult{"region", doctorFail, fmt.Sprintf("%q is not an AWS region name", opts.Region), "pass -region, e.g. -region ap-southeast-3, or fix \"region\" in config.json"})
+		return doctorSummary(results)
+	}
+	report(checkEndpoint(&http.Client{Timeout: 5 * time.Second}, opts.Region, time.Now))
+
+	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(opts.Region))
+	if err == nil {
+		_, err = cfg.Credentials.Retrieve(context.TODO())
+	}
+	if err != nil {
+		report(doctorResult{"credentials", doctorFail, err.Error(), "configure credentials: run 'aws configure' or 'aws sso login', or set AWS_PROFILE or AWS_ACCESS_KEY_ID"})
+		report(doctorResult{"permissions", doctorSkipped, "no credentials", ""})
+		return doctorSummary(results)
+	}
+	identity, err := sts.NewFromConfig(cfg).GetCallerIdentity(context.TODO(), &sts.GetCallerIdentityInput{})
+	if err != nil {
+		report(doctorResult{"credentials", doctorFail, err.Error(), "the credentials were found but rejected; refresh them ('aws sso login') or check the system clock"})
+		report(doctorResult{"permissions", doctorSkipped, "no identity", ""})
+		return doctorSummary(results)
+	}
+	callerARN := aws.ToString(identity.Arn)
+	report(doctorResult{"credentials", doctorOK, callerARN, ""})
+
+	d := diagnostics{iam: iam.NewFromConfig(cfg), ssm: ssm.NewFromConfig(cfg)}
+	report(d.checkPermissions(callerARN, aws.ToString(identity.Account), opts.Region, opts.Prefix))
+	return doctorSummary(results)
+}
+
+// doctorSummary prints the number of problems and returns an error if any check failed.
+func doctorSummary(results []doctorResult) error {
+	failed, warned := 0, 0
+	for _, r := range results {
+		switch r.status {
+		case doctorFail:
+			failed++
+		case doctorWarn:
+			warned++
+		}
+	}
+	fmt.Printf("%d checks: %d failed, %d warnings\n", len(results), failed, warned)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(results))
+	}
+	return nil
+}
+
+// checkConfigFile validates the tool configuration and returns it, or the defaults when it is missing or broken.
+// Unlike LoadConfig it never creates the file.
+func checkConfigFile(path string) (doctorResult, *Config) {
+	cfg := defaultConfig()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return doctorResult{"config", doctorWarn, path + " not found; defaults are used", "run any other action once to create it, then set parameterPrefix and region"}, cfg
+	}
+	if err != nil {
+		return doctorResult{"config", doctorFail, err.Error(), "make " + path + " readable"}, cfg
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	strict := *cfg // Missing fields keep their defaults, as in LoadConfig.
+	if err := dec.Decode(&strict); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line := 1 + bytes.Count(data[:syntaxErr.Offset], []byte("\n"))
+			return doctorResult{"config", doctorFail, fmt.Sprintf("%s line %d: %v", path, line, err), "fix the JSON syntax"}, cfg
+		}
+		if json.Unmarshal(data, cfg) == nil && strings.HasPrefix(err.Error(), "json: unknown field") {
+			return doctorResult{"config", doctorWarn, fmt.Sprintf("%s: %v", path, err), "remove or correct the field; it is ignored"}, cfg
+		}
+		return doctorResult{"config", doctorFail, fmt.Sprintf("%s: %v", path, err), "check the field types against the README"}, defaultConfig()
+	}
+	*cfg = strict
+
+	var problems []string
+	if cfg.SchemaVersion > ConfigSchemaVersion {
+		problems = append(problems, fmt.Sprintf("schemaVersion %d is newer than the %d this build supports; run -action self-update", cfg.SchemaVersion, ConfigSchemaVersion))
+	}
+	if !strings.HasPrefix(cfg.ParameterPrefix, "/") || !strings.HasSuffix(cfg.ParameterPrefix, "/") {
+		problems = append(problems, fmt.Sprintf("parameterPrefix %q should start and end with /", cfg.ParameterPrefix))
+	}
+	if cfg.Region != "" && !regionPattern.MatchString(cfg.Region) {
+		problems = append(problems, fmt.Sprintf("region %q is not an AWS region name", cfg.Region))
+	}
+	names := make(map[string]bool)
+	for i, account := range cfg.Accounts {
+		switch {
+		case account.Name == "":
+			problems = append(problems, fmt.Sprintf("accounts[%d] has no name", i))
+		case names[account.Name]:
+			problems = append(problems, fmt.Sprintf("account %q is listed twice", account.Name))
+		}
+		names[account.Name] = true
+		if account.RoleARN != "" && !roleARNPattern.MatchString(account.RoleARN) {
+			problems = append(problems, fmt.Sprintf("account %q roleArn %q is not an IAM role ARN", account.Name, account.RoleARN))
+		}
+	}
+	for _, p := range cfg.ProtectedPrefixes {
+		if !strings.HasPrefix(p, "/") || p == "/" {
+			problems = append(problems, fmt.Sprintf("protectedPrefixes entry %q should be a path below /", p))
+		}
+	}
+	if err := cfg.NameRules.Validate(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := newDetectionRules(cfg.Detection); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if cfg.Hooks != nil {
+		if err := CheckHooks(*cfg.Hooks); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if len(problems) > 0 {
+		return doctorResult{"config", doctorFail, path + ": " + strings.Join(problems, "; "), "edit " + path}, cfg
+	}
+	return doctorResult{"config", doctorOK, fmt.Sprintf("%s (prefix %s, region %s)", path, cfg.ParameterPrefix, cfg.Region), ""}, cfg
+}
+
+// checkOutputDir checks that a file can be created in dir.
+func checkOutputDir(dir string) doctorResult {
+	if dir == "" {
+		dir = "."
+	}
+	f, err := os.CreateTemp(dir, ".salter-aws-doctor-*")
+	if err != nil {
+		hint := "choose a writable directory with -o, or fix its permissions"
+		if os.IsNotExist(err) {
+			hint = "create it with 'mkdir -p " + dir + "'"
+		}
+		return doctorResult{"output", doctorFail, err.Error(), hint}
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return doctorResult{"output", doctorOK, "can write to " + dir, ""}
+}
+
+// checkEndpoint checks that the SSM endpoint of region answers and compares the local clock with its Date header.
+func checkEndpoint(client *http.Client, region string, now func() time.Time) doctorResult {
+	url := "https://ssm." + region + ".amazonaws.com/"
+	start := now()
+	resp, err := client.Head(url)
+	if err != nil {
+		return doctorResult{"region", doctorFail, fmt.Sprintf("%s unreachable: %v", url, err), "check -region, network access and HTTPS_PROXY"}
+	}
+	resp.Body.Close()
+	latency := now().Sub(start).Round(time.Millisecond)
+	detail := fmt.Sprintf("%s reachable in %s", url, latency)
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return doctorResult{"region", doctorWarn, detail + "; clock skew unknown (no Date header)", ""}
+	}
+	skew := start.Sub(date).Round(time.Second)
+	if skew < 0 {
+		skew = -skew
+	}
+	switch {
+	case skew > clockSkewFail:
+		return doctorResult{"region", doctorFail, fmt.Sprintf("%s; local clock is off by %s", detail, skew), "AWS rejects signed requests beyond 15 minutes of skew; enable NTP (e.g. 'timedatectl set-ntp true')"}
+	case skew > clockSkewWarn:
+		return doctorResult{"region", doctorWarn, fmt.Sprintf("%s; local clock is off by %s", detail, skew), "enable NTP time synchronisation"}
+	}
+	return doctorResult{"region", doctorOK, detail + ", clock in sync", ""}
+}
+
+// diagnostics holds the clients permission checks use.
+type diagnostics struct {
+	iam iamAPI
+	ssm SSMClient
+}
+
+// doctorActions are the SSM actions checked on the prefix, and whether the tool is usable read-only without them.
+var doctorActions = []struct {
+	action   string
+	optional bool
+}{
+	{"ssm:GetParameter", false},
+	{"ssm:GetParametersByPath", false},
+	{"ssm:PutParameter", true},
+}
+
+// checkPermissions simulates the caller's IAM policies for the actions the tool uses on prefix. When the caller
+// may not run iam:SimulatePrincipalPolicy, a read of the prefix is tried instead.
+func (d diagnostics) checkPermissions(callerARN, accountID, region, prefix string) doctorResult {
+	principal := policySourceARN(callerARN)
+	resource := ParameterARN(region, accountID, prefix) + "*"
+	if principal == "" {
+		return doctorResult{"permissions", doctorWarn, callerARN + " cannot be simulated (root or federated user)", "check the prefix with a read such as '-action list -prefix " + prefix + "'"}
+	}
+	var actions []string
+	for _, a := range doctorActions {
+		actions = append(actions, a.action)
+	}
+	result, err := d.iam.SimulatePrincipalPolicy(context.TODO(), &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: aws.String(principal),
+		ActionNames:     actions,
+		ResourceArns:    []string{resource},
+	})
+	if err != nil {
+		return d.probeRead(prefix, fmt.Sprintf("iam:SimulatePrincipalPolicy unavailable (%v)", classifyOrRaw(err)))
+	}
+	decisions := make(map[string]iamtypes.PolicyEvaluationDecisionType)
+	for _, r := range result.EvaluationResults {
+		decisions[aws.ToString(r.EvalActionName)] = r.EvalDecision
+	}
+	var denied, deniedOptional []string
+	for _, a := range doctorActions {
+		if decision := decisions[a.action]; decision != iamtypes.PolicyEvaluationDecisionTypeAllowed {
+			entry := fmt.Sprintf("%s (%s)", a.action, decision)
+			if a.optional {
+				deniedOptional = append(deniedOptional, entry)
+			} else {
+				denied = append(denied, entry)
+			}
+		}
+	}
+	hint := func(entries []string) string {
+		return "allow " + strings.Join(entries, ", ") + " on " + resource + " in the policy of " + principal
+	}
+	switch {
+	case len(denied) > 0:
+		return doctorResult{"permissions", doctorFail, "denied " + strings.Join(append(denied, deniedOptional...), ", "), hint(append(denied, deniedOptional...))}
+	case len(deniedOptional) > 0:
+		return doctorResult{"permissions", doctorWarn, "read-only: denied " + strings.Join(deniedOptional, ", "), hint(deniedOptional) + " to use put actions"}
+	}
+	return doctorResult{"permissions", doctorOK, "read and write allowed on " + resource + " (KMS key policies are checked by put-from-template)", ""}
+}
+
+// probeRead checks read access to prefix with one GetParametersByPath call.
+func (d diagnostics) probeRead(prefix, why string) doctorResult {
+	_, err := d.ssm.GetParametersByPath(context.TODO(), &ssm.GetParametersByPathInput{
+		Path:       aws.String(prefix),
+		Recursive:  aws.Bool(true),
+		MaxResults: aws.Int32(1),
+	})
+	if err != nil {
+		err = wrapAWSError("GetParametersByPath", prefix, err)
+		hint := "check -region and the prefix"
+		if errors.Is(err, ErrAccessDenied) {
+			hint = "allow ssm:GetParametersByPath on the prefix"
+		}
+		return doctorResult{"permissions", doctorFail, why + "; reading " + prefix + " failed: " + err.Error(), hint}
+	}
+	return doctorResult{"permissions", doctorWarn, why + "; reading " + prefix + " works, writes not checked", "allow iam:SimulatePrincipalPolicy on yourself for a full check"}
+}
+
+// classifyOrRaw returns the error kind of an AWS error, or the error itself when it is not recognised.
+func classifyOrRaw(err error) error {
+	if kind := classifyAWSError(err); kind != nil {
+		return kind
+	}
+	return err
+}
+
+// policySourceARN returns the IAM principal SimulatePrincipalPolicy accepts for an STS caller ARN:
+// the user itself, or the role of an assumed-role session (roles with a path are not recoverable from the
+// session ARN and will fail the simulation). It returns "" for the root user and federated users.
+func policySourceARN(callerARN string) string {
+	parts := strings.SplitN(callerARN, ":", 6)
+	if len(parts) != 6 {
+		return ""
+	}
+	partition, accountID, resource := parts[1], parts[4], parts[5]
+	switch {
+	case parts[2] == "iam" && strings.HasPrefix(resource, "user/"):
+		return callerARN
+	case parts[2] == "sts" && strings.HasPrefix(resource, "assumed-role/"):
+		role := strings.SplitN(strings.TrimPrefix(resource, "assumed-role/"), "/", 2)[0]
+		return fmt.Sprintf("arn:%s:iam::%s:role/%s", partition, accountID, role)
+	}
+	return ""
+}
+
+// DoctorOutputDir returns the directory an -o path or base name is written into.
+func DoctorOutputDir(output string) string {
+	if output == "" {
+		return "."
+	}
+	if info, err := os.Stat(output); err == nil && info.IsDir() {
+		return output
+	}
+	return filepath.Dir(output)
+}
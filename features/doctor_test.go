@@ -0,0 +1,154 @@
+package features
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/smithy-go"
+)
+
+func TestCheckConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name, content, status, detail string
+	}{
+		{"ok", `{"parameterPrefix": "/prod/app/", "region": "eu-west-1"}`, doctorOK, "prefix /prod/app/, region eu-west-1"},
+		{"defaults kept", `{"parameterPrefix": "/prod/app/"}`, doctorOK, "region ap-southeast-3"},
+		{"syntax", "{\n  \"region\": \"eu-west-1\",\n}", doctorFail, "line 3"},
+		{"unknown field", `{"parameterPrefix": "/a/", "regoin": "eu-west-1"}`, doctorWarn, `unknown field "regoin"`},
+		{"bad values", `{"parameterPrefix": "prod", "region": "europe", "accounts": [{"name": "a", "roleArn": "role/x"}, {"name": "a"}]}`,
+			doctorFail, `parameterPrefix "prod" should start and end with /; region "europe" is not an AWS region name; ` +
+				`account "a" roleArn "role/x" is not an IAM role ARN; account "a" is listed twice`},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name+".json")
+		if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, _ := checkConfigFile(path)
+		if got.status != tt.status || !strings.Contains(got.detail, tt.detail) {
+			t.Errorf("%s: %s %q; want %s containing %q", tt.name, got.status, got.detail, tt.status, tt.detail)
+		}
+	}
+	if got, cfg := checkConfigFile(filepath.Join(dir, "missing.json")); got.status != doctorWarn || cfg.Region != "ap-southeast-3" {
+		t.Errorf("missing file: %s with region %q; want a warning and the defaults", got.status, cfg.Region)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("checkConfigFile created the missing file")
+	}
+}
+
+func TestCheckOutputDir(t *testing.T) {
+	dir := t.TempDir()
+	if got := checkOutputDir(dir); got.status != doctorOK {
+		t.Errorf("writable dir: %s %s", got.status, got.detail)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("probe file left behind: %v", entries)
+	}
+	if got := checkOutputDir(filepath.Join(dir, "nope")); got.status != doctorFail || !strings.Contains(got.hint, "mkdir -p") {
+		t.Errorf("missing dir: %s, hint %q; want FAIL suggesting mkdir", got.status, got.hint)
+	}
+}
+
+func TestCheckEndpointClockSkew(t *testing.T) {
+	serverTime := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", serverTime.Format(http.TimeFormat))
+	}))
+	defer srv.Close()
+	client := srv.Client()
+	client.Transport.(*http.Transport).DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+	}
+	client.Transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify = true
+
+	tests := []struct {
+		offset time.Duration
+		status string
+	}{
+		{10 * time.Second, doctorOK},
+		{-3 * time.Minute, doctorWarn},
+		{20 * time.Minute, doctorFail},
+	}
+	for _, tt := range tests {
+		now := func() time.Time { return serverTime.Add(tt.offset) }
+		if got := checkEndpoint(client, "eu-west-1", now); got.status != tt.status {
+			t.Errorf("skew %s: %s %q; want %s", tt.offset, got.status, got.detail, tt.status)
+		}
+	}
+}
+
+func TestPolicySourceARN(t *testing.T) {
+	tests := []struct{ caller, want string }{
+		{"arn:aws:iam::123456789012:user/alice", "arn:aws:iam::123456789012:user/alice"},
+		{"arn:aws:sts::123456789012:assumed-role/deployer/session-1", "arn:aws:iam::123456789012:role/deployer"},
+		{"arn:aws:iam::123456789012:root", ""},
+		{"arn:aws:sts::123456789012:federated-user/bob", ""},
+	}
+	for _, tt := range tests {
+		if got := policySourceARN(tt.caller); got != tt.want {
+			t.Errorf("policySourceARN(%q) = %q; want %q", tt.caller, got, tt.want)
+		}
+	}
+}
+
+// fakeIAM allows the listed actions, or fails every simulation with err.
+type fakeIAM struct {
+	allowed map[string]bool
+	err     error
+}
+
+func (f *fakeIAM) SimulatePrincipalPolicy(ctx context.Context, params *iam.SimulatePrincipalPolicyInput, optFns ...func(*iam.Options)) (*iam.SimulatePrincipalPolicyOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	out := &iam.SimulatePrincipalPolicyOutput{}
+	for _, action := range params.ActionNames {
+		decision := iamtypes.PolicyEvaluationDecisionTypeImplicitDeny
+		if f.allowed[action] {
+			decision = iamtypes.PolicyEvaluationDecisionTypeAllowed
+		}
+		out.EvaluationResults = append(out.EvaluationResults, iamtypes.EvaluationResult{EvalActionName: aws.String(action), EvalDecision: decision})
+	}
+	return out, nil
+}
+
+func TestCheckPermissions(t *testing.T) {
+	const caller = "arn:aws:sts::123456789012:assumed-role/deployer/s"
+	fake := newFakeSSM()
+	fake.set("/prod/app/A", "a", types.ParameterTypeString)
+	tests := []struct {
+		name   string
+		iam    *fakeIAM
+		status string
+		detail string
+	}{
+		{"all", &fakeIAM{allowed: map[string]bool{"ssm:GetParameter": true, "ssm:GetParametersByPath": true, "ssm:PutParameter": true}}, doctorOK, "read and write allowed"},
+		{"read-only", &fakeIAM{allowed: map[string]bool{"ssm:GetParameter": true, "ssm:GetParametersByPath": true}}, doctorWarn, "read-only: denied ssm:PutParameter"},
+		{"no read", &fakeIAM{allowed: map[string]bool{"ssm:PutParameter": true}}, doctorFail, "denied ssm:GetParameter (implicitDeny), ssm:GetParametersByPath"},
+		{"no simulate", &fakeIAM{err: &smithy.GenericAPIError{Code: "AccessDenied", Message: "no"}}, doctorWarn, "reading /prod/app/ works"},
+	}
+	for _, tt := range tests {
+		d := diagnostics{iam: tt.iam, ssm: fake}
+		got := d.checkPermissions(caller, "123456789012", "eu-west-1", "/prod/app/")
+		if got.status != tt.status || !strings.Contains(got.detail, tt.detail) {
+			t.Errorf("%s: %s %q; want %s containing %q", tt.name, got.status, got.detail, tt.status, tt.detail)
+		}
+		if got.status != doctorOK && got.hint == "" {
+			t.Errorf("%s: no hint", tt.name)
+		}
+	}
+}
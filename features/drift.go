@@ -0,0 +1,50 @@
+package features
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CheckDrift compares the live value and type of every parameter a put-from-template template writes
+// with the template and prints a diff with values masked as fingerprints. It returns a DriftError when
+// anything differs or is missing, so scheduled CI runs can page on exit code ExitDrift.
+func CheckDrift(client SSMClient, filename string, tmplOpts TemplateOptions) error {
+	puts, err := loadTemplatePuts(filename, tmplOpts)
+	if err != nil {
+		return err
+	}
+	ordered, cycle := orderTemplatePuts(puts)
+	if cycle != nil {
+		return cycleError(cycle)
+	}
+	if err := resolveTemplateValues(client, ordered, tmplOpts.Resources); err != nil {
+		return err
+	}
+
+	drifted := 0
+	for _, p := range puts { // Template order reads best.
+		value, paramType, err := GetParameter(client, p.paramName)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			fmt.Printf("%s %s: missing (template: %s %s)\n", green("+"), p.paramName, p.paramType, maskValue(p.secret.Value))
+			drifted++
+		case err != nil:
+			return err
+		case value != p.secret.Value || paramType != p.paramType:
+			fmt.Printf("%s %s:", yellow("~"), p.paramName)
+			if paramType != p.paramType {
+				fmt.Printf(" type %s -> %s", paramType, p.paramType)
+			}
+			if value != p.secret.Value {
+				fmt.Printf(" value %s -> %s", maskValue(value), maskValue(p.secret.Value))
+			}
+			fmt.Println(" (live -> template)")
+			drifted++
+		}
+	}
+	if drifted > 0 {
+		return &DriftError{Drifted: drifted, Total: len(puts)}
+	}
+	fmt.Printf("No drift: %d parameters match %s\n", len(puts), filename)
+	return nil
+}
@@ -0,0 +1,49 @@
+package features
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestCheckDrift(t *testing.T) {
+	template := filepath.Join(t.TempDir(), "template.json")
+	data := `{"containerDefinitions": [{"secrets": [
+  {"name": "DB_URL", "valueFrom": "/app/DB_URL", "value": "postgres://db", "type": "SecureString"},
+  {"name": "HOST", "valueFrom": "/app/HOST", "value": "app.internal"}
+]}]}`
+	if err := os.WriteFile(template, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeSSM()
+	fake.set("/app/DB_URL", "postgres://db", types.ParameterTypeSecureString)
+	fake.set("/app/HOST", "app.internal", types.ParameterTypeString)
+	if err := CheckDrift(fake, template, TemplateOptions{}); err != nil {
+		t.Fatalf("check-drift without drift: %v", err)
+	}
+
+	tests := []struct {
+		desc  string
+		apply func(*fakeSSM)
+	}{
+		{"value", func(f *fakeSSM) { f.set("/app/HOST", "hand-edited", types.ParameterTypeString) }},
+		{"type", func(f *fakeSSM) { f.set("/app/HOST", "app.internal", types.ParameterTypeSecureString) }},
+		{"missing", func(f *fakeSSM) { delete(f.params, "/app/DB_URL") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			fake := newFakeSSM()
+			fake.set("/app/DB_URL", "postgres://db", types.ParameterTypeSecureString)
+			fake.set("/app/HOST", "app.internal", types.ParameterTypeString)
+			tt.apply(fake)
+			if err := CheckDrift(fake, template, TemplateOptions{}); ExitCode(err) != ExitDrift {
+				t.Errorf("check-drift error = %v; want exit code %d", err, ExitDrift)
+			}
+			if fake.puts != 0 {
+				t.Errorf("check-drift wrote %d parameters", fake.puts)
+			}
+		})
+	}
+}
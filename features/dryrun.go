@@ -0,0 +1,116 @@
+package features
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// dryRunClient passes read calls through to the wrapped client and prints mutating calls instead of executing them.
+type dryRunClient struct {
+	decorator
+}
+
+// NewDryRunClient wraps client so that mutating API calls are only printed, together with a diff against the live value.
+func NewDryRunClient(client SSMClient) SSMClient {
+	return &dryRunClient{decorator: decorator{client}}
+}
+
+// isDryRun reports whether client was created by NewDryRunClient, or only wraps dry-run clients.
+func isDryRun(client SSMClient) bool {
+	d, ok := client.(interface{ dryRun() bool })
+	return ok && d.dryRun()
+}
+
+func (c *dryRunClient) dryRun() bool {
+	return true
+}
+
+// PutParameter prints the PutParameter call that would be made and how it differs from the current value.
+func (c *dryRunClient) PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+	name := aws.ToString(params.Name)
+	// Build the report first so concurrent dry runs (e.g. multi-region) do not interleave lines.
+	var out strings.Builder
+	label := "[dry-run]"
+	if region := clientRegion(c.SSMClient); region != "" {
+		label = fmt.Sprintf("[dry-run %s]", region)
+	}
+	defer func() { fmt.Print(out.String()) }()
+	fmt.Fprintf(&out, "%s PutParameter Name=%s Type=%s Overwrite=%t", label, name, params.Type, aws.ToBool(params.Overwrite))
+	if params.KeyId != nil {
+		fmt.Fprintf(&out, " KeyId=%s", aws.ToString(params.KeyId))
+	}
+	if params.DataType != nil {
+		fmt.Fprintf(&out, " DataType=%s", aws.ToString(params.DataType))
+	}
+	if params.Tier != "" {
+		fmt.Fprintf(&out, " Tier=%s", params.Tier)
+	}
+	fmt.Fprintln(&out)
+
+	// Fetch the live value to show what would change.
+	current, err := c.SSMClient.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           params.Name,
+		WithDecryption: aws.Bool(true),
+	})
+	var notFound *types.ParameterNotFound
+	switch {
+	case errors.As(err, &notFound):
+		fmt.Fprintf(&out, "  %s\n", green("+ "+displayValue(aws.ToString(params.Value), params.Type)))
+	case err != nil:
+		fmt.Fprintf(&out, "  ? could not read current value: %v\n", err)
+	case !aws.ToBool(params.Overwrite):
+		fmt.Fprintln(&out, "  = already exists, would be skipped")
+		return nil, &types.ParameterAlreadyExists{Message: aws.String("dry run: parameter already exists")}
+	default:
+		oldValue := aws.ToString(current.Parameter.Value)
+		newValue := aws.ToString(params.Value)
+		if current.Parameter.Type != params.Type {
+			fmt.Fprintf(&out, "  %s\n", yellow(fmt.Sprintf("~ type %s -> %s", current.Parameter.Type, params.Type)))
+		}
+		if oldValue == newValue {
+			fmt.Fprintln(&out, "  = value unchanged")
+		} else {
+			fmt.Fprintf(&out, "  %s\n", red("- "+displayValue(oldValue, current.Parameter.Type)))
+			fmt.Fprintf(&out, "  %s\n", green("+ "+displayValue(newValue, params.Type)))
+		}
+	}
+	return &ssm.PutParameterOutput{}, nil
+}
+
+// DeleteParameter prints the DeleteParameter call that would be made.
+func (c *dryRunClient) DeleteParameter(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error) {
+	label := "[dry-run]"
+	if region := clientRegion(c.SSMClient); region != "" {
+		label = fmt.Sprintf("[dry-run %s]", region)
+	}
+	fmt.Printf("%s DeleteParameter Name=%s\n", label, aws.ToString(params.Name))
+	return &ssm.DeleteParameterOutput{}, nil
+}
+
+// AddTagsToResource prints the AddTagsToResource call that would be made.
+func (c *dryRunClient) AddTagsToResource(ctx context.Context, params *ssm.AddTagsToResourceInput, optFns ...func(*ssm.Options)) (*ssm.AddTagsToResourceOutput, error) {
+	label := "[dry-run]"
+	if region := clientRegion(c.SSMClient); region != "" {
+		label = fmt.Sprintf("[dry-run %s]", region)
+	}
+	tags := make([]string, len(params.Tags))
+	for i, tag := range params.Tags {
+		tags[i] = aws.ToString(tag.Key) + "=" + aws.ToString(tag.Value)
+	}
+	fmt.Printf("%s AddTagsToResource ResourceId=%s Tags=%s\n", label, aws.ToString(params.ResourceId), strings.Join(tags, ","))
+	return &ssm.AddTagsToResourceOutput{}, nil
+}
+
+// displayValue masks SecureString values so dry-run output can be shared safely.
+func displayValue(value string, paramType types.ParameterType) string {
+	if paramType == types.ParameterTypeSecureString {
+		return fmt.Sprintf("<secure, %d chars>", len(value))
+	}
+	return value
+}
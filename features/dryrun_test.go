@@ -0,0 +1,41 @@
+package features
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestDryRunClientDoesNotWrite(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/app/EXISTING", "old", types.ParameterTypeString)
+	client := NewDryRunClient(fake)
+
+	if err := PutParameter(client, "/app/EXISTING", "new", StringType, PutOptions{}); err != nil {
+		t.Fatalf("PutParameter on existing parameter: %v", err)
+	}
+	if err := PutParameter(client, "/app/NEW", "value", SecureStringType, PutOptions{}); err != nil {
+		t.Fatalf("PutParameter on new parameter: %v", err)
+	}
+
+	if fake.puts != 0 {
+		t.Errorf("dry run performed %d puts; want 0", fake.puts)
+	}
+	if got := *fake.params["/app/EXISTING"].Value; got != "old" {
+		t.Errorf("existing value = %q; want %q", got, "old")
+	}
+	if _, ok := fake.params["/app/NEW"]; ok {
+		t.Error("dry run created /app/NEW")
+	}
+}
+
+func TestDryRunClientCreateOnlySkipsExisting(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/app/EXISTING", "old", types.ParameterTypeString)
+
+	err := PutParameter(NewDryRunClient(fake), "/app/EXISTING", "new", StringType, PutOptions{NoOverwrite: true})
+	if !errors.Is(err, ErrOverwriteDeclined) {
+		t.Errorf("create-only dry run error = %v; want ErrOverwriteDeclined", err)
+	}
+}
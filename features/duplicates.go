@@ -0,0 +1,60 @@
+package features
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DuplicateKey is a key defined more than once in a .env file or template, with the lines defining it.
+type DuplicateKey struct {
+	Key   string
+	Lines []int
+}
+
+// findDuplicates returns the keys of lines that occur more than once, sorted by key.
+func findDuplicates(lines map[string][]int) []DuplicateKey {
+	var dups []DuplicateKey
+	for key, at := range lines {
+		if len(at) > 1 {
+			dups = append(dups, DuplicateKey{Key: key, Lines: at})
+		}
+	}
+	sort.Slice(dups, func(i, j int) bool { return dups[i].Key < dups[j].Key })
+	return dups
+}
+
+// reportDuplicates warns about every duplicate key in source, where the last definition wins.
+// With strict it returns a validation error instead.
+func reportDuplicates(source string, dups []DuplicateKey, strict bool) error {
+	if len(dups) == 0 {
+		return nil
+	}
+	var descriptions []string
+	for _, dup := range dups {
+		lines := make([]string, len(dup.Lines))
+		for i, line := range dup.Lines {
+			lines[i] = fmt.Sprint(line)
+		}
+		descriptions = append(descriptions, fmt.Sprintf("%s (lines %s)", dup.Key, strings.Join(lines, ", ")))
+	}
+	if strict {
+		return validationErrorf("%s: duplicate keys: %s", source, strings.Join(descriptions, "; "))
+	}
+	for _, description := range descriptions {
+		Infof("Warning: %s: duplicate key %s; the last one is used\n", source, description)
+	}
+	return nil
+}
+
+// templateSecretLines returns the lines of every secret name in the first container of a task definition,
+// keyed by name. Malformed JSON yields whatever was found before the error; callers report parse errors.
+func templateSecretLines(data []byte) map[string][]int {
+	lines := make(map[string][]int)
+	for _, span := range scanSecrets(data) {
+		if span.name != "" {
+			lines[span.name] = append(lines[span.name], span.line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,97 @@
+package features
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestGenerateDuplicateKeys(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, "app.env")
+	outFile := filepath.Join(dir, "task.json")
+	if err := os.WriteFile(envFile, []byte("DB_HOST=a\nPORT=1\n\nDB_HOST=b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := GenerateTaskDef(envFile, outFile, GenerateOptions{Format: InputDotenv, Prefix: "/app/", Strict: true})
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("strict generate error = %v; want ErrValidation", err)
+	}
+	if _, err := os.Stat(outFile); err == nil {
+		t.Errorf("strict generate wrote %s despite duplicates", outFile)
+	}
+
+	if err := GenerateTaskDef(envFile, outFile, GenerateOptions{Format: InputDotenv, Prefix: "/app/"}); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	data, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var taskDef TaskDefinition
+	if err := json.Unmarshal(data, &taskDef); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range taskDef.ContainerDefinitions[0].Secrets {
+		got = append(got, s.Name+"="+s.Value)
+	}
+	if want := []string{"DB_HOST=b", "PORT=1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("secrets = %q; want %q", got, want)
+	}
+}
+
+func TestTemplateSecretLines(t *testing.T) {
+	data := []byte(`{
+  "family": "app",
+  "containerDefinitions": [
+    {
+      "name": "web",
+      "secrets": [
+        {"name": "DB_HOST", "valueFrom": "/app/DB_HOST", "value": "a"},
+        {"name": "PORT", "valueFrom": "/app/PORT", "value": "1"},
+        {
+          "name": "DB_HOST",
+          "valueFrom": "/app/DB_HOST",
+          "value": "b"
+        }
+      ]
+    },
+    {"name": "sidecar", "secrets": [{"name": "PORT"}]}
+  ]
+}`)
+	want := map[string][]int{"DB_HOST": {7, 10}, "PORT": {8}}
+	if got := templateSecretLines(data); !reflect.DeepEqual(got, want) {
+		t.Errorf("templateSecretLines = %v; want %v", got, want)
+	}
+
+	dir := t.TempDir()
+	template := filepath.Join(dir, "task.json")
+	if err := os.WriteFile(template, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeSSM()
+	err := PutParametersFromTemplate(fake, template, TemplateOptions{Strict: true}, PutOptions{AssumeYes: true})
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("strict put-from-template error = %v; want ErrValidation", err)
+	}
+	if fake.puts != 0 {
+		t.Errorf("strict put-from-template wrote %d parameters", fake.puts)
+	}
+
+	if err := PutParametersFromTemplate(fake, template, TemplateOptions{}, PutOptions{AssumeYes: true}); err != nil {
+		t.Fatalf("put-from-template: %v", err)
+	}
+	if got := *fake.params["/app/DB_HOST"].Value; got != "b" {
+		t.Errorf("/app/DB_HOST = %q; want %q", got, "b")
+	}
+	if fake.params["/app/DB_HOST"].Type != types.ParameterTypeString {
+		t.Errorf("/app/DB_HOST type = %s; want String", fake.params["/app/DB_HOST"].Type)
+	}
+}
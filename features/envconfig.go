@@ -0,0 +1,86 @@
+package features
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ConfigEnvPrefix starts the environment variables that override config.json: PARAM_STORE_ and the field
+// name in upper snake case, e.g. PARAM_STORE_PARAMETER_PREFIX for parameterPrefix.
+const ConfigEnvPrefix = "PARAM_STORE_"
+
+// ConfigEnvName returns the environment variable that overrides the config.json field named field.
+func ConfigEnvName(field string) string {
+	var b strings.Builder
+	b.WriteString(ConfigEnvPrefix)
+	for i, c := range field {
+		if unicode.IsUpper(c) && i > 0 {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(c))
+	}
+	return b.String()
+}
+
+// configFieldName returns the config.json name of f, or "" for schemaVersion, which describes the file
+// rather than a setting.
+func configFieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" || name == "schemaVersion" {
+		return ""
+	}
+	return name
+}
+
+// applyConfigEnv overrides every field of config whose environment variable (see ConfigEnvName) is set.
+// Strings are taken as they are, booleans and numbers are parsed, and maps, lists and objects such as
+// variables, accounts or nameRules are JSON.
+func applyConfigEnv(config *Config) error {
+	v := reflect.ValueOf(config).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := configFieldName(t.Field(i))
+		if name == "" {
+			continue
+		}
+		env := ConfigEnvName(name)
+		value, ok := os.LookupEnv(env)
+		if !ok {
+			continue
+		}
+		field := v.Field(i)
+		var err error
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Bool:
+			var b bool
+			if b, err = strconv.ParseBool(value); err == nil {
+				field.SetBool(b)
+			}
+		case reflect.Int:
+			var n int64
+			if n, err = strconv.ParseInt(value, 10, 0); err == nil {
+				field.SetInt(n)
+			}
+		case reflect.Float64:
+			var f float64
+			if f, err = strconv.ParseFloat(value, 64); err == nil {
+				field.SetFloat(f)
+			}
+		default:
+			target := reflect.New(field.Type())
+			if err = json.Unmarshal([]byte(value), target.Interface()); err == nil {
+				field.Set(target.Elem())
+			}
+		}
+		if err != nil {
+			return validationErrorf("%s=%q is not a valid %s: %v", env, value, name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package features
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestConfigEnvName(t *testing.T) {
+	for field, want := range map[string]string{
+		"region":           "PARAM_STORE_REGION",
+		"parameterPrefix":  "PARAM_STORE_PARAMETER_PREFIX",
+		"kmsKeyId":         "PARAM_STORE_KMS_KEY_ID",
+		"fallbackToPrefix": "PARAM_STORE_FALLBACK_TO_PREFIX",
+	} {
+		if got := ConfigEnvName(field); got != want {
+			t.Errorf("ConfigEnvName(%q) = %q; want %q", field, got, want)
+		}
+	}
+}
+
+func TestLoadConfigEnvOverrides(t *testing.T) {
+	inTempDir(t)
+	file := `{"schemaVersion": 1, "parameterPrefix": "/file/", "region": "eu-west-1", "variables": {"app": "billing"}}`
+	if err := os.WriteFile("config.json", []byte(file), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PARAM_STORE_REGION", "us-east-1")
+	t.Setenv("PARAM_STORE_TPS", "2.5")
+	t.Setenv("PARAM_STORE_FALLBACK_TO_PREFIX", "true")
+	t.Setenv("PARAM_STORE_VARIABLES", `{"env": "prod"}`)
+	t.Setenv("PARAM_STORE_ENDPOINT", "http://localhost:4566")
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if config.ParameterPrefix != "/file/" || config.Region != "us-east-1" || config.TPS != 2.5 || !config.FallbackToPrefix || config.Endpoint != "http://localhost:4566" {
+		t.Errorf("config = %+v; want the environment over the file", config)
+	}
+	if !reflect.DeepEqual(config.Variables, map[string]string{"env": "prod"}) {
+		t.Errorf("variables = %v; want the environment's map", config.Variables)
+	}
+	if data, _ := os.ReadFile("config.json"); string(data) != file {
+		t.Errorf("config.json changed to %s", data)
+	}
+
+	t.Setenv("PARAM_STORE_TPS", "fast")
+	if _, err := LoadConfig(); !errors.Is(err, ErrValidation) {
+		t.Errorf("LoadConfig with PARAM_STORE_TPS=fast: %v; want a validation error", err)
+	}
+}
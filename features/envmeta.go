@@ -0,0 +1,89 @@
+package features
+
+import (
+	"fmt"
+	"strings"
+)
+
+// envDirective is the metadata from "# param:" comments, applied to the next key of a .env file:
+//
+//	# param: type=securestring kms=alias/app path=/prod/app/CUSTOM_NAME
+//	DB_PASSWORD=...
+type envDirective struct {
+	Type ParameterType // Empty detects the type from the key and value.
+	KMS  string        // KMS key for a SecureString; implies type=securestring.
+	Path string        // Full parameter path; empty uses the prefix plus the key.
+	Line int           // Line of the first directive comment, for errors.
+}
+
+// isEnvDirective reports whether the trimmed .env line is a "# param:" comment.
+func isEnvDirective(line string) bool {
+	_, ok := envDirectiveFields(line)
+	return ok
+}
+
+// envDirectiveFields returns the text after "# param:", allowing spaces around "#".
+func envDirectiveFields(line string) (string, bool) {
+	if !strings.HasPrefix(line, "#") {
+		return "", false
+	}
+	return strings.CutPrefix(strings.TrimSpace(line[1:]), "param:")
+}
+
+// merge adds the settings of the directive comment line to d; later settings win.
+func (d *envDirective) merge(line string) error {
+	text, _ := envDirectiveFields(line)
+	for _, field := range strings.Fields(text) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || value == "" {
+			return fmt.Errorf("expected key=value in # param:, got %q", field)
+		}
+		if err := d.set(key, value); err != nil {
+			return err
+		}
+	}
+	return d.check()
+}
+
+// set sets the setting key (type, kms or path) of d.
+func (d *envDirective) set(key, value string) error {
+	switch key {
+	case "type":
+		paramType, err := ParseParameterType(value)
+		if err != nil {
+			return err
+		}
+		d.Type = paramType
+	case "kms":
+		d.KMS = value
+	case "path":
+		if !strings.HasPrefix(value, "/") {
+			return fmt.Errorf("path %q must start with /", value)
+		}
+		d.Path = value
+	default:
+		return fmt.Errorf("unknown setting %q (use type, kms or path)", key)
+	}
+	return nil
+}
+
+// check reports settings of d that contradict each other.
+func (d *envDirective) check() error {
+	if d.KMS != "" && d.Type != "" && d.Type != SecureStringType {
+		return fmt.Errorf("kms needs type=securestring, not %s", d.Type)
+	}
+	return nil
+}
+
+// apply returns param with the directive's overrides.
+func (d *envDirective) apply(param InputParam) InputParam {
+	switch {
+	case d.Type != "":
+		param.Type = d.Type
+	case d.KMS != "":
+		param.Type = SecureStringType
+	}
+	param.Name = d.Path
+	param.KeyID = d.KMS
+	return param
+}
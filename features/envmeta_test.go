@@ -0,0 +1,79 @@
+package features
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestGenerateEnvDirectives(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, "app.env")
+	template := filepath.Join(dir, "task.json")
+	env := `# Database
+# param: kms=alias/app path=/prod/shared/DB_URL
+DB_URL=postgres://db
+#param: type=string
+API_TOKEN=not-secret
+CERT=-----BEGIN CERTIFICATE-----
+abc
+-----END CERTIFICATE-----
+# param: type=stringlist
+HOSTS=a,b
+`
+	if err := os.WriteFile(envFile, []byte(env), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := GenerateTaskDef(envFile, template, GenerateOptions{Format: InputDotenv, Prefix: "/app/"}); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	fake := newFakeSSM()
+	if err := PutParametersFromTemplate(fake, template, TemplateOptions{}, PutOptions{AssumeYes: true}); err != nil {
+		t.Fatalf("put-from-template: %v", err)
+	}
+	want := map[string]types.ParameterType{
+		"/prod/shared/DB_URL": types.ParameterTypeSecureString,
+		"/app/API_TOKEN":      types.ParameterTypeString,
+		"/app/CERT":           types.ParameterTypeSecureString,
+		"/app/HOSTS":          types.ParameterTypeStringList,
+	}
+	got := make(map[string]types.ParameterType)
+	for name, param := range fake.params {
+		got[name] = param.Type
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("put parameters = %v; want %v", got, want)
+	}
+	if got := *fake.params["/app/CERT"].Value; got != "-----BEGIN CERTIFICATE-----\nabc\n-----END CERTIFICATE-----" {
+		t.Errorf("/app/CERT = %q; the directive must not end up in the value", got)
+	}
+	if want := map[string]string{"/prod/shared/DB_URL": "alias/app"}; !reflect.DeepEqual(fake.keyIDs, want) {
+		t.Errorf("KeyIds = %v; want %v", fake.keyIDs, want)
+	}
+}
+
+func TestGenerateEnvDirectiveErrors(t *testing.T) {
+	tests := []string{
+		"# param: type=blob\nKEY=v\n",
+		"# param: kms=alias/app type=string\nKEY=v\n",
+		"# param: path=relative\nKEY=v\n",
+		"# param: owner=me\nKEY=v\n",
+		"KEY=v\n# param: type=string\n",
+	}
+	dir := t.TempDir()
+	for _, env := range tests {
+		envFile := filepath.Join(dir, "app.env")
+		if err := os.WriteFile(envFile, []byte(env), 0644); err != nil {
+			t.Fatal(err)
+		}
+		err := GenerateTaskDef(envFile, filepath.Join(dir, "task.json"), GenerateOptions{Format: InputDotenv, Prefix: "/app/"})
+		if !errors.Is(err, ErrValidation) {
+			t.Errorf("generate of %q error = %v; want ErrValidation", env, err)
+		}
+	}
+}
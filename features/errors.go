@@ -0,0 +1,354 @@
+package features
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/smithy-go"
+)
+
+// Exit codes used by the CLI so wrapper scripts can branch on the cause of a failure.
+const (
+	ExitOK             = 0
+	ExitFailure        = 1 // Any failure not covered below.
+	ExitNotFound       = 2 // A parameter does not exist.
+	ExitAccessDenied   = 3 // IAM or KMS denied the call.
+	ExitThrottled      = 4 // SSM throttled the call after retries.
+	ExitPartialFailure = 5 // Some items of a bulk operation failed.
+	ExitValidation     = 6 // Input was rejected, by the tool or by SSM.
+	ExitDrift          = 2 // check-drift: live values differ from the template (shares the not-found code).
+	ExitCheckFailed    = 7 // A check found problems: stale or deleted secrets (verify-running), expiring credentials, a broken contract, secrets stored as String (audit-types), leaked values (scan-leaks) or values lost in a round trip.
+)
+
+// Error kinds; test for them with errors.Is.
+var (
+	ErrNotFound     = errors.New("parameter not found")
+	ErrAccessDenied = errors.New("access denied")
+	ErrThrottled    = errors.New("request throttled")
+	ErrValidation   = errors.New("validation failed")
+)
+
+// ParameterError reports an SSM API failure for one parameter (or prefix).
+// errors.Is matches its Kind, one of the Err* kinds above, or nil when the cause is unknown.
+type ParameterError struct {
+	Op     string // SSM operation, e.g. "GetParameter".
+	Name   string // Parameter name or path.
+	Region string // Region the call went to, when known.
+	Kind   error
+	Err    error // Underlying SDK error.
+}
+
+func (e *ParameterError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Op, e.Name, e.Err)
+}
+
+func (e *ParameterError) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// Describe returns a one-line explanation of e without the SDK request details,
+// e.g. "parameter /app/KEY not found in eu-west-1".
+func (e *ParameterError) Describe() string {
+	in := ""
+	if e.Region != "" {
+		in = " in " + e.Region
+	}
+	switch e.Kind {
+	case ErrNotFound:
+		return fmt.Sprintf("parameter %s not found%s", e.Name, in)
+	case ErrAccessDenied:
+		return fmt.Sprintf("access to %s denied%s (%s)", e.Name, in, e.Op)
+	case ErrThrottled:
+		return fmt.Sprintf("%s of %s throttled by SSM%s after retries", e.Op, e.Name, in)
+	case ErrValidation:
+		return fmt.Sprintf("%s rejected by SSM: %s", e.Name, apiMessage(e.Err))
+	}
+	return e.Error()
+}
+
+// Hints returns the likely causes of e, most likely first, or nil when there is nothing to suggest.
+func (e *ParameterError) Hints() []string {
+	switch e.Kind {
+	case ErrNotFound:
+		var hints []string
+		if !strings.HasPrefix(e.Name, "/") && strings.Contains(e.Name, "/") {
+			hints = append(hints, fmt.Sprintf("names with a path start with \"/\": did you mean /%s?", e.Name))
+		}
+		if e.Region != "" {
+			hints = append(hints, fmt.Sprintf("it may be in another region: this run used %s; set -region", e.Region))
+		} else {
+			hints = append(hints, "it may be in another region: check -region")
+		}
+		return append(hints, "it may be in another account: check AWS_PROFILE or the role in use (-action doctor shows the caller)")
+	case ErrAccessDenied:
+		p, _ := e.Permission()
+		caller := "the caller"
+		if p.Principal != "" {
+			caller = p.Principal
+		}
+		var hints []string
+		switch msg := apiMessage(e.Err); {
+		case p.Explicit:
+			hints = append(hints, fmt.Sprintf("an explicit Deny of %s on %s applies to %s (an SCP, permissions boundary or policy): an Allow will not override it", p.Action, p.Resource, caller))
+		case strings.HasPrefix(p.Action, "kms:"):
+			return []string{fmt.Sprintf("%s may not use the parameter's KMS key: grant %s on %s", caller, p.Action, p.Resource)}
+		case strings.Contains(msg, "KMS") || strings.Contains(msg, "kms:"):
+			action := "kms:Decrypt"
+			if e.Op == "PutParameter" {
+				action = "kms:Encrypt"
+			}
+			return []string{fmt.Sprintf("the caller may not use the parameter's KMS key: grant %s on it", action)}
+		default:
+			hints = append(hints, fmt.Sprintf("%s needs %s on %s (-print-policy prints a policy that allows it)", caller, p.Action, p.Resource))
+		}
+		return append(hints, "the credentials may be for another account or role than intended (-action doctor shows the caller)")
+	case ErrThrottled:
+		return []string{"the account's SSM request rate is shared by every client in the region: retry later, or lower it with -tps"}
+	}
+	return nil
+}
+
+// apiMessage returns the message of the SDK API error in err, or err's text when there is none.
+func apiMessage(err error) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorMessage() != "" {
+		return apiErr.ErrorMessage()
+	}
+	return err.Error()
+}
+
+// DescribeError returns the one-line explanation of the first ParameterError in err (see ParameterError.Describe),
+// or err's text when it holds none. Use it where a single item's failure is printed.
+func DescribeError(err error) string {
+	var perr *ParameterError
+	if errors.As(err, &perr) {
+		return perr.Describe()
+	}
+	return err.Error()
+}
+
+// ErrorReport returns one entry per ParameterError in err, including those collected by a PartialFailureError,
+// with its explanation and likely causes, or "" when err holds none.
+func ErrorReport(err error) string {
+	var b strings.Builder
+	for _, perr := range parameterErrors(err) {
+		fmt.Fprintf(&b, "  %s\n", perr.Describe())
+		for _, hint := range perr.Hints() {
+			fmt.Fprintf(&b, "    - %s\n", hint)
+		}
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return "Failed parameters:\n" + b.String()
+}
+
+// parameterErrors returns the ParameterErrors in err's tree, in order, without looking inside them.
+func parameterErrors(err error) []*ParameterError {
+	if perr, ok := err.(*ParameterError); ok {
+		return []*ParameterError{perr}
+	}
+	switch wrapped := err.(type) {
+	case interface{ Unwrap() error }:
+		return parameterErrors(wrapped.Unwrap())
+	case interface{ Unwrap() []error }:
+		var perrs []*ParameterError
+		for _, e := range wrapped.Unwrap() {
+			perrs = append(perrs, parameterErrors(e)...)
+		}
+		return perrs
+	}
+	return nil
+}
+
+// ValidationError reports input rejected before any API call was made. It matches ErrValidation.
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string        { return e.Err.Error() }
+func (e *ValidationError) Unwrap() error        { return e.Err }
+func (e *ValidationError) Is(target error) bool { return target == ErrValidation }
+
+// validationErrorf formats a ValidationError.
+func validationErrorf(format string, args ...any) error {
+	return &ValidationError{Err: fmt.Errorf(format, args...)}
+}
+
+// PartialFailureError reports a bulk operation where some items failed and the rest were processed.
+type PartialFailureError struct {
+	Failed []error // One error per failed item.
+	Total  int     // Number of items attempted.
+}
+
+func (e *PartialFailureError) Error() string {
+	return fmt.Sprintf("%d of %d parameters failed", len(e.Failed), e.Total)
+}
+
+func (e *PartialFailureError) Unwrap() []error {
+	return e.Failed
+}
+
+// DriftError reports live parameters that differ from a template.
+type DriftError struct {
+	Drifted int // Parameters missing or with another value or type.
+	Total   int // Parameters checked.
+}
+
+func (e *DriftError) Error() string {
+	return fmt.Sprintf("%d of %d parameters drifted from the template", e.Drifted, e.Total)
+}
+
+// StaleError reports secrets whose value in running tasks differs from the current parameter, and secrets
+// whose parameter no longer exists.
+type StaleError struct {
+	Stale   int // Secrets with another value, or missing from the environment.
+	Missing int // Secrets whose parameter was deleted; the next deployment fails on them.
+	Total   int // Secrets checked, over every task.
+}
+
+func (e *StaleError) Error() string {
+	var parts []string
+	if e.Stale > 0 {
+		parts = append(parts, fmt.Sprintf("%d are stale and update on the next deployment", e.Stale))
+	}
+	if e.Missing > 0 {
+		parts = append(parts, fmt.Sprintf("%d come from deleted parameters and will fail the next deployment", e.Missing))
+	}
+	return fmt.Sprintf("of %d secrets in running tasks, %s", e.Total, strings.Join(parts, "; "))
+}
+
+// ExpiringError reports certificates and tokens in parameters that expire within the checked window.
+type ExpiringError struct {
+	Expiring int // Credentials expired or expiring within the window.
+	Total    int // Credentials found.
+}
+
+func (e *ExpiringError) Error() string {
+	return fmt.Sprintf("%d of %d credentials have expired or expire soon", e.Expiring, e.Total)
+}
+
+// TypeAuditError reports String parameters that look like secrets.
+type TypeAuditError struct {
+	Plain int // String parameters that look like secrets.
+	Total int // String parameters checked.
+}
+
+func (e *TypeAuditError) Error() string {
+	return fmt.Sprintf("%d of %d String parameters look like secrets stored in plain text", e.Plain, e.Total)
+}
+
+// LeakError reports SecureString values found in files.
+type LeakError struct {
+	Leaks int // Occurrences found.
+	Files int // Files scanned.
+}
+
+func (e *LeakError) Error() string {
+	return fmt.Sprintf("%d occurrences of secret values found in the %d files scanned", e.Leaks, e.Files)
+}
+
+// RoundTripError reports keys of a source that did not come back unchanged from Parameter Store.
+type RoundTripError struct {
+	Changed int // Keys lost, added or with another value.
+	Total   int // Keys of the source.
+}
+
+func (e *RoundTripError) Error() string {
+	return fmt.Sprintf("%d of %d keys did not survive the round trip", e.Changed, e.Total)
+}
+
+// ContractError reports parameters under a prefix that break a service's contract.
+type ContractError struct {
+	Violations int // Missing required keys and keys of another type (with strict, also undeclared keys).
+	Total      int // Keys the contract declares.
+}
+
+func (e *ContractError) Error() string {
+	return fmt.Sprintf("%d violations of a contract declaring %d keys", e.Violations, e.Total)
+}
+
+// KMSAccessError lists the KMS permissions a bulk put was found to be missing before anything was written.
+// It matches ErrAccessDenied.
+type KMSAccessError struct {
+	Missing []string // One line per missing permission or unusable key.
+}
+
+func (e *KMSAccessError) Error() string {
+	return fmt.Sprintf("missing KMS access; grant these before applying:\n  %s", strings.Join(e.Missing, "\n  "))
+}
+
+func (e *KMSAccessError) Is(target error) bool { return target == ErrAccessDenied }
+
+// wrapAWSError wraps an SSM SDK error for name as a ParameterError with its kind classified. nil stays nil.
+func wrapAWSError(op, name string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ParameterError{Op: op, Name: name, Kind: classifyAWSError(err), Err: err}
+}
+
+// wrapClientError is wrapAWSError that also records the region of the client the call was made with.
+// Errors already holding ParameterErrors, such as the per-region failures of a MultiRegionClient, are kept.
+func wrapClientError(client SSMClient, op, name string, err error) error {
+	if err == nil || len(parameterErrors(err)) > 0 {
+		return err
+	}
+	return &ParameterError{Op: op, Name: name, Region: clientRegion(client), Kind: classifyAWSError(err), Err: err}
+}
+
+// classifyAWSError maps SDK error codes to an error kind, or nil if the code is not recognised.
+func classifyAWSError(err error) error {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return nil
+	}
+	switch apiErr.ErrorCode() {
+	case "ParameterNotFound", "ParameterVersionNotFound":
+		return ErrNotFound
+	case "AccessDeniedException", "AccessDenied", "UnauthorizedOperation", "KMSAccessDeniedException":
+		return ErrAccessDenied
+	case "ThrottlingException", "Throttling", "TooManyUpdates", "RequestLimitExceeded":
+		return ErrThrottled
+	case "ValidationException", "ParameterPatternMismatchException", "InvalidAllowedPatternException",
+		"ParameterMaxVersionLimitExceeded", "HierarchyLevelLimitExceededException", "HierarchyTypeMismatchException",
+		"UnsupportedParameterType", "InvalidKeyId", "ParameterLimitExceeded":
+		return ErrValidation
+	}
+	return nil
+}
+
+// ExitCode returns the CLI exit code for err.
+func ExitCode(err error) int {
+	var partial *PartialFailureError
+	var drift *DriftError
+	var stale *StaleError
+	var expiring *ExpiringError
+	var contract *ContractError
+	var audit *TypeAuditError
+	var leak *LeakError
+	var roundTrip *RoundTripError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &drift):
+		return ExitDrift
+	case errors.As(err, &stale), errors.As(err, &expiring), errors.As(err, &contract), errors.As(err, &audit), errors.As(err, &leak), errors.As(err, &roundTrip):
+		return ExitCheckFailed
+	case errors.As(err, &partial):
+		return ExitPartialFailure
+	case errors.Is(err, ErrNotFound):
+		return ExitNotFound
+	case errors.Is(err, ErrAccessDenied):
+		return ExitAccessDenied
+	case errors.Is(err, ErrThrottled):
+		return ExitThrottled
+	case errors.Is(err, ErrValidation):
+		return ExitValidation
+	}
+	return ExitFailure
+}
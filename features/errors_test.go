@@ -0,0 +1,103 @@
+package features
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/smithy-go"
+)
+
+func TestExitCode(t *testing.T) {
+	apiErr := func(code string) error {
+		return wrapAWSError("GetParameter", "/app/KEY", &smithy.GenericAPIError{Code: code, Message: "test"})
+	}
+	tests := []struct {
+		err  error
+		want int
+		desc string
+	}{
+		{nil, ExitOK, "no error"},
+		{errors.New("boom"), ExitFailure, "plain error"},
+		{apiErr("ParameterNotFound"), ExitNotFound, "not found"},
+		{apiErr("AccessDeniedException"), ExitAccessDenied, "access denied"},
+		{apiErr("ThrottlingException"), ExitThrottled, "throttled"},
+		{apiErr("ValidationException"), ExitValidation, "SSM validation"},
+		{apiErr("InternalServerError"), ExitFailure, "unknown code"},
+		{validationErrorf("bad input"), ExitValidation, "tool validation"},
+		{fmt.Errorf("failed to put secret X: %w", apiErr("ParameterNotFound")), ExitNotFound, "wrapped"},
+		{&PartialFailureError{Failed: []error{apiErr("ParameterNotFound")}, Total: 3}, ExitPartialFailure, "partial"},
+		{&DriftError{Drifted: 1, Total: 3}, ExitDrift, "drift"},
+		{&ExpiringError{Expiring: 1, Total: 2}, ExitCheckFailed, "expiring"},
+		{&ContractError{Violations: 1, Total: 3}, ExitCheckFailed, "contract"},
+		{&TypeAuditError{Plain: 1, Total: 3}, ExitCheckFailed, "audit"},
+		{&LeakError{Leaks: 1}, ExitCheckFailed, "leak"},
+		{&RoundTripError{Changed: 1, Total: 3}, ExitCheckFailed, "round trip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d; want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorReport(t *testing.T) {
+	notFound := &ParameterError{Op: "GetParameter", Name: "prod/app/DB_URL", Region: "eu-west-1", Kind: ErrNotFound,
+		Err: &smithy.GenericAPIError{Code: "ParameterNotFound", Message: "request id 1234"}}
+	denied := wrapAWSError("GetParameter", "/prod/app/KEY", &smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not authorized"})
+	err := &PartialFailureError{Failed: []error{notFound, fmt.Errorf("secret KEY: %w", denied), errors.New("invalid ARN")}, Total: 4}
+
+	want := `Failed parameters:
+  parameter prod/app/DB_URL not found in eu-west-1
+    - names with a path start with "/": did you mean /prod/app/DB_URL?
+    - it may be in another region: this run used eu-west-1; set -region
+    - it may be in another account: check AWS_PROFILE or the role in use (-action doctor shows the caller)
+  access to /prod/app/KEY denied (GetParameter)
+    - the caller needs ssm:GetParameter on arn:aws:ssm:*:*:parameter/prod/app/KEY (-print-policy prints a policy that allows it)
+    - the credentials may be for another account or role than intended (-action doctor shows the caller)
+`
+	if got := ErrorReport(err); got != want {
+		t.Errorf("ErrorReport = %q; want %q", got, want)
+	}
+	if got := ErrorReport(errors.New("boom")); got != "" {
+		t.Errorf("ErrorReport(plain error) = %q; want empty", got)
+	}
+	if got := DescribeError(fmt.Errorf("secret KEY: %w", denied)); got != "access to /prod/app/KEY denied (GetParameter)" {
+		t.Errorf("DescribeError = %q", got)
+	}
+}
+
+func TestGetParameterErrorNamesRegion(t *testing.T) {
+	fake := newFakeSSM()
+	_, _, err := GetParameter(regionalSSM{fake, "ap-southeast-3"}, "/app/MISSING")
+	if got, want := DescribeError(err), "parameter /app/MISSING not found in ap-southeast-3"; got != want {
+		t.Errorf("DescribeError = %q; want %q", got, want)
+	}
+}
+
+// regionalSSM reports a region like an *ssm.Client does.
+type regionalSSM struct {
+	SSMClient
+	region string
+}
+
+func (c regionalSSM) Options() ssm.Options { return ssm.Options{Region: c.region} }
+
+func TestDecoratorsKeepRegion(t *testing.T) {
+	var client SSMClient = regionalSSM{newFakeSSM(), "ap-southeast-3"}
+	client = NewMetricsClient(client, NewMetrics())
+	client = NewRateLimitedClient(client, NewRateLimiter(100))
+	client = NewMemoClient(client)
+	client = NewEventClient(client, NewEventStream(io.Discard, "get"))
+	client = NewHookClient(client, Hooks{}, "get")
+	client = NewProtectedClient(client, ProtectOptions{})
+	client = NewReadOnlyClient(client)
+	client = NewDryRunClient(client)
+	if got := clientRegion(client); got != "ap-southeast-3" {
+		t.Errorf("clientRegion through every decorator = %q; want ap-southeast-3", got)
+	}
+}
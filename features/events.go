@@ -0,0 +1,220 @@
+package features
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// EventsNDJSON is the -events format: one JSON object per line.
+const EventsNDJSON = "ndjson"
+
+// Event kinds, in the "event" field of an Event.
+const (
+	EventStarted  = "started"  // The run began; Action is set.
+	EventFetched  = "fetched"  // A parameter was read.
+	EventPut      = "put"      // A parameter was written.
+	EventDeleted  = "deleted"  // A parameter was deleted.
+	EventFailed   = "failed"   // A call failed after the SDK's retries.
+	EventRetried  = "retried"  // The SDK is retrying a call; Error is why the previous attempt failed.
+	EventFinished = "finished" // The run ended; Counts holds the events by kind, Error why the run failed.
+)
+
+// Event is one line of the event stream. Values are never included.
+type Event struct {
+	Time      time.Time      `json:"time"`
+	Event     string         `json:"event"`
+	Action    string         `json:"action,omitempty"`
+	Operation string         `json:"operation,omitempty"`
+	Name      string         `json:"name,omitempty"`
+	Region    string         `json:"region,omitempty"`
+	Type      string         `json:"type,omitempty"`
+	Version   int64          `json:"version,omitempty"`
+	Attempt   int            `json:"attempt,omitempty"`
+	DryRun    bool           `json:"dryRun,omitempty"` // A write only printed by -dry-run.
+	Error     string         `json:"error,omitempty"`
+	Counts    map[string]int `json:"counts,omitempty"`
+}
+
+// EventStream writes events as NDJSON to w as they happen, so wrappers can show the progress of a run.
+// It is safe for concurrent use; each event is written with a single Write call.
+type EventStream struct {
+	mu       sync.Mutex
+	w        io.Writer
+	action   string
+	counts   map[string]int
+	finished bool
+}
+
+// NewEventStream returns a stream writing to w and writes its started event for action.
+func NewEventStream(w io.Writer, action string) *EventStream {
+	s := &EventStream{w: w, action: action, counts: make(map[string]int)}
+	s.emit(Event{Event: EventStarted, Action: action})
+	return s
+}
+
+// emit stamps e with the current time and writes it. Write errors are ignored: a closed reader of the
+// stream must not fail the run.
+func (s *EventStream) emit(e Event) {
+	e.Time = time.Now().UTC()
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finished {
+		return
+	}
+	s.counts[e.Event]++
+	s.w.Write(append(data, '\n'))
+}
+
+// Finish writes the finished event with runErr, the error the run ends with. Only the first call writes;
+// it does nothing on a nil stream.
+func (s *EventStream) Finish(runErr error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	counts := make(map[string]int, len(s.counts))
+	for kind, n := range s.counts {
+		counts[kind] = n
+	}
+	s.mu.Unlock()
+	e := Event{Event: EventFinished, Action: s.action, Counts: counts}
+	if runErr != nil {
+		e.Error = DescribeError(runErr)
+	}
+	s.emit(e)
+	s.mu.Lock()
+	s.finished = true
+	s.mu.Unlock()
+}
+
+// eventClient reports every call made through the wrapped client to an EventStream.
+type eventClient struct {
+	decorator
+	events *EventStream
+}
+
+// NewEventClient wraps client so its calls are written to events. Wrap each regional client, so events carry
+// the region; retries are seen through the SDK's middleware, and so only for *ssm.Client.
+func NewEventClient(client SSMClient, events *EventStream) SSMClient {
+	return &eventClient{decorator: decorator{client}, events: events}
+}
+
+func (c *eventClient) dryRun() bool {
+	return isDryRun(c.SSMClient)
+}
+
+// call returns the optFns of a call to op on name, with the middleware reporting its retries added.
+func (c *eventClient) call(op, name string, optFns []func(*ssm.Options)) []func(*ssm.Options) {
+	region := clientRegion(c.SSMClient)
+	retries := ssm.WithAPIOptions(func(stack *middleware.Stack) error {
+		attempt, last := 0, error(nil)
+		return stack.Finalize.Insert(middleware.FinalizeMiddlewareFunc("ParamStoreEvents", func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+			attempt++
+			if attempt > 1 && last != nil {
+				c.events.emit(Event{Event: EventRetried, Operation: op, Name: name, Region: region, Attempt: attempt, Error: DescribeError(last)})
+			}
+			out, md, err := next.HandleFinalize(ctx, in)
+			last = err
+			return out, md, err
+		}), "Retry", middleware.After)
+	})
+	return append(optFns[:len(optFns):len(optFns)], retries)
+}
+
+// report writes e for a finished call, or a failed event in its place when err is set.
+func (c *eventClient) report(e Event, err error) {
+	e.Region = clientRegion(c.SSMClient)
+	if err != nil {
+		e.Event, e.Type, e.Version, e.Error = EventFailed, "", 0, DescribeError(err)
+	} else if e.Event == EventPut || e.Event == EventDeleted {
+		e.DryRun = c.dryRun()
+	}
+	c.events.emit(e)
+}
+
+func (c *eventClient) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	name := aws.ToString(params.Name)
+	out, err := c.SSMClient.GetParameter(ctx, params, c.call("GetParameter", name, optFns)...)
+	if errors.Is(classifyAWSError(err), ErrNotFound) {
+		return out, err // Actions look names up before creating them; a missing one is not a failure.
+	}
+	e := Event{Event: EventFetched, Operation: "GetParameter", Name: name}
+	if err == nil && out.Parameter != nil {
+		e.Type, e.Version = string(out.Parameter.Type), out.Parameter.Version
+	}
+	c.report(e, err)
+	return out, err
+}
+
+// GetParametersByPath writes a fetched event for each parameter of the page.
+func (c *eventClient) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	path := aws.ToString(params.Path)
+	out, err := c.SSMClient.GetParametersByPath(ctx, params, c.call("GetParametersByPath", path, optFns)...)
+	if err != nil {
+		c.report(Event{Operation: "GetParametersByPath", Name: path}, err)
+		return out, err
+	}
+	for _, p := range out.Parameters {
+		c.report(Event{Event: EventFetched, Operation: "GetParametersByPath", Name: aws.ToString(p.Name), Type: string(p.Type), Version: p.Version}, nil)
+	}
+	return out, err
+}
+
+// DescribeParameters only reports failures: it reads metadata, not parameters.
+func (c *eventClient) DescribeParameters(ctx context.Context, params *ssm.DescribeParametersInput, optFns ...func(*ssm.Options)) (*ssm.DescribeParametersOutput, error) {
+	out, err := c.SSMClient.DescribeParameters(ctx, params, c.call("DescribeParameters", "", optFns)...)
+	if err != nil {
+		c.report(Event{Operation: "DescribeParameters"}, err)
+	}
+	return out, err
+}
+
+func (c *eventClient) PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+	name := aws.ToString(params.Name)
+	out, err := c.SSMClient.PutParameter(ctx, params, c.call("PutParameter", name, optFns)...)
+	e := Event{Event: EventPut, Operation: "PutParameter", Name: name, Type: string(params.Type)}
+	if err == nil && out != nil {
+		e.Version = out.Version
+	}
+	c.report(e, err)
+	return out, err
+}
+
+func (c *eventClient) DeleteParameter(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error) {
+	name := aws.ToString(params.Name)
+	out, err := c.SSMClient.DeleteParameter(ctx, params, c.call("DeleteParameter", name, optFns)...)
+	c.report(Event{Event: EventDeleted, Operation: "DeleteParameter", Name: name}, err)
+	return out, err
+}
+
+// ListTagsForResource only reports failures, like DescribeParameters.
+func (c *eventClient) ListTagsForResource(ctx context.Context, params *ssm.ListTagsForResourceInput, optFns ...func(*ssm.Options)) (*ssm.ListTagsForResourceOutput, error) {
+	id := aws.ToString(params.ResourceId)
+	out, err := c.SSMClient.ListTagsForResource(ctx, params, c.call("ListTagsForResource", id, optFns)...)
+	if err != nil {
+		c.report(Event{Operation: "ListTagsForResource", Name: id}, err)
+	}
+	return out, err
+}
+
+// AddTagsToResource only reports failures: the put the tags belong to reported the change.
+func (c *eventClient) AddTagsToResource(ctx context.Context, params *ssm.AddTagsToResourceInput, optFns ...func(*ssm.Options)) (*ssm.AddTagsToResourceOutput, error) {
+	id := aws.ToString(params.ResourceId)
+	out, err := c.SSMClient.AddTagsToResource(ctx, params, c.call("AddTagsToResource", id, optFns)...)
+	if err != nil {
+		c.report(Event{Operation: "AddTagsToResource", Name: id}, err)
+	}
+	return out, err
+}
@@ -0,0 +1,126 @@
+package features
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// readEvents parses an NDJSON event stream.
+func readEvents(t *testing.T, data string) []Event {
+	t.Helper()
+	var events []Event
+	for _, line := range strings.Split(strings.TrimSuffix(data, "\n"), "\n") {
+		var e Event
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("event line %q: %v", line, err)
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+func TestEventStream(t *testing.T) {
+	var out bytes.Buffer
+	stream := NewEventStream(&out, "put-from-template")
+	fake := newFakeSSM()
+	fake.set("/app/A", "old", types.ParameterTypeString)
+	client := NewEventClient(fake, stream)
+
+	if err := PutParametersFromTemplate(client, writeHookTemplate(t), TemplateOptions{}, PutOptions{AssumeYes: true}); err != nil {
+		t.Fatalf("PutParametersFromTemplate: %v", err)
+	}
+	if _, _, _, err := getParameterWithVersion(client, "/app/missing"); err == nil {
+		t.Fatal("get of a missing parameter succeeded")
+	}
+	stream.Finish(nil)
+	stream.Finish(nil) // Only the first finish is written.
+
+	if strings.Contains(out.String(), `"a"`) || strings.Contains(out.String(), `"b"`) {
+		t.Fatalf("values in the event stream:\n%s", out.String())
+	}
+	var kinds []string
+	puts := map[string]string{}
+	for _, e := range readEvents(t, out.String()) {
+		kinds = append(kinds, e.Event)
+		if e.Event == EventPut {
+			puts[e.Name] = e.Type
+		}
+	}
+	if kinds[0] != EventStarted || kinds[len(kinds)-1] != EventFinished {
+		t.Errorf("events = %v; want started first and finished last", kinds)
+	}
+	if puts["/app/A"] != "String" || puts["/app/B"] != "SecureString" || len(puts) != 2 {
+		t.Errorf("put events = %v; want /app/A and /app/B", puts)
+	}
+	if strings.Contains(out.String(), "/app/missing") {
+		t.Errorf("a missing parameter was reported:\n%s", out.String())
+	}
+	last := readEvents(t, out.String())[len(kinds)-1]
+	if last.Counts[EventPut] != 2 || last.Action != "put-from-template" || last.Error != "" {
+		t.Errorf("finished event = %+v; want 2 puts and no error", last)
+	}
+}
+
+func TestEventStreamDryRun(t *testing.T) {
+	var out bytes.Buffer
+	stream := NewEventStream(&out, "put")
+	client := NewEventClient(NewDryRunClient(newFakeSSM()), stream)
+	if err := PutParameter(client, "/app/A", "a", StringType, PutOptions{AssumeYes: true}); err != nil {
+		t.Fatalf("PutParameter: %v", err)
+	}
+	events := readEvents(t, out.String())
+	if e := events[len(events)-1]; e.Event != EventPut || !e.DryRun {
+		t.Errorf("last event = %+v; want a dry-run put", e)
+	}
+}
+
+func TestEventStreamRetries(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		if calls == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"__type":"InternalServerError","Message":"try again"}`))
+			return
+		}
+		w.Write([]byte(`{"Parameter":{"Name":"/app/A","Type":"String","Value":"a","Version":3}}`))
+	}))
+	defer server.Close()
+	ssmClient := ssm.New(ssm.Options{
+		Region:       "ap-southeast-3",
+		BaseEndpoint: aws.String(server.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		Retryer: retry.NewStandard(func(o *retry.StandardOptions) {
+			o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+		}),
+	})
+
+	var out bytes.Buffer
+	client := NewEventClient(ssmClient, NewEventStream(&out, "get"))
+	if _, err := client.GetParameter(context.TODO(), &ssm.GetParameterInput{Name: aws.String("/app/A")}); err != nil {
+		t.Fatalf("GetParameter: %v", err)
+	}
+	events := readEvents(t, out.String())
+	if len(events) != 3 {
+		t.Fatalf("events = %+v; want started, retried and fetched", events)
+	}
+	if e := events[1]; e.Event != EventRetried || e.Attempt != 2 || e.Region != "ap-southeast-3" || !strings.Contains(e.Error, "StatusCode: 500") {
+		t.Errorf("retried event = %+v", e)
+	}
+	if e := events[2]; e.Event != EventFetched || e.Name != "/app/A" || e.Version != 3 {
+		t.Errorf("fetched event = %+v", e)
+	}
+}
@@ -0,0 +1,112 @@
+package features
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// credential is a certificate or token found in a parameter value, with when it stops working.
+type credential struct {
+	Kind     string // "certificate" or "jwt".
+	Subject  string // Certificate subject, or the token's sub (or iss) claim.
+	NotAfter time.Time
+}
+
+// jwtPattern matches a compact JWT: three base64url segments, the signature possibly empty.
+var jwtPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*$`)
+
+// credentialsIn returns the certificates of the PEM CERTIFICATE blocks in value, every one of a chain, or
+// the JWT value is, if it has an exp claim. Anything else yields nothing.
+func credentialsIn(value string) []credential {
+	if token := strings.TrimSpace(value); jwtPattern.MatchString(token) {
+		if c, ok := jwtExpiry(token); ok {
+			return []credential{c}
+		}
+		return nil
+	}
+	certs, _, _ := pemCertificates(value) // Keys have no expiry; unparsable certificates are skipped.
+	var found []credential
+	for _, cert := range certs {
+		found = append(found, credential{Kind: "certificate", Subject: certName(cert), NotAfter: cert.NotAfter})
+	}
+	return found
+}
+
+// jwtExpiry decodes the header and claims of a JWT, without verifying it, and returns its exp claim.
+func jwtExpiry(token string) (credential, bool) {
+	parts := strings.Split(token, ".")
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	var claims struct {
+		Exp *float64 `json:"exp"` // NumericDate, which may have a fraction.
+		Sub string   `json:"sub"`
+		Iss string   `json:"iss"`
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || json.Unmarshal(headerJSON, &header) != nil || header.Alg == "" {
+		return credential{}, false
+	}
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || json.Unmarshal(claimsJSON, &claims) != nil || claims.Exp == nil {
+		return credential{}, false
+	}
+	subject := claims.Sub
+	if subject == "" {
+		subject = claims.Iss
+	}
+	return credential{Kind: "jwt", Subject: subject, NotAfter: time.Unix(int64(*claims.Exp), 0)}, true
+}
+
+// ScanExpiring reads the parameters under prefix and reports every certificate (x509 NotAfter) and JWT
+// (exp claim) that has expired or expires within the window, soonest first. Values are never printed. It
+// returns an ExpiringError (exit code ExitCheckFailed) when any credential needs renewing, so it can run on a
+// schedule and alert.
+func ScanExpiring(client SSMClient, prefix string, within time.Duration) error {
+	params, err := listParameters(client, prefix)
+	if err != nil {
+		return err
+	}
+	type finding struct {
+		name string
+		credential
+	}
+	var expiring []finding
+	total := 0
+	now := time.Now()
+	for _, p := range params {
+		for _, c := range credentialsIn(aws.ToString(p.Value)) {
+			total++
+			if c.NotAfter.Before(now.Add(within)) {
+				expiring = append(expiring, finding{aws.ToString(p.Name), c})
+			}
+		}
+	}
+	if len(expiring) == 0 {
+		fmt.Printf("%s %d credentials in %d parameters under %s are valid for more than %s\n", green("OK:"), total, len(params), prefix, formatAge(within))
+		return nil
+	}
+	sort.SliceStable(expiring, func(i, j int) bool { return expiring[i].NotAfter.Before(expiring[j].NotAfter) })
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tKIND\tSUBJECT\tEXPIRES\tSTATUS")
+	for _, f := range expiring {
+		status := yellow("expires in " + formatAge(f.NotAfter.Sub(now)))
+		if !f.NotAfter.After(now) {
+			status = red("expired " + formatAge(now.Sub(f.NotAfter)) + " ago")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", f.name, f.Kind, f.Subject, f.NotAfter.UTC().Format(time.RFC3339), status)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return &ExpiringError{Expiring: len(expiring), Total: total}
+}
@@ -0,0 +1,114 @@
+package features
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"text/tabwriter"
+)
+
+// ConfigFlag is a command-line flag given for a setting, such as -region for region.
+type ConfigFlag struct {
+	Flag  string // Flag name without the dash.
+	Value string
+}
+
+// ConfigSetting is the effective value of a setting and the layer it comes from.
+type ConfigSetting struct {
+	Name   string // config.json field name.
+	Value  string // The value as JSON, or the flag's value; "-" when unset.
+	Source string // "flag -region", "env PARAM_STORE_REGION", the config file, or "default".
+}
+
+// ExplainConfig prints every setting with its effective value and where it comes from, in the order of
+// precedence: a flag of flags (the set flags, by setting name), an environment variable (see ConfigEnvName),
+// the config file at path, or the built-in default. The file is never created.
+func ExplainConfig(path string, flags map[string]ConfigFlag) error {
+	settings, err := explainConfig(path, flags)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SETTING\tVALUE\tSOURCE")
+	for _, s := range settings {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Name, s.Value, s.Source)
+	}
+	return w.Flush()
+}
+
+// explainConfig returns the settings ExplainConfig prints.
+func explainConfig(path string, flags map[string]ConfigFlag) ([]ConfigSetting, error) {
+	config := defaultConfig()
+	inFile := make(map[string]bool)
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if data, _, err = migrateConfig(data); err != nil {
+			return nil, err
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		for name := range fields {
+			inFile[name] = true
+		}
+		if err := json.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := applyConfigEnv(config); err != nil {
+		return nil, err
+	}
+
+	v := reflect.ValueOf(config).Elem()
+	var settings []ConfigSetting
+	for i := 0; i < v.NumField(); i++ {
+		name := configFieldName(v.Type().Field(i))
+		if name == "" {
+			continue
+		}
+		setting := ConfigSetting{Name: name, Value: settingValue(v.Field(i)), Source: "default"}
+		if inFile[name] {
+			setting.Source = path
+		}
+		if env := ConfigEnvName(name); envSet(env) {
+			setting.Source = "env " + env
+		}
+		if flag, ok := flags[name]; ok {
+			setting.Value, setting.Source = flag.Value, "flag -"+flag.Flag
+		}
+		settings = append(settings, setting)
+	}
+	return settings, nil
+}
+
+// settingValue formats a field of Config: strings as they are, anything else as JSON, and "-" when a
+// string, list, map or object is not set.
+func settingValue(field reflect.Value) string {
+	switch field.Kind() {
+	case reflect.String:
+		if field.String() == "" {
+			return "-"
+		}
+		return field.String()
+	case reflect.Map, reflect.Slice, reflect.Pointer:
+		if field.IsNil() || field.Kind() != reflect.Pointer && field.Len() == 0 {
+			return "-"
+		}
+	}
+	data, err := json.Marshal(field.Interface())
+	if err != nil {
+		return fmt.Sprint(field.Interface())
+	}
+	return string(data)
+}
+
+// envSet reports whether the environment variable name is set, even to "".
+func envSet(name string) bool {
+	_, ok := os.LookupEnv(name)
+	return ok
+}
@@ -0,0 +1,53 @@
+package features
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExplainConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"schemaVersion": 1, "parameterPrefix": "/prod/app/", "region": "eu-west-1", "tps": 5}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PARAM_STORE_TPS", "2")
+	t.Setenv("PARAM_STORE_VARIABLES", `{"env":"prod"}`)
+	settings, err := explainConfig(path, map[string]ConfigFlag{"region": {Flag: "region", Value: "us-east-1"}})
+	if err != nil {
+		t.Fatalf("explainConfig: %v", err)
+	}
+	got := make(map[string]ConfigSetting)
+	for _, s := range settings {
+		got[s.Name] = s
+	}
+	want := map[string]ConfigSetting{
+		"parameterPrefix": {"parameterPrefix", "/prod/app/", path},
+		"region":          {"region", "us-east-1", "flag -region"},
+		"tps":             {"tps", "2", "env PARAM_STORE_TPS"},
+		"variables":       {"variables", `{"env":"prod"}`, "env PARAM_STORE_VARIABLES"},
+		"pathPattern":     {"pathPattern", "-", "default"},
+		"nameRules":       {"nameRules", "-", "default"},
+	}
+	for name, w := range want {
+		if !reflect.DeepEqual(got[name], w) {
+			t.Errorf("%s = %+v; want %+v", name, got[name], w)
+		}
+	}
+	if _, ok := got["schemaVersion"]; ok {
+		t.Error("schemaVersion is listed as a setting")
+	}
+
+	missing := filepath.Join(t.TempDir(), "config.json")
+	settings, err = explainConfig(missing, nil)
+	if err != nil {
+		t.Fatalf("explainConfig without a file: %v", err)
+	}
+	if settings[0] != (ConfigSetting{"parameterPrefix", "/preprod/testing/", "default"}) {
+		t.Errorf("first setting without a file = %+v; want the default prefix", settings[0])
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Error("explainConfig created the config file")
+	}
+}
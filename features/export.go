@@ -0,0 +1,304 @@
+package features
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats only available with export, because they need more than the flat environment.
+// FormatJSON (nested JSON) is shared with import.
+const (
+	FormatYAML    = "yaml"    // Nested YAML, keys nest on "/".
+	FormatK8s     = "k8s"     // Kubernetes Secret manifest with the values in stringData.
+	FormatTaskDef = "taskdef" // Skeleton ECS task definition with a secrets array.
+)
+
+// ExportOptions selects what export writes.
+type ExportOptions struct {
+	Formats    []string         // One or more output formats.
+	Output     string           // Output base path; each format appends its extension. Empty prints one format to stdout.
+	Refs       ReferenceOptions // valueFrom form for taskdef.
+	SecretName string           // metadata.name for k8s; derived from the prefix when empty.
+	Canonical  bool             // Write taskdef canonically (see canonicalTaskDef).
+	// Fingerprint prints the Fingerprint of the exported set and embeds it in each output that can hold it:
+	// a "# fingerprint:" comment in the text formats and an annotation in k8s.
+	Fingerprint bool
+	VersionVar  string // Environment variable that carries the Fingerprint in taskdef (see withVersionVar); empty for none.
+	// WithTags reads the tags of every parameter for taskdef, one API call each.
+	WithTags bool
+	// Transforms lists the transform steps applied to the values of keys, by key or key pattern (see
+	// applyTransforms). The Fingerprint is of the stored values, before any transform.
+	Transforms map[string][]string
+}
+
+var k8sKeyPattern = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+var k8sNameInvalid = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// ExportParameters writes every parameter under prefix in each of opts.Formats.
+func ExportParameters(client SSMClient, prefix string, opts ExportOptions) error {
+	if err := checkExportOptions(opts); err != nil {
+		return err
+	}
+	params, err := loadExportParams(client, prefix)
+	if err != nil {
+		return err
+	}
+	for _, format := range opts.Formats {
+		if typedFormats[format] {
+			if err := loadMetadata(client, prefix, params); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	if opts.WithTags && slices.Contains(opts.Formats, FormatTaskDef) {
+		if err := loadTags(client, params); err != nil {
+			return err
+		}
+	}
+	return writeExport(params, prefix, opts)
+}
+
+// loadTags sets the Tags of params, with one ListTagsForResource call per parameter.
+func loadTags(client SSMClient, params []OutputParam) error {
+	for i := range params {
+		out, err := client.ListTagsForResource(context.TODO(), &ssm.ListTagsForResourceInput{
+			ResourceType: types.ResourceTypeForTaggingParameter,
+			ResourceId:   aws.String(params[i].Name),
+		})
+		if err != nil {
+			return wrapClientError(client, "ListTagsForResource", params[i].Name, err)
+		}
+		for _, tag := range out.TagList {
+			if params[i].Tags == nil {
+				params[i].Tags = make(map[string]string, len(out.TagList))
+			}
+			params[i].Tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+	}
+	return nil
+}
+
+// checkExportOptions validates the formats and that their output paths do not collide.
+func checkExportOptions(opts ExportOptions) error {
+	if len(opts.Formats) == 0 {
+		return validationErrorf("at least one -format is required")
+	}
+	paths := make(map[string]string) // Output path -> format, to catch formats that would overwrite each other.
+	for _, format := range opts.Formats {
+		f, err := lookupOutput(format)
+		if err != nil {
+			return err
+		}
+		_, isDir := f.writer.(DirectoryWriter)
+		if opts.Output == "" {
+			if len(opts.Formats) > 1 || isDir {
+				return validationErrorf("-o is required for format %s", strings.Join(opts.Formats, ","))
+			}
+			continue
+		}
+		path := opts.Output + f.writer.Extension()
+		if other, ok := paths[path]; ok {
+			return validationErrorf("formats %s and %s would both write %s", other, format, path)
+		}
+		paths[path] = format
+	}
+	if err := checkTransforms(opts.Transforms); err != nil {
+		return err
+	}
+	return checkVersionVar(opts.VersionVar)
+}
+
+// loadExportParams lists the parameters under prefix.
+func loadExportParams(client SSMClient, prefix string) ([]OutputParam, error) {
+	listed, err := listParameters(client, prefix)
+	if err != nil {
+		return nil, err
+	}
+	var params []OutputParam
+	for _, param := range listed {
+		name := aws.ToString(param.Name)
+		params = append(params, OutputParam{
+			Key:   strings.TrimPrefix(name, prefix),
+			Name:  name,
+			Value: aws.ToString(param.Value),
+			Type:  ParameterType(param.Type),
+		})
+	}
+	return params, nil
+}
+
+// writeExport writes params in each of opts.Formats, which checkExportOptions has accepted. source, the
+// prefix or the task definition family, names the k8s Secret when opts.SecretName is empty.
+func writeExport(params []OutputParam, source string, opts ExportOptions) error {
+	if opts.SecretName == "" {
+		opts.SecretName = strings.Trim(k8sNameInvalid.ReplaceAllString(strings.ToLower(source), "-"), "-")
+	}
+	fingerprint := ""
+	if opts.Fingerprint {
+		fingerprint = Fingerprint(outputVars(params))
+		report := os.Stdout
+		if opts.Output == "" {
+			report = os.Stderr // Keep stdout for the output itself.
+		}
+		fmt.Fprintf(report, "Fingerprint of %d parameters: %s\n", len(params), fingerprint)
+	}
+	params, err := applyTransforms(params, opts.Transforms)
+	if err != nil {
+		return err
+	}
+	for _, format := range opts.Formats {
+		f, err := lookupOutput(format)
+		if err != nil {
+			return err
+		}
+		if dir, ok := f.writer.(DirectoryWriter); ok {
+			if err := dir.WriteDir(opts.Output, params); err != nil {
+				return err
+			}
+			Infof("Saved %s to %s/\n", format, opts.Output)
+			continue
+		}
+		content, err := f.writer.Render(params, opts)
+		if err != nil {
+			return err
+		}
+		content = withFingerprint(f.writer, content, fingerprint)
+		if opts.Output == "" {
+			fmt.Print(content)
+			return nil
+		}
+		path := opts.Output + f.writer.Extension()
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			return fmt.Errorf("failed to write %s file %s: %w", format, path, err)
+		}
+		Infof("Saved %s to %s\n", format, path)
+	}
+	return nil
+}
+
+// jsonWriter writes nested JSON, keys nesting on "/", with typed values as JSON numbers, booleans and arrays.
+type jsonWriter struct{}
+
+func (jsonWriter) Extension() string { return ".json" }
+
+func (jsonWriter) Render(params []OutputParam, _ ExportOptions) (string, error) {
+	tree, err := nestParams(FormatJSON, params)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(tree, "", "  ")
+	return string(data) + "\n", err
+}
+
+// yamlWriter writes nested YAML, keys nesting on "/", with typed values as YAML numbers, booleans and sequences.
+type yamlWriter struct{ hashComments }
+
+func (yamlWriter) Extension() string { return ".yaml" }
+
+func (yamlWriter) Render(params []OutputParam, _ ExportOptions) (string, error) {
+	tree, err := nestParams(FormatYAML, params)
+	if err != nil {
+		return "", err
+	}
+	yamlNumbers(tree)
+	return marshalYAML(tree)
+}
+
+// k8sWriter writes a Kubernetes Secret manifest named opts.SecretName, with the fingerprint as an annotation.
+type k8sWriter struct{}
+
+func (k8sWriter) Extension() string { return ".secret.yaml" }
+
+func (k8sWriter) Render(params []OutputParam, opts ExportOptions) (string, error) {
+	type metadata struct {
+		Name        string            `yaml:"name"`
+		Annotations map[string]string `yaml:"annotations,omitempty"`
+	}
+	manifest := struct {
+		APIVersion string            `yaml:"apiVersion"`
+		Kind       string            `yaml:"kind"`
+		Metadata   metadata          `yaml:"metadata"`
+		Type       string            `yaml:"type"`
+		StringData map[string]string `yaml:"stringData"`
+	}{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata:   metadata{Name: opts.SecretName},
+		Type:       "Opaque",
+		StringData: make(map[string]string),
+	}
+	if opts.Fingerprint {
+		manifest.Metadata.Annotations = map[string]string{fingerprintAnnotation: Fingerprint(outputVars(params))}
+	}
+	for _, p := range params {
+		key, err := k8sSecretKey(p.Key)
+		if err != nil {
+			return "", err
+		}
+		manifest.StringData[key] = p.Value
+	}
+	return marshalYAML(manifest)
+}
+
+// k8sSecretKey returns the Kubernetes Secret key of a parameter key, with "/" turned into ".".
+func k8sSecretKey(key string) (string, error) {
+	key = strings.ReplaceAll(strings.Trim(key, "/"), "/", ".")
+	if !k8sKeyPattern.MatchString(key) {
+		return "", validationErrorf("%q is not a valid Kubernetes Secret key", key)
+	}
+	return key, nil
+}
+
+// taskDefWriter writes a skeleton ECS task definition whose secrets reference the parameters.
+type taskDefWriter struct{}
+
+func (taskDefWriter) Extension() string { return ".json" }
+
+func (taskDefWriter) Render(params []OutputParam, opts ExportOptions) (string, error) {
+	var secrets []ExtendedSecret
+	for _, p := range params {
+		secrets = append(secrets, ExtendedSecret{
+			Name:        p.Key,
+			ValueFrom:   opts.Refs.Format(p.Name), // Full parameter name or ARN for valueFrom.
+			Type:        p.Type,
+			Value:       p.Value,
+			KMSKeyID:    p.KeyID,
+			Tier:        p.Tier,
+			Description: p.Description,
+			Tags:        p.Tags,
+			ValueType:   p.ValueType,
+		})
+	}
+	taskDef := TaskDefinition{ContainerDefinitions: []ContainerDefinition{{Secrets: secrets}}}
+	withVersionVar(&taskDef, opts.VersionVar)
+	data, err := marshalTaskDef(taskDef, opts.Canonical)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return string(data), nil
+}
+
+// marshalYAML encodes v with the two-space indentation kubectl and most tools use.
+func marshalYAML(v interface{}) (string, error) {
+	var b strings.Builder
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
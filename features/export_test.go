@@ -0,0 +1,117 @@
+package features
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestExportParameters(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/prod/app/DB_URL", "postgres://db", types.ParameterTypeString)
+	fake.set("/prod/app/API_KEY", "s3cret", types.ParameterTypeSecureString)
+	base := filepath.Join(t.TempDir(), "app")
+
+	err := ExportParameters(fake, "/prod/app/", ExportOptions{Formats: []string{FormatEnv, FormatK8s, FormatYAML}, Output: base})
+	if err != nil {
+		t.Fatalf("ExportParameters: %v", err)
+	}
+	want := map[string]string{
+		".env": "API_KEY=s3cret\nDB_URL=postgres://db\n",
+		".secret.yaml": "apiVersion: v1\nkind: Secret\nmetadata:\n  name: prod-app\ntype: Opaque\n" +
+			"stringData:\n  API_KEY: s3cret\n  DB_URL: postgres://db\n",
+		".yaml": "API_KEY: s3cret\nDB_URL: postgres://db\n",
+	}
+	for ext, content := range want {
+		data, err := os.ReadFile(base + ext)
+		if err != nil || string(data) != content {
+			t.Errorf("%s =\n%s\nwant\n%s (err %v)", ext, data, content, err)
+		}
+		checkPerm(t, base+ext, 0600)
+	}
+	if _, err := os.Stat(base + ".json"); !os.IsNotExist(err) {
+		t.Errorf("task definition written without -format taskdef (err %v)", err)
+	}
+
+	err = ExportParameters(fake, "/prod/app/", ExportOptions{Formats: []string{FormatJSON, FormatTaskDef}, Output: base})
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("colliding formats err = %v; want ErrValidation", err)
+	}
+	err = ExportParameters(fake, "/prod/app/", ExportOptions{Formats: []string{FormatEnv, FormatYAML}})
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("two formats to stdout err = %v; want ErrValidation", err)
+	}
+}
+
+func TestExportFingerprint(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/prod/app/DB_URL", "postgres://db", types.ParameterTypeString)
+	fake.set("/prod/app/API_KEY", "s3cret", types.ParameterTypeSecureString)
+	base := filepath.Join(t.TempDir(), "app")
+
+	err := ExportParameters(fake, "/prod/app/", ExportOptions{Formats: []string{FormatEnv, FormatK8s, FormatJSON}, Output: base, Fingerprint: true})
+	if err != nil {
+		t.Fatalf("ExportParameters: %v", err)
+	}
+	fingerprint := Fingerprint([]EnvVar{{"DB_URL", "postgres://db"}, {"API_KEY", "s3cret"}})
+	if other := Fingerprint([]EnvVar{{"API_KEY", "s3cret"}, {"DB_URL", "postgres://db"}}); other != fingerprint {
+		t.Errorf("fingerprint depends on order: %s != %s", other, fingerprint)
+	}
+	if changed := Fingerprint([]EnvVar{{"API_KEY", "s3cret"}, {"DB_URL", "postgres://db2"}}); changed == fingerprint {
+		t.Errorf("fingerprint unchanged after a value change")
+	}
+	if joined := Fingerprint([]EnvVar{{"API_KEY", "s3cretDB_URL"}}); joined == Fingerprint([]EnvVar{{"API_KEY", "s3cret"}, {"DB_URL", ""}}) {
+		t.Errorf("fingerprints of different sets collide")
+	}
+
+	want := map[string]string{
+		".env": "# fingerprint: " + fingerprint + "\nAPI_KEY=s3cret\nDB_URL=postgres://db\n",
+		".secret.yaml": "apiVersion: v1\nkind: Secret\nmetadata:\n  name: prod-app\n  annotations:\n    salter-aws/fingerprint: " + fingerprint +
+			"\ntype: Opaque\nstringData:\n  API_KEY: s3cret\n  DB_URL: postgres://db\n",
+		".json": "{\n  \"API_KEY\": \"s3cret\",\n  \"DB_URL\": \"postgres://db\"\n}\n", // JSON has no comments.
+	}
+	for ext, content := range want {
+		data, err := os.ReadFile(base + ext)
+		if err != nil || string(data) != content {
+			t.Errorf("%s =\n%s\nwant\n%s (err %v)", ext, data, content, err)
+		}
+	}
+}
+
+func TestVersionVar(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/prod/app/DB_URL", "postgres://db", types.ParameterTypeString)
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, "app.env")
+	if err := os.WriteFile(envFile, []byte("DB_URL=postgres://db\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ExportParameters(fake, "/prod/app/", ExportOptions{Formats: []string{FormatTaskDef}, Output: filepath.Join(dir, "exported"), VersionVar: "CONFIG_VERSION"}); err != nil {
+		t.Fatalf("ExportParameters: %v", err)
+	}
+	if err := GenerateTaskDef(envFile, filepath.Join(dir, "generated.json"), GenerateOptions{Format: InputDotenv, Prefix: "/prod/app/", VersionVar: "CONFIG_VERSION"}); err != nil {
+		t.Fatalf("GenerateTaskDef: %v", err)
+	}
+	want := []Environment{{Name: "CONFIG_VERSION", Value: Fingerprint([]EnvVar{{"DB_URL", "postgres://db"}})}}
+	for _, file := range []string{"exported.json", "generated.json"} {
+		var taskDef TaskDefinition
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err == nil {
+			err = json.Unmarshal(data, &taskDef)
+		}
+		if err != nil || !reflect.DeepEqual(taskDef.ContainerDefinitions[0].Environment, want) {
+			t.Errorf("%s environment = %+v (err %v); want %+v", file, taskDef.ContainerDefinitions, err, want)
+		}
+	}
+
+	err := ExportParameters(fake, "/prod/app/", ExportOptions{Formats: []string{FormatTaskDef}, VersionVar: "CONFIG-VERSION"})
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("invalid -version-var error = %v; want ErrValidation", err)
+	}
+}
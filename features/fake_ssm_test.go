@@ -0,0 +1,178 @@
+package features
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/smithy-go"
+)
+
+// fakeSSM is an in-memory SSMClient used by tests.
+type fakeSSM struct {
+	SSMClient // Unimplemented methods panic through the nil interface.
+	params    map[string]types.Parameter
+	puts      int
+	keyIDs    map[string]string // KeyId of the last put of each parameter, when given.
+	meta      map[string]types.ParameterMetadata
+	tags      map[string][]types.Tag
+}
+
+func newFakeSSM() *fakeSSM {
+	return &fakeSSM{
+		params: make(map[string]types.Parameter),
+		keyIDs: make(map[string]string),
+		meta:   make(map[string]types.ParameterMetadata),
+		tags:   make(map[string][]types.Tag),
+	}
+}
+
+// set stores a parameter directly, bypassing PutParameter bookkeeping.
+func (f *fakeSSM) set(name, value string, paramType types.ParameterType) {
+	f.params[name] = types.Parameter{
+		Name:             aws.String(name),
+		Value:            aws.String(value),
+		Type:             paramType,
+		Version:          1,
+		LastModifiedDate: aws.Time(time.Now()),
+	}
+}
+
+func (f *fakeSSM) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	p, ok := f.params[aws.ToString(params.Name)]
+	if !ok {
+		return nil, &types.ParameterNotFound{Message: aws.String("not found")}
+	}
+	return &ssm.GetParameterOutput{Parameter: &p}, nil
+}
+
+func (f *fakeSSM) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	var names []string
+	for name := range f.params {
+		if strings.HasPrefix(name, aws.ToString(params.Path)) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	out := &ssm.GetParametersByPathOutput{}
+	for _, name := range names {
+		out.Parameters = append(out.Parameters, f.params[name])
+	}
+	return out, nil
+}
+
+func (f *fakeSSM) PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+	name := aws.ToString(params.Name)
+	existing, exists := f.params[name]
+	if exists && !aws.ToBool(params.Overwrite) {
+		return nil, &types.ParameterAlreadyExists{Message: aws.String("exists")}
+	}
+	f.puts++
+	if params.KeyId != nil {
+		f.keyIDs[name] = aws.ToString(params.KeyId)
+	}
+	if params.Tags != nil {
+		if exists {
+			return nil, &smithy.GenericAPIError{Code: "ValidationException", Message: "tags and overwrite can't be used together"}
+		}
+		f.tags[name] = params.Tags
+	}
+	f.meta[name] = types.ParameterMetadata{
+		Name:           aws.String(name),
+		Type:           params.Type,
+		Description:    params.Description,
+		AllowedPattern: params.AllowedPattern,
+		KeyId:          params.KeyId,
+		Tier:           params.Tier,
+		DataType:       params.DataType,
+	}
+	version := existing.Version + 1
+	f.params[name] = types.Parameter{
+		Name:             aws.String(name),
+		Value:            params.Value,
+		Type:             params.Type,
+		Version:          version,
+		LastModifiedDate: aws.Time(time.Now()),
+		DataType:         params.DataType,
+	}
+	return &ssm.PutParameterOutput{Version: version}, nil
+}
+
+func (f *fakeSSM) DeleteParameter(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error) {
+	name := aws.ToString(params.Name)
+	if _, ok := f.params[name]; !ok {
+		return nil, &types.ParameterNotFound{Message: aws.String("not found")}
+	}
+	delete(f.params, name)
+	delete(f.meta, name)
+	delete(f.tags, name)
+	return &ssm.DeleteParameterOutput{}, nil
+}
+
+func (f *fakeSSM) ListTagsForResource(ctx context.Context, params *ssm.ListTagsForResourceInput, optFns ...func(*ssm.Options)) (*ssm.ListTagsForResourceOutput, error) {
+	return &ssm.ListTagsForResourceOutput{TagList: f.tags[aws.ToString(params.ResourceId)]}, nil
+}
+
+// AddTagsToResource merges the tags into those of the parameter, as SSM does.
+func (f *fakeSSM) AddTagsToResource(ctx context.Context, params *ssm.AddTagsToResourceInput, optFns ...func(*ssm.Options)) (*ssm.AddTagsToResourceOutput, error) {
+	id := aws.ToString(params.ResourceId)
+	if _, ok := f.params[id]; !ok {
+		return nil, &types.InvalidResourceId{Message: aws.String("not found")}
+	}
+	for _, tag := range params.Tags {
+		i := slices.IndexFunc(f.tags[id], func(t types.Tag) bool { return aws.ToString(t.Key) == aws.ToString(tag.Key) })
+		if i < 0 {
+			f.tags[id] = append(f.tags[id], tag)
+		} else {
+			f.tags[id][i] = tag
+		}
+	}
+	return &ssm.AddTagsToResourceOutput{}, nil
+}
+
+// DescribeParameters supports Name (Equals or BeginsWith), Path and tag:<key> filters, all of which must
+// match, without pagination.
+func (f *fakeSSM) DescribeParameters(ctx context.Context, params *ssm.DescribeParametersInput, optFns ...func(*ssm.Options)) (*ssm.DescribeParametersOutput, error) {
+	var names []string
+	for name := range f.params {
+		if f.matchesFilters(name, params.ParameterFilters) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	out := &ssm.DescribeParametersOutput{}
+	for _, name := range names {
+		meta, ok := f.meta[name]
+		if !ok {
+			meta = types.ParameterMetadata{Name: aws.String(name), Type: f.params[name].Type}
+		}
+		out.Parameters = append(out.Parameters, meta)
+	}
+	return out, nil
+}
+
+func (f *fakeSSM) matchesFilters(name string, filters []types.ParameterStringFilter) bool {
+	for _, filter := range filters {
+		value := filter.Values[0]
+		key := aws.ToString(filter.Key)
+		switch {
+		case key == "Path" && strings.HasPrefix(name, strings.TrimSuffix(value, "/")+"/"),
+			aws.ToString(filter.Option) == "BeginsWith" && strings.HasPrefix(name, value),
+			aws.ToString(filter.Option) == "Equals" && slices.Contains(filter.Values, name):
+		case strings.HasPrefix(key, "tag:"):
+			if !slices.ContainsFunc(f.tags[name], func(tag types.Tag) bool {
+				return aws.ToString(tag.Key) == strings.TrimPrefix(key, "tag:") && slices.Contains(filter.Values, aws.ToString(tag.Value))
+			}) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
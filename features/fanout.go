@@ -0,0 +1,270 @@
+package features
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// Per-region outcomes recorded by MultiRegionClient.
+const (
+	resultPut     = "put"
+	resultSkipped = "skipped"
+	resultFailed  = "FAILED"
+)
+
+// MultiRegionClient sends every PutParameter call to several regions concurrently and records the outcome per region.
+// Reads are served by the first region, so what PutParameter decides from the current parameter (confirmation,
+// -expect-version, KeepType and its KMS key, and the metadata -recreate-on-type-change keeps) comes from it.
+// An overwrite is checked in every other region first, though: one that would change the type of the
+// parameter there fails in every region before anything is written. Failures name the region they come from,
+// and PutParameter asks before a recreate with -recreate-on-type-change deletes the parameter in every region.
+type MultiRegionClient struct {
+	decorator // The first region.
+	regions   []string
+	clients   map[string]SSMClient
+
+	mu      sync.Mutex
+	names   []string                     // Parameter names in the order they were put.
+	results map[string]map[string]string // name -> region -> outcome.
+	errs    []error
+}
+
+// NewMultiRegionClient fans puts out to clients, one per entry of regions. regions must not be empty.
+func NewMultiRegionClient(regions []string, clients map[string]SSMClient) *MultiRegionClient {
+	return &MultiRegionClient{
+		decorator: decorator{clients[regions[0]]},
+		regions:   regions,
+		clients:   clients,
+		results:   make(map[string]map[string]string),
+	}
+}
+
+// dryRun reports whether the per-region clients only print their calls.
+func (c *MultiRegionClient) dryRun() bool {
+	return isDryRun(c.SSMClient)
+}
+
+// writeRegions returns the regions every write goes to.
+func (c *MultiRegionClient) writeRegions() []string {
+	return c.regions
+}
+
+// PutParameter writes the parameter to every region concurrently.
+// It fails if any region failed, and reports ParameterAlreadyExists only if every region skipped the put.
+func (c *MultiRegionClient) PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+	name := aws.ToString(params.Name)
+	outcomes := make(map[string]string)
+	var failures []error
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	if aws.ToBool(params.Overwrite) {
+		// PutParameter checked the type in the first region only.
+		for _, region := range c.regions[1:] {
+			wg.Add(1)
+			go func(region string) {
+				defer wg.Done()
+				if err := checkRegionType(ctx, c.clients[region], region, params); err != nil {
+					mu.Lock()
+					outcomes[region] = resultFailed
+					failures = append(failures, err)
+					mu.Unlock()
+				}
+			}(region)
+		}
+		wg.Wait()
+		if len(failures) > 0 {
+			c.record(name, outcomes, failures)
+			return nil, errors.Join(failures...)
+		}
+	}
+	for _, region := range c.regions {
+		wg.Add(1)
+		go func(region string) {
+			defer wg.Done()
+			_, err := c.clients[region].PutParameter(ctx, params, optFns...)
+			var exists *types.ParameterAlreadyExists
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case errors.As(err, &exists):
+				outcomes[region] = resultSkipped
+			case err != nil:
+				outcomes[region] = resultFailed
+				failures = append(failures, regionError("PutParameter", name, region, err))
+			default:
+				outcomes[region] = resultPut
+			}
+		}(region)
+	}
+	wg.Wait()
+	c.record(name, outcomes, failures)
+
+	if len(failures) > 0 {
+		return nil, errors.Join(failures...)
+	}
+	skipped := 0
+	for _, outcome := range outcomes {
+		if outcome == resultSkipped {
+			skipped++
+		}
+	}
+	if skipped == len(c.regions) {
+		return nil, &types.ParameterAlreadyExists{Message: aws.String("parameter already exists in every region")}
+	}
+	return &ssm.PutParameterOutput{}, nil
+}
+
+// record stores the per-region outcomes and failures of a put of name.
+func (c *MultiRegionClient) record(name string, outcomes map[string]string, failures []error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, seen := c.results[name]; !seen {
+		c.names = append(c.names, name)
+	}
+	c.results[name] = outcomes
+	c.errs = append(c.errs, failures...)
+}
+
+// checkRegionType fails when the parameter params overwrites exists in region with another type, which the
+// put would change there.
+func checkRegionType(ctx context.Context, client SSMClient, region string, params *ssm.PutParameterInput) error {
+	name := aws.ToString(params.Name)
+	current, err := client.GetParameter(ctx, &ssm.GetParameterInput{Name: params.Name})
+	var notFound *types.ParameterNotFound
+	switch {
+	case errors.As(err, &notFound):
+		return nil
+	case err != nil:
+		return regionError("GetParameter", name, region, err)
+	case current.Parameter.Type != params.Type:
+		return validationErrorf("%s exists as %s in %s; putting it as %s would change its type there (put it to %s alone with -region to recreate it or keep its type)",
+			name, current.Parameter.Type, region, params.Type, region)
+	}
+	return nil
+}
+
+// regionError reports err, returned by op on name in region, as a ParameterError of that region, so the
+// failure is not attributed to the first region when PutParameter wraps it.
+func regionError(op, name, region string, err error) error {
+	return fmt.Errorf("%s in %s: %w", name, region, &ParameterError{Op: op, Name: name, Region: region, Kind: classifyAWSError(err), Err: err})
+}
+
+// DeleteParameter deletes the parameter in every region concurrently and fails if any region failed.
+// Regions where the parameter does not exist are not failures.
+func (c *MultiRegionClient) DeleteParameter(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error) {
+	name := aws.ToString(params.Name)
+	var failures []error
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, region := range c.regions {
+		wg.Add(1)
+		go func(region string) {
+			defer wg.Done()
+			_, err := c.clients[region].DeleteParameter(ctx, params, optFns...)
+			var notFound *types.ParameterNotFound
+			if err != nil && !errors.As(err, &notFound) {
+				mu.Lock()
+				failures = append(failures, regionError("DeleteParameter", name, region, err))
+				mu.Unlock()
+			}
+		}(region)
+	}
+	wg.Wait()
+	if len(failures) > 0 {
+		return nil, errors.Join(failures...)
+	}
+	return &ssm.DeleteParameterOutput{}, nil
+}
+
+// AddTagsToResource tags the parameter in every region concurrently and fails if any region failed.
+func (c *MultiRegionClient) AddTagsToResource(ctx context.Context, params *ssm.AddTagsToResourceInput, optFns ...func(*ssm.Options)) (*ssm.AddTagsToResourceOutput, error) {
+	id := aws.ToString(params.ResourceId)
+	var failures []error
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, region := range c.regions {
+		wg.Add(1)
+		go func(region string) {
+			defer wg.Done()
+			if _, err := c.clients[region].AddTagsToResource(ctx, params, optFns...); err != nil {
+				mu.Lock()
+				failures = append(failures, regionError("AddTagsToResource", id, region, err))
+				mu.Unlock()
+			}
+		}(region)
+	}
+	wg.Wait()
+	if len(failures) > 0 {
+		return nil, errors.Join(failures...)
+	}
+	return &ssm.AddTagsToResourceOutput{}, nil
+}
+
+// PrintResults prints a parameter-by-region matrix of put outcomes followed by any errors.
+func (c *MultiRegionClient) PrintResults() {
+	c.writeResults(os.Stdout)
+}
+
+// writeResults writes the PrintResults matrix to w. Columns are padded by hand rather than with tabwriter,
+// which would count color codes as text.
+func (c *MultiRegionClient) writeResults(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	rows := [][]string{append([]string{"PARAMETER"}, c.regions...)}
+	for _, name := range c.names {
+		row := []string{name}
+		for _, region := range c.regions {
+			outcome := c.results[name][region]
+			if outcome == "" {
+				outcome = "-"
+			}
+			row = append(row, outcome)
+		}
+		rows = append(rows, row)
+	}
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+	for r, row := range rows {
+		var line strings.Builder
+		for i, cell := range row {
+			padding := ""
+			if i < len(row)-1 {
+				padding = strings.Repeat(" ", widths[i]-len(cell)+2)
+			}
+			if r > 0 && i > 0 {
+				cell = paintOutcome(cell)
+			}
+			line.WriteString(cell + padding)
+		}
+		fmt.Fprintln(w, line.String())
+	}
+	for _, err := range c.errs {
+		fmt.Fprintf(w, "%s %v\n", red("Error:"), err)
+	}
+}
+
+// paintOutcome colors a per-region outcome of PrintResults.
+func paintOutcome(outcome string) string {
+	switch outcome {
+	case resultPut:
+		return green(outcome)
+	case resultSkipped:
+		return yellow(outcome)
+	case resultFailed:
+		return red(outcome)
+	}
+	return outcome
+}
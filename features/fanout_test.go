@@ -0,0 +1,138 @@
+package features
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestMultiRegionClientPutsEveryRegion(t *testing.T) {
+	primary, secondary := newFakeSSM(), newFakeSSM()
+	secondary.set("/app/KEY", "old", types.ParameterTypeString)
+	client := NewMultiRegionClient([]string{"ap-southeast-3", "ap-southeast-1"}, map[string]SSMClient{
+		"ap-southeast-3": primary,
+		"ap-southeast-1": secondary,
+	})
+
+	// Create-only: written where missing, skipped where it exists.
+	if err := PutParameter(client, "/app/KEY", "new", StringType, PutOptions{NoOverwrite: true}); err != nil {
+		t.Fatalf("PutParameter: %v", err)
+	}
+	if got := *primary.params["/app/KEY"].Value; got != "new" {
+		t.Errorf("primary value = %q; want %q", got, "new")
+	}
+	if got := *secondary.params["/app/KEY"].Value; got != "old" {
+		t.Errorf("secondary value = %q; want %q", got, "old")
+	}
+	if got := client.results["/app/KEY"]; got["ap-southeast-3"] != resultPut || got["ap-southeast-1"] != resultSkipped {
+		t.Errorf("results = %v", got)
+	}
+
+	// Skipped everywhere surfaces as a declined overwrite.
+	err := PutParameter(client, "/app/KEY", "newer", StringType, PutOptions{NoOverwrite: true})
+	if !errors.Is(err, ErrOverwriteDeclined) {
+		t.Errorf("all-skipped error = %v; want ErrOverwriteDeclined", err)
+	}
+}
+
+func TestMultiRegionClientChecksEveryRegion(t *testing.T) {
+	primary, secondary := newFakeSSM(), newFakeSSM()
+	primary.set("/app/KEY", "old", types.ParameterTypeString)
+	secondary.set("/app/KEY", "old", types.ParameterTypeSecureString)
+	primary.set("/app/OTHER", "old", types.ParameterTypeString) // Missing in the secondary region.
+	client := NewMultiRegionClient([]string{"ap-southeast-3", "ap-southeast-1"}, map[string]SSMClient{
+		"ap-southeast-3": primary,
+		"ap-southeast-1": secondary,
+	})
+
+	// The type differs only in the secondary region: nothing is written anywhere.
+	err := PutParameter(client, "/app/KEY", "new", StringType, PutOptions{AssumeYes: true})
+	if ExitCode(err) != ExitValidation || !strings.Contains(err.Error(), "ap-southeast-1") {
+		t.Errorf("put with another type in ap-southeast-1 error = %v; want a validation error naming the region", err)
+	}
+	if primary.puts != 0 || secondary.puts != 0 {
+		t.Errorf("puts = %d, %d; want none", primary.puts, secondary.puts)
+	}
+	if got := client.results["/app/KEY"]["ap-southeast-1"]; got != resultFailed {
+		t.Errorf("ap-southeast-1 result = %q; want %q", got, resultFailed)
+	}
+
+	// Missing in the secondary region: created there.
+	if err := PutParameter(client, "/app/OTHER", "new", StringType, PutOptions{AssumeYes: true}); err != nil {
+		t.Fatalf("PutParameter: %v", err)
+	}
+	if got := secondary.params["/app/OTHER"]; aws.ToString(got.Value) != "new" || got.Type != types.ParameterTypeString {
+		t.Errorf("secondary /app/OTHER = %q (%s); want %q (String)", aws.ToString(got.Value), got.Type, "new")
+	}
+}
+
+func TestMultiRegionResultsColor(t *testing.T) {
+	client := NewMultiRegionClient([]string{"eu-west-1", "us-east-1"}, map[string]SSMClient{"eu-west-1": newFakeSSM(), "us-east-1": newFakeSSM()})
+	client.names = []string{"/app/A"}
+	client.results = map[string]map[string]string{"/app/A": {"eu-west-1": resultPut, "us-east-1": resultFailed}}
+	want := "PARAMETER  eu-west-1  us-east-1\n/app/A     put        FAILED\n"
+
+	var plain, colored bytes.Buffer
+	client.writeResults(&plain)
+	SetColor(true)
+	defer SetColor(false)
+	client.writeResults(&colored)
+	if plain.String() != want {
+		t.Errorf("plain results =\n%s\nwant\n%s", plain.String(), want)
+	}
+	if !strings.Contains(colored.String(), colorGreen+"put"+colorReset+"        ") || !strings.Contains(colored.String(), colorRed+"FAILED"+colorReset) {
+		t.Errorf("colored results = %q; want a green put padded as in plain text and a red FAILED", colored.String())
+	}
+}
+
+func TestMultiRegionClientNamesFailedRegion(t *testing.T) {
+	secondary := &rejectTypeClient{fakeSSM: newFakeSSM(), reject: types.ParameterTypeString}
+	client := NewMultiRegionClient([]string{"ap-southeast-3", "ap-southeast-1"}, map[string]SSMClient{
+		"ap-southeast-3": regionalSSM{newFakeSSM(), "ap-southeast-3"},
+		"ap-southeast-1": regionalSSM{secondary, "ap-southeast-1"},
+	})
+	if got := clientRegion(client); got != "ap-southeast-3" {
+		t.Errorf("clientRegion = %q; want the first region", got)
+	}
+
+	err := PutParameter(client, "/app/KEY", "new", StringType, PutOptions{AssumeYes: true})
+	perrs := parameterErrors(err)
+	if len(perrs) != 1 || perrs[0].Region != "ap-southeast-1" || perrs[0].Op != "PutParameter" {
+		t.Errorf("put failing in ap-southeast-1: ParameterErrors = %+v (err %v); want one in ap-southeast-1", perrs, err)
+	}
+}
+
+func TestMultiRegionRecreateAsks(t *testing.T) {
+	primary, secondary := newFakeSSM(), newFakeSSM()
+	primary.set("/app/KEY", "old", types.ParameterTypeString)
+	secondary.set("/app/KEY", "old", types.ParameterTypeString)
+	multiRegion := NewMultiRegionClient([]string{"ap-southeast-3", "ap-southeast-1"}, map[string]SSMClient{
+		"ap-southeast-3": primary,
+		"ap-southeast-1": secondary,
+	})
+	client := NewMetricsClient(multiRegion, NewMetrics())
+	opts := PutOptions{AssumeYes: true, RecreateOnTypeChange: true}
+
+	// -yes alone does not delete the parameter in every region.
+	withStdin(t, "n\n")
+	if err := PutParameter(client, "/app/KEY", "new", SecureStringType, opts); !errors.Is(err, ErrOverwriteDeclined) {
+		t.Errorf("declined recreate err = %v; want ErrOverwriteDeclined", err)
+	}
+	if primary.puts != 0 || secondary.puts != 0 || primary.params["/app/KEY"].Type != types.ParameterTypeString {
+		t.Errorf("declined recreate changed the parameter: puts %d, %d", primary.puts, secondary.puts)
+	}
+
+	withStdin(t, "y\n")
+	if err := PutParameter(client, "/app/KEY", "new", SecureStringType, opts); err != nil {
+		t.Fatalf("confirmed recreate: %v", err)
+	}
+	for region, fake := range map[string]*fakeSSM{"ap-southeast-3": primary, "ap-southeast-1": secondary} {
+		if got := fake.params["/app/KEY"]; got.Type != types.ParameterTypeSecureString || aws.ToString(got.Value) != "new" {
+			t.Errorf("%s /app/KEY = %q (%s); want %q (SecureString)", region, aws.ToString(got.Value), got.Type, "new")
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package features
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// findShown is how many of the best matches find lists at a time.
+const findShown = 10
+
+// findMatch is a name matching the query of find, with its score and the positions of the matched characters.
+type findMatch struct {
+	name      string
+	paramType types.ParameterType
+	score     int
+	positions []int
+}
+
+// FindParameter lets the user pick a parameter under root with a fuzzy search, like fzf, and returns its
+// name, or "" when they quit. The best matches of the query are listed on stderr and refined by typing
+// another query until one is picked by number; query is the first one. Characters of a query must appear
+// in the name in order, and words separated by spaces must all match. Only metadata is read.
+func FindParameter(client SSMClient, root, query string) (string, error) {
+	if stdinSource.read {
+		return "", validationErrorf("find reads the search from standard input, which was read as -s")
+	}
+	metadata, err := describeParameters(client, types.ParameterStringFilter{
+		Key:    aws.String("Name"),
+		Option: aws.String("BeginsWith"),
+		Values: []string{root},
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(metadata) == 0 {
+		return "", fmt.Errorf("%w: no parameters under %s", ErrNotFound, root)
+	}
+	fmt.Fprintf(os.Stderr, "%d parameters under %s\n", len(metadata), root)
+
+	for {
+		matches := rankMatches(metadata, query)
+		if len(matches) == 0 {
+			fmt.Fprintf(os.Stderr, "No names match %q. ", query)
+		} else {
+			printMatches(matches)
+		}
+		prompt := "Search: "
+		if len(matches) > 0 {
+			prompt = fmt.Sprintf("Pick 1-%d (Enter picks 1), type to search again, q quits: ", min(len(matches), findShown))
+		}
+		fmt.Fprint(os.Stderr, prompt)
+		line, err := stdinReader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf("failed to read the search: %w", err)
+		}
+		if err == io.EOF && line == "" {
+			fmt.Fprintln(os.Stderr) // End of input quits.
+			return "", nil
+		}
+		line = strings.TrimSpace(line)
+		if line == "q" {
+			return "", nil
+		}
+		if len(matches) > 0 {
+			if line == "" {
+				return matches[0].name, nil
+			}
+			if n, err := strconv.Atoi(line); err == nil && n >= 1 && n <= min(len(matches), findShown) {
+				return matches[n-1].name, nil
+			}
+		}
+		query = line
+	}
+}
+
+// rankMatches returns the names of metadata matching query, best first: higher scores, then shorter and
+// alphabetically earlier names. An empty query matches every name.
+func rankMatches(metadata map[string]types.ParameterMetadata, query string) []findMatch {
+	words := strings.Fields(query)
+	var matches []findMatch
+	for name, meta := range metadata {
+		m := findMatch{name: name, paramType: meta.Type}
+		matched := true
+		for _, word := range words {
+			score, positions, ok := fuzzyMatch(word, name)
+			if !ok {
+				matched = false
+				break
+			}
+			m.score += score
+			m.positions = append(m.positions, positions...)
+		}
+		if matched {
+			matches = append(matches, m)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		switch {
+		case a.score != b.score:
+			return a.score > b.score
+		case len(a.name) != len(b.name):
+			return len(a.name) < len(b.name)
+		}
+		return a.name < b.name
+	})
+	return matches
+}
+
+// fuzzyMatch reports whether the characters of pattern appear in name in order, ignoring case, with a
+// score and the positions matched. Like fzf, each match is shortened to the last start before its end, and
+// consecutive characters and the starts of path levels and words score higher; the best match is used.
+func fuzzyMatch(pattern, name string) (int, []int, bool) {
+	p, s := strings.ToLower(pattern), strings.ToLower(name) // Parameter names are ASCII.
+	best, bestPositions, found := 0, []int(nil), false
+	for from := strings.IndexByte(s, p[0]); from >= 0; {
+		end := -1
+		for i, j := from, 0; i < len(s); i++ {
+			if s[i] == p[j] {
+				if j++; j == len(p) {
+					end = i
+					break
+				}
+			}
+		}
+		if end < 0 {
+			break // No later start fits either.
+		}
+		start := end
+		for i, j := end, len(p)-1; i >= from; i-- {
+			if s[i] == p[j] {
+				if j--; j < 0 {
+					start = i
+					break
+				}
+			}
+		}
+		if score, positions := scoreMatch(p, s, start, end); !found || score > best {
+			best, bestPositions, found = score, positions, true
+		}
+		next := strings.IndexByte(s[start+1:], p[0])
+		if next < 0 {
+			break
+		}
+		from = start + 1 + next
+	}
+	return best, bestPositions, found
+}
+
+// scoreMatch scores the match of p in s from start to end, returning the positions of its characters.
+func scoreMatch(p, s string, start, end int) (int, []int) {
+	positions := make([]int, 0, len(p))
+	score := 0
+	for i, j := start, 0; i <= end && j < len(p); i++ {
+		if s[i] != p[j] {
+			continue
+		}
+		score += 16
+		if len(positions) > 0 && positions[len(positions)-1] == i-1 {
+			score += 8 // Consecutive.
+		}
+		if i == 0 || strings.IndexByte("/_-.", s[i-1]) >= 0 {
+			score += 10 // Start of a level or word.
+		}
+		positions = append(positions, i)
+		j++
+	}
+	return score - (end - start + 1 - len(p)), positions // One point per character skipped.
+}
+
+// printMatches lists the best matches, numbered, with their matched characters highlighted.
+func printMatches(matches []findMatch) {
+	for i, m := range matches {
+		if i == findShown {
+			fmt.Fprintf(os.Stderr, "  … and %d more; type more of the name to narrow them down\n", len(matches)-findShown)
+			break
+		}
+		fmt.Fprintf(os.Stderr, "  %2d  %-12s  %s\n", i+1, m.paramType, highlight(m.name, m.positions))
+	}
+}
+
+// highlight colors the characters of name at positions.
+func highlight(name string, positions []int) string {
+	marked := make(map[int]bool, len(positions))
+	for _, p := range positions {
+		marked[p] = true
+	}
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if marked[i] {
+			b.WriteString(green(name[i : i+1]))
+		} else {
+			b.WriteByte(name[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,64 @@
+package features
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	for _, tt := range []struct {
+		pattern, name string
+		ok            bool
+	}{
+		{"dburl", "/prod/app/DB_URL", true},
+		{"pdburl", "/prod/app/DB_URL", true},
+		{"lrubd", "/prod/app/DB_URL", false},
+		{"x", "/prod/app/DB_URL", false},
+	} {
+		if _, _, ok := fuzzyMatch(tt.pattern, tt.name); ok != tt.ok {
+			t.Errorf("fuzzyMatch(%q, %q) ok = %t; want %t", tt.pattern, tt.name, ok, tt.ok)
+		}
+	}
+	if _, positions, _ := fuzzyMatch("app", "/prod/xapp/app"); len(positions) != 3 || positions[0] != 11 {
+		t.Errorf("positions = %v; want the match at the start of a level, at 11", positions)
+	}
+	word, _, _ := fuzzyMatch("url", "/prod/app/DB_URL")
+	inner, _, _ := fuzzyMatch("url", "/prod/app/CURLOPTS")
+	if word <= inner {
+		t.Errorf("score at a word start %d <= inside a word %d", word, inner)
+	}
+}
+
+func TestFindParameter(t *testing.T) {
+	fake := newFakeSSM()
+	for _, name := range []string{"/prod/app/DB_URL", "/prod/app/DB_URL_POOL_SIZE", "/prod/billing/DB_URL", "/prod/app/REDIS_URL", "/staging/app/DB_URL"} {
+		fake.set(name, "v", types.ParameterTypeString)
+	}
+	metadata, err := describeParameters(fake, types.ParameterStringFilter{Key: aws.String("Name"), Option: aws.String("BeginsWith"), Values: []string{"/prod/"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches := rankMatches(metadata, "db url")
+	if len(matches) != 3 || matches[0].name != "/prod/app/DB_URL" || matches[2].name != "/prod/app/DB_URL_POOL_SIZE" {
+		t.Errorf("matches of %q = %v", "db url", matches)
+	}
+
+	withStdin(t, "\n")
+	if name, err := FindParameter(fake, "/prod/", "dburl"); err != nil || name != "/prod/app/DB_URL" {
+		t.Errorf("Enter picked %q, %v; want the best match", name, err)
+	}
+	withStdin(t, "redis\n1\n")
+	if name, err := FindParameter(fake, "/prod/", "nomatch"); err != nil || name != "/prod/app/REDIS_URL" {
+		t.Errorf("refined search picked %q, %v; want /prod/app/REDIS_URL", name, err)
+	}
+	withStdin(t, "q\n")
+	if name, err := FindParameter(fake, "/prod/", ""); err != nil || name != "" {
+		t.Errorf("q picked %q, %v; want nothing", name, err)
+	}
+	if _, err := FindParameter(fake, "/dev/", ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("FindParameter with no parameters = %v; want ErrNotFound", err)
+	}
+}
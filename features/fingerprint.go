@@ -0,0 +1,64 @@
+package features
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// fingerprintAnnotation is the Kubernetes annotation export stores the fingerprint under.
+const fingerprintAnnotation = "salter-aws/fingerprint"
+
+// Fingerprint returns "sha256:<hex>" of the keys and values of vars. It does not depend on their order, so
+// two exports of the same configuration match however SSM paginated them, and any change to a key or
+// value changes it. Deployment tooling can compare fingerprints to decide whether a service must restart.
+func Fingerprint(vars []EnvVar) string {
+	sorted := append([]EnvVar(nil), vars...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+	h := sha256.New()
+	for _, v := range sorted {
+		fmt.Fprintf(h, "%d:%s%d:%s", len(v.Key), v.Key, len(v.Value), v.Value) // Length-prefixed, so no separator is ambiguous.
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// withFingerprint prepends a fingerprint comment to content when w's format has comments.
+func withFingerprint(w OutputWriter, content, fingerprint string) string {
+	commenter, ok := w.(CommentWriter)
+	if fingerprint == "" || !ok {
+		return content
+	}
+	return commenter.Comment("fingerprint: "+fingerprint) + content
+}
+
+// checkVersionVar validates the name of the environment variable a task definition carries its fingerprint in.
+func checkVersionVar(name string) error {
+	if name != "" && !envNamePattern.MatchString(name) {
+		return validationErrorf("-version-var %q is not a valid environment variable name", name)
+	}
+	return nil
+}
+
+// withVersionVar sets the environment variable name to the fingerprint of the secrets in every container of
+// taskDef. Registering the task definition then changes the containers, and so forces a new ECS deployment,
+// only when a key or value changed. An empty name leaves taskDef unchanged.
+func withVersionVar(taskDef *TaskDefinition, name string) {
+	if name == "" {
+		return
+	}
+	for i := range taskDef.ContainerDefinitions {
+		c := &taskDef.ContainerDefinitions[i]
+		vars := make([]EnvVar, len(c.Secrets))
+		for j, s := range c.Secrets {
+			vars[j] = EnvVar{Key: s.Name, Value: s.Value}
+		}
+		env := Environment{Name: name, Value: Fingerprint(vars)}
+		if j := slices.IndexFunc(c.Environment, func(e Environment) bool { return e.Name == name }); j >= 0 {
+			c.Environment[j] = env
+		} else {
+			c.Environment = append(c.Environment, env)
+		}
+	}
+}
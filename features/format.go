@@ -0,0 +1,309 @@
+package features
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/BurntSushi/toml"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Output formats for the parameters written by get -s, get-by-prefix and export.
+const (
+	FormatEnv     = "env"     // KEY=value lines, values written as-is.
+	FormatSystemd = "systemd" // systemd EnvironmentFile= with quoting where needed.
+	FormatEnvdir  = "envdir"  // daemontools/runit envdir: one file per variable.
+
+	FormatProperties  = "properties"  // Java .properties; "/" in keys becomes ".".
+	FormatAppsettings = "appsettings" // .NET appsettings.json; keys nest on "__" and "/".
+	FormatTOML        = "toml"        // TOML; keys nest into tables on "/". Also an import format.
+	FormatHCL         = "hcl"         // HCL; keys nest into blocks on "/". Also an import format.
+)
+
+// EnvVar is one variable of an environment output, kept in order.
+type EnvVar struct {
+	Key   string
+	Value string
+}
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// systemdSafeValue matches values systemd reads back unchanged without quotes.
+var systemdSafeValue = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,=-]*$`)
+
+// RenderEnv renders vars as text in format. envdir is not a text format and is rejected,
+// as are the formats only export can produce.
+func RenderEnv(format string, vars []EnvVar) (string, error) {
+	return renderOutput(format, envParams(vars), ExportOptions{}, false)
+}
+
+// envWriter writes KEY=value lines, values as-is.
+type envWriter struct{ hashComments }
+
+func (envWriter) Extension() string { return ".env" }
+
+func (envWriter) Render(params []OutputParam, _ ExportOptions) (string, error) {
+	var b strings.Builder
+	for _, p := range params {
+		fmt.Fprintf(&b, "%s=%s\n", p.Key, p.Value)
+	}
+	return b.String(), nil
+}
+
+// systemdWriter writes a systemd EnvironmentFile=, quoting values systemd would otherwise alter.
+type systemdWriter struct{ hashComments }
+
+func (systemdWriter) Extension() string { return ".env" }
+
+func (systemdWriter) Render(params []OutputParam, _ ExportOptions) (string, error) {
+	var b strings.Builder
+	for _, p := range params {
+		if !envNamePattern.MatchString(p.Key) {
+			return "", validationErrorf("%q is not a valid variable name for systemd", p.Key)
+		}
+		fmt.Fprintf(&b, "%s=%s\n", p.Key, systemdQuote(p.Value))
+	}
+	return b.String(), nil
+}
+
+// envdirWriter writes a daemontools/runit envdir (see writeEnvdir).
+type envdirWriter struct{}
+
+func (envdirWriter) Extension() string { return "" }
+
+func (envdirWriter) Render([]OutputParam, ExportOptions) (string, error) {
+	return "", validationErrorf("format %s writes a directory; use -o", FormatEnvdir)
+}
+
+func (envdirWriter) WriteDir(dir string, params []OutputParam) error {
+	return writeEnvdir(dir, outputVars(params))
+}
+
+// propertiesWriter writes Java .properties, with "/" in keys turned into ".".
+type propertiesWriter struct{ hashComments }
+
+func (propertiesWriter) Extension() string { return ".properties" }
+
+func (propertiesWriter) Render(params []OutputParam, _ ExportOptions) (string, error) {
+	var b strings.Builder
+	for _, p := range params {
+		key := strings.ReplaceAll(strings.Trim(p.Key, "/"), "/", ".")
+		fmt.Fprintf(&b, "%s=%s\n", propertiesEscape(key, true), propertiesEscape(p.Value, false))
+	}
+	return b.String(), nil
+}
+
+// appsettingsWriter writes .NET appsettings.json (see renderAppsettings).
+type appsettingsWriter struct{}
+
+// Extension is not .json, which get-by-prefix uses for the task definition.
+func (appsettingsWriter) Extension() string { return ".appsettings.json" }
+
+func (appsettingsWriter) Render(params []OutputParam, _ ExportOptions) (string, error) {
+	return renderAppsettings(outputVars(params))
+}
+
+// tomlWriter writes TOML, nesting keys into tables on "/".
+type tomlWriter struct{ hashComments }
+
+func (tomlWriter) Extension() string { return ".toml" }
+
+func (tomlWriter) Render(params []OutputParam, _ ExportOptions) (string, error) {
+	tree, err := nestVars(FormatTOML, outputVars(params), splitPath)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := toml.NewEncoder(&b).Encode(tree); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// hclWriter writes HCL (see renderHCL).
+type hclWriter struct{ hashComments }
+
+func (hclWriter) Extension() string { return ".hcl" }
+
+func (hclWriter) Render(params []OutputParam, _ ExportOptions) (string, error) {
+	return renderHCL(outputVars(params))
+}
+
+// systemdQuote double-quotes value when systemd would otherwise alter it, escaping the characters
+// systemd unescapes inside double quotes. Newlines are kept literally, which systemd accepts in quotes.
+func systemdQuote(value string) string {
+	if systemdSafeValue.MatchString(value) {
+		return value
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", `$`, `\$`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// propertiesEscape escapes s the way java.util.Properties.store does, so Properties.load reads it back
+// exactly: separators and comment characters are backslash-escaped, and control and non-ASCII characters
+// become \uXXXX because .properties files are read as ISO-8859-1.
+func propertiesEscape(s string, isKey bool) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r == ' ':
+			if isKey || i == 0 {
+				b.WriteString(`\ `)
+			} else {
+				b.WriteRune(r)
+			}
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\f':
+			b.WriteString(`\f`)
+		case strings.ContainsRune(`\=:#!`, r):
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			for _, unit := range utf16.Encode([]rune{r}) {
+				fmt.Fprintf(&b, `\u%04X`, unit)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// splitPath splits a parameter key into its path segments.
+func splitPath(key string) []string {
+	return strings.FieldsFunc(key, func(r rune) bool { return r == '/' })
+}
+
+// nestVars builds a tree of nested maps from vars, splitting each key with split.
+// Leaves are strings. A key that is both a value and a section is an error.
+func nestVars(format string, vars []EnvVar, split func(string) []string) (map[string]interface{}, error) {
+	root := make(map[string]interface{})
+	for _, v := range vars {
+		parts := split(v.Key)
+		if len(parts) == 0 {
+			return nil, validationErrorf("%q is not a valid %s key", v.Key, format)
+		}
+		if err := insertTree(root, parts, v.Value); err != nil {
+			return nil, err
+		}
+	}
+	return root, nil
+}
+
+// renderAppsettings nests vars into a JSON object the way .NET configuration maps environment variables:
+// DB__Host becomes {"DB": {"Host": ...}}.
+func renderAppsettings(vars []EnvVar) (string, error) {
+	root, err := nestVars(FormatAppsettings, vars, func(key string) []string {
+		return splitPath(strings.ReplaceAll(key, "__", "/"))
+	})
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+// renderHCL writes vars as HCL attributes, with one block per path segment: db/host becomes db { host = "..." }.
+func renderHCL(vars []EnvVar) (string, error) {
+	tree, err := nestVars(FormatHCL, vars, splitPath)
+	if err != nil {
+		return "", err
+	}
+	file := hclwrite.NewEmptyFile()
+	if err := writeHCLBody(file.Body(), tree); err != nil {
+		return "", err
+	}
+	return string(file.Bytes()), nil
+}
+
+// writeHCLBody writes tree into body: attributes first, then blocks, each sorted by name.
+func writeHCLBody(body *hclwrite.Body, tree map[string]interface{}) error {
+	var names []string
+	for name := range tree {
+		if !hclsyntax.ValidIdentifier(name) {
+			return validationErrorf("%q is not a valid HCL identifier", name)
+		}
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		_, iBlock := tree[names[i]].(map[string]interface{})
+		_, jBlock := tree[names[j]].(map[string]interface{})
+		if iBlock != jBlock {
+			return jBlock
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		switch node := tree[name].(type) {
+		case string:
+			body.SetAttributeValue(name, cty.StringVal(node))
+		case map[string]interface{}:
+			body.AppendNewline()
+			if err := writeHCLBody(body.AppendNewBlock(name, nil).Body(), node); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// OutputBase turns the -o value into the base path writers append their extensions to: a trailing
+// extension of any known format is dropped, so -o app.env and -o app name the same files. With a
+// non-empty timestampLayout (a Go time layout such as "2006-01-02") the current time is appended.
+func OutputBase(output, timestampLayout string) string {
+	var longest string
+	for _, format := range FormatNames() {
+		if ext := formatExtension(format); ext != "" && strings.HasSuffix(output, ext) && len(ext) > len(longest) {
+			longest = ext
+		}
+	}
+	base := strings.TrimSuffix(output, longest)
+	if timestampLayout != "" {
+		base += "-" + time.Now().Format(timestampLayout)
+	}
+	return base
+}
+
+// WriteEnv writes vars in format under base and returns the path written: base plus the format's
+// extension for the text formats, or the directory base for envdir.
+func WriteEnv(format, base string, vars []EnvVar) (string, error) {
+	return writeOutput(format, base, envParams(vars), ExportOptions{}, false)
+}
+
+// writeEnvdir writes one file per variable into dir. envdir reads only the first line of each file
+// and turns NUL bytes into newlines, so newlines in values are stored as NUL.
+func writeEnvdir(dir string, vars []EnvVar) error {
+	for _, v := range vars {
+		if v.Key == "" || v.Key == "." || v.Key == ".." || strings.ContainsAny(v.Key, "/=\x00") {
+			return validationErrorf("%q is not a valid envdir file name", v.Key)
+		}
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create envdir %s: %w", dir, err)
+	}
+	for _, v := range vars {
+		path := filepath.Join(dir, v.Key)
+		value := strings.ReplaceAll(v.Value, "\n", "\x00")
+		if err := os.WriteFile(path, []byte(value+"\n"), 0600); err != nil {
+			return fmt.Errorf("failed to write envdir file %s: %w", path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package features
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// checkPerm reports an error if path does not have the permissions want. Windows has no such modes.
+func checkPerm(t *testing.T, path string, want os.FileMode) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Error(err)
+	} else if info.Mode().Perm() != want {
+		t.Errorf("%s mode = %v; want %v", path, info.Mode().Perm(), want)
+	}
+}
+
+func TestRenderEnvSystemd(t *testing.T) {
+	vars := []EnvVar{
+		{"PLAIN", "postgres://db:5432/app"},
+		{"EMPTY", ""},
+		{"SPACES", "hello world"},
+		{"SPECIAL", `a"b\c$d` + "`e"},
+		{"MULTI", "line1\nline2"},
+	}
+	got, err := RenderEnv(FormatSystemd, vars)
+	if err != nil {
+		t.Fatalf("RenderEnv: %v", err)
+	}
+	want := "PLAIN=postgres://db:5432/app\n" +
+		"EMPTY=\n" +
+		"SPACES=\"hello world\"\n" +
+		"SPECIAL=\"a\\\"b\\\\c\\$d\\`e\"\n" +
+		"MULTI=\"line1\nline2\"\n"
+	if got != want {
+		t.Errorf("RenderEnv(systemd) =\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := RenderEnv(FormatSystemd, []EnvVar{{"db/url", "x"}}); !errors.Is(err, ErrValidation) {
+		t.Errorf("invalid name err = %v; want ErrValidation", err)
+	}
+}
+
+func TestWriteEnvdir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "app")
+	path, err := WriteEnv(FormatEnvdir, dir, []EnvVar{{"DB_URL", "postgres://db"}, {"CERT", "a\nb"}})
+	if err != nil || path != dir {
+		t.Fatalf("WriteEnv = %q, %v", path, err)
+	}
+	for name, want := range map[string]string{"DB_URL": "postgres://db\n", "CERT": "a\x00b\n"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v; want %q", name, data, err, want)
+		}
+		checkPerm(t, filepath.Join(dir, name), 0600)
+	}
+	checkPerm(t, dir, 0700)
+
+	if _, err := WriteEnv(FormatEnvdir, dir, []EnvVar{{"../escape", "x"}}); !errors.Is(err, ErrValidation) {
+		t.Errorf("path traversal err = %v; want ErrValidation", err)
+	}
+}
+
+func TestWriteEnvPermissions(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "app")
+	path, err := WriteEnv(FormatEnv, base, []EnvVar{{"API_KEY", "s3cret"}})
+	if err != nil {
+		t.Fatalf("WriteEnv: %v", err)
+	}
+	checkPerm(t, path, 0600)
+}
+
+func TestRenderEnvProperties(t *testing.T) {
+	got, err := RenderEnv(FormatProperties, []EnvVar{
+		{"db/url", "jdbc:postgresql://db:5432/app"},
+		{"greeting", " hello = world # not a comment"},
+		{"multi", "a\nb"},
+		{"name", "café"},
+	})
+	if err != nil {
+		t.Fatalf("RenderEnv: %v", err)
+	}
+	want := "db.url=jdbc\\:postgresql\\://db\\:5432/app\n" +
+		"greeting=\\ hello \\= world \\# not a comment\n" +
+		"multi=a\\nb\n" +
+		"name=caf\\u00E9\n"
+	if got != want {
+		t.Errorf("RenderEnv(properties) =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderEnvAppsettings(t *testing.T) {
+	got, err := RenderEnv(FormatAppsettings, []EnvVar{
+		{"ConnectionStrings__Default", "Server=db"},
+		{"Logging__LogLevel__Default", "Information"},
+		{"AllowedHosts", "*"},
+	})
+	if err != nil {
+		t.Fatalf("RenderEnv: %v", err)
+	}
+	want := `{
+  "AllowedHosts": "*",
+  "ConnectionStrings": {
+    "Default": "Server=db"
+  },
+  "Logging": {
+    "LogLevel": {
+      "Default": "Information"
+    }
+  }
+}
+`
+	if got != want {
+		t.Errorf("RenderEnv(appsettings) =\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := RenderEnv(FormatAppsettings, []EnvVar{{"DB", "x"}, {"DB__Host", "y"}}); !errors.Is(err, ErrValidation) {
+		t.Errorf("conflicting keys err = %v; want ErrValidation", err)
+	}
+}
+
+func TestOutputBase(t *testing.T) {
+	for in, want := range map[string]string{
+		"env":                  "env",
+		"env.env":              "env",
+		"out/app.secret.yaml":  "out/app",
+		"app.appsettings.json": "app",
+		"app.txt":              "app.txt",
+	} {
+		if got := OutputBase(in, ""); got != want {
+			t.Errorf("OutputBase(%q) = %q; want %q", in, got, want)
+		}
+	}
+	if got := OutputBase("env.env", "2006"); len(got) != len("env-2006") || got[:4] != "env-" {
+		t.Errorf("OutputBase with layout = %q", got)
+	}
+}
@@ -0,0 +1,124 @@
+package features
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// EphemeralTag is the tag bench puts on its parameters, so gc can remove those a failed run left behind.
+const EphemeralTag = "purpose=ephemeral"
+
+// GCOptions configures gc.
+type GCOptions struct {
+	Tag       string        // key=value the parameters to delete carry, e.g. purpose=ephemeral.
+	OlderThan time.Duration // Only parameters not modified for this long are deleted.
+	Prefix    string        // When set, only parameters under this path are considered.
+	AssumeYes bool          // Delete without asking.
+}
+
+// parseTag parses a key=value tag.
+func parseTag(s string) (types.Tag, error) {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || key == "" || value == "" {
+		return types.Tag{}, validationErrorf("invalid tag %q: want key=value, such as %s", s, EphemeralTag)
+	}
+	return types.Tag{Key: aws.String(key), Value: aws.String(value)}, nil
+}
+
+// CollectGarbage deletes the parameters tagged opts.Tag that were not modified for opts.OlderThan, such as
+// the synthetic parameters of CI runs and benchmarks, so test prefixes do not accumulate them. The parameters
+// are listed, oldest first, and deleted after confirmation. Only metadata is read; a failed delete is
+// reported and the others continue, and failures are returned as a PartialFailureError.
+func CollectGarbage(client SSMClient, opts GCOptions) error {
+	if opts.Tag == "" {
+		return validationErrorf("gc needs the -tag of the parameters to delete, such as -tag %s", EphemeralTag)
+	}
+	tag, err := parseTag(opts.Tag)
+	if err != nil {
+		return err
+	}
+	if opts.OlderThan <= 0 {
+		return validationErrorf("gc needs an -older-than age, such as 24h")
+	}
+	filters := []types.ParameterStringFilter{{Key: aws.String("tag:" + aws.ToString(tag.Key)), Values: []string{aws.ToString(tag.Value)}}}
+	scope := "tagged " + opts.Tag
+	if opts.Prefix != "" {
+		if !strings.HasPrefix(opts.Prefix, "/") {
+			return validationErrorf("-prefix must be a path starting with /, got %q", opts.Prefix)
+		}
+		filters = append(filters, types.ParameterStringFilter{Key: aws.String("Path"), Option: aws.String("Recursive"), Values: []string{strings.TrimSuffix(opts.Prefix, "/")}})
+		scope += " under " + opts.Prefix
+	}
+	metadata, err := describeParameters(client, filters[0], filters[1:]...)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var expired []types.ParameterMetadata
+	for _, meta := range metadata {
+		if meta.LastModifiedDate != nil && now.Sub(*meta.LastModifiedDate) > opts.OlderThan {
+			expired = append(expired, meta)
+		}
+	}
+	if len(expired) == 0 {
+		Infof("No parameters %s are older than %s (%d in total)\n", scope, formatAge(opts.OlderThan), len(metadata))
+		return nil
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		if !expired[i].LastModifiedDate.Equal(*expired[j].LastModifiedDate) {
+			return expired[i].LastModifiedDate.Before(*expired[j].LastModifiedDate)
+		}
+		return aws.ToString(expired[i].Name) < aws.ToString(expired[j].Name)
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tLAST MODIFIED\tAGE")
+	for _, meta := range expired {
+		modified := aws.ToTime(meta.LastModifiedDate)
+		fmt.Fprintf(w, "%s\t%s\t%s\n", aws.ToString(meta.Name), modified.UTC().Format(time.RFC3339), formatAge(now.Sub(modified)))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if !opts.AssumeYes && !isDryRun(client) && !Confirm(fmt.Sprintf("Delete the %d parameters %s older than %s?", len(expired), scope, formatAge(opts.OlderThan))) {
+		Infof("Nothing deleted\n")
+		return nil
+	}
+	deletes := make([]Change, len(expired))
+	for i, meta := range expired {
+		deletes[i] = Change{Operation: "DeleteParameter", Name: aws.ToString(meta.Name)}
+	}
+	if err := planChanges(client, deletes); err != nil {
+		return err
+	}
+
+	var failures []error
+	for _, meta := range expired {
+		name := aws.ToString(meta.Name)
+		if _, err := client.DeleteParameter(context.TODO(), &ssm.DeleteParameterInput{Name: meta.Name}); err != nil {
+			err = wrapClientError(client, "DeleteParameter", name, err)
+			Infof("%s %s: %s\n", red("Failed to delete"), name, DescribeError(err))
+			failures = append(failures, err)
+			continue
+		}
+		if !isDryRun(client) {
+			Infof("Deleted %s\n", name)
+		}
+	}
+	if len(failures) > 0 {
+		return &PartialFailureError{Failed: failures, Total: len(expired)}
+	}
+	if !isDryRun(client) {
+		Infof("%s %d parameters %s\n", green("Deleted"), len(expired), scope)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package features
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestCollectGarbage(t *testing.T) {
+	fake := newFakeSSM()
+	now := time.Now()
+	for name, p := range map[string]struct {
+		tag string
+		age time.Duration
+	}{
+		"/ci/old":        {"ephemeral", 48 * time.Hour},
+		"/ci/new":        {"ephemeral", time.Hour},
+		"/ci/kept":       {"permanent", 48 * time.Hour},
+		"/other/old":     {"ephemeral", 72 * time.Hour},
+		"/ci/untagged/x": {"", 48 * time.Hour},
+	} {
+		fake.set(name, "v", types.ParameterTypeString)
+		fake.meta[name] = types.ParameterMetadata{Name: aws.String(name), LastModifiedDate: aws.Time(now.Add(-p.age))}
+		if p.tag != "" {
+			fake.tags[name] = []types.Tag{{Key: aws.String("purpose"), Value: aws.String(p.tag)}}
+		}
+	}
+
+	if err := CollectGarbage(fake, GCOptions{Tag: "purpose=ephemeral", OlderThan: 24 * time.Hour, Prefix: "/ci/", AssumeYes: true}); err != nil {
+		t.Fatalf("CollectGarbage: %v", err)
+	}
+	if _, ok := fake.params["/ci/old"]; ok || len(fake.params) != 4 {
+		t.Errorf("parameters left = %v; want only /ci/old deleted", fake.params)
+	}
+
+	if err := CollectGarbage(NewDryRunClient(fake), GCOptions{Tag: "purpose=ephemeral", OlderThan: 24 * time.Hour}); err != nil {
+		t.Fatalf("dry-run CollectGarbage: %v", err)
+	}
+	if _, ok := fake.params["/other/old"]; !ok {
+		t.Error("dry run deleted /other/old")
+	}
+
+	withStdin(t, "n\n")
+	if err := CollectGarbage(fake, GCOptions{Tag: "purpose=ephemeral", OlderThan: 24 * time.Hour}); err != nil {
+		t.Fatalf("declined CollectGarbage: %v", err)
+	}
+	if _, ok := fake.params["/other/old"]; !ok {
+		t.Error("declined gc deleted /other/old")
+	}
+
+	for _, opts := range []GCOptions{
+		{OlderThan: time.Hour},
+		{Tag: "purpose", OlderThan: time.Hour},
+		{Tag: "purpose=ephemeral"},
+		{Tag: "purpose=ephemeral", OlderThan: time.Hour, Prefix: "ci"},
+	} {
+		if err := CollectGarbage(fake, opts); !errors.Is(err, ErrValidation) {
+			t.Errorf("CollectGarbage(%+v) = %v; want a validation error", opts, err)
+		}
+	}
+}
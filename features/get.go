@@ -3,119 +3,256 @@ package features
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"strings"
-	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
 )
 
+// GetFileOptions controls what GetParametersFromFile resolves and writes.
+type GetFileOptions struct {
+	// Export selects the formats, fingerprint and transforms of the environment, which is written by the
+	// same pipeline as export and get-by-prefix; its Output is the outputPrefix argument.
+	Export  ExportOptions
+	Keys    []string // Resolve only the secrets with these names; the others are not fetched. Empty resolves all.
+	Rewrite string   // How the task definition JSON is saved: RewriteDefault, RewriteClean or RewritePreserve.
+	// Canonical saves the task definition canonically (see canonicalTaskDef); it cannot be combined with RewritePreserve.
+	Canonical bool
+	Missing   string // What to do about parameters that do not exist: MissingError (default), MissingSkip or MissingPlaceholder.
+}
+
+// Handling of referenced parameters that do not exist, for GetFileOptions.Missing.
+const (
+	MissingError       = "error"       // Report them and fail with a PartialFailureError.
+	MissingSkip        = "skip"        // Leave them out of the environment, with a warning.
+	MissingPlaceholder = "placeholder" // Write the secret's "default", or <MISSING:/path>.
+)
+
 // getParametersFromFile reads an ECS task definition JSON file and retrieves all SSM parameters referenced in the secrets.
 // It parses the JSON, extracts parameter ARNs, fetches values and types, and either prints them or saves to files.
-func GetParametersFromFile(client *ssm.Client, filename, outputPrefix string) error {
+// Placeholders like {{env}} in valueFrom are expanded from tmplOpts, and the saved JSON contains the expanded paths.
+// The environment is printed, or saved in each of opts.Export.Formats under the output base outputPrefix (see
+// OutputBase) as export saves it, with the task definition saved as <outputPrefix>.json.
+func GetParametersFromFile(client SSMClient, filename, outputPrefix string, tmplOpts TemplateOptions, opts GetFileOptions) error {
+	if opts.Canonical && opts.Rewrite == RewritePreserve {
+		return validationErrorf("canonical output reorders the file; it cannot be combined with preserving it")
+	}
+	switch opts.Missing {
+	case "", MissingError, MissingSkip, MissingPlaceholder:
+	default:
+		return validationErrorf("invalid -missing %q: use %s, %s or %s", opts.Missing, MissingError, MissingSkip, MissingPlaceholder)
+	}
+	exportOpts := opts.Export
+	exportOpts.Output = outputPrefix
+	if err := checkExportOptions(exportOpts); err != nil {
+		return err
+	}
+	jsonFile := outputPrefix + ".json"
+	if outputPrefix != "" {
+		for _, format := range exportOpts.Formats {
+			if formatExtension(format) == ".json" {
+				return validationErrorf("format %s would overwrite the task definition saved to %s", format, jsonFile)
+			}
+		}
+	}
+
 	// Read the entire JSON file into memory.
-	data, err := os.ReadFile(filename)
+	data, err := readSource(filename)
 	if err != nil {
 		return err
 	}
 
+	if err := validateTemplate(data, filename); err != nil {
+		return err
+	}
+
 	// Unmarshal the JSON data into a map to preserve all fields.
 	var jsonMap map[string]interface{}
 	err = json.Unmarshal(data, &jsonMap)
 	if err != nil {
-		return err
+		return validationErrorf("failed to parse %s: %w", filename, err)
+	}
+	jsonMap = unwrapTaskDef(jsonMap) // describe-task-definition output is saved unwrapped, except with RewritePreserve.
+	if opts.Rewrite == RewriteClean {
+		stripReadOnlyFields(jsonMap)
 	}
 
 	// Navigate to containerDefinitions[0].secrets
 	containerDefs, ok := jsonMap["containerDefinitions"].([]interface{})
 	if !ok || len(containerDefs) == 0 {
-		return fmt.Errorf("no container definitions found")
+		return validationErrorf("no container definitions found")
 	}
 	containerDef, ok := containerDefs[0].(map[string]interface{})
 	if !ok {
-		return fmt.Errorf("invalid container definition")
+		return validationErrorf("invalid container definition")
 	}
 	secretsInterface, ok := containerDef["secrets"].([]interface{})
 	if !ok {
-		return fmt.Errorf("no secrets found")
+		return validationErrorf("no secrets found")
+	}
+
+	if err := reportDuplicates(filename, findDuplicates(templateSecretLines(data)), tmplOpts.Strict); err != nil {
+		return err
+	}
+	last := make(map[string]int) // Index of the last secret with each name; earlier duplicates are ignored.
+	for i, sec := range secretsInterface {
+		if secret, ok := sec.(map[string]interface{}); ok {
+			if name, ok := secret["name"].(string); ok {
+				last[name] = i
+			}
+		}
+	}
+	selected := make(map[string]bool)
+	for _, key := range opts.Keys {
+		if _, ok := last[key]; !ok {
+			return validationErrorf("secret %s not found in %s", key, filename)
+		}
+		selected[key] = true
 	}
 
-	envMap := make(map[string]string) // For saving to .env if outputPrefix is provided.
+	var resolved []OutputParam           // The resolved environment, in template order.
+	var failures []error                 // Secrets that could not be resolved.
+	patches := make(map[int][]jsonField) // Fields to set in the raw JSON with RewritePreserve, by secret index.
 
 	// Iterate over the secrets.
-	for _, sec := range secretsInterface {
+	for i, sec := range secretsInterface {
 		secret, ok := sec.(map[string]interface{})
 		if !ok {
 			continue
 		}
 		name, ok := secret["name"].(string)
-		if !ok {
+		if !ok || last[name] != i || len(selected) > 0 && !selected[name] {
 			continue
 		}
 		valueFrom, ok := secret["valueFrom"].(string)
 		if !ok {
 			continue
 		}
+		valueFrom, err := ExpandTemplateVars(valueFrom, tmplOpts.Vars)
+		if err != nil {
+			Infof("Invalid valueFrom for %s: %v\n", name, err)
+			failures = append(failures, err)
+			continue
+		}
+		if tmplOpts.isLogicalName(valueFrom) {
+			// Written back as the path, which is what ECS needs.
+			if valueFrom, err = tmplOpts.ParameterPath(valueFrom); err != nil {
+				Infof("Invalid valueFrom for %s: %v\n", name, err)
+				failures = append(failures, err)
+				continue
+			}
+		}
+		if valueFrom != secret["valueFrom"] {
+			patches[i] = append(patches[i], jsonField{"valueFrom", valueFrom})
+		}
+		secret["valueFrom"] = valueFrom
+		if err := checkARNScope(valueFrom, tmplOpts); err != nil {
+			Infof("Skipping %s: %v\n", name, err)
+			failures = append(failures, err)
+			continue
+		}
 		// Extract the parameter name from the ARN.
 		paramName := ExtractParameterName(valueFrom)
 		if paramName == "" {
-			fmt.Printf("Invalid ARN for %s: %s\n", name, valueFrom)
+			Infof("Invalid ARN for %s: %s\n", name, valueFrom)
+			failures = append(failures, validationErrorf("invalid ARN for %s: %s", name, valueFrom))
 			continue
 		}
 		// Fetch the parameter value and type from SSM.
 		val, typ, err := GetParameter(client, paramName)
+		if errors.Is(err, ErrNotFound) && opts.Missing == MissingSkip {
+			Infof("%s %s: %s does not exist\n", yellow("Skipped"), name, paramName)
+			continue
+		}
+		if errors.Is(err, ErrNotFound) && opts.Missing == MissingPlaceholder {
+			// Only in the environment: the saved task definition gets no value, so it is never put.
+			placeholder, ok := secret["default"].(string)
+			if !ok {
+				placeholder = "<MISSING:" + paramName + ">"
+			}
+			Infof("%s %s: %s does not exist; wrote %s\n", yellow("Missing"), name, paramName, placeholder)
+			resolved = append(resolved, OutputParam{Key: name, Name: paramName, Value: placeholder, Type: StringType})
+			continue
+		}
 		if err != nil {
-			fmt.Printf("Failed to get %s: %v\n", name, err)
+			Infof("%s %s: %s\n", red("Failed to get"), name, DescribeError(err))
+			failures = append(failures, err)
 			continue
 		}
 		// Add value and type to the secret map.
 		secret["value"] = val
 		secret["type"] = string(typ)
-
-		if outputPrefix != "" {
-			// Collect for .env output.
-			envMap[name] = val
-		} else {
-			// Print the result in environment variable format.
-			fmt.Printf("%s=%s\n", name, val)
-		}
+		patches[i] = append(patches[i], jsonField{"value", val}, jsonField{"type", string(typ)})
+		resolved = append(resolved, OutputParam{Key: name, Name: paramName, Value: val, Type: typ})
 	}
 
-	// If outputPrefix is provided, save to .env and .json files.
-	if outputPrefix != "" {
-		// Save .env file with date.
-		dateStr := time.Now().Format("020106") // ddmmyy format.
-		envFile := fmt.Sprintf("%s-%s.env", outputPrefix, dateStr)
-		var content strings.Builder
-		for key, value := range envMap {
-			content.WriteString(fmt.Sprintf("%s=%s\n", key, value))
-		}
-		err = os.WriteFile(envFile, []byte(content.String()), 0644)
-		if err != nil {
-			return fmt.Errorf("failed to write .env file %s: %w", envFile, err)
+	family, _ := jsonMap["family"].(string) // Names the k8s Secret, as the prefix does for export.
+	if outputPrefix == "" {
+		// Print the environment.
+		if err := writeExport(resolved, family, exportOpts); err != nil {
+			return err
+		}
+	} else {
+		// Save the environment and the modified JSON next to each other.
+		if sameFile(jsonFile, filename) {
+			return validationErrorf("-o %s would overwrite the source file %s", outputPrefix, filename)
+		}
+		if err := writeExport(resolved, family, exportOpts); err != nil {
+			return err
 		}
-		fmt.Printf("Saved bulk env to %s\n", envFile)
 
 		// Save modified JSON file.
-		jsonFile := fmt.Sprintf("%s-%s.json", outputPrefix, dateStr)
-		jsonData, err := json.MarshalIndent(jsonMap, "", "  ")
-		if err != nil {
+		var jsonData []byte
+		if opts.Rewrite == RewritePreserve {
+			jsonData = patchSecrets(data, patches)
+		} else if jsonData, err = marshalTaskDef(jsonMap, opts.Canonical); err != nil {
 			return fmt.Errorf("failed to marshal JSON: %w", err)
 		}
-		err = os.WriteFile(jsonFile, jsonData, 0644)
+		err = os.WriteFile(jsonFile, jsonData, 0600)
 		if err != nil {
 			return fmt.Errorf("failed to write JSON file %s: %w", jsonFile, err)
 		}
-		fmt.Printf("Saved modified task definition to %s\n", jsonFile)
+		Infof("Saved modified task definition to %s\n", jsonFile)
 	}
 
+	if len(failures) > 0 {
+		total := len(secretsInterface)
+		if len(selected) > 0 {
+			total = len(selected)
+		}
+		return &PartialFailureError{Failed: failures, Total: total}
+	}
 	return nil
 }
 
+// sameFile reports whether a and b name the same existing file.
+func sameFile(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+// PrintParameter writes the value of the parameter name as "Parameter name: value" and a newline, or with
+// raw exactly the stored bytes, so VAR=$(salter-aws -action get -raw ...) and multi-line values round-trip.
+func PrintParameter(w io.Writer, name, value string, raw bool) {
+	if raw {
+		fmt.Fprint(w, value)
+		return
+	}
+	fmt.Fprintf(w, "Parameter %s: %s\n", name, value)
+}
+
 // getParameter retrieves a single parameter from AWS SSM, with decryption enabled for SecureStrings.
-func GetParameter(client *ssm.Client, name string) (string, ParameterType, error) {
+func GetParameter(client SSMClient, name string) (string, ParameterType, error) {
+	value, paramType, _, err := getParameterWithVersion(client, name)
+	return value, paramType, err
+}
+
+// getParameterWithVersion is GetParameter that also returns the parameter version.
+func getParameterWithVersion(client SSMClient, name string) (string, ParameterType, int64, error) {
 	// Prepare the input for the GetParameter API call.
 	input := &ssm.GetParameterInput{
 		Name:           aws.String(name),
@@ -125,7 +262,7 @@ func GetParameter(client *ssm.Client, name string) (string, ParameterType, error
 	// Call the SSM API to get the parameter.
 	result, err := client.GetParameter(context.TODO(), input)
 	if err != nil {
-		return "", "", err
+		return "", "", 0, wrapClientError(client, "GetParameter", name, err)
 	}
 
 	// Determine the parameter type.
@@ -141,102 +278,22 @@ func GetParameter(client *ssm.Client, name string) (string, ParameterType, error
 		paramType = StringType
 	}
 
-	// Return the decrypted parameter value and type.
-	return *result.Parameter.Value, paramType, nil
+	// Return the decrypted parameter value, type and version.
+	return *result.Parameter.Value, paramType, result.Parameter.Version, nil
 }
 
-// getParametersByPrefix retrieves all parameters under a specified prefix from AWS SSM and saves them to a .env file and a task-definition JSON.
-// Parameter names are stripped of the prefix for the key in .env, but full names used in JSON.
-func GetParametersByPrefix(client *ssm.Client, prefix, outputBase string) error {
-	// Build the content for the .env file and collect secrets for JSON.
-	var envContent strings.Builder
-	var secrets []ExtendedSecret
-
-	// Paginate through all parameters under the prefix.
-	var nextToken *string
-	for {
-		// Prepare the input for the GetParametersByPath API call.
-		input := &ssm.GetParametersByPathInput{
-			Path:           aws.String(prefix),
-			Recursive:      aws.Bool(true),
-			WithDecryption: aws.Bool(true), // Decrypt SecureString parameters.
-			NextToken:      nextToken,
-			MaxResults:     aws.Int32(10), // Max allowed is 10.
-		}
-
-		// Call the SSM API to get parameters by path.
-		result, err := client.GetParametersByPath(context.TODO(), input)
-		if err != nil {
-			return err
-		}
-
-		// Process the parameters.
-		for _, param := range result.Parameters {
-			name := *param.Name
-			// Strip the prefix from the parameter name to create the key for .env.
-			key := strings.TrimPrefix(name, prefix)
-			if key == name {
-				// If prefix not found, use the full name (though unlikely).
-				key = name
-			}
-			value := *param.Value
-			envContent.WriteString(fmt.Sprintf("%s=%s\n", key, value))
-
-			// Determine the parameter type.
-			var paramType ParameterType
-			switch param.Type {
-			case "String":
-				paramType = StringType
-			case "StringList":
-				paramType = StringListType
-			case "SecureString":
-				paramType = SecureStringType
-			default:
-				paramType = StringType
-			}
-
-			// Create secret for JSON.
-			secret := ExtendedSecret{
-				Name:      key,
-				ValueFrom: name, // Full parameter name for valueFrom.
-				Type:      paramType,
-				Value:     value,
-			}
-			secrets = append(secrets, secret)
-		}
-
-		// Check if there are more pages.
-		if result.NextToken == nil {
-			break
-		}
-		nextToken = result.NextToken
-	}
-
-	// Write the .env file.
-	envFile := outputBase + ".env"
-	err := os.WriteFile(envFile, []byte(envContent.String()), 0644)
-	if err != nil {
-		return fmt.Errorf("failed to write .env file %s: %w", envFile, err)
-	}
-
-	// Create and write the task-definition JSON.
-	taskDef := TaskDefinition{
-		ContainerDefinitions: []ContainerDefinition{
-			{
-				Secrets: secrets,
-			},
-		},
+// getParametersByPrefix retrieves all parameters under a specified prefix from AWS SSM and saves them in opts.Formats and as a task-definition JSON.
+// Parameter names are stripped of the prefix for the env keys, but full names (or ARNs, per opts.Refs) used in JSON.
+// It is export with the task definition always included.
+func GetParametersByPrefix(client SSMClient, prefix string, opts ExportOptions) error {
+	formats := append([]string(nil), opts.Formats...)
+	hasTaskDef := false
+	for _, format := range formats {
+		hasTaskDef = hasTaskDef || format == FormatTaskDef
 	}
-	jsonData, err := json.MarshalIndent(taskDef, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal JSON: %w", err)
-	}
-	jsonFile := outputBase + ".json"
-	err = os.WriteFile(jsonFile, jsonData, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to write JSON file %s: %w", jsonFile, err)
+	if !hasTaskDef {
+		formats = append(formats, FormatTaskDef)
 	}
-
-	fmt.Printf("Saved .env to %s and task-definition JSON to %s\n", envFile, jsonFile)
-	return nil
+	opts.Formats = formats
+	return ExportParameters(client, prefix, opts)
 }
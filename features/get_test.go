@@ -0,0 +1,168 @@
+package features
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestGetParametersFromFileKeys(t *testing.T) {
+	dir := t.TempDir()
+	template := filepath.Join(dir, "task.json")
+	data := `{"containerDefinitions": [{"secrets": [
+  {"name": "DB_URL", "valueFrom": "/app/DB_URL"},
+  {"name": "UNUSED", "valueFrom": "/app/UNUSED"},
+  {"name": "REDIS_URL", "valueFrom": "/app/REDIS_URL"}
+]}]}`
+	if err := os.WriteFile(template, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeSSM()
+	fake.set("/app/DB_URL", "postgres://db", types.ParameterTypeString)
+	fake.set("/app/REDIS_URL", "redis://cache", types.ParameterTypeString)
+
+	// /app/UNUSED does not exist, so fetching it would be a partial failure.
+	base := filepath.Join(dir, "out")
+	if err := GetParametersFromFile(fake, template, base, TemplateOptions{}, GetFileOptions{Export: ExportOptions{Formats: []string{FormatEnv}}, Keys: []string{"REDIS_URL", "DB_URL"}}); err != nil {
+		t.Fatalf("get -keys: %v", err)
+	}
+	env, err := os.ReadFile(base + ".env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "DB_URL=postgres://db\nREDIS_URL=redis://cache\n"; string(env) != want {
+		t.Errorf("env = %q; want %q", env, want)
+	}
+
+	err = GetParametersFromFile(fake, template, "", TemplateOptions{}, GetFileOptions{Export: ExportOptions{Formats: []string{FormatEnv}}, Keys: []string{"MISSING"}})
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("get -keys MISSING error = %v; want ErrValidation", err)
+	}
+}
+
+func TestGetParametersFromFileMissing(t *testing.T) {
+	dir := t.TempDir()
+	template := filepath.Join(dir, "task.json")
+	data := `{"containerDefinitions": [{"secrets": [
+  {"name": "DB_URL", "valueFrom": "/app/DB_URL"},
+  {"name": "API_KEY", "valueFrom": "/app/API_KEY"},
+  {"name": "LOG_LEVEL", "valueFrom": "/app/LOG_LEVEL", "default": "info"}
+]}]}`
+	if err := os.WriteFile(template, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeSSM()
+	fake.set("/app/DB_URL", "postgres://db", types.ParameterTypeString)
+
+	tests := []struct {
+		missing string
+		env     string
+		partial bool
+	}{
+		{MissingError, "DB_URL=postgres://db\n", true},
+		{MissingSkip, "DB_URL=postgres://db\n", false},
+		{MissingPlaceholder, "DB_URL=postgres://db\nAPI_KEY=<MISSING:/app/API_KEY>\nLOG_LEVEL=info\n", false},
+	}
+	for _, tt := range tests {
+		base := filepath.Join(dir, tt.missing)
+		err := GetParametersFromFile(fake, template, base, TemplateOptions{}, GetFileOptions{Export: ExportOptions{Formats: []string{FormatEnv}}, Missing: tt.missing})
+		var partial *PartialFailureError
+		if errors.As(err, &partial) != tt.partial || err != nil && !tt.partial {
+			t.Errorf("-missing %s: err = %v; want partial failure %v", tt.missing, err, tt.partial)
+		}
+		env, err := os.ReadFile(base + ".env")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(env) != tt.env {
+			t.Errorf("-missing %s: env = %q; want %q", tt.missing, env, tt.env)
+		}
+		saved, err := os.ReadFile(base + ".json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(saved), "MISSING") {
+			t.Errorf("-missing %s: placeholder saved in the task definition:\n%s", tt.missing, saved)
+		}
+	}
+	if err := GetParametersFromFile(fake, template, "", TemplateOptions{}, GetFileOptions{Export: ExportOptions{Formats: []string{FormatEnv}}, Missing: "ignore"}); !errors.Is(err, ErrValidation) {
+		t.Errorf("-missing ignore: err = %v; want ErrValidation", err)
+	}
+}
+
+func TestGetParametersFromFileExportPipeline(t *testing.T) {
+	dir := t.TempDir()
+	template := filepath.Join(dir, "task.json")
+	data := `{"family": "Billing_API", "containerDefinitions": [{"secrets": [
+  {"name": "DB_URL", "valueFrom": "/app/DB_URL"},
+  {"name": "LOG_LEVEL", "valueFrom": "/app/LOG_LEVEL"}
+]}]}`
+	if err := os.WriteFile(template, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeSSM()
+	fake.set("/app/DB_URL", "postgres://db", types.ParameterTypeString)
+	fake.set("/app/LOG_LEVEL", "  INFO ", types.ParameterTypeString)
+
+	// Several formats and transforms, as with export; the task definition is saved next to them.
+	base := filepath.Join(dir, "out")
+	opts := GetFileOptions{Export: ExportOptions{
+		Formats:    []string{FormatEnv, FormatK8s},
+		Transforms: map[string][]string{"LOG_LEVEL": {TransformTrim, TransformLowercase}},
+	}}
+	if err := GetParametersFromFile(fake, template, base, TemplateOptions{}, opts); err != nil {
+		t.Fatalf("get -s: %v", err)
+	}
+	env, err := os.ReadFile(base + ".env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "DB_URL=postgres://db\nLOG_LEVEL=info\n"; string(env) != want {
+		t.Errorf("env = %q; want %q", env, want)
+	}
+	secret, err := os.ReadFile(base + ".secret.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(secret), "name: billing-api") {
+		t.Errorf("k8s Secret is not named after the family:\n%s", secret)
+	}
+	if _, err := os.Stat(base + ".json"); err != nil {
+		t.Errorf("task definition not saved: %v", err)
+	}
+
+	for _, formats := range [][]string{{FormatJSON}, {FormatTaskDef}} {
+		opts := GetFileOptions{Export: ExportOptions{Formats: formats}}
+		if err := GetParametersFromFile(fake, template, base, TemplateOptions{}, opts); !errors.Is(err, ErrValidation) {
+			t.Errorf("get -s -format %s -o: %v; want the task definition collision rejected", formats[0], err)
+		}
+	}
+	opts = GetFileOptions{Export: ExportOptions{Formats: []string{FormatEnv, FormatYAML}}}
+	if err := GetParametersFromFile(fake, template, "", TemplateOptions{}, opts); !errors.Is(err, ErrValidation) {
+		t.Errorf("get -s with two formats and no -o: %v; want ErrValidation", err)
+	}
+}
+
+func TestPrintParameter(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		raw   bool
+		want  string
+	}{
+		{"hunter2", false, "Parameter /app/TOKEN: hunter2\n"},
+		{"hunter2", true, "hunter2"},
+		{"line1\nline2\n", true, "line1\nline2\n"}, // The value's own newline, and no other.
+		{"", true, ""},
+	} {
+		var out bytes.Buffer
+		PrintParameter(&out, "/app/TOKEN", tc.value, tc.raw)
+		if out.String() != tc.want {
+			t.Errorf("PrintParameter(%q, raw %v) = %q; want %q", tc.value, tc.raw, out.String(), tc.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package features
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// hunkHeader matches the header of a unified diff hunk, capturing the start and length on the new side.
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// lineChange is what a diff hunk did to the current file: lines from..to were added or changed, or with
+// to < from, lines were only deleted between lines to and from.
+type lineChange struct{ from, to int }
+
+// gitChanges returns the changes to filename since the git revision ref, from git diff run in the file's
+// directory. A file that did not exist at ref is one change of all its lines.
+func gitChanges(filename, ref string) ([]lineChange, error) {
+	if filename == StdinSource {
+		return nil, validationErrorf("-changed-since needs a file in a git work tree, not standard input")
+	}
+	cmd := exec.Command("git", "diff", "--no-color", "--no-ext-diff", "--unified=0", ref, "--", filepath.Base(filename))
+	cmd.Dir = filepath.Dir(filename)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return nil, validationErrorf("git diff %s -- %s failed: %s", ref, filename, strings.TrimSpace(stderr.String()))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to run git diff: %w", err)
+	}
+	return parseHunks(out), nil
+}
+
+// parseHunks returns the changes of each hunk of git diff --unified=0 output.
+func parseHunks(diff []byte) []lineChange {
+	var changes []lineChange
+	for _, line := range strings.Split(string(diff), "\n") {
+		m := hunkHeader.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		start, _ := strconv.Atoi(m[1])
+		count := 1
+		if m[2] != "" {
+			count, _ = strconv.Atoi(m[2])
+		}
+		if count == 0 {
+			changes = append(changes, lineChange{from: start + 1, to: start}) // Deleted after line start.
+		} else {
+			changes = append(changes, lineChange{from: start, to: start + count - 1})
+		}
+	}
+	return changes
+}
+
+// changedKeys returns the keys whose line ranges, from ranges, a change touches. A deletion touches a range
+// only when it is inside it, so removing a whole key does not count as changing its neighbours.
+func changedKeys(ranges map[string][][2]int, changes []lineChange) map[string]bool {
+	changed := make(map[string]bool)
+	for key, keyRanges := range ranges {
+		for _, r := range keyRanges {
+			for _, c := range changes {
+				added := c.to >= c.from && c.from <= r[1] && c.to >= r[0]
+				deleted := c.to < c.from && c.to >= r[0] && c.from <= r[1]
+				if added || deleted {
+					changed[key] = true
+				}
+			}
+		}
+	}
+	return changed
+}
+
+// templateSecretRanges returns the lines of each secret of a template, from its "{" to its last field.
+func templateSecretRanges(data []byte) map[string][][2]int {
+	ranges := make(map[string][][2]int)
+	for _, span := range scanSecrets(data) {
+		if span.name != "" {
+			ranges[span.name] = append(ranges[span.name], [2]int{lineAt(data, span.start), lineAt(data, span.end)})
+		}
+	}
+	return ranges
+}
+
+// envKeyRanges returns the lines of each key of a .env file as dotenvReader reads it: its "# param:"
+// comments, its line, and the continuation lines of a multi-line value.
+func envKeyRanges(data []byte) map[string][][2]int {
+	lines := strings.Split(string(data), "\n")
+	ranges := make(map[string][][2]int)
+	first := 0 // Line of the pending "# param:" comment, or 0.
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if isEnvDirective(line) {
+			if first == 0 {
+				first = i + 1
+			}
+			continue
+		}
+		key, _, ok := strings.Cut(line, "=")
+		if line == "" || strings.HasPrefix(line, "#") || !ok {
+			continue
+		}
+		start, end := i+1, i+1
+		if first != 0 {
+			start, first = first, 0
+		}
+		for j := i + 1; j < len(lines); j++ {
+			next := strings.TrimSpace(lines[j])
+			if envKeyLine.MatchString(next) || isEnvDirective(next) {
+				break
+			}
+			if next != "" {
+				end = j + 1
+			}
+			i = j
+		}
+		key = strings.TrimSpace(key)
+		ranges[key] = append(ranges[key], [2]int{start, end})
+	}
+	return ranges
+}
+
+// sourceChangedKeys returns the keys of the template or .env file filename whose lines changed since ref,
+// and prints how many did.
+func sourceChangedKeys(filename, ref string, ranges map[string][][2]int) (map[string]bool, error) {
+	changes, err := gitChanges(filename, ref)
+	if err != nil {
+		return nil, err
+	}
+	changed := changedKeys(ranges, changes)
+	keys := make([]string, 0, len(changed))
+	for key := range changed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if len(keys) == 0 {
+		Infof("No keys of %s changed since %s\n", filename, ref)
+	} else {
+		Infof("%d of %d keys of %s changed since %s: %s\n", len(keys), len(ranges), filename, ref, strings.Join(keys, ", "))
+	}
+	return changed, nil
+}
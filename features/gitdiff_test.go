@@ -0,0 +1,101 @@
+package features
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestParseHunks(t *testing.T) {
+	diff := []byte(`diff --git a/app.env b/app.env
+--- a/app.env
++++ b/app.env
+@@ -2 +2 @@ A=1
+-B=2
++B=3
+@@ -5,2 +4,0 @@ C=x
+@@ -0,0 +9,3 @@
+`)
+	want := []lineChange{{2, 2}, {5, 4}, {9, 11}}
+	if got := parseHunks(diff); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseHunks = %v; want %v", got, want)
+	}
+}
+
+func TestEnvKeyRanges(t *testing.T) {
+	data := []byte("A=1\n\n# param: type=securestring\nCERT=-----BEGIN-----\nabc\n-----END-----\n\n# note\nB=2\n")
+	want := map[string][][2]int{"A": {{1, 1}}, "CERT": {{3, 8}}, "B": {{9, 9}}}
+	if got := envKeyRanges(data); !reflect.DeepEqual(got, want) {
+		t.Errorf("envKeyRanges = %v; want %v", got, want)
+	}
+}
+
+func TestChangedKeys(t *testing.T) {
+	ranges := map[string][][2]int{"A": {{1, 3}}, "B": {{4, 6}}, "C": {{8, 8}}}
+	tests := []struct {
+		changes []lineChange
+		want    map[string]bool
+	}{
+		{[]lineChange{{2, 2}}, map[string]bool{"A": true}},
+		{[]lineChange{{3, 4}}, map[string]bool{"A": true, "B": true}},
+		{[]lineChange{{6, 5}}, map[string]bool{"B": true}}, // Deleted inside B.
+		{[]lineChange{{7, 6}}, map[string]bool{}},          // Deleted between B and C.
+	}
+	for _, tt := range tests {
+		if got := changedKeys(ranges, tt.changes); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("changedKeys(%v) = %v; want %v", tt.changes, got, tt.want)
+		}
+	}
+}
+
+func TestPutFromTemplateChangedSince(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	template := filepath.Join(dir, "task.json")
+	write := func(b string) {
+		t.Helper()
+		data := `{"containerDefinitions": [{"secrets": [
+  {"name": "A", "valueFrom": "/app/A", "value": "a"},
+  {"name": "B", "valueFrom": "/app/B", "value": "` + b + `"},
+  {"name": "C", "valueFrom": "/app/C", "value": "c"}
+]}]}
+`
+		if err := os.WriteFile(template, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	write("b")
+	git("add", "task.json")
+	git("commit", "-q", "-m", "one")
+	write("b2")
+	git("commit", "-q", "-am", "two")
+
+	fake := newFakeSSM()
+	if err := PutParametersFromTemplate(fake, template, TemplateOptions{}, PutOptions{AssumeYes: true, ChangedSince: "HEAD~1"}); err != nil {
+		t.Fatalf("PutParametersFromTemplate: %v", err)
+	}
+	if len(fake.params) != 1 || aws.ToString(fake.params["/app/B"].Value) != "b2" {
+		t.Errorf("parameters = %v; want only /app/B as b2", fake.params)
+	}
+
+	err := PutParametersFromTemplate(fake, template, TemplateOptions{}, PutOptions{AssumeYes: true, ChangedSince: "no-such-ref"})
+	if err == nil {
+		t.Error("unknown revision succeeded; want an error")
+	}
+}
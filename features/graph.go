@@ -0,0 +1,189 @@
+package features
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// paramRefPattern matches {{ssm:/path/KEY}} references to other parameters in template values.
+// The path (or ARN) is literal; {{name}} variables are not expanded inside it, but a logical name such as
+// {{ssm:DB_URL}} is mapped through the path pattern.
+var paramRefPattern = regexp.MustCompile(`\{\{\s*ssm:([^{}\s]+)\s*\}\}`)
+
+// templateRefs returns the parameters the value of secret is built from: its valueFromParameter,
+// or every {{ssm:...}} reference in its value.
+func templateRefs(secret ExtendedSecret, tmplOpts TemplateOptions) ([]string, error) {
+	var sources []string
+	if secret.ValueFromParameter != "" {
+		source, err := ExpandTemplateVars(secret.ValueFromParameter, tmplOpts.Vars)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	} else {
+		for _, m := range paramRefPattern.FindAllStringSubmatch(secret.Value, -1) {
+			sources = append(sources, m[1])
+		}
+	}
+	var refs []string
+	for _, source := range sources {
+		if err := checkARNScope(source, tmplOpts); err != nil {
+			return nil, err
+		}
+		name, err := tmplOpts.parameterName(source)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			return nil, validationErrorf("invalid parameter reference %q", source)
+		}
+		refs = append(refs, name)
+	}
+	return refs, nil
+}
+
+// orderTemplatePuts sorts puts so every put comes after the puts of the parameters it references,
+// keeping template order otherwise. If the references form a cycle, it returns the puts on it instead.
+func orderTemplatePuts(puts []*templatePut) (ordered, cycle []*templatePut) {
+	producer := make(map[string]int) // Target parameter -> index of the put writing it.
+	for i, p := range puts {
+		producer[p.paramName] = i
+	}
+	deps := make([][]int, len(puts))
+	for i, p := range puts {
+		for _, ref := range p.refs {
+			if j, ok := producer[ref]; ok {
+				deps[i] = append(deps[i], j)
+			}
+		}
+	}
+	placed := make([]bool, len(puts))
+	ready := func(i int) bool {
+		for _, j := range deps[i] {
+			if !placed[j] {
+				return false
+			}
+		}
+		return true
+	}
+	for len(ordered) < len(puts) {
+		progress := false
+		for i := range puts {
+			if !placed[i] && ready(i) {
+				placed[i] = true
+				ordered = append(ordered, puts[i])
+				progress = true
+				break // Restart so earlier template entries go first.
+			}
+		}
+		if !progress {
+			break
+		}
+	}
+	if len(ordered) == len(puts) {
+		return ordered, nil
+	}
+
+	// Every unplaced put waits for another unplaced one, so following those edges must revisit a put.
+	start := 0
+	for placed[start] {
+		start++
+	}
+	seen := make(map[int]int) // Put index -> position on the path.
+	var path []int
+	for i := start; ; {
+		if at, ok := seen[i]; ok {
+			for _, j := range path[at:] {
+				cycle = append(cycle, puts[j])
+			}
+			return nil, cycle
+		}
+		seen[i] = len(path)
+		path = append(path, i)
+		for _, j := range deps[i] {
+			if !placed[j] {
+				i = j
+				break
+			}
+		}
+	}
+}
+
+// cycleError describes a reference cycle found by orderTemplatePuts.
+func cycleError(cycle []*templatePut) error {
+	var names []string
+	for i := len(cycle) - 1; i >= 0; i-- { // Dependencies first.
+		names = append(names, cycle[i].paramName)
+	}
+	names = append(names, names[0])
+	return validationErrorf("parameter references form a cycle: %s", strings.Join(names, " -> "))
+}
+
+// WriteTemplateGraph writes the parameter reference graph of a put-from-template template in Graphviz
+// DOT format to output, or prints it when output is empty. Edges point from a parameter to the ones
+// whose value is built from it; parameters the template does not write are dashed, and a cycle is drawn
+// in red and returned as an error after the graph is written.
+func WriteTemplateGraph(filename, output string, tmplOpts TemplateOptions) error {
+	puts, err := loadTemplatePuts(filename, tmplOpts)
+	if err != nil {
+		return err
+	}
+	_, cycle := orderTemplatePuts(puts)
+	onCycle := make(map[[2]string]bool)
+	for i, p := range cycle {
+		dependent := cycle[(i+len(cycle)-1)%len(cycle)]
+		onCycle[[2]string{p.paramName, dependent.paramName}] = true
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph template {\n  rankdir=LR;\n  node [shape=box];\n")
+	written := make(map[string]bool)
+	for _, p := range puts {
+		written[p.paramName] = true
+		fmt.Fprintf(&b, "  %s [label=\"%s\\n%s\"];\n", dotQuote(p.paramName), dotEscape(p.secret.Name), dotEscape(p.paramName))
+	}
+	external := make(map[string]bool)
+	for _, p := range puts {
+		for _, ref := range p.refs {
+			if !written[ref] && !external[ref] {
+				external[ref] = true
+				fmt.Fprintf(&b, "  %s [shape=ellipse, style=dashed];\n", dotQuote(ref))
+			}
+		}
+	}
+	for _, p := range puts {
+		for _, ref := range p.refs {
+			attrs := ""
+			if onCycle[[2]string{ref, p.paramName}] {
+				attrs = " [color=red]"
+			}
+			fmt.Fprintf(&b, "  %s -> %s%s;\n", dotQuote(ref), dotQuote(p.paramName), attrs)
+		}
+	}
+	b.WriteString("}\n")
+
+	if output == "" {
+		fmt.Print(b.String())
+	} else {
+		if err := os.WriteFile(output, []byte(b.String()), 0644); err != nil {
+			return fmt.Errorf("failed to write graph file %s: %w", output, err)
+		}
+		Infof("Saved reference graph to %s\n", output)
+	}
+	if cycle != nil {
+		return cycleError(cycle)
+	}
+	return nil
+}
+
+// dotEscape escapes s for use inside a double-quoted DOT string.
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
+// dotQuote returns s as a double-quoted DOT ID.
+func dotQuote(s string) string {
+	return `"` + dotEscape(s) + `"`
+}
@@ -0,0 +1,93 @@
+package features
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestPutFromTemplateReferenceOrder(t *testing.T) {
+	dir := t.TempDir()
+	template := filepath.Join(dir, "template.json")
+	data := `{"containerDefinitions": [{"secrets": [
+  {"name": "DSN", "valueFrom": "/app/DSN", "value": "postgres://{{ssm:/app/DB_USER}}:{{ssm:/shared/DB_PASSWORD}}@db"},
+  {"name": "DSN_COPY", "valueFrom": "/app/DSN_COPY", "valueFromParameter": "/app/DSN"},
+  {"name": "DB_USER", "valueFrom": "/app/DB_USER", "value": "app"}
+]}]}`
+	if err := os.WriteFile(template, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeSSM()
+	fake.set("/app/DB_USER", "old-user", types.ParameterTypeString)
+	fake.set("/shared/DB_PASSWORD", "s3cret", types.ParameterTypeSecureString)
+
+	if err := PutParametersFromTemplate(fake, template, TemplateOptions{}, PutOptions{AssumeYes: true}); err != nil {
+		t.Fatalf("put-from-template: %v", err)
+	}
+	for name, want := range map[string]string{
+		"/app/DB_USER":  "app",
+		"/app/DSN":      "postgres://app:s3cret@db", // The template's DB_USER, not the stale one in SSM.
+		"/app/DSN_COPY": "postgres://app:s3cret@db",
+	} {
+		if got := aws.ToString(fake.params[name].Value); got != want {
+			t.Errorf("%s = %q; want %q", name, got, want)
+		}
+	}
+
+	output := filepath.Join(dir, "graph.dot")
+	if err := WriteTemplateGraph(template, output, TemplateOptions{}); err != nil {
+		t.Fatalf("graph: %v", err)
+	}
+	dot, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"/app/DB_USER" -> "/app/DSN";`,
+		`"/shared/DB_PASSWORD" -> "/app/DSN";`,
+		`"/app/DSN" -> "/app/DSN_COPY";`,
+		`"/shared/DB_PASSWORD" [shape=ellipse, style=dashed];`,
+	} {
+		if !strings.Contains(string(dot), want) {
+			t.Errorf("graph is missing %s:\n%s", want, dot)
+		}
+	}
+}
+
+func TestPutFromTemplateReferenceCycle(t *testing.T) {
+	dir := t.TempDir()
+	template := filepath.Join(dir, "template.json")
+	data := `{"containerDefinitions": [{"secrets": [
+  {"name": "SOLO", "valueFrom": "/app/SOLO", "value": "x"},
+  {"name": "A", "valueFrom": "/app/A", "value": "{{ssm:/app/B}}"},
+  {"name": "B", "valueFrom": "/app/B", "valueFromParameter": "/app/A"}
+]}]}`
+	if err := os.WriteFile(template, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeSSM()
+	err := PutParametersFromTemplate(fake, template, TemplateOptions{}, PutOptions{AssumeYes: true})
+	if !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), "/app/A -> /app/B -> /app/A") && !strings.Contains(err.Error(), "/app/B -> /app/A -> /app/B") {
+		t.Fatalf("put-from-template with a cycle error = %v; want a validation error naming the cycle", err)
+	}
+	if fake.puts != 0 {
+		t.Errorf("put-from-template wrote %d parameters despite the cycle", fake.puts)
+	}
+
+	output := filepath.Join(dir, "graph.dot")
+	if err := WriteTemplateGraph(template, output, TemplateOptions{}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("graph with a cycle error = %v; want ErrValidation", err)
+	}
+	dot, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(dot), "[color=red]"); got != 2 {
+		t.Errorf("graph has %d red edges; want 2:\n%s", got, dot)
+	}
+}
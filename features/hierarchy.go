@@ -0,0 +1,154 @@
+package features
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// Placeholders of a path pattern that list-apps and list-envs list the values of.
+const (
+	HierarchyApp = "app"
+	HierarchyEnv = "env"
+)
+
+// pathPatternRoot returns the literal path a pattern starts with, up to the segment of its first
+// placeholder: "/" for /{{env}}/{{app}}/{{name}}, "/company/" for /company/{{env}}/{{name}}.
+func pathPatternRoot(pattern string) string {
+	literal := pattern
+	if loc := templateVarPattern.FindStringIndex(pattern); loc != nil {
+		literal = pattern[:loc[0]]
+	}
+	return literal[:strings.LastIndex(literal, "/")+1]
+}
+
+// pathPatternRegexp returns a regexp matching the paths pattern gives, with a named group for each
+// placeholder: {{name}} matches the rest of the path, any other placeholder one segment.
+func pathPatternRegexp(pattern string) (*regexp.Regexp, error) {
+	var expr strings.Builder
+	expr.WriteString("^")
+	seen := make(map[string]bool)
+	last := 0
+	for _, loc := range templateVarPattern.FindAllStringSubmatchIndex(pattern, -1) {
+		expr.WriteString(regexp.QuoteMeta(pattern[last:loc[0]]))
+		name := pattern[loc[2]:loc[3]]
+		switch {
+		case seen[name]:
+			expr.WriteString(`[^/]+`) // Only the first occurrence is captured.
+		case name == "name":
+			expr.WriteString(`(?P<name>.+)`)
+		default:
+			expr.WriteString(`(?P<` + name + `>[^/]+)`)
+		}
+		seen[name] = true
+		last = loc[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(pattern[last:]) + "$")
+	if !seen["name"] {
+		return nil, validationErrorf("path pattern %q has no {{name}} placeholder", pattern)
+	}
+	return regexp.Compile(expr.String())
+}
+
+// hierarchyEntry is one application or environment found by ListHierarchy.
+type hierarchyEntry struct {
+	Value      string
+	Others     []string // Environments of an application, or applications of an environment.
+	Parameters int
+}
+
+// ListHierarchy prints the distinct values of placeholder (HierarchyApp or HierarchyEnv) in the names of
+// the parameters under the root of pattern, such as /{{env}}/{{app}}/{{name}}, with the values of the other
+// one they occur with and their number of parameters: a map of what exists in Parameter Store. Only
+// metadata is read, so no values are decrypted.
+func ListHierarchy(client SSMClient, pattern, placeholder string) error {
+	entries, unmatched, err := collectHierarchy(client, pattern, placeholder)
+	if err != nil {
+		return err
+	}
+	root := pathPatternRoot(pattern)
+	if len(entries) == 0 {
+		fmt.Printf("No parameters under %s match %s\n", root, pattern)
+		return nil
+	}
+	other := HierarchyEnv
+	if placeholder == HierarchyEnv {
+		other = HierarchyApp
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\t%sS\tPARAMETERS\n", strings.ToUpper(placeholder), strings.ToUpper(other))
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%d\n", e.Value, strings.Join(e.Others, ", "), e.Parameters)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if unmatched > 0 {
+		fmt.Printf("%s %d parameters under %s that do not match %s\n", yellow("Skipped"), unmatched, root, pattern)
+	}
+	return nil
+}
+
+// collectHierarchy returns the entries of ListHierarchy sorted by value, and the number of parameters
+// under the root that do not match pattern.
+func collectHierarchy(client SSMClient, pattern, placeholder string) ([]hierarchyEntry, int, error) {
+	if pattern == "" {
+		return nil, 0, validationErrorf("no path pattern: set \"pathPattern\" in config.json, e.g. \"/{{env}}/{{app}}/{{name}}\"")
+	}
+	re, err := pathPatternRegexp(pattern)
+	if err != nil {
+		return nil, 0, err
+	}
+	index := re.SubexpIndex(placeholder)
+	if index < 0 {
+		return nil, 0, validationErrorf("path pattern %q has no {{%s}} placeholder", pattern, placeholder)
+	}
+	other := HierarchyEnv
+	if placeholder == HierarchyEnv {
+		other = HierarchyApp
+	}
+	otherIndex := re.SubexpIndex(other) // -1 when the pattern has only one of them.
+
+	metadata, err := describeParameters(client, types.ParameterStringFilter{
+		Key:    aws.String("Name"),
+		Option: aws.String("BeginsWith"),
+		Values: []string{pathPatternRoot(pattern)},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	found := make(map[string]*hierarchyEntry)
+	others := make(map[string]map[string]bool)
+	unmatched := 0
+	for name := range metadata {
+		m := re.FindStringSubmatch(name)
+		if m == nil {
+			unmatched++
+			continue
+		}
+		e := found[m[index]]
+		if e == nil {
+			e = &hierarchyEntry{Value: m[index]}
+			found[e.Value] = e
+			others[e.Value] = make(map[string]bool)
+		}
+		e.Parameters++
+		if otherIndex >= 0 && !others[e.Value][m[otherIndex]] {
+			others[e.Value][m[otherIndex]] = true
+			e.Others = append(e.Others, m[otherIndex])
+		}
+	}
+	entries := make([]hierarchyEntry, 0, len(found))
+	for _, e := range found {
+		sort.Strings(e.Others)
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Value < entries[j].Value })
+	return entries, unmatched, nil
+}
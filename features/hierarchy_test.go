@@ -0,0 +1,45 @@
+package features
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestCollectHierarchy(t *testing.T) {
+	fake := newFakeSSM()
+	for _, name := range []string{"/prod/billing/DB_URL", "/prod/billing/stripe/KEY", "/prod/web/API_URL", "/staging/billing/DB_URL", "/legacy"} {
+		fake.set(name, "v", types.ParameterTypeString)
+	}
+	pattern := "/{{env}}/{{app}}/{{name}}"
+
+	apps, unmatched, err := collectHierarchy(fake, pattern, HierarchyApp)
+	if err != nil {
+		t.Fatalf("apps: %v", err)
+	}
+	want := []hierarchyEntry{
+		{Value: "billing", Others: []string{"prod", "staging"}, Parameters: 3},
+		{Value: "web", Others: []string{"prod"}, Parameters: 1},
+	}
+	if !reflect.DeepEqual(apps, want) || unmatched != 1 {
+		t.Errorf("apps = %+v, %d unmatched; want %+v, 1 unmatched", apps, unmatched, want)
+	}
+	envs, _, err := collectHierarchy(fake, pattern, HierarchyEnv)
+	if err != nil {
+		t.Fatalf("envs: %v", err)
+	}
+	if len(envs) != 2 || envs[0].Value != "prod" || !reflect.DeepEqual(envs[0].Others, []string{"billing", "web"}) {
+		t.Errorf("envs = %+v", envs)
+	}
+
+	if root := pathPatternRoot("/company/{{env}}/{{app}}/{{name}}"); root != "/company/" {
+		t.Errorf("root = %q; want /company/", root)
+	}
+	for _, p := range []string{"", "/{{env}}/{{name}}", "/{{env}}/{{app}}"} {
+		if _, _, err := collectHierarchy(fake, p, HierarchyApp); !errors.Is(err, ErrValidation) {
+			t.Errorf("pattern %q: err = %v; want ErrValidation", p, err)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package features
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// ErrHookRejected is returned for writes a pre hook did not allow.
+var ErrHookRejected = errors.New("rejected by pre hook")
+
+// defaultHookTimeout is how long a hook may run when it does not set a timeout.
+const defaultHookTimeout = 30 * time.Second
+
+// Hooks are the commands and webhooks config.json runs around the writes of a run (see NewHookClient).
+type Hooks struct {
+	Pre  []Hook `json:"pre,omitempty"`  // Run before writes are sent; a failure cancels them.
+	Post []Hook `json:"post,omitempty"` // Run once after the run, with the result of every write.
+}
+
+// Hook is a shell command, which reads the HookPayload on stdin, or a URL the payload is POSTed to as JSON.
+type Hook struct {
+	Command string            `json:"command,omitempty"`
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"` // Request headers for URL; ${VAR} is taken from the environment.
+	Timeout string            `json:"timeout,omitempty"` // How long the hook may take, e.g. 10s; empty is 30s.
+}
+
+// Change is one write in a HookPayload. Values are never included.
+type Change struct {
+	Operation string `json:"operation"` // PutParameter or DeleteParameter.
+	Name      string `json:"name"`
+	Type      string `json:"type,omitempty"`  // Parameter type of a put.
+	Error     string `json:"error,omitempty"` // In post payloads, why the write failed.
+}
+
+// HookPayload is the JSON document hooks receive.
+type HookPayload struct {
+	Phase   string    `json:"phase"` // "pre" or "post".
+	Action  string    `json:"action"`
+	User    string    `json:"user"`
+	Time    time.Time `json:"time"`
+	Changes []Change  `json:"changes"`         // Pre: the writes about to be sent. Post: the writes that were sent.
+	Error   string    `json:"error,omitempty"` // In post payloads, the error the run failed with.
+}
+
+// HookClient runs the pre hooks before writes are sent and records them for the post hooks, which Finish
+// runs. Actions that write several parameters announce them together (see planChanges), so the pre hooks
+// see the whole change set once; other writes are announced one at a time. Dry runs run no hooks.
+type HookClient struct {
+	decorator
+	hooks   Hooks
+	action  string
+	mu      sync.Mutex
+	planned map[string]bool // Operation and name of the writes the pre hooks allowed.
+	sent    []Change
+}
+
+// NewHookClient wraps client so hooks run around its writes; action names the run in the payloads.
+func NewHookClient(client SSMClient, hooks Hooks, action string) *HookClient {
+	return &HookClient{decorator: decorator{client}, hooks: hooks, action: action, planned: make(map[string]bool)}
+}
+
+func (c *HookClient) dryRun() bool {
+	return isDryRun(c.SSMClient)
+}
+
+// planChanges runs the pre hooks for the changes not announced yet. It fails with ErrHookRejected when a
+// hook fails.
+func (c *HookClient) planChanges(changes []Change) error {
+	if c.dryRun() || len(c.hooks.Pre) == 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var pending []Change
+	for _, change := range changes {
+		if !c.planned[change.Operation+" "+change.Name] {
+			pending = append(pending, change)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	payload := c.payload("pre", pending)
+	for _, hook := range c.hooks.Pre {
+		if err := runHook(hook, payload); err != nil {
+			return validationErrorf("%w: %s: %v", ErrHookRejected, hook.describe(), err)
+		}
+	}
+	for _, change := range pending {
+		c.planned[change.Operation+" "+change.Name] = true
+	}
+	return nil
+}
+
+// PutParameter announces the put to the pre hooks unless it was planned, sends it and records the result.
+func (c *HookClient) PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+	change := Change{Operation: "PutParameter", Name: aws.ToString(params.Name), Type: string(params.Type)}
+	if err := c.planChanges([]Change{change}); err != nil {
+		return nil, err
+	}
+	out, err := c.SSMClient.PutParameter(ctx, params, optFns...)
+	c.record(change, err)
+	return out, err
+}
+
+// DeleteParameter announces the delete to the pre hooks unless it was planned, sends it and records the result.
+func (c *HookClient) DeleteParameter(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error) {
+	change := Change{Operation: "DeleteParameter", Name: aws.ToString(params.Name)}
+	if err := c.planChanges([]Change{change}); err != nil {
+		return nil, err
+	}
+	out, err := c.SSMClient.DeleteParameter(ctx, params, optFns...)
+	c.record(change, err)
+	return out, err
+}
+
+// record remembers a sent write for the post hooks.
+func (c *HookClient) record(change Change, err error) {
+	if c.dryRun() {
+		return
+	}
+	if err != nil {
+		change.Error = DescribeError(err)
+	}
+	c.mu.Lock()
+	c.sent = append(c.sent, change)
+	c.mu.Unlock()
+}
+
+// Finish runs the post hooks with the writes that were sent and runErr, the error the run ends with. It does
+// nothing on a nil client or when nothing was written; hook failures are printed, since the writes are done.
+func (c *HookClient) Finish(runErr error) {
+	if c == nil || len(c.hooks.Post) == 0 {
+		return
+	}
+	c.mu.Lock()
+	sent := c.sent
+	c.sent = nil
+	c.mu.Unlock()
+	if len(sent) == 0 {
+		return
+	}
+	payload := c.payload("post", sent)
+	if runErr != nil {
+		payload.Error = DescribeError(runErr)
+	}
+	for _, hook := range c.hooks.Post {
+		if err := runHook(hook, payload); err != nil {
+			Infof("%s post hook %s failed: %v\n", yellow("Warning:"), hook.describe(), err)
+		}
+	}
+}
+
+// payload returns the HookPayload of changes in phase.
+func (c *HookClient) payload(phase string, changes []Change) HookPayload {
+	return HookPayload{Phase: phase, Action: c.action, User: currentUser(), Time: time.Now().UTC(), Changes: changes}
+}
+
+// planChanges announces the writes an action is about to make to the pre hooks when client runs hooks,
+// before the first of them is sent.
+func planChanges(client SSMClient, changes []Change) error {
+	if p, ok := client.(interface{ planChanges([]Change) error }); ok {
+		return p.planChanges(changes)
+	}
+	return nil
+}
+
+// CheckHooks validates the hooks of config.json: each one is a command or a URL, with a valid timeout.
+func CheckHooks(hooks Hooks) error {
+	for _, phase := range []struct {
+		name  string
+		hooks []Hook
+	}{{"pre", hooks.Pre}, {"post", hooks.Post}} {
+		for i, hook := range phase.hooks {
+			if (hook.Command == "") == (hook.URL == "") {
+				return validationErrorf("hooks.%s[%d] needs either a command or a url", phase.name, i)
+			}
+			if hook.URL != "" {
+				if u, err := url.Parse(hook.URL); err != nil || u.Scheme != "http" && u.Scheme != "https" {
+					return validationErrorf("hooks.%s[%d]: %s is not an http or https URL", phase.name, i, hook.describe())
+				}
+			}
+			if _, err := hook.timeout(); err != nil {
+				return validationErrorf("hooks.%s[%d]: invalid timeout %q", phase.name, i, hook.Timeout)
+			}
+		}
+	}
+	return nil
+}
+
+// timeout returns how long the hook may take.
+func (h Hook) timeout() (time.Duration, error) {
+	if h.Timeout == "" {
+		return defaultHookTimeout, nil
+	}
+	return time.ParseDuration(h.Timeout)
+}
+
+// describe names the hook in messages: its command, or its URL without the query, which may hold a token.
+func (h Hook) describe() string {
+	if h.Command != "" {
+		return fmt.Sprintf("%q", h.Command)
+	}
+	if u, err := url.Parse(h.URL); err == nil {
+		u.RawQuery, u.User = "", nil
+		return u.String()
+	}
+	return h.URL
+}
+
+// runHook runs one hook with payload. A command fails with a non-zero exit status and a webhook with a
+// non-2xx response.
+func runHook(hook Hook, payload HookPayload) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	timeout, err := hook.timeout()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if hook.Command != "" {
+		shell, flag := "sh", "-c"
+		if runtime.GOOS == "windows" {
+			shell, flag = "cmd", "/C"
+		}
+		cmd := exec.CommandContext(ctx, shell, flag, hook.Command)
+		cmd.Stdin = bytes.NewReader(data)
+		cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr // Keep stdout for the action's output.
+		cmd.Env = append(os.Environ(), "PARAM_STORE_HOOK_PHASE="+payload.Phase, "PARAM_STORE_HOOK_ACTION="+payload.Action)
+		if err := cmd.Run(); ctx.Err() != nil {
+			return fmt.Errorf("timed out after %s", timeout)
+		} else if err != nil {
+			return err
+		}
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range hook.Headers {
+		req.Header.Set(name, os.ExpandEnv(value))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
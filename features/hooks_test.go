@@ -0,0 +1,145 @@
+package features
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// hookServer records the payloads POSTed to it and answers with status.
+type hookServer struct {
+	mu       sync.Mutex
+	payloads []HookPayload
+	status   int
+}
+
+func (s *hookServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var payload HookPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || r.Header.Get("X-Token") != "t0k" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.payloads = append(s.payloads, payload)
+	s.mu.Unlock()
+	w.WriteHeader(s.status)
+}
+
+func writeHookTemplate(t *testing.T) string {
+	t.Helper()
+	template := filepath.Join(t.TempDir(), "task.json")
+	data := `{"containerDefinitions": [{"secrets": [
+  {"name": "A", "valueFrom": "/app/A", "value": "a"},
+  {"name": "B", "valueFrom": "/app/B", "type": "securestring", "value": "b"}
+]}]}`
+	if err := os.WriteFile(template, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return template
+}
+
+func TestHooksAroundTemplatePut(t *testing.T) {
+	server := &hookServer{status: http.StatusOK}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	t.Setenv("HOOK_TOKEN", "t0k")
+	hook := Hook{URL: ts.URL, Headers: map[string]string{"X-Token": "${HOOK_TOKEN}"}}
+	fake := newFakeSSM()
+	client := NewHookClient(fake, Hooks{Pre: []Hook{hook}, Post: []Hook{hook}}, "put-from-template")
+
+	if err := PutParametersFromTemplate(client, writeHookTemplate(t), TemplateOptions{}, PutOptions{AssumeYes: true}); err != nil {
+		t.Fatalf("PutParametersFromTemplate: %v", err)
+	}
+	client.Finish(nil)
+	want := []Change{{Operation: "PutParameter", Name: "/app/A", Type: "String"}, {Operation: "PutParameter", Name: "/app/B", Type: "SecureString"}}
+	if len(server.payloads) != 2 {
+		t.Fatalf("payloads = %+v; want one pre and one post", server.payloads)
+	}
+	for i, phase := range []string{"pre", "post"} {
+		p := server.payloads[i]
+		if p.Phase != phase || p.Action != "put-from-template" || len(p.Changes) != 2 || p.Changes[0] != want[0] || p.Changes[1] != want[1] {
+			t.Errorf("%s payload = %+v; want the two puts", phase, p)
+		}
+	}
+
+	// A write the action did not announce gets a pre hook of its own.
+	if err := PutParameter(client, "/app/C", "c", StringType, PutOptions{AssumeYes: true}); err != nil {
+		t.Fatalf("PutParameter: %v", err)
+	}
+	if len(server.payloads) != 3 || len(server.payloads[2].Changes) != 1 || server.payloads[2].Changes[0].Name != "/app/C" {
+		t.Errorf("payloads after PutParameter = %+v; want a pre hook for /app/C", server.payloads)
+	}
+}
+
+func TestPreHookRejects(t *testing.T) {
+	server := &hookServer{status: http.StatusForbidden}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	t.Setenv("HOOK_TOKEN", "t0k")
+	fake := newFakeSSM()
+	client := NewHookClient(fake, Hooks{Pre: []Hook{{URL: ts.URL, Headers: map[string]string{"X-Token": "${HOOK_TOKEN}"}}}}, "put-from-template")
+
+	err := PutParametersFromTemplate(client, writeHookTemplate(t), TemplateOptions{}, PutOptions{AssumeYes: true})
+	if !errors.Is(err, ErrHookRejected) || !errors.Is(err, ErrValidation) {
+		t.Errorf("PutParametersFromTemplate error = %v; want ErrHookRejected", err)
+	}
+	if fake.puts != 0 {
+		t.Errorf("puts = %d; want 0", fake.puts)
+	}
+}
+
+func TestCommandHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	out := filepath.Join(t.TempDir(), "payload.json")
+	fake := newFakeSSM()
+	client := NewHookClient(fake, Hooks{Post: []Hook{{Command: `cat > "` + out + `"`}}}, "put")
+	if err := PutParameter(client, "/app/A", "secret-value", SecureStringType, PutOptions{AssumeYes: true}); err != nil {
+		t.Fatalf("PutParameter: %v", err)
+	}
+	client.Finish(errors.New("later step failed"))
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload HookPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Phase != "post" || payload.Error != "later step failed" || len(payload.Changes) != 1 || payload.Changes[0].Name != "/app/A" {
+		t.Errorf("payload = %+v; want the post payload of /app/A with the run's error", payload)
+	}
+
+	// A failing pre command cancels the write; dry runs run no hooks at all.
+	client = NewHookClient(fake, Hooks{Pre: []Hook{{Command: "exit 3"}}}, "put")
+	if err := PutParameter(client, "/app/B", "b", StringType, PutOptions{AssumeYes: true}); !errors.Is(err, ErrHookRejected) {
+		t.Errorf("PutParameter error = %v; want ErrHookRejected", err)
+	}
+	client = NewHookClient(NewDryRunClient(fake), Hooks{Pre: []Hook{{Command: "exit 3"}}}, "put")
+	if err := PutParameter(client, "/app/B", "b", StringType, PutOptions{AssumeYes: true}); err != nil {
+		t.Errorf("dry-run PutParameter: %v", err)
+	}
+}
+
+func TestCheckHooks(t *testing.T) {
+	for _, hooks := range []Hooks{
+		{Pre: []Hook{{}}},
+		{Pre: []Hook{{Command: "true", URL: "https://hooks.example.com"}}},
+		{Post: []Hook{{URL: "ftp://hooks.example.com"}}},
+		{Post: []Hook{{Command: "true", Timeout: "soon"}}},
+	} {
+		if err := CheckHooks(hooks); !errors.Is(err, ErrValidation) {
+			t.Errorf("CheckHooks(%+v) = %v; want a validation error", hooks, err)
+		}
+	}
+	if err := CheckHooks(Hooks{Pre: []Hook{{Command: "true", Timeout: "5s"}}, Post: []Hook{{URL: "https://hooks.example.com/x"}}}); err != nil {
+		t.Errorf("CheckHooks of valid hooks: %v", err)
+	}
+}
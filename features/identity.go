@@ -0,0 +1,18 @@
+package features
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// CallerAccountID returns the AWS account ID of the active credentials using STS GetCallerIdentity.
+func CallerAccountID(cfg aws.Config) (string, error) {
+	result, err := sts.NewFromConfig(cfg).GetCallerIdentity(context.TODO(), &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get caller identity: %w", err)
+	}
+	return aws.ToString(result.Account), nil
+}
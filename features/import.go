@@ -0,0 +1,483 @@
+package features
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FormatJSON is nested JSON, for import and get-as-json.
+const FormatJSON = "json"
+
+// How arrays in structured files map to parameters.
+const (
+	ArraysStringList = "stringlist" // Arrays of plain values become one StringList parameter.
+	ArraysIndex      = "index"      // Each element becomes its own parameter, <key>/0, <key>/1, ...
+	ArraysJSON       = "json"       // The whole array is stored as one JSON-encoded String parameter.
+)
+
+// ImportOptions controls how a source file is mapped to parameters.
+type ImportOptions struct {
+	Format string // Input format (see InputReader); empty chooses it by the file's extension.
+	Prefix string // Path the file's top level is written under; a trailing "/" is added if missing.
+	Arrays string // ArraysStringList (default), ArraysIndex or ArraysJSON.
+	Strict bool   // Fail instead of warning when the file defines a key twice.
+}
+
+// treeReader reads structured files, in which nested tables, blocks or objects become path segments.
+type treeReader struct {
+	format string // FormatTOML, FormatHCL or FormatJSON.
+}
+
+func (r treeReader) Extensions() []string { return []string{"." + r.format} }
+
+func (r treeReader) Read(data []byte, filename string, opts InputOptions) ([]InputParam, error) {
+	tree, err := decodeStructured(data, filename, r.format)
+	if err != nil {
+		return nil, err
+	}
+	var params []InputParam
+	if err := flattenTree("", tree, opts.Arrays, &params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// ValidateArrays rejects unknown array modes.
+func ValidateArrays(mode string) error {
+	switch mode {
+	case ArraysStringList, ArraysIndex, ArraysJSON:
+		return nil
+	}
+	return validationErrorf("unknown array mode %q (use %s, %s or %s)", mode, ArraysStringList, ArraysIndex, ArraysJSON)
+}
+
+// ImportParameters puts every parameter of a source file under importOpts.Prefix. In the tree formats
+// nested tables, blocks or objects become path segments, so {"db": {"host": "x"}} is written to
+// <prefix>db/host, and arrays are mapped according to importOpts.Arrays. Parameters whose source sets a
+// full name (a "# param: path=" comment, a CSV name column or a task definition valueFrom) are put there;
+// types the source does not set are detected as for generate. Existing parameters are overwritten
+// according to opts.
+func ImportParameters(client SSMClient, filename string, importOpts ImportOptions, opts PutOptions) error {
+	prefix, err := importPrefix(importOpts.Prefix)
+	if err != nil {
+		return err
+	}
+	params, err := readInput(filename, importOpts.Format, InputOptions{Arrays: importOpts.Arrays, Strict: importOpts.Strict})
+	if err != nil {
+		return err
+	}
+	if len(params) == 0 {
+		return validationErrorf("no values found in %s", filename)
+	}
+	if opts.ChangedSince != "" {
+		if params, err = changedInputParams(filename, importOpts.Format, opts.ChangedSince, params); err != nil {
+			return err
+		}
+	}
+	return putInputParams(client, prefix, params, opts)
+}
+
+// changedInputParams returns the params of a .env file or task definition template whose lines changed since
+// the git revision ref.
+func changedInputParams(filename, format, ref string, params []InputParam) ([]InputParam, error) {
+	if format == "" {
+		format = InputFormatFromFile(filename)
+	}
+	data, err := readSource(filename)
+	if err != nil {
+		return nil, err
+	}
+	var ranges map[string][][2]int
+	switch format {
+	case InputDotenv:
+		ranges = envKeyRanges(data)
+	case InputTaskDef:
+		ranges = templateSecretRanges(data)
+	default:
+		return nil, validationErrorf("-changed-since supports the %s and %s formats, not %s", InputDotenv, InputTaskDef, format)
+	}
+	changed, err := sourceChangedKeys(filename, ref, ranges)
+	if err != nil {
+		return nil, err
+	}
+	var kept []InputParam
+	for _, p := range params {
+		if changed[p.Key] {
+			kept = append(kept, p)
+		}
+	}
+	return kept, nil
+}
+
+// importPrefix checks the prefix parameters are imported under and adds the trailing "/" if missing.
+func importPrefix(prefix string) (string, error) {
+	if !strings.HasPrefix(prefix, "/") {
+		return "", validationErrorf("-prefix must start with /, got %q", prefix)
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix, nil
+}
+
+// putInputParams puts params, naming those without a Name by prefix (which ends in "/") and their Key.
+func putInputParams(client SSMClient, prefix string, params []InputParam, opts PutOptions) error {
+	names := make([]string, len(params))
+	types := make([]ParameterType, len(params))
+	changes := make([]Change, len(params))
+	for i, p := range params {
+		names[i], types[i] = p.Name, p.Type
+		if names[i] == "" {
+			names[i] = prefix + p.Key
+		}
+		if types[i] == "" {
+			types[i] = detectParameterType(p.Key, p.Value)
+		}
+		changes[i] = Change{Operation: "PutParameter", Name: names[i], Type: string(types[i])}
+	}
+	if err := planChanges(client, changes); err != nil {
+		return err
+	}
+	for i, p := range params {
+		name, paramType := names[i], types[i]
+		putOpts := opts
+		if p.KeyID != "" {
+			putOpts.KeyID = p.KeyID
+		}
+		err := PutParameter(client, name, p.Value, paramType, putOpts)
+		if errors.Is(err, ErrOverwriteDeclined) {
+			Infof("%s %s: existing parameter not overwritten\n", yellow("Skipped"), name)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to put %s: %w", name, err)
+		}
+		if isDryRun(client) {
+			Infof("Would put %s as %s\n", name, paramType)
+		} else {
+			Infof("%s %s as %s\n", green("Put"), name, paramType)
+		}
+	}
+	return nil
+}
+
+// decodeStructured parses data into a tree of map[string]interface{}, []interface{} and scalars
+// (string, bool, int64, float64, json.Number or time.Time).
+func decodeStructured(data []byte, filename, format string) (map[string]interface{}, error) {
+	switch format {
+	case FormatTOML:
+		var doc map[string]interface{}
+		if _, err := toml.Decode(string(data), &doc); err != nil {
+			return nil, validationErrorf("failed to parse TOML: %w", err)
+		}
+		return doc, nil
+	case FormatHCL:
+		return decodeHCL(data, filename)
+	case FormatJSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber() // Keep numbers exactly as written.
+		var doc map[string]interface{}
+		if err := dec.Decode(&doc); err != nil {
+			return nil, validationErrorf("failed to parse JSON (the top level must be an object): %w", err)
+		}
+		return doc, nil
+	}
+	return nil, validationErrorf("cannot import format %q (use %s, %s or %s)", format, FormatTOML, FormatHCL, FormatJSON)
+}
+
+// flattenTree appends one InputParam per leaf of node, sorted by key.
+func flattenTree(name string, node interface{}, arrays string, params *[]InputParam) error {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 && name != "" {
+			return validationErrorf("%s: empty objects have no value to store", name)
+		}
+		var keys []string
+		for key := range v {
+			if key == "" || strings.Contains(key, "/") {
+				return validationErrorf("%s: key %q cannot be used as a path segment", name, key)
+			}
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := key
+			if name != "" {
+				child = name + "/" + key
+			}
+			if err := flattenTree(child, v[key], arrays, params); err != nil {
+				return err
+			}
+		}
+	case []map[string]interface{}: // TOML arrays of tables.
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		return flattenArray(name, items, arrays, params)
+	case []interface{}:
+		return flattenArray(name, v, arrays, params)
+	default:
+		s, ok := scalarString(v)
+		if !ok {
+			return validationErrorf("%s: unsupported value %v", name, v)
+		}
+		*params = append(*params, InputParam{Key: name, Value: s})
+	}
+	return nil
+}
+
+// flattenArray maps an array according to the array mode.
+func flattenArray(name string, items []interface{}, arrays string, params *[]InputParam) error {
+	if len(items) == 0 {
+		return validationErrorf("%s: empty arrays have no value to store", name)
+	}
+	switch arrays {
+	case ArraysIndex:
+		for i, item := range items {
+			if err := flattenTree(name+"/"+strconv.Itoa(i), item, arrays, params); err != nil {
+				return err
+			}
+		}
+	case ArraysJSON:
+		data, err := json.Marshal(items)
+		if err != nil {
+			return validationErrorf("%s: %w", name, err)
+		}
+		*params = append(*params, InputParam{Key: name, Value: string(data), Type: StringType})
+	default:
+		values := make([]string, 0, len(items))
+		for _, item := range items {
+			s, ok := scalarString(item)
+			if !ok {
+				return validationErrorf("%s: only arrays of plain values can be a StringList (use -arrays index or json)", name)
+			}
+			if strings.Contains(s, ",") {
+				return validationErrorf("%s: StringList items cannot contain commas (%q)", name, s)
+			}
+			values = append(values, s)
+		}
+		*params = append(*params, InputParam{Key: name, Value: strings.Join(values, ","), Type: StringListType})
+	}
+	return nil
+}
+
+// scalarString formats a string, number, boolean or date as a parameter value.
+func scalarString(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case int64, float64, bool:
+		return fmt.Sprint(v), true
+	case time.Time:
+		// TOML local dates and times keep the form they were written in; the decoder marks them by zone name.
+		switch v.Location().String() {
+		case "date-local":
+			return v.Format("2006-01-02"), true
+		case "time-local":
+			return v.Format("15:04:05.999999999"), true
+		case "datetime-local":
+			return v.Format("2006-01-02T15:04:05.999999999"), true
+		}
+		return v.Format(time.RFC3339Nano), true
+	}
+	return "", false
+}
+
+// decodeHCL decodes an HCL file into a tree. Attributes are values; each block nests under its type and
+// labels, so db "primary" { host = "x" } becomes db/primary/host. Expressions may not reference variables
+// or functions.
+func decodeHCL(data []byte, filename string) (map[string]interface{}, error) {
+	file, diags := hclsyntax.ParseConfig(data, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, validationErrorf("failed to parse HCL: %s", diags.Error())
+	}
+	root := make(map[string]interface{})
+	if err := decodeHCLBody("", file.Body.(*hclsyntax.Body), root); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+func decodeHCLBody(path string, body *hclsyntax.Body, into map[string]interface{}) error {
+	for name, attr := range body.Attributes {
+		value, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return validationErrorf("%s%s: %s", path, name, diags.Error())
+		}
+		converted, err := ctyToTree(path+name, value)
+		if err != nil {
+			return err
+		}
+		if _, exists := into[name]; exists {
+			return validationErrorf("%s%s is defined more than once", path, name)
+		}
+		into[name] = converted
+	}
+	for _, block := range body.Blocks {
+		node := into
+		blockPath := path
+		for _, segment := range append([]string{block.Type}, block.Labels...) {
+			blockPath += segment + "/"
+			child, exists := node[segment]
+			if !exists {
+				child = make(map[string]interface{})
+				node[segment] = child
+			}
+			section, ok := child.(map[string]interface{})
+			if !ok {
+				return validationErrorf("%s is both a value and a block", strings.TrimSuffix(blockPath, "/"))
+			}
+			node = section
+		}
+		if err := decodeHCLBody(blockPath, block.Body, node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ctyToTree converts an evaluated HCL value to the tree form used by decodeStructured.
+func ctyToTree(name string, value cty.Value) (interface{}, error) {
+	if value.IsNull() || !value.IsKnown() {
+		return nil, validationErrorf("%s: value must be known and not null", name)
+	}
+	t := value.Type()
+	switch {
+	case t.IsObjectType() || t.IsMapType():
+		out := make(map[string]interface{})
+		for key, field := range value.AsValueMap() {
+			converted, err := ctyToTree(name+"/"+key, field)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = converted
+		}
+		return out, nil
+	case t.IsTupleType() || t.IsListType() || t.IsSetType():
+		out := []interface{}{}
+		for i, item := range value.AsValueSlice() {
+			converted, err := ctyToTree(fmt.Sprintf("%s/%d", name, i), item)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, converted)
+		}
+		return out, nil
+	case t == cty.String:
+		return value.AsString(), nil
+	case t == cty.Number:
+		return json.Number(value.AsBigFloat().Text('f', -1)), nil
+	case t == cty.Bool:
+		return value.True(), nil
+	}
+	return nil, validationErrorf("%s: unsupported value of type %s", name, t.FriendlyName())
+}
+
+// GetParametersAsJSON reads every parameter under prefix and rebuilds the nested JSON object,
+// the inverse of ImportParameters. Values are strings; arrays are rebuilt according to arrays from
+// StringList parameters (stringlist), objects whose keys are 0..n-1 (index), or values holding a JSON array (json).
+func GetParametersAsJSON(client SSMClient, prefix, arrays string) ([]byte, error) {
+	if err := ValidateArrays(arrays); err != nil {
+		return nil, err
+	}
+	params, err := listParameters(client, prefix)
+	if err != nil {
+		return nil, err
+	}
+	root := make(map[string]interface{})
+	for _, param := range params {
+		parts := splitPath(strings.TrimPrefix(*param.Name, prefix))
+		if len(parts) == 0 {
+			continue
+		}
+		var value interface{} = *param.Value
+		switch {
+		case arrays == ArraysStringList && ParameterType(param.Type) == StringListType:
+			var items []interface{}
+			for _, item := range strings.Split(*param.Value, ",") {
+				items = append(items, item)
+			}
+			value = items
+		case arrays == ArraysJSON && strings.HasPrefix(*param.Value, "["):
+			var items []interface{}
+			if json.Unmarshal([]byte(*param.Value), &items) == nil {
+				value = items
+			}
+		}
+		if err := insertTree(root, parts, value); err != nil {
+			return nil, err
+		}
+	}
+	if arrays == ArraysIndex {
+		for key, child := range root {
+			root[key] = indexedToArrays(child) // The top level stays an object.
+		}
+	}
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// insertTree stores value at the path parts below root.
+func insertTree(root map[string]interface{}, parts []string, value interface{}) error {
+	node := root
+	for i, part := range parts {
+		if i == len(parts)-1 {
+			if _, exists := node[part]; exists {
+				return validationErrorf("%s is both a value and a parent path", strings.Join(parts, "/"))
+			}
+			node[part] = value
+			return nil
+		}
+		child, exists := node[part]
+		if !exists {
+			child = make(map[string]interface{})
+			node[part] = child
+		}
+		section, ok := child.(map[string]interface{})
+		if !ok {
+			return validationErrorf("%s is both a value and a parent path", strings.Join(parts[:i+1], "/"))
+		}
+		node = section
+	}
+	return nil
+}
+
+// indexedToArrays turns every object whose keys are exactly 0..n-1 into an array, recursively.
+func indexedToArrays(node interface{}) interface{} {
+	obj, ok := node.(map[string]interface{})
+	if !ok {
+		return node
+	}
+	for key, child := range obj {
+		obj[key] = indexedToArrays(child)
+	}
+	if len(obj) == 0 {
+		return obj
+	}
+	items := make([]interface{}, len(obj))
+	for key, child := range obj {
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(obj) || strconv.Itoa(i) != key {
+			return obj
+		}
+		items[i] = child
+	}
+	return items
+}
@@ -0,0 +1,149 @@
+package features
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestImportParameters(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		file string
+		src  string
+	}{
+		{"config.toml", `
+name = "api"
+ports = [8080, 8081]
+
+[db]
+host = "db.internal"
+port = 5432
+
+[db.replica]
+host = "replica.internal"
+`},
+		{"config.hcl", `
+name  = "api"
+ports = [8080, 8081]
+
+db {
+  host = "db.internal"
+  port = 5432
+
+  replica {
+    host = "replica.internal"
+  }
+}
+`},
+	}
+	want := map[string]string{
+		"/prod/app/name":            "api",
+		"/prod/app/ports":           "8080,8081",
+		"/prod/app/db/host":         "db.internal",
+		"/prod/app/db/port":         "5432",
+		"/prod/app/db/replica/host": "replica.internal",
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.file)
+		if err := os.WriteFile(path, []byte(tt.src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		fake := newFakeSSM()
+		if err := ImportParameters(fake, path, ImportOptions{Prefix: "/prod/app"}, PutOptions{AssumeYes: true}); err != nil {
+			t.Fatalf("%s: ImportParameters: %v", tt.file, err)
+		}
+		if len(fake.params) != len(want) {
+			t.Errorf("%s: imported %d parameters; want %d", tt.file, len(fake.params), len(want))
+		}
+		for name, value := range want {
+			if p, ok := fake.params[name]; !ok || *p.Value != value {
+				t.Errorf("%s: %s = %v; want %q", tt.file, name, p.Value, value)
+			}
+		}
+		if got := fake.params["/prod/app/ports"].Type; got != types.ParameterTypeStringList {
+			t.Errorf("%s: ports type = %s; want StringList", tt.file, got)
+		}
+	}
+}
+
+func TestImportJSONArrayModes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	src := `{"db": {"host": "x", "port": 5432}, "hosts": ["a", "b"], "users": [{"name": "u1"}, {"name": "u2"}]}`
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := newFakeSSM()
+	opts := ImportOptions{Format: FormatJSON, Prefix: "/prod/app/"}
+	if err := ImportParameters(fake, path, opts, PutOptions{AssumeYes: true}); !errors.Is(err, ErrValidation) {
+		t.Errorf("stringlist with objects err = %v; want ErrValidation", err)
+	}
+
+	for mode, want := range map[string]map[string]string{
+		ArraysIndex: {
+			"/prod/app/db/host": "x", "/prod/app/db/port": "5432",
+			"/prod/app/hosts/0": "a", "/prod/app/hosts/1": "b",
+			"/prod/app/users/0/name": "u1", "/prod/app/users/1/name": "u2",
+		},
+		ArraysJSON: {
+			"/prod/app/db/host": "x", "/prod/app/db/port": "5432",
+			"/prod/app/hosts": `["a","b"]`, "/prod/app/users": `[{"name":"u1"},{"name":"u2"}]`,
+		},
+	} {
+		fake := newFakeSSM()
+		opts.Arrays = mode
+		if err := ImportParameters(fake, path, opts, PutOptions{AssumeYes: true}); err != nil {
+			t.Fatalf("%s: ImportParameters: %v", mode, err)
+		}
+		if len(fake.params) != len(want) {
+			t.Errorf("%s: imported %d parameters; want %d", mode, len(fake.params), len(want))
+		}
+		for name, value := range want {
+			if p, ok := fake.params[name]; !ok || *p.Value != value {
+				t.Errorf("%s: %s = %v; want %q", mode, name, p.Value, value)
+			}
+		}
+
+		// get-as-json with the same mode rebuilds the tree, with values as strings.
+		data, err := GetParametersAsJSON(fake, "/prod/app/", mode)
+		if err != nil {
+			t.Fatalf("%s: GetParametersAsJSON: %v", mode, err)
+		}
+		var got map[string]interface{}
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("%s: %v", mode, err)
+		}
+		wantTree := map[string]interface{}{
+			"db":    map[string]interface{}{"host": "x", "port": "5432"},
+			"hosts": []interface{}{"a", "b"},
+			"users": []interface{}{map[string]interface{}{"name": "u1"}, map[string]interface{}{"name": "u2"}},
+		}
+		if !reflect.DeepEqual(got, wantTree) {
+			t.Errorf("%s: GetParametersAsJSON = %s", mode, data)
+		}
+	}
+}
+
+func TestRenderEnvTOMLAndHCL(t *testing.T) {
+	vars := []EnvVar{{"name", "api"}, {"db/host", "db.internal"}, {"db/port", "5432"}}
+	got, err := RenderEnv(FormatTOML, vars)
+	if err != nil {
+		t.Fatalf("RenderEnv(toml): %v", err)
+	}
+	if want := "name = \"api\"\n\n[db]\n  host = \"db.internal\"\n  port = \"5432\"\n"; got != want {
+		t.Errorf("RenderEnv(toml) =\n%s\nwant\n%s", got, want)
+	}
+	got, err = RenderEnv(FormatHCL, vars)
+	if err != nil {
+		t.Fatalf("RenderEnv(hcl): %v", err)
+	}
+	if want := "name = \"api\"\n\ndb {\n  host = \"db.internal\"\n  port = \"5432\"\n}\n"; got != want {
+		t.Errorf("RenderEnv(hcl) =\n%s\nwant\n%s", got, want)
+	}
+}
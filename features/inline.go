@@ -0,0 +1,117 @@
+package features
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// inlineWarning is stored in inlined task definitions, where a JSON comment cannot go. RegisterTaskDefinition
+// rejects the unknown field, so the file cannot be registered by accident.
+const inlineWarning = "LOCAL DEVELOPMENT ONLY: secrets from SSM are inlined as plaintext environment values. Do not register, commit or share this file."
+
+// InlineTaskDef resolves the secrets of every container in a task definition and writes them as plain
+// environment entries to outputFile, for local ECS emulators without SSM access. Placeholders in valueFrom
+// are expanded from tmplOpts. Nothing is written unless every secret resolves. The output is readable
+// only by the current user and carries a warning field.
+func InlineTaskDef(client SSMClient, filename, outputFile string, tmplOpts TemplateOptions) error {
+	if sameFile(outputFile, filename) {
+		return validationErrorf("-o %s would overwrite the source file %s", outputFile, filename)
+	}
+	data, err := readSource(filename)
+	if err != nil {
+		return err
+	}
+	if err := validateTemplate(data, filename); err != nil {
+		return err
+	}
+	var jsonMap map[string]interface{}
+	if err := json.Unmarshal(data, &jsonMap); err != nil {
+		return validationErrorf("failed to parse %s: %w", filename, err)
+	}
+	jsonMap = unwrapTaskDef(jsonMap)
+	containerDefs, ok := jsonMap["containerDefinitions"].([]interface{})
+	if !ok || len(containerDefs) == 0 {
+		return validationErrorf("no container definitions found")
+	}
+
+	var failures []error
+	total := 0
+	for _, def := range containerDefs {
+		containerDef, ok := def.(map[string]interface{})
+		if !ok {
+			return validationErrorf("invalid container definition")
+		}
+		secrets, _ := containerDef["secrets"].([]interface{})
+		environment, _ := containerDef["environment"].([]interface{})
+		envIndex := make(map[string]int) // Position of each environment name, so a secret replaces it.
+		for i, entry := range environment {
+			if env, ok := entry.(map[string]interface{}); ok {
+				if name, ok := env["name"].(string); ok {
+					envIndex[name] = i
+				}
+			}
+		}
+		for _, sec := range secrets {
+			secret, ok := sec.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := secret["name"].(string)
+			valueFrom, _ := secret["valueFrom"].(string)
+			total++
+			value, err := resolveInlineSecret(client, valueFrom, tmplOpts)
+			if err != nil {
+				Infof("%s %s: %s\n", red("Failed to resolve"), name, DescribeError(err))
+				failures = append(failures, fmt.Errorf("secret %s: %w", name, err))
+				continue
+			}
+			entry := map[string]interface{}{"name": name, "value": value}
+			if i, ok := envIndex[name]; ok {
+				environment[i] = entry
+			} else {
+				envIndex[name] = len(environment)
+				environment = append(environment, entry)
+			}
+		}
+		if environment != nil {
+			containerDef["environment"] = environment
+		}
+		delete(containerDef, "secrets")
+	}
+	if len(failures) > 0 {
+		return &PartialFailureError{Failed: failures, Total: total}
+	}
+
+	jsonMap["_warning"] = inlineWarning
+	jsonData, err := json.MarshalIndent(jsonMap, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if err := os.WriteFile(outputFile, jsonData, 0600); err != nil {
+		return fmt.Errorf("failed to write JSON file %s: %w", outputFile, err)
+	}
+	fmt.Fprintf(os.Stderr, "WARNING: %s now holds %d secret values in plaintext. %s\n", outputFile, total, inlineWarning)
+	Infof("Saved inlined task definition to %s\n", outputFile)
+	return nil
+}
+
+// resolveInlineSecret fetches the value a secret's valueFrom refers to.
+func resolveInlineSecret(client SSMClient, valueFrom string, tmplOpts TemplateOptions) (string, error) {
+	valueFrom, err := ExpandTemplateVars(valueFrom, tmplOpts.Vars)
+	if err != nil {
+		return "", err
+	}
+	if err := checkARNScope(valueFrom, tmplOpts); err != nil {
+		return "", err
+	}
+	paramName, err := tmplOpts.parameterName(valueFrom)
+	if err != nil {
+		return "", err
+	}
+	if paramName == "" {
+		return "", validationErrorf("invalid valueFrom %q", valueFrom)
+	}
+	value, _, err := GetParameter(client, paramName)
+	return value, err
+}
@@ -0,0 +1,86 @@
+package features
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestInlineTaskDef(t *testing.T) {
+	dir := t.TempDir()
+	template := filepath.Join(dir, "task.json")
+	output := filepath.Join(dir, "local.json")
+	data := `{
+  "family": "app",
+  "containerDefinitions": [
+    {
+      "name": "web",
+      "environment": [{"name": "LOG_LEVEL", "value": "info"}, {"name": "DB_URL", "value": "stale"}],
+      "secrets": [
+        {"name": "DB_URL", "valueFrom": "/{{env}}/app/DB_URL"},
+        {"name": "API_TOKEN", "valueFrom": "arn:aws:ssm:ap-southeast-3:1234:parameter/dev/app/API_TOKEN"}
+      ]
+    },
+    {"name": "worker", "secrets": [{"name": "DB_URL", "valueFrom": "/dev/app/DB_URL"}]}
+  ]
+}`
+	if err := os.WriteFile(template, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeSSM()
+	fake.set("/dev/app/DB_URL", "postgres://db", types.ParameterTypeString)
+	tmplOpts := TemplateOptions{Vars: map[string]string{"env": "dev"}, Region: "ap-southeast-3", AccountID: "1234"}
+
+	if err := InlineTaskDef(fake, template, output, tmplOpts); ExitCode(err) != ExitPartialFailure {
+		t.Fatalf("inline with a missing parameter error = %v; want a partial failure", err)
+	}
+	if _, err := os.Stat(output); err == nil {
+		t.Fatalf("inline wrote %s although a secret did not resolve", output)
+	}
+
+	fake.set("/dev/app/API_TOKEN", "t0ken", types.ParameterTypeSecureString)
+	if err := InlineTaskDef(fake, template, output, tmplOpts); err != nil {
+		t.Fatalf("inline: %v", err)
+	}
+	info, err := os.Stat(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("output mode = %o; want 600", perm)
+	}
+	out, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var taskDef struct {
+		Warning              string                   `json:"_warning"`
+		ContainerDefinitions []map[string]interface{} `json:"containerDefinitions"`
+	}
+	if err := json.Unmarshal(out, &taskDef); err != nil {
+		t.Fatal(err)
+	}
+	if taskDef.Warning == "" {
+		t.Error("inlined task definition has no _warning field")
+	}
+	want := []interface{}{
+		map[string]interface{}{"name": "LOG_LEVEL", "value": "info"},
+		map[string]interface{}{"name": "DB_URL", "value": "postgres://db"},
+		map[string]interface{}{"name": "API_TOKEN", "value": "t0ken"},
+	}
+	for i, c := range taskDef.ContainerDefinitions {
+		if _, ok := c["secrets"]; ok {
+			t.Errorf("container %d still has secrets", i)
+		}
+	}
+	if got := taskDef.ContainerDefinitions[0]["environment"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("web environment = %v; want %v", got, want)
+	}
+	if got, want := taskDef.ContainerDefinitions[1]["environment"], []interface{}{want[1]}; !reflect.DeepEqual(got, want) {
+		t.Errorf("worker environment = %v; want %v", got, want)
+	}
+}
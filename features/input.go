@@ -0,0 +1,344 @@
+package features
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Input formats that are not also output formats. FormatJSON, FormatTOML and FormatHCL are read as trees.
+const (
+	InputDotenv  = "dotenv"      // KEY=value lines with "# param:" metadata (see envDirective).
+	InputCSV     = "csv"         // Rows of key or name, value and optionally type and kms, under a header.
+	InputTaskDef = "ecs-taskdef" // Secrets of an ECS task definition template, with their value and type.
+)
+
+// InputParam is one parameter read from a source file by an InputReader.
+type InputParam struct {
+	Key   string        // Name relative to the prefix the source is put under, such as DB_HOST or db/host.
+	Name  string        // Full parameter name when the source sets it; empty uses the prefix plus Key.
+	Value string        // Value to put.
+	Type  ParameterType // Empty to detect from the key and value, as generate does.
+	KeyID string        // KMS key for a SecureString; empty for the account's aws/ssm key.
+}
+
+// InputOptions controls how sources are read.
+type InputOptions struct {
+	Arrays string // How arrays of tree formats map to parameters; ArraysStringList if empty.
+	Strict bool   // Fail instead of warning when a key is defined twice.
+}
+
+// InputReader reads the parameters of a source file in one format. import and generate look the format up
+// among the registered readers (see RegisterInputReader), by -input-format or by the file's extension.
+type InputReader interface {
+	// Extensions lists the file name suffixes that select the format, such as ".env"; it may be empty for
+	// formats that must be chosen by name.
+	Extensions() []string
+	// Read returns the parameters of data, in source order. filename is used in messages.
+	Read(data []byte, filename string, opts InputOptions) ([]InputParam, error)
+}
+
+// inputFormat is a registered input format.
+type inputFormat struct {
+	name   string
+	reader InputReader
+}
+
+var (
+	inputMu      sync.RWMutex
+	inputFormats []inputFormat // In registration order.
+)
+
+func init() {
+	RegisterInputReader(InputDotenv, dotenvReader{})
+	RegisterInputReader(FormatJSON, treeReader{format: FormatJSON})
+	RegisterInputReader(FormatTOML, treeReader{format: FormatTOML})
+	RegisterInputReader(FormatHCL, treeReader{format: FormatHCL})
+	RegisterInputReader(InputCSV, csvReader{})
+	RegisterInputReader(InputTaskDef, taskDefReader{})
+	RegisterInputReader(InputTerraform, terraformReader{})
+	RegisterInputReader(Input1Password, onePasswordReader{})
+	RegisterInputReader(InputBitwarden, bitwardenReader{})
+}
+
+// RegisterInputReader makes format available to import and generate, read by r. Programs embedding the
+// package call it from an init function. It panics if format is empty or already registered.
+func RegisterInputReader(format string, r InputReader) {
+	inputMu.Lock()
+	defer inputMu.Unlock()
+	if format == "" || r == nil {
+		panic("features: RegisterInputReader needs a format name and a reader")
+	}
+	for _, f := range inputFormats {
+		if f.name == format {
+			panic("features: input format " + format + " registered twice")
+		}
+	}
+	inputFormats = append(inputFormats, inputFormat{name: format, reader: r})
+}
+
+// InputFormatNames returns every input format name.
+func InputFormatNames() []string {
+	inputMu.RLock()
+	defer inputMu.RUnlock()
+	names := make([]string, len(inputFormats))
+	for i, f := range inputFormats {
+		names[i] = f.name
+	}
+	return names
+}
+
+// InputFormatFromFile returns the input format whose extension ends filename, preferring the longest
+// match, or "" if none does.
+func InputFormatFromFile(filename string) string {
+	inputMu.RLock()
+	defer inputMu.RUnlock()
+	format, longest := "", 0
+	lower := strings.ToLower(filename)
+	for _, f := range inputFormats {
+		for _, ext := range f.reader.Extensions() {
+			if strings.HasSuffix(lower, ext) && len(ext) > longest {
+				format, longest = f.name, len(ext)
+			}
+		}
+	}
+	return format
+}
+
+// lookupInput returns the reader of format, or a validation error listing the known formats.
+func lookupInput(format string) (InputReader, error) {
+	inputMu.RLock()
+	for _, f := range inputFormats {
+		if f.name == format {
+			inputMu.RUnlock()
+			return f.reader, nil
+		}
+	}
+	inputMu.RUnlock()
+	return nil, validationErrorf("unknown input format %q (use one of: %s)", format, strings.Join(InputFormatNames(), ", "))
+}
+
+// readInput reads the parameters of filename (or standard input, see StdinSource) in format, or in the
+// format of its extension if format is empty.
+func readInput(filename, format string, opts InputOptions) ([]InputParam, error) {
+	if format == "" {
+		format = InputFormatFromFile(filename)
+	}
+	if format == "" {
+		return nil, validationErrorf("cannot tell the format of %s from its name; use -input-format (one of: %s)", filename, strings.Join(InputFormatNames(), ", "))
+	}
+	reader, err := lookupInput(format)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Arrays == "" {
+		opts.Arrays = ArraysStringList
+	}
+	if err := ValidateArrays(opts.Arrays); err != nil {
+		return nil, err
+	}
+	data, err := readSource(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return reader.Read(data, filename, opts)
+}
+
+// envKeyLine matches a line that starts a new .env key.
+var envKeyLine = regexp.MustCompile(`^[A-Z_][A-Z0-9_]*=`)
+
+// dotenvReader reads .env files. Lines after a key that do not start a new key or "# param:" comment are
+// appended to its value, for multi-line certificates.
+type dotenvReader struct{}
+
+func (dotenvReader) Extensions() []string { return []string{".env"} }
+
+func (dotenvReader) Read(data []byte, filename string, opts InputOptions) ([]InputParam, error) {
+	lines := strings.Split(string(data), "\n")
+	var params []InputParam
+	keyLines := make(map[string][]int) // Line numbers defining each key.
+	keyIndex := make(map[string]int)   // Index of each key in params.
+	var directive *envDirective        // Pending "# param:" metadata for the next key.
+	i := 0
+	for i < len(lines) {
+		line := strings.TrimSpace(lines[i])
+		if isEnvDirective(line) {
+			if directive == nil {
+				directive = &envDirective{Line: i + 1}
+			}
+			if err := directive.merge(line); err != nil {
+				return nil, validationErrorf("%s line %d: %w", filename, i+1, err)
+			}
+			i++
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			i++
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			i++
+			continue // Skip invalid lines
+		}
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+		keyLines[key] = append(keyLines[key], i+1)
+		// Accumulate multiline values until the next key=value line
+		for j := i + 1; j < len(lines); j++ {
+			nextLine := strings.TrimSpace(lines[j])
+			if envKeyLine.MatchString(nextLine) || isEnvDirective(nextLine) {
+				// Next line looks like a new key=value or its metadata, stop accumulating
+				i = j - 1 // Set i to j-1 so i++ will process the next key
+				break
+			} else if nextLine != "" { // Skip empty lines but accumulate non-empty
+				value += "\n" + nextLine
+			}
+			if j == len(lines)-1 {
+				i = j // If end of file, set i to last
+			}
+		}
+		param := InputParam{Key: key, Value: value}
+		if directive != nil {
+			param = directive.apply(param)
+			directive = nil
+		}
+		if index, ok := keyIndex[key]; ok {
+			params[index] = param
+		} else {
+			keyIndex[key] = len(params)
+			params = append(params, param)
+		}
+		i++
+	}
+	if directive != nil {
+		return nil, validationErrorf("%s line %d: # param: is not followed by a key", filename, directive.Line)
+	}
+	if err := reportDuplicates(filename, findDuplicates(keyLines), opts.Strict); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// csvReader reads CSV files whose header names the columns: key (relative to the prefix) or name (a full
+// parameter name), value, and optionally type and kms. Other columns are ignored.
+type csvReader struct{}
+
+func (csvReader) Extensions() []string { return []string{".csv"} }
+
+func (csvReader) Read(data []byte, filename string, opts InputOptions) ([]InputParam, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return nil, validationErrorf("%s: missing CSV header: %w", filename, err)
+	}
+	columns := make(map[string]int)
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	_, hasKey := columns["key"]
+	_, hasName := columns["name"]
+	if _, ok := columns["value"]; !ok || !hasKey && !hasName {
+		return nil, validationErrorf("%s: the CSV header needs a value column and a key or name column, got %q", filename, strings.Join(header, ","))
+	}
+	field := func(record []string, column string) string {
+		if i, ok := columns[column]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	var params []InputParam
+	keyLines := make(map[string][]int)
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, validationErrorf("%s: %w", filename, err)
+		}
+		line, _ := r.FieldPos(0)
+		param := InputParam{Key: field(record, "key"), Name: field(record, "name"), Value: field(record, "value"), KeyID: field(record, "kms")}
+		if param.Key == "" && param.Name == "" {
+			return nil, validationErrorf("%s line %d: no key or name", filename, line)
+		}
+		if param.Name != "" && param.Key == "" {
+			param.Key = param.Name[strings.LastIndex(param.Name, "/")+1:]
+		}
+		if t := field(record, "type"); t != "" {
+			if param.Type, err = ParseParameterType(t); err != nil {
+				return nil, validationErrorf("%s line %d: %w", filename, line, err)
+			}
+		}
+		if param.KeyID != "" && param.Type == "" {
+			param.Type = SecureStringType
+		}
+		id := param.Name
+		if id == "" {
+			id = param.Key
+		}
+		keyLines[id] = append(keyLines[id], line)
+		params = append(params, param)
+	}
+	if err := reportDuplicates(filename, findDuplicates(keyLines), opts.Strict); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// taskDefReader reads the secrets of the first container of a task definition template, as put-from-template
+// does but without template variables or references: the parameter is named by valueFrom, or by the prefix
+// and the secret name when valueFrom is empty.
+type taskDefReader struct{}
+
+func (taskDefReader) Extensions() []string { return nil } // .json is read as a tree; choose this by name.
+
+func (taskDefReader) Read(data []byte, filename string, opts InputOptions) ([]InputParam, error) {
+	if err := validateTemplate(data, filename); err != nil {
+		return nil, err
+	}
+	var taskDef TaskDefinition
+	if err := json.Unmarshal(unwrapTaskDefJSON(data), &taskDef); err != nil {
+		return nil, validationErrorf("failed to parse %s: %w", filename, err)
+	}
+	if len(taskDef.ContainerDefinitions) == 0 {
+		return nil, validationErrorf("no container definitions found")
+	}
+	if err := reportDuplicates(filename, findDuplicates(templateSecretLines(data)), opts.Strict); err != nil {
+		return nil, err
+	}
+	var params []InputParam
+	index := make(map[string]int)
+	for _, secret := range taskDef.ContainerDefinitions[0].Secrets {
+		if secret.Value == "" {
+			continue // Nothing to put, as with put-from-template.
+		}
+		param := InputParam{Key: secret.Name, Value: secret.Value, KeyID: secret.KMSKeyID}
+		if secret.ValueFrom != "" {
+			if param.Name = ExtractParameterName(secret.ValueFrom); param.Name == "" {
+				return nil, validationErrorf("%s: invalid valueFrom for %s: %s", filename, secret.Name, secret.ValueFrom)
+			}
+		}
+		if secret.Type != "" {
+			paramType, err := ParseParameterType(string(secret.Type))
+			if err != nil {
+				return nil, validationErrorf("%s: secret %s: %w", filename, secret.Name, err)
+			}
+			param.Type = paramType
+		}
+		if i, ok := index[secret.Name]; ok {
+			params[i] = param // The last one is used.
+		} else {
+			index[secret.Name] = len(params)
+			params = append(params, param)
+		}
+	}
+	return params, nil
+}
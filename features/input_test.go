@@ -0,0 +1,122 @@
+package features
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestImportInputFormats(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"app.env": "DB_HOST=db\n# param: path=/shared/API_TOKEN\nAPI_TOKEN=t0k\n",
+		"app.csv": "key,value,type\nDB_HOST,db,\nPORTS,\"80,443\",stringlist\n",
+		"task.json": `{"containerDefinitions": [{"secrets": [
+  {"name": "DB_HOST", "valueFrom": "/prod/web/DB_HOST", "value": "db", "type": "String"},
+  {"name": "EMPTY", "valueFrom": "/prod/web/EMPTY"}
+]}]}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		file, format string
+		want         map[string]types.ParameterType
+	}{
+		{"app.env", "", map[string]types.ParameterType{"/prod/app/DB_HOST": types.ParameterTypeString, "/shared/API_TOKEN": types.ParameterTypeSecureString}},
+		{"app.csv", "", map[string]types.ParameterType{"/prod/app/DB_HOST": types.ParameterTypeString, "/prod/app/PORTS": types.ParameterTypeStringList}},
+		{"task.json", InputTaskDef, map[string]types.ParameterType{"/prod/web/DB_HOST": types.ParameterTypeString}},
+	}
+	for _, tt := range tests {
+		fake := newFakeSSM()
+		err := ImportParameters(fake, filepath.Join(dir, tt.file), ImportOptions{Format: tt.format, Prefix: "/prod/app/"}, PutOptions{AssumeYes: true})
+		if err != nil {
+			t.Errorf("import %s: %v", tt.file, err)
+			continue
+		}
+		got := make(map[string]types.ParameterType)
+		for name, p := range fake.params {
+			got[name] = p.Type
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("import %s put %v; want %v", tt.file, got, tt.want)
+		}
+	}
+
+	err := ImportParameters(newFakeSSM(), filepath.Join(dir, "app.txt"), ImportOptions{Prefix: "/prod/app/"}, PutOptions{AssumeYes: true})
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("import of an unknown extension: err = %v; want ErrValidation", err)
+	}
+}
+
+func TestGenerateFromTOML(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "app.toml")
+	if err := os.WriteFile(source, []byte("[db]\nhost = \"db\"\npassword = \"pw\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "task.json")
+	if err := GenerateTaskDef(source, out, GenerateOptions{Prefix: "/app/"}); err != nil {
+		t.Fatalf("GenerateTaskDef: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var taskDef TaskDefinition
+	if err := json.Unmarshal(data, &taskDef); err != nil {
+		t.Fatal(err)
+	}
+	want := []ExtendedSecret{
+		{Name: "db/host", ValueFrom: "/app/db/host", Type: StringType, Value: "db"},
+		{Name: "db/password", ValueFrom: "/app/db/password", Type: SecureStringType, Value: "pw"},
+	}
+	if got := taskDef.ContainerDefinitions[0].Secrets; !reflect.DeepEqual(got, want) {
+		t.Errorf("secrets = %+v; want %+v", got, want)
+	}
+}
+
+// linesReader is a third-party style reader of "KEY value" lines, registered as "test-lines".
+type linesReader struct{}
+
+func (linesReader) Extensions() []string { return []string{".lines"} }
+
+func (linesReader) Read(data []byte, _ string, _ InputOptions) ([]InputParam, error) {
+	var params []InputParam
+	for _, line := range strings.Split(string(data), "\n") {
+		if key, value, ok := strings.Cut(line, " "); ok {
+			params = append(params, InputParam{Key: key, Value: value})
+		}
+	}
+	return params, nil
+}
+
+func init() {
+	RegisterInputReader("test-lines", linesReader{})
+}
+
+func TestRegisteredInputReader(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "app.lines")
+	if err := os.WriteFile(source, []byte("HOST db\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := InputFormatFromFile(source); got != "test-lines" {
+		t.Errorf("InputFormatFromFile(app.lines) = %q; want test-lines", got)
+	}
+	fake := newFakeSSM()
+	if err := ImportParameters(fake, source, ImportOptions{Prefix: "/app/"}, PutOptions{AssumeYes: true}); err != nil {
+		t.Fatalf("ImportParameters: %v", err)
+	}
+	if p, ok := fake.params["/app/HOST"]; !ok || aws.ToString(p.Value) != "db" {
+		t.Errorf("/app/HOST = %+v; want db", p)
+	}
+}
@@ -0,0 +1,158 @@
+package features
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// putChoice is one secret of the put-from-template checklist with how it differs from the live parameter.
+type putChoice struct {
+	put      *templatePut
+	status   string // "+" new, "~" changed or "=" unchanged, as check-drift marks them.
+	detail   string // What changes, for "~".
+	selected bool
+}
+
+// selectTemplatePuts shows the secrets of a template as a checklist on stderr, with the new and changed ones
+// selected, and reads toggles from stdin until an empty line. It returns the selected puts of ordered, in
+// that order; none when the user quits. puts is the template order the list is shown in.
+func selectTemplatePuts(client SSMClient, filename string, puts, ordered []*templatePut) ([]*templatePut, error) {
+	if stdinSource.read {
+		return nil, validationErrorf("-interactive reads the selection from standard input, which was read as -s")
+	}
+	choices := make([]*putChoice, len(puts))
+	for i, p := range puts {
+		c := &putChoice{put: p, status: "="}
+		value, paramType, err := GetParameter(client, p.paramName)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			c.status = "+"
+		case err != nil:
+			return nil, err
+		case paramType != p.paramType:
+			c.status, c.detail = "~", fmt.Sprintf("type %s -> %s", paramType, p.paramType)
+		case value != p.secret.Value:
+			c.status, c.detail = "~", fmt.Sprintf("value %s -> %s", maskValue(value), maskValue(p.secret.Value))
+		}
+		c.selected = c.status != "="
+		choices[i] = c
+	}
+
+	fmt.Fprintf(os.Stderr, "Secrets of %s (+ new, ~ changed, = unchanged):\n", filename)
+	printChoices(choices)
+	for {
+		count := 0
+		for _, c := range choices {
+			if c.selected {
+				count++
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Toggle by number or range (2 5-7), a all, n none, c changed; Enter puts %d, q quits: ", count)
+		line, err := stdinReader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read the selection: %w", err)
+		}
+		if err == io.EOF && line == "" {
+			fmt.Fprintln(os.Stderr) // End of input quits.
+			return nil, nil
+		}
+		line = strings.TrimSpace(line)
+		switch line {
+		case "":
+			return selectedPuts(choices, ordered), nil
+		case "q":
+			return nil, nil
+		}
+		if err := toggleChoices(choices, line); err != nil {
+			fmt.Fprintf(os.Stderr, "%s %v\n", red("Error:"), err)
+			continue
+		}
+		printChoices(choices)
+	}
+}
+
+// printChoices prints the checklist, one numbered line per secret.
+func printChoices(choices []*putChoice) {
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	for i, c := range choices {
+		box := "[ ]"
+		if c.selected {
+			box = "[x]"
+		}
+		fmt.Fprintf(w, "  %s %d\t%s %s\t%s\t%s\n", box, i+1, c.status, c.put.secret.Name, c.put.paramName, c.detail)
+	}
+	w.Flush()
+}
+
+// toggleChoices applies one line of checklist input: numbers and ranges toggle those secrets, "a" selects
+// all, "n" none and "c" the new and changed ones. Nothing changes when any of it is invalid.
+func toggleChoices(choices []*putChoice, line string) error {
+	var toggle []int
+	var set func(c *putChoice) bool
+	for _, field := range strings.FieldsFunc(line, func(r rune) bool { return r == ' ' || r == ',' }) {
+		switch field {
+		case "a":
+			set = func(*putChoice) bool { return true }
+			continue
+		case "n":
+			set = func(*putChoice) bool { return false }
+			continue
+		case "c":
+			set = func(c *putChoice) bool { return c.status != "=" }
+			continue
+		}
+		from, to, isRange := strings.Cut(field, "-")
+		first, err := strconv.Atoi(from)
+		last := first
+		if err == nil && isRange {
+			last, err = strconv.Atoi(to)
+		}
+		if err != nil || first < 1 || last > len(choices) || first > last {
+			return fmt.Errorf("%q is not a number or range between 1 and %d", field, len(choices))
+		}
+		for i := first; i <= last; i++ {
+			toggle = append(toggle, i-1)
+		}
+	}
+	if set != nil {
+		for _, c := range choices {
+			c.selected = set(c)
+		}
+	}
+	for _, i := range toggle {
+		choices[i].selected = !choices[i].selected
+	}
+	return nil
+}
+
+// selectedPuts returns the selected puts in the order of ordered, warning about selected secrets whose value
+// was built from a template secret that is not selected: they are put with the template's value of it.
+func selectedPuts(choices []*putChoice, ordered []*templatePut) []*templatePut {
+	selected := make(map[*templatePut]bool)
+	written := make(map[string]string) // Secret name by parameter, for the unselected template targets.
+	for _, c := range choices {
+		if c.selected {
+			selected[c.put] = true
+		} else {
+			written[c.put.paramName] = c.put.secret.Name
+		}
+	}
+	var result []*templatePut
+	for _, p := range ordered {
+		if !selected[p] {
+			continue
+		}
+		for _, ref := range p.refs {
+			if name, ok := written[ref]; ok {
+				Infof("%s %s uses the template value of %s, which is not selected\n", yellow("Warning:"), p.secret.Name, name)
+			}
+		}
+		result = append(result, p)
+	}
+	return result
+}
@@ -0,0 +1,83 @@
+package features
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestPutFromTemplateInteractive(t *testing.T) {
+	template := filepath.Join(t.TempDir(), "task.json")
+	data := `{"containerDefinitions": [{"secrets": [
+  {"name": "A", "valueFrom": "/app/A", "value": "new-a"},
+  {"name": "B", "valueFrom": "/app/B", "value": "b"},
+  {"name": "C", "valueFrom": "/app/C", "value": "c"},
+  {"name": "D", "valueFrom": "/app/D", "value": "d"}
+]}]}`
+	if err := os.WriteFile(template, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		input string
+		want  map[string]string // Values after the put.
+	}{
+		{"changed preselected", "\n", map[string]string{"/app/A": "new-a", "/app/B": "b", "/app/C": "c", "/app/D": "d"}},
+		{"toggle", "1\n\n", map[string]string{"/app/A": "a", "/app/B": "b", "/app/C": "c", "/app/D": "d"}},
+		{"invalid then none and one", "9\nn 1\n\n", map[string]string{"/app/A": "new-a", "/app/B": "b"}},
+		{"quit", "q\n", map[string]string{"/app/A": "a", "/app/B": "b"}},
+		{"end of input", "", map[string]string{"/app/A": "a", "/app/B": "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeSSM()
+			fake.set("/app/A", "a", types.ParameterTypeString) // Changed.
+			fake.set("/app/B", "b", types.ParameterTypeString) // Unchanged.
+			withStdin(t, tt.input)
+			if err := PutParametersFromTemplate(fake, template, TemplateOptions{}, PutOptions{Interactive: true}); err != nil {
+				t.Fatalf("PutParametersFromTemplate: %v", err)
+			}
+			got := make(map[string]string)
+			for name, p := range fake.params {
+				got[name] = aws.ToString(p.Value)
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("parameters = %v; want %v", got, tt.want)
+			}
+			for name, value := range tt.want {
+				if got[name] != value {
+					t.Errorf("%s = %q; want %q", name, got[name], value)
+				}
+			}
+		})
+	}
+}
+
+func TestToggleChoices(t *testing.T) {
+	choices := []*putChoice{{status: "+"}, {status: "="}, {status: "~"}, {status: "="}}
+	for _, step := range []struct {
+		line string
+		want []bool
+	}{
+		{"a", []bool{true, true, true, true}},
+		{"n 2-3", []bool{false, true, true, false}},
+		{"c,4", []bool{true, false, true, true}},
+	} {
+		if err := toggleChoices(choices, step.line); err != nil {
+			t.Fatalf("toggleChoices(%q): %v", step.line, err)
+		}
+		for i, c := range choices {
+			if c.selected != step.want[i] {
+				t.Errorf("after %q, choice %d selected = %v; want %v", step.line, i+1, c.selected, step.want[i])
+			}
+		}
+	}
+	for _, line := range []string{"0", "5", "3-2", "x"} {
+		if err := toggleChoices(choices, line); err == nil {
+			t.Errorf("toggleChoices(%q) succeeded; want an error", line)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package features
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// K8sSyncOptions configures sync-k8s.
+type K8sSyncOptions struct {
+	Prefix    string        // Parameters to copy into the Secret.
+	Secret    string        // Name of the Secret, created if missing.
+	Namespace string        // Namespace of the Secret; the pod's own namespace when empty.
+	Interval  time.Duration // Time between syncs.
+}
+
+// k8sFieldManager owns the Secret fields sync-k8s applies, so keys removed from the prefix are removed
+// from the Secret and fields other tools set are kept.
+const k8sFieldManager = "salter-aws"
+
+// kubeServiceAccountDir holds the credentials Kubernetes mounts into pods. Tests replace it.
+var kubeServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// kubeClient calls the Kubernetes API server with the pod's service account.
+type kubeClient struct {
+	http      *http.Client
+	base      string // https://host:port
+	tokenFile string // Read for each request: projected tokens are rotated while the pod runs.
+}
+
+// inClusterKube returns a client for the API server of the cluster the process runs in, and the pod's
+// namespace.
+func inClusterKube() (*kubeClient, string, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, "", validationErrorf("not running in a Kubernetes pod (KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT are unset)")
+	}
+	ca, err := os.ReadFile(filepath.Join(kubeServiceAccountDir, "ca.crt"))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read the service account CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, "", fmt.Errorf("no certificates in %s", filepath.Join(kubeServiceAccountDir, "ca.crt"))
+	}
+	namespace, _ := os.ReadFile(filepath.Join(kubeServiceAccountDir, "namespace"))
+	return &kubeClient{
+		http: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+		},
+		base:      "https://" + net.JoinHostPort(host, port),
+		tokenFile: filepath.Join(kubeServiceAccountDir, "token"),
+	}, strings.TrimSpace(string(namespace)), nil
+}
+
+// kubeError reports a failed API server request. errors.Is matches the Err* kind of its status.
+type kubeError struct {
+	method, path string
+	status       int
+	message      string
+}
+
+func (e *kubeError) Error() string {
+	return fmt.Sprintf("kubernetes %s %s: %d %s", e.method, e.path, e.status, e.message)
+}
+
+func (e *kubeError) Unwrap() error {
+	if e.status == http.StatusUnauthorized {
+		return ErrAccessDenied
+	}
+	return statusKind(e.status)
+}
+
+// do sends body with contentType to path and decodes the response into out (if not nil).
+func (k *kubeClient) do(ctx context.Context, method, path, contentType string, body []byte, out interface{}) error {
+	token, err := os.ReadFile(k.tokenFile)
+	if err != nil {
+		return fmt.Errorf("failed to read the service account token: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, k.base+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := k.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("kubernetes API not reachable: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var status struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &status)
+		return &kubeError{method: method, path: path, status: resp.StatusCode, message: status.Message}
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("invalid kubernetes response for %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// SyncK8s keeps a Kubernetes Secret equal to the parameters under opts.Prefix until ctx is cancelled,
+// using the credentials of the pod it runs in. Every opts.Interval the parameters are read and, when their
+// Fingerprint differs from the Secret's fingerprint annotation, applied with server-side apply: keys are
+// the parameter names relative to the prefix, with "/" turned into ".". A failed first sync is returned;
+// later failures are printed and retried on the next interval.
+func SyncK8s(ctx context.Context, client SSMClient, opts K8sSyncOptions) error {
+	if opts.Secret == "" {
+		return validationErrorf("-secret is required for 'sync-k8s'")
+	}
+	if opts.Interval <= 0 {
+		return validationErrorf("-interval must be positive, got %s", opts.Interval)
+	}
+	kube, namespace, err := inClusterKube()
+	if err != nil {
+		return err
+	}
+	if opts.Namespace == "" {
+		opts.Namespace = namespace
+	}
+	if opts.Namespace == "" {
+		return validationErrorf("-namespace is required: the pod's namespace is unknown")
+	}
+	if err := syncK8sSecret(ctx, client, kube, opts); err != nil {
+		return err
+	}
+	Infof("Syncing %s to secret %s/%s every %s\n", opts.Prefix, opts.Namespace, opts.Secret, opts.Interval)
+	for {
+		sleepContext(ctx, opts.Interval)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err := syncK8sSecret(ctx, client, kube, opts); err != nil && ctx.Err() == nil {
+			Infof("Sync failed, retrying in %s: %v\n", opts.Interval, err)
+		}
+	}
+}
+
+// syncK8sSecret applies the parameters under opts.Prefix to the Secret unless its fingerprint matches.
+func syncK8sSecret(ctx context.Context, client SSMClient, kube *kubeClient, opts K8sSyncOptions) error {
+	params, err := loadExportParams(client, opts.Prefix)
+	if err != nil {
+		return err
+	}
+	data := make(map[string]string, len(params))
+	for _, p := range params {
+		key, err := k8sSecretKey(p.Key)
+		if err != nil {
+			return err
+		}
+		data[key] = base64.StdEncoding.EncodeToString([]byte(p.Value))
+	}
+	fingerprint := Fingerprint(outputVars(params))
+	path := fmt.Sprintf("/api/v1/namespaces/%s/secrets/%s", url.PathEscape(opts.Namespace), url.PathEscape(opts.Secret))
+
+	var live struct {
+		Metadata struct {
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+	}
+	err = kube.do(ctx, http.MethodGet, path, "", nil, &live)
+	if err == nil && live.Metadata.Annotations[fingerprintAnnotation] == fingerprint {
+		return nil // Unchanged.
+	}
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	// data rather than stringData: server-side apply tracks the fields it sets, and stringData is never stored.
+	manifest := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]interface{}{
+			"name":        opts.Secret,
+			"namespace":   opts.Namespace,
+			"labels":      map[string]string{"app.kubernetes.io/managed-by": k8sFieldManager},
+			"annotations": map[string]string{fingerprintAnnotation: fingerprint},
+		},
+		"type": "Opaque",
+		"data": data,
+	}
+	body, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	query := "?fieldManager=" + k8sFieldManager + "&force=true" // Take over fields last written by kubectl apply.
+	if err := kube.do(ctx, http.MethodPatch, path+query, "application/apply-patch+yaml", body, nil); err != nil {
+		return err
+	}
+	Infof("%s %d parameters under %s to secret %s/%s (%s)\n", green("Synced"), len(params), opts.Prefix, opts.Namespace, opts.Secret, fingerprint)
+	return nil
+}
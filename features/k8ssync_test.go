@@ -0,0 +1,87 @@
+package features
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestSyncK8s(t *testing.T) {
+	var secret map[string]interface{} // As last applied; nil until created.
+	patches := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sa-token" || r.URL.Path != "/api/v1/namespaces/prod/secrets/app-config" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			if secret == nil {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"kind": "Status", "message": "secrets \"app-config\" not found"}`))
+				return
+			}
+			json.NewEncoder(w).Encode(secret)
+		case http.MethodPatch:
+			if r.Header.Get("Content-Type") != "application/apply-patch+yaml" || r.URL.Query().Get("fieldManager") != k8sFieldManager {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			body, _ := io.ReadAll(r.Body)
+			json.Unmarshal(body, &secret)
+			patches++
+			w.Write(body)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	for name, content := range map[string][]byte{"ca.crt": ca, "token": []byte("sa-token\n"), "namespace": []byte("prod")} {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer func(old string) { kubeServiceAccountDir = old }(kubeServiceAccountDir)
+	kubeServiceAccountDir = dir
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	t.Setenv("KUBERNETES_SERVICE_HOST", host)
+	t.Setenv("KUBERNETES_SERVICE_PORT", port)
+
+	fake := newFakeSSM()
+	fake.set("/prod/app/DB_URL", "postgres://db", types.ParameterTypeSecureString)
+	fake.set("/prod/app/feature/flag", "on", types.ParameterTypeString)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := SyncK8s(ctx, fake, K8sSyncOptions{Prefix: "/prod/app/", Secret: "app-config", Interval: time.Hour}); err != nil {
+		t.Fatalf("SyncK8s: %v", err)
+	}
+	data, _ := secret["data"].(map[string]interface{})
+	if got, _ := data["feature.flag"].(string); got != base64.StdEncoding.EncodeToString([]byte("on")) || len(data) != 2 {
+		t.Errorf("secret data = %v; want DB_URL and feature.flag", data)
+	}
+
+	kube, _, err := inClusterKube()
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := K8sSyncOptions{Prefix: "/prod/app/", Secret: "app-config", Namespace: "prod"}
+	if err := syncK8sSecret(context.Background(), fake, kube, opts); err != nil || patches != 1 {
+		t.Errorf("unchanged sync: err = %v, %d patches; want no new patch", err, patches)
+	}
+	fake.set("/prod/app/DB_URL", "postgres://db2", types.ParameterTypeSecureString)
+	if err := syncK8sSecret(context.Background(), fake, kube, opts); err != nil || patches != 2 {
+		t.Errorf("changed sync: err = %v, %d patches; want one new patch", err, patches)
+	}
+}
@@ -0,0 +1,106 @@
+package features
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// KMSAPI is the subset of the KMS API used to check key access before bulk puts. *kms.Client satisfies it.
+type KMSAPI interface {
+	Encrypt(ctx context.Context, params *kms.EncryptInput, optFns ...func(*kms.Options)) (*kms.EncryptOutput, error)
+	Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+// defaultSSMKey is the AWS managed key SSM uses when no KeyId is given; every caller of SSM may use it.
+const defaultSSMKey = "alias/aws/ssm"
+
+// kmsProbe is encrypted to get a ciphertext for the Decrypt check; it is never stored.
+const kmsProbe = "salter-aws kms access check"
+
+// checkKMSAccess verifies that the caller may use the customer managed key of every SecureString put, the way SSM
+// will use it: kms:Encrypt to write and kms:Decrypt to read back, with the PARAMETER_ARN encryption context key
+// policies and grants are often scoped by. Encrypt is checked with a dry run; Decrypt needs a real ciphertext,
+// so a fixed probe string is encrypted and the Decrypt is a dry run. Everything missing is reported at once.
+func checkKMSAccess(client KMSAPI, puts []*templatePut, tmplOpts TemplateOptions) error {
+	ctx := context.TODO()
+	var missing []string
+	checked := 0
+	for _, p := range puts {
+		keyID := p.secret.KMSKeyID
+		if keyID == "" || keyID == defaultSSMKey || p.paramType != SecureStringType {
+			continue
+		}
+		checked++
+		var encryptionContext map[string]string
+		if tmplOpts.AccountID != "" {
+			encryptionContext = map[string]string{"PARAMETER_ARN": ParameterARN(tmplOpts.Region, tmplOpts.AccountID, p.paramName)}
+		}
+
+		_, err := client.Encrypt(ctx, &kms.EncryptInput{
+			KeyId:             aws.String(keyID),
+			Plaintext:         []byte(kmsProbe),
+			EncryptionContext: encryptionContext,
+			DryRun:            aws.Bool(true),
+		})
+		if problem, err := kmsCheckProblem("kms:Encrypt", keyID, p.paramName, err); err != nil {
+			return err
+		} else if problem != "" {
+			missing = append(missing, problem)
+			continue // Without Encrypt there is no ciphertext to check Decrypt with.
+		}
+		probe, err := client.Encrypt(ctx, &kms.EncryptInput{
+			KeyId:             aws.String(keyID),
+			Plaintext:         []byte(kmsProbe),
+			EncryptionContext: encryptionContext,
+		})
+		if err != nil {
+			return wrapAWSError("Encrypt", keyID, err)
+		}
+		_, err = client.Decrypt(ctx, &kms.DecryptInput{
+			KeyId:             aws.String(keyID),
+			CiphertextBlob:    probe.CiphertextBlob,
+			EncryptionContext: encryptionContext,
+			DryRun:            aws.Bool(true),
+		})
+		if problem, err := kmsCheckProblem("kms:Decrypt", keyID, p.paramName, err); err != nil {
+			return err
+		} else if problem != "" {
+			missing = append(missing, problem)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return &KMSAccessError{Missing: missing}
+	}
+	if checked > 0 {
+		Infof("KMS access verified for %d SecureString parameters\n", checked)
+	}
+	return nil
+}
+
+// kmsCheckProblem interprets the result of a dry-run KMS call: an empty problem if the call would succeed,
+// a description if access is missing, or an error if the check itself failed.
+func kmsCheckProblem(action, keyID, paramName string, err error) (string, error) {
+	var dryRun *kmstypes.DryRunOperationException
+	var notFound *kmstypes.NotFoundException
+	var disabled *kmstypes.DisabledException
+	var invalidState *kmstypes.KMSInvalidStateException
+	switch {
+	case err == nil, errors.As(err, &dryRun):
+		return "", nil
+	case errors.Is(classifyAWSError(err), ErrAccessDenied):
+		return fmt.Sprintf("%s on %s for %s", action, keyID, paramName), nil
+	case errors.As(err, &notFound):
+		return fmt.Sprintf("key %s does not exist (for %s)", keyID, paramName), nil
+	case errors.As(err, &disabled), errors.As(err, &invalidState):
+		return fmt.Sprintf("key %s is disabled or pending deletion (for %s)", keyID, paramName), nil
+	}
+	return "", wrapAWSError(strings.TrimPrefix(action, "kms:"), keyID, err)
+}
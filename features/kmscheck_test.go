@@ -0,0 +1,104 @@
+package features
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/aws/smithy-go"
+)
+
+// fakeKMS answers dry runs like KMS: DryRunOperationException when allowed, AccessDeniedException otherwise.
+type fakeKMS struct {
+	canEncrypt, canDecrypt map[string]bool // Key IDs the caller may use.
+	contexts               []string        // PARAMETER_ARN of every call.
+}
+
+func (k *fakeKMS) result(allowed map[string]bool, keyID string, dryRun *bool) error {
+	switch {
+	case keyID == "alias/missing":
+		return &kmstypes.NotFoundException{Message: aws.String("alias not found")}
+	case !allowed[keyID]:
+		return &smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not authorized"}
+	case aws.ToBool(dryRun):
+		return &kmstypes.DryRunOperationException{Message: aws.String("would have succeeded")}
+	}
+	return nil
+}
+
+func (k *fakeKMS) Encrypt(ctx context.Context, params *kms.EncryptInput, optFns ...func(*kms.Options)) (*kms.EncryptOutput, error) {
+	k.contexts = append(k.contexts, params.EncryptionContext["PARAMETER_ARN"])
+	if err := k.result(k.canEncrypt, aws.ToString(params.KeyId), params.DryRun); err != nil {
+		return nil, err
+	}
+	return &kms.EncryptOutput{CiphertextBlob: []byte("ciphertext")}, nil
+}
+
+func (k *fakeKMS) Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error) {
+	if err := k.result(k.canDecrypt, aws.ToString(params.KeyId), params.DryRun); err != nil {
+		return nil, err
+	}
+	return &kms.DecryptOutput{Plaintext: []byte(kmsProbe)}, nil
+}
+
+func TestPutFromTemplateChecksKMSAccess(t *testing.T) {
+	template := filepath.Join(t.TempDir(), "template.json")
+	data := `{"containerDefinitions": [{"secrets": [
+  {"name": "A", "valueFrom": "/prod/app/A", "type": "SecureString", "value": "a", "kmsKeyId": "alias/app"},
+  {"name": "B", "valueFrom": "/prod/app/B", "type": "SecureString", "value": "b", "kmsKeyId": "alias/writeonly"},
+  {"name": "C", "valueFrom": "/prod/app/C", "type": "SecureString", "value": "c", "kmsKeyId": "alias/other"},
+  {"name": "D", "valueFrom": "/prod/app/D", "type": "SecureString", "value": "d", "kmsKeyId": "alias/missing"},
+  {"name": "E", "valueFrom": "/prod/app/E", "type": "SecureString", "value": "e"}
+]}]}`
+	if err := os.WriteFile(template, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tmplOpts := TemplateOptions{Region: "us-east-1", AccountID: "123456789012"}
+	fakeKeys := &fakeKMS{
+		canEncrypt: map[string]bool{"alias/app": true, "alias/writeonly": true},
+		canDecrypt: map[string]bool{"alias/app": true},
+	}
+	fake := newFakeSSM()
+
+	err := PutParametersFromTemplate(fake, template, tmplOpts, PutOptions{AssumeYes: true, KMS: fakeKeys})
+	if !errors.Is(err, ErrAccessDenied) {
+		t.Fatalf("err = %v; want ErrAccessDenied", err)
+	}
+	var accessErr *KMSAccessError
+	if !errors.As(err, &accessErr) {
+		t.Fatalf("err = %T; want *KMSAccessError", err)
+	}
+	want := []string{
+		"key alias/missing does not exist (for /prod/app/D)",
+		"kms:Decrypt on alias/writeonly for /prod/app/B",
+		"kms:Encrypt on alias/other for /prod/app/C",
+	}
+	if strings.Join(accessErr.Missing, "\n") != strings.Join(want, "\n") {
+		t.Errorf("missing =\n%s\nwant\n%s", strings.Join(accessErr.Missing, "\n"), strings.Join(want, "\n"))
+	}
+	if fake.puts != 0 {
+		t.Errorf("%d puts made despite missing KMS access; want none", fake.puts)
+	}
+	if fakeKeys.contexts[0] != "arn:aws:ssm:us-east-1:123456789012:parameter/prod/app/A" {
+		t.Errorf("encryption context = %q; want the parameter ARN", fakeKeys.contexts[0])
+	}
+
+	fakeKeys.canEncrypt["alias/other"], fakeKeys.canDecrypt["alias/other"] = true, true
+	fakeKeys.canDecrypt["alias/writeonly"] = true
+	data = strings.Replace(data, "alias/missing", "alias/app", 1)
+	if err := os.WriteFile(template, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := PutParametersFromTemplate(fake, template, tmplOpts, PutOptions{AssumeYes: true, KMS: fakeKeys}); err != nil {
+		t.Fatalf("put-from-template with access: %v", err)
+	}
+	if fake.puts != 5 {
+		t.Errorf("puts = %d; want 5", fake.puts)
+	}
+}
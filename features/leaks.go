@@ -0,0 +1,213 @@
+package features
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// Limits of scan-leaks.
+const (
+	minLeakLength = 8        // Shorter values, such as "true" or a port, would match all over a repository.
+	maxLeakFile   = 10 << 20 // Larger files are skipped, as are files with a NUL byte in their first block.
+)
+
+// leakHashBase is the base of the Rabin-Karp rolling hash scan-leaks finds candidate occurrences with.
+const leakHashBase = 1099511628211
+
+// leakSet holds the SecureStrings scan-leaks looks for, by length, as hashes only: a rolling hash finds
+// candidates and a SHA-256 digest confirms them, so the values are not kept in memory while files are read.
+type leakSet map[int]map[uint64][]leakSecret
+
+// leakSecret is the digest of one value and the parameters holding it.
+type leakSecret struct {
+	digest [sha256.Size]byte
+	names  []string
+}
+
+// Leak is an occurrence of a SecureString value in a file.
+type Leak struct {
+	File  string
+	Line  int
+	Names []string // Parameters with this value.
+}
+
+// add records value as the value of the parameter name.
+func (s leakSet) add(name, value string) {
+	data := []byte(value)
+	hash, digest := rollingHash(data), sha256.Sum256(data)
+	byHash := s[len(data)]
+	if byHash == nil {
+		byHash = make(map[uint64][]leakSecret)
+		s[len(data)] = byHash
+	}
+	for i, secret := range byHash[hash] {
+		if secret.digest == digest {
+			byHash[hash][i].names = append(secret.names, name)
+			return
+		}
+	}
+	byHash[hash] = append(byHash[hash], leakSecret{digest: digest, names: []string{name}})
+}
+
+// rollingHash returns the rolling hash of data.
+func rollingHash(data []byte) uint64 {
+	var h uint64
+	for _, b := range data {
+		h = h*leakHashBase + uint64(b)
+	}
+	return h
+}
+
+// find returns the occurrences in data, with the 1-based line each one starts on.
+func (s leakSet) find(file string, data []byte) []Leak {
+	var leaks []Leak
+	for length, byHash := range s {
+		if length > len(data) {
+			continue
+		}
+		var pow uint64 = 1 // leakHashBase^(length-1), to remove the byte leaving the window.
+		for i := 1; i < length; i++ {
+			pow *= leakHashBase
+		}
+		h := rollingHash(data[:length])
+		for start := 0; ; start++ {
+			for _, secret := range byHash[h] {
+				if sha256.Sum256(data[start:start+length]) == secret.digest {
+					leaks = append(leaks, Leak{File: file, Line: 1 + bytes.Count(data[:start], []byte("\n")), Names: secret.names})
+				}
+			}
+			if start+length == len(data) {
+				break
+			}
+			h = (h-uint64(data[start])*pow)*leakHashBase + uint64(data[start+length])
+		}
+	}
+	return leaks
+}
+
+// ScanLeaks looks for the values of the SecureStrings under prefix in the files under root, a directory or
+// a file, and prints the file and line of each occurrence with the parameter it belongs to, never the value:
+// a guard against secrets committed by accident, for pre-commit hooks and CI. In a git work tree the files
+// git would commit are scanned (tracked and untracked, not ignored), otherwise every file but .git.
+// Values shorter than 8 characters are not looked for. Returns a LeakError (exit code ExitCheckFailed) when any
+// value is found.
+func ScanLeaks(client SSMClient, prefix, root string) error {
+	if !strings.HasPrefix(prefix, "/") {
+		return validationErrorf("scan-leaks needs a -prefix path starting with /, got %q", prefix)
+	}
+	params, err := listParameters(client, prefix)
+	if err != nil {
+		return err
+	}
+	secrets := make(leakSet)
+	count, short := 0, 0
+	for _, p := range params {
+		if p.Type != types.ParameterTypeSecureString {
+			continue
+		}
+		if value := aws.ToString(p.Value); len(value) < minLeakLength {
+			short++
+		} else {
+			secrets.add(aws.ToString(p.Name), value)
+			count++
+		}
+	}
+	if count == 0 {
+		Infof("No SecureStrings under %s to look for (%d shorter than %d characters)\n", prefix, short, minLeakLength)
+		return nil
+	}
+
+	files, err := leakScanFiles(root)
+	if err != nil {
+		return err
+	}
+	var leaks []Leak
+	skipped := 0
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil || !info.Mode().IsRegular() {
+			continue // Deleted but still tracked, or a symlink to a directory.
+		}
+		if info.Size() > maxLeakFile {
+			skipped++
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		if bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+			continue // Binary.
+		}
+		leaks = append(leaks, secrets.find(file, data)...)
+	}
+	sort.Slice(leaks, func(i, j int) bool {
+		if leaks[i].File != leaks[j].File {
+			return leaks[i].File < leaks[j].File
+		}
+		return leaks[i].Line < leaks[j].Line
+	})
+
+	for _, leak := range leaks {
+		fmt.Printf("%s:%d: value of %s\n", leak.File, leak.Line, strings.Join(leak.Names, ", "))
+	}
+	summary := fmt.Sprintf("%d files scanned for %d SecureStrings under %s", len(files)-skipped, count, prefix)
+	if short > 0 {
+		summary += fmt.Sprintf(" (%d shorter than %d characters not looked for)", short, minLeakLength)
+	}
+	if skipped > 0 {
+		summary += fmt.Sprintf("; %d files over %d MB skipped", skipped, maxLeakFile>>20)
+	}
+	if len(leaks) > 0 {
+		Infof("%s %d occurrences of secret values: %s\n", red("Found"), len(leaks), summary)
+		return &LeakError{Leaks: len(leaks), Files: len(files) - skipped}
+	}
+	Infof("%s: no secret values found; %s\n", green("OK"), summary)
+	return nil
+}
+
+// leakScanFiles returns the files to scan under root: in a git work tree those git lists as tracked or
+// untracked and not ignored, otherwise every regular file outside .git directories.
+func leakScanFiles(root string) ([]string, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, validationErrorf("cannot scan %s: %v", root, err)
+	}
+	if !info.IsDir() {
+		return []string{root}, nil
+	}
+	cmd := exec.Command("git", "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	cmd.Dir = root
+	if out, err := cmd.Output(); err == nil {
+		var files []string
+		for _, name := range strings.Split(string(out), "\x00") {
+			if name != "" {
+				files = append(files, filepath.Join(root, filepath.FromSlash(name)))
+			}
+		}
+		return files, nil
+	}
+	var files []string
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case d.IsDir() && d.Name() == ".git":
+			return filepath.SkipDir
+		case d.Type().IsRegular():
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
@@ -0,0 +1,73 @@
+package features
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestLeakSetFind(t *testing.T) {
+	secrets := make(leakSet)
+	secrets.add("/prod/app/DB_PASSWORD", "correct-horse")
+	secrets.add("/prod/worker/DB_PASSWORD", "correct-horse")
+	secrets.add("/prod/app/API_KEY", "sk_live_abcdef")
+
+	data := []byte("correct-horse\nurl: x\n  key = \"sk_live_abcdef\" # sk_live_abcde\ncorrect-hors")
+	got := secrets.find("cfg", data)
+	want := []Leak{
+		{File: "cfg", Line: 1, Names: []string{"/prod/app/DB_PASSWORD", "/prod/worker/DB_PASSWORD"}},
+		{File: "cfg", Line: 3, Names: []string{"/prod/app/API_KEY"}},
+	}
+	if len(got) == 2 && got[0].Line > got[1].Line {
+		got[0], got[1] = got[1], got[0] // Lengths are searched in map order.
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("find = %+v; want %+v", got, want)
+	}
+	if got := secrets.find("short", []byte("correct")); len(got) != 0 {
+		t.Errorf("find in a shorter file = %+v; want none", got)
+	}
+}
+
+func TestScanLeaks(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/prod/app/DB_PASSWORD", "correct-horse", types.ParameterTypeSecureString)
+	fake.set("/prod/app/PIN", "1234", types.ParameterTypeSecureString) // Too short to look for.
+	fake.set("/prod/app/LOG_LEVEL", "verbose-debug", types.ParameterTypeString)
+
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("README.md", "pin 1234, level verbose-debug\n")
+	write("blob.bin", "\x00correct-horse")
+	write(".git/config", "correct-horse")
+
+	if err := ScanLeaks(fake, "/prod/", dir); err != nil {
+		t.Fatalf("scan of a clean tree: %v", err)
+	}
+
+	write("deploy/app.env", "# db\nDB_PASSWORD=correct-horse\n")
+	err := ScanLeaks(fake, "/prod/", dir)
+	var leak *LeakError
+	if !errors.As(err, &leak) || leak.Leaks != 1 || ExitCode(err) != ExitCheckFailed {
+		t.Fatalf("scan error = %v; want one leak", err)
+	}
+	if err := ScanLeaks(fake, "/prod/", filepath.Join(dir, "README.md")); err != nil {
+		t.Errorf("scan of a clean file: %v", err)
+	}
+	if err := ScanLeaks(fake, "/prod/", filepath.Join(dir, "missing")); ExitCode(err) != ExitValidation {
+		t.Errorf("scan of a missing path = %v; want a validation error", err)
+	}
+}
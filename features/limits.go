@@ -0,0 +1,162 @@
+package features
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// SSM limits checked before PutParameter, so mistakes get a clear error instead of a generic ValidationException.
+const (
+	standardTierLimit = 4 * 1024 // Maximum value size in bytes of a Standard parameter.
+	advancedTierLimit = 8 * 1024 // Maximum value size in bytes of an Advanced parameter.
+	maxNameLength     = 1011     // Characters of a name; ARN characters reserved by SSM are not included.
+	maxHierarchyDepth = 15       // Levels of a path such as /a/b/c.
+	maxDescription    = 1024     // Characters of a description.
+	maxTags           = 50       // Tags of a parameter.
+	maxTagKey         = 128      // Characters of a tag key.
+	maxTagValue       = 256      // Characters of a tag value.
+)
+
+var parameterNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.\-/]+$`)
+
+// Data types of String parameters. SSM checks that an aws:ec2:image value names an AMI it can see after the
+// put returns; a value it cannot find does not become a new version.
+const (
+	DataTypeText     = "text"
+	DataTypeEC2Image = "aws:ec2:image"
+)
+
+// amiIDPattern matches the AMI IDs an aws:ec2:image parameter holds.
+var amiIDPattern = regexp.MustCompile(`^ami-([0-9a-f]{8}|[0-9a-f]{17})$`)
+
+// ParseDataType parses a data type: text or aws:ec2:image.
+func ParseDataType(s string) (string, error) {
+	switch strings.ToLower(s) {
+	case DataTypeText:
+		return DataTypeText, nil
+	case DataTypeEC2Image:
+		return DataTypeEC2Image, nil
+	}
+	return "", validationErrorf("invalid data type %q (use text or %s)", s, DataTypeEC2Image)
+}
+
+// validateDataType checks value and paramType against dataType; empty and text accept any value.
+func validateDataType(name, value string, paramType ParameterType, dataType string) error {
+	if dataType == "" || dataType == DataTypeText {
+		return nil
+	}
+	if _, err := ParseDataType(dataType); err != nil {
+		return validationErrorf("%s: %w", name, err)
+	}
+	if paramType != StringType {
+		return validationErrorf("%s: data type %s needs type string, not %s", name, dataType, paramType)
+	}
+	if !amiIDPattern.MatchString(value) {
+		return validationErrorf("%s: %q is not an AMI ID such as ami-0123456789abcdef0, which data type %s needs", name, value, dataType)
+	}
+	return nil
+}
+
+// ParseTier parses a case-insensitive tier name: standard, advanced or intelligent-tiering.
+func ParseTier(s string) (types.ParameterTier, error) {
+	switch strings.ToLower(s) {
+	case "standard":
+		return types.ParameterTierStandard, nil
+	case "advanced":
+		return types.ParameterTierAdvanced, nil
+	case "intelligent-tiering":
+		return types.ParameterTierIntelligentTiering, nil
+	}
+	return "", validationErrorf("invalid tier %q (use standard, advanced or intelligent-tiering)", s)
+}
+
+// validateParameterName checks name against the SSM naming rules.
+func validateParameterName(name string) error {
+	switch {
+	case name == "":
+		return validationErrorf("parameter name is empty")
+	case len(name) > maxNameLength:
+		return validationErrorf("parameter name %s... is %d characters; SSM allows %d", name[:40], len(name), maxNameLength)
+	case !parameterNamePattern.MatchString(name):
+		return validationErrorf("parameter name %q may only contain letters, digits and _ . - /", name)
+	case strings.Contains(name, "/") && !strings.HasPrefix(name, "/"):
+		return validationErrorf("parameter name %s has a path but does not start with \"/\"; use /%s", name, name)
+	case strings.Contains(name, "//") || len(name) > 1 && strings.HasSuffix(name, "/"):
+		return validationErrorf("parameter name %s has an empty path level", name)
+	case strings.Count(name, "/") > maxHierarchyDepth:
+		return validationErrorf("parameter name %s is %d levels deep; SSM allows %d", name, strings.Count(name, "/"), maxHierarchyDepth)
+	}
+	first := strings.ToLower(strings.SplitN(strings.TrimPrefix(name, "/"), "/", 2)[0])
+	if strings.HasPrefix(first, "aws") || strings.HasPrefix(first, "ssm") {
+		return validationErrorf("parameter name %s: names and first path levels starting with \"aws\" or \"ssm\" are reserved", name)
+	}
+	return nil
+}
+
+// validateMetadata checks the description and tags of a put of name against the SSM limits.
+func validateMetadata(name, description string, tags map[string]string) error {
+	if problem := metadataProblem(description, tags); problem != "" {
+		return validationErrorf("%s: %s", name, problem)
+	}
+	return nil
+}
+
+// metadataProblem returns what breaks the SSM limits on descriptions and tags, or "".
+func metadataProblem(description string, tags map[string]string) string {
+	if n := utf8.RuneCountInString(description); n > maxDescription {
+		return fmt.Sprintf("description is %d characters; SSM allows %d", n, maxDescription)
+	}
+	if len(tags) > maxTags {
+		return fmt.Sprintf("%d tags; SSM allows %d per parameter", len(tags), maxTags)
+	}
+	for key, value := range tags {
+		switch {
+		case key == "":
+			return "tag with an empty key"
+		case strings.HasPrefix(strings.ToLower(key), "aws:"):
+			return fmt.Sprintf("tag %s: keys starting with \"aws:\" are reserved", key)
+		case utf8.RuneCountInString(key) > maxTagKey:
+			return fmt.Sprintf("a tag key is %d characters; SSM allows %d", utf8.RuneCountInString(key), maxTagKey)
+		case utf8.RuneCountInString(value) > maxTagValue:
+			return fmt.Sprintf("tag %s: value is %d characters; SSM allows %d", key, utf8.RuneCountInString(value), maxTagValue)
+		}
+	}
+	return ""
+}
+
+// validatePut checks name, and value against paramType and the size limit of tier, before a PutParameter call.
+// An empty tier is treated as Standard, the default of new accounts.
+func validatePut(name, value string, paramType ParameterType, tier types.ParameterTier) error {
+	if err := validateParameterName(name); err != nil {
+		return err
+	}
+	if value == "" {
+		return validationErrorf("%s: SSM does not store empty values", name)
+	}
+	switch limit := valueLimit(tier); {
+	case len(value) > advancedTierLimit:
+		return validationErrorf("%s: value is %d bytes; no tier allows more than %d", name, len(value), advancedTierLimit)
+	case len(value) > limit:
+		return validationErrorf("%s: value is %d bytes; the standard tier allows %d (use -tier advanced or intelligent-tiering)", name, len(value), limit)
+	}
+	if paramType == StringListType {
+		for i, item := range strings.Split(value, ",") {
+			if strings.TrimSpace(item) == "" {
+				return validationErrorf("%s: StringList item %d is empty; items are separated by commas, which cannot be escaped", name, i+1)
+			}
+		}
+	}
+	return nil
+}
+
+// valueLimit returns the maximum value size in bytes of tier.
+func valueLimit(tier types.ParameterTier) int {
+	if tier == types.ParameterTierAdvanced || tier == types.ParameterTierIntelligentTiering {
+		return advancedTierLimit
+	}
+	return standardTierLimit
+}
@@ -0,0 +1,228 @@
+package features
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// Lint severities. Errors make lint-template fail; warnings only do with TemplateOptions.Strict.
+const (
+	lintError   = "error"
+	lintWarning = "warning"
+)
+
+// lintFinding is one problem lint-template found, at a line of the template.
+type lintFinding struct {
+	line     int
+	severity string
+	secret   string // Secret name, or "" for problems of the whole template.
+	message  string
+}
+
+// LintTemplate checks a put-from-template template without writing anything and prints one line per problem
+// with its line number: duplicate secret names, missing or invalid valueFrom, unknown types, values over the
+// tier size limits, and for parameters that already exist, a type that differs from the live one. client may
+// be nil to skip the live checks. It returns a validation error if any error was found, or any warning with
+// tmplOpts.Strict.
+func LintTemplate(client SSMClient, filename string, tmplOpts TemplateOptions) error {
+	data, err := readSource(filename)
+	if err != nil {
+		return err
+	}
+	findings := lintTemplateData(data, tmplOpts, client)
+
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].line < findings[j].line })
+	errorCount, warningCount := 0, 0
+	for _, f := range findings {
+		subject := ""
+		if f.secret != "" {
+			subject = "secret " + f.secret + ": "
+		}
+		fmt.Printf("%s:%d: %s: %s%s\n", filename, f.line, f.severity, subject, f.message)
+		if f.severity == lintError {
+			errorCount++
+		} else {
+			warningCount++
+		}
+	}
+	if errorCount > 0 || tmplOpts.Strict && warningCount > 0 {
+		return validationErrorf("%s: %d errors, %d warnings", filename, errorCount, warningCount)
+	}
+	fmt.Printf("%s: %d errors, %d warnings\n", filename, errorCount, warningCount)
+	return nil
+}
+
+// lintTemplateData returns the findings for the raw template data, in no particular order.
+func lintTemplateData(data []byte, tmplOpts TemplateOptions, client SSMClient) []lintFinding {
+	var taskDef TaskDefinition
+	unmarshalErr := json.Unmarshal(unwrapTaskDefJSON(data), &taskDef)
+	var syntaxErr *json.SyntaxError
+	if errors.As(unmarshalErr, &syntaxErr) {
+		return []lintFinding{{line: lineAt(data, int(syntaxErr.Offset)), severity: lintError, message: fmt.Sprintf("invalid JSON: %v", unmarshalErr)}}
+	}
+	findings, err := lintSchema(data, taskDef)
+	if err != nil {
+		return []lintFinding{{line: 1, severity: lintError, message: err.Error()}}
+	}
+	if unmarshalErr != nil || len(taskDef.ContainerDefinitions) == 0 {
+		// The other checks need the template's structure; the schema findings say what is wrong with it.
+		if len(findings) > 0 {
+			return findings
+		}
+		if unmarshalErr != nil {
+			return []lintFinding{{line: 1, severity: lintError, message: fmt.Sprintf("invalid JSON: %v", unmarshalErr)}}
+		}
+		return []lintFinding{{line: 1, severity: lintError, message: "no container definitions found"}}
+	}
+	lines := make(map[int]int) // Line of each secret, by index.
+	for _, span := range scanSecrets(data) {
+		lines[span.index] = lineAt(data, span.start)
+	}
+
+	add := func(i int, severity, secret, format string, args ...any) {
+		findings = append(findings, lintFinding{line: lines[i], severity: severity, secret: secret, message: fmt.Sprintf(format, args...)})
+	}
+	duplicateSeverity := lintWarning
+	if tmplOpts.Strict {
+		duplicateSeverity = lintError
+	}
+	scope := tmplOpts
+	scope.AllowCrossAccount = false // Report mismatches here instead of letting checkARNScope print them.
+	seen := make(map[string]int)    // Line of the first secret with each name.
+	live := client != nil
+
+	for i, secret := range taskDef.ContainerDefinitions[0].Secrets {
+		name := secret.Name
+		if name == "" {
+			add(i, lintError, "", "secret without a name")
+		} else if first, ok := seen[name]; ok {
+			add(i, duplicateSeverity, name, "duplicate name, also on line %d; the last one is used", first)
+		} else {
+			seen[name] = lines[i]
+		}
+
+		paramType, typeErr := ParseParameterType(string(secret.Type))
+		if typeErr != nil {
+			paramType = StringType // Empty, or unknown and reported by lintSchema.
+		}
+		if secret.KMSKeyID != "" && paramType != SecureStringType {
+			add(i, lintError, name, "kmsKeyId needs type securestring, not %s", paramType)
+		}
+
+		if problem := metadataProblem(secret.Description, secret.Tags); problem != "" {
+			add(i, lintError, name, "%s", problem)
+		}
+		tier, _ := ParseTier(secret.Tier) // Invalid tiers are reported by lintSchema, and checked as Standard.
+
+		computed := paramRefPattern.MatchString(secret.Value) || resourceRefPattern.MatchString(secret.Value) // Size known when applied.
+		if secret.DataType == DataTypeEC2Image {
+			if paramType != StringType {
+				add(i, lintError, name, "dataType %s needs type string, not %s", DataTypeEC2Image, paramType)
+			} else if secret.Value != "" && !computed && !amiIDPattern.MatchString(secret.Value) {
+				add(i, lintError, name, "dataType %s needs an AMI ID such as ami-0123456789abcdef0, not %q", DataTypeEC2Image, secret.Value)
+			}
+		}
+		switch {
+		case secret.Value != "" && secret.ValueFromParameter != "":
+			add(i, lintError, name, "value and valueFromParameter cannot both be set")
+		case secret.Value == "" && secret.ValueFromParameter == "":
+			add(i, lintWarning, name, "empty value; put-from-template skips it")
+		case len(secret.Value) > advancedTierLimit && !computed:
+			add(i, lintError, name, "value is %d bytes, over the %d-byte advanced tier limit", len(secret.Value), advancedTierLimit)
+		case len(secret.Value) > valueLimit(tier) && !computed:
+			add(i, lintWarning, name, "value is %d bytes, over the %d-byte standard tier limit; it needs the advanced tier", len(secret.Value), standardTierLimit)
+		}
+
+		valueFrom, err := ExpandTemplateVars(secret.ValueFrom, tmplOpts.Vars)
+		if err != nil {
+			add(i, lintError, name, "%v", err)
+			continue
+		}
+		paramName, err := tmplOpts.parameterName(valueFrom)
+		if err != nil {
+			add(i, lintError, name, "%v", err)
+			continue
+		}
+		switch {
+		case valueFrom == "" && tmplOpts.FallbackPrefix == "":
+			add(i, lintError, name, "missing valueFrom (use -fallback-to-prefix to derive it from parameterPrefix)")
+			continue
+		case valueFrom == "":
+			paramName = tmplOpts.FallbackPrefix + name
+			add(i, lintWarning, name, "missing valueFrom; %s is used", paramName)
+		case paramName == "":
+			add(i, lintError, name, "invalid valueFrom %q: not an SSM parameter ARN or path", valueFrom)
+			continue
+		}
+		if err := validateParameterName(paramName); err != nil {
+			add(i, lintError, name, "%v", err)
+		}
+		if err := checkARNScope(valueFrom, scope); err != nil {
+			severity := lintError
+			if tmplOpts.AllowCrossAccount {
+				severity = lintWarning
+			}
+			add(i, severity, name, "%v", err)
+		}
+
+		if !live {
+			continue
+		}
+		result, err := client.GetParameter(context.TODO(), &ssm.GetParameterInput{Name: aws.String(paramName)})
+		switch {
+		case errors.Is(classifyAWSError(err), ErrNotFound):
+			// New parameter; nothing to compare.
+		case err != nil:
+			add(i, lintWarning, name, "skipping live type checks: %s", DescribeError(wrapClientError(client, "GetParameter", paramName, err)))
+			live = false
+		case ParameterType(result.Parameter.Type) == SecureStringType && paramType != SecureStringType:
+			add(i, lintError, name, "%s is a SecureString but the template has %s; putting it would store the value unencrypted", paramName, paramType)
+		case ParameterType(result.Parameter.Type) != paramType:
+			add(i, lintWarning, name, "%s is a %s but the template has %s; putting it changes the type", paramName, result.Parameter.Type, paramType)
+		}
+	}
+	return findings
+}
+
+// lintSchema returns the problems of data against the template schema as errors. Those within a secret of
+// the first container are attributed to it by name, with the field as the subject of the message.
+func lintSchema(data []byte, taskDef TaskDefinition) ([]lintFinding, error) {
+	problems, err := templateSchemaProblems(data)
+	if err != nil {
+		return nil, err
+	}
+	var secrets []ExtendedSecret
+	if len(taskDef.ContainerDefinitions) > 0 {
+		secrets = taskDef.ContainerDefinitions[0].Secrets
+	}
+	var findings []lintFinding
+	for _, p := range problems {
+		f := lintFinding{line: lineAt(data, p.offset), severity: lintError, message: p.String()}
+		field := strings.TrimPrefix(p.pointer, "/taskDefinition")
+		if rest, ok := strings.CutPrefix(field, "/containerDefinitions/0/secrets/"); ok {
+			index, field, _ := strings.Cut(rest, "/")
+			if i, err := strconv.Atoi(index); err == nil && i < len(secrets) && field != "" {
+				f.secret, f.message = secrets[i].Name, field+": "+p.message
+			}
+		}
+		findings = append(findings, f)
+	}
+	return findings, nil
+}
+
+// lineAt returns the 1-based line of offset in data.
+func lineAt(data []byte, offset int) int {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	return 1 + bytes.Count(data[:offset], []byte("\n"))
+}
@@ -0,0 +1,64 @@
+package features
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestLintTemplateData(t *testing.T) {
+	template := `{"containerDefinitions": [{"secrets": [
+  {"name": "DB_URL", "valueFrom": "/app/DB_URL", "type": "string", "value": "postgres://db"},
+  {"name": "DB_URL", "valueFrom": "/app/DB_URL2", "value": "x"},
+  {"name": "TOKEN", "value": "t"},
+  {"name": "BAD_ARN", "valueFrom": "arn:aws:s3:::bucket/key", "value": "v"},
+  {"name": "BIG", "valueFrom": "/app/BIG", "value": "` + strings.Repeat("a", 5000) + `"},
+  {"name": "HUGE", "valueFrom": "/app/HUGE", "value": "` + strings.Repeat("a", 9000) + `"},
+  {"name": "ODD", "valueFrom": "/app/ODD", "type": "secret", "value": "v"},
+  {"name": "EMPTY", "valueFrom": "/app/EMPTY"},
+  {"name": "OTHER", "valueFrom": "arn:aws:ssm:us-east-1:111111111111:parameter/app/OTHER", "value": "v"},
+  {"name": "AMI", "valueFrom": "/app/AMI", "dataType": "aws:ec2:image", "value": "latest"},
+  {"name": "IMAGE", "valueFrom": "/app/IMAGE", "dataType": "image", "value": "ami-12345678"}
+]}]}`
+	fake := newFakeSSM()
+	fake.set("/app/DB_URL", "postgres://db", types.ParameterTypeSecureString)
+	fake.set("/app/DB_URL2", "x", types.ParameterTypeStringList)
+
+	findings := lintTemplateData([]byte(template), TemplateOptions{Region: "ap-southeast-3"}, fake)
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].line < findings[j].line })
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.severity+" "+f.secret)
+	}
+	want := []string{
+		"error DB_URL",   // SecureString downgraded to String.
+		"warning DB_URL", // Duplicate.
+		"warning DB_URL", // StringList becomes String.
+		"error TOKEN",    // Missing valueFrom.
+		"error BAD_ARN",  // Not an SSM ARN.
+		"warning BIG",    // Needs the advanced tier.
+		"error HUGE",     // Over 8 KB.
+		"error ODD",      // Unknown type.
+		"warning EMPTY",  // No value.
+		"error OTHER",    // Another region.
+		"error AMI",      // Not an AMI ID.
+		"error IMAGE",    // Unknown data type.
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("findings =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	for _, f := range findings {
+		if f.secret == "BAD_ARN" && f.line != 5 {
+			t.Errorf("BAD_ARN finding on line %d; want 5", f.line)
+		}
+	}
+}
+
+func TestLintTemplateDataSyntaxError(t *testing.T) {
+	findings := lintTemplateData([]byte("{\n  \"containerDefinitions\": [\n    {,}\n]}"), TemplateOptions{}, nil)
+	if len(findings) != 1 || findings[0].severity != lintError || findings[0].line != 3 {
+		t.Errorf("findings = %+v; want one error on line 3", findings)
+	}
+}
@@ -0,0 +1,36 @@
+package features
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+// maskKey is the random key of this run's value fingerprints (see maskValue), created on first use.
+var (
+	maskKeyOnce sync.Once
+	maskKey     []byte
+)
+
+// maskValue identifies a value by a short fingerprint, never its content: an HMAC-SHA256 under a random
+// key of this run. Equal values have equal fingerprints within a run, so live, template and per-account
+// values can be compared, but a fingerprint cannot be checked against guesses outside it, even for short
+// secrets. It is safe for CI logs.
+func maskValue(value string) string {
+	maskKeyOnce.Do(func() {
+		maskKey = make([]byte, 32)
+		if _, err := rand.Read(maskKey); err != nil {
+			panic("features: failed to generate fingerprint key: " + err.Error())
+		}
+	})
+	return "#" + hmacHex(maskKey, value)[:8]
+}
+
+// hmacHex returns the hex HMAC-SHA256 of value under key.
+func hmacHex(key []byte, value string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
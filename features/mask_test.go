@@ -0,0 +1,30 @@
+package features
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newMaskRun makes the next maskValue call pick a new key, as a new run of the tool does.
+func newMaskRun() {
+	maskKeyOnce = sync.Once{}
+}
+
+func TestMaskValue(t *testing.T) {
+	newMaskRun()
+	first := maskValue("1234")
+	if first != maskValue("1234") {
+		t.Errorf("maskValue differs for one value within a run")
+	}
+	if first == maskValue("12345") {
+		t.Errorf("maskValue is equal for different values")
+	}
+	if strings.Contains(first, ValueHash("1234")[:8]) || strings.Contains(first, "chars") {
+		t.Errorf("maskValue(%q) = %s; want a keyed fingerprint without the length", "1234", first)
+	}
+	newMaskRun()
+	if maskValue("1234") == first {
+		t.Errorf("maskValue is equal across runs: %s", first)
+	}
+}
@@ -0,0 +1,114 @@
+package features
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// memoKey identifies a cached parameter read: the requested name, possibly with a :version or :label selector,
+// and whether the value was decrypted.
+type memoKey struct {
+	name    string
+	decrypt bool
+}
+
+// memoClient remembers every parameter read through it for the rest of the run, so a parameter referenced by
+// several containers, templates or steps is fetched once. Each read is also stored under name:version, which
+// never changes; a put through the client forgets the name's other entries so later reads see the new value.
+type memoClient struct {
+	decorator
+	mu     sync.Mutex
+	params map[memoKey]types.Parameter
+}
+
+// NewMemoClient wraps client with a run-scoped cache of parameter reads. It suits one-shot commands; long-running
+// ones such as serve or watch must see changes and should not use it.
+func NewMemoClient(client SSMClient) SSMClient {
+	return &memoClient{decorator: decorator{client}, params: make(map[memoKey]types.Parameter)}
+}
+
+func (c *memoClient) dryRun() bool {
+	return isDryRun(c.SSMClient)
+}
+
+func (c *memoClient) planChanges(changes []Change) error {
+	return planChanges(c.SSMClient, changes)
+}
+
+// remember stores p under the name it was requested by and under its name:version.
+func (c *memoClient) remember(requested string, decrypt bool, p types.Parameter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.params[memoKey{requested, decrypt}] = p
+	if p.Name != nil && p.Version != 0 {
+		c.params[memoKey{aws.ToString(p.Name) + ":" + strconv.FormatInt(p.Version, 10), decrypt}] = p
+	}
+}
+
+func (c *memoClient) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	key := memoKey{aws.ToString(params.Name), aws.ToBool(params.WithDecryption)}
+	c.mu.Lock()
+	p, ok := c.params[key]
+	c.mu.Unlock()
+	if ok {
+		return &ssm.GetParameterOutput{Parameter: &p}, nil
+	}
+	out, err := c.SSMClient.GetParameter(ctx, params, optFns...)
+	if err == nil && out.Parameter != nil {
+		c.remember(key.name, key.decrypt, *out.Parameter)
+	}
+	return out, err
+}
+
+// GetParametersByPath always calls SSM, since the set of parameters under a path can change, but remembers
+// every parameter returned so later reads of them are served from the cache.
+func (c *memoClient) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	out, err := c.SSMClient.GetParametersByPath(ctx, params, optFns...)
+	if err == nil {
+		for _, p := range out.Parameters {
+			c.remember(aws.ToString(p.Name), aws.ToBool(params.WithDecryption), p)
+		}
+	}
+	return out, err
+}
+
+// PutParameter forgets the cached reads of the parameter, except those of fixed versions.
+func (c *memoClient) PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+	out, err := c.SSMClient.PutParameter(ctx, params, optFns...)
+	c.forget(aws.ToString(params.Name), false)
+	return out, err
+}
+
+// DeleteParameter forgets every cached read of the parameter, fixed versions included.
+func (c *memoClient) DeleteParameter(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error) {
+	out, err := c.SSMClient.DeleteParameter(ctx, params, optFns...)
+	c.forget(aws.ToString(params.Name), true)
+	return out, err
+}
+
+// forget drops the cached reads of name; fixed versions are kept unless allVersions is set.
+func (c *memoClient) forget(name string, allVersions bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.params {
+		base, selector := splitSelector(key.name)
+		if _, parseErr := strconv.ParseInt(selector, 10, 64); base == name && (allVersions || parseErr != nil) {
+			delete(c.params, key) // The plain name, or a label that may now point elsewhere.
+		}
+	}
+}
+
+// splitSelector splits a :version or :label selector off a parameter name. ARNs keep their colons.
+func splitSelector(name string) (base, selector string) {
+	i := strings.LastIndex(name, ":")
+	if i < 0 || i < strings.LastIndex(name, "/") {
+		return name, ""
+	}
+	return name[:i], name[i+1:]
+}
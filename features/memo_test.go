@@ -0,0 +1,86 @@
+package features
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// countingSSM counts the GetParameter calls reaching the wrapped client.
+type countingSSM struct {
+	SSMClient
+	gets map[string]int
+}
+
+func (c *countingSSM) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	c.gets[aws.ToString(params.Name)]++
+	return c.SSMClient.GetParameter(ctx, params, optFns...)
+}
+
+func TestMemoClient(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/app/A", "one", types.ParameterTypeString)
+	fake.set("/app/B", "b", types.ParameterTypeString)
+	counting := &countingSSM{SSMClient: fake, gets: make(map[string]int)}
+	client := NewMemoClient(counting)
+
+	get := func(name string) string {
+		t.Helper()
+		value, _, err := GetParameter(client, name)
+		if err != nil {
+			t.Fatalf("GetParameter(%s): %v", name, err)
+		}
+		return value
+	}
+	get("/app/A")
+	get("/app/A")
+	if counting.gets["/app/A"] != 1 {
+		t.Errorf("two reads made %d calls; want 1", counting.gets["/app/A"])
+	}
+
+	if err := PutParameter(client, "/app/A", "two", StringType, PutOptions{AssumeYes: true}); err != nil {
+		t.Fatalf("PutParameter: %v", err)
+	}
+	if got := get("/app/A"); got != "two" {
+		t.Errorf("read after put = %q; want the new value", got)
+	}
+	if got := get("/app/A:1"); got != "one" || counting.gets["/app/A:1"] != 0 {
+		t.Errorf("read of version 1 = %q after %d calls; want the first value from the cache", got, counting.gets["/app/A:1"])
+	}
+
+	if _, err := listParameters(client, "/app/"); err != nil {
+		t.Fatalf("listParameters: %v", err)
+	}
+	get("/app/B")
+	if counting.gets["/app/B"] != 0 {
+		t.Errorf("read of a listed parameter made %d calls; want 0", counting.gets["/app/B"])
+	}
+}
+
+func TestMemoClientInlineFetchesOnce(t *testing.T) {
+	dir := t.TempDir()
+	template := filepath.Join(dir, "task.json")
+	data := `{"containerDefinitions": [
+  {"name": "web", "secrets": [{"name": "DB_URL", "valueFrom": "/dev/app/DB_URL"}]},
+  {"name": "worker", "secrets": [{"name": "DB_URL", "valueFrom": "/dev/app/DB_URL"}]},
+  {"name": "cron", "secrets": [{"name": "DATABASE", "valueFrom": "/dev/app/DB_URL"}]}
+]}`
+	if err := os.WriteFile(template, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeSSM()
+	fake.set("/dev/app/DB_URL", "postgres://db", types.ParameterTypeString)
+	counting := &countingSSM{SSMClient: fake, gets: make(map[string]int)}
+
+	if err := InlineTaskDef(NewMemoClient(counting), template, filepath.Join(dir, "local.json"), TemplateOptions{}); err != nil {
+		t.Fatalf("InlineTaskDef: %v", err)
+	}
+	if counting.gets["/dev/app/DB_URL"] != 1 {
+		t.Errorf("3 references made %d GetParameter calls; want 1", counting.gets["/dev/app/DB_URL"])
+	}
+}
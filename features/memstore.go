@@ -0,0 +1,207 @@
+package features
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/smithy-go"
+)
+
+// memoryClient is an in-memory Parameter Store, so verify-roundtrip can put and read back a template without
+// AWS. It keeps values exactly as they are put and answers with the errors SSM gives for missing parameters,
+// create-only puts of existing ones and tags sent with an overwrite. Lists are not paginated.
+type memoryClient struct {
+	mu     sync.Mutex
+	params map[string]types.Parameter
+	meta   map[string]types.ParameterMetadata
+	tags   map[string][]types.Tag
+}
+
+// newMemoryClient returns an empty in-memory Parameter Store.
+func newMemoryClient() *memoryClient {
+	return &memoryClient{
+		params: make(map[string]types.Parameter),
+		meta:   make(map[string]types.ParameterMetadata),
+		tags:   make(map[string][]types.Tag),
+	}
+}
+
+func (c *memoryClient) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, ok := c.params[aws.ToString(params.Name)]
+	if !ok {
+		return nil, &types.ParameterNotFound{Message: aws.String("parameter not found")}
+	}
+	return &ssm.GetParameterOutput{Parameter: &p}, nil
+}
+
+func (c *memoryClient) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := &ssm.GetParametersByPathOutput{}
+	for _, name := range c.namesUnder(aws.ToString(params.Path), aws.ToBool(params.Recursive)) {
+		out.Parameters = append(out.Parameters, c.params[name])
+	}
+	return out, nil
+}
+
+// DescribeParameters supports the Name (Equals or BeginsWith) and Path (Recursive or OneLevel) filters, all
+// of which must match.
+func (c *memoryClient) DescribeParameters(ctx context.Context, params *ssm.DescribeParametersInput, optFns ...func(*ssm.Options)) (*ssm.DescribeParametersOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := c.namesUnder("/", true)
+	for _, filter := range params.ParameterFilters {
+		var kept []string
+		switch key, option := aws.ToString(filter.Key), aws.ToString(filter.Option); {
+		case key == "Path":
+			under := make(map[string]bool)
+			for _, path := range filter.Values {
+				for _, name := range c.namesUnder(path, option == "Recursive") {
+					under[name] = true
+				}
+			}
+			for _, name := range names {
+				if under[name] {
+					kept = append(kept, name)
+				}
+			}
+		case key == "Name" && (option == "" || option == "Equals" || option == "BeginsWith"):
+			for _, name := range names {
+				for _, value := range filter.Values {
+					if name == value || option == "BeginsWith" && strings.HasPrefix(name, value) {
+						kept = append(kept, name)
+						break
+					}
+				}
+			}
+		default:
+			return nil, &types.InvalidFilterKey{Message: aws.String("filter not supported by the in-memory store: " + key)}
+		}
+		names = kept
+	}
+	out := &ssm.DescribeParametersOutput{}
+	for _, name := range names {
+		out.Parameters = append(out.Parameters, c.meta[name])
+	}
+	return out, nil
+}
+
+func (c *memoryClient) PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	name := aws.ToString(params.Name)
+	existing, exists := c.params[name]
+	overwrite := aws.ToBool(params.Overwrite)
+	switch {
+	case exists && !overwrite:
+		return nil, &types.ParameterAlreadyExists{Message: aws.String("the parameter already exists")}
+	case overwrite && len(params.Tags) > 0:
+		return nil, &smithy.GenericAPIError{Code: "ValidationException", Message: "tags and overwrite can't be used together"}
+	}
+	version := existing.Version + 1
+	now := time.Now()
+	dataType := params.DataType
+	if dataType == nil {
+		dataType = aws.String(DataTypeText)
+	}
+	c.params[name] = types.Parameter{
+		Name:             aws.String(name),
+		Value:            aws.String(aws.ToString(params.Value)),
+		Type:             params.Type,
+		Version:          version,
+		LastModifiedDate: aws.Time(now),
+		DataType:         dataType,
+	}
+	meta := types.ParameterMetadata{
+		Name:             aws.String(name),
+		Type:             params.Type,
+		Description:      params.Description,
+		AllowedPattern:   params.AllowedPattern,
+		Tier:             params.Tier,
+		DataType:         dataType,
+		Version:          version,
+		LastModifiedDate: aws.Time(now),
+	}
+	if meta.Tier == "" {
+		meta.Tier = types.ParameterTierStandard
+	}
+	if params.Type == types.ParameterTypeSecureString {
+		meta.KeyId = aws.String(defaultSSMKey)
+		if params.KeyId != nil {
+			meta.KeyId = params.KeyId
+		}
+	}
+	c.meta[name] = meta
+	if !exists {
+		c.tags[name] = append([]types.Tag(nil), params.Tags...)
+	}
+	return &ssm.PutParameterOutput{Version: version, Tier: meta.Tier}, nil
+}
+
+func (c *memoryClient) DeleteParameter(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	name := aws.ToString(params.Name)
+	if _, ok := c.params[name]; !ok {
+		return nil, &types.ParameterNotFound{Message: aws.String("parameter not found")}
+	}
+	delete(c.params, name)
+	delete(c.meta, name)
+	delete(c.tags, name)
+	return &ssm.DeleteParameterOutput{}, nil
+}
+
+func (c *memoryClient) ListTagsForResource(ctx context.Context, params *ssm.ListTagsForResourceInput, optFns ...func(*ssm.Options)) (*ssm.ListTagsForResourceOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id := aws.ToString(params.ResourceId)
+	if _, ok := c.params[id]; !ok {
+		return nil, &types.InvalidResourceId{Message: aws.String("parameter not found")}
+	}
+	return &ssm.ListTagsForResourceOutput{TagList: append([]types.Tag(nil), c.tags[id]...)}, nil
+}
+
+// AddTagsToResource sets the tags on the parameter, replacing the values of keys it already has.
+func (c *memoryClient) AddTagsToResource(ctx context.Context, params *ssm.AddTagsToResourceInput, optFns ...func(*ssm.Options)) (*ssm.AddTagsToResourceOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id := aws.ToString(params.ResourceId)
+	if _, ok := c.params[id]; !ok {
+		return nil, &types.InvalidResourceId{Message: aws.String("parameter not found")}
+	}
+	for _, tag := range params.Tags {
+		replaced := false
+		for i, have := range c.tags[id] {
+			if aws.ToString(have.Key) == aws.ToString(tag.Key) {
+				c.tags[id][i], replaced = tag, true
+			}
+		}
+		if !replaced {
+			c.tags[id] = append(c.tags[id], tag)
+		}
+	}
+	return &ssm.AddTagsToResourceOutput{}, nil
+}
+
+// namesUnder returns the sorted names below path: every level when recursive, otherwise the first one.
+// c.mu must be held.
+func (c *memoryClient) namesUnder(path string, recursive bool) []string {
+	path = strings.TrimSuffix(path, "/") + "/"
+	var names []string
+	for name := range c.params {
+		rest, ok := strings.CutPrefix(name, path)
+		if ok && (recursive || !strings.Contains(rest, "/")) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,37 @@
+package features
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// infoOutput receives informational messages: what was saved, put, skipped or generated, and warnings. It is
+// stdout by default, so library callers and tests see them as before; the CLI sets it with SetInfoOutput.
+var infoOutput io.Writer = os.Stdout
+
+// SetInfoOutput sends informational messages to w; io.Discard silences them (-q).
+func SetInfoOutput(w io.Writer) {
+	infoOutput = w
+}
+
+// InfoOutputDefault returns where informational messages go when -q is not given: stdout on a terminal,
+// where they read in order with the output, and stderr when stdout is piped or redirected, so that it holds
+// only what the action outputs (a value, an export, a table) and can be consumed by another program.
+func InfoOutputDefault() io.Writer {
+	info, err := os.Stdout.Stat()
+	if err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		return os.Stdout
+	}
+	return os.Stderr
+}
+
+// Infof prints an informational message (see SetInfoOutput).
+func Infof(format string, args ...any) {
+	fmt.Fprintf(infoOutput, format, args...)
+}
+
+// infoln prints an informational message followed by a newline.
+func infoln(msg string) {
+	fmt.Fprintln(infoOutput, msg)
+}
@@ -0,0 +1,31 @@
+package features
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInfoOutput(t *testing.T) {
+	var info bytes.Buffer
+	SetInfoOutput(&info)
+	t.Cleanup(func() { SetInfoOutput(os.Stdout) })
+
+	base := filepath.Join(t.TempDir(), "app")
+	params := []OutputParam{{Key: "A", Name: "/app/A", Value: "1", Type: StringType}}
+	if err := writeExport(params, "/app/", ExportOptions{Formats: []string{FormatEnv}, Output: base}); err != nil {
+		t.Fatalf("writeExport: %v", err)
+	}
+	if want := "Saved env to " + base + ".env\n"; info.String() != want {
+		t.Errorf("info = %q; want %q", info.String(), want)
+	}
+
+	SetInfoOutput(io.Discard)
+	Infof("dropped %d\n", 1)
+	if strings.Contains(info.String(), "dropped") {
+		t.Error("message written after SetInfoOutput(io.Discard)")
+	}
+}
@@ -0,0 +1,187 @@
+package features
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// Metrics counts SSM API calls, cache use and per-prefix syncs for the /metrics endpoint.
+// It is safe for concurrent use.
+type Metrics struct {
+	mu           sync.Mutex
+	apiCalls     map[[2]string]int64 // {operation, outcome} -> calls; outcome is "ok" or "error".
+	throttles    map[string]int64    // operation -> throttled calls.
+	cacheHits    int64
+	cacheMisses  int64
+	lastSync     map[string]time.Time // prefix -> last successful sync.
+	syncFailures map[string]int64     // prefix -> failed syncs.
+}
+
+// NewMetrics returns empty metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		apiCalls:     make(map[[2]string]int64),
+		throttles:    make(map[string]int64),
+		lastSync:     make(map[string]time.Time),
+		syncFailures: make(map[string]int64),
+	}
+}
+
+func (m *Metrics) recordCall(op string, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.apiCalls[[2]string{op, outcome}]++
+	if errors.Is(classifyAWSError(err), ErrThrottled) {
+		m.throttles[op]++
+	}
+}
+
+func (m *Metrics) recordCache(hit bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if hit {
+		m.cacheHits++
+	} else {
+		m.cacheMisses++
+	}
+}
+
+// recordSync records the outcome of loading every parameter under prefix.
+func (m *Metrics) recordSync(prefix string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		m.syncFailures[prefix]++
+		return
+	}
+	m.lastSync[prefix] = time.Now()
+}
+
+// WritePrometheus writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var b strings.Builder
+
+	b.WriteString("# HELP salter_aws_api_calls_total SSM API calls by operation and outcome.\n")
+	b.WriteString("# TYPE salter_aws_api_calls_total counter\n")
+	var calls [][2]string
+	for key := range m.apiCalls {
+		calls = append(calls, key)
+	}
+	sort.Slice(calls, func(i, j int) bool {
+		return calls[i][0] < calls[j][0] || calls[i][0] == calls[j][0] && calls[i][1] < calls[j][1]
+	})
+	for _, key := range calls {
+		fmt.Fprintf(&b, "salter_aws_api_calls_total{operation=%q,outcome=%q} %d\n", key[0], key[1], m.apiCalls[key])
+	}
+
+	b.WriteString("# HELP salter_aws_api_throttles_total SSM API calls rejected by throttling, after SDK retries.\n")
+	b.WriteString("# TYPE salter_aws_api_throttles_total counter\n")
+	for _, op := range sortedKeys(m.throttles) {
+		fmt.Fprintf(&b, "salter_aws_api_throttles_total{operation=%q} %d\n", op, m.throttles[op])
+	}
+
+	b.WriteString("# HELP salter_aws_cache_requests_total Response cache lookups by result.\n")
+	b.WriteString("# TYPE salter_aws_cache_requests_total counter\n")
+	fmt.Fprintf(&b, "salter_aws_cache_requests_total{result=\"hit\"} %d\n", m.cacheHits)
+	fmt.Fprintf(&b, "salter_aws_cache_requests_total{result=\"miss\"} %d\n", m.cacheMisses)
+
+	b.WriteString("# HELP salter_aws_last_sync_timestamp_seconds Unix time of the last successful load of a prefix.\n")
+	b.WriteString("# TYPE salter_aws_last_sync_timestamp_seconds gauge\n")
+	var prefixes []string
+	for prefix := range m.lastSync {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		fmt.Fprintf(&b, "salter_aws_last_sync_timestamp_seconds{prefix=%q} %d\n", prefix, m.lastSync[prefix].Unix())
+	}
+
+	b.WriteString("# HELP salter_aws_sync_failures_total Failed loads of a prefix.\n")
+	b.WriteString("# TYPE salter_aws_sync_failures_total counter\n")
+	for _, prefix := range sortedKeys(m.syncFailures) {
+		fmt.Fprintf(&b, "salter_aws_sync_failures_total{prefix=%q} %d\n", prefix, m.syncFailures[prefix])
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func sortedKeys(m map[string]int64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// metricsClient counts every call made through the wrapped client.
+type metricsClient struct {
+	decorator
+	metrics *Metrics
+}
+
+// NewMetricsClient wraps client so that every API call is counted in metrics.
+func NewMetricsClient(client SSMClient, metrics *Metrics) SSMClient {
+	return &metricsClient{decorator: decorator{client}, metrics: metrics}
+}
+
+func (c *metricsClient) dryRun() bool {
+	return isDryRun(c.SSMClient)
+}
+
+func (c *metricsClient) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	out, err := c.SSMClient.GetParameter(ctx, params, optFns...)
+	c.metrics.recordCall("GetParameter", err)
+	return out, err
+}
+
+func (c *metricsClient) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	out, err := c.SSMClient.GetParametersByPath(ctx, params, optFns...)
+	c.metrics.recordCall("GetParametersByPath", err)
+	return out, err
+}
+
+func (c *metricsClient) DescribeParameters(ctx context.Context, params *ssm.DescribeParametersInput, optFns ...func(*ssm.Options)) (*ssm.DescribeParametersOutput, error) {
+	out, err := c.SSMClient.DescribeParameters(ctx, params, optFns...)
+	c.metrics.recordCall("DescribeParameters", err)
+	return out, err
+}
+
+func (c *metricsClient) PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+	out, err := c.SSMClient.PutParameter(ctx, params, optFns...)
+	c.metrics.recordCall("PutParameter", err)
+	return out, err
+}
+
+func (c *metricsClient) DeleteParameter(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error) {
+	out, err := c.SSMClient.DeleteParameter(ctx, params, optFns...)
+	c.metrics.recordCall("DeleteParameter", err)
+	return out, err
+}
+
+func (c *metricsClient) ListTagsForResource(ctx context.Context, params *ssm.ListTagsForResourceInput, optFns ...func(*ssm.Options)) (*ssm.ListTagsForResourceOutput, error) {
+	out, err := c.SSMClient.ListTagsForResource(ctx, params, optFns...)
+	c.metrics.recordCall("ListTagsForResource", err)
+	return out, err
+}
+
+func (c *metricsClient) AddTagsToResource(ctx context.Context, params *ssm.AddTagsToResourceInput, optFns ...func(*ssm.Options)) (*ssm.AddTagsToResourceOutput, error) {
+	out, err := c.SSMClient.AddTagsToResource(ctx, params, optFns...)
+	c.metrics.recordCall("AddTagsToResource", err)
+	return out, err
+}
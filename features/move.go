@@ -0,0 +1,252 @@
+package features
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// MoveOptions selects the parameters move renames and the task definitions it updates.
+type MoveOptions struct {
+	From, To  string   // Parameter names, or with Prefix, the path prefixes of the subtrees.
+	Prefix    bool     // Move every parameter under From to the same relative name under To.
+	TaskDefs  []string // Task definition files whose valueFrom references to moved parameters are rewritten in place.
+	AssumeYes bool     // Delete the sources without asking.
+}
+
+// moveItem is one parameter to move with everything copied to its new name.
+type moveItem struct {
+	from, to string
+	param    types.Parameter
+	meta     types.ParameterMetadata
+	tags     []types.Tag
+}
+
+// MoveParameters renames a parameter, or every parameter under a prefix. Each one is copied with its value,
+// type, description, KMS key, tier, policies and tags; then references in opts.TaskDefs are rewritten and,
+// after confirmation, the sources are deleted. Version history and labels stay with the old name.
+// Nothing is written when a destination already exists, and the sources are only deleted once every copy succeeded.
+func MoveParameters(client SSMClient, opts MoveOptions) error {
+	for _, file := range opts.TaskDefs {
+		if err := checkNotStdin(file, "-s"); err != nil {
+			return err
+		}
+	}
+	items, err := planMove(client, opts)
+	if err != nil {
+		return err
+	}
+	copies := make([]Change, len(items))
+	for i, item := range items {
+		copies[i] = Change{Operation: "PutParameter", Name: item.to, Type: string(item.param.Type)}
+	}
+	if err := planChanges(client, copies); err != nil {
+		return err
+	}
+	for i, item := range items {
+		if _, err := client.PutParameter(context.TODO(), copyInput(item)); err != nil {
+			return fmt.Errorf("copied %d of %d parameters, sources untouched: %w", i, len(items),
+				wrapClientError(client, "PutParameter", item.to, err))
+		}
+		if !isDryRun(client) {
+			Infof("%s %s -> %s\n", green("Copied"), item.from, item.to)
+		}
+	}
+
+	renames := make(map[string]string, len(items))
+	for _, item := range items {
+		renames[item.from] = item.to
+	}
+	for _, file := range opts.TaskDefs {
+		if err := renameReferences(file, renames, isDryRun(client)); err != nil {
+			return fmt.Errorf("parameters copied, sources untouched: %w", err)
+		}
+	}
+
+	if !opts.AssumeYes && !isDryRun(client) && !Confirm(fmt.Sprintf("Delete the %d source parameters?", len(items))) {
+		Infof("Kept the %d source parameters\n", len(items))
+		return nil
+	}
+	deletes := make([]Change, len(items))
+	for i, item := range items {
+		deletes[i] = Change{Operation: "DeleteParameter", Name: item.from}
+	}
+	if err := planChanges(client, deletes); err != nil {
+		return fmt.Errorf("parameters copied, sources untouched: %w", err)
+	}
+	var failures []error
+	for _, item := range items {
+		_, err := client.DeleteParameter(context.TODO(), &ssm.DeleteParameterInput{Name: aws.String(item.from)})
+		if err != nil {
+			err = wrapClientError(client, "DeleteParameter", item.from, err)
+			Infof("%s %s: %s\n", red("Failed to delete"), item.from, DescribeError(err))
+			failures = append(failures, err)
+			continue
+		}
+		if !isDryRun(client) {
+			Infof("Deleted %s\n", item.from)
+		}
+	}
+	if len(failures) > 0 {
+		return &PartialFailureError{Failed: failures, Total: len(items)}
+	}
+	return nil
+}
+
+// planMove reads the parameters to move with their metadata and tags, and checks that no destination exists.
+func planMove(client SSMClient, opts MoveOptions) ([]*moveItem, error) {
+	from, to := opts.From, opts.To
+	if from == "" || to == "" {
+		return nil, validationErrorf("move needs a source and a destination")
+	}
+	var items []*moveItem
+	filter := types.ParameterStringFilter{Key: aws.String("Name"), Option: aws.String("Equals"), Values: []string{from}}
+	if opts.Prefix {
+		from, to = strings.TrimSuffix(from, "/")+"/", strings.TrimSuffix(to, "/")+"/"
+		if strings.HasPrefix(to, from) || strings.HasPrefix(from, to) {
+			return nil, validationErrorf("prefixes %s and %s overlap", from, to)
+		}
+		params, err := listParameters(client, from)
+		if err != nil {
+			return nil, err
+		}
+		if len(params) == 0 {
+			return nil, validationErrorf("no parameters under %s", from)
+		}
+		for _, p := range params {
+			name := aws.ToString(p.Name)
+			items = append(items, &moveItem{from: name, to: to + strings.TrimPrefix(name, from), param: p})
+		}
+		filter = types.ParameterStringFilter{Key: aws.String("Path"), Option: aws.String("Recursive"), Values: []string{strings.TrimSuffix(from, "/")}}
+	} else {
+		if from == to {
+			return nil, validationErrorf("source and destination are both %s", from)
+		}
+		result, err := client.GetParameter(context.TODO(), &ssm.GetParameterInput{Name: aws.String(from), WithDecryption: aws.Bool(true)})
+		if err != nil {
+			return nil, wrapClientError(client, "GetParameter", from, err)
+		}
+		items = append(items, &moveItem{from: from, to: to, param: *result.Parameter})
+	}
+
+	var existing []string
+	for _, item := range items {
+		_, err := client.GetParameter(context.TODO(), &ssm.GetParameterInput{Name: aws.String(item.to)})
+		var notFound *types.ParameterNotFound
+		switch {
+		case err == nil:
+			existing = append(existing, item.to)
+		case !errors.As(err, &notFound):
+			return nil, wrapClientError(client, "GetParameter", item.to, err)
+		}
+	}
+	if len(existing) > 0 {
+		return nil, validationErrorf("move never overwrites; these destinations already exist: %s", strings.Join(existing, ", "))
+	}
+
+	metadata, err := describeParameters(client, filter)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		item.meta = metadata[item.from]
+		result, err := client.ListTagsForResource(context.TODO(), &ssm.ListTagsForResourceInput{
+			ResourceType: types.ResourceTypeForTaggingParameter,
+			ResourceId:   aws.String(item.from),
+		})
+		if err != nil {
+			return nil, wrapClientError(client, "ListTagsForResource", item.from, err)
+		}
+		item.tags = result.TagList
+	}
+	return items, nil
+}
+
+// describeParameters returns the metadata of the parameters matching every filter, by name.
+func describeParameters(client SSMClient, filter types.ParameterStringFilter, more ...types.ParameterStringFilter) (map[string]types.ParameterMetadata, error) {
+	metadata := make(map[string]types.ParameterMetadata)
+	input := &ssm.DescribeParametersInput{ParameterFilters: append([]types.ParameterStringFilter{filter}, more...), MaxResults: aws.Int32(50)}
+	for {
+		result, err := client.DescribeParameters(context.TODO(), input)
+		if err != nil {
+			return nil, wrapClientError(client, "DescribeParameters", filter.Values[0], err)
+		}
+		for _, meta := range result.Parameters {
+			metadata[aws.ToString(meta.Name)] = meta
+		}
+		if result.NextToken == nil {
+			return metadata, nil
+		}
+		input.NextToken = result.NextToken
+	}
+}
+
+// copyInput is the create-only PutParameter call that recreates item under its new name.
+func copyInput(item *moveItem) *ssm.PutParameterInput {
+	input := &ssm.PutParameterInput{
+		Name:           aws.String(item.to),
+		Value:          item.param.Value,
+		Type:           item.param.Type,
+		Overwrite:      aws.Bool(false), // Tags can only be given when creating a parameter.
+		Description:    item.meta.Description,
+		AllowedPattern: item.meta.AllowedPattern,
+		Tags:           item.tags,
+	}
+	if item.param.DataType != nil && aws.ToString(item.param.DataType) != "text" {
+		input.DataType = item.param.DataType
+	}
+	if item.meta.Tier == types.ParameterTierAdvanced {
+		input.Tier = item.meta.Tier
+	}
+	if item.param.Type == types.ParameterTypeSecureString && item.meta.KeyId != nil && aws.ToString(item.meta.KeyId) != defaultSSMKey {
+		input.KeyId = item.meta.KeyId
+	}
+	var policies []string
+	for _, policy := range item.meta.Policies {
+		policies = append(policies, aws.ToString(policy.PolicyText))
+	}
+	if len(policies) > 0 {
+		input.Policies = aws.String("[" + strings.Join(policies, ",") + "]")
+	}
+	return input
+}
+
+// renameReferences rewrites the valueFrom references in a task definition file that name a key of renames,
+// keeping ARNs as ARNs of the same region and account. With dryRun the file is only reported on.
+func renameReferences(file string, renames map[string]string, dryRun bool) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	updated, changed, err := rewriteValueFrom(data, func(valueFrom string) string {
+		to, ok := renames[ExtractParameterName(valueFrom)]
+		if !ok {
+			return valueFrom
+		}
+		if region, accountID, _, isARN := ParseParameterARN(valueFrom); isARN {
+			return ParameterARN(region, accountID, to)
+		}
+		return to
+	})
+	if err != nil {
+		return validationErrorf("failed to parse %s: %w", file, err)
+	}
+	switch {
+	case dryRun:
+		Infof("[dry-run] Would update %d references in %s\n", changed, file)
+	case changed > 0:
+		if err := os.WriteFile(file, updated, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file, err)
+		}
+		Infof("%s %d references in %s\n", yellow("Updated"), changed, file)
+	default:
+		Infof("No references to update in %s\n", file)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package features
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestMoveParametersSubtree(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/prod/old/DB_URL", "postgres://db", types.ParameterTypeSecureString)
+	fake.set("/prod/old/nested/TOKEN", "t0k3n", types.ParameterTypeString)
+	fake.set("/prod/other/KEEP", "keep", types.ParameterTypeString)
+	fake.meta["/prod/old/DB_URL"] = types.ParameterMetadata{
+		Name:        aws.String("/prod/old/DB_URL"),
+		Description: aws.String("primary database"),
+		KeyId:       aws.String("alias/app"),
+		Tier:        types.ParameterTierAdvanced,
+	}
+	fake.tags["/prod/old/DB_URL"] = []types.Tag{{Key: aws.String("team"), Value: aws.String("core")}}
+
+	dir := t.TempDir()
+	taskDef := filepath.Join(dir, "task.json")
+	data := `{"containerDefinitions": [
+  {"name": "web", "secrets": [{"name": "DB_URL", "valueFrom": "arn:aws:ssm:ap-southeast-3:123456789012:parameter/prod/old/DB_URL"}]},
+  {"name": "worker", "secrets": [
+    {"name": "TOKEN", "valueFrom": "/prod/old/nested/TOKEN"},
+    {"name": "KEEP", "valueFrom": "/prod/other/KEEP"}
+  ]}
+]}`
+	if err := os.WriteFile(taskDef, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := MoveParameters(fake, MoveOptions{From: "/prod/old", To: "/prod/new/", Prefix: true, TaskDefs: []string{taskDef}, AssumeYes: true})
+	if err != nil {
+		t.Fatalf("MoveParameters: %v", err)
+	}
+	for _, name := range []string{"/prod/old/DB_URL", "/prod/old/nested/TOKEN"} {
+		if _, ok := fake.params[name]; ok {
+			t.Errorf("source %s still exists", name)
+		}
+	}
+	if got := aws.ToString(fake.params["/prod/new/nested/TOKEN"].Value); got != "t0k3n" {
+		t.Errorf("/prod/new/nested/TOKEN = %q; want t0k3n", got)
+	}
+	moved := fake.meta["/prod/new/DB_URL"]
+	if fake.params["/prod/new/DB_URL"].Type != types.ParameterTypeSecureString || aws.ToString(moved.Description) != "primary database" ||
+		aws.ToString(moved.KeyId) != "alias/app" || moved.Tier != types.ParameterTierAdvanced {
+		t.Errorf("/prod/new/DB_URL metadata = %+v; want the source's type, description, key and tier", moved)
+	}
+	if tags := fake.tags["/prod/new/DB_URL"]; len(tags) != 1 || aws.ToString(tags[0].Key) != "team" {
+		t.Errorf("/prod/new/DB_URL tags = %v; want team=core", tags)
+	}
+
+	got, _ := os.ReadFile(taskDef)
+	want := strings.NewReplacer(
+		"parameter/prod/old/DB_URL", "parameter/prod/new/DB_URL",
+		`"/prod/old/nested/TOKEN"`, `"/prod/new/nested/TOKEN"`,
+	).Replace(data)
+	if string(got) != want {
+		t.Errorf("task definition =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMoveParametersRefusesExistingDestination(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/app/OLD_KEY", "old", types.ParameterTypeString)
+	fake.set("/app/NEW_KEY", "taken", types.ParameterTypeString)
+
+	err := MoveParameters(fake, MoveOptions{From: "/app/OLD_KEY", To: "/app/NEW_KEY", AssumeYes: true})
+	if ExitCode(err) != ExitValidation {
+		t.Fatalf("err = %v; want a validation error", err)
+	}
+	if fake.puts != 0 || aws.ToString(fake.params["/app/NEW_KEY"].Value) != "taken" || fake.params["/app/OLD_KEY"].Value == nil {
+		t.Errorf("a refused move changed parameters: %d puts", fake.puts)
+	}
+}
+
+func TestMoveParametersDryRun(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/app/OLD_KEY", "value", types.ParameterTypeString)
+
+	if err := MoveParameters(NewDryRunClient(fake), MoveOptions{From: "/app/OLD_KEY", To: "/app/NEW_KEY"}); err != nil {
+		t.Fatalf("MoveParameters: %v", err)
+	}
+	if _, ok := fake.params["/app/OLD_KEY"]; !ok || fake.puts != 0 {
+		t.Errorf("dry run changed parameters: %d puts, source kept %t", fake.puts, ok)
+	}
+}
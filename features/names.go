@@ -0,0 +1,94 @@
+package features
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// NameRules normalizes source keys into parameter names where generate composes a path from the prefix and
+// the key, so that keys such as db.host or api-key follow the naming of the other parameters. The secret
+// name in the task definition, the environment variable, keeps the key as written.
+type NameRules struct {
+	Case      string `json:"case,omitempty"`      // "upper" or "lower"; empty (or "keep") keeps the case of the key.
+	Replace   string `json:"replace,omitempty"`   // Characters replaced by Separator, e.g. ".-".
+	Separator string `json:"separator,omitempty"` // "_", "-" or "."; "_" if empty.
+	MaxLength int    `json:"maxLength,omitempty"` // Longest segment of a normalized key; 0 for no limit.
+}
+
+// minNameLength is the shortest MaxLength: a truncated segment keeps some of the key before its hash suffix.
+const minNameLength = 16
+
+// nameInvalid matches characters that are never allowed in a parameter name segment.
+var nameInvalid = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+// Validate reports rules that cannot be applied.
+func (r *NameRules) Validate() error {
+	if r == nil {
+		return nil
+	}
+	switch strings.ToLower(r.Case) {
+	case "", "keep", "upper", "lower":
+	default:
+		return validationErrorf("nameRules case %q is invalid (use upper, lower or keep)", r.Case)
+	}
+	switch r.Separator {
+	case "", "_", "-", ".":
+	default:
+		return validationErrorf("nameRules separator %q is invalid (use _, - or .)", r.Separator)
+	}
+	if strings.Contains(r.Replace, "/") {
+		return validationErrorf("nameRules replace cannot contain /, which separates the segments of a key")
+	}
+	if r.MaxLength != 0 && r.MaxLength < minNameLength {
+		return validationErrorf("nameRules maxLength %d is too short (at least %d)", r.MaxLength, minNameLength)
+	}
+	return nil
+}
+
+// Normalize returns key as a parameter name relative to the prefix. Each /-separated segment is normalized
+// on its own: the case is mapped, runs of Replace characters and of characters not allowed in parameter names
+// become one Separator, and a segment longer than MaxLength is cut and ends with the separator and 8 hex
+// digits of the SHA-256 of the original segment, so long keys sharing a start stay distinct. Without rules
+// (nil) only the characters not allowed in names are replaced.
+func (r *NameRules) Normalize(key string) string {
+	if r == nil {
+		r = &NameRules{}
+	}
+	sep := r.Separator
+	if sep == "" {
+		sep = "_"
+	}
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		s := segment
+		switch strings.ToLower(r.Case) {
+		case "upper":
+			s = strings.ToUpper(s)
+		case "lower":
+			s = strings.ToLower(s)
+		}
+		var b strings.Builder
+		replacing := false
+		for _, c := range s {
+			if strings.ContainsRune(r.Replace, c) || nameInvalid.MatchString(string(c)) {
+				if !replacing {
+					b.WriteString(sep)
+				}
+				replacing = true
+				continue
+			}
+			b.WriteRune(c)
+			replacing = false
+		}
+		s = b.String()
+		if r.MaxLength > 0 && len(s) > r.MaxLength {
+			sum := sha256.Sum256([]byte(segment))
+			suffix := sep + hex.EncodeToString(sum[:])[:8]
+			s = strings.TrimRight(s[:r.MaxLength-len(suffix)], sep) + suffix
+		}
+		segments[i] = s
+	}
+	return strings.Join(segments, "/")
+}
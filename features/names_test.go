@@ -0,0 +1,69 @@
+package features
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNameRulesNormalize(t *testing.T) {
+	rules := &NameRules{Case: "upper", Replace: ".-", MaxLength: 20}
+	for key, want := range map[string]string{
+		"DB_URL":          "DB_URL",
+		"db.host":         "DB_HOST",
+		"api-key..v2":     "API_KEY_V2",
+		"db/read.replica": "DB/READ_REPLICA",
+		"has space":       "HAS_SPACE",
+	} {
+		if got := rules.Normalize(key); got != want {
+			t.Errorf("Normalize(%q) = %q; want %q", key, got, want)
+		}
+	}
+	long := rules.Normalize("a_very_long_setting_name_1")
+	if len(long) != 20 || !strings.HasPrefix(long, "A_VERY_LONG_") || long == rules.Normalize("a_very_long_setting_name_2") {
+		t.Errorf("Normalize of a long key = %q; want 20 characters with a hash suffix distinct from a similar key", long)
+	}
+	var none *NameRules
+	if got := none.Normalize("db.host name"); got != "db.host_name" {
+		t.Errorf("Normalize without rules = %q; want only the space replaced", got)
+	}
+	if got := (&NameRules{Case: "lower", Replace: "_", Separator: "-"}).Normalize("DB_HOST"); got != "db-host" {
+		t.Errorf("Normalize to kebab case = %q; want db-host", got)
+	}
+
+	for _, bad := range []NameRules{{Case: "title"}, {Separator: "/"}, {Replace: "/"}, {MaxLength: 4}} {
+		if err := bad.Validate(); !errors.Is(err, ErrValidation) {
+			t.Errorf("Validate(%+v) = %v; want a validation error", bad, err)
+		}
+	}
+}
+
+func TestGenerateTaskDefNameRules(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "app.env")
+	env := "db.host=db\nPORT=8080\n# param: path=/custom/api.key\napi.key=k\n"
+	if err := os.WriteFile(source, []byte(env), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "task.json")
+	if err := GenerateTaskDef(source, out, GenerateOptions{Prefix: "/app/", Names: &NameRules{Case: "upper", Replace: "."}}); err != nil {
+		t.Fatalf("GenerateTaskDef: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var taskDef TaskDefinition
+	if err := json.Unmarshal(data, &taskDef); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"db.host": "/app/DB_HOST", "PORT": "/app/PORT", "api.key": "/custom/api.key"}
+	for _, s := range taskDef.ContainerDefinitions[0].Secrets {
+		if want[s.Name] != s.ValueFrom {
+			t.Errorf("%s valueFrom = %s; want %s", s.Name, s.ValueFrom, want[s.Name])
+		}
+	}
+}
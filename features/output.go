@@ -0,0 +1,196 @@
+package features
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// OutputParam is one parameter handed to an OutputWriter.
+type OutputParam struct {
+	Key   string        // Variable name: the parameter name relative to the prefix, or the secret name for get -s.
+	Name  string        // Full parameter name.
+	Value string        // Decrypted value.
+	Type  ParameterType // Parameter type.
+	// ValueType is the type the value was put with (see ValueNumber), for the formats that keep it; "" is a string.
+	ValueType string
+	// KeyID, Tier, Description and Tags are the metadata of the parameter that taskdef writes, when export
+	// read it: KeyID is empty for the default aws/ssm key and Tier for Standard.
+	KeyID       string
+	Tier        string
+	Description string
+	Tags        map[string]string
+}
+
+// OutputWriter renders parameters in one output format. Every action that takes -format looks the format up
+// among the registered writers (see RegisterOutputWriter), so a new format is one type and its registration.
+type OutputWriter interface {
+	// Extension is the suffix appended to the -o base path, such as ".env".
+	Extension() string
+	// Render returns the content for params, which are in output order. opts carries the settings of
+	// export some formats use, such as SecretName or Refs; for get -s it is the zero value.
+	Render(params []OutputParam, opts ExportOptions) (string, error)
+}
+
+// DirectoryWriter is implemented by writers that write a directory at the -o base instead of a file, such
+// as envdir. WriteDir is called instead of Render, and the format cannot be printed to stdout.
+type DirectoryWriter interface {
+	WriteDir(dir string, params []OutputParam) error
+}
+
+// CommentWriter is implemented by writers of formats with line comments, so export can add the
+// fingerprint (see ExportOptions.Fingerprint) without changing the data.
+type CommentWriter interface {
+	// Comment returns text as a complete comment line, with its newline.
+	Comment(text string) string
+}
+
+// outputFormat is a registered output format.
+type outputFormat struct {
+	name   string
+	writer OutputWriter
+	// exportOnly formats need what only the export pipeline (export, get-by-prefix and get -s) has: the
+	// prefix or family k8s names its Secret after, or the full parameter names.
+	exportOnly bool
+}
+
+var (
+	outputMu      sync.RWMutex
+	outputFormats []outputFormat // In registration order, the order formats are offered to users.
+)
+
+func init() {
+	registerOutput(FormatEnv, envWriter{}, false)
+	registerOutput(FormatSystemd, systemdWriter{}, false)
+	registerOutput(FormatEnvdir, envdirWriter{}, false)
+	registerOutput(FormatProperties, propertiesWriter{}, false)
+	registerOutput(FormatAppsettings, appsettingsWriter{}, false)
+	registerOutput(FormatTOML, tomlWriter{}, false)
+	registerOutput(FormatHCL, hclWriter{}, false)
+	registerOutput(FormatJSON, jsonWriter{}, true)
+	registerOutput(FormatYAML, yamlWriter{}, true)
+	registerOutput(FormatK8s, k8sWriter{}, true)
+	registerOutput(FormatTaskDef, taskDefWriter{}, true)
+}
+
+// RegisterOutputWriter makes format available to -format everywhere, written by w. Programs embedding the
+// package call it from an init function. It panics if format is empty or already registered.
+func RegisterOutputWriter(format string, w OutputWriter) {
+	registerOutput(format, w, false)
+}
+
+func registerOutput(format string, w OutputWriter, exportOnly bool) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	if format == "" || w == nil {
+		panic("features: RegisterOutputWriter needs a format name and a writer")
+	}
+	for _, f := range outputFormats {
+		if f.name == format {
+			panic("features: output format " + format + " registered twice")
+		}
+	}
+	outputFormats = append(outputFormats, outputFormat{name: format, writer: w, exportOnly: exportOnly})
+}
+
+// lookupOutput returns the registered format, or a validation error listing the known ones.
+func lookupOutput(format string) (outputFormat, error) {
+	outputMu.RLock()
+	for _, f := range outputFormats {
+		if f.name == format {
+			outputMu.RUnlock()
+			return f, nil
+		}
+	}
+	outputMu.RUnlock()
+	return outputFormat{}, validationErrorf("unknown format %q (use one of: %s)", format, strings.Join(FormatNames(), ", "))
+}
+
+// FormatNames returns every output format name.
+func FormatNames() []string {
+	outputMu.RLock()
+	defer outputMu.RUnlock()
+	names := make([]string, len(outputFormats))
+	for i, f := range outputFormats {
+		names[i] = f.name
+	}
+	return names
+}
+
+// ValidateFormat rejects unknown output formats.
+func ValidateFormat(format string) error {
+	_, err := lookupOutput(format)
+	return err
+}
+
+// formatExtension is the suffix format appends to the output base, or "" for unknown and directory formats.
+func formatExtension(format string) string {
+	f, err := lookupOutput(format)
+	if err != nil {
+		return ""
+	}
+	return f.writer.Extension()
+}
+
+// renderOutput renders params as text in format. Directory formats are rejected, as are the formats only
+// export can produce unless export is set.
+func renderOutput(format string, params []OutputParam, opts ExportOptions, export bool) (string, error) {
+	f, err := lookupOutput(format)
+	if err != nil {
+		return "", err
+	}
+	if f.exportOnly && !export {
+		return "", validationErrorf("format %s is only available with export, get-by-prefix and get -s", format)
+	}
+	if _, ok := f.writer.(DirectoryWriter); ok {
+		return "", validationErrorf("format %s writes a directory; use -o", format)
+	}
+	return f.writer.Render(params, opts)
+}
+
+// writeOutput writes params in format under base and returns the path written: base plus the format's
+// extension, or the directory base for directory formats.
+func writeOutput(format, base string, params []OutputParam, opts ExportOptions, export bool) (string, error) {
+	f, err := lookupOutput(format)
+	if err != nil {
+		return "", err
+	}
+	if dir, ok := f.writer.(DirectoryWriter); ok {
+		return base, dir.WriteDir(base, params)
+	}
+	content, err := renderOutput(format, params, opts, export)
+	if err != nil {
+		return "", err
+	}
+	path := base + f.writer.Extension()
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		return "", fmt.Errorf("failed to write %s file %s: %w", format, path, err)
+	}
+	return path, nil
+}
+
+// envParams turns an environment into output parameters without names or types.
+func envParams(vars []EnvVar) []OutputParam {
+	params := make([]OutputParam, len(vars))
+	for i, v := range vars {
+		params[i] = OutputParam{Key: v.Key, Value: v.Value}
+	}
+	return params
+}
+
+// outputVars returns the environment of params.
+func outputVars(params []OutputParam) []EnvVar {
+	vars := make([]EnvVar, len(params))
+	for i, p := range params {
+		vars[i] = EnvVar{Key: p.Key, Value: p.Value}
+	}
+	return vars
+}
+
+// hashComments gives a writer "#" line comments.
+type hashComments struct{}
+
+func (hashComments) Comment(text string) string {
+	return "# " + text + "\n"
+}
@@ -0,0 +1,55 @@
+package features
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// csvWriter is a third-party style writer, registered as "test-csv".
+type csvWriter struct{}
+
+func (csvWriter) Extension() string { return ".csv" }
+
+func (csvWriter) Render(params []OutputParam, _ ExportOptions) (string, error) {
+	var b strings.Builder
+	for _, p := range params {
+		b.WriteString(p.Name + "," + string(p.Type) + "," + p.Value + "\n")
+	}
+	return b.String(), nil
+}
+
+func init() {
+	RegisterOutputWriter("test-csv", csvWriter{})
+}
+
+func TestRegisteredOutputWriter(t *testing.T) {
+	if names := FormatNames(); !slices.Contains(names, "test-csv") || names[0] != FormatEnv {
+		t.Errorf("FormatNames() = %v; want the built-in formats first and test-csv", names)
+	}
+	fake := newFakeSSM()
+	fake.set("/prod/app/DB_URL", "postgres://db", types.ParameterTypeString)
+	base := filepath.Join(t.TempDir(), "app")
+
+	if err := ExportParameters(fake, "/prod/app/", ExportOptions{Formats: []string{"test-csv", FormatEnv}, Output: base}); err != nil {
+		t.Fatalf("ExportParameters: %v", err)
+	}
+	data, err := os.ReadFile(base + ".csv")
+	if err != nil || string(data) != "/prod/app/DB_URL,String,postgres://db\n" {
+		t.Errorf("app.csv = %q (err %v)", data, err)
+	}
+	if got := OutputBase(base+".csv", ""); got != base {
+		t.Errorf("OutputBase(app.csv) = %q; want the extension of a registered writer dropped", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("registering test-csv twice did not panic")
+		}
+	}()
+	RegisterOutputWriter("test-csv", csvWriter{})
+}
@@ -0,0 +1,208 @@
+package features
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Input formats for the JSON the password manager CLIs print. Both are .json, so they are chosen by name.
+const (
+	Input1Password = "1password" // op item get --format json, one or more items.
+	InputBitwarden = "bitwarden" // bw list items or bw get item.
+)
+
+// passwordKeyInvalid matches what a parameter name segment cannot hold, in item titles and field labels.
+var passwordKeyInvalid = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// passwordKey joins item and field names into a key relative to the prefix, such as Stripe_API/password.
+func passwordKey(parts ...string) string {
+	var segments []string
+	for _, part := range parts {
+		if segment := strings.Trim(passwordKeyInvalid.ReplaceAllString(part, "_"), "_"); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// decodeItems decodes data as a JSON array of items, or as one or more items in a row (as op item get prints
+// them when items are piped in), calling item with each raw item.
+func decodeItems(data []byte, filename string, item func(json.RawMessage) error) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return validationErrorf("failed to parse %s: %w", filename, err)
+		}
+		raw = bytes.TrimSpace(raw)
+		if len(raw) == 0 || raw[0] != '[' {
+			if err := item(raw); err != nil {
+				return err
+			}
+			continue
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return validationErrorf("failed to parse %s: %w", filename, err)
+		}
+		for _, raw := range items {
+			if err := item(raw); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// itemParams collects the parameters of password manager items, keeping the last of a key several items
+// define and warning about it, or failing with strict.
+type itemParams struct {
+	params []InputParam
+	index  map[string]int      // Index of each key in params.
+	items  map[string][]string // Titles of the items defining each key.
+}
+
+func (p *itemParams) add(title string, param InputParam) {
+	if p.index == nil {
+		p.index, p.items = make(map[string]int), make(map[string][]string)
+	}
+	if param.Key == "" || param.Value == "" {
+		return
+	}
+	p.items[param.Key] = append(p.items[param.Key], title)
+	if i, ok := p.index[param.Key]; ok {
+		p.params[i] = param
+		return
+	}
+	p.index[param.Key] = len(p.params)
+	p.params = append(p.params, param)
+}
+
+func (p *itemParams) result(filename string, strict bool) ([]InputParam, error) {
+	for _, param := range p.params {
+		titles := p.items[param.Key]
+		if len(titles) < 2 {
+			continue
+		}
+		if strict {
+			return nil, validationErrorf("%s: %s is defined by several items (%s)", filename, param.Key, strings.Join(titles, ", "))
+		}
+		Infof("Warning: %s: %s is defined by several items (%s); the last one is used\n", filename, param.Key, strings.Join(titles, ", "))
+	}
+	return p.params, nil
+}
+
+// onePasswordReader reads 1Password items as printed by op item get --format json. Each field with a value
+// becomes <title>/<label>, or <title>/<section>/<label> for fields in a named section; concealed fields
+// (passwords, API credentials), one-time password secrets and notes are SecureStrings and other fields Strings:
+// the types come from the item, not from names like those generate detects.
+type onePasswordReader struct{}
+
+func (onePasswordReader) Extensions() []string { return nil }
+
+func (onePasswordReader) Read(data []byte, filename string, opts InputOptions) ([]InputParam, error) {
+	var params itemParams
+	err := decodeItems(data, filename, func(raw json.RawMessage) error {
+		var item struct {
+			Title  string `json:"title"`
+			Fields []struct {
+				Type    string `json:"type"`
+				Purpose string `json:"purpose"`
+				Label   string `json:"label"`
+				Value   string `json:"value"`
+				Section *struct {
+					Label string `json:"label"`
+				} `json:"section"`
+			} `json:"fields"`
+		}
+		if err := json.Unmarshal(raw, &item); err != nil {
+			return validationErrorf("failed to parse %s: %w", filename, err)
+		}
+		if item.Title == "" {
+			return validationErrorf("%s: an item has no title (use op item get --format json, not op item list)", filename)
+		}
+		for _, field := range item.Fields {
+			if passwordKey(field.Label) == "" {
+				continue // Unlabelled fields have no name to put them under.
+			}
+			section := ""
+			if field.Section != nil {
+				section = field.Section.Label
+			}
+			param := InputParam{Key: passwordKey(item.Title, section, field.Label), Value: field.Value, Type: StringType}
+			if field.Type == "CONCEALED" || field.Type == "OTP" || field.Purpose == "NOTES" {
+				param.Type = SecureStringType
+			}
+			params.add(item.Title, param)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return params.result(filename, opts.Strict)
+}
+
+// Bitwarden custom field types.
+const (
+	bitwardenFieldHidden = 1
+	bitwardenFieldLinked = 3 // Refers to another field of the item; it has no value of its own.
+)
+
+// bitwardenReader reads Bitwarden items as printed by bw list items or bw get item. A login becomes
+// <name>/username, <name>/password and <name>/totp, notes become <name>/notes and custom fields
+// <name>/<field>; passwords, TOTP seeds, notes and hidden fields are SecureStrings and the others Strings.
+type bitwardenReader struct{}
+
+func (bitwardenReader) Extensions() []string { return nil }
+
+func (bitwardenReader) Read(data []byte, filename string, opts InputOptions) ([]InputParam, error) {
+	var params itemParams
+	err := decodeItems(data, filename, func(raw json.RawMessage) error {
+		var item struct {
+			Name  string `json:"name"`
+			Notes string `json:"notes"`
+			Login *struct {
+				Username string `json:"username"`
+				Password string `json:"password"`
+				TOTP     string `json:"totp"`
+			} `json:"login"`
+			Fields []struct {
+				Name  string `json:"name"`
+				Value string `json:"value"`
+				Type  int    `json:"type"`
+			} `json:"fields"`
+		}
+		if err := json.Unmarshal(raw, &item); err != nil {
+			return validationErrorf("failed to parse %s: %w", filename, err)
+		}
+		if item.Name == "" {
+			return validationErrorf("%s: an item has no name (use bw list items or bw get item)", filename)
+		}
+		if item.Login != nil {
+			params.add(item.Name, InputParam{Key: passwordKey(item.Name, "username"), Value: item.Login.Username, Type: StringType})
+			params.add(item.Name, InputParam{Key: passwordKey(item.Name, "password"), Value: item.Login.Password, Type: SecureStringType})
+			params.add(item.Name, InputParam{Key: passwordKey(item.Name, "totp"), Value: item.Login.TOTP, Type: SecureStringType})
+		}
+		params.add(item.Name, InputParam{Key: passwordKey(item.Name, "notes"), Value: item.Notes, Type: SecureStringType})
+		for _, field := range item.Fields {
+			if field.Type == bitwardenFieldLinked || passwordKey(field.Name) == "" {
+				continue
+			}
+			param := InputParam{Key: passwordKey(item.Name, field.Name), Value: field.Value, Type: StringType}
+			if field.Type == bitwardenFieldHidden {
+				param.Type = SecureStringType
+			}
+			params.add(item.Name, param)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return params.result(filename, opts.Strict)
+}
@@ -0,0 +1,86 @@
+package features
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestImportPasswordManagers(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		// Two items in a row, as op item get - prints them.
+		"op.json": `{"title": "Stripe API", "fields": [
+  {"type": "STRING", "purpose": "USERNAME", "label": "username", "value": "acct_1"},
+  {"type": "CONCEALED", "purpose": "PASSWORD", "label": "password", "value": "sk_live"},
+  {"type": "STRING", "purpose": "NOTES", "label": "notesPlain", "value": ""}
+]}
+{"title": "db", "fields": [
+  {"type": "STRING", "label": "host", "value": "db.internal", "section": {"id": "s1", "label": "Primary"}}
+]}`,
+		"bw.json": `[{"type": 1, "name": "Mail Relay", "notes": null,
+  "login": {"username": "relay", "password": "hunter2", "totp": null},
+  "fields": [{"name": "api key", "value": "k3y", "type": 1}, {"name": "linked", "value": null, "type": 3, "linkedId": 100}]}]`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		file, format string
+		want         map[string]types.ParameterType
+	}{
+		{"op.json", Input1Password, map[string]types.ParameterType{
+			"/legacy/Stripe_API/username": types.ParameterTypeString,
+			"/legacy/Stripe_API/password": types.ParameterTypeSecureString,
+			"/legacy/db/Primary/host":     types.ParameterTypeString,
+		}},
+		{"bw.json", InputBitwarden, map[string]types.ParameterType{
+			"/legacy/Mail_Relay/username": types.ParameterTypeString,
+			"/legacy/Mail_Relay/password": types.ParameterTypeSecureString,
+			"/legacy/Mail_Relay/api_key":  types.ParameterTypeSecureString,
+		}},
+	}
+	for _, tt := range tests {
+		fake := newFakeSSM()
+		err := ImportParameters(fake, filepath.Join(dir, tt.file), ImportOptions{Format: tt.format, Prefix: "/legacy/"}, PutOptions{AssumeYes: true})
+		if err != nil {
+			t.Errorf("import %s: %v", tt.file, err)
+			continue
+		}
+		got := make(map[string]types.ParameterType)
+		for name, p := range fake.params {
+			got[name] = p.Type
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("import %s put %v; want %v", tt.file, got, tt.want)
+		}
+		if tt.format == InputBitwarden && aws.ToString(fake.params["/legacy/Mail_Relay/api_key"].Value) != "k3y" {
+			t.Errorf("api_key = %+v; want k3y", fake.params["/legacy/Mail_Relay/api_key"])
+		}
+	}
+}
+
+func TestPasswordItemsDefiningOneKey(t *testing.T) {
+	data := []byte(`[{"name": "App", "login": {"password": "a"}}, {"name": "app?", "login": {"password": "b"}}, {"name": "App", "login": {"password": "c"}}]`)
+	params, err := bitwardenReader{}.Read(data, "bw.json", InputOptions{})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	want := []InputParam{
+		{Key: "App/password", Value: "c", Type: SecureStringType},
+		{Key: "app/password", Value: "b", Type: SecureStringType},
+	}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("params = %+v; want %+v", params, want)
+	}
+	if _, err := (bitwardenReader{}).Read(data, "bw.json", InputOptions{Strict: true}); !errors.Is(err, ErrValidation) {
+		t.Errorf("strict: err = %v; want ErrValidation", err)
+	}
+}
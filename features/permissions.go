@@ -0,0 +1,104 @@
+package features
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Permission is an IAM action on a resource that a call was denied.
+type Permission struct {
+	Action    string // e.g. "ssm:GetParameter" or "kms:Decrypt".
+	Resource  string // Resource ARN, or "*" for actions without resource-level permissions.
+	Principal string // The denied caller's ARN, when AWS named it.
+	Explicit  bool   // An explicit Deny matched, so an Allow is not enough.
+}
+
+// deniedPattern matches the access denied messages of IAM-authorized APIs, e.g. "User: arn:aws:sts::1:assumed-role/dev/x
+// is not authorized to perform: ssm:GetParameter on resource: arn:aws:ssm:eu-west-1:1:parameter/app/KEY because ...".
+var deniedPattern = regexp.MustCompile(`(?:User: (\S+) is )?not authorized to perform: (\S+) on resource: (\S+)`)
+
+// Permission returns the permission e was denied, or false when e is not an access denied error. The action and
+// resource come from AWS's message when it names them, otherwise from e's operation and parameter name; the
+// account of a derived ARN is then "*".
+func (e *ParameterError) Permission() (Permission, bool) {
+	if e.Kind != ErrAccessDenied {
+		return Permission{}, false
+	}
+	msg := apiMessage(e.Err)
+	p := Permission{Explicit: strings.Contains(msg, "explicit deny")}
+	if m := deniedPattern.FindStringSubmatch(msg); m != nil {
+		p.Principal, p.Action, p.Resource = m[1], m[2], strings.TrimSuffix(m[3], ".")
+		return p, true
+	}
+	p.Action = "ssm:" + e.Op
+	p.Resource = parameterARN(e.Op, e.Region, e.Name)
+	return p, true
+}
+
+// parameterARN returns the ARN IAM checks for op on the parameter or path name in region ("*" when unknown).
+func parameterARN(op, region, name string) string {
+	if op == "DescribeParameters" {
+		return "*" // DescribeParameters has no resource-level permissions.
+	}
+	if region == "" {
+		region = "*"
+	}
+	if op == "GetParametersByPath" {
+		name = strings.TrimSuffix(name, "/")
+	}
+	if !strings.HasPrefix(name, "/") {
+		name = "/" + name
+	}
+	return "arn:aws:ssm:" + region + ":*:parameter" + name
+}
+
+// policyStatement is one statement of an IAM policy document.
+type policyStatement struct {
+	Effect   string   `json:"Effect"`
+	Action   string   `json:"Action"`
+	Resource []string `json:"Resource"`
+}
+
+// PolicySnippet returns an IAM policy document that allows every permission denied in err, including the
+// errors collected by a PartialFailureError, with one statement per action, or "" when err holds none.
+// Explicit denies are left out, since no Allow overrides them.
+func PolicySnippet(err error) string {
+	resources := make(map[string]map[string]bool)
+	for _, perr := range parameterErrors(err) {
+		p, ok := perr.Permission()
+		if !ok || p.Explicit {
+			continue
+		}
+		if resources[p.Action] == nil {
+			resources[p.Action] = make(map[string]bool)
+		}
+		resources[p.Action][p.Resource] = true
+	}
+	if len(resources) == 0 {
+		return ""
+	}
+	actions := make([]string, 0, len(resources))
+	for action := range resources {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	policy := struct {
+		Version   string            `json:"Version"`
+		Statement []policyStatement `json:"Statement"`
+	}{Version: "2012-10-17"}
+	for _, action := range actions {
+		statement := policyStatement{Effect: "Allow", Action: action}
+		for resource := range resources[action] {
+			statement.Resource = append(statement.Resource, resource)
+		}
+		sort.Strings(statement.Resource)
+		policy.Statement = append(policy.Statement, statement)
+	}
+	data, err := json.MarshalIndent(policy, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(data) + "\n"
+}
@@ -0,0 +1,97 @@
+package features
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/smithy-go"
+)
+
+func deniedError(op, name, region, message string) *ParameterError {
+	return &ParameterError{Op: op, Name: name, Region: region, Kind: ErrAccessDenied,
+		Err: &smithy.GenericAPIError{Code: "AccessDeniedException", Message: message}}
+}
+
+func TestParameterErrorPermission(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *ParameterError
+		want Permission
+	}{
+		{
+			"from message",
+			deniedError("GetParameter", "/prod/app/KEY", "eu-west-1", "User: arn:aws:sts::123456789012:assumed-role/dev/alice is not authorized to perform: ssm:GetParameter on resource: arn:aws:ssm:eu-west-1:123456789012:parameter/prod/app/KEY because no identity-based policy allows the ssm:GetParameter action"),
+			Permission{Action: "ssm:GetParameter", Resource: "arn:aws:ssm:eu-west-1:123456789012:parameter/prod/app/KEY", Principal: "arn:aws:sts::123456789012:assumed-role/dev/alice"},
+		},
+		{
+			"explicit deny",
+			deniedError("PutParameter", "/prod/app/KEY", "eu-west-1", "User: arn:aws:iam::123456789012:user/bob is not authorized to perform: ssm:PutParameter on resource: arn:aws:ssm:eu-west-1:123456789012:parameter/prod/app/KEY with an explicit deny in a service control policy"),
+			Permission{Action: "ssm:PutParameter", Resource: "arn:aws:ssm:eu-west-1:123456789012:parameter/prod/app/KEY", Principal: "arn:aws:iam::123456789012:user/bob", Explicit: true},
+		},
+		{
+			"derived",
+			deniedError("GetParametersByPath", "/prod/app/", "ap-southeast-3", "not authorized"),
+			Permission{Action: "ssm:GetParametersByPath", Resource: "arn:aws:ssm:ap-southeast-3:*:parameter/prod/app"},
+		},
+		{
+			"describe",
+			deniedError("DescribeParameters", "/prod/app/", "", "not authorized"),
+			Permission{Action: "ssm:DescribeParameters", Resource: "*"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.err.Permission()
+			if !ok || got != tt.want {
+				t.Errorf("Permission() = %+v, %v; want %+v", got, ok, tt.want)
+			}
+		})
+	}
+	if _, ok := (&ParameterError{Op: "GetParameter", Name: "/x", Kind: ErrNotFound, Err: errors.New("x")}).Permission(); ok {
+		t.Error("Permission() of a not found error reported a permission")
+	}
+}
+
+func TestPolicySnippet(t *testing.T) {
+	err := &PartialFailureError{Failed: []error{
+		fmt.Errorf("secret A: %w", deniedError("GetParameter", "/prod/app/A", "eu-west-1", "not authorized")),
+		deniedError("GetParameter", "/prod/app/B", "eu-west-1", "not authorized"),
+		deniedError("GetParameter", "/prod/app/A", "eu-west-1", "not authorized"),
+		deniedError("PutParameter", "/prod/app/C", "eu-west-1", "User: arn:aws:iam::1:user/bob is not authorized to perform: ssm:PutParameter on resource: arn:aws:ssm:eu-west-1:1:parameter/prod/app/C with an explicit deny"),
+		errors.New("invalid ARN"),
+	}, Total: 5}
+
+	var policy struct {
+		Version   string
+		Statement []struct {
+			Effect   string
+			Action   string
+			Resource []string
+		}
+	}
+	if err := json.Unmarshal([]byte(PolicySnippet(err)), &policy); err != nil {
+		t.Fatalf("PolicySnippet is not JSON: %v", err)
+	}
+	if policy.Version != "2012-10-17" || len(policy.Statement) != 1 {
+		t.Fatalf("policy = %+v; want one statement", policy)
+	}
+	s := policy.Statement[0]
+	want := "arn:aws:ssm:eu-west-1:*:parameter/prod/app/A,arn:aws:ssm:eu-west-1:*:parameter/prod/app/B"
+	if s.Effect != "Allow" || s.Action != "ssm:GetParameter" || strings.Join(s.Resource, ",") != want {
+		t.Errorf("statement = %+v; want Allow ssm:GetParameter on %s", s, want)
+	}
+	if got := PolicySnippet(errors.New("boom")); got != "" {
+		t.Errorf("PolicySnippet(plain error) = %q; want empty", got)
+	}
+}
+
+func TestAccessDeniedHintsExplicitDeny(t *testing.T) {
+	err := deniedError("PutParameter", "/prod/app/KEY", "eu-west-1", "User: arn:aws:iam::1:user/bob is not authorized to perform: ssm:PutParameter on resource: arn:aws:ssm:eu-west-1:1:parameter/prod/app/KEY with an explicit deny")
+	hints := err.Hints()
+	if len(hints) == 0 || !strings.Contains(hints[0], "explicit Deny") || !strings.Contains(hints[0], "arn:aws:iam::1:user/bob") {
+		t.Errorf("Hints() = %q; want the explicit deny first", hints)
+	}
+}
@@ -0,0 +1,159 @@
+package features
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// ErrProtected is returned for a write under a protected prefix without a justification.
+var ErrProtected = errors.New("protected prefix")
+
+// DefaultAuditLog is the audit log written when config.json does not set auditLog.
+const DefaultAuditLog = "audit.log"
+
+// ProtectOptions controls writes under the protectedPrefixes of config.json.
+type ProtectOptions struct {
+	Prefixes      []string // Protected path prefixes, e.g. "/prod/payments/".
+	Justification string   // Why the write is made (-i-know-what-i-am-doing); empty refuses protected writes.
+	Action        string   // The action of the run, recorded in the audit log.
+	AuditLog      string   // File the audit entries are appended to; empty uses DefaultAuditLog.
+}
+
+// AuditEntry is one line of the audit log, written before a change under a protected prefix is sent.
+type AuditEntry struct {
+	Time          time.Time `json:"time"`
+	User          string    `json:"user"`
+	Action        string    `json:"action"`
+	Operation     string    `json:"operation"` // PutParameter or DeleteParameter.
+	Name          string    `json:"name"`
+	Region        string    `json:"region,omitempty"`
+	Prefix        string    `json:"prefix"`
+	Justification string    `json:"justification"`
+}
+
+// protectedClient refuses writes under protected prefixes without a justification, and audits the others.
+type protectedClient struct {
+	decorator
+	opts ProtectOptions
+}
+
+// auditMu serializes audit log appends, e.g. of concurrent multi-region puts.
+var auditMu sync.Mutex
+
+// NewProtectedClient wraps client so that PutParameter and DeleteParameter on a name under one of
+// opts.Prefixes fail with ErrProtected (a validation error) unless opts.Justification is set. With a
+// justification an AuditEntry is appended to opts.AuditLog first; when that fails the call is not made.
+func NewProtectedClient(client SSMClient, opts ProtectOptions) SSMClient {
+	if len(opts.Prefixes) == 0 {
+		return client
+	}
+	if opts.AuditLog == "" {
+		opts.AuditLog = DefaultAuditLog
+	}
+	return &protectedClient{decorator: decorator{client}, opts: opts}
+}
+
+// PutParameter checks and audits the put, then sends it.
+func (c *protectedClient) PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+	if err := c.permit("PutParameter", aws.ToString(params.Name)); err != nil {
+		return nil, err
+	}
+	return c.SSMClient.PutParameter(ctx, params, optFns...)
+}
+
+// DeleteParameter checks and audits the delete, then sends it.
+func (c *protectedClient) DeleteParameter(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error) {
+	if err := c.permit("DeleteParameter", aws.ToString(params.Name)); err != nil {
+		return nil, err
+	}
+	return c.SSMClient.DeleteParameter(ctx, params, optFns...)
+}
+
+// AddTagsToResource checks and audits the tagging, then sends it.
+func (c *protectedClient) AddTagsToResource(ctx context.Context, params *ssm.AddTagsToResourceInput, optFns ...func(*ssm.Options)) (*ssm.AddTagsToResourceOutput, error) {
+	if err := c.permit("AddTagsToResource", aws.ToString(params.ResourceId)); err != nil {
+		return nil, err
+	}
+	return c.SSMClient.AddTagsToResource(ctx, params, optFns...)
+}
+
+// permit returns nil when op on name may be sent: name is not protected, or the justification was audited.
+func (c *protectedClient) permit(op, name string) error {
+	prefix := ProtectedPrefix(c.opts.Prefixes, name)
+	if prefix == "" {
+		return nil
+	}
+	if strings.TrimSpace(c.opts.Justification) == "" {
+		return validationErrorf("%w: %s is under %s; give the reason with -i-know-what-i-am-doing \"<justification>\"", ErrProtected, name, prefix)
+	}
+	entry := AuditEntry{
+		Time:          time.Now().UTC(),
+		User:          currentUser(),
+		Action:        c.opts.Action,
+		Operation:     op,
+		Name:          name,
+		Region:        clientRegion(c.SSMClient),
+		Prefix:        prefix,
+		Justification: c.opts.Justification,
+	}
+	if err := appendAudit(c.opts.AuditLog, entry); err != nil {
+		return fmt.Errorf("refusing to change %s: failed to write audit log %s: %w", name, c.opts.AuditLog, err)
+	}
+	return nil
+}
+
+// ProtectedPrefix returns the longest of prefixes that name is under, or "" when it is under none. A prefix
+// matches the path itself and everything below it, so "/prod/payments" and "/prod/payments/" are the same.
+func ProtectedPrefix(prefixes []string, name string) string {
+	match := ""
+	for _, p := range prefixes {
+		dir := strings.TrimSuffix(p, "/")
+		if dir == "" {
+			continue
+		}
+		if (name == dir || strings.HasPrefix(name, dir+"/")) && len(p) > len(match) {
+			match = p
+		}
+	}
+	return match
+}
+
+// appendAudit appends entry to path as one JSON line.
+func appendAudit(path string, entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// currentUser returns the local user name for the audit log.
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
@@ -0,0 +1,102 @@
+package features
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestProtectedPrefix(t *testing.T) {
+	prefixes := []string{"/prod/", "/prod/payments/", "/stage/db"}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"/prod/payments/API_KEY", "/prod/payments/"},
+		{"/prod/app/KEY", "/prod/"},
+		{"/stage/db", "/stage/db"},
+		{"/stage/db/PASSWORD", "/stage/db"},
+		{"/stage/dbx/PASSWORD", ""},
+		{"/dev/app/KEY", ""},
+	}
+	for _, tt := range tests {
+		if got := ProtectedPrefix(prefixes, tt.name); got != tt.want {
+			t.Errorf("ProtectedPrefix(%q) = %q; want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestProtectedClientRefusesWithoutJustification(t *testing.T) {
+	fake := newFakeSSM()
+	logPath := filepath.Join(t.TempDir(), "audit.log")
+	client := NewProtectedClient(fake, ProtectOptions{Prefixes: []string{"/prod/payments/"}, AuditLog: logPath})
+
+	err := PutParameter(client, "/prod/payments/API_KEY", "secret", SecureStringType, PutOptions{AssumeYes: true})
+	if !errors.Is(err, ErrProtected) || !errors.Is(err, ErrValidation) {
+		t.Errorf("protected put error = %v; want ErrProtected and ErrValidation", err)
+	}
+	if err := PutParameter(client, "/prod/app/KEY", "value", StringType, PutOptions{AssumeYes: true}); err != nil {
+		t.Errorf("unprotected put: %v", err)
+	}
+	if fake.puts != 1 {
+		t.Errorf("puts = %d; want 1", fake.puts)
+	}
+	if _, err := os.Stat(logPath); !os.IsNotExist(err) {
+		t.Errorf("audit log written without a protected write: %v", err)
+	}
+}
+
+func TestProtectedClientAuditsJustifiedWrites(t *testing.T) {
+	fake := newFakeSSM()
+	logPath := filepath.Join(t.TempDir(), "audit.log")
+	client := NewProtectedClient(fake, ProtectOptions{
+		Prefixes:      []string{"/prod/payments/"},
+		Justification: "rotate leaked key",
+		Action:        "put",
+		AuditLog:      logPath,
+	})
+
+	if err := PutParameter(client, "/prod/payments/API_KEY", "secret", SecureStringType, PutOptions{AssumeYes: true}); err != nil {
+		t.Fatalf("justified put: %v", err)
+	}
+	if fake.puts != 1 {
+		t.Errorf("puts = %d; want 1", fake.puts)
+	}
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("reading audit log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("audit log has %d lines; want 1:\n%s", len(lines), data)
+	}
+	var entry AuditEntry
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("parsing audit entry: %v", err)
+	}
+	if entry.Operation != "PutParameter" || entry.Name != "/prod/payments/API_KEY" || entry.Prefix != "/prod/payments/" || entry.Justification != "rotate leaked key" || entry.Action != "put" {
+		t.Errorf("audit entry = %+v", entry)
+	}
+}
+
+func TestProtectedClientRefusesWhenAuditFails(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/prod/payments/API_KEY", "old", types.ParameterTypeSecureString)
+	client := NewProtectedClient(fake, ProtectOptions{
+		Prefixes:      []string{"/prod/payments/"},
+		Justification: "cleanup",
+		AuditLog:      filepath.Join(t.TempDir(), "missing", "audit.log"),
+	})
+
+	if err := PutParameter(client, "/prod/payments/API_KEY", "new", SecureStringType, PutOptions{AssumeYes: true}); err == nil {
+		t.Error("put succeeded although the audit log could not be written")
+	}
+	if fake.puts != 0 {
+		t.Errorf("puts = %d; want 0", fake.puts)
+	}
+}
@@ -0,0 +1,143 @@
+package features
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// publicServicePath is where AWS publishes public parameters such as AMI IDs, regions and service endpoints.
+const publicServicePath = "/aws/service/"
+
+// PublicOptions configures get-public.
+type PublicOptions struct {
+	// Field is a dotted path into a JSON value, e.g. image_id or a.0.b; only it is printed.
+	Field string
+	Raw   bool // Print exactly the stored value instead of pretty-printing JSON.
+}
+
+// publicParameterName returns the full name of a public parameter. Names without a leading / are relative
+// to /aws/service/, so ecs/optimized-ami/amazon-linux-2/recommended is enough.
+func publicParameterName(name string) (string, error) {
+	switch {
+	case name == "":
+		return "", validationErrorf("get-public needs the -name of a public parameter, such as %secs/optimized-ami/amazon-linux-2/recommended", publicServicePath)
+	case !strings.HasPrefix(name, "/"):
+		return publicServicePath + strings.TrimPrefix(name, "aws/service/"), nil
+	case !strings.HasPrefix(name, "/aws/"):
+		return "", validationErrorf("%s is not a public parameter; those are under /aws/ (use get for your own parameters)", name)
+	}
+	return name, nil
+}
+
+// GetPublicParameter prints a public parameter AWS publishes, such as the recommended ECS-optimized AMI.
+// JSON values are pretty-printed unless opts.Raw is set, and opts.Field prints one field of them, as is for
+// strings, so IMAGE=$(salter-aws -action get-public -name ... -field image_id) works. When name is a path
+// rather than a parameter, the parameters under it are listed instead.
+func GetPublicParameter(client SSMClient, name string, opts PublicOptions) error {
+	name, err := publicParameterName(name)
+	if err != nil {
+		return err
+	}
+	result, err := client.GetParameter(context.TODO(), &ssm.GetParameterInput{Name: aws.String(name)})
+	if err != nil {
+		err = wrapClientError(client, "GetParameter", name, err)
+		if errors.Is(err, ErrNotFound) && opts.Field == "" {
+			if listed, listErr := listPublicPath(client, name); listErr != nil || listed {
+				return listErr
+			}
+		}
+		return err
+	}
+	param := result.Parameter
+	value := aws.ToString(param.Value)
+	Infof("%s (version %d, modified %s)\n", name, param.Version, aws.ToTime(param.LastModifiedDate).UTC().Format("2006-01-02"))
+
+	switch {
+	case opts.Field != "":
+		field, err := publicField(value, opts.Field)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		fmt.Println(field)
+	case opts.Raw:
+		fmt.Print(value) // Exactly the stored bytes, as with get -raw.
+	default:
+		var pretty bytes.Buffer
+		if json.Indent(&pretty, []byte(value), "", "  ") == nil {
+			value = pretty.String()
+		}
+		fmt.Println(value)
+	}
+	return nil
+}
+
+// listPublicPath prints the parameters under path and whether there were any.
+func listPublicPath(client SSMClient, path string) (bool, error) {
+	params, err := listParameters(client, path)
+	if err != nil || len(params) == 0 {
+		return false, err
+	}
+	Infof("%s is a path; its %d parameters:\n", path, len(params))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tVALUE")
+	for _, param := range params {
+		fmt.Fprintf(w, "%s\t%s\n", aws.ToString(param.Name), oneLine(aws.ToString(param.Value)))
+	}
+	return true, w.Flush()
+}
+
+// oneLine shortens value to its first line, for tables.
+func oneLine(value string) string {
+	if first, _, ok := strings.Cut(value, "\n"); ok {
+		return first + " …"
+	}
+	return value
+}
+
+// publicField returns the field at the dotted path of the JSON document value: a string as is, anything else
+// as indented JSON. Array elements are selected by index.
+func publicField(value, path string) (string, error) {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(value), &doc); err != nil {
+		return "", validationErrorf("-field needs a JSON value, but this one is not: %v", err)
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			next, ok := node[key]
+			if !ok {
+				keys := make([]string, 0, len(node))
+				for k := range node {
+					keys = append(keys, k)
+				}
+				sort.Strings(keys)
+				return "", validationErrorf("no field %q in %s (fields: %s)", key, path, strings.Join(keys, ", "))
+			}
+			doc = next
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return "", validationErrorf("%q in %s is not an index of a list of %d", key, path, len(node))
+			}
+			doc = node[i]
+		default:
+			return "", validationErrorf("%q in %s is past a value that has no fields", key, path)
+		}
+	}
+	if s, ok := doc.(string); ok {
+		return s, nil
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	return string(data), err
+}
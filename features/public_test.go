@@ -0,0 +1,64 @@
+package features
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestPublicParameterName(t *testing.T) {
+	for name, want := range map[string]string{
+		"ecs/optimized-ami/amazon-linux-2/recommended":               "/aws/service/ecs/optimized-ami/amazon-linux-2/recommended",
+		"aws/service/global-infrastructure/regions":                  "/aws/service/global-infrastructure/regions",
+		"/aws/service/ami-amazon-linux-latest/al2023-ami-kernel-6.1": "/aws/service/ami-amazon-linux-latest/al2023-ami-kernel-6.1",
+	} {
+		if got, err := publicParameterName(name); err != nil || got != want {
+			t.Errorf("publicParameterName(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	for _, name := range []string{"", "/prod/app/KEY"} {
+		if _, err := publicParameterName(name); !errors.Is(err, ErrValidation) {
+			t.Errorf("publicParameterName(%q) error = %v; want a validation error", name, err)
+		}
+	}
+}
+
+func TestPublicField(t *testing.T) {
+	value := `{"image_id": "ami-0123456789abcdef0", "schema_version": 1, "tags": ["ecs", "al2"], "agent": {"version": "1.82.0"}}`
+	for path, want := range map[string]string{
+		"image_id":       "ami-0123456789abcdef0",
+		"schema_version": "1",
+		"tags.1":         "al2",
+		"agent.version":  "1.82.0",
+		"tags":           "[\n  \"ecs\",\n  \"al2\"\n]",
+	} {
+		if got, err := publicField(value, path); err != nil || got != want {
+			t.Errorf("publicField(%q) = %q, %v; want %q", path, got, err, want)
+		}
+	}
+	for _, path := range []string{"image", "tags.2", "image_id.x"} {
+		if _, err := publicField(value, path); !errors.Is(err, ErrValidation) {
+			t.Errorf("publicField(%q) error = %v; want a validation error", path, err)
+		}
+	}
+	if _, err := publicField("ami-0123", "image_id"); !errors.Is(err, ErrValidation) {
+		t.Errorf("publicField of a plain value error = %v; want a validation error", err)
+	}
+}
+
+func TestGetPublicParameter(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/aws/service/ecs/optimized-ami/amazon-linux-2/recommended", `{"image_id":"ami-0123456789abcdef0"}`, types.ParameterTypeString)
+	fake.set("/aws/service/ami-amazon-linux-latest/al2023-ami-kernel-6.1-x86_64", "ami-0fedcba9876543210", types.ParameterTypeString)
+
+	if err := GetPublicParameter(fake, "ecs/optimized-ami/amazon-linux-2/recommended", PublicOptions{Field: "image_id"}); err != nil {
+		t.Errorf("GetPublicParameter with a field: %v", err)
+	}
+	if err := GetPublicParameter(fake, "ami-amazon-linux-latest", PublicOptions{}); err != nil {
+		t.Errorf("GetPublicParameter of a path: %v", err)
+	}
+	if err := GetPublicParameter(fake, "ecs/missing", PublicOptions{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetPublicParameter of a missing parameter error = %v; want ErrNotFound", err)
+	}
+}
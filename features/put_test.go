@@ -0,0 +1,283 @@
+package features
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestPutParameterOverwriteOptions(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/app/KEY", "old", types.ParameterTypeString)
+
+	err := PutParameter(fake, "/app/KEY", "new", StringType, PutOptions{NoOverwrite: true})
+	if !errors.Is(err, ErrOverwriteDeclined) {
+		t.Fatalf("NoOverwrite put error = %v; want ErrOverwriteDeclined", err)
+	}
+	if got := *fake.params["/app/KEY"].Value; got != "old" {
+		t.Errorf("value after NoOverwrite = %q; want %q", got, "old")
+	}
+
+	if err := PutParameter(fake, "/app/KEY", "new", StringType, PutOptions{AssumeYes: true}); err != nil {
+		t.Fatalf("AssumeYes put: %v", err)
+	}
+	if got := *fake.params["/app/KEY"].Value; got != "new" {
+		t.Errorf("value after AssumeYes = %q; want %q", got, "new")
+	}
+
+	// Creating a new parameter never needs confirmation.
+	if err := PutParameter(fake, "/app/OTHER", "v", StringType, PutOptions{}); err != nil {
+		t.Fatalf("put of new parameter: %v", err)
+	}
+}
+
+func TestPutParameterExpectVersion(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/app/KEY", "old", types.ParameterTypeString) // Version 1.
+
+	err := PutParameter(fake, "/app/KEY", "new", StringType, PutOptions{AssumeYes: true, ExpectVersion: 2})
+	if !errors.Is(err, ErrVersionMismatch) {
+		t.Fatalf("stale ExpectVersion error = %v; want ErrVersionMismatch", err)
+	}
+	if err := PutParameter(fake, "/app/KEY", "new", StringType, PutOptions{AssumeYes: true, ExpectVersion: 1}); err != nil {
+		t.Fatalf("matching ExpectVersion put: %v", err)
+	}
+	if got := fake.params["/app/KEY"].Version; got != 2 {
+		t.Errorf("version after put = %d; want 2", got)
+	}
+}
+
+func TestPutParameterPreflight(t *testing.T) {
+	big := strings.Repeat("a", standardTierLimit+1)
+	tests := []struct {
+		name, value string
+		paramType   ParameterType
+		tier        types.ParameterTier
+		ok          bool
+	}{
+		{"/app/KEY", "v", StringType, "", true},
+		{"KEY", "v", StringType, "", true},
+		{"/app/KEY", big, StringType, "", false},
+		{"/app/KEY", big, StringType, types.ParameterTierAdvanced, true},
+		{"/app/KEY", big, StringType, types.ParameterTierIntelligentTiering, true},
+		{"/app/KEY", strings.Repeat("a", advancedTierLimit+1), StringType, types.ParameterTierAdvanced, false},
+		{"/app/KEY", "", StringType, "", false},
+		{"/app/KEY", "a,b,c", StringListType, "", true},
+		{"/app/KEY", "a,,c", StringListType, "", false},
+		{"/app/KEY", "a,b,", StringListType, "", false},
+		{"/app/KEY", "a,,c", StringType, "", true},
+		{"app/KEY", "v", StringType, "", false},
+		{"/app/MY KEY", "v", StringType, "", false},
+		{"/app//KEY", "v", StringType, "", false},
+		{"/aws/app/KEY", "v", StringType, "", false},
+		{"/app/ssm-KEY", "v", StringType, "", true},
+		{"/" + strings.Repeat("a/", 15) + "KEY", "v", StringType, "", false},
+		{"/" + strings.Repeat("a", maxNameLength), "v", StringType, "", false},
+	}
+	for _, tt := range tests {
+		fake := newFakeSSM()
+		err := PutParameter(fake, tt.name, tt.value, tt.paramType, PutOptions{Tier: tt.tier})
+		if tt.ok && err != nil || !tt.ok && ExitCode(err) != ExitValidation {
+			t.Errorf("PutParameter(%.40s, %d bytes, %s, %q) = %v; want ok %t", tt.name, len(tt.value), tt.paramType, tt.tier, err, tt.ok)
+		}
+		if !tt.ok && fake.puts != 0 {
+			t.Errorf("PutParameter(%.40s) called SSM after failing the preflight", tt.name)
+		}
+	}
+}
+
+func TestPutFromTemplateValueFromParameter(t *testing.T) {
+	template := filepath.Join(t.TempDir(), "promote.json")
+	data := `{"containerDefinitions": [{"secrets": [
+  {"name": "DB_URL", "valueFrom": "/prod/app/DB_URL", "valueFromParameter": "/{{from}}/app/DB_URL"},
+  {"name": "HOST", "valueFrom": "/prod/app/HOST", "valueFromParameter": "/staging/app/HOST", "type": "String"}
+]}]}`
+	if err := os.WriteFile(template, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeSSM()
+	fake.set("/staging/app/DB_URL", "postgres://staging", types.ParameterTypeSecureString)
+	fake.set("/staging/app/HOST", "staging.internal", types.ParameterTypeSecureString)
+	tmplOpts := TemplateOptions{Vars: map[string]string{"from": "staging"}}
+
+	if err := PutParametersFromTemplate(fake, template, tmplOpts, PutOptions{AssumeYes: true}); err != nil {
+		t.Fatalf("put-from-template: %v", err)
+	}
+	tests := []struct {
+		name, value string
+		paramType   types.ParameterType
+	}{
+		{"/prod/app/DB_URL", "postgres://staging", types.ParameterTypeSecureString}, // Source type kept.
+		{"/prod/app/HOST", "staging.internal", types.ParameterTypeString},           // Template type wins.
+	}
+	for _, tt := range tests {
+		got := fake.params[tt.name]
+		if aws.ToString(got.Value) != tt.value || got.Type != tt.paramType {
+			t.Errorf("%s = %q (%s); want %q (%s)", tt.name, aws.ToString(got.Value), got.Type, tt.value, tt.paramType)
+		}
+	}
+
+	// A missing source fails before anything is written.
+	fake = newFakeSSM()
+	fake.set("/staging/app/HOST", "staging.internal", types.ParameterTypeString)
+	if err := PutParametersFromTemplate(fake, template, tmplOpts, PutOptions{AssumeYes: true}); ExitCode(err) != ExitNotFound {
+		t.Errorf("put-from-template with a missing source error = %v; want not found", err)
+	}
+	if fake.puts != 0 {
+		t.Errorf("put-from-template wrote %d parameters before failing", fake.puts)
+	}
+}
+
+func TestPutParameterDataType(t *testing.T) {
+	fake := newFakeSSM()
+	if err := PutParameter(fake, "/golden/ami", "ami-0123456789abcdef0", StringType, PutOptions{DataType: DataTypeEC2Image}); err != nil {
+		t.Fatalf("PutParameter with aws:ec2:image: %v", err)
+	}
+	if got := aws.ToString(fake.params["/golden/ami"].DataType); got != DataTypeEC2Image {
+		t.Errorf("DataType = %q; want %s", got, DataTypeEC2Image)
+	}
+	for _, tt := range []struct {
+		value     string
+		paramType ParameterType
+	}{
+		{"ami-0123", StringType},
+		{"i-0123456789abcdef0", StringType},
+		{"ami-12345678", SecureStringType},
+	} {
+		if err := PutParameter(fake, "/golden/other", tt.value, tt.paramType, PutOptions{DataType: DataTypeEC2Image}); ExitCode(err) != ExitValidation {
+			t.Errorf("PutParameter(%q, %s) with aws:ec2:image = %v; want a validation error", tt.value, tt.paramType, err)
+		}
+	}
+	if fake.puts != 1 {
+		t.Errorf("puts = %d; want invalid AMI IDs refused before the call", fake.puts)
+	}
+
+	template := filepath.Join(t.TempDir(), "amis.json")
+	data := `{"containerDefinitions": [{"secrets": [
+  {"name": "BASE_AMI", "valueFrom": "/golden/base", "type": "string", "dataType": "aws:ec2:image", "value": "ami-12345678"},
+  {"name": "NOTE", "valueFrom": "/golden/note", "value": "rebuilt weekly"}
+]}]}`
+	if err := os.WriteFile(template, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := PutParametersFromTemplate(fake, template, TemplateOptions{}, PutOptions{AssumeYes: true}); err != nil {
+		t.Fatalf("put-from-template: %v", err)
+	}
+	if got := aws.ToString(fake.params["/golden/base"].DataType); got != DataTypeEC2Image {
+		t.Errorf("/golden/base DataType = %q; want %s", got, DataTypeEC2Image)
+	}
+	if got := fake.params["/golden/note"].DataType; got != nil {
+		t.Errorf("/golden/note DataType = %q; want none", aws.ToString(got))
+	}
+}
+
+func TestPutFromTemplateMetadata(t *testing.T) {
+	dir := t.TempDir()
+	template := filepath.Join(dir, "task.json")
+	data := `{"containerDefinitions": [{"secrets": [
+  {"name": "SIGNING_KEY", "valueFrom": "/app/SIGNING_KEY", "type": "securestring", "value": "k3y", "kmsKeyId": "alias/app",
+   "tier": "Advanced", "description": "Signs cookies", "tags": {"team": "payments", "owner": "app"}},
+  {"name": "HOST", "valueFrom": "/app/HOST", "type": "string", "value": "db.internal"}
+]}]}`
+	if err := os.WriteFile(template, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeSSM()
+	fake.set("/app/SIGNING_KEY", "old", types.ParameterTypeSecureString)
+	fake.tags["/app/SIGNING_KEY"] = []types.Tag{{Key: aws.String("owner"), Value: aws.String("ops")}, {Key: aws.String("env"), Value: aws.String("prod")}}
+
+	if err := PutParametersFromTemplate(fake, template, TemplateOptions{}, PutOptions{AssumeYes: true}); err != nil {
+		t.Fatalf("put-from-template: %v", err)
+	}
+	meta := fake.meta["/app/SIGNING_KEY"]
+	if meta.Tier != types.ParameterTierAdvanced || aws.ToString(meta.Description) != "Signs cookies" || aws.ToString(meta.KeyId) != "alias/app" {
+		t.Errorf("metadata = tier %s, description %q, key %q", meta.Tier, aws.ToString(meta.Description), aws.ToString(meta.KeyId))
+	}
+
+	// Exporting as taskdef gives the metadata back, with the tags the parameter already had.
+	if err := ExportParameters(fake, "/app/", ExportOptions{Formats: []string{FormatTaskDef}, Output: filepath.Join(dir, "exported"), WithTags: true}); err != nil {
+		t.Fatalf("ExportParameters: %v", err)
+	}
+	var taskDef TaskDefinition
+	exported, err := os.ReadFile(filepath.Join(dir, "exported.json"))
+	if err == nil {
+		err = json.Unmarshal(exported, &taskDef)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ExtendedSecret{
+		{Name: "HOST", ValueFrom: "/app/HOST", Type: StringType, Value: "db.internal"},
+		{Name: "SIGNING_KEY", ValueFrom: "/app/SIGNING_KEY", Type: SecureStringType, Value: "k3y", KMSKeyID: "alias/app",
+			Tier: "advanced", Description: "Signs cookies", Tags: map[string]string{"team": "payments", "owner": "app", "env": "prod"}},
+	}
+	if got := taskDef.ContainerDefinitions[0].Secrets; !reflect.DeepEqual(got, want) {
+		t.Errorf("exported secrets = %+v; want %+v", got, want)
+	}
+
+	for _, secret := range []string{
+		`"tags": {"aws:team": "x"}`,
+		`"tier": "premium"`,
+		`"description": "` + strings.Repeat("x", 1025) + `"`,
+	} {
+		data := `{"containerDefinitions": [{"secrets": [{"name": "HOST", "valueFrom": "/app/OTHER", "value": "x", ` + secret + `}]}]}`
+		if err := os.WriteFile(template, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		puts := fake.puts
+		if err := PutParametersFromTemplate(fake, template, TemplateOptions{}, PutOptions{AssumeYes: true}); ExitCode(err) != ExitValidation {
+			t.Errorf("put-from-template with %s = %v; want a validation error", secret, err)
+		}
+		if fake.puts != puts {
+			t.Errorf("put-from-template with %s wrote before failing", secret)
+		}
+	}
+}
+
+func TestPutFromTemplateTarget(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, secret string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(`{"containerDefinitions": [{"secrets": [`+secret+`]}]}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	missing := write("missing.json", `{"name": "DB_HOST", "value": "db.internal"}`)
+	invalid := write("invalid.json", `{"name": "DB_HOST", "valueFrom": "arn:aws:secretsmanager:ap-southeast-3:1234:secret:db", "value": "db.internal"}`)
+
+	// Without -fallback-to-prefix a secret without valueFrom is an error, not a put under another prefix.
+	fake := newFakeSSM()
+	if err := PutParametersFromTemplate(fake, missing, TemplateOptions{}, PutOptions{AssumeYes: true}); ExitCode(err) != ExitValidation {
+		t.Errorf("missing valueFrom error = %v; want a validation error", err)
+	}
+	if fake.puts != 0 {
+		t.Errorf("missing valueFrom wrote %d parameters", fake.puts)
+	}
+
+	fake = newFakeSSM()
+	if err := PutParametersFromTemplate(fake, missing, TemplateOptions{FallbackPrefix: "/staging/app/"}, PutOptions{AssumeYes: true}); err != nil {
+		t.Fatalf("put with FallbackPrefix: %v", err)
+	}
+	if got := aws.ToString(fake.params["/staging/app/DB_HOST"].Value); got != "db.internal" || fake.puts != 1 {
+		t.Errorf("/staging/app/DB_HOST = %q after %d puts; want %q after 1", got, fake.puts, "db.internal")
+	}
+
+	// An invalid valueFrom never falls back to the prefix.
+	fake = newFakeSSM()
+	err := PutParametersFromTemplate(fake, invalid, TemplateOptions{FallbackPrefix: "/staging/app/"}, PutOptions{AssumeYes: true})
+	if ExitCode(err) != ExitValidation || !strings.Contains(err.Error(), "invalid valueFrom") {
+		t.Errorf("invalid valueFrom error = %v; want an invalid valueFrom validation error", err)
+	}
+	if fake.puts != 0 {
+		t.Errorf("invalid valueFrom wrote %d parameters", fake.puts)
+	}
+}
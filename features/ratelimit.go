@@ -0,0 +1,112 @@
+package features
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// RateLimiter spaces calls evenly so that together they stay under a number of calls per second.
+// One RateLimiter is shared by every client and worker of a run, so the budget holds however many
+// regions, accounts or goroutines are making calls.
+type RateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time // Earliest start of the next call.
+}
+
+// NewRateLimiter returns a limiter allowing tps calls per second. tps must be positive.
+func NewRateLimiter(tps float64) *RateLimiter {
+	return &RateLimiter{interval: time.Duration(float64(time.Second) / tps)}
+}
+
+// Wait blocks until the caller may make its call, or returns ctx's error if ctx ends first.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	at := l.next
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// rateLimitedClient waits for its limiter before every call to the wrapped client.
+type rateLimitedClient struct {
+	decorator
+	limiter *RateLimiter
+}
+
+// NewRateLimitedClient wraps client so that every API call first waits for limiter.
+func NewRateLimitedClient(client SSMClient, limiter *RateLimiter) SSMClient {
+	return &rateLimitedClient{decorator: decorator{client}, limiter: limiter}
+}
+
+func (c *rateLimitedClient) dryRun() bool {
+	return isDryRun(c.SSMClient)
+}
+
+func (c *rateLimitedClient) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.SSMClient.GetParameter(ctx, params, optFns...)
+}
+
+func (c *rateLimitedClient) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.SSMClient.GetParametersByPath(ctx, params, optFns...)
+}
+
+func (c *rateLimitedClient) DescribeParameters(ctx context.Context, params *ssm.DescribeParametersInput, optFns ...func(*ssm.Options)) (*ssm.DescribeParametersOutput, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.SSMClient.DescribeParameters(ctx, params, optFns...)
+}
+
+func (c *rateLimitedClient) PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.SSMClient.PutParameter(ctx, params, optFns...)
+}
+
+func (c *rateLimitedClient) DeleteParameter(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.SSMClient.DeleteParameter(ctx, params, optFns...)
+}
+
+func (c *rateLimitedClient) ListTagsForResource(ctx context.Context, params *ssm.ListTagsForResourceInput, optFns ...func(*ssm.Options)) (*ssm.ListTagsForResourceOutput, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.SSMClient.ListTagsForResource(ctx, params, optFns...)
+}
+
+func (c *rateLimitedClient) AddTagsToResource(ctx context.Context, params *ssm.AddTagsToResourceInput, optFns ...func(*ssm.Options)) (*ssm.AddTagsToResourceOutput, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.SSMClient.AddTagsToResource(ctx, params, optFns...)
+}
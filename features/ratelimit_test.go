@@ -0,0 +1,50 @@
+package features
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestRateLimitedClientSharesBudget(t *testing.T) {
+	limiter := NewRateLimiter(100) // One call every 10ms.
+	fake := newFakeSSM()
+	fake.set("/app/A", "a", types.ParameterTypeString)
+	// Two clients, as for two regions, drawing from the same budget.
+	clients := []SSMClient{NewRateLimitedClient(fake, limiter), NewRateLimitedClient(NewDryRunClient(fake), limiter)}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(client SSMClient) {
+			defer wg.Done()
+			client.GetParameter(context.Background(), &ssm.GetParameterInput{Name: aws.String("/app/A")})
+		}(clients[i%2])
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 85*time.Millisecond {
+		t.Errorf("10 calls at 100 tps took %s; want about 90ms (9 intervals)", elapsed)
+	}
+	if isDryRun(clients[0]) || !isDryRun(clients[1]) {
+		t.Errorf("isDryRun through the limiter = %t, %t; want false, true", isDryRun(clients[0]), isDryRun(clients[1]))
+	}
+}
+
+func TestRateLimiterWaitCancelled(t *testing.T) {
+	limiter := NewRateLimiter(0.1) // One call every 10s.
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatalf("first Wait: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("second Wait err = %v; want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,38 @@
+package features
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// ErrReadOnly is returned for calls that would change Parameter Store in read-only mode.
+var ErrReadOnly = errors.New("read-only mode")
+
+// readOnlyClient passes read calls through to the wrapped client and refuses every mutating call.
+type readOnlyClient struct {
+	decorator
+}
+
+// NewReadOnlyClient wraps client so that mutating API calls fail with ErrReadOnly (a validation error) instead
+// of reaching AWS. Read-only mode refuses mutating actions up front; the client is the safety net behind that.
+func NewReadOnlyClient(client SSMClient) SSMClient {
+	return &readOnlyClient{decorator: decorator{client}}
+}
+
+// PutParameter refuses the put.
+func (c *readOnlyClient) PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+	return nil, validationErrorf("%w: refusing to put %s", ErrReadOnly, aws.ToString(params.Name))
+}
+
+// DeleteParameter refuses the delete.
+func (c *readOnlyClient) DeleteParameter(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error) {
+	return nil, validationErrorf("%w: refusing to delete %s", ErrReadOnly, aws.ToString(params.Name))
+}
+
+// AddTagsToResource refuses the tagging.
+func (c *readOnlyClient) AddTagsToResource(ctx context.Context, params *ssm.AddTagsToResourceInput, optFns ...func(*ssm.Options)) (*ssm.AddTagsToResourceOutput, error) {
+	return nil, validationErrorf("%w: refusing to tag %s", ErrReadOnly, aws.ToString(params.ResourceId))
+}
@@ -0,0 +1,46 @@
+package features
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestReadOnlyClientRefusesWrites(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/app/EXISTING", "old", types.ParameterTypeString)
+	client := NewReadOnlyClient(fake)
+
+	err := PutParameter(client, "/app/EXISTING", "new", StringType, PutOptions{AssumeYes: true})
+	if !errors.Is(err, ErrReadOnly) || !errors.Is(err, ErrValidation) {
+		t.Errorf("PutParameter error = %v; want ErrReadOnly and ErrValidation", err)
+	}
+	_, err = client.DeleteParameter(context.TODO(), &ssm.DeleteParameterInput{Name: aws.String("/app/EXISTING")})
+	if !errors.Is(err, ErrReadOnly) {
+		t.Errorf("DeleteParameter error = %v; want ErrReadOnly", err)
+	}
+
+	if fake.puts != 0 {
+		t.Errorf("read-only client performed %d puts; want 0", fake.puts)
+	}
+	if got := *fake.params["/app/EXISTING"].Value; got != "old" {
+		t.Errorf("existing value = %q; want %q", got, "old")
+	}
+}
+
+func TestReadOnlyClientReads(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/app/KEY", "value", types.ParameterTypeString)
+
+	got, _, err := GetParameter(NewReadOnlyClient(fake), "/app/KEY")
+	if err != nil {
+		t.Fatalf("GetParameter: %v", err)
+	}
+	if got != "value" {
+		t.Errorf("GetParameter = %q; want %q", got, "value")
+	}
+}
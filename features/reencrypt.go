@@ -0,0 +1,88 @@
+package features
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// ReencryptParameters puts every SecureString under prefix again, with the same value, encrypted with the KMS
+// key keyID, for a key rotation or to move parameters off aws/ssm. Parameters of other types and SecureStrings
+// already recorded with keyID are skipped. Each put is a new version; description, tier and policies are kept.
+// A failed put is reported and the others continue; failures are returned as a PartialFailureError.
+func ReencryptParameters(client SSMClient, prefix, keyID string) error {
+	if keyID == "" {
+		return validationErrorf("a KMS key is required (-kms-key-id)")
+	}
+	prefix = strings.TrimSuffix(prefix, "/") + "/"
+	params, err := listParameters(client, prefix)
+	if err != nil {
+		return err
+	}
+	metadata, err := describeParameters(client, types.ParameterStringFilter{
+		Key:    aws.String("Path"),
+		Option: aws.String("Recursive"),
+		Values: []string{strings.TrimSuffix(prefix, "/")},
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(params, func(i, j int) bool { return aws.ToString(params[i].Name) < aws.ToString(params[j].Name) })
+
+	var secure []types.Parameter
+	skipped := 0
+	for _, p := range params {
+		name := aws.ToString(p.Name)
+		switch {
+		case p.Type != types.ParameterTypeSecureString:
+			Infof("%s %s: %s\n", yellow("Skipped"), name, p.Type)
+			skipped++
+		case aws.ToString(metadata[name].KeyId) == keyID:
+			Infof("%s %s: already encrypted with %s\n", yellow("Skipped"), name, keyID)
+			skipped++
+		default:
+			secure = append(secure, p)
+		}
+	}
+
+	var failures []error
+	for i, p := range secure {
+		name := aws.ToString(p.Name)
+		input := &ssm.PutParameterInput{
+			Name:      p.Name,
+			Value:     p.Value,
+			Type:      types.ParameterTypeSecureString,
+			KeyId:     aws.String(keyID),
+			Overwrite: aws.Bool(true), // Description, allowed pattern and policies are kept by an overwrite.
+		}
+		if metadata[name].Tier == types.ParameterTierAdvanced {
+			input.Tier = types.ParameterTierAdvanced // An advanced parameter cannot go back to standard.
+		}
+		progress := fmt.Sprintf("[%d/%d]", i+1, len(secure))
+		if _, err := client.PutParameter(context.TODO(), input); err != nil {
+			err = wrapClientError(client, "PutParameter", name, err)
+			Infof("%s %s %s: %s\n", progress, red("Failed to re-encrypt"), name, DescribeError(err))
+			failures = append(failures, err)
+			continue
+		}
+		if isDryRun(client) {
+			Infof("%s Would re-encrypt %s with %s\n", progress, name, keyID)
+		} else {
+			Infof("%s %s %s with %s\n", progress, green("Re-encrypted"), name, keyID)
+		}
+	}
+	summary := "Re-encrypted"
+	if isDryRun(client) {
+		summary = "Would re-encrypt"
+	}
+	Infof("%s %d of %d SecureStrings under %s, skipped %d parameters\n", summary, len(secure)-len(failures), len(secure), prefix, skipped)
+	if len(failures) > 0 {
+		return &PartialFailureError{Failed: failures, Total: len(secure)}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package features
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestReencryptParameters(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/prod/app/DB_PASSWORD", "pw", types.ParameterTypeSecureString)
+	fake.set("/prod/app/PORT", "8080", types.ParameterTypeString)
+	if _, err := fake.PutParameter(context.TODO(), &ssm.PutParameterInput{
+		Name:  aws.String("/prod/app/TOKEN"),
+		Value: aws.String("t"),
+		Type:  types.ParameterTypeSecureString,
+		KeyId: aws.String("alias/new-key"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	fake.set("/staging/app/DB_PASSWORD", "other", types.ParameterTypeSecureString)
+	puts := fake.puts
+
+	if err := ReencryptParameters(fake, "/prod", "alias/new-key"); err != nil {
+		t.Fatalf("ReencryptParameters: %v", err)
+	}
+	if fake.puts-puts != 1 {
+		t.Errorf("%d puts; want only DB_PASSWORD re-encrypted", fake.puts-puts)
+	}
+	if got := fake.params["/prod/app/DB_PASSWORD"]; aws.ToString(got.Value) != "pw" || got.Type != types.ParameterTypeSecureString || got.Version != 2 {
+		t.Errorf("DB_PASSWORD = %+v; want the same value as a new SecureString version", got)
+	}
+	if fake.keyIDs["/prod/app/DB_PASSWORD"] != "alias/new-key" {
+		t.Errorf("KMS key = %q; want alias/new-key", fake.keyIDs["/prod/app/DB_PASSWORD"])
+	}
+	if _, ok := fake.keyIDs["/staging/app/DB_PASSWORD"]; ok {
+		t.Error("a parameter outside the prefix was re-encrypted")
+	}
+	if err := ReencryptParameters(fake, "/prod/", ""); !errors.Is(err, ErrValidation) {
+		t.Errorf("ReencryptParameters without a key: %v; want a validation error", err)
+	}
+}
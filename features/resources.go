@@ -0,0 +1,196 @@
+package features
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	ectypes "github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+)
+
+// resourceRefPattern matches references to AWS resources in template values, such as {{rds-endpoint my-db}},
+// {{rds-reader-endpoint my-cluster}}, {{rds-port my-db}} and the same three for elasticache. The identifier
+// is literal.
+var resourceRefPattern = regexp.MustCompile(`\{\{\s*(rds|elasticache)-(endpoint|reader-endpoint|port)\s+([^{}\s]+)\s*\}\}`)
+
+// RDSAPI is the subset of the RDS API used to resolve {{rds-...}} references. *rds.Client satisfies it.
+type RDSAPI interface {
+	DescribeDBInstances(ctx context.Context, params *rds.DescribeDBInstancesInput, optFns ...func(*rds.Options)) (*rds.DescribeDBInstancesOutput, error)
+	DescribeDBClusters(ctx context.Context, params *rds.DescribeDBClustersInput, optFns ...func(*rds.Options)) (*rds.DescribeDBClustersOutput, error)
+}
+
+// ElastiCacheAPI is the subset of the ElastiCache API used to resolve {{elasticache-...}} references.
+// *elasticache.Client satisfies it.
+type ElastiCacheAPI interface {
+	DescribeReplicationGroups(ctx context.Context, params *elasticache.DescribeReplicationGroupsInput, optFns ...func(*elasticache.Options)) (*elasticache.DescribeReplicationGroupsOutput, error)
+	DescribeCacheClusters(ctx context.Context, params *elasticache.DescribeCacheClustersInput, optFns ...func(*elasticache.Options)) (*elasticache.DescribeCacheClustersOutput, error)
+}
+
+// ResourceResolver resolves the resource references of template values when they are applied, so endpoints
+// follow infrastructure changes instead of being copied into templates. Each resource is described once per
+// run. It is safe for concurrent use.
+type ResourceResolver struct {
+	rds         RDSAPI
+	elastiCache ElastiCacheAPI
+	mu          sync.Mutex
+	resolved    map[string]resourceEndpoints // By service and identifier.
+}
+
+// resourceEndpoints are the addresses of one database or cache.
+type resourceEndpoints struct {
+	address string
+	reader  string // Empty when the resource has no reader endpoint.
+	port    int32
+}
+
+// NewResourceResolver returns a resolver describing resources with the given clients.
+func NewResourceResolver(rdsClient RDSAPI, elastiCacheClient ElastiCacheAPI) *ResourceResolver {
+	return &ResourceResolver{rds: rdsClient, elastiCache: elastiCacheClient, resolved: make(map[string]resourceEndpoints)}
+}
+
+// expandResourceRefs replaces every resource reference in value with what it names. It fails for the first
+// reference that cannot be resolved, and when value has references but resolver is nil.
+func expandResourceRefs(resolver *ResourceResolver, value string) (string, error) {
+	var expandErr error
+	expanded := resourceRefPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		if expandErr != nil {
+			return ref
+		}
+		m := resourceRefPattern.FindStringSubmatch(ref)
+		if resolver == nil {
+			expandErr = validationErrorf("%s cannot be resolved by this action", ref)
+			return ref
+		}
+		endpoints, err := resolver.lookup(m[1], m[3])
+		if err != nil {
+			expandErr = fmt.Errorf("%s: %w", ref, err)
+			return ref
+		}
+		switch m[2] {
+		case "port":
+			return strconv.Itoa(int(endpoints.port))
+		case "reader-endpoint":
+			if endpoints.reader == "" {
+				expandErr = validationErrorf("%s: %s has no reader endpoint", ref, m[3])
+			}
+			return endpoints.reader
+		}
+		return endpoints.address
+	})
+	return expanded, expandErr
+}
+
+// lookup returns the endpoints of the resource id of service, describing it on first use.
+func (r *ResourceResolver) lookup(service, id string) (resourceEndpoints, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := service + " " + id
+	if endpoints, ok := r.resolved[key]; ok {
+		return endpoints, nil
+	}
+	var endpoints resourceEndpoints
+	var err error
+	if service == "rds" {
+		endpoints, err = r.describeDatabase(id)
+	} else {
+		endpoints, err = r.describeCache(id)
+	}
+	if err != nil {
+		return resourceEndpoints{}, err
+	}
+	r.resolved[key] = endpoints
+	return endpoints, nil
+}
+
+// describeDatabase returns the endpoints of the RDS DB instance id, or of the Aurora cluster id when there
+// is no such instance.
+func (r *ResourceResolver) describeDatabase(id string) (resourceEndpoints, error) {
+	ctx := context.TODO()
+	instances, err := r.rds.DescribeDBInstances(ctx, &rds.DescribeDBInstancesInput{DBInstanceIdentifier: aws.String(id)})
+	var noInstance *rdstypes.DBInstanceNotFoundFault
+	switch {
+	case errors.As(err, &noInstance):
+	case err != nil:
+		return resourceEndpoints{}, resourceError("rds:DescribeDBInstances", id, err)
+	case len(instances.DBInstances) > 0 && instances.DBInstances[0].Endpoint != nil:
+		endpoint := instances.DBInstances[0].Endpoint
+		return resourceEndpoints{address: aws.ToString(endpoint.Address), port: aws.ToInt32(endpoint.Port)}, nil
+	case len(instances.DBInstances) > 0:
+		return resourceEndpoints{}, validationErrorf("RDS DB instance %s has no endpoint yet (status %s)", id, aws.ToString(instances.DBInstances[0].DBInstanceStatus))
+	}
+
+	clusters, err := r.rds.DescribeDBClusters(ctx, &rds.DescribeDBClustersInput{DBClusterIdentifier: aws.String(id)})
+	var noCluster *rdstypes.DBClusterNotFoundFault
+	switch {
+	case errors.As(err, &noCluster) || err == nil && len(clusters.DBClusters) == 0:
+		return resourceEndpoints{}, fmt.Errorf("%w: no RDS DB instance or cluster %s", ErrNotFound, id)
+	case err != nil:
+		return resourceEndpoints{}, resourceError("rds:DescribeDBClusters", id, err)
+	}
+	cluster := clusters.DBClusters[0]
+	return resourceEndpoints{address: aws.ToString(cluster.Endpoint), reader: aws.ToString(cluster.ReaderEndpoint), port: aws.ToInt32(cluster.Port)}, nil
+}
+
+// describeCache returns the endpoints of the ElastiCache replication group id, or of the cache cluster id
+// when there is no such group. Cluster-mode groups and Memcached clusters resolve to their configuration
+// endpoint, others to the primary (or only) node.
+func (r *ResourceResolver) describeCache(id string) (resourceEndpoints, error) {
+	ctx := context.TODO()
+	groups, err := r.elastiCache.DescribeReplicationGroups(ctx, &elasticache.DescribeReplicationGroupsInput{ReplicationGroupId: aws.String(id)})
+	var noGroup *ectypes.ReplicationGroupNotFoundFault
+	switch {
+	case errors.As(err, &noGroup):
+	case err != nil:
+		return resourceEndpoints{}, resourceError("elasticache:DescribeReplicationGroups", id, err)
+	case len(groups.ReplicationGroups) > 0:
+		group := groups.ReplicationGroups[0]
+		if group.ConfigurationEndpoint != nil {
+			return cacheEndpoints(group.ConfigurationEndpoint, nil), nil
+		}
+		if len(group.NodeGroups) > 0 && group.NodeGroups[0].PrimaryEndpoint != nil {
+			return cacheEndpoints(group.NodeGroups[0].PrimaryEndpoint, group.NodeGroups[0].ReaderEndpoint), nil
+		}
+		return resourceEndpoints{}, validationErrorf("ElastiCache replication group %s has no endpoint yet (status %s)", id, aws.ToString(group.Status))
+	}
+
+	clusters, err := r.elastiCache.DescribeCacheClusters(ctx, &elasticache.DescribeCacheClustersInput{CacheClusterId: aws.String(id), ShowCacheNodeInfo: aws.Bool(true)})
+	var noCluster *ectypes.CacheClusterNotFoundFault
+	switch {
+	case errors.As(err, &noCluster) || err == nil && len(clusters.CacheClusters) == 0:
+		return resourceEndpoints{}, fmt.Errorf("%w: no ElastiCache replication group or cache cluster %s", ErrNotFound, id)
+	case err != nil:
+		return resourceEndpoints{}, resourceError("elasticache:DescribeCacheClusters", id, err)
+	}
+	cluster := clusters.CacheClusters[0]
+	if cluster.ConfigurationEndpoint != nil {
+		return cacheEndpoints(cluster.ConfigurationEndpoint, nil), nil
+	}
+	if len(cluster.CacheNodes) > 0 && cluster.CacheNodes[0].Endpoint != nil {
+		return cacheEndpoints(cluster.CacheNodes[0].Endpoint, nil), nil
+	}
+	return resourceEndpoints{}, validationErrorf("ElastiCache cache cluster %s has no endpoint yet (status %s)", id, aws.ToString(cluster.CacheClusterStatus))
+}
+
+// cacheEndpoints returns the endpoints of a cache with the given primary and, if any, reader endpoint.
+func cacheEndpoints(primary, reader *ectypes.Endpoint) resourceEndpoints {
+	endpoints := resourceEndpoints{address: aws.ToString(primary.Address), port: aws.ToInt32(primary.Port)}
+	if reader != nil {
+		endpoints.reader = aws.ToString(reader.Address)
+	}
+	return endpoints
+}
+
+// resourceError wraps the error of a describe call, keeping whether it was denied or throttled.
+func resourceError(action, id string, err error) error {
+	if kind := classifyAWSError(err); kind != nil {
+		return fmt.Errorf("%w: %s of %s: %v", kind, action, id, err)
+	}
+	return fmt.Errorf("%s of %s: %w", action, id, err)
+}
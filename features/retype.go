@@ -0,0 +1,84 @@
+package features
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// recreateParameter changes the type of an existing parameter, which SSM does not do in place: it deletes the
+// parameter and creates it again from input, keeping the description, tier, allowed pattern, policies and tags
+// input does not set. Everything is read before the delete, and when the new parameter cannot be created the
+// old one is put back, so a failure leaves the parameter as it was (apart from its version history).
+func recreateParameter(client SSMClient, input *ssm.PutParameterInput) error {
+	name := aws.ToString(input.Name)
+	current, err := client.GetParameter(context.TODO(), &ssm.GetParameterInput{Name: input.Name, WithDecryption: aws.Bool(true)})
+	if err != nil {
+		return wrapClientError(client, "GetParameter", name, err)
+	}
+	metadata, err := describeParameters(client, types.ParameterStringFilter{
+		Key:    aws.String("Name"),
+		Option: aws.String("Equals"),
+		Values: []string{name},
+	})
+	if err != nil {
+		return err
+	}
+	tags, err := client.ListTagsForResource(context.TODO(), &ssm.ListTagsForResourceInput{
+		ResourceType: types.ResourceTypeForTaggingParameter,
+		ResourceId:   input.Name,
+	})
+	if err != nil {
+		return wrapClientError(client, "ListTagsForResource", name, err)
+	}
+	old := &moveItem{from: name, to: name, param: *current.Parameter, meta: metadata[name], tags: tags.TagList}
+
+	recreate := copyInput(old)
+	recreate.Value = input.Value
+	recreate.Type = input.Type
+	recreate.KeyId = input.KeyId
+	if input.Type != types.ParameterTypeString {
+		recreate.DataType = nil // Only String parameters have data types such as aws:ec2:image.
+	}
+	if input.Description != nil {
+		recreate.Description = input.Description
+	}
+	if input.Tier != "" {
+		recreate.Tier = input.Tier
+	}
+	if input.AllowedPattern != nil {
+		recreate.AllowedPattern = input.AllowedPattern
+	}
+
+	if _, err := client.DeleteParameter(context.TODO(), &ssm.DeleteParameterInput{Name: input.Name}); err != nil {
+		return wrapClientError(client, "DeleteParameter", name, err)
+	}
+	if _, err := client.PutParameter(context.TODO(), recreate); err != nil {
+		putErr := wrapClientError(client, "PutParameter", name, err)
+		if _, err := client.PutParameter(context.TODO(), copyInput(old)); err != nil {
+			return fmt.Errorf("%w; restoring the old %s parameter also failed, it is deleted: %v", putErr, old.param.Type, err)
+		}
+		return fmt.Errorf("%w (the old %s parameter was restored)", putErr, old.param.Type)
+	}
+	return nil
+}
+
+// currentKeyID returns the KMS key of the existing SecureString name, or "" for the default aws/ssm key.
+func currentKeyID(client SSMClient, name string) (string, error) {
+	metadata, err := describeParameters(client, types.ParameterStringFilter{
+		Key:    aws.String("Name"),
+		Option: aws.String("Equals"),
+		Values: []string{name},
+	})
+	if err != nil {
+		return "", err
+	}
+	keyID := aws.ToString(metadata[name].KeyId)
+	if keyID == defaultSSMKey {
+		return "", nil
+	}
+	return keyID, nil
+}
@@ -0,0 +1,99 @@
+package features
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// rejectTypeClient fails every put of type, as SSM does for a value the allowed pattern rejects.
+type rejectTypeClient struct {
+	*fakeSSM
+	reject types.ParameterType
+}
+
+func (c *rejectTypeClient) PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+	if params.Type == c.reject {
+		return nil, errors.New("ParameterPatternMismatchException")
+	}
+	return c.fakeSSM.PutParameter(ctx, params, optFns...)
+}
+
+func TestPutParameterTypeChange(t *testing.T) {
+	fake := newFakeSSM()
+	if _, err := fake.PutParameter(context.TODO(), &ssm.PutParameterInput{
+		Name:        aws.String("/app/TOKEN"),
+		Value:       aws.String("plain"),
+		Type:        types.ParameterTypeString,
+		Description: aws.String("API token"),
+		Tags:        []types.Tag{{Key: aws.String("team"), Value: aws.String("core")}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := PutParameter(fake, "/app/TOKEN", "secret", SecureStringType, PutOptions{AssumeYes: true})
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("PutParameter changing the type: %v; want a validation error", err)
+	}
+	if got := fake.params["/app/TOKEN"]; got.Type != types.ParameterTypeString || aws.ToString(got.Value) != "plain" {
+		t.Fatalf("parameter changed despite the error: %+v", got)
+	}
+
+	failing := &rejectTypeClient{fakeSSM: fake, reject: types.ParameterTypeSecureString}
+	err = PutParameter(failing, "/app/TOKEN", "secret", SecureStringType, PutOptions{AssumeYes: true, RecreateOnTypeChange: true})
+	if err == nil {
+		t.Fatal("PutParameter succeeded although the new parameter was rejected")
+	}
+	if got := fake.params["/app/TOKEN"]; got.Type != types.ParameterTypeString || aws.ToString(got.Value) != "plain" || len(fake.tags["/app/TOKEN"]) != 1 {
+		t.Fatalf("old parameter not restored after the failed recreate: %+v", got)
+	}
+
+	if err := PutParameter(fake, "/app/TOKEN", "secret", SecureStringType, PutOptions{AssumeYes: true, RecreateOnTypeChange: true}); err != nil {
+		t.Fatalf("PutParameter with RecreateOnTypeChange: %v", err)
+	}
+	got := fake.params["/app/TOKEN"]
+	if got.Type != types.ParameterTypeSecureString || aws.ToString(got.Value) != "secret" {
+		t.Errorf("recreated parameter = %+v; want the SecureString", got)
+	}
+	if aws.ToString(fake.meta["/app/TOKEN"].Description) != "API token" || len(fake.tags["/app/TOKEN"]) != 1 {
+		t.Errorf("description or tags lost: %+v, %v", fake.meta["/app/TOKEN"], fake.tags["/app/TOKEN"])
+	}
+}
+
+func TestPutParameterKeepType(t *testing.T) {
+	fake := newFakeSSM()
+	if _, err := fake.PutParameter(context.TODO(), &ssm.PutParameterInput{
+		Name:  aws.String("/app/DB_PASSWORD"),
+		Value: aws.String("old"),
+		Type:  types.ParameterTypeSecureString,
+		KeyId: aws.String("alias/app"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	opts := PutOptions{AssumeYes: true, KeepType: true}
+	if err := PutParameter(fake, "/app/DB_PASSWORD", "new", StringType, opts); err != nil {
+		t.Fatalf("PutParameter with KeepType: %v", err)
+	}
+	if got := fake.params["/app/DB_PASSWORD"]; got.Type != types.ParameterTypeSecureString || aws.ToString(got.Value) != "new" {
+		t.Errorf("parameter = %+v; want the new value as SecureString", got)
+	}
+	if fake.keyIDs["/app/DB_PASSWORD"] != "alias/app" {
+		t.Errorf("KMS key = %q; want alias/app kept", fake.keyIDs["/app/DB_PASSWORD"])
+	}
+
+	if err := PutParameter(fake, "/app/NEW", "v", StringType, opts); err != nil {
+		t.Fatalf("PutParameter of a new parameter with KeepType: %v", err)
+	}
+	if got := fake.params["/app/NEW"].Type; got != types.ParameterTypeString {
+		t.Errorf("new parameter type = %s; want String", got)
+	}
+
+	fake.set("/app/HOSTS", "a,b", types.ParameterTypeStringList)
+	if err := PutParameter(fake, "/app/HOSTS", "a,,c", StringType, opts); !errors.Is(err, ErrValidation) {
+		t.Errorf("PutParameter of an empty StringList item with KeepType: %v; want a validation error", err)
+	}
+}
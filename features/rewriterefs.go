@@ -0,0 +1,154 @@
+package features
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var accountIDPattern = regexp.MustCompile(`^\d{12}$`)
+
+// RewriteRefsOptions controls how rewrite-refs maps valueFrom references.
+type RewriteRefsOptions struct {
+	// Map holds old=new prefix pairs. Keys may be parameter paths ("/old/"), applied to the name of bare paths
+	// and ARNs alike; ARN prefixes ("arn:aws:ssm:us-east-1:111111111111:parameter/old/"), applied to ARNs only;
+	// or "region:<old>" and "account:<old>", which replace that component of ARNs. The longest matching
+	// path or ARN prefix wins, and an ARN prefix match takes precedence over the other kinds.
+	Map    map[string]string
+	Output string // File to write; empty rewrites each file in place. Only valid for a single file.
+	DryRun bool   // Print the changes without writing.
+}
+
+// refMapper applies parsed RewriteRefsOptions.Map entries to one reference.
+type refMapper struct {
+	paths, arns       map[string]string
+	regions, accounts map[string]string
+}
+
+// newRefMapper sorts the entries of mapping by kind and checks that each side has the same form.
+func newRefMapper(mapping map[string]string) (*refMapper, error) {
+	if len(mapping) == 0 {
+		return nil, validationErrorf("at least one -map old=new is required")
+	}
+	m := &refMapper{paths: map[string]string{}, arns: map[string]string{}, regions: map[string]string{}, accounts: map[string]string{}}
+	for from, to := range mapping {
+		switch {
+		case strings.HasPrefix(from, "region:"):
+			if !regionPattern.MatchString(to) {
+				return nil, validationErrorf("-map %s=%s: %q is not a region", from, to, to)
+			}
+			m.regions[strings.TrimPrefix(from, "region:")] = to
+		case strings.HasPrefix(from, "account:"):
+			if !accountIDPattern.MatchString(to) {
+				return nil, validationErrorf("-map %s=%s: %q is not a 12-digit account ID", from, to, to)
+			}
+			m.accounts[strings.TrimPrefix(from, "account:")] = to
+		case strings.HasPrefix(from, "arn:"):
+			if !strings.HasPrefix(to, "arn:") {
+				return nil, validationErrorf("-map %s=%s: an ARN prefix must map to an ARN prefix", from, to)
+			}
+			m.arns[from] = to
+		case strings.HasPrefix(from, "/"):
+			if !strings.HasPrefix(to, "/") {
+				return nil, validationErrorf("-map %s=%s: a path prefix must map to a path starting with /", from, to)
+			}
+			m.paths[from] = to
+		default:
+			return nil, validationErrorf("-map %s=%s: expected a path, an ARN prefix, region:<name> or account:<id>", from, to)
+		}
+	}
+	return m, nil
+}
+
+// longestPrefix returns the entry of prefixes that is the longest prefix of s.
+func longestPrefix(s string, prefixes map[string]string) (from, to string, ok bool) {
+	for prefix, replacement := range prefixes {
+		if strings.HasPrefix(s, prefix) && len(prefix) > len(from) {
+			from, to, ok = prefix, replacement, true
+		}
+	}
+	return from, to, ok
+}
+
+// rewrite returns valueFrom with the mapping applied, or valueFrom itself when nothing matches.
+func (m *refMapper) rewrite(valueFrom string) string {
+	if from, to, ok := longestPrefix(valueFrom, m.arns); ok {
+		return to + strings.TrimPrefix(valueFrom, from)
+	}
+	name := ExtractParameterName(valueFrom)
+	if name == "" {
+		return valueFrom // Not a parameter reference, e.g. a Secrets Manager ARN.
+	}
+	mapped := name
+	if from, to, ok := longestPrefix(name, m.paths); ok {
+		mapped = to + strings.TrimPrefix(name, from)
+	}
+	region, accountID, _, isARN := ParseParameterARN(valueFrom)
+	if !isARN {
+		return mapped
+	}
+	newRegion, newAccountID := region, accountID
+	if to, ok := m.regions[region]; ok {
+		newRegion = to
+	}
+	if to, ok := m.accounts[accountID]; ok {
+		newAccountID = to
+	}
+	if mapped == name && newRegion == region && newAccountID == accountID {
+		return valueFrom
+	}
+	return ParameterARN(newRegion, newAccountID, mapped)
+}
+
+// RewriteReferences rewrites the valueFrom references of every container in each task definition file
+// according to opts.Map, printing each change. Every other byte of the files is kept.
+func RewriteReferences(files []string, opts RewriteRefsOptions) error {
+	if len(files) == 0 {
+		return validationErrorf("no task definition files given")
+	}
+	if opts.Output != "" && len(files) > 1 {
+		return validationErrorf("-o needs a single -s file; %d files are rewritten in place", len(files))
+	}
+	mapper, err := newRefMapper(opts.Map)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if opts.Output == "" {
+			if err := checkNotStdin(file, "-s"); err != nil {
+				return err
+			}
+		}
+		data, err := readSource(file)
+		if err != nil {
+			return err
+		}
+		updated, changed, err := rewriteValueFrom(data, func(valueFrom string) string {
+			rewritten := mapper.rewrite(valueFrom)
+			if rewritten != valueFrom {
+				Infof("  %s -> %s\n", valueFrom, rewritten)
+			}
+			return rewritten
+		})
+		if err != nil {
+			return validationErrorf("failed to parse %s: %w", file, err)
+		}
+		output := file
+		if opts.Output != "" {
+			output = opts.Output
+		}
+		switch {
+		case opts.DryRun:
+			Infof("[dry-run] Would update %d references in %s\n", changed, output)
+		case changed == 0 && output == file:
+			Infof("No references to update in %s\n", file)
+		default:
+			if err := os.WriteFile(output, updated, 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", output, err)
+			}
+			Infof("%s %d references in %s\n", yellow("Updated"), changed, output)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package features
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRefMapperRewrite(t *testing.T) {
+	mapper, err := newRefMapper(map[string]string{
+		"/prod/app/":           "/prod/svc/app/",
+		"/prod/app/legacy/":    "/prod/legacy/",
+		"region:us-east-1":     "eu-west-1",
+		"account:111111111111": "222222222222",
+		"arn:aws:ssm:ap-southeast-1:333333333333:parameter/shared/": "arn:aws:ssm:ap-southeast-3:444444444444:parameter/common/",
+	})
+	if err != nil {
+		t.Fatalf("newRefMapper: %v", err)
+	}
+	tests := []struct {
+		in, want string
+	}{
+		{"/prod/app/DB_URL", "/prod/svc/app/DB_URL"},
+		{"/prod/app/legacy/TOKEN", "/prod/legacy/TOKEN"},
+		{"/dev/app/DB_URL", "/dev/app/DB_URL"},
+		{"arn:aws:ssm:us-east-1:111111111111:parameter/prod/app/DB_URL", "arn:aws:ssm:eu-west-1:222222222222:parameter/prod/svc/app/DB_URL"},
+		{"arn:aws:ssm:us-west-2:555555555555:parameter/prod/app/DB_URL", "arn:aws:ssm:us-west-2:555555555555:parameter/prod/svc/app/DB_URL"},
+		{"arn:aws:ssm:us-east-1:111111111111:parameter/other/KEY", "arn:aws:ssm:eu-west-1:222222222222:parameter/other/KEY"},
+		{"arn:aws:ssm:ap-southeast-1:333333333333:parameter/shared/KEY", "arn:aws:ssm:ap-southeast-3:444444444444:parameter/common/KEY"},
+		{"arn:aws-cn:ssm:cn-north-1:555555555555:parameter/other/KEY", "arn:aws-cn:ssm:cn-north-1:555555555555:parameter/other/KEY"},
+		{"arn:aws:secretsmanager:us-east-1:111111111111:secret:db", "arn:aws:secretsmanager:us-east-1:111111111111:secret:db"},
+	}
+	for _, tt := range tests {
+		if got := mapper.rewrite(tt.in); got != tt.want {
+			t.Errorf("rewrite(%s) = %s; want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNewRefMapperRejectsMixedForms(t *testing.T) {
+	for _, mapping := range []map[string]string{
+		{"/prod/": "arn:aws:ssm:us-east-1:111111111111:parameter/prod/"},
+		{"arn:aws:ssm:us-east-1:111111111111:parameter/": "/prod/"},
+		{"account:111111111111": "prod"},
+		{"region:us-east-1": "Europe"},
+		{"prod/": "/prod/"},
+		{},
+	} {
+		if _, err := newRefMapper(mapping); ExitCode(err) != ExitValidation {
+			t.Errorf("newRefMapper(%v) err = %v; want a validation error", mapping, err)
+		}
+	}
+}
+
+func TestRewriteReferences(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "task.json")
+	data := `{
+  "family": "app",
+  "containerDefinitions": [
+    {"name": "web", "secrets": [{"name": "DB_URL", "valueFrom": "/prod/app/DB_URL"}]},
+    {"name": "log", "logConfiguration": {"secretOptions": [{"name": "KEY", "valueFrom": "/prod/app/LOG_KEY"}]}}
+  ]
+}`
+	if err := os.WriteFile(source, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "out.json")
+	if err := RewriteReferences([]string{source}, RewriteRefsOptions{Map: map[string]string{"/prod/app/": "/prod/svc/"}, Output: output}); err != nil {
+		t.Fatalf("RewriteReferences: %v", err)
+	}
+	want := `{
+  "family": "app",
+  "containerDefinitions": [
+    {"name": "web", "secrets": [{"name": "DB_URL", "valueFrom": "/prod/svc/DB_URL"}]},
+    {"name": "log", "logConfiguration": {"secretOptions": [{"name": "KEY", "valueFrom": "/prod/svc/LOG_KEY"}]}}
+  ]
+}`
+	if got, _ := os.ReadFile(output); string(got) != want {
+		t.Errorf("output =\n%s\nwant\n%s", got, want)
+	}
+	if got, _ := os.ReadFile(source); string(got) != data {
+		t.Errorf("source changed with -o:\n%s", got)
+	}
+}
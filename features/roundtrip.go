@@ -0,0 +1,197 @@
+package features
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// roundTripPrefix is where verify-roundtrip puts the keys in its in-memory store when no prefix is given.
+const roundTripPrefix = "/roundtrip/"
+
+// RoundTripOptions configures VerifyRoundTrip.
+type RoundTripOptions struct {
+	Format string     // Input format of the source; empty picks it by extension, as generate does.
+	Arrays string     // How arrays of tree formats map to parameters, as for generate.
+	Strict bool       // Fail instead of warning when the source defines a key twice.
+	Prefix string     // Parameter path prefix of the keys; roundTripPrefix when empty, which only the in-memory store allows.
+	Names  *NameRules // Normalization of the keys in paths composed from Prefix, as for generate.
+	Types  string     // Type mapping file, as for generate.
+	// Output is a directory to keep the generated template and the regenerated environment in; empty uses a
+	// temporary one, removed afterwards.
+	Output string
+}
+
+// VerifyRoundTrip checks that the keys of source survive the trip through Parameter Store: it generates a
+// template from source as generate does, puts it as put-from-template does, reads it back as get -s does
+// into a .env file, reads that file again and compares every key and value with source. Values lost to
+// parsing or serialization, such as multi-line values, quotes or non-ASCII text, are printed masked as by
+// check-drift and a RoundTripError is returned.
+//
+// With a nil client the parameters are put in an in-memory store. Otherwise they are put with client under
+// opts.Prefix, which must be empty, and deleted afterwards; every generated path must be under it.
+func VerifyRoundTrip(client SSMClient, source string, opts RoundTripOptions) (err error) {
+	live := client != nil
+	switch {
+	case live && opts.Prefix == "":
+		return validationErrorf("a prefix of scratch parameters is required to verify the round trip through Parameter Store")
+	case opts.Prefix == "":
+		opts.Prefix = roundTripPrefix
+	case !strings.HasPrefix(opts.Prefix, "/") || !strings.HasSuffix(opts.Prefix, "/"):
+		return validationErrorf("prefix %q must start and end with \"/\"", opts.Prefix)
+	}
+	if opts.Format == "" && InputFormatFromFile(source) == "" {
+		opts.Format = InputDotenv // As generate: .env files are often named app.env.prod.
+	}
+	original, err := readInput(source, opts.Format, InputOptions{Arrays: opts.Arrays, Strict: opts.Strict})
+	if err != nil {
+		return err
+	}
+	if live {
+		existing, err := listParametersEncrypted(client, opts.Prefix)
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			return validationErrorf("%s has %d parameters; the round trip puts and deletes parameters under it, so use an empty prefix", opts.Prefix, len(existing))
+		}
+	} else {
+		client = newMemoryClient()
+	}
+
+	dir := opts.Output
+	if dir == "" {
+		if dir, err = os.MkdirTemp("", "salter-roundtrip-"); err != nil {
+			return fmt.Errorf("failed to create a temporary directory: %w", err)
+		}
+		defer os.RemoveAll(dir)
+	} else if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	template := filepath.Join(dir, "template.json")
+	genOpts := GenerateOptions{Format: opts.Format, Prefix: opts.Prefix, Strict: opts.Strict, Arrays: opts.Arrays, Names: opts.Names, Types: opts.Types}
+	if err := GenerateTaskDef(source, template, genOpts); err != nil {
+		return err
+	}
+	secrets, err := readTemplateSecrets(template, TemplateOptions{})
+	if err != nil {
+		return err
+	}
+	paramTypes := make(map[string]ParameterType, len(secrets))
+	for _, secret := range secrets {
+		paramTypes[secret.Name] = secret.Type
+		name := ExtractParameterName(secret.ValueFrom)
+		if live && !strings.HasPrefix(name, opts.Prefix) {
+			return validationErrorf("%s would be put at %s, outside %s; remove its path from the source or type mapping to verify it", secret.Name, name, opts.Prefix)
+		}
+	}
+	if live {
+		defer func() {
+			if cleanupErr := deleteRoundTrip(client, opts.Prefix); cleanupErr != nil {
+				err = errors.Join(err, cleanupErr)
+			}
+		}()
+	}
+
+	if err := PutParametersFromTemplate(client, template, TemplateOptions{}, PutOptions{AssumeYes: true}); err != nil {
+		return err
+	}
+	base := filepath.Join(dir, "roundtrip")
+	getOpts := GetFileOptions{Export: ExportOptions{Formats: []string{FormatEnv}}, Missing: MissingSkip}
+	if err := GetParametersFromFile(client, template, base, TemplateOptions{}, getOpts); err != nil {
+		return err
+	}
+	regenerated, err := readInput(base+".env", InputDotenv, InputOptions{})
+	if err != nil {
+		return err
+	}
+
+	changed := compareRoundTrip(original, regenerated, paramTypes)
+	if changed > 0 {
+		return &RoundTripError{Changed: changed, Total: len(original)}
+	}
+	fmt.Printf("%s all %d keys of %s survived the round trip\n", green("OK:"), len(original), source)
+	return nil
+}
+
+// compareRoundTrip prints the keys of original that regenerated lost, changed or added, with values masked,
+// and returns how many there are. paramTypes holds the parameter type of each key; String values are also
+// shown around their first difference.
+func compareRoundTrip(original, regenerated []InputParam, paramTypes map[string]ParameterType) int {
+	values := make(map[string]string, len(regenerated))
+	for _, p := range regenerated {
+		values[p.Key] = p.Value
+	}
+	changed := 0
+	seen := make(map[string]bool, len(original))
+	for _, p := range original {
+		seen[p.Key] = true
+		got, ok := values[p.Key]
+		switch {
+		case !ok:
+			reason := "not read back"
+			if p.Value == "" {
+				reason = "SSM does not store empty values"
+			}
+			fmt.Printf("%s %s: lost (%s)\n", red("-"), p.Key, reason)
+			changed++
+		case got != p.Value:
+			at := firstDifference(p.Value, got)
+			fmt.Printf("%s %s: value %s -> %s, first difference at byte %d", yellow("~"), p.Key, maskValue(p.Value), maskValue(got), at)
+			if paramTypes[p.Key] == StringType {
+				fmt.Printf(": %q -> %q", excerpt(p.Value, at), excerpt(got, at))
+			}
+			fmt.Println(" (source -> round trip)")
+			changed++
+		}
+	}
+	for _, p := range regenerated {
+		if !seen[p.Key] {
+			fmt.Printf("%s %s: added by the round trip %s\n", green("+"), p.Key, maskValue(p.Value))
+			changed++
+		}
+	}
+	return changed
+}
+
+// firstDifference returns the offset of the first byte where a and b differ.
+func firstDifference(a, b string) int {
+	n := min(len(a), len(b))
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
+
+// excerpt returns up to 12 bytes of s on each side of offset at.
+func excerpt(s string, at int) string {
+	const around = 12
+	return s[max(0, at-around):min(len(s), at+around)]
+}
+
+// deleteRoundTrip deletes the parameters verify-roundtrip put under prefix.
+func deleteRoundTrip(client SSMClient, prefix string) error {
+	params, err := listParametersEncrypted(client, prefix)
+	if err != nil {
+		return fmt.Errorf("failed to list the round trip parameters to delete them: %w", err)
+	}
+	var failures []error
+	for _, p := range params {
+		if _, err := client.DeleteParameter(context.TODO(), &ssm.DeleteParameterInput{Name: p.Name}); err != nil {
+			failures = append(failures, wrapClientError(client, "DeleteParameter", aws.ToString(p.Name), err))
+		}
+	}
+	if len(failures) > 0 {
+		return &PartialFailureError{Failed: failures, Total: len(params)}
+	}
+	Infof("Deleted the %d round trip parameters under %s\n", len(params), prefix)
+	return nil
+}
@@ -0,0 +1,81 @@
+package features
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "app.env")
+	content := "# Comments and blank lines are not values.\n\n" +
+		"GREETING=héllo wörld ✓ 日本語\n" +
+		"QUOTED=\"double\" and 'single' quotes\n" +
+		"JSON={\"a\": [1, 2], \"b\": \"c=d\"}\n" +
+		"HASH=value # not a comment\n" +
+		"EQUALS=a=b=c\n" +
+		"WINDOWS=crlf line\r\n" +
+		"TLS_CERT=-----BEGIN CERTIFICATE-----\nMIIBszCCAVmgAwIBAgIUZ2V0\nc2FsdGVyLWF3cy10ZXN0\n-----END CERTIFICATE-----\n" +
+		"# param: type=stringlist\nHOSTS=a.example.com,b.example.com\n" +
+		"DB_PASSWORD=p@ss w0rd\"!\n"
+	if err := os.WriteFile(source, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	keep := filepath.Join(dir, "kept")
+	if err := VerifyRoundTrip(nil, source, RoundTripOptions{Output: keep}); err != nil {
+		t.Fatalf("VerifyRoundTrip: %v", err)
+	}
+	for _, file := range []string{"template.json", "roundtrip.env"} {
+		if _, err := os.Stat(filepath.Join(keep, file)); err != nil {
+			t.Errorf("%s not kept: %v", file, err)
+		}
+	}
+
+	// An empty value cannot be stored, so it is lost.
+	if err := os.WriteFile(source, []byte("EMPTY=\nKEPT=x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := VerifyRoundTrip(nil, source, RoundTripOptions{})
+	if rt, ok := err.(*RoundTripError); !ok || rt.Changed != 1 || rt.Total != 2 || ExitCode(err) != ExitCheckFailed {
+		t.Errorf("VerifyRoundTrip with an empty value = %v; want 1 of 2 keys lost", err)
+	}
+
+	// JSON keeps what a .env file cannot: the blank line and the leading spaces do not come back.
+	tree := filepath.Join(dir, "app.json")
+	if err := os.WriteFile(tree, []byte(`{"NOTES": "first\n\nthird", "PADDED": "  x", "PLAIN": "ok"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = VerifyRoundTrip(nil, tree, RoundTripOptions{})
+	if rt, ok := err.(*RoundTripError); !ok || rt.Changed != 2 || rt.Total != 3 {
+		t.Errorf("VerifyRoundTrip of %s = %v; want 2 of 3 keys changed", tree, err)
+	}
+}
+
+func TestVerifyRoundTripLive(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "app.env")
+	if err := os.WriteFile(source, []byte("A=1\n# param: path=/prod/app/B\nB=2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeSSM()
+	if err := VerifyRoundTrip(fake, source, RoundTripOptions{}); ExitCode(err) != ExitValidation {
+		t.Errorf("VerifyRoundTrip without a prefix = %v; want a validation error", err)
+	}
+	if err := VerifyRoundTrip(fake, source, RoundTripOptions{Prefix: "/scratch/"}); ExitCode(err) != ExitValidation {
+		t.Errorf("VerifyRoundTrip with a path outside the prefix = %v; want a validation error", err)
+	}
+
+	if err := os.WriteFile(source, []byte("A=1\nB=two\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyRoundTrip(fake, source, RoundTripOptions{Prefix: "/scratch/"}); err != nil {
+		t.Fatalf("VerifyRoundTrip: %v", err)
+	}
+	if len(fake.params) != 0 || fake.puts != 2 {
+		t.Errorf("after the round trip: %d parameters left, %d puts; want 0 left after 2 puts", len(fake.params), fake.puts)
+	}
+	fake.set("/scratch/OTHER", "x", "String")
+	if err := VerifyRoundTrip(fake, source, RoundTripOptions{Prefix: "/scratch/"}); ExitCode(err) != ExitValidation {
+		t.Errorf("VerifyRoundTrip with a non-empty prefix = %v; want a validation error", err)
+	}
+}
@@ -0,0 +1,272 @@
+package features
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Kinds of detection rules.
+const (
+	RuleKeyword = "keyword" // The target contains one of Keywords, ignoring case.
+	RuleRegex   = "regex"   // The target matches Pattern.
+	RuleEntropy = "entropy" // The target has at least MinEntropy bits of Shannon entropy per character.
+	RulePEM     = "pem"     // The target contains a PEM block.
+	RuleJWT     = "jwt"     // The target has the three dot-separated parts of a JWT.
+)
+
+// Targets of detection rules.
+const (
+	TargetKey   = "key"
+	TargetValue = "value"
+)
+
+// DetectionRule is one check deciding that a key is stored as SecureString by generate, import and the
+// other actions that type new keys. The rules are checked in order and the first match decides; keys no
+// rule matches are String.
+type DetectionRule struct {
+	ID          string   `json:"id"`
+	Kind        string   `json:"kind"`                  // RuleKeyword, RuleRegex, RuleEntropy, RulePEM or RuleJWT.
+	Description string   `json:"description,omitempty"` // Why a match is a secret, as classify reports it.
+	Target      string   `json:"target,omitempty"`      // TargetKey or TargetValue; keyword rules check the key by default, the others the value.
+	Keywords    []string `json:"keywords,omitempty"`    // For keyword rules.
+	Pattern     string   `json:"pattern,omitempty"`     // For regex rules; unanchored, so use ^ and $ to match the whole target.
+	MinLength   int      `json:"minLength,omitempty"`   // Shortest target the rule applies to.
+	MinEntropy  float64  `json:"minEntropy,omitempty"`  // For entropy rules.
+	Disabled    bool     `json:"disabled,omitempty"`    // Off unless listed in DetectionConfig.Enable.
+
+	pattern *regexp.Regexp
+}
+
+// DetectionConfig adjusts the detection rules, as "detection" in config.json.
+type DetectionConfig struct {
+	Disable []string        `json:"disable,omitempty"` // IDs of rules to turn off.
+	Enable  []string        `json:"enable,omitempty"`  // IDs of rules off by default to turn on, such as high-entropy.
+	Rules   []DetectionRule `json:"rules,omitempty"`   // Rules of your own, checked before the built-in ones.
+}
+
+// Detection names the rule that typed a key as SecureString, for classify reports.
+type Detection struct {
+	Rule   string // ID of the rule, such as secret-keyword.
+	Reason string // What matched, never the value itself.
+}
+
+// builtinRules are the detection rules in their default order.
+var builtinRules = []DetectionRule{
+	{ID: "secret-keyword", Kind: RuleKeyword, Description: "key contains a word such as password, secret, token or key",
+		Keywords: []string{"password", "secret", "key", "token", "api", "auth", "credential", "private", "cert", "ssl", "secure"}},
+	{ID: "pem-block", Kind: RulePEM, Description: "value contains a PEM block"},
+	{ID: "encoded-token", Kind: RuleRegex, Pattern: `^[A-Za-z0-9+/=]{20,}$`, Description: "value is a token of 20 or more base64 characters"},
+	{ID: "url-credentials", Kind: RuleRegex, Pattern: `^https?://[^@]+@`, Description: "value is a URL with credentials"},
+	{ID: "jwt", Kind: RuleJWT, Description: "value has the three dot-separated parts of a JWT"},
+	{ID: "encoded-value", Kind: RuleRegex, Pattern: `^[A-Za-z0-9+/=\-\n]+$`, MinLength: 21, Description: "value is over 20 base64-like characters"},
+	{ID: "high-entropy", Kind: RuleEntropy, MinLength: 20, MinEntropy: 4, Disabled: true,
+		Description: "value is a random-looking string of 20 or more characters"},
+}
+
+// jwtLikePattern matches three dot-separated base64 parts; looser than jwtPattern, which reads exp claims.
+var jwtLikePattern = regexp.MustCompile(`^[A-Za-z0-9+/=]+\.[A-Za-z0-9+/=]+\.[A-Za-z0-9+/=]+$`)
+
+var (
+	detectionMu    sync.RWMutex
+	detectionRules = mustDetectionRules(nil)
+)
+
+// mustDetectionRules is newDetectionRules for configurations known to be valid.
+func mustDetectionRules(cfg *DetectionConfig) []DetectionRule {
+	rules, err := newDetectionRules(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return rules
+}
+
+// newDetectionRules returns the enabled rules of cfg, its own first and then the built-in ones, compiled.
+// A nil cfg gives the built-in rules.
+func newDetectionRules(cfg *DetectionConfig) ([]DetectionRule, error) {
+	if cfg == nil {
+		cfg = &DetectionConfig{}
+	}
+	all := append(append([]DetectionRule(nil), cfg.Rules...), builtinRules...)
+	index := make(map[string]int, len(all))
+	for i := range all {
+		rule := &all[i]
+		if rule.ID == "" {
+			return nil, validationErrorf("detection rule %d has no id", i+1)
+		}
+		if _, ok := index[rule.ID]; ok {
+			return nil, validationErrorf("detection rule %q is defined twice", rule.ID)
+		}
+		index[rule.ID] = i
+		if err := rule.compile(); err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range cfg.Enable {
+		i, ok := index[id]
+		if !ok {
+			return nil, validationErrorf("detection enable: no rule %q (rules: %s)", id, ruleIDs(all))
+		}
+		all[i].Disabled = false
+	}
+	for _, id := range cfg.Disable {
+		i, ok := index[id]
+		if !ok {
+			return nil, validationErrorf("detection disable: no rule %q (rules: %s)", id, ruleIDs(all))
+		}
+		all[i].Disabled = true
+	}
+	var rules []DetectionRule
+	for _, rule := range all {
+		if !rule.Disabled {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+// compile checks the fields of the rule's kind and compiles its pattern.
+func (r *DetectionRule) compile() error {
+	switch r.Target {
+	case "":
+		r.Target = TargetValue
+		if r.Kind == RuleKeyword {
+			r.Target = TargetKey
+		}
+	case TargetKey, TargetValue:
+	default:
+		return validationErrorf("detection rule %q: invalid target %q (use %s or %s)", r.ID, r.Target, TargetKey, TargetValue)
+	}
+	switch r.Kind {
+	case RuleKeyword:
+		if len(r.Keywords) == 0 {
+			return validationErrorf("detection rule %q: keyword rules need keywords", r.ID)
+		}
+	case RuleRegex:
+		pattern, err := regexp.Compile(r.Pattern)
+		if err != nil || r.Pattern == "" {
+			return validationErrorf("detection rule %q: invalid pattern %q: %v", r.ID, r.Pattern, err)
+		}
+		r.pattern = pattern
+	case RuleEntropy:
+		if r.MinEntropy <= 0 {
+			return validationErrorf("detection rule %q: entropy rules need a minEntropy in bits per character, such as 4", r.ID)
+		}
+	case RulePEM, RuleJWT:
+	default:
+		return validationErrorf("detection rule %q: invalid kind %q (use %s, %s, %s, %s or %s)", r.ID, r.Kind,
+			RuleKeyword, RuleRegex, RuleEntropy, RulePEM, RuleJWT)
+	}
+	return nil
+}
+
+// ruleIDs lists the IDs of rules, for messages.
+func ruleIDs(rules []DetectionRule) string {
+	ids := make([]string, len(rules))
+	for i, rule := range rules {
+		ids[i] = rule.ID
+	}
+	return strings.Join(ids, ", ")
+}
+
+// ConfigureDetection replaces the detection rules by the built-in ones adjusted by cfg, if it is valid.
+func ConfigureDetection(cfg *DetectionConfig) error {
+	rules, err := newDetectionRules(cfg)
+	if err != nil {
+		return err
+	}
+	detectionMu.Lock()
+	detectionRules = rules
+	detectionMu.Unlock()
+	return nil
+}
+
+// match returns why key and value match the rule, or false.
+func (r *DetectionRule) match(key, value string) (string, bool) {
+	target := value
+	if r.Target == TargetKey {
+		target = key
+	}
+	if len(target) < r.MinLength {
+		return "", false
+	}
+	switch r.Kind {
+	case RuleKeyword:
+		lower := strings.ToLower(target)
+		for _, keyword := range r.Keywords {
+			if strings.Contains(lower, strings.ToLower(keyword)) {
+				return fmt.Sprintf("%s contains %q", r.Target, keyword), true
+			}
+		}
+		return "", false
+	case RuleRegex:
+		if !r.pattern.MatchString(target) {
+			return "", false
+		}
+	case RuleEntropy:
+		entropy := shannonEntropy(target)
+		if entropy < r.MinEntropy {
+			return "", false
+		}
+		return fmt.Sprintf("%s has %.1f bits of entropy per character", r.Target, entropy), true
+	case RulePEM:
+		if !strings.Contains(target, "-----BEGIN") {
+			return "", false
+		}
+	case RuleJWT:
+		if !jwtLikePattern.MatchString(target) {
+			return "", false
+		}
+	}
+	if r.Description == "" {
+		return fmt.Sprintf("%s matches rule %s", r.Target, r.ID), true
+	}
+	return r.Description, true
+}
+
+// classifyParameter returns the type the detection rules give a key and its value, with the rule that
+// matched, or nil for StringType.
+func classifyParameter(key, value string) (ParameterType, *Detection) {
+	detectionMu.RLock()
+	rules := detectionRules
+	detectionMu.RUnlock()
+	for i := range rules {
+		if reason, ok := rules[i].match(key, value); ok {
+			return SecureStringType, &Detection{Rule: rules[i].ID, Reason: reason}
+		}
+	}
+	return StringType, nil
+}
+
+// detectionRule returns the enabled rule id.
+func detectionRule(id string) (DetectionRule, bool) {
+	detectionMu.RLock()
+	defer detectionMu.RUnlock()
+	for _, rule := range detectionRules {
+		if rule.ID == id {
+			return rule, true
+		}
+	}
+	return DetectionRule{}, false
+}
+
+// shannonEntropy returns the Shannon entropy of value in bits per character, as gitleaks reports it.
+func shannonEntropy(value string) float64 {
+	if value == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range value {
+		counts[r]++
+		n++
+	}
+	entropy := 0.0
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
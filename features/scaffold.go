@@ -0,0 +1,70 @@
+package features
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+)
+
+// scaffoldFiles holds the starter files scaffold writes, so a release binary needs no checkout of the repo.
+//
+//go:embed scaffold
+var scaffoldFiles embed.FS
+
+// scaffoldKinds maps each scaffold kind to its embedded file, which is also the default output name.
+var scaffoldKinds = map[string]string{
+	"taskdef": "task-definition.json", // Task definition for get -s and put-from-template.
+	"spec":    "spec.yaml",            // Environment spec for bootstrap.
+	"config":  "config.json",          // config.json with placeholders and a pathPattern.
+	"schema":  "template.schema.json", // JSON Schema of templates, for editors; templates are checked against it.
+}
+
+// ScaffoldKinds returns the kinds scaffold can write, sorted.
+func ScaffoldKinds() []string {
+	kinds := make([]string, 0, len(scaffoldKinds))
+	for kind := range scaffoldKinds {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Scaffold writes the starter file of kind to output, or to its default name (such as task-definition.json)
+// when output is empty; "-" prints it. An existing file is never overwritten.
+func Scaffold(kind, output string) error {
+	name, ok := scaffoldKinds[kind]
+	if !ok {
+		return validationErrorf("unknown scaffold %q (use %s)", kind, strings.Join(ScaffoldKinds(), ", "))
+	}
+	data, err := fs.ReadFile(scaffoldFiles, "scaffold/"+name)
+	if err != nil {
+		return err
+	}
+	if output == "-" {
+		fmt.Print(string(data))
+		return nil
+	}
+	if output == "" {
+		output = name
+	}
+	f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, fs.ErrExist) {
+		return validationErrorf("%s already exists; remove it or choose another file with -o", output)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	Infof("Saved %s scaffold to %s\n", kind, output)
+	return nil
+}
@@ -0,0 +1,53 @@
+package features
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScaffold(t *testing.T) {
+	dir := t.TempDir()
+	files := make(map[string]string)
+	for _, kind := range ScaffoldKinds() {
+		files[kind] = filepath.Join(dir, kind)
+		if err := Scaffold(kind, files[kind]); err != nil {
+			t.Fatalf("Scaffold(%s): %v", kind, err)
+		}
+	}
+
+	// Each starter file is usable as written.
+	tmplOpts := TemplateOptions{Vars: map[string]string{"env": "staging"}}
+	secrets, err := readTemplateSecrets(files["taskdef"], tmplOpts)
+	if err != nil || len(secrets) == 0 {
+		t.Fatalf("taskdef: %d secrets, %v", len(secrets), err)
+	}
+	for _, secret := range secrets {
+		if _, err := templateTarget(secret, tmplOpts); err != nil {
+			t.Errorf("taskdef secret %s: %v", secret.Name, err)
+		}
+	}
+	if _, err := ReadBootstrapSpec(files["spec"]); err != nil {
+		t.Errorf("spec: %v", err)
+	}
+	data, err := os.ReadFile(files["config"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var config Config
+	if err := dec.Decode(&config); err != nil || config.PathPattern == "" {
+		t.Errorf("config: %+v, %v", config, err)
+	}
+
+	if err := Scaffold("spec", files["spec"]); !errors.Is(err, ErrValidation) {
+		t.Errorf("Scaffold over an existing file: %v; want ErrValidation", err)
+	}
+	if err := Scaffold("helm", filepath.Join(dir, "chart")); !errors.Is(err, ErrValidation) {
+		t.Errorf("Scaffold(helm): %v; want ErrValidation", err)
+	}
+}
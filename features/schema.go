@@ -0,0 +1,363 @@
+package features
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// templateSchemaFile is the embedded JSON Schema of templates, also written by scaffold schema.
+const templateSchemaFile = "scaffold/template.schema.json"
+
+// jsonSchema is the part of JSON Schema the template schema uses: $ref into $defs, type, properties,
+// additionalProperties (as a schema), required, anyOf, items, minItems, minLength and pattern. Properties not
+// listed are allowed unless additionalProperties is set, since task definitions carry many fields the tool
+// passes through.
+type jsonSchema struct {
+	Ref         string                 `json:"$ref"`
+	Defs        map[string]*jsonSchema `json:"$defs"`
+	Description string                 `json:"description"`
+	Type        jsonTypes              `json:"type"`
+	Properties  map[string]*jsonSchema `json:"properties"`
+	// AdditionalProperties checks the values of the properties not in Properties, such as those of a map.
+	AdditionalProperties *jsonSchema   `json:"additionalProperties"`
+	Required             []string      `json:"required"`
+	AnyOf                []*jsonSchema `json:"anyOf"`
+	Items                *jsonSchema   `json:"items"`
+	MinItems             *int          `json:"minItems"`
+	MinLength            *int          `json:"minLength"`
+	Pattern              string        `json:"pattern"`
+
+	pattern *regexp.Regexp // Pattern, compiled.
+}
+
+// jsonTypes is the type keyword: one type name or a list of them.
+type jsonTypes []string
+
+func (t *jsonTypes) UnmarshalJSON(data []byte) error {
+	var one string
+	if json.Unmarshal(data, &one) == nil {
+		*t = jsonTypes{one}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+var (
+	templateSchemaOnce sync.Once
+	templateSchema     *jsonSchema
+	templateSchemaErr  error
+)
+
+// loadTemplateSchema parses the embedded template schema once.
+func loadTemplateSchema() (*jsonSchema, error) {
+	templateSchemaOnce.Do(func() {
+		data, err := fs.ReadFile(scaffoldFiles, templateSchemaFile)
+		if err != nil {
+			templateSchemaErr = err
+			return
+		}
+		var schema jsonSchema
+		if err := json.Unmarshal(data, &schema); err != nil {
+			templateSchemaErr = fmt.Errorf("invalid template schema: %w", err)
+			return
+		}
+		if err := schema.compile(); err != nil {
+			templateSchemaErr = fmt.Errorf("invalid template schema: %w", err)
+			return
+		}
+		templateSchema = &schema
+	})
+	return templateSchema, templateSchemaErr
+}
+
+// compile compiles the patterns of s and every schema below it.
+func (s *jsonSchema) compile() error {
+	if s == nil {
+		return nil
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return err
+		}
+		s.pattern = re
+	}
+	children := []*jsonSchema{s.Items, s.AdditionalProperties}
+	children = append(children, s.AnyOf...)
+	for _, m := range []map[string]*jsonSchema{s.Defs, s.Properties} {
+		for _, child := range m {
+			children = append(children, child)
+		}
+	}
+	for _, child := range children {
+		if err := child.compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonNode is a decoded JSON value with the offset it starts at, so problems can be reported by line.
+type jsonNode struct {
+	offset int
+	value  any // nil, bool, json.Number, string, []*jsonNode or *jsonObject.
+}
+
+// jsonObject is a decoded JSON object; keys keep the order of the file.
+type jsonObject struct {
+	keys       []string
+	fields     map[string]*jsonNode
+	keyOffsets map[string]int
+}
+
+// parseJSONNode decodes data into a tree of jsonNodes.
+func parseJSONNode(data []byte) (*jsonNode, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	node, err := readJSONNode(dec, data)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("data after the top-level value at offset %d", dec.InputOffset())
+	}
+	return node, nil
+}
+
+// readJSONNode reads the next value of dec.
+func readJSONNode(dec *json.Decoder, data []byte) (*jsonNode, error) {
+	tok, start, err := nextToken(dec, data)
+	if err != nil {
+		return nil, err
+	}
+	node := &jsonNode{offset: start}
+	switch tok {
+	case json.Delim('{'):
+		obj := &jsonObject{fields: make(map[string]*jsonNode), keyOffsets: make(map[string]int)}
+		for dec.More() {
+			keyTok, keyStart, err := nextToken(dec, data)
+			if err != nil {
+				return nil, err
+			}
+			key := keyTok.(string) // The decoder only returns strings as object keys.
+			child, err := readJSONNode(dec, data)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := obj.fields[key]; !ok {
+				obj.keys = append(obj.keys, key)
+			}
+			obj.fields[key], obj.keyOffsets[key] = child, keyStart // The last one wins, as with json.Unmarshal.
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		node.value = obj
+	case json.Delim('['):
+		var items []*jsonNode
+		for dec.More() {
+			child, err := readJSONNode(dec, data)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, child)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		node.value = items
+	default:
+		node.value = tok
+	}
+	return node, nil
+}
+
+// nextToken returns dec's next token and the offset it starts at.
+func nextToken(dec *json.Decoder, data []byte) (json.Token, int, error) {
+	prev := int(dec.InputOffset())
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, 0, err
+	}
+	offset := int(dec.InputOffset())
+	return tok, prev + len(data[prev:offset]) - len(bytes.TrimLeft(data[prev:offset], " \t\r\n:,")), nil
+}
+
+// schemaProblem is a place where a document breaks its schema.
+type schemaProblem struct {
+	offset  int
+	pointer string // JSON Pointer of the value, e.g. "/containerDefinitions/0/secrets/2/type".
+	message string
+}
+
+// String returns the problem as "<JSON pointer>: <message>".
+func (p schemaProblem) String() string {
+	if p.pointer == "" {
+		return "/: " + p.message
+	}
+	return p.pointer + ": " + p.message
+}
+
+// jsonKind returns the JSON Schema type name of a decoded value.
+func jsonKind(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []*jsonNode:
+		return "array"
+	}
+	return "object"
+}
+
+// validate returns the problems of node against s, where root resolves $ref.
+func (s *jsonSchema) validate(root *jsonSchema, node *jsonNode, pointer string) []schemaProblem {
+	if s.Ref != "" {
+		ref := root.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+		if ref == nil {
+			return []schemaProblem{{node.offset, pointer, "schema reference " + s.Ref + " not found"}}
+		}
+		s = ref
+	}
+	kind := jsonKind(node.value)
+	if len(s.Type) > 0 && !slices.Contains(s.Type, kind) {
+		return []schemaProblem{{node.offset, pointer, fmt.Sprintf("must be %s, not %s", typeList(s.Type), withArticle(kind))}}
+	}
+	var problems []schemaProblem
+	if len(s.AnyOf) > 0 {
+		var first []schemaProblem
+		for i, alt := range s.AnyOf {
+			altProblems := alt.validate(root, node, pointer)
+			if len(altProblems) == 0 {
+				first = nil
+				break
+			}
+			if i == 0 {
+				first = altProblems // When nothing matches, the first alternative is the one explained.
+			}
+		}
+		problems = append(problems, first...)
+	}
+	switch v := node.value.(type) {
+	case string:
+		if s.MinLength != nil && len([]rune(v)) < *s.MinLength {
+			msg := fmt.Sprintf("must be at least %d characters", *s.MinLength)
+			if *s.MinLength == 1 {
+				msg = "must not be empty"
+			}
+			problems = append(problems, schemaProblem{node.offset, pointer, msg})
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			allowed := strings.TrimSuffix(s.Description, ".")
+			if allowed == "" {
+				allowed = "must match " + s.Pattern
+			}
+			problems = append(problems, schemaProblem{node.offset, pointer, fmt.Sprintf("%q is invalid (%s)", v, allowed)})
+		}
+	case []*jsonNode:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			msg := fmt.Sprintf("must have at least %d items", *s.MinItems)
+			if *s.MinItems == 1 {
+				msg = "must not be empty"
+			}
+			problems = append(problems, schemaProblem{node.offset, pointer, msg})
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				problems = append(problems, s.Items.validate(root, item, fmt.Sprintf("%s/%d", pointer, i))...)
+			}
+		}
+	case *jsonObject:
+		for _, name := range s.Required {
+			if _, ok := v.fields[name]; !ok {
+				problems = append(problems, schemaProblem{node.offset, pointer, fmt.Sprintf("missing required property %q", name)})
+			}
+		}
+		for _, key := range v.keys {
+			prop, ok := s.Properties[key]
+			if !ok {
+				prop = s.AdditionalProperties
+			}
+			if prop != nil {
+				childPointer := pointer + "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+				problems = append(problems, prop.validate(root, v.fields[key], childPointer)...)
+			}
+		}
+	}
+	return problems
+}
+
+// withArticle returns a JSON type name for a message: "an object", "a string" or "null".
+func withArticle(kind string) string {
+	switch kind {
+	case "null":
+		return kind
+	case "object", "array":
+		return "an " + kind
+	}
+	return "a " + kind
+}
+
+// typeList returns JSON type names for a message: "a string", or "a string, a number or null".
+func typeList(kinds []string) string {
+	names := make([]string, len(kinds))
+	for i, kind := range kinds {
+		names[i] = withArticle(kind)
+	}
+	if len(names) == 1 {
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
+
+// validateTemplate checks template data against the embedded template schema. Every problem is listed in
+// one validation error as "<filename>:<line>: <JSON pointer>: <problem>", in file order; invalid JSON is
+// reported at the line of the syntax error.
+func validateTemplate(data []byte, filename string) error {
+	problems, err := templateSchemaProblems(data)
+	if err != nil {
+		line := 1
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line = lineAt(data, int(syntaxErr.Offset))
+		}
+		return validationErrorf("%s:%d: invalid JSON: %w", filename, line, err)
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	lines := make([]string, len(problems))
+	for i, p := range problems {
+		lines[i] = fmt.Sprintf("%s:%d: %s", filename, lineAt(data, p.offset), p)
+	}
+	return validationErrorf("%s does not match the template schema:\n  %s", filename, strings.Join(lines, "\n  "))
+}
+
+// templateSchemaProblems returns the problems of template data against the embedded schema, in file order.
+func templateSchemaProblems(data []byte) ([]schemaProblem, error) {
+	schema, err := loadTemplateSchema()
+	if err != nil {
+		return nil, err
+	}
+	node, err := parseJSONNode(data)
+	if err != nil {
+		return nil, err
+	}
+	problems := schema.validate(schema, node, "")
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].offset < problems[j].offset })
+	return problems, nil
+}
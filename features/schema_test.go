@@ -0,0 +1,103 @@
+package features
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     []string // Problem lines, without the file name; none when valid.
+	}{
+		{
+			"valid",
+			`{"family": "app", "containerDefinitions": [{"environment": null, "secrets": [
+  {"name": "A", "valueFrom": "/app/A", "type": "SECURESTRING", "value": "v", "x-note": "kept"},
+  {}
+]}]}`,
+			nil,
+		},
+		{
+			"describe-task-definition output",
+			`{"taskDefinition": {"containerDefinitions": [{"secrets": [{"name": "A", "type": "stringlist"}]}]}}`,
+			nil,
+		},
+		{
+			"problems",
+			`{"containerDefinitions": [{"secrets": [
+  {"name": "A", "type": "securestrng"},
+  {"name": "", "value": {"port": 42}},
+  "B"
+]}]}`,
+			[]string{
+				`2: /containerDefinitions/0/secrets/0/type: "securestrng" is invalid (The parameter type: string, stringlist or securestring, in any case)`,
+				`3: /containerDefinitions/0/secrets/1/name: must not be empty`,
+				`3: /containerDefinitions/0/secrets/1/value: must be a string, a number, a boolean or an array, not an object`,
+				`4: /containerDefinitions/0/secrets/2: must be an object, not a string`,
+			},
+		},
+		{
+			"no containers",
+			`{"family": "app",
+  "containerDefinitions": []}`,
+			[]string{`2: /containerDefinitions: must not be empty`},
+		},
+		{
+			"not a task definition",
+			`{"family": "app"}`,
+			[]string{`1: /: missing required property "containerDefinitions"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTemplate([]byte(tt.template), "t.json")
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("validateTemplate: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrValidation) {
+				t.Fatalf("validateTemplate error = %v; want a validation error", err)
+			}
+			lines := strings.Split(err.Error(), "\n")[1:]
+			for i := range lines {
+				lines[i] = strings.TrimPrefix(strings.TrimSpace(lines[i]), "t.json:")
+			}
+			if got, want := strings.Join(lines, "\n"), strings.Join(tt.want, "\n"); got != want {
+				t.Errorf("problems =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestValidateTemplateSyntaxError(t *testing.T) {
+	err := validateTemplate([]byte("{\n  \"containerDefinitions\": [\n    {,}\n]}"), "t.json")
+	if !errors.Is(err, ErrValidation) || !strings.HasPrefix(err.Error(), "t.json:3: invalid JSON") {
+		t.Errorf("validateTemplate error = %v; want invalid JSON on line 3", err)
+	}
+}
+
+func TestPutFromTemplateChecksSchemaFirst(t *testing.T) {
+	template := filepath.Join(t.TempDir(), "task.json")
+	data := `{"containerDefinitions": [{"secrets": [
+  {"name": "A", "valueFrom": "/app/A", "value": "a"},
+  {"name": "B", "valueFrom": "/app/B", "value": [["x"]]}
+]}]}`
+	if err := os.WriteFile(template, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeSSM()
+	err := PutParametersFromTemplate(fake, template, TemplateOptions{}, PutOptions{AssumeYes: true})
+	if !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), "task.json:3: /containerDefinitions/0/secrets/1/value/0: must be a string, a number or a boolean, not an array") {
+		t.Errorf("PutParametersFromTemplate error = %v; want the schema problem of B", err)
+	}
+	if fake.puts != 0 {
+		t.Errorf("puts = %d; want 0", fake.puts)
+	}
+}
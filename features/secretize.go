@@ -0,0 +1,143 @@
+package features
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// SecretizeOptions selects which environment entries secretize moves and where their parameters go.
+type SecretizeOptions struct {
+	Keys   []string         // Environment entries to move; empty moves the ones detected as SecureString.
+	Type   ParameterType    // Type for every moved entry; empty detects it per key as generate does.
+	Prefix string           // Parameter path prefix; the entry name is appended.
+	Refs   ReferenceOptions // valueFrom form of the new secrets.
+	// Canonical saves the task definition canonically (see canonicalTaskDef).
+	Canonical bool
+}
+
+// SecretizeTaskDef moves plaintext environment entries of the first container in a task definition into its
+// secrets: each value is put to SSM at opts.Prefix+name and the entry is replaced by a secret referencing it.
+// The rewritten task definition is saved to outputFile, which may be filename itself; other fields are kept.
+// Entries whose parameter already exists and is not overwritten stay in the environment.
+func SecretizeTaskDef(client SSMClient, filename, outputFile string, opts SecretizeOptions, putOpts PutOptions) error {
+	if err := checkNotStdin(outputFile, "the secretized task definition"); err != nil {
+		return err
+	}
+	data, err := readSource(filename)
+	if err != nil {
+		return err
+	}
+	if err := validateTemplate(data, filename); err != nil {
+		return err
+	}
+	var jsonMap map[string]interface{}
+	if err := json.Unmarshal(data, &jsonMap); err != nil {
+		return validationErrorf("failed to parse %s: %w", filename, err)
+	}
+	jsonMap = unwrapTaskDef(jsonMap)
+	containerDefs, ok := jsonMap["containerDefinitions"].([]interface{})
+	if !ok || len(containerDefs) == 0 {
+		return validationErrorf("no container definitions found")
+	}
+	containerDef, ok := containerDefs[0].(map[string]interface{})
+	if !ok {
+		return validationErrorf("invalid container definition")
+	}
+	environment, _ := containerDef["environment"].([]interface{})
+	secrets, _ := containerDef["secrets"].([]interface{})
+
+	existing := make(map[string]bool) // Names already in secrets.
+	for _, sec := range secrets {
+		if secret, ok := sec.(map[string]interface{}); ok {
+			if name, ok := secret["name"].(string); ok {
+				existing[name] = true
+			}
+		}
+	}
+	wanted := make(map[string]bool)
+	for _, key := range opts.Keys {
+		wanted[key] = true
+	}
+
+	// Select the entries to move and check them before writing anything.
+	type move struct {
+		name, value, paramName string
+		paramType              ParameterType
+	}
+	var moves []move
+	found := make(map[string]bool)
+	for _, entry := range environment {
+		env, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := env["name"].(string)
+		value, _ := env["value"].(string)
+		found[name] = true
+		paramType := opts.Type
+		if paramType == "" {
+			paramType = detectParameterType(name, value)
+		}
+		if len(wanted) > 0 && !wanted[name] || len(wanted) == 0 && paramType != SecureStringType {
+			continue
+		}
+		if existing[name] {
+			return validationErrorf("%s is in both environment and secrets", name)
+		}
+		moves = append(moves, move{name: name, value: value, paramName: opts.Prefix + name, paramType: paramType})
+	}
+	for _, key := range opts.Keys {
+		if !found[key] {
+			return validationErrorf("%s is not in the environment of %s", key, filename)
+		}
+	}
+	if len(moves) == 0 {
+		infoln("No environment entries to move; use -keys to choose them")
+		return nil
+	}
+
+	moved := make(map[string]bool)
+	for _, m := range moves {
+		err := PutParameter(client, m.paramName, m.value, m.paramType, putOpts)
+		if errors.Is(err, ErrOverwriteDeclined) {
+			Infof("%s %s: existing parameter %s not overwritten; left in environment\n", yellow("Skipped"), m.name, m.paramName)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to put %s: %w", m.name, err)
+		}
+		if isDryRun(client) {
+			Infof("Would move %s to secret %s as %s\n", m.name, m.paramName, m.paramType)
+		} else {
+			Infof("%s %s to secret %s as %s\n", green("Moved"), m.name, m.paramName, m.paramType)
+		}
+		moved[m.name] = true
+		secrets = append(secrets, map[string]interface{}{"name": m.name, "valueFrom": opts.Refs.Format(m.paramName)})
+	}
+	if isDryRun(client) || len(moved) == 0 {
+		return nil
+	}
+
+	kept := []interface{}{}
+	for _, entry := range environment {
+		if env, ok := entry.(map[string]interface{}); ok {
+			if name, _ := env["name"].(string); moved[name] {
+				continue
+			}
+		}
+		kept = append(kept, entry)
+	}
+	containerDef["environment"] = kept
+	containerDef["secrets"] = secrets
+	jsonData, err := marshalTaskDef(jsonMap, opts.Canonical)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if err := os.WriteFile(outputFile, jsonData, 0644); err != nil {
+		return fmt.Errorf("failed to write JSON file %s: %w", outputFile, err)
+	}
+	Infof("Saved task definition with %d new secrets to %s\n", len(moved), outputFile)
+	return nil
+}
@@ -0,0 +1,83 @@
+package features
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestSecretizeTaskDef(t *testing.T) {
+	dir := t.TempDir()
+	template := filepath.Join(dir, "task.json")
+	data := `{
+  "family": "app",
+  "containerDefinitions": [{
+    "name": "web",
+    "environment": [
+      {"name": "LOG_LEVEL", "value": "info"},
+      {"name": "DB_PASSWORD", "value": "hunter2"},
+      {"name": "SERVICE_URL", "value": "https://svc"}
+    ],
+    "secrets": [{"name": "EXISTING", "valueFrom": "/app/EXISTING"}]
+  }]
+}`
+	if err := os.WriteFile(template, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeSSM()
+	opts := SecretizeOptions{Prefix: "/app/"}
+
+	err := SecretizeTaskDef(fake, template, template, SecretizeOptions{Prefix: "/app/", Keys: []string{"MISSING"}}, PutOptions{})
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("secretize of a missing key error = %v; want ErrValidation", err)
+	}
+
+	// Without -keys only the entries that look like secrets move.
+	if err := SecretizeTaskDef(fake, template, template, opts, PutOptions{}); err != nil {
+		t.Fatalf("secretize: %v", err)
+	}
+	if got := fake.params["/app/DB_PASSWORD"]; *got.Value != "hunter2" || got.Type != types.ParameterTypeSecureString {
+		t.Errorf("/app/DB_PASSWORD = %q (%s); want %q (SecureString)", *got.Value, got.Type, "hunter2")
+	}
+
+	opts.Keys = []string{"SERVICE_URL"}
+	opts.Type = StringType
+	output := filepath.Join(dir, "out.json")
+	if err := SecretizeTaskDef(fake, template, output, opts, PutOptions{}); err != nil {
+		t.Fatalf("secretize -keys: %v", err)
+	}
+	out, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var taskDef struct {
+		Family               string                `json:"family"`
+		ContainerDefinitions []ContainerDefinition `json:"containerDefinitions"`
+	}
+	if err := json.Unmarshal(out, &taskDef); err != nil {
+		t.Fatal(err)
+	}
+	if taskDef.Family != "app" {
+		t.Errorf("family = %q; other fields must be kept", taskDef.Family)
+	}
+	container := taskDef.ContainerDefinitions[0]
+	if want := []Environment{{Name: "LOG_LEVEL", Value: "info"}}; !reflect.DeepEqual(container.Environment, want) {
+		t.Errorf("environment = %v; want %v", container.Environment, want)
+	}
+	want := []ExtendedSecret{
+		{Name: "EXISTING", ValueFrom: "/app/EXISTING"},
+		{Name: "DB_PASSWORD", ValueFrom: "/app/DB_PASSWORD"},
+		{Name: "SERVICE_URL", ValueFrom: "/app/SERVICE_URL"},
+	}
+	if !reflect.DeepEqual(container.Secrets, want) {
+		t.Errorf("secrets = %v; want %v", container.Secrets, want)
+	}
+	if got := fake.params["/app/SERVICE_URL"].Type; got != types.ParameterTypeString {
+		t.Errorf("/app/SERVICE_URL type = %s; want String", got)
+	}
+}
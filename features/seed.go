@@ -0,0 +1,184 @@
+package features
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// SeedOptions configures seed.
+type SeedOptions struct {
+	Defaults       string       // File with values for missing parameters, in any import format; empty prompts for all.
+	DefaultsFormat string       // Input format of Defaults; empty detects it from the file name.
+	Put            PutOptions   // KeyID and Tier of the puts; parameters are always created, never overwritten.
+	Input          InputOptions // How Defaults is read.
+}
+
+// seedItem is a parameter a template references that does not exist yet.
+type seedItem struct {
+	secret    ExtendedSecret
+	paramName string
+	paramType ParameterType // Empty when the template has no type: detected from the name and value.
+	value     string
+	opts      PutOptions // With the KMS key, tier, description and tags of the secret.
+}
+
+// SeedParameters finds the parameters the secrets of a template reference that do not exist, and creates
+// them in one pass once every value is known. A value comes from opts.Defaults (by secret name, or by
+// parameter name for formats that carry it), otherwise it is asked for on the terminal, hidden for
+// SecureStrings, with the secret's "default" offered; an empty answer leaves the parameter out. Existing
+// parameters are never read or overwritten.
+func SeedParameters(client SSMClient, filename string, tmplOpts TemplateOptions, opts SeedOptions) error {
+	secrets, err := readTemplateSecrets(filename, tmplOpts)
+	if err != nil {
+		return err
+	}
+	var defaults []InputParam
+	if opts.Defaults != "" {
+		if defaults, err = readInput(opts.Defaults, opts.DefaultsFormat, opts.Input); err != nil {
+			return err
+		}
+	}
+
+	var missing []*seedItem
+	seen := make(map[string]bool)
+	for _, secret := range secrets {
+		paramName, err := templateTarget(secret, tmplOpts)
+		if err != nil {
+			return err
+		}
+		if seen[paramName] {
+			continue // Two secrets reading one parameter.
+		}
+		seen[paramName] = true
+		_, _, err = GetParameter(client, paramName)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		item := &seedItem{secret: secret, paramName: paramName}
+		if secret.Type != "" {
+			item.paramType = templateType(secret)
+		}
+		missing = append(missing, item)
+	}
+	if len(missing) == 0 {
+		Infof("All %d parameters of %s exist\n", len(seen), filename)
+		return nil
+	}
+	Infof("%d of %d parameters of %s do not exist\n", len(missing), len(seen), filename)
+
+	// Collect every value first, so an interrupted session creates nothing.
+	var puts []*seedItem
+	for _, item := range missing {
+		value, ok := seedDefault(defaults, item)
+		if !ok {
+			if value, err = promptSeedValue(item); err != nil {
+				return err
+			}
+		}
+		if value == "" {
+			Infof("%s %s: no value\n", yellow("Skipped"), item.paramName)
+			continue
+		}
+		item.value = value
+		if item.paramType == "" {
+			item.paramType = detectParameterType(item.secret.Name, value)
+		}
+		if item.opts, err = secretPutOptions(item.secret, opts.Put); err != nil {
+			return err
+		}
+		if err := validatePut(item.paramName, value, item.paramType, item.opts.Tier); err != nil {
+			return fmt.Errorf("secret %s: %w", item.secret.Name, err)
+		}
+		if err := validateMetadata(item.paramName, item.opts.Description, item.opts.Tags); err != nil {
+			return fmt.Errorf("secret %s: %w", item.secret.Name, err)
+		}
+		item.opts.NoOverwrite = true // Created since the check by someone else: theirs wins.
+		puts = append(puts, item)
+	}
+
+	var failures []error
+	for _, item := range puts {
+		err := PutParameter(client, item.paramName, item.value, item.paramType, item.opts)
+		switch {
+		case errors.Is(err, ErrOverwriteDeclined):
+			Infof("%s %s: created by someone else meanwhile\n", yellow("Skipped"), item.paramName)
+		case err != nil:
+			Infof("%s %s: %s\n", red("Failed to create"), item.paramName, DescribeError(err))
+			failures = append(failures, fmt.Errorf("%s: %w", item.paramName, err))
+		case isDryRun(client):
+			Infof("Would create %s as %s\n", item.paramName, item.paramType)
+		default:
+			Infof("%s %s as %s\n", green("Created"), item.paramName, item.paramType)
+		}
+	}
+	if len(failures) > 0 {
+		return &PartialFailureError{Failed: failures, Total: len(puts)}
+	}
+	return nil
+}
+
+// seedDefault returns the value opts.Defaults holds for item, and its type when the file sets one.
+func seedDefault(defaults []InputParam, item *seedItem) (string, bool) {
+	for _, p := range defaults {
+		if p.Key == item.secret.Name || p.Name != "" && p.Name == item.paramName {
+			if item.paramType == "" && p.Type != "" {
+				item.paramType = p.Type
+			}
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// promptSeedValue asks for the value of item on stderr. Input is hidden when standard input is a terminal
+// and the parameter is (or, untyped, looks like) a SecureString; Enter accepts the secret's default.
+func promptSeedValue(item *seedItem) (string, error) {
+	if stdinSource.read {
+		return "", validationErrorf("no value for %s: standard input was read as -s; use -defaults", item.paramName)
+	}
+	paramType := item.paramType
+	if paramType == "" {
+		paramType = detectParameterType(item.secret.Name, "")
+	}
+	hidden := paramType == SecureStringType
+	prompt := fmt.Sprintf("%s (%s, %s)", item.secret.Name, item.paramName, paramType)
+	if item.secret.Default != "" {
+		shown := item.secret.Default
+		if hidden {
+			shown = maskValue(shown)
+		}
+		prompt += " [" + shown + "]"
+	}
+	fmt.Fprintf(os.Stderr, "%s: ", prompt)
+
+	var value string
+	if fd := int(os.Stdin.Fd()); hidden && term.IsTerminal(fd) {
+		data, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the value of %s: %w", item.paramName, err)
+		}
+		value = string(data)
+	} else {
+		line, err := stdinReader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf("failed to read the value of %s: %w", item.paramName, err)
+		}
+		if err == io.EOF && line == "" {
+			fmt.Fprintln(os.Stderr) // End of input: the default, if any.
+		}
+		value = strings.TrimRight(line, "\r\n")
+	}
+	if value == "" {
+		value = item.secret.Default
+	}
+	return value, nil
+}
@@ -0,0 +1,56 @@
+package features
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestSeedParameters(t *testing.T) {
+	dir := t.TempDir()
+	template := filepath.Join(dir, "task.json")
+	data := `{"containerDefinitions": [{"secrets": [
+  {"name": "DB_URL", "valueFrom": "/staging/app/DB_URL"},
+  {"name": "API_TOKEN", "valueFrom": "/staging/app/API_TOKEN"},
+  {"name": "LOG_LEVEL", "valueFrom": "/staging/app/LOG_LEVEL", "type": "String", "default": "info"},
+  {"name": "FEATURE", "valueFrom": "/staging/app/FEATURE"},
+  {"name": "EXISTING", "valueFrom": "/staging/app/EXISTING"}
+]}]}`
+	defaults := filepath.Join(dir, "staging.env")
+	for name, content := range map[string]string{template: data, defaults: "DB_URL=postgres://staging-db\n"} {
+		if err := os.WriteFile(name, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fake := newFakeSSM()
+	fake.set("/staging/app/EXISTING", "keep", types.ParameterTypeString)
+
+	// API_TOKEN is typed in, LOG_LEVEL takes its default and FEATURE is skipped.
+	withStdin(t, "t0k3n\n\n\n")
+	if err := SeedParameters(fake, template, TemplateOptions{}, SeedOptions{Defaults: defaults}); err != nil {
+		t.Fatalf("SeedParameters: %v", err)
+	}
+	want := map[string]struct {
+		value string
+		typ   types.ParameterType
+	}{
+		"/staging/app/DB_URL":    {"postgres://staging-db", types.ParameterTypeString},
+		"/staging/app/API_TOKEN": {"t0k3n", types.ParameterTypeSecureString},
+		"/staging/app/LOG_LEVEL": {"info", types.ParameterTypeString},
+		"/staging/app/EXISTING":  {"keep", types.ParameterTypeString},
+	}
+	if len(fake.params) != len(want) {
+		t.Errorf("parameters = %v; want %d", fake.params, len(want))
+	}
+	for name, w := range want {
+		if p := fake.params[name]; aws.ToString(p.Value) != w.value || p.Type != w.typ {
+			t.Errorf("%s = %q (%s); want %q (%s)", name, aws.ToString(p.Value), p.Type, w.value, w.typ)
+		}
+	}
+	if fake.puts != 3 {
+		t.Errorf("%d puts; want 3", fake.puts)
+	}
+}
@@ -0,0 +1,264 @@
+package features
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BuildInfo describes the running binary. The CLI fills it from variables set with -ldflags "-X ..."
+// (see the Makefile); go build without them gives version "dev".
+type BuildInfo struct {
+	Version string // Release tag such as v1.4.0, or "dev".
+	Commit  string // Short commit hash.
+	Date    string // Build time, RFC 3339.
+}
+
+// String returns the version line printed by -action version.
+func (b BuildInfo) String() string {
+	return fmt.Sprintf("salter-aws %s (commit %s, built %s, %s %s/%s)", b.Version, b.Commit, b.Date, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
+
+// Where self-update looks for releases, and the assets it expects in one, as written by make release.
+const (
+	DefaultRepo    = "jahrulnr/parameter-store-tools"
+	ChecksumsAsset = "checksums.txt" // sha256sum output for every binary of the release.
+	GitHubTokenEnv = "GITHUB_TOKEN"  // Optional token for the GitHub API, against its rate limit for anonymous calls.
+)
+
+const (
+	githubAPI     = "https://api.github.com"
+	maxBinarySize = 256 << 20 // Largest binary download accepted.
+)
+
+// SelfUpdateOptions configures SelfUpdate.
+type SelfUpdateOptions struct {
+	Current BuildInfo
+	Repo    string // GitHub owner/name; DefaultRepo when empty.
+	APIURL  string // GitHub API base URL; https://api.github.com when empty.
+	Target  string // Binary to replace; the running executable when empty.
+	DryRun  bool   // Only report whether a newer release exists.
+	Client  *http.Client
+}
+
+// githubRelease is the part of a GitHub release self-update reads.
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// ReleaseAssetName is the name of the binary for goos and goarch in a release, e.g. salter-aws-linux-amd64.
+func ReleaseAssetName(goos, goarch string) string {
+	name := "salter-aws-" + goos + "-" + goarch
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// SelfUpdate replaces the binary with the one of the latest GitHub release for this platform when that
+// release is newer than opts.Current (dev builds are always older). The download must match its SHA-256
+// in the release's checksums.txt, or nothing is replaced. The new binary is written next to the old one and
+// renamed over it; on Windows, where a running executable cannot be replaced, the old one is kept as .old.
+func SelfUpdate(opts SelfUpdateOptions) error {
+	if opts.Repo == "" {
+		opts.Repo = DefaultRepo
+	}
+	if opts.APIURL == "" {
+		opts.APIURL = githubAPI
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 5 * time.Minute}
+	}
+	var release githubRelease
+	body, err := githubGet(opts, strings.TrimSuffix(opts.APIURL, "/")+"/repos/"+opts.Repo+"/releases/latest", 1<<20)
+	if err != nil {
+		return fmt.Errorf("failed to look up the latest release of %s: %w", opts.Repo, err)
+	}
+	if err := json.Unmarshal(body, &release); err != nil || release.TagName == "" {
+		return fmt.Errorf("unexpected release data from %s: %v", opts.APIURL, err)
+	}
+	if !newerVersion(release.TagName, opts.Current.Version) {
+		Infof("salter-aws %s is up to date (latest release %s)\n", opts.Current.Version, release.TagName)
+		return nil
+	}
+	if opts.DryRun {
+		Infof("Would update salter-aws %s to %s\n", opts.Current.Version, release.TagName)
+		return nil
+	}
+
+	asset := ReleaseAssetName(runtime.GOOS, runtime.GOARCH)
+	urls := make(map[string]string)
+	for _, a := range release.Assets {
+		urls[a.Name] = a.URL
+	}
+	if urls[asset] == "" {
+		return validationErrorf("release %s has no binary %s for this platform", release.TagName, asset)
+	}
+	if urls[ChecksumsAsset] == "" {
+		return validationErrorf("release %s has no %s; refusing to install an unverified binary", release.TagName, ChecksumsAsset)
+	}
+	sums, err := githubGet(opts, urls[ChecksumsAsset], 1<<20)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", ChecksumsAsset, err)
+	}
+	want, err := assetChecksum(sums, asset)
+	if err != nil {
+		return err
+	}
+	binary, err := githubGet(opts, urls[asset], maxBinarySize)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", asset, err)
+	}
+	if sum := sha256.Sum256(binary); hex.EncodeToString(sum[:]) != want {
+		return validationErrorf("checksum mismatch for %s: got %s, %s lists %s", asset, hex.EncodeToString(sum[:]), ChecksumsAsset, want)
+	}
+
+	target := opts.Target
+	if target == "" {
+		if target, err = os.Executable(); err != nil {
+			return fmt.Errorf("failed to find the running binary: %w", err)
+		}
+	}
+	if resolved, err := filepath.EvalSymlinks(target); err == nil {
+		target = resolved // Replace the binary, not the symlink to it (as Homebrew installs).
+	}
+	if err := replaceBinary(target, binary); err != nil {
+		return err
+	}
+	Infof("%s salter-aws %s to %s (%s)\n", green("Updated"), opts.Current.Version, release.TagName, target)
+	return nil
+}
+
+// githubGet fetches url, reading at most limit bytes.
+func githubGet(opts SelfUpdateOptions, url string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "salter-aws-self-update")
+	if strings.HasPrefix(url, opts.APIURL) {
+		req.Header.Set("Accept", "application/vnd.github+json")
+		if token := os.Getenv(GitHubTokenEnv); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("GET %s: response larger than %d bytes", url, limit)
+	}
+	return data, nil
+}
+
+// assetChecksum returns the SHA-256 sums (sha256sum format, "<hex>  <name>" or "<hex> *<name>") lists for asset.
+func assetChecksum(sums []byte, asset string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == asset && len(fields[0]) == 64 {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", validationErrorf("%s lists no checksum for %s", ChecksumsAsset, asset)
+}
+
+// replaceBinary writes data next to target and renames it over target, keeping target's permissions.
+func replaceBinary(target string, data []byte) error {
+	mode := os.FileMode(0755)
+	if info, err := os.Stat(target); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".salter-aws-update-*")
+	if err != nil {
+		return fmt.Errorf("failed to write next to %s: %w", target, err)
+	}
+	defer os.Remove(tmp.Name()) // After a successful rename there is nothing left to remove.
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	if runtime.GOOS == "windows" {
+		old := target + ".old"
+		os.Remove(old)
+		if err := os.Rename(target, old); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to move %s aside: %w", target, err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", target, err)
+	}
+	return nil
+}
+
+// newerVersion reports whether release (a tag such as v1.4.0) is newer than current. A current version
+// that is not a release, such as "dev", is older than every release.
+func newerVersion(release, current string) bool {
+	r, ok := parseVersion(release)
+	if !ok {
+		return false
+	}
+	c, ok := parseVersion(current)
+	if !ok {
+		return true
+	}
+	for i := range r {
+		if r[i] != c[i] {
+			return r[i] > c[i]
+		}
+	}
+	return false
+}
+
+// parseVersion parses major.minor.patch with an optional leading "v"; pre-release and build suffixes
+// ("-rc1", "+meta") and the "-N-gHASH" of git describe are ignored.
+func parseVersion(s string) ([3]int, bool) {
+	var v [3]int
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return v, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		v[i] = n
+	}
+	return v, true
+}
@@ -0,0 +1,111 @@
+package features
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// releaseServer serves a latest release with tag whose binary for this platform is binary and whose
+// checksums.txt lists sum for it.
+func releaseServer(t *testing.T, tag string, binary []byte, sum string) *httptest.Server {
+	t.Helper()
+	asset := ReleaseAssetName(runtime.GOOS, runtime.GOARCH)
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	mux.HandleFunc("/repos/acme/tool/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		release := map[string]any{
+			"tag_name": tag,
+			"assets": []map[string]string{
+				{"name": asset, "browser_download_url": srv.URL + "/download/" + asset},
+				{"name": ChecksumsAsset, "browser_download_url": srv.URL + "/download/" + ChecksumsAsset},
+			},
+		}
+		json.NewEncoder(w).Encode(release)
+	})
+	mux.HandleFunc("/download/"+asset, func(w http.ResponseWriter, r *http.Request) { w.Write(binary) })
+	mux.HandleFunc("/download/"+ChecksumsAsset, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s  salter-aws-plan9-386\n%s  %s\n", sum, sum, asset)
+	})
+	return srv
+}
+
+func TestSelfUpdate(t *testing.T) {
+	binary := []byte("#!/bin/sh\necho new\n")
+	sum := sha256.Sum256(binary)
+	target := filepath.Join(t.TempDir(), "salter-aws")
+	if err := os.WriteFile(target, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := releaseServer(t, "v1.5.0", binary, hex.EncodeToString(sum[:]))
+	opts := SelfUpdateOptions{Current: BuildInfo{Version: "v1.4.2"}, Repo: "acme/tool", APIURL: srv.URL, Target: target}
+	dryRun := opts
+	dryRun.DryRun = true
+	if err := SelfUpdate(dryRun); err != nil {
+		t.Fatalf("SelfUpdate dry run: %v", err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "old" {
+		t.Fatal("dry run replaced the binary")
+	}
+	if err := SelfUpdate(opts); err != nil {
+		t.Fatalf("SelfUpdate: %v", err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != string(binary) {
+		t.Errorf("binary = %q; want the release", data)
+	}
+
+	// Up to date: nothing is downloaded.
+	os.WriteFile(target, []byte("current"), 0755)
+	opts.Current.Version = "v1.5.0"
+	if err := SelfUpdate(opts); err != nil {
+		t.Fatalf("SelfUpdate when up to date: %v", err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "current" {
+		t.Error("an up-to-date binary was replaced")
+	}
+}
+
+func TestSelfUpdateChecksumMismatch(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "salter-aws")
+	if err := os.WriteFile(target, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	other := sha256.Sum256([]byte("something else"))
+	srv := releaseServer(t, "v2.0.0", []byte("tampered"), hex.EncodeToString(other[:]))
+	err := SelfUpdate(SelfUpdateOptions{Current: BuildInfo{Version: "dev"}, Repo: "acme/tool", APIURL: srv.URL, Target: target})
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("SelfUpdate with a bad checksum: %v; want a validation error", err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "old" {
+		t.Error("binary replaced despite the checksum mismatch")
+	}
+}
+
+func TestNewerVersion(t *testing.T) {
+	for _, tt := range []struct {
+		release, current string
+		want             bool
+	}{
+		{"v1.5.0", "v1.4.9", true},
+		{"v1.10.0", "v1.9.0", true},
+		{"v1.4.0", "v1.4.0", false},
+		{"v1.4.0", "v1.4.0-3-g1a2b3c4-dirty", false},
+		{"v1.3.0", "v1.4.0", false},
+		{"v1.4.0", "dev", true},
+		{"nightly", "v1.0.0", false},
+	} {
+		if got := newerVersion(tt.release, tt.current); got != tt.want {
+			t.Errorf("newerVersion(%q, %q) = %t; want %t", tt.release, tt.current, got, tt.want)
+		}
+	}
+}
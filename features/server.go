@@ -0,0 +1,343 @@
+package features
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// ServerTokenEnv is the environment variable holding the bearer token for serve mode.
+const ServerTokenEnv = "PARAM_STORE_SERVER_TOKEN"
+
+// ServerOptions configures serve and agent mode.
+type ServerOptions struct {
+	Listen   string        // TCP address to listen on, e.g. "127.0.0.1:8099".
+	Socket   string        // Unix socket path to listen on instead of Listen (agent mode).
+	Token    string        // Bearer token clients must send; generated when empty, unless serving on Socket.
+	CacheTTL time.Duration // How long SSM responses are reused; 0 disables caching.
+}
+
+// ParameterResponse is the JSON form of one parameter in the HTTP API.
+type ParameterResponse struct {
+	Name    string        `json:"name"`
+	Value   string        `json:"value"`
+	Type    ParameterType `json:"type"`
+	Version int64         `json:"version"`
+}
+
+// Server exposes parameters over a small authenticated HTTP/JSON API:
+//
+//	GET /v1/parameter?name=/app/KEY   one parameter
+//	GET /v1/parameters?prefix=/app/   every parameter under a prefix
+//	GET /v1/env?prefix=/app/          the prefix rendered as KEY=value lines, quoted as for systemd
+//	GET /metrics                      Prometheus metrics
+//	GET /healthz                      liveness, no authentication
+type Server struct {
+	client  SSMClient
+	opts    ServerOptions
+	cache   *ttlCache
+	metrics *Metrics
+}
+
+// NewServer returns a Server backed by client. If opts.Token is empty a random token is generated,
+// except on a unix socket, where the 0600 socket file permissions authenticate callers instead.
+func NewServer(client SSMClient, opts ServerOptions) (*Server, error) {
+	if opts.Token == "" && opts.Socket == "" {
+		buf := make([]byte, 24)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("failed to generate token: %w", err)
+		}
+		opts.Token = hex.EncodeToString(buf)
+	}
+	metrics := NewMetrics()
+	return &Server{
+		client:  NewMetricsClient(client, metrics),
+		opts:    opts,
+		cache:   newTTLCache(opts.CacheTTL, metrics),
+		metrics: metrics,
+	}, nil
+}
+
+// Token returns the bearer token clients must present.
+func (s *Server) Token() string {
+	return s.opts.Token
+}
+
+// Handler returns the HTTP handler with authentication and audit logging applied.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.Handle("/v1/parameter", s.authenticated(s.handleParameter))
+	mux.Handle("/v1/parameters", s.authenticated(s.handleParameters))
+	mux.Handle("/v1/env", s.authenticated(s.handleEnv))
+	mux.Handle("/metrics", s.authenticated(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		s.metrics.WritePrometheus(w)
+	}))
+	return auditLog(mux)
+}
+
+// ListenAndServe serves the API on the TCP address or unix socket until ctx is cancelled.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	srv := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	var listener net.Listener
+	var err error
+	if s.opts.Socket != "" {
+		listener, err = listenUnix(s.opts.Socket)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(s.opts.Socket)
+	} else {
+		listener, err = net.Listen("tcp", s.opts.Listen)
+		if err != nil {
+			return err
+		}
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// listenUnix listens on a unix socket only the current user can connect to, replacing a stale socket file.
+// The socket is created inside a 0700 directory so it is never reachable by others, even before the chmod.
+func listenUnix(path string) (net.Listener, error) {
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("an agent is already listening on %s", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	os.Remove(path)
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// authenticated rejects requests without the expected bearer token.
+func (s *Server) authenticated(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, bearer := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if s.opts.Token != "" && (!bearer || subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) != 1) {
+			writeJSONError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+		if r.Method != http.MethodGet {
+			writeJSONError(w, http.StatusMethodNotAllowed, "only GET is supported")
+			return
+		}
+		next(w, r)
+	})
+}
+
+func (s *Server) handleParameter(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		writeJSONError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	resp, err := s.cache.get("parameter:"+name, func() (interface{}, error) {
+		value, paramType, version, err := getParameterWithVersion(s.client, name)
+		if err != nil {
+			return nil, err
+		}
+		return ParameterResponse{Name: name, Value: value, Type: paramType, Version: version}, nil
+	})
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, resp)
+}
+
+func (s *Server) handleParameters(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	if prefix == "" {
+		writeJSONError(w, http.StatusBadRequest, "prefix is required")
+		return
+	}
+	params, err := s.listCached(prefix)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	resp := struct {
+		Parameters []ParameterResponse `json:"parameters"`
+	}{Parameters: []ParameterResponse{}}
+	for _, param := range params {
+		resp.Parameters = append(resp.Parameters, ParameterResponse{
+			Name:    aws.ToString(param.Name),
+			Value:   aws.ToString(param.Value),
+			Type:    ParameterType(param.Type),
+			Version: param.Version,
+		})
+	}
+	writeJSON(w, resp)
+}
+
+func (s *Server) handleEnv(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	if prefix == "" {
+		writeJSONError(w, http.StatusBadRequest, "prefix is required")
+		return
+	}
+	params, err := s.listCached(prefix)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	// Values are quoted as for systemd, so newlines, "#" and quotes cannot break or add lines.
+	vars := make([]EnvVar, 0, len(params))
+	for _, param := range params {
+		key := strings.TrimPrefix(aws.ToString(param.Name), prefix)
+		if !envNamePattern.MatchString(key) {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("%q is not a valid variable name (use /v1/parameters for nested names)", key))
+			return
+		}
+		vars = append(vars, EnvVar{Key: key, Value: aws.ToString(param.Value)})
+	}
+	body, err := RenderEnv(FormatSystemd, vars)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, body)
+}
+
+// listCached lists prefix through the response cache.
+func (s *Server) listCached(prefix string) ([]types.Parameter, error) {
+	result, err := s.cache.get("prefix:"+prefix, func() (interface{}, error) {
+		params, err := listParameters(s.client, prefix)
+		s.metrics.recordSync(prefix, err)
+		return params, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]types.Parameter), nil
+}
+
+// auditLog logs every request without query values, so secrets and tokens never reach the log.
+func auditLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(rec, r)
+		log.Printf("audit remote=%s method=%s path=%s name=%q prefix=%q status=%d duration=%s",
+			r.RemoteAddr, r.Method, r.URL.Path, r.URL.Query().Get("name"), r.URL.Query().Get("prefix"), rec.status, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+// statusRecorder captures the response status for the audit log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+// writeAPIError maps an error kind to an HTTP status.
+func writeAPIError(w http.ResponseWriter, err error) {
+	status := http.StatusBadGateway
+	switch {
+	case errors.Is(err, ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrAccessDenied):
+		status = http.StatusForbidden
+	case errors.Is(err, ErrThrottled):
+		status = http.StatusTooManyRequests
+	case errors.Is(err, ErrValidation):
+		status = http.StatusBadRequest
+	}
+	writeJSONError(w, status, err.Error())
+}
+
+// ttlCache memoizes successful lookups for a fixed time.
+type ttlCache struct {
+	ttl     time.Duration
+	metrics *Metrics // Counts hits and misses; may be nil.
+	mu      sync.Mutex
+	entries map[string]ttlEntry
+}
+
+type ttlEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+func newTTLCache(ttl time.Duration, metrics *Metrics) *ttlCache {
+	return &ttlCache{ttl: ttl, metrics: metrics, entries: make(map[string]ttlEntry)}
+}
+
+// get returns the cached value for key, or calls load and caches its result if it succeeds.
+func (c *ttlCache) get(key string, load func() (interface{}, error)) (interface{}, error) {
+	if c.ttl > 0 {
+		c.mu.Lock()
+		entry, ok := c.entries[key]
+		c.mu.Unlock()
+		hit := ok && time.Now().Before(entry.expires)
+		if c.metrics != nil {
+			c.metrics.recordCache(hit)
+		}
+		if hit {
+			return entry.value, nil
+		}
+	}
+	value, err := load()
+	if err != nil || c.ttl <= 0 {
+		return value, err
+	}
+	c.mu.Lock()
+	c.entries[key] = ttlEntry{value: value, expires: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+	return value, nil
+}
@@ -0,0 +1,162 @@
+package features
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestServerAPI(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/dev/app/DB_URL", "postgres://db", types.ParameterTypeString)
+	fake.set("/dev/app/API_KEY", "secret", types.ParameterTypeSecureString)
+	server, err := NewServer(fake, ServerOptions{Token: "t0k", CacheTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	get := func(path, token string) (int, string) {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	if status, _ := get("/v1/parameter?name=/dev/app/DB_URL", ""); status != http.StatusUnauthorized {
+		t.Errorf("unauthenticated status = %d; want 401", status)
+	}
+	for _, header := range []string{"t0k", "Basic t0k"} {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/v1/parameter?name=/dev/app/DB_URL", nil)
+		req.Header.Set("Authorization", header)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Authorization %q status = %d; want 401", header, resp.StatusCode)
+		}
+	}
+	status, body := get("/v1/parameter?name=/dev/app/DB_URL", "t0k")
+	var param ParameterResponse
+	if status != http.StatusOK || json.Unmarshal([]byte(body), &param) != nil || param.Value != "postgres://db" {
+		t.Errorf("parameter = %d %s", status, body)
+	}
+	if status, _ := get("/v1/parameter?name=/dev/app/MISSING", "t0k"); status != http.StatusNotFound {
+		t.Errorf("missing status = %d; want 404", status)
+	}
+	status, body = get("/v1/env?prefix=/dev/app/", "t0k")
+	if want := "API_KEY=secret\nDB_URL=postgres://db\n"; status != http.StatusOK || body != want {
+		t.Errorf("env = %d %q; want %q", status, body, want)
+	}
+	if status, _ := get("/healthz", ""); status != http.StatusOK {
+		t.Errorf("healthz status = %d", status)
+	}
+
+	get("/v1/env?prefix=/dev/app/", "t0k") // Served from the cache.
+	status, body = get("/metrics", "t0k")
+	for _, want := range []string{
+		`salter_aws_api_calls_total{operation="GetParameter",outcome="error"} 1`,
+		`salter_aws_api_calls_total{operation="GetParametersByPath",outcome="ok"} 1`,
+		`salter_aws_cache_requests_total{result="hit"} 1`,
+		`salter_aws_last_sync_timestamp_seconds{prefix="/dev/app/"}`,
+	} {
+		if status != http.StatusOK || !strings.Contains(body, want) {
+			t.Errorf("metrics missing %s:\n%s", want, body)
+		}
+	}
+}
+
+func TestServerEnvQuoting(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/dev/app/CERT", "-----BEGIN CERTIFICATE-----\nINJECTED=1\n-----END CERTIFICATE-----", types.ParameterTypeSecureString)
+	fake.set("/dev/app/NOTE", "a # b", types.ParameterTypeString)
+	fake.set("/dev/app/QUOTE", `say "hi" for $5`, types.ParameterTypeString)
+	fake.set("/dev/nested/db/HOST", "db", types.ParameterTypeString)
+	server, err := NewServer(fake, ServerOptions{Token: "t0k"})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+	get := func(path string) (int, string) {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+path, nil)
+		req.Header.Set("Authorization", "Bearer t0k")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	status, body := get("/v1/env?prefix=/dev/app/")
+	want := "CERT=\"-----BEGIN CERTIFICATE-----\nINJECTED=1\n-----END CERTIFICATE-----\"\n" +
+		"NOTE=\"a # b\"\n" +
+		`QUOTE="say \"hi\" for \$5"` + "\n"
+	if status != http.StatusOK || body != want {
+		t.Errorf("env = %d\n%s\nwant\n%s", status, body, want)
+	}
+	if status, body := get("/v1/env?prefix=/dev/nested/"); status != http.StatusBadRequest {
+		t.Errorf("env of nested names = %d %s; want 400", status, body)
+	}
+}
+
+func TestAgentSocket(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/dev/app/DB_URL", "postgres://db", types.ParameterTypeString)
+	dir, err := os.MkdirTemp("", "agent") // Short path: socket paths are limited to ~100 bytes.
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "run", "agent.sock")
+	server, err := NewServer(fake, ServerOptions{Socket: socket})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- server.ListenAndServe(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	var value string
+	for i := 0; i < 50; i++ {
+		if value, err = AgentGet(socket, "/dev/app/DB_URL"); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil || value != "postgres://db" {
+		t.Fatalf("AgentGet = %q, %v", value, err)
+	}
+	if info, err := os.Stat(socket); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("socket mode = %v, %v; want 0600", info.Mode().Perm(), err)
+	}
+	if _, err := AgentGet(socket, "/dev/app/MISSING"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing err = %v; want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,95 @@
+package features
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// ParseAge parses an age such as 180d, 2w or 36h: a number of days or weeks, or a Go duration.
+func ParseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if number, ok := strings.CutSuffix(s, suffix); ok {
+			n, err := strconv.Atoi(number)
+			if err != nil || n <= 0 {
+				return 0, validationErrorf("invalid age %q: want a positive number of days or weeks, such as 180d", s)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, validationErrorf("invalid age %q: want a positive age such as 180d, 2w or 36h", s)
+	}
+	return d, nil
+}
+
+// staleParameters returns the metadata of the parameters under prefix last modified before cutoff,
+// oldest first.
+func staleParameters(client SSMClient, prefix string, cutoff time.Time) ([]types.ParameterMetadata, error) {
+	metadata, err := describeParameters(client, types.ParameterStringFilter{
+		Key:    aws.String("Path"),
+		Option: aws.String("Recursive"),
+		Values: []string{strings.TrimSuffix(prefix, "/")},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var stale []types.ParameterMetadata
+	for _, meta := range metadata {
+		if meta.LastModifiedDate != nil && meta.LastModifiedDate.Before(cutoff) {
+			stale = append(stale, meta)
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool {
+		if !stale[i].LastModifiedDate.Equal(*stale[j].LastModifiedDate) {
+			return stale[i].LastModifiedDate.Before(*stale[j].LastModifiedDate)
+		}
+		return aws.ToString(stale[i].Name) < aws.ToString(stale[j].Name)
+	})
+	return stale, nil
+}
+
+// ListStale prints the parameters under prefix not modified for olderThan, oldest first, with their
+// last-modified date, age in days, version and the IAM identity that last modified them, for cleanup and
+// rotation reviews. Only metadata is read, so no values are decrypted.
+func ListStale(client SSMClient, prefix string, olderThan time.Duration) error {
+	if !strings.HasPrefix(prefix, "/") {
+		return validationErrorf("-prefix must be a path starting with /, got %q", prefix)
+	}
+	now := time.Now()
+	stale, err := staleParameters(client, prefix, now.Add(-olderThan))
+	if err != nil {
+		return err
+	}
+	if len(stale) == 0 {
+		fmt.Printf("No parameters under %s are older than %s\n", prefix, formatAge(olderThan))
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tLAST MODIFIED\tAGE\tVERSION\tLAST MODIFIED BY")
+	for _, meta := range stale {
+		modified := aws.ToTime(meta.LastModifiedDate)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", aws.ToString(meta.Name), modified.UTC().Format("2006-01-02"), formatAge(now.Sub(modified)), meta.Version, aws.ToString(meta.LastModifiedUser))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("%s %d parameters under %s not modified for %s\n", yellow("Stale:"), len(stale), prefix, formatAge(olderThan))
+	return nil
+}
+
+// formatAge prints d in whole days, or as a duration when it is shorter than a day.
+func formatAge(d time.Duration) string {
+	if d < 24*time.Hour {
+		return d.Round(time.Minute).String()
+	}
+	return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+}
@@ -0,0 +1,46 @@
+package features
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestParseAge(t *testing.T) {
+	for in, want := range map[string]time.Duration{"180d": 180 * 24 * time.Hour, "2w": 14 * 24 * time.Hour, "36h": 36 * time.Hour} {
+		if got, err := ParseAge(in); err != nil || got != want {
+			t.Errorf("ParseAge(%q) = %s, %v; want %s", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "d", "-3d", "1.5d", "soon"} {
+		if _, err := ParseAge(in); !errors.Is(err, ErrValidation) {
+			t.Errorf("ParseAge(%q): err = %v; want ErrValidation", in, err)
+		}
+	}
+}
+
+func TestStaleParameters(t *testing.T) {
+	fake := newFakeSSM()
+	now := time.Now()
+	for name, age := range map[string]time.Duration{"/prod/app/OLD": 400 * 24 * time.Hour, "/prod/app/OLDER": 500 * 24 * time.Hour, "/prod/app/NEW": time.Hour, "/staging/app/OLD": 400 * 24 * time.Hour} {
+		fake.set(name, "v", types.ParameterTypeString)
+		fake.meta[name] = types.ParameterMetadata{
+			Name:             aws.String(name),
+			LastModifiedDate: aws.Time(now.Add(-age)),
+			LastModifiedUser: aws.String("arn:aws:iam::111111111111:user/alice"),
+		}
+	}
+	stale, err := staleParameters(fake, "/prod/", now.Add(-180*24*time.Hour))
+	if err != nil {
+		t.Fatalf("staleParameters: %v", err)
+	}
+	if len(stale) != 2 || aws.ToString(stale[0].Name) != "/prod/app/OLDER" || aws.ToString(stale[1].Name) != "/prod/app/OLD" {
+		t.Errorf("stale = %+v; want /prod/app/OLDER then /prod/app/OLD", stale)
+	}
+	if err := ListStale(fake, "prod", time.Hour); !errors.Is(err, ErrValidation) {
+		t.Errorf("relative prefix: err = %v; want ErrValidation", err)
+	}
+}
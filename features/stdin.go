@@ -0,0 +1,45 @@
+package features
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// StdinSource is the -s value that reads the task definition, template or .env file from standard input, so
+// the tool can end a pipe such as `aws ecs describe-task-definition ... | salter-aws -s -` and the secrets it
+// resolves never sit in a temporary file.
+const StdinSource = "-"
+
+// stdinSource holds standard input once it has been read as the source file.
+var stdinSource struct {
+	once sync.Once
+	read bool
+	data []byte
+	err  error
+}
+
+// readSource returns the contents of filename, or of standard input for StdinSource. Standard input is read
+// to the end once and kept, so every step of an action that loads the source sees the same data.
+func readSource(filename string) ([]byte, error) {
+	if filename != StdinSource {
+		return os.ReadFile(filename)
+	}
+	stdinSource.once.Do(func() {
+		stdinSource.data, stdinSource.err = io.ReadAll(stdinReader)
+		stdinSource.read = true
+	})
+	if stdinSource.err != nil {
+		return nil, fmt.Errorf("failed to read standard input: %w", stdinSource.err)
+	}
+	return stdinSource.data, nil
+}
+
+// checkNotStdin rejects StdinSource for a file that would be written back in place.
+func checkNotStdin(filename, what string) error {
+	if filename == StdinSource {
+		return validationErrorf("%s cannot be standard input: it is rewritten in place; use -o", what)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package features
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// withStdin makes input the process's standard input for the rest of the test.
+func withStdin(t *testing.T, input string) {
+	t.Helper()
+	saved := stdinReader
+	reset := func() {
+		stdinSource.once, stdinSource.read, stdinSource.data, stdinSource.err = sync.Once{}, false, nil, nil
+	}
+	stdinReader = bufio.NewReader(strings.NewReader(input))
+	reset()
+	t.Cleanup(func() {
+		stdinReader = saved
+		reset()
+	})
+}
+
+func TestGenerateFromStdin(t *testing.T) {
+	withStdin(t, "DB_HOST=db\nAPI_TOKEN=t0k\n")
+	out := filepath.Join(t.TempDir(), "task.json")
+	if err := GenerateTaskDef(StdinSource, out, GenerateOptions{Format: InputDotenv, Prefix: "/app/"}); err != nil {
+		t.Fatalf("GenerateTaskDef: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var taskDef TaskDefinition
+	if err := json.Unmarshal(data, &taskDef); err != nil {
+		t.Fatal(err)
+	}
+	if secrets := taskDef.ContainerDefinitions[0].Secrets; len(secrets) != 2 || secrets[1].Name != "API_TOKEN" {
+		t.Errorf("secrets = %+v; want DB_HOST and API_TOKEN", secrets)
+	}
+
+	// The source is kept for later steps, and prompts cannot read the answer from it.
+	if data, err := readSource(StdinSource); err != nil || !strings.HasPrefix(string(data), "DB_HOST=") {
+		t.Errorf("second read = %q, %v; want the same input", data, err)
+	}
+	if Confirm("Overwrite?") {
+		t.Errorf("Confirm after reading the source from stdin = true; want false")
+	}
+}
+
+func TestStdinSourceNotRewrittenInPlace(t *testing.T) {
+	withStdin(t, `{"containerDefinitions": [{"secrets": [{"name": "A", "valueFrom": "/old/A"}]}]}`)
+	err := RewriteReferences([]string{StdinSource}, RewriteRefsOptions{Map: map[string]string{"/old/": "/new/"}})
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("rewrite-refs of stdin without -o: err = %v; want ErrValidation", err)
+	}
+	out := filepath.Join(t.TempDir(), "task.json")
+	err = RewriteReferences([]string{StdinSource}, RewriteRefsOptions{Map: map[string]string{"/old/": "/new/"}, Output: out})
+	if data, _ := os.ReadFile(out); err != nil || !strings.Contains(string(data), `"/new/A"`) {
+		t.Errorf("rewrite-refs of stdin with -o wrote %s (err %v); want /new/A", data, err)
+	}
+}
@@ -0,0 +1,325 @@
+package features
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Rewrite modes for the task definition JSON that get -s saves next to the environment.
+const (
+	RewriteDefault  = ""         // Re-encode; describe-task-definition output is unwrapped and cleaned.
+	RewriteClean    = "clean"    // Re-encode without the read-only fields RegisterTaskDefinition rejects.
+	RewritePreserve = "preserve" // Keep the file byte-for-byte, only setting value, type and the expanded valueFrom.
+)
+
+// readOnlyTaskDefFields are returned by DescribeTaskDefinition but rejected by RegisterTaskDefinition.
+var readOnlyTaskDefFields = []string{
+	"taskDefinitionArn", "revision", "status", "requiresAttributes", "compatibilities",
+	"registeredAt", "registeredBy", "deregisteredAt",
+}
+
+// unwrapTaskDef returns the task definition in jsonMap. `aws ecs describe-task-definition` output,
+// {"taskDefinition": {...}, "tags": [...]}, is unwrapped and the read-only fields are dropped, so the
+// JSON written back can be registered as is. Anything else is returned unchanged.
+func unwrapTaskDef(jsonMap map[string]interface{}) map[string]interface{} {
+	inner, ok := jsonMap["taskDefinition"].(map[string]interface{})
+	if !ok || jsonMap["containerDefinitions"] != nil {
+		return jsonMap
+	}
+	stripReadOnlyFields(inner)
+	return inner
+}
+
+// stripReadOnlyFields deletes readOnlyTaskDefFields from a task definition.
+func stripReadOnlyFields(taskDef map[string]interface{}) {
+	for _, field := range readOnlyTaskDefFields {
+		delete(taskDef, field)
+	}
+}
+
+// unwrapTaskDefJSON is unwrapTaskDef for raw JSON decoded into a TaskDefinition.
+func unwrapTaskDefJSON(data []byte) []byte {
+	var envelope struct {
+		TaskDefinition       json.RawMessage `json:"taskDefinition"`
+		ContainerDefinitions json.RawMessage `json:"containerDefinitions"`
+	}
+	if json.Unmarshal(data, &envelope) != nil || len(envelope.TaskDefinition) == 0 || envelope.ContainerDefinitions != nil {
+		return data
+	}
+	return envelope.TaskDefinition
+}
+
+// registrationFields record who registered a task definition and when; canonical output drops them.
+var registrationFields = []string{"registeredAt", "registeredBy", "deregisteredAt"}
+
+// canonicalTaskDef re-encodes task definition JSON so generated files diff cleanly: describe-task-definition
+// output is unwrapped, registration times and users are dropped, object keys are sorted, every secrets,
+// environment and secretOptions array is sorted by name, and the indentation is two spaces with a final
+// newline. Container order is kept, since the first container is the one templates describe. Numbers keep
+// their exact text.
+func canonicalTaskDef(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if taskDef, ok := v.(map[string]interface{}); ok {
+		taskDef = unwrapTaskDef(taskDef)
+		for _, field := range registrationFields {
+			delete(taskDef, field)
+		}
+		v = taskDef
+	}
+	sortNamedEntries(v)
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b) // Encoding a map sorts its keys.
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// sortNamedEntries sorts the secrets, environment and secretOptions arrays anywhere in v by their "name".
+func sortNamedEntries(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if entries, ok := child.([]interface{}); ok && (key == "secrets" || key == "environment" || key == "secretOptions") {
+				sort.SliceStable(entries, func(i, j int) bool { return entryName(entries[i]) < entryName(entries[j]) })
+			}
+			sortNamedEntries(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			sortNamedEntries(child)
+		}
+	}
+}
+
+// entryName returns the "name" of a name/value entry, or "" when it has none.
+func entryName(entry interface{}) string {
+	fields, _ := entry.(map[string]interface{})
+	name, _ := fields["name"].(string)
+	return name
+}
+
+// marshalTaskDef encodes a task definition with two-space indentation, canonically if asked.
+func marshalTaskDef(v interface{}, canonical bool) ([]byte, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil || !canonical {
+		return data, err
+	}
+	return canonicalTaskDef(data)
+}
+
+// jsonFrame is one open object or array while scanning JSON tokens.
+type jsonFrame struct {
+	object  bool
+	key     string // Objects: the current key. Arrays: the key the array is stored under.
+	wantKey bool   // Objects: the next token is a key.
+	index   int    // Arrays: index of the current element.
+	start   int    // Offset of the opening delimiter.
+	secret  *secretSpan
+}
+
+// next moves f past a complete value.
+func (f *jsonFrame) next() {
+	if f.object {
+		f.wantKey = true
+	} else {
+		f.index++
+	}
+}
+
+// secretSpan locates one object of containerDefinitions[0].secrets in the raw JSON of a task definition.
+type secretSpan struct {
+	index  int               // Position in the secrets array.
+	start  int               // Offset of the opening "{".
+	name   string            // Value of "name", if it is a string.
+	line   int               // Line of the name value.
+	fields map[string][2]int // Byte range of each field's value.
+	indent string            // Whitespace before the first key, reused for added fields.
+	end    int               // Offset after the last field value, or after "{" in an empty object.
+}
+
+// scanSecrets returns the secrets of the first container, at the top level or inside a
+// describe-task-definition "taskDefinition" envelope. Malformed JSON yields the secrets found before the error.
+func scanSecrets(data []byte) []*secretSpan {
+	var spans []*secretSpan
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var stack []*jsonFrame
+	// inFirstContainerSecrets reports whether the innermost frame is containerDefinitions[0].secrets.
+	inFirstContainerSecrets := func() bool {
+		n := len(stack)
+		return (n == 4 || n == 5 && stack[0].key == "taskDefinition") &&
+			!stack[n-1].object && stack[n-1].key == "secrets" &&
+			!stack[n-3].object && stack[n-3].key == "containerDefinitions" && stack[n-3].index == 0
+	}
+	for {
+		prev := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err != nil {
+			return spans
+		}
+		offset := int(dec.InputOffset())
+		start := prev + len(data[prev:offset]) - len(bytes.TrimLeft(data[prev:offset], " \t\r\n:,"))
+		var top *jsonFrame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		delim, isDelim := tok.(json.Delim)
+		switch {
+		case isDelim && (delim == '}' || delim == ']'):
+			stack = stack[:len(stack)-1]
+			if top.secret != nil {
+				spans = append(spans, top.secret)
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				if parent.secret != nil {
+					parent.secret.fields[parent.key] = [2]int{top.start, offset}
+					parent.secret.end = offset
+				}
+				parent.next()
+			}
+		case top != nil && top.object && top.wantKey:
+			top.key, _ = tok.(string)
+			top.wantKey = false
+			if top.secret != nil && len(top.secret.fields) == 0 {
+				top.secret.indent = string(data[top.start+1 : start])
+			}
+		case isDelim:
+			frame := &jsonFrame{object: delim == '{', wantKey: delim == '{', start: start}
+			if top != nil && top.object {
+				frame.key = top.key
+			}
+			if frame.object && inFirstContainerSecrets() {
+				frame.secret = &secretSpan{index: top.index, start: start, fields: make(map[string][2]int), end: start + 1}
+			}
+			stack = append(stack, frame)
+		default:
+			if top != nil && top.secret != nil {
+				top.secret.fields[top.key] = [2]int{start, offset}
+				top.secret.end = offset
+				if name, ok := tok.(string); ok && top.key == "name" {
+					top.secret.name = name
+					top.secret.line = 1 + bytes.Count(data[:start], []byte("\n"))
+				}
+			}
+			if top != nil {
+				top.next()
+			}
+		}
+	}
+}
+
+// rewriteValueFrom replaces every "valueFrom" string in raw task definition JSON, in any container, with
+// rewrite's result and leaves every other byte as it was. It returns the new JSON and the number of
+// references changed, or an error if data is not valid JSON.
+func rewriteValueFrom(data []byte, rewrite func(valueFrom string) string) ([]byte, int, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var stack []*jsonFrame
+	var out []byte
+	copied, changed := 0, 0 // copied is the offset up to which data has been copied to out.
+	for {
+		prev := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		offset := int(dec.InputOffset())
+		start := prev + len(data[prev:offset]) - len(bytes.TrimLeft(data[prev:offset], " \t\r\n:,"))
+		var top *jsonFrame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		delim, isDelim := tok.(json.Delim)
+		switch {
+		case isDelim && (delim == '}' || delim == ']'):
+			stack = stack[:len(stack)-1]
+			if len(stack) > 0 {
+				stack[len(stack)-1].next()
+			}
+		case top != nil && top.object && top.wantKey:
+			top.key, _ = tok.(string)
+			top.wantKey = false
+		case isDelim:
+			stack = append(stack, &jsonFrame{object: delim == '{', wantKey: delim == '{'})
+		default:
+			if valueFrom, ok := tok.(string); ok && top != nil && top.object && top.key == "valueFrom" {
+				if updated := rewrite(valueFrom); updated != valueFrom {
+					out = append(append(out, data[copied:start]...), jsonString(updated)...)
+					copied = offset
+					changed++
+				}
+			}
+			if top != nil {
+				top.next()
+			}
+		}
+	}
+	return append(out, data[copied:]...), changed, nil
+}
+
+// jsonField is a string field to set in a JSON object.
+type jsonField struct {
+	key, value string
+}
+
+// patchSecrets sets string fields of the first container's secrets in raw task definition JSON, keyed by
+// the secret's index, and leaves every other byte as it was. Existing fields are replaced in place; new
+// ones are appended to the object with the indentation of its first field.
+func patchSecrets(data []byte, updates map[int][]jsonField) []byte {
+	type edit struct {
+		from, to int
+		text     string
+	}
+	var edits []edit
+	for _, span := range scanSecrets(data) {
+		var added strings.Builder
+		nonEmpty := len(span.fields) > 0
+		for _, field := range updates[span.index] {
+			if at, ok := span.fields[field.key]; ok {
+				edits = append(edits, edit{at[0], at[1], jsonString(field.value)})
+				continue
+			}
+			if nonEmpty {
+				sep := span.indent
+				if sep == "" {
+					sep = " " // {"name": ...} on one line.
+				}
+				added.WriteString("," + sep)
+			}
+			added.WriteString(jsonString(field.key) + ": " + jsonString(field.value))
+			nonEmpty = true
+		}
+		if added.Len() > 0 {
+			edits = append(edits, edit{span.end, span.end, added.String()})
+		}
+	}
+	// Apply from the end so earlier offsets stay valid.
+	sort.Slice(edits, func(i, j int) bool { return edits[i].from > edits[j].from })
+	out := append([]byte(nil), data...)
+	for _, e := range edits {
+		out = append(out[:e.from], append([]byte(e.text), out[e.to:]...)...)
+	}
+	return out
+}
+
+// jsonString encodes s as a JSON string without HTML escaping.
+func jsonString(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
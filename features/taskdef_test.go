@@ -0,0 +1,212 @@
+package features
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// describeOutput is what `aws ecs describe-task-definition` prints.
+const describeOutput = `{
+    "taskDefinition": {
+        "taskDefinitionArn": "arn:aws:ecs:ap-southeast-3:1234:task-definition/app:7",
+        "family": "app",
+        "revision": 7,
+        "status": "ACTIVE",
+        "registeredAt": "2024-01-02T03:04:05.000Z",
+        "registeredBy": "arn:aws:iam::1234:user/dev",
+        "compatibilities": ["EC2", "FARGATE"],
+        "containerDefinitions": [
+            {
+                "name": "web",
+                "secrets": [
+                    {"name": "DB_URL", "valueFrom": "/app/DB_URL", "value": "postgres://db"},
+                    {"name": "DB_URL", "valueFrom": "/app/DB_URL", "value": "postgres://db2"}
+                ]
+            }
+        ]
+    },
+    "tags": []
+}`
+
+func TestDescribeTaskDefinitionEnvelope(t *testing.T) {
+	dir := t.TempDir()
+	template := filepath.Join(dir, "describe.json")
+	if err := os.WriteFile(template, []byte(describeOutput), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := templateSecretLines([]byte(describeOutput)), map[string][]int{"DB_URL": {14, 15}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("templateSecretLines = %v; want %v", got, want)
+	}
+
+	fake := newFakeSSM()
+	if err := PutParametersFromTemplate(fake, template, TemplateOptions{}, PutOptions{AssumeYes: true}); err != nil {
+		t.Fatalf("put-from-template: %v", err)
+	}
+	if got := *fake.params["/app/DB_URL"].Value; got != "postgres://db2" {
+		t.Errorf("/app/DB_URL = %q; want %q", got, "postgres://db2")
+	}
+
+	fake.set("/app/DB_URL", "postgres://live", types.ParameterTypeString)
+	base := filepath.Join(dir, "out")
+	if err := GetParametersFromFile(fake, template, base, TemplateOptions{}, GetFileOptions{Export: ExportOptions{Formats: []string{FormatEnv}}}); err != nil {
+		t.Fatalf("get -s: %v", err)
+	}
+	data, err := os.ReadFile(base + ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved map[string]interface{}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	var fields []string
+	for field := range saved {
+		fields = append(fields, field)
+	}
+	if saved["family"] != "app" || saved["containerDefinitions"] == nil || len(saved) != 2 {
+		t.Errorf("saved task definition fields = %v; want only family and containerDefinitions", fields)
+	}
+}
+
+func TestGetParametersFromFileRewriteModes(t *testing.T) {
+	dir := t.TempDir()
+	template := filepath.Join(dir, "task.json")
+	data := `{
+  "taskDefinitionArn": "arn:aws:ecs:ap-southeast-3:1234:task-definition/app:7",
+  "revision": 7,
+  "family":   "app",
+  "containerDefinitions": [
+    {
+      "secrets": [
+        {
+          "name": "DB_URL",
+          "valueFrom": "/{{env}}/app/DB_URL"
+        },
+        {"name": "TOKEN", "valueFrom": "/dev/app/TOKEN", "value": "stale", "x-note": "kept"},
+        {}
+      ]
+    }
+  ]
+}
+`
+	if err := os.WriteFile(template, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeSSM()
+	fake.set("/dev/app/DB_URL", "postgres://db?a=1&b=<2>", types.ParameterTypeString)
+	fake.set("/dev/app/TOKEN", "t0ken", types.ParameterTypeSecureString)
+	tmplOpts := TemplateOptions{Vars: map[string]string{"env": "dev"}}
+	base := filepath.Join(dir, "out")
+
+	if err := GetParametersFromFile(fake, template, base, tmplOpts, GetFileOptions{Export: ExportOptions{Formats: []string{FormatEnv}}, Rewrite: RewritePreserve}); err != nil {
+		t.Fatalf("get -preserve-unknown: %v", err)
+	}
+	got, err := os.ReadFile(base + ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "taskDefinitionArn": "arn:aws:ecs:ap-southeast-3:1234:task-definition/app:7",
+  "revision": 7,
+  "family":   "app",
+  "containerDefinitions": [
+    {
+      "secrets": [
+        {
+          "name": "DB_URL",
+          "valueFrom": "/dev/app/DB_URL",
+          "value": "postgres://db?a=1&b=<2>",
+          "type": "String"
+        },
+        {"name": "TOKEN", "valueFrom": "/dev/app/TOKEN", "value": "t0ken", "x-note": "kept", "type": "SecureString"},
+        {}
+      ]
+    }
+  ]
+}
+`
+	if string(got) != want {
+		t.Errorf("preserved task definition =\n%s\nwant\n%s", got, want)
+	}
+
+	if err := GetParametersFromFile(fake, template, base, tmplOpts, GetFileOptions{Export: ExportOptions{Formats: []string{FormatEnv}}, Rewrite: RewriteClean}); err != nil {
+		t.Fatalf("get -clean: %v", err)
+	}
+	got, err = os.ReadFile(base + ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved map[string]interface{}
+	if err := json.Unmarshal(got, &saved); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"taskDefinitionArn", "revision"} {
+		if _, ok := saved[field]; ok {
+			t.Errorf("-clean kept read-only field %s", field)
+		}
+	}
+	if saved["family"] != "app" {
+		t.Errorf("-clean dropped family: %v", saved)
+	}
+}
+
+func TestCanonicalTaskDef(t *testing.T) {
+	a := `{"taskDefinition": {"family": "app", "registeredAt": "2024-01-02T03:04:05Z", "registeredBy": "arn:aws:iam::1:user/alice",
+  "containerDefinitions": [{"name": "web", "memory": 512, "cpu": 0.25,
+    "secrets": [{"valueFrom": "/app/B", "name": "B"}, {"name": "A", "valueFrom": "/app/A"}],
+    "environment": [{"name": "Z", "value": "<z>"}, {"name": "M", "value": "m"}]}]}}`
+	b := `{
+	"containerDefinitions": [
+		{"cpu": 0.25, "memory": 512, "name": "web",
+		 "environment": [{"value": "m", "name": "M"}, {"name": "Z", "value": "<z>"}],
+		 "secrets": [{"name": "A", "valueFrom": "/app/A"}, {"name": "B", "valueFrom": "/app/B"}]}
+	],
+	"family": "app", "registeredAt": "2025-06-07T08:09:10Z", "registeredBy": "arn:aws:iam::1:user/bob"
+}`
+	want := `{
+  "containerDefinitions": [
+    {
+      "cpu": 0.25,
+      "environment": [
+        {
+          "name": "M",
+          "value": "m"
+        },
+        {
+          "name": "Z",
+          "value": "<z>"
+        }
+      ],
+      "memory": 512,
+      "name": "web",
+      "secrets": [
+        {
+          "name": "A",
+          "valueFrom": "/app/A"
+        },
+        {
+          "name": "B",
+          "valueFrom": "/app/B"
+        }
+      ]
+    }
+  ],
+  "family": "app"
+}
+`
+	for _, in := range []string{a, b} {
+		got, err := canonicalTaskDef([]byte(in))
+		if err != nil {
+			t.Fatalf("canonicalTaskDef: %v", err)
+		}
+		if string(got) != want {
+			t.Errorf("canonicalTaskDef =\n%s\nwant\n%s", got, want)
+		}
+	}
+}
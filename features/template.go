@@ -0,0 +1,123 @@
+package features
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// TemplateOptions controls how task definition templates are interpreted.
+type TemplateOptions struct {
+	Vars map[string]string // Values for {{name}} placeholders in valueFrom paths, e.g. {"env": "prod"}.
+	// FallbackPrefix, when set, derives the path of secrets without valueFrom as FallbackPrefix+name.
+	// When empty such secrets are an error.
+	FallbackPrefix string
+	// Region and AccountID describe the active credentials; ARNs pointing elsewhere are rejected.
+	// An empty AccountID skips the account check.
+	Region    string
+	AccountID string
+	// AllowCrossAccount downgrades region/account mismatches from errors to warnings.
+	AllowCrossAccount bool
+	// Strict makes a secret name defined twice an error; otherwise the last definition is used with a warning.
+	Strict bool
+	// PathPattern, such as /{{env}}/{{app}}/{{name}}, maps logical names to parameter paths: a valueFrom or
+	// parameter reference without "/" or ":" (such as DB_URL) is the {{name}} of the pattern, and the
+	// other placeholders come from Vars. When empty such names are used as they are. See ParameterPath.
+	PathPattern string
+	// Resources resolves {{rds-endpoint id}} and the other resource references of values when templates are
+	// applied or checked; when nil such references are an error.
+	Resources *ResourceResolver
+}
+
+// templateVarPattern matches placeholders such as {{env}} or {{ account_id }}.
+var templateVarPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// ExpandTemplateVars replaces every {{name}} placeholder in s with its value from vars.
+// It fails, listing the names, if any placeholder has no value.
+func ExpandTemplateVars(s string, vars map[string]string) (string, error) {
+	missing := make(map[string]bool)
+	result := templateVarPattern.ReplaceAllStringFunc(s, func(match string) string {
+		name := templateVarPattern.FindStringSubmatch(match)[1]
+		value, ok := vars[name]
+		if !ok {
+			missing[name] = true
+			return match
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		var names []string
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return "", validationErrorf("undefined template variable(s) %s in %q (set them with -var or \"variables\" in config.json)", strings.Join(names, ", "), s)
+	}
+	return result, nil
+}
+
+// ParameterPath returns the parameter path of the logical name, such as DB_URL, by expanding PathPattern
+// with Vars and name as {{name}}. It is what templates resolve logical names to, for Go callers that need
+// the same path without building it themselves.
+func (o TemplateOptions) ParameterPath(name string) (string, error) {
+	if o.PathPattern == "" {
+		return "", validationErrorf("no path pattern for logical name %s (set \"pathPattern\" in config.json)", name)
+	}
+	if !strings.Contains(templateVarPattern.ReplaceAllString(o.PathPattern, "{{$1}}"), "{{name}}") {
+		return "", validationErrorf("path pattern %q has no {{name}} placeholder", o.PathPattern)
+	}
+	vars := make(map[string]string, len(o.Vars)+1)
+	for key, value := range o.Vars {
+		vars[key] = value
+	}
+	vars["name"] = name
+	path, err := ExpandTemplateVars(o.PathPattern, vars)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(path, "/") || strings.Contains(path, "//") {
+		return "", validationErrorf("path pattern %q gives %q for %s; every placeholder needs a non-empty value", o.PathPattern, path, name)
+	}
+	return path, nil
+}
+
+// isLogicalName reports whether ref, an expanded valueFrom or parameter reference, is a logical name
+// that PathPattern maps to a path.
+func (o TemplateOptions) isLogicalName(ref string) bool {
+	return o.PathPattern != "" && ref != "" && !strings.ContainsAny(ref, "/:")
+}
+
+// parameterName returns the parameter ref refers to: the ParameterPath of a logical name, or what
+// ExtractParameterName returns for paths and ARNs ("" for anything else).
+func (o TemplateOptions) parameterName(ref string) (string, error) {
+	if o.isLogicalName(ref) {
+		return o.ParameterPath(ref)
+	}
+	return ExtractParameterName(ref), nil
+}
+
+// checkARNScope verifies that a valueFrom ARN points at the active region and account.
+// Bare paths always pass. With AllowCrossAccount a mismatch is printed as a warning instead of returned.
+func checkARNScope(valueFrom string, opts TemplateOptions) error {
+	region, accountID, _, ok := ParseParameterARN(valueFrom)
+	if !ok {
+		return nil
+	}
+	var problems []string
+	if opts.Region != "" && region != opts.Region {
+		problems = append(problems, fmt.Sprintf("region %s does not match active region %s", region, opts.Region))
+	}
+	if opts.AccountID != "" && accountID != opts.AccountID {
+		problems = append(problems, fmt.Sprintf("account %s does not match active account %s", accountID, opts.AccountID))
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	msg := fmt.Sprintf("%s: %s", valueFrom, strings.Join(problems, "; "))
+	if opts.AllowCrossAccount {
+		Infof("Warning: %s (the parameter is read/written in the active account and region)\n", msg)
+		return nil
+	}
+	return validationErrorf("%s (use -allow-cross-account to proceed anyway)", msg)
+}
@@ -0,0 +1,81 @@
+package features
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandTemplateVars(t *testing.T) {
+	vars := map[string]string{"env": "prod", "account_id": "123456789012"}
+
+	got, err := ExpandTemplateVars("arn:aws:ssm:ap-southeast-3:{{account_id}}:parameter/{{ env }}/app/DB_URL", vars)
+	if err != nil {
+		t.Fatalf("ExpandTemplateVars: %v", err)
+	}
+	if want := "arn:aws:ssm:ap-southeast-3:123456789012:parameter/prod/app/DB_URL"; got != want {
+		t.Errorf("ExpandTemplateVars = %q; want %q", got, want)
+	}
+
+	if got, err := ExpandTemplateVars("/plain/path", vars); err != nil || got != "/plain/path" {
+		t.Errorf("ExpandTemplateVars(plain) = %q, %v; want unchanged", got, err)
+	}
+
+	if _, err := ExpandTemplateVars("/{{stage}}/app", vars); err == nil {
+		t.Error("ExpandTemplateVars with undefined variable succeeded; want error")
+	}
+}
+
+func TestCheckARNScope(t *testing.T) {
+	opts := TemplateOptions{Region: "ap-southeast-3", AccountID: "1234"}
+
+	if err := checkARNScope("arn:aws:ssm:ap-southeast-3:1234:parameter/app/KEY", opts); err != nil {
+		t.Errorf("matching ARN: %v", err)
+	}
+	if err := checkARNScope("/app/KEY", opts); err != nil {
+		t.Errorf("bare path: %v", err)
+	}
+	if err := checkARNScope("arn:aws:ssm:ap-southeast-3:9999:parameter/app/KEY", opts); err == nil {
+		t.Error("other account accepted; want error")
+	}
+	if err := checkARNScope("arn:aws:ssm:us-east-1:1234:parameter/app/KEY", opts); err == nil {
+		t.Error("other region accepted; want error")
+	}
+	opts.AllowCrossAccount = true
+	if err := checkARNScope("arn:aws:ssm:us-east-1:9999:parameter/app/KEY", opts); err != nil {
+		t.Errorf("AllowCrossAccount: %v", err)
+	}
+}
+
+func TestLogicalNames(t *testing.T) {
+	opts := TemplateOptions{Vars: map[string]string{"env": "prod", "app": "billing"}, PathPattern: "/{{env}}/{{app}}/{{name}}"}
+	if got, err := opts.ParameterPath("DB_URL"); err != nil || got != "/prod/billing/DB_URL" {
+		t.Errorf("ParameterPath = %q, %v; want /prod/billing/DB_URL", got, err)
+	}
+	if _, err := (TemplateOptions{PathPattern: "/{{env}}/app"}).ParameterPath("DB_URL"); !errors.Is(err, ErrValidation) {
+		t.Errorf("pattern without {{name}}: err = %v; want ErrValidation", err)
+	}
+	if _, err := (TemplateOptions{Vars: map[string]string{"env": ""}, PathPattern: "/{{env}}/{{name}}"}).ParameterPath("DB_URL"); !errors.Is(err, ErrValidation) {
+		t.Errorf("empty placeholder: err = %v; want ErrValidation", err)
+	}
+
+	template := filepath.Join(t.TempDir(), "task.json")
+	data := `{"containerDefinitions": [{"secrets": [
+  {"name": "DB_HOST", "valueFrom": "DB_HOST", "value": "db.internal"},
+  {"name": "DB_URL", "valueFrom": "DB_URL", "value": "postgres://{{ssm:DB_HOST}}/app"},
+  {"name": "REGION", "valueFrom": "/shared/REGION", "value": "eu-west-1"}
+]}]}`
+	if err := os.WriteFile(template, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeSSM()
+	if err := PutParametersFromTemplate(fake, template, opts, PutOptions{AssumeYes: true}); err != nil {
+		t.Fatalf("put-from-template: %v", err)
+	}
+	for name, want := range map[string]string{"/prod/billing/DB_HOST": "db.internal", "/prod/billing/DB_URL": "postgres://db.internal/app", "/shared/REGION": "eu-west-1"} {
+		if p, ok := fake.params[name]; !ok || *p.Value != want {
+			t.Errorf("%s = %+v; want %q", name, p, want)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package features
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// InputTerraform reads the aws_ssm_parameter resources of a Terraform state file.
+const InputTerraform = "terraform-state"
+
+// tfState is the part of a Terraform state file (format version 4) that holds resources.
+type tfState struct {
+	Version   int `json:"version"`
+	Resources []struct {
+		Module    string `json:"module"`
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			IndexKey   interface{}            `json:"index_key"`
+			Attributes map[string]interface{} `json:"attributes"`
+		} `json:"instances"`
+	} `json:"resources"`
+}
+
+// terraformParameter is one aws_ssm_parameter resource instance of a state.
+type terraformParameter struct {
+	address   string // Resource address, such as module.app.aws_ssm_parameter.db["primary"].
+	name      string
+	value     string // Empty when the state holds no value (write-only values or ignored changes).
+	paramType ParameterType
+	keyID     string // Customer managed KMS key; empty for aws/ssm.
+}
+
+// readTerraformParameters returns the managed aws_ssm_parameter instances of a state file, in state order.
+// Data sources are left out: Terraform reads them but does not own them.
+func readTerraformParameters(data []byte, filename string) ([]terraformParameter, error) {
+	var state tfState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, validationErrorf("failed to parse %s: %w", filename, err)
+	}
+	if state.Version != 4 {
+		return nil, validationErrorf("%s: unsupported Terraform state version %d (only version 4, Terraform 0.12 and later, is read)", filename, state.Version)
+	}
+	var params []terraformParameter
+	for _, resource := range state.Resources {
+		if resource.Type != "aws_ssm_parameter" || resource.Mode != "managed" {
+			continue
+		}
+		address := resource.Type + "." + resource.Name
+		if resource.Module != "" {
+			address = resource.Module + "." + address
+		}
+		for _, instance := range resource.Instances {
+			p := terraformParameter{address: address}
+			switch key := instance.IndexKey.(type) {
+			case string:
+				p.address += fmt.Sprintf("[%q]", key)
+			case float64:
+				p.address += fmt.Sprintf("[%d]", int(key))
+			}
+			attribute := func(name string) string {
+				s, _ := instance.Attributes[name].(string)
+				return s
+			}
+			p.name = attribute("name")
+			if p.name == "" {
+				return nil, validationErrorf("%s: %s has no name attribute", filename, p.address)
+			}
+			p.value = attribute("value")
+			if p.value == "" {
+				p.value = attribute("insecure_value")
+			}
+			paramType, err := ParseParameterType(attribute("type"))
+			if err != nil {
+				return nil, validationErrorf("%s: %s: %w", filename, p.address, err)
+			}
+			p.paramType = paramType
+			if keyID := attribute("key_id"); keyID != "alias/aws/ssm" {
+				p.keyID = keyID
+			}
+			params = append(params, p)
+		}
+	}
+	return params, nil
+}
+
+// terraformReader reads Terraform state files as an input format. Parameters without a value in the state
+// are left out, since there is nothing to put.
+type terraformReader struct{}
+
+func (terraformReader) Extensions() []string { return []string{".tfstate"} }
+
+func (terraformReader) Read(data []byte, filename string, _ InputOptions) ([]InputParam, error) {
+	resources, err := readTerraformParameters(data, filename)
+	if err != nil {
+		return nil, err
+	}
+	var params []InputParam
+	for _, r := range resources {
+		if r.value == "" {
+			continue
+		}
+		params = append(params, InputParam{Key: r.name[strings.LastIndex(r.name, "/")+1:], Name: r.name, Value: r.value, Type: r.paramType, KeyID: r.keyID})
+	}
+	return params, nil
+}
+
+// ImportTerraformState converts the aws_ssm_parameter resources of a Terraform state file into a
+// put-from-template template, for auditing what Terraform manages or for taking ownership of the parameters
+// with this tool. Each secret is named after the last path segment of its parameter (the whole path when
+// that is taken) and carries the value, type and KMS key from the state. The template is written to
+// outputFile, readable only by the current user since it holds the values, or printed when outputFile is empty.
+func ImportTerraformState(filename, outputFile string) error {
+	data, err := readSource(filename)
+	if err != nil {
+		return err
+	}
+	resources, err := readTerraformParameters(data, filename)
+	if err != nil {
+		return err
+	}
+	if len(resources) == 0 {
+		return validationErrorf("no aws_ssm_parameter resources in %s", filename)
+	}
+
+	report := infoOutput
+	if outputFile == "" && report == io.Writer(os.Stdout) {
+		report = os.Stderr // Keep stdout for the template.
+	}
+	var secrets []ExtendedSecret
+	taken := make(map[string]bool)
+	missing := 0
+	for _, r := range resources {
+		name := r.name[strings.LastIndex(r.name, "/")+1:]
+		if taken[name] || name == "" {
+			name = strings.ReplaceAll(strings.Trim(r.name, "/"), "/", "_")
+		}
+		taken[name] = true
+		note := ""
+		if r.value == "" {
+			note = yellow(" (no value in the state; put-from-template skips it)")
+			missing++
+		}
+		fmt.Fprintf(report, "  %s -> %s (%s)%s\n", r.address, r.name, r.paramType, note)
+		secrets = append(secrets, ExtendedSecret{
+			Name:      name,
+			ValueFrom: r.name,
+			Type:      r.paramType,
+			Value:     r.value,
+			KMSKeyID:  r.keyID,
+		})
+	}
+	data, err = marshalTaskDef(TaskDefinition{ContainerDefinitions: []ContainerDefinition{{Secrets: secrets}}}, false)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if outputFile == "" {
+		fmt.Print(string(data))
+	} else if err := os.WriteFile(outputFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outputFile, err)
+	}
+	fmt.Fprintf(report, "Converted %d aws_ssm_parameter resources from %s (%d without a value)\n", len(resources), filename, missing)
+	if outputFile != "" {
+		Infof("Saved template to %s\n", outputFile)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package features
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+const testTerraformState = `{
+  "version": 4,
+  "terraform_version": "1.6.6",
+  "resources": [
+    {"mode": "managed", "type": "aws_ssm_parameter", "name": "db_host", "instances": [
+      {"attributes": {"name": "/prod/app/DB_HOST", "type": "String", "value": "db", "key_id": ""}}
+    ]},
+    {"module": "module.api", "mode": "managed", "type": "aws_ssm_parameter", "name": "token", "instances": [
+      {"index_key": "primary", "attributes": {"name": "/prod/api/TOKEN", "type": "SecureString", "value": "t0k", "key_id": "alias/api"}},
+      {"index_key": "legacy", "attributes": {"name": "/prod/legacy/TOKEN", "type": "SecureString", "value": "", "key_id": "alias/aws/ssm"}}
+    ]},
+    {"mode": "data", "type": "aws_ssm_parameter", "name": "shared", "instances": [
+      {"attributes": {"name": "/shared/VPC_ID", "type": "String", "value": "vpc-1"}}
+    ]},
+    {"mode": "managed", "type": "aws_s3_bucket", "name": "assets", "instances": [{"attributes": {"bucket": "assets"}}]}
+  ]
+}`
+
+func TestImportTerraformState(t *testing.T) {
+	dir := t.TempDir()
+	state := filepath.Join(dir, "terraform.tfstate")
+	if err := os.WriteFile(state, []byte(testTerraformState), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "template.json")
+	if err := ImportTerraformState(state, out); err != nil {
+		t.Fatalf("ImportTerraformState: %v", err)
+	}
+	info, err := os.Stat(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("template mode = %v; want 0600", perm)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var taskDef TaskDefinition
+	if err := json.Unmarshal(data, &taskDef); err != nil {
+		t.Fatal(err)
+	}
+	want := []ExtendedSecret{
+		{Name: "DB_HOST", ValueFrom: "/prod/app/DB_HOST", Type: StringType, Value: "db"},
+		{Name: "TOKEN", ValueFrom: "/prod/api/TOKEN", Type: SecureStringType, Value: "t0k", KMSKeyID: "alias/api"},
+		{Name: "prod_legacy_TOKEN", ValueFrom: "/prod/legacy/TOKEN", Type: SecureStringType},
+	}
+	if got := taskDef.ContainerDefinitions[0].Secrets; !reflect.DeepEqual(got, want) {
+		t.Errorf("secrets = %+v; want %+v", got, want)
+	}
+
+	old := filepath.Join(dir, "old.tfstate")
+	if err := os.WriteFile(old, []byte(`{"version": 3, "modules": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ImportTerraformState(old, ""); !errors.Is(err, ErrValidation) {
+		t.Errorf("state version 3: err = %v; want ErrValidation", err)
+	}
+}
+
+func TestImportFromTerraformState(t *testing.T) {
+	state := filepath.Join(t.TempDir(), "terraform.tfstate")
+	if err := os.WriteFile(state, []byte(testTerraformState), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeSSM()
+	if err := ImportParameters(fake, state, ImportOptions{Prefix: "/unused/"}, PutOptions{AssumeYes: true}); err != nil {
+		t.Fatalf("ImportParameters: %v", err)
+	}
+	if len(fake.params) != 2 {
+		t.Errorf("put %d parameters; want the 2 with values", len(fake.params))
+	}
+	if p, ok := fake.params["/prod/api/TOKEN"]; !ok || aws.ToString(p.Value) != "t0k" {
+		t.Errorf("/prod/api/TOKEN = %+v; want t0k", p)
+	}
+}
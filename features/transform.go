@@ -0,0 +1,120 @@
+package features
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Transform steps for the "transforms" map of config.json, applied in order to exported values.
+const (
+	TransformBase64Decode = "base64-decode" // Standard or URL-safe base64, padded or not.
+	TransformJSONPretty   = "json-pretty"   // Indent JSON with two spaces.
+	TransformTrim         = "trim"          // Remove leading and trailing whitespace.
+	TransformLowercase    = "lowercase"
+	TransformPrepend      = "prepend:" // prepend:<text> puts text before the value.
+)
+
+// valueTransform is one parsed transform step.
+type valueTransform func(value string) (string, error)
+
+// parseTransform returns the transform a step names.
+func parseTransform(step string) (valueTransform, error) {
+	switch {
+	case step == TransformBase64Decode:
+		return base64Decode, nil
+	case step == TransformJSONPretty:
+		return func(value string) (string, error) {
+			var out bytes.Buffer
+			if err := json.Indent(&out, []byte(value), "", "  "); err != nil {
+				return "", validationErrorf("not JSON: %v", err)
+			}
+			return out.String(), nil
+		}, nil
+	case step == TransformTrim:
+		return func(value string) (string, error) { return strings.TrimSpace(value), nil }, nil
+	case step == TransformLowercase:
+		return func(value string) (string, error) { return strings.ToLower(value), nil }, nil
+	case strings.HasPrefix(step, TransformPrepend):
+		text := strings.TrimPrefix(step, TransformPrepend)
+		return func(value string) (string, error) { return text + value, nil }, nil
+	}
+	return nil, validationErrorf("unknown transform %q (use %s, %s, %s, %s or %s<text>)", step, TransformBase64Decode, TransformJSONPretty, TransformTrim, TransformLowercase, TransformPrepend)
+}
+
+// base64Decode decodes value, which must decode to text: every output format holds text.
+func base64Decode(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if decoded, err := enc.DecodeString(value); err == nil {
+			if !utf8.Valid(decoded) {
+				return "", validationErrorf("base64 value decodes to binary data, which exports cannot hold")
+			}
+			return string(decoded), nil
+		}
+	}
+	return "", validationErrorf("not base64")
+}
+
+// checkTransforms parses every step of transforms, so a typo fails before anything is read or written.
+func checkTransforms(transforms map[string][]string) error {
+	for pattern, steps := range transforms {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return validationErrorf("invalid transform key pattern %q: %v", pattern, err)
+		}
+		for _, step := range steps {
+			if _, err := parseTransform(step); err != nil {
+				return validationErrorf("transforms for %s: %v", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// applyTransforms returns params with the transforms of each key applied to its value. A key uses the
+// steps listed under its exact name, otherwise those of the one pattern (such as "certs/*", matched
+// with path.Match) it matches; a key matching several patterns is an error.
+func applyTransforms(params []OutputParam, transforms map[string][]string) ([]OutputParam, error) {
+	if len(transforms) == 0 {
+		return params, nil
+	}
+	var patterns []string
+	for pattern := range transforms {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	out := make([]OutputParam, len(params))
+	for i, p := range params {
+		out[i] = p
+		steps, ok := transforms[p.Key]
+		if !ok {
+			var matched []string
+			for _, pattern := range patterns {
+				if m, _ := path.Match(pattern, p.Key); m {
+					matched = append(matched, pattern)
+				}
+			}
+			if len(matched) > 1 {
+				return nil, validationErrorf("key %s matches several transforms: %s", p.Key, strings.Join(matched, ", "))
+			}
+			if len(matched) == 1 {
+				steps = transforms[matched[0]]
+			}
+		}
+		for _, step := range steps {
+			transform, err := parseTransform(step)
+			if err != nil {
+				return nil, err
+			}
+			if out[i].Value, err = transform(out[i].Value); err != nil {
+				return nil, validationErrorf("transform %s of %s: %v", step, p.Key, err)
+			}
+			out[i].ValueType = "" // A transformed value is a string.
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,51 @@
+package features
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestApplyTransforms(t *testing.T) {
+	params := []OutputParam{
+		{Key: "TLS_CERT", Value: "LS0tLS1CRUdJTg=="},
+		{Key: "settings/app", Value: `{"a":1}`},
+		{Key: "REGION", Value: " EU-West-1\n"},
+		{Key: "DB_HOST", Value: "db:5432"},
+		{Key: "OTHER", Value: "as is"},
+	}
+	transforms := map[string][]string{
+		"TLS_CERT":   {TransformBase64Decode},
+		"settings/*": {TransformJSONPretty},
+		"REGION":     {TransformTrim, TransformLowercase},
+		"DB_*":       {"prepend:tcp://"},
+	}
+	if err := checkTransforms(transforms); err != nil {
+		t.Fatalf("checkTransforms: %v", err)
+	}
+	got, err := applyTransforms(params, transforms)
+	if err != nil {
+		t.Fatalf("applyTransforms: %v", err)
+	}
+	var values []string
+	for _, p := range got {
+		values = append(values, p.Value)
+	}
+	want := []string{"-----BEGIN", "{\n  \"a\": 1\n}", "eu-west-1", "tcp://db:5432", "as is"}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("values = %q; want %q", values, want)
+	}
+	if params[0].Value != "LS0tLS1CRUdJTg==" {
+		t.Error("applyTransforms changed its input")
+	}
+
+	if err := checkTransforms(map[string][]string{"KEY": {"uppercase"}}); !errors.Is(err, ErrValidation) {
+		t.Errorf("unknown step: err = %v; want ErrValidation", err)
+	}
+	if _, err := applyTransforms(params, map[string][]string{"OTHER": {TransformJSONPretty}}); !errors.Is(err, ErrValidation) {
+		t.Errorf("json-pretty of text: err = %v; want ErrValidation", err)
+	}
+	if _, err := applyTransforms(params, map[string][]string{"D*": {TransformTrim}, "*_HOST": {TransformTrim}}); !errors.Is(err, ErrValidation) {
+		t.Errorf("two matching patterns: err = %v; want ErrValidation", err)
+	}
+}
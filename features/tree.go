@@ -0,0 +1,176 @@
+package features
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// Annotations tree can add to parameters.
+const (
+	TreeType = "type"
+	TreeSize = "size"
+)
+
+// TreeOptions configures tree.
+type TreeOptions struct {
+	Types bool // Show the type of each parameter.
+	Sizes bool // Show the value size of each parameter and the total of each path; values are decrypted.
+}
+
+// ParseTreeAnnotations parses a comma-separated list of TreeType and TreeSize into options.
+func ParseTreeAnnotations(list []string) (TreeOptions, error) {
+	var opts TreeOptions
+	for _, a := range list {
+		switch strings.ToLower(a) {
+		case TreeType:
+			opts.Types = true
+		case TreeSize:
+			opts.Sizes = true
+		default:
+			return TreeOptions{}, validationErrorf("invalid annotation %q (use %s or %s)", a, TreeType, TreeSize)
+		}
+	}
+	return opts, nil
+}
+
+// treeNode is one level of a parameter name. A node can be both a parameter and a path, as /a/b and /a/b/c
+// can both exist.
+type treeNode struct {
+	children  map[string]*treeNode
+	parameter bool
+	paramType types.ParameterType
+	size      int // Bytes of the value, or of all values under a path.
+	count     int // Parameters at and under the node.
+}
+
+// add places a parameter at the path of segments below n.
+func (n *treeNode) add(segments []string, paramType types.ParameterType, size int) {
+	n.count++
+	n.size += size
+	if len(segments) == 0 {
+		n.parameter, n.paramType = true, paramType
+		return
+	}
+	child := n.children[segments[0]]
+	if child == nil {
+		child = &treeNode{children: make(map[string]*treeNode)}
+		n.children[segments[0]] = child
+	}
+	child.add(segments[1:], paramType, size)
+}
+
+// PrintTree prints the parameters under prefix as an indented tree, like tree(1), with the number of
+// parameters under each path, so the structure of a large namespace shows at a glance. Only metadata is
+// read unless opts.Sizes needs the values.
+func PrintTree(client SSMClient, prefix string, opts TreeOptions) error {
+	if !strings.HasPrefix(prefix, "/") {
+		return validationErrorf("tree needs a -prefix path starting with /, got %q", prefix)
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/" // So /prod does not take in /production.
+	}
+	root, err := collectTree(client, prefix, opts)
+	if err != nil {
+		return err
+	}
+	if root.count == 0 {
+		fmt.Printf("No parameters under %s\n", prefix)
+		return nil
+	}
+	fmt.Print(root.render(prefix, opts))
+	return nil
+}
+
+// collectTree returns the tree of the parameters under prefix, which ends in /.
+func collectTree(client SSMClient, prefix string, opts TreeOptions) (*treeNode, error) {
+	root := &treeNode{children: make(map[string]*treeNode)}
+	if opts.Sizes {
+		params, err := listParameters(client, prefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, param := range params {
+			root.add(treeSegments(prefix, aws.ToString(param.Name)), param.Type, len(aws.ToString(param.Value)))
+		}
+		return root, nil
+	}
+	metadata, err := describeParameters(client, types.ParameterStringFilter{
+		Key:    aws.String("Name"),
+		Option: aws.String("BeginsWith"),
+		Values: []string{prefix},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for name, meta := range metadata {
+		root.add(treeSegments(prefix, name), meta.Type, 0)
+	}
+	return root, nil
+}
+
+// render returns the tree below n, named prefix, with children in name order and a summary line.
+func (n *treeNode) render(prefix string, opts TreeOptions) string {
+	var out strings.Builder
+	paths := 0
+	fmt.Fprintf(&out, "%s%s\n", prefix, n.label(opts))
+	var walk func(n *treeNode, indent string)
+	walk = func(n *treeNode, indent string) {
+		names := make([]string, 0, len(n.children))
+		for name := range n.children {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for i, name := range names {
+			child := n.children[name]
+			branch, next := "├── ", "│   "
+			if i == len(names)-1 {
+				branch, next = "└── ", "    "
+			}
+			if len(child.children) > 0 {
+				paths++
+				name += "/"
+			}
+			fmt.Fprintf(&out, "%s%s%s%s\n", indent, branch, name, child.label(opts))
+			walk(child, indent+next)
+		}
+	}
+	walk(n, "")
+	fmt.Fprintf(&out, "\n%d paths, %d parameters\n", paths, n.count)
+	return out.String()
+}
+
+// treeSegments returns the levels of name below prefix.
+func treeSegments(prefix, name string) []string {
+	return strings.Split(strings.TrimPrefix(name, prefix), "/")
+}
+
+// label returns what follows the name of n: the count of a path and the annotations of a parameter.
+func (n *treeNode) label(opts TreeOptions) string {
+	var parts []string
+	if n.parameter && opts.Types {
+		parts = append(parts, string(n.paramType))
+	}
+	if opts.Sizes {
+		parts = append(parts, formatSize(n.size))
+	}
+	var label string
+	if len(n.children) > 0 {
+		label = fmt.Sprintf(" (%d)", n.count)
+	}
+	if len(parts) > 0 {
+		label += " [" + strings.Join(parts, ", ") + "]"
+	}
+	return label
+}
+
+// formatSize formats a size in bytes, in KB from 1 KB.
+func formatSize(bytes int) string {
+	if bytes < 1024 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	return fmt.Sprintf("%.1f KB", float64(bytes)/1024)
+}
@@ -0,0 +1,51 @@
+package features
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestPrintTree(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/prod/api/DB_URL", "postgres://db", types.ParameterTypeSecureString)
+	fake.set("/prod/api/workers/QUEUES", "a,b", types.ParameterTypeStringList)
+	fake.set("/prod/api/workers", "2", types.ParameterTypeString) // Both a parameter and a path.
+	fake.set("/prod/web/API_KEY", strings.Repeat("k", 2048), types.ParameterTypeSecureString)
+	fake.set("/production/OTHER", "x", types.ParameterTypeString)
+
+	opts := TreeOptions{Types: true, Sizes: true}
+	root, err := collectTree(fake, "/prod/", opts)
+	if err != nil {
+		t.Fatalf("collectTree: %v", err)
+	}
+	want := `/prod/ (4) [2.0 KB]
+├── api/ (3) [17 B]
+│   ├── DB_URL [SecureString, 13 B]
+│   └── workers/ (2) [String, 4 B]
+│       └── QUEUES [StringList, 3 B]
+└── web/ (1) [2.0 KB]
+    └── API_KEY [SecureString, 2.0 KB]
+
+3 paths, 4 parameters
+`
+	if out := root.render("/prod/", opts); out != want {
+		t.Errorf("tree =\n%s\nwant\n%s", out, want)
+	}
+
+	if root, err = collectTree(fake, "/prod/api/", TreeOptions{}); err != nil {
+		t.Fatalf("collectTree without annotations: %v", err)
+	}
+	if out := root.render("/prod/api/", TreeOptions{}); !strings.HasPrefix(out, "/prod/api/ (3)\n├── DB_URL\n└── workers/ (2)\n") {
+		t.Errorf("tree without annotations =\n%s", out)
+	}
+
+	if err := PrintTree(fake, "prod", TreeOptions{}); !errors.Is(err, ErrValidation) {
+		t.Errorf("PrintTree without a leading / = %v; want a validation error", err)
+	}
+	if _, err := ParseTreeAnnotations([]string{"type", "owner"}); !errors.Is(err, ErrValidation) {
+		t.Errorf("ParseTreeAnnotations(owner) = %v; want a validation error", err)
+	}
+}
@@ -0,0 +1,218 @@
+package features
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Types of template values that are not plain strings. SSM stores every value as text; the type is recorded
+// as the parameter's AllowedPattern (see valuePatterns), which also keeps later writes to that type, and
+// export restores it.
+const (
+	ValueNumber  = "number"  // A JSON number, stored as its text, e.g. 8080 or 0.25.
+	ValueBoolean = "boolean" // true or false.
+	ValueList    = "list"    // A list of plain values, stored as a StringList.
+)
+
+// valuePatterns maps each value type to the AllowedPattern that records it.
+var valuePatterns = map[string]string{
+	ValueNumber:  `^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`,
+	ValueBoolean: `^(true|false)$`,
+	ValueList:    `^[^,]+(,[^,]+)*$`,
+}
+
+// valueTypeOf returns the value type recorded by allowedPattern, or "" for a plain string.
+func valueTypeOf(allowedPattern string) string {
+	for valueType, pattern := range valuePatterns {
+		if pattern == allowedPattern {
+			return valueType
+		}
+	}
+	return ""
+}
+
+// numberPattern matches the text of a JSON number.
+var numberPattern = regexp.MustCompile(valuePatterns[ValueNumber])
+
+// typedValue returns value, stored as text, as the JSON value of valueType: a json.Number, a bool, a list of
+// strings, or the string itself for a plain string or text that does not parse as its type.
+func typedValue(value, valueType string) interface{} {
+	switch valueType {
+	case ValueNumber:
+		if numberPattern.MatchString(value) {
+			return json.Number(value)
+		}
+	case ValueBoolean:
+		if value == "true" || value == "false" {
+			return value == "true"
+		}
+	case ValueList:
+		if value != "" {
+			return strings.Split(value, ",")
+		}
+	}
+	return value
+}
+
+// parseTemplateValue returns the text SSM stores for a template value and its value type: strings are
+// themselves, numbers keep their exact text, booleans are true or false, and lists of plain values are joined
+// with commas as a StringList. null and a missing value are "".
+func parseTemplateValue(raw json.RawMessage) (string, string, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", "", nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return "", "", err
+	}
+	switch v := v.(type) {
+	case string:
+		return v, "", nil
+	case json.Number:
+		return v.String(), ValueNumber, nil
+	case bool:
+		return fmt.Sprint(v), ValueBoolean, nil
+	case []interface{}:
+		if len(v) == 0 {
+			return "", "", errors.New("an empty list has no value to store")
+		}
+		items := make([]string, len(v))
+		for i, item := range v {
+			switch item := item.(type) {
+			case string:
+				if item == "" || strings.Contains(item, ",") {
+					return "", "", fmt.Errorf("list item %q cannot be stored in a StringList: items must be non-empty and without commas", item)
+				}
+				items[i] = item
+			case json.Number, bool:
+				items[i] = fmt.Sprint(item)
+			default:
+				return "", "", errors.New("lists can only hold strings, numbers and booleans")
+			}
+		}
+		return strings.Join(items, ","), ValueList, nil
+	}
+	return "", "", errors.New("a value must be a string, number, boolean or list")
+}
+
+// UnmarshalJSON reads a secret whose value may be typed (see ValueNumber); ValueType records the type. A list
+// makes the secret a StringList, so its type must be empty or stringlist.
+func (s *ExtendedSecret) UnmarshalJSON(data []byte) error {
+	type plain ExtendedSecret
+	v := struct {
+		*plain
+		Value json.RawMessage `json:"value,omitempty"`
+	}{plain: (*plain)(s)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	value, valueType, err := parseTemplateValue(v.Value)
+	if err != nil {
+		return fmt.Errorf("secret %s: value: %w", s.Name, err)
+	}
+	s.Value, s.ValueType = value, valueType
+	if valueType == ValueList {
+		switch strings.ToLower(string(s.Type)) {
+		case "":
+			s.Type = StringListType
+		case "stringlist":
+		default:
+			return fmt.Errorf("secret %s: a list value needs type stringlist, not %s", s.Name, s.Type)
+		}
+	}
+	return nil
+}
+
+// MarshalJSON writes the value typed according to ValueType, with the fields in the order of ExtendedSecret.
+func (s ExtendedSecret) MarshalJSON() ([]byte, error) {
+	type plain ExtendedSecret
+	if s.ValueType == "" || s.Value == "" {
+		return json.Marshal(plain(s))
+	}
+	return json.Marshal(struct {
+		Name               string            `json:"name"`
+		ValueFrom          string            `json:"valueFrom"`
+		Type               ParameterType     `json:"type,omitempty"`
+		Value              interface{}       `json:"value"`
+		KMSKeyID           string            `json:"kmsKeyId,omitempty"`
+		DataType           string            `json:"dataType,omitempty"`
+		Tier               string            `json:"tier,omitempty"`
+		Description        string            `json:"description,omitempty"`
+		Tags               map[string]string `json:"tags,omitempty"`
+		ValueFromParameter string            `json:"valueFromParameter,omitempty"`
+		Default            string            `json:"default,omitempty"`
+	}{s.Name, s.ValueFrom, s.Type, typedValue(s.Value, s.ValueType), s.KMSKeyID, s.DataType, s.Tier, s.Description, s.Tags,
+		s.ValueFromParameter, s.Default})
+}
+
+// typedFormats are the output formats that can hold typed values.
+var typedFormats = map[string]bool{FormatJSON: true, FormatYAML: true, FormatTaskDef: true}
+
+// loadMetadata sets the ValueType of params from the AllowedPattern of the parameters under prefix, and
+// their KeyID, Tier and Description. The metadata comes from DescribeParameters; when it is not allowed,
+// values stay strings and a warning is printed.
+func loadMetadata(client SSMClient, prefix string, params []OutputParam) error {
+	metadata, err := describeParameters(client, types.ParameterStringFilter{
+		Key:    aws.String("Path"),
+		Option: aws.String("Recursive"),
+		Values: []string{strings.TrimSuffix(prefix, "/")},
+	})
+	if errors.Is(err, ErrAccessDenied) {
+		Infof("%s value types and metadata not read, typed values are exported as strings: %s\n", yellow("Warning:"), DescribeError(err))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for i := range params {
+		meta := metadata[params[i].Name]
+		params[i].ValueType = valueTypeOf(aws.ToString(meta.AllowedPattern))
+		if keyID := aws.ToString(meta.KeyId); keyID != defaultSSMKey {
+			params[i].KeyID = keyID
+		}
+		if meta.Tier != "" && meta.Tier != types.ParameterTierStandard {
+			params[i].Tier = strings.ToLower(string(meta.Tier))
+		}
+		params[i].Description = aws.ToString(meta.Description)
+	}
+	return nil
+}
+
+// nestParams is nestVars for the formats that keep value types: each leaf is typedValue of the parameter.
+func nestParams(format string, params []OutputParam) (map[string]interface{}, error) {
+	root := make(map[string]interface{})
+	for _, p := range params {
+		parts := splitPath(p.Key)
+		if len(parts) == 0 {
+			return nil, validationErrorf("%q is not a valid %s key", p.Key, format)
+		}
+		if err := insertTree(root, parts, typedValue(p.Value, p.ValueType)); err != nil {
+			return nil, err
+		}
+	}
+	return root, nil
+}
+
+// yamlNumbers replaces the json.Numbers of a nestParams tree with plain YAML scalars, which keep their exact
+// text; yaml.v3 would write them as strings.
+func yamlNumbers(tree map[string]interface{}) {
+	for key, v := range tree {
+		switch v := v.(type) {
+		case json.Number:
+			tree[key] = &yaml.Node{Kind: yaml.ScalarNode, Value: v.String()}
+		case map[string]interface{}:
+			yamlNumbers(v)
+		}
+	}
+}
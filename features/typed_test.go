@@ -0,0 +1,131 @@
+package features
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/smithy-go"
+)
+
+func TestParseTemplateValue(t *testing.T) {
+	tests := []struct {
+		raw       string
+		value     string
+		valueType string
+		wantErr   bool
+	}{
+		{`"8080"`, "8080", "", false},
+		{`8080`, "8080", ValueNumber, false},
+		{`1.50e3`, "1.50e3", ValueNumber, false},
+		{`false`, "false", ValueBoolean, false},
+		{`["a", 2, true]`, "a,2,true", ValueList, false},
+		{`null`, "", "", false},
+		{`[]`, "", "", true},
+		{`["a,b"]`, "", "", true},
+		{`[["a"]]`, "", "", true},
+		{`{"a": 1}`, "", "", true},
+	}
+	for _, tt := range tests {
+		value, valueType, err := parseTemplateValue(json.RawMessage(tt.raw))
+		if (err != nil) != tt.wantErr || value != tt.value || valueType != tt.valueType {
+			t.Errorf("parseTemplateValue(%s) = %q, %q, %v; want %q, %q, error %v", tt.raw, value, valueType, err, tt.value, tt.valueType, tt.wantErr)
+		}
+	}
+}
+
+func TestExtendedSecretTypedValues(t *testing.T) {
+	var secret ExtendedSecret
+	if err := json.Unmarshal([]byte(`{"name": "HOSTS", "valueFrom": "/app/HOSTS", "value": ["a", "b"]}`), &secret); err != nil {
+		t.Fatal(err)
+	}
+	if secret.Value != "a,b" || secret.ValueType != ValueList || secret.Type != StringListType {
+		t.Errorf("secret = %+v; want a StringList of a,b", secret)
+	}
+	data, err := json.Marshal(secret)
+	if want := `{"name":"HOSTS","valueFrom":"/app/HOSTS","type":"StringList","value":["a","b"]}`; err != nil || string(data) != want {
+		t.Errorf("Marshal = %s, %v; want %s", data, err, want)
+	}
+	err = json.Unmarshal([]byte(`{"name": "HOSTS", "type": "securestring", "value": ["a"]}`), &secret)
+	if err == nil || !strings.Contains(err.Error(), "needs type stringlist") {
+		t.Errorf("list as securestring err = %v; want a type error", err)
+	}
+}
+
+func TestTypedValuesRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	template := filepath.Join(dir, "task.json")
+	data := `{"containerDefinitions": [{"secrets": [
+  {"name": "PORT", "valueFrom": "/app/PORT", "value": 8080},
+  {"name": "DEBUG", "valueFrom": "/app/DEBUG", "value": false},
+  {"name": "HOSTS", "valueFrom": "/app/HOSTS", "value": ["a", "b"]},
+  {"name": "ZONE", "valueFrom": "/app/ZONE", "value": "42"}
+]}]}`
+	if err := os.WriteFile(template, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeSSM()
+	if err := PutParametersFromTemplate(fake, template, TemplateOptions{}, PutOptions{AssumeYes: true}); err != nil {
+		t.Fatalf("PutParametersFromTemplate: %v", err)
+	}
+	if got := aws.ToString(fake.params["/app/PORT"].Value); got != "8080" {
+		t.Errorf("/app/PORT = %q; want 8080", got)
+	}
+	if got := fake.meta["/app/PORT"].AllowedPattern; aws.ToString(got) != valuePatterns[ValueNumber] {
+		t.Errorf("/app/PORT AllowedPattern = %v; want the number pattern", aws.ToString(got))
+	}
+
+	base := filepath.Join(dir, "out")
+	if err := ExportParameters(fake, "/app/", ExportOptions{Formats: []string{FormatYAML, FormatTaskDef}, Output: base}); err != nil {
+		t.Fatalf("ExportParameters: %v", err)
+	}
+	yamlData, _ := os.ReadFile(base + ".yaml")
+	if want := "DEBUG: false\nHOSTS:\n  - a\n  - b\nPORT: 8080\nZONE: \"42\"\n"; string(yamlData) != want {
+		t.Errorf("yaml =\n%s\nwant\n%s", yamlData, want)
+	}
+	taskDefData, _ := os.ReadFile(base + ".json")
+	for _, want := range []string{`"value": 8080`, `"value": false`, `"value": "42"`} {
+		if !strings.Contains(string(taskDefData), want) {
+			t.Errorf("taskdef does not contain %s:\n%s", want, taskDefData)
+		}
+	}
+
+	// The exported task definition puts the same values with the same types.
+	again := newFakeSSM()
+	if err := PutParametersFromTemplate(again, base+".json", TemplateOptions{}, PutOptions{AssumeYes: true}); err != nil {
+		t.Fatalf("PutParametersFromTemplate of the export: %v", err)
+	}
+	for name, param := range fake.params {
+		if aws.ToString(again.params[name].Value) != aws.ToString(param.Value) || again.params[name].Type != param.Type ||
+			aws.ToString(again.meta[name].AllowedPattern) != aws.ToString(fake.meta[name].AllowedPattern) {
+			t.Errorf("%s after the round trip = %+v; want %+v", name, again.params[name], param)
+		}
+	}
+}
+
+func TestExportWithoutDescribeAccess(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/app/PORT", "8080", types.ParameterTypeString)
+	client := denyDescribe{fake}
+	base := filepath.Join(t.TempDir(), "out")
+	if err := ExportParameters(client, "/app/", ExportOptions{Formats: []string{FormatJSON}, Output: base}); err != nil {
+		t.Fatalf("ExportParameters: %v", err)
+	}
+	data, _ := os.ReadFile(base + ".json")
+	if want := "{\n  \"PORT\": \"8080\"\n}\n"; string(data) != want {
+		t.Errorf("json = %s; want %s", data, want)
+	}
+}
+
+// denyDescribe fails DescribeParameters with access denied.
+type denyDescribe struct{ SSMClient }
+
+func (denyDescribe) DescribeParameters(ctx context.Context, params *ssm.DescribeParametersInput, optFns ...func(*ssm.Options)) (*ssm.DescribeParametersOutput, error) {
+	return nil, &smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not authorized"}
+}
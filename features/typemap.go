@@ -0,0 +1,156 @@
+package features
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TypeMapFile is the name of the type mapping file generate reads next to its source when -types is not given.
+const TypeMapFile = "types.yaml"
+
+// TypeMap pins the type, KMS key or path of source keys, consulted before the detection rules so that known
+// keys are classified the same way every time and the rules only handle the others. A file maps each key, or
+// a path.Match pattern such as "*_URL", to a type or to the settings of a "# param:" comment:
+//
+//	DB_PASSWORD: securestring
+//	PUBLIC_KEY_ID: string
+//	"*_URL": string
+//	DB_URL:
+//	  type: securestring
+//	  kms: alias/app
+//	  path: /prod/app/DATABASE_URL
+//
+// A key uses its exact entry, otherwise the one pattern it matches; matching several patterns is an error.
+type TypeMap struct {
+	File     string
+	entries  map[string]envDirective
+	patterns []string // Sorted.
+}
+
+// ReadTypeMap reads and checks a type mapping file.
+func ReadTypeMap(filename string) (*TypeMap, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read type mapping: %w", err)
+	}
+	var doc map[string]yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, validationErrorf("failed to parse %s: %v", filename, err)
+	}
+	m := &TypeMap{File: filename, entries: make(map[string]envDirective, len(doc))}
+	for key, node := range doc {
+		if key == "" || strings.HasPrefix(key, "/") {
+			return nil, validationErrorf("%s: key %q must be a source key, not a parameter path (use path: to set the path)", filename, key)
+		}
+		if _, err := path.Match(key, ""); err != nil {
+			return nil, validationErrorf("%s: invalid key pattern %q: %v", filename, key, err)
+		}
+		var d envDirective
+		if err := d.decode(&node); err != nil {
+			return nil, validationErrorf("%s line %d: %s: %v", filename, node.Line, key, err)
+		}
+		m.entries[key] = d
+		if strings.ContainsAny(key, "*?[") {
+			m.patterns = append(m.patterns, key)
+		}
+	}
+	sort.Strings(m.patterns)
+	return m, nil
+}
+
+// decode sets d from a type name or a mapping of type, kms and path.
+func (d *envDirective) decode(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return d.set("type", node.Value)
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if value.Kind != yaml.ScalarNode || value.Value == "" {
+				return fmt.Errorf("%s needs a value", key.Value)
+			}
+			if err := d.set(key.Value, value.Value); err != nil {
+				return err
+			}
+		}
+		if *d == (envDirective{}) {
+			return fmt.Errorf("no type, kms or path")
+		}
+		return d.check()
+	}
+	return fmt.Errorf("expected a type or a mapping of type, kms and path")
+}
+
+// lookup returns the entry of key: its own, otherwise that of the one pattern it matches.
+func (m *TypeMap) lookup(key string) (envDirective, bool, error) {
+	if d, ok := m.entries[key]; ok {
+		return d, true, nil
+	}
+	var matched []string
+	for _, pattern := range m.patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			matched = append(matched, pattern)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return envDirective{}, false, nil
+	case 1:
+		return m.entries[matched[0]], true, nil
+	}
+	return envDirective{}, false, validationErrorf("key %s matches several patterns of %s: %s", key, m.File, strings.Join(matched, ", "))
+}
+
+// apply returns param with the settings of its entry where the source left them empty, and whether it has
+// one: a "# param:" comment or a type column in the source still wins. A nil map applies nothing.
+func (m *TypeMap) apply(param InputParam) (InputParam, bool, error) {
+	if m == nil {
+		return param, false, nil
+	}
+	d, ok, err := m.lookup(param.Key)
+	if err != nil || !ok {
+		return param, false, err
+	}
+	if param.Type == "" {
+		param.Type = d.Type
+		if d.Type == "" && d.KMS != "" {
+			param.Type = SecureStringType
+		}
+	}
+	if param.KeyID == "" && param.Type == SecureStringType {
+		param.KeyID = d.KMS
+	}
+	if param.Name == "" {
+		param.Name = d.Path
+	}
+	return param, true, nil
+}
+
+// typeMapFor returns the type mapping of source: file when it is set, otherwise TypeMapFile in the
+// directory of source if there is one, otherwise nil.
+func typeMapFor(source, file string) (*TypeMap, error) {
+	if file != "" {
+		return ReadTypeMap(file)
+	}
+	if source == StdinSource {
+		return nil, nil
+	}
+	sidecar := filepath.Join(filepath.Dir(source), TypeMapFile)
+	if _, err := os.Stat(sidecar); err != nil {
+		return nil, nil
+	}
+	m, err := ReadTypeMap(sidecar)
+	if err == nil {
+		Infof("Using the type mapping of %s\n", sidecar)
+	}
+	return m, err
+}
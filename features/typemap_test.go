@@ -0,0 +1,79 @@
+package features
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGenerateWithTypeMap(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	source := write("app.env", "API_ENDPOINT=https://api.example.com\nLICENSE=abc\nDB_URL=postgres://db\nSERVICE_URL=https://svc\n# param: type=string\nSIGNING=xyz\n")
+	write(TypeMapFile, `
+API_ENDPOINT: string
+LICENSE: securestring
+SIGNING: securestring
+"*_URL": string
+DB_URL:
+  kms: alias/app
+  path: /prod/app/DATABASE_URL
+`)
+
+	out := filepath.Join(dir, "task.json")
+	if err := GenerateTaskDef(source, out, GenerateOptions{Prefix: "/app/"}); err != nil {
+		t.Fatalf("GenerateTaskDef: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var taskDef TaskDefinition
+	if err := json.Unmarshal(data, &taskDef); err != nil {
+		t.Fatal(err)
+	}
+	want := []ExtendedSecret{
+		{Name: "API_ENDPOINT", ValueFrom: "/app/API_ENDPOINT", Type: StringType, Value: "https://api.example.com"}, // Not "api" in the key.
+		{Name: "LICENSE", ValueFrom: "/app/LICENSE", Type: SecureStringType, Value: "abc"},
+		{Name: "DB_URL", ValueFrom: "/prod/app/DATABASE_URL", Type: SecureStringType, Value: "postgres://db", KMSKeyID: "alias/app"},
+		{Name: "SERVICE_URL", ValueFrom: "/app/SERVICE_URL", Type: StringType, Value: "https://svc"},
+		{Name: "SIGNING", ValueFrom: "/app/SIGNING", Type: StringType, Value: "xyz"}, // # param: wins.
+	}
+	if got := taskDef.ContainerDefinitions[0].Secrets; !reflect.DeepEqual(got, want) {
+		t.Errorf("secrets = %+v; want %+v", got, want)
+	}
+
+	other := write("other.yaml", "\"LIC*\": string\n\"*NSE\": securestring\n")
+	if err := GenerateTaskDef(source, out, GenerateOptions{Prefix: "/app/", Types: other}); ExitCode(err) != ExitValidation {
+		t.Errorf("GenerateTaskDef with overlapping patterns = %v; want a validation error", err)
+	}
+}
+
+func TestReadTypeMapErrors(t *testing.T) {
+	dir := t.TempDir()
+	for _, content := range []string{
+		"KEY: secret\n",
+		"KEY:\n  type: string\n  kms: alias/app\n",
+		"KEY:\n  region: us-east-1\n",
+		"/prod/KEY: string\n",
+		"KEY: [string]\n",
+		"- KEY\n",
+	} {
+		path := filepath.Join(dir, TypeMapFile)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadTypeMap(path); ExitCode(err) != ExitValidation {
+			t.Errorf("ReadTypeMap(%q) = %v; want a validation error", content, err)
+		}
+	}
+}
@@ -21,10 +21,24 @@ type Environment struct {
 
 // ExtendedSecret extends Secret with type and value for pusher functionality.
 type ExtendedSecret struct {
-	Name      string        `json:"name"`            // The environment variable name.
-	ValueFrom string        `json:"valueFrom"`       // The SSM parameter ARN.
-	Type      ParameterType `json:"type,omitempty"`  // Parameter type: string, stringlist, securestring.
-	Value     string        `json:"value,omitempty"` // The value to store in SSM.
+	Name      string        `json:"name"`               // The environment variable name.
+	ValueFrom string        `json:"valueFrom"`          // The SSM parameter ARN.
+	Type      ParameterType `json:"type,omitempty"`     // Parameter type: string, stringlist, securestring.
+	Value     string        `json:"value,omitempty"`    // The value to store in SSM.
+	KMSKeyID  string        `json:"kmsKeyId,omitempty"` // KMS key for a SecureString; empty uses aws/ssm.
+	DataType  string        `json:"dataType,omitempty"` // Data type of a String: text, or aws:ec2:image for an AMI ID.
+	// Tier is the tier to put the parameter with: standard, advanced or intelligent-tiering; empty uses -tier.
+	Tier string `json:"tier,omitempty"`
+	// Description is stored with the parameter.
+	Description string `json:"description,omitempty"`
+	// Tags are added to the parameter; tags it already has with other keys are kept.
+	Tags map[string]string `json:"tags,omitempty"`
+	// ValueFromParameter names a parameter (path or ARN) whose value put-from-template copies instead of Value.
+	ValueFromParameter string `json:"valueFromParameter,omitempty"`
+	// Default is what get -s -missing placeholder writes when the parameter does not exist; it is never put.
+	Default string `json:"default,omitempty"`
+	// ValueType is how Value was typed in the template: ValueNumber, ValueBoolean, ValueList, or "" for a string.
+	ValueType string `json:"-"`
 }
 
 // ContainerDefinition holds the environment and secrets arrays for a container.
@@ -40,8 +54,23 @@ type TaskDefinition struct {
 
 // Config holds configuration settings for the tool.
 type Config struct {
-	ParameterPrefix string `json:"parameterPrefix"` // Prefix for parameter paths, e.g., "/preprod/testing/"
-	Region          string `json:"region"`          // Default AWS region.
+	SchemaVersion     int                 `json:"schemaVersion"`               // Version of the file's layout (see ConfigSchemaVersion).
+	ParameterPrefix   string              `json:"parameterPrefix"`             // Prefix for parameter paths, e.g., "/preprod/testing/"
+	Region            string              `json:"region"`                      // Default AWS region.
+	Variables         map[string]string   `json:"variables,omitempty"`         // Values for {{name}} placeholders in template valueFrom paths.
+	FallbackToPrefix  bool                `json:"fallbackToPrefix,omitempty"`  // Derive missing template valueFrom from ParameterPrefix instead of failing.
+	PathPattern       string              `json:"pathPattern,omitempty"`       // Path of logical template names, e.g. "/{{env}}/{{app}}/{{name}}".
+	Transforms        map[string][]string `json:"transforms,omitempty"`        // Transform steps applied to exported values, by key or key pattern.
+	Accounts          []Account           `json:"accounts,omitempty"`          // Accounts for -accounts/-all-accounts read-only fan-out.
+	NameRules         *NameRules          `json:"nameRules,omitempty"`         // Normalization of keys in paths composed by generate.
+	KMSKeyID          string              `json:"kmsKeyId,omitempty"`          // KMS key for SecureStrings that do not name one; empty uses aws/ssm.
+	TPS               float64             `json:"tps,omitempty"`               // Default for -tps.
+	Endpoint          string              `json:"endpoint,omitempty"`          // SSM endpoint URL, e.g. for LocalStack; empty uses AWS's.
+	ReadOnly          bool                `json:"readOnly,omitempty"`          // Refuse mutating actions, as -read-only does.
+	ProtectedPrefixes []string            `json:"protectedPrefixes,omitempty"` // Writes below these prefixes need -i-know-what-i-am-doing.
+	AuditLog          string              `json:"auditLog,omitempty"`          // File justified protected writes are logged to; empty uses audit.log.
+	Hooks             *Hooks              `json:"hooks,omitempty"`             // Commands and webhooks run around writes.
+	Detection         *DetectionConfig    `json:"detection,omitempty"`         // Rules typing new keys as SecureString (see DetectionRule).
 }
 
 // ParameterType represents the type of SSM parameter.
@@ -53,12 +82,55 @@ const (
 	StringListType   ParameterType = "StringList"
 )
 
-// LoadConfig reads config.json if it exists, otherwise creates it with defaults.
-func LoadConfig() (*Config, error) {
-	config := &Config{
+// ParseParameterType parses a case-insensitive type name: string, stringlist or securestring.
+func ParseParameterType(s string) (ParameterType, error) {
+	switch strings.ToLower(s) {
+	case "string":
+		return StringType, nil
+	case "stringlist":
+		return StringListType, nil
+	case "securestring":
+		return SecureStringType, nil
+	}
+	return "", validationErrorf("invalid type %q (use string, stringlist or securestring)", s)
+}
+
+// defaultConfig returns the settings used when config.json does not exist.
+func defaultConfig() *Config {
+	return &Config{
+		SchemaVersion:   ConfigSchemaVersion,
 		ParameterPrefix: "/preprod/testing/",
 		Region:          "ap-southeast-3",
 	}
+}
+
+// ReadConfig reads config.json if it exists and returns the defaults otherwise, without creating the file.
+// It is meant for callers such as shell completion that must not write to the working directory; a file of
+// an older schema version is migrated in memory only. Environment variables override the file (see applyConfigEnv).
+func ReadConfig() (*Config, error) {
+	config := defaultConfig()
+	data, err := os.ReadFile("config.json")
+	if os.IsNotExist(err) {
+		return config, applyConfigEnv(config)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config.json: %w", err)
+	}
+	if data, _, err = migrateConfig(data); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse config.json: %w", err)
+	}
+	return config, applyConfigEnv(config)
+}
+
+// LoadConfig reads config.json if it exists, otherwise creates it with defaults. A file of an older schema
+// version is migrated and written back (with its fields sorted), or only used when the file cannot be written.
+// Writes are atomic, so concurrent invocations cannot corrupt the file. Environment variables override the
+// settings of the file, or of the defaults, and are never written to it (see applyConfigEnv).
+func LoadConfig() (*Config, error) {
+	config := defaultConfig()
 	data, err := os.ReadFile("config.json")
 	if err != nil {
 		// File doesn't exist, create it with defaults
@@ -66,23 +138,44 @@ func LoadConfig() (*Config, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal default config: %w", err)
 		}
-		err = os.WriteFile("config.json", defaultData, 0644)
+		err = writeFileAtomic("config.json", append(defaultData, '\n'), 0644)
 		if err != nil {
 			return nil, fmt.Errorf("failed to write default config.json: %w", err)
 		}
-		fmt.Println("Generated default config.json")
-		return config, nil
+		infoln("Generated default config.json")
+		return config, applyConfigEnv(config)
 	}
-	err = json.Unmarshal(data, config)
+	migrated, version, err := migrateConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	if version < ConfigSchemaVersion {
+		if err := writeFileAtomic("config.json", migrated, 0644); err != nil {
+			Infof("Warning: config.json (schema version %d) migrated in memory only: %v\n", version, err)
+		} else {
+			Infof("Migrated config.json from schema version %d to %d\n", version, ConfigSchemaVersion)
+		}
+	}
+	err = json.Unmarshal(migrated, config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse config.json: %w", err)
 	}
-	return config, nil
+	return config, applyConfigEnv(config)
 }
 
-// extractParameterName parses an SSM parameter ARN and returns the parameter path.
-// Example: arn:aws:ssm:region:account:parameter/path/name -> /path/name
+// ExtractParameterName returns the parameter name referenced by an SSM parameter ARN or a bare path.
+// Examples: arn:aws:ssm:region:account:parameter/path/name -> /path/name, /path/name -> /path/name.
+// An ARN of a name outside any hierarchy gives the bare name, as SSM names it:
+// arn:aws:ssm:region:account:parameter/name -> name, so names round-trip through ParameterARN.
+// It returns "" for anything that is neither.
 func ExtractParameterName(arn string) string {
+	if !strings.HasPrefix(arn, "arn:") {
+		// Bare parameter path or name, as accepted by ECS valueFrom in the same region.
+		if arn == "" || strings.Contains(arn, ":") {
+			return ""
+		}
+		return arn
+	}
 	// Split the ARN by colons to extract components.
 	parts := strings.Split(arn, ":")
 	if len(parts) < 6 || parts[2] != "ssm" {
@@ -92,6 +185,45 @@ func ExtractParameterName(arn string) string {
 	if !strings.HasPrefix(paramPath, "parameter/") {
 		return "" // Not an SSM parameter ARN.
 	}
-	// Return the path with a leading slash.
-	return "/" + strings.TrimPrefix(paramPath, "parameter/")
+	name := strings.TrimPrefix(paramPath, "parameter/")
+	if !strings.Contains(name, "/") {
+		return name // Not in a hierarchy.
+	}
+	return "/" + name // Hierarchical names start with a slash, which the ARN drops.
+}
+
+// ParseParameterARN splits an SSM parameter ARN into its region, account and parameter name.
+// ok is false when arn is not an SSM parameter ARN.
+func ParseParameterARN(arn string) (region, accountID, name string, ok bool) {
+	if !strings.HasPrefix(arn, "arn:") {
+		return "", "", "", false
+	}
+	name = ExtractParameterName(arn)
+	if name == "" {
+		return "", "", "", false
+	}
+	parts := strings.Split(arn, ":")
+	return parts[3], parts[4], name, true
+}
+
+// ParameterARN builds the full SSM ARN for a parameter name in the given region and account. The ARN of
+// /path/name ends in parameter/path/name and that of a bare name in parameter/name; ExtractParameterName
+// reverses it. A top-level /name has the same ARN as name and comes back as name.
+func ParameterARN(region, accountID, name string) string {
+	return fmt.Sprintf("arn:aws:ssm:%s:%s:parameter/%s", region, accountID, strings.TrimPrefix(name, "/"))
+}
+
+// ReferenceOptions controls how valueFrom references are written in generated templates.
+type ReferenceOptions struct {
+	AsARN     bool   // Emit full ARNs instead of bare paths.
+	Region    string // Region used in emitted ARNs.
+	AccountID string // Account used in emitted ARNs.
+}
+
+// Format returns the valueFrom reference for a parameter name.
+func (o ReferenceOptions) Format(name string) string {
+	if !o.AsARN {
+		return name
+	}
+	return ParameterARN(o.Region, o.AccountID, name)
 }
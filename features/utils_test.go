@@ -0,0 +1,65 @@
+package features
+
+import "testing"
+
+func TestExtractParameterName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"arn:aws:ssm:ap-southeast-3:1234:parameter/preprod/testing/exp1", "/preprod/testing/exp1"},
+		{"arn:aws:ssm:ap-southeast-3:1234:parameter/plain-name", "plain-name"},
+		{"/prod/app/KEY", "/prod/app/KEY"},
+		{"plain-name", "plain-name"},
+		{"arn:aws:secretsmanager:ap-southeast-3:1234:secret:db", ""},
+		{"arn:aws:ssm:ap-southeast-3:1234:document/doc", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := ExtractParameterName(tt.in); got != tt.want {
+			t.Errorf("ExtractParameterName(%q) = %q; want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestReferenceOptionsFormat(t *testing.T) {
+	if got := (ReferenceOptions{}).Format("/prod/app/KEY"); got != "/prod/app/KEY" {
+		t.Errorf("path Format = %q", got)
+	}
+	refs := ReferenceOptions{AsARN: true, Region: "ap-southeast-3", AccountID: "1234"}
+	want := "arn:aws:ssm:ap-southeast-3:1234:parameter/prod/app/KEY"
+	if got := refs.Format("/prod/app/KEY"); got != want {
+		t.Errorf("arn Format = %q; want %q", got, want)
+	}
+	if got := ExtractParameterName(refs.Format("/prod/app/KEY")); got != "/prod/app/KEY" {
+		t.Errorf("round trip = %q", got)
+	}
+}
+
+func TestParameterARNRoundTrip(t *testing.T) {
+	for _, name := range []string{"plain-name", "/prod/app/KEY", "/prod/KEY"} {
+		arn := ParameterARN("ap-southeast-3", "1234", name)
+		if got := ExtractParameterName(arn); got != name {
+			t.Errorf("ExtractParameterName(ParameterARN(%q)) = %q (ARN %s)", name, got, arn)
+		}
+		if _, _, got, ok := ParseParameterARN(arn); !ok || got != name {
+			t.Errorf("ParseParameterARN(%s) = %q, %v; want %q", arn, got, ok, name)
+		}
+	}
+}
+
+func TestSelectAccounts(t *testing.T) {
+	accounts := []Account{{Name: "staging"}, {Name: "prod"}}
+
+	all, err := SelectAccounts(accounts, nil)
+	if err != nil || len(all) != 2 {
+		t.Fatalf("SelectAccounts(all) = %v, %v", all, err)
+	}
+	picked, err := SelectAccounts(accounts, []string{"prod"})
+	if err != nil || len(picked) != 1 || picked[0].Name != "prod" {
+		t.Errorf("SelectAccounts(prod) = %v, %v", picked, err)
+	}
+	if _, err := SelectAccounts(accounts, []string{"sandbox"}); err == nil {
+		t.Error("SelectAccounts(unknown) succeeded; want error")
+	}
+}
@@ -0,0 +1,314 @@
+package features
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Environment variables the Vault actions read, named as the vault CLI names them.
+const (
+	VaultAddrEnv      = "VAULT_ADDR"      // Vault server URL; defaultVaultAddr when unset.
+	VaultTokenEnv     = "VAULT_TOKEN"     // Token to authenticate with.
+	VaultRoleIDEnv    = "VAULT_ROLE_ID"   // AppRole role ID, used with VAULT_SECRET_ID when there is no token.
+	VaultSecretIDEnv  = "VAULT_SECRET_ID" // AppRole secret ID.
+	VaultNamespaceEnv = "VAULT_NAMESPACE" // Vault Enterprise namespace; empty for the root namespace.
+	VaultCACertEnv    = "VAULT_CACERT"    // PEM file of the CA that signed the server certificate.
+)
+
+const defaultVaultAddr = "http://127.0.0.1:8200"
+
+// VaultOptions says which Vault server to use and how to authenticate to it.
+type VaultOptions struct {
+	Address   string
+	Token     string
+	RoleID    string // AppRole login (auth/approle) when Token is empty.
+	SecretID  string
+	Namespace string
+	CACert    string
+}
+
+// VaultOptionsFromEnv returns the options set by the VAULT_* environment variables.
+func VaultOptionsFromEnv() VaultOptions {
+	return VaultOptions{
+		Address:   os.Getenv(VaultAddrEnv),
+		Token:     os.Getenv(VaultTokenEnv),
+		RoleID:    os.Getenv(VaultRoleIDEnv),
+		SecretID:  os.Getenv(VaultSecretIDEnv),
+		Namespace: os.Getenv(VaultNamespaceEnv),
+		CACert:    os.Getenv(VaultCACertEnv),
+	}
+}
+
+// VaultError reports a failed Vault request. errors.Is matches the Err* kind of its status, like agent errors.
+type VaultError struct {
+	Method string
+	Path   string
+	Status int
+	Errors []string // Messages from the response body.
+}
+
+func (e *VaultError) Error() string {
+	msg := http.StatusText(e.Status)
+	if len(e.Errors) > 0 {
+		msg = strings.Join(e.Errors, "; ")
+	}
+	return fmt.Sprintf("vault %s %s: %d %s", e.Method, e.Path, e.Status, msg)
+}
+
+func (e *VaultError) Unwrap() error { return statusKind(e.Status) }
+
+// vaultClient calls the Vault HTTP API.
+type vaultClient struct {
+	http      *http.Client
+	address   string
+	token     string
+	namespace string
+}
+
+// newVaultClient connects to the server of opts, logging in with AppRole when no token is given.
+func newVaultClient(opts VaultOptions) (*vaultClient, error) {
+	c := &vaultClient{
+		http:      &http.Client{Timeout: 30 * time.Second},
+		address:   strings.TrimSuffix(opts.Address, "/"),
+		token:     opts.Token,
+		namespace: opts.Namespace,
+	}
+	if c.address == "" {
+		c.address = defaultVaultAddr
+	}
+	if opts.CACert != "" {
+		pem, err := os.ReadFile(opts.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", VaultCACertEnv, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, validationErrorf("%s %s holds no PEM certificates", VaultCACertEnv, opts.CACert)
+		}
+		c.http.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}
+	}
+	if c.token != "" {
+		return c, nil
+	}
+	if opts.RoleID == "" || opts.SecretID == "" {
+		return nil, validationErrorf("no Vault credentials: set %s, or %s and %s for AppRole", VaultTokenEnv, VaultRoleIDEnv, VaultSecretIDEnv)
+	}
+	var login struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	body := map[string]string{"role_id": opts.RoleID, "secret_id": opts.SecretID}
+	if err := c.do(http.MethodPost, "auth/approle/login", body, &login); err != nil {
+		return nil, fmt.Errorf("AppRole login failed: %w", err)
+	}
+	if login.Auth.ClientToken == "" {
+		return nil, fmt.Errorf("AppRole login returned no token")
+	}
+	c.token = login.Auth.ClientToken
+	return c, nil
+}
+
+// do sends body (if not nil) as JSON to the API path and decodes the response into out (if not nil).
+func (c *vaultClient) do(method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.address+"/v1/"+path, reader)
+	if err != nil {
+		return validationErrorf("invalid %s %q: %w", VaultAddrEnv, c.address, err)
+	}
+	if c.token != "" {
+		req.Header.Set("X-Vault-Token", c.token)
+	}
+	if c.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("vault not reachable at %s (set %s): %w", c.address, VaultAddrEnv, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Errors []string `json:"errors"`
+		}
+		json.Unmarshal(data, &apiErr)
+		return &VaultError{Method: method, Path: path, Status: resp.StatusCode, Errors: apiErr.Errors}
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("invalid vault response for %s: %w", path, err)
+	}
+	return nil
+}
+
+// kvPath returns the API path of the secret at path, such as secret/prod/app, and whether its KV secrets
+// engine is version 2, which keeps the secret under <mount>/data/.
+func (c *vaultClient) kvPath(path string) (string, bool, error) {
+	path = strings.Trim(path, "/")
+	if path == "" || !strings.Contains(path, "/") {
+		return "", false, validationErrorf("-vault-path must be <mount>/<path>, such as secret/prod/app, got %q", path)
+	}
+	var mount struct {
+		Data struct {
+			Path    string            `json:"path"`
+			Type    string            `json:"type"`
+			Options map[string]string `json:"options"`
+		} `json:"data"`
+	}
+	if err := c.do(http.MethodGet, "sys/internal/ui/mounts/"+path, nil, &mount); err != nil {
+		return "", false, fmt.Errorf("failed to look up the secrets engine of %s: %w", path, err)
+	}
+	if mount.Data.Type != "kv" && mount.Data.Type != "generic" {
+		return "", false, validationErrorf("%s is in a %s secrets engine, not kv", path, mount.Data.Type)
+	}
+	if mount.Data.Options["version"] != "2" {
+		return path, false, nil
+	}
+	mountPath := strings.TrimSuffix(mount.Data.Path, "/")
+	return mountPath + "/data/" + strings.TrimPrefix(strings.TrimPrefix(path, mountPath), "/"), true, nil
+}
+
+// readKV returns the data of the KV secret at path.
+func (c *vaultClient) readKV(path string) (map[string]interface{}, error) {
+	apiPath, v2, err := c.kvPath(path)
+	if err != nil {
+		return nil, err
+	}
+	var secret struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := c.do(http.MethodGet, apiPath, nil, &secret); err != nil {
+		return nil, err
+	}
+	if v2 {
+		var versioned struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(secret.Data, &versioned); err != nil {
+			return nil, fmt.Errorf("invalid vault response for %s: %w", apiPath, err)
+		}
+		secret.Data = versioned.Data
+	}
+	dec := json.NewDecoder(bytes.NewReader(secret.Data))
+	dec.UseNumber()
+	var data map[string]interface{}
+	if err := dec.Decode(&data); err != nil || data == nil {
+		return nil, fmt.Errorf("%s holds no data (it may have been deleted)", path)
+	}
+	return data, nil
+}
+
+// writeKV replaces the data of the KV secret at path; KV version 2 keeps the previous data as a version.
+func (c *vaultClient) writeKV(path string, data map[string]string) error {
+	apiPath, v2, err := c.kvPath(path)
+	if err != nil {
+		return err
+	}
+	var body interface{} = data
+	if v2 {
+		body = map[string]interface{}{"data": data}
+	}
+	return c.do(http.MethodPost, apiPath, body, nil)
+}
+
+// ExportVault writes the parameters under prefix to the Vault KV secret at vaultPath, one key per
+// parameter named relative to prefix, replacing the secret's data. StringList values are written as
+// their comma-separated text. A dry-run client only prints the keys that would be written.
+func ExportVault(client SSMClient, prefix, vaultPath string, vaultOpts VaultOptions) error {
+	params, err := loadExportParams(client, prefix)
+	if err != nil {
+		return err
+	}
+	if len(params) == 0 {
+		return validationErrorf("no parameters found under %s", prefix)
+	}
+	data := make(map[string]string, len(params))
+	keys := make([]string, 0, len(params))
+	for _, p := range params {
+		data[p.Key] = p.Value
+		keys = append(keys, p.Key)
+	}
+	sort.Strings(keys)
+	if isDryRun(client) {
+		Infof("Would write %d keys to vault %s: %s\n", len(keys), vaultPath, strings.Join(keys, ", "))
+		return nil
+	}
+	vault, err := newVaultClient(vaultOpts)
+	if err != nil {
+		return err
+	}
+	if err := vault.writeKV(vaultPath, data); err != nil {
+		return err
+	}
+	Infof("%s %d parameters under %s to vault %s\n", green("Wrote"), len(params), prefix, vaultPath)
+	return nil
+}
+
+// ImportVault puts each key of the Vault KV secret at vaultPath under importOpts.Prefix. Values that are
+// objects or arrays are mapped like a JSON import (see ImportParameters); types are detected as for
+// generate. Existing parameters are overwritten according to opts.
+func ImportVault(client SSMClient, vaultPath string, importOpts ImportOptions, vaultOpts VaultOptions, opts PutOptions) error {
+	prefix, err := importPrefix(importOpts.Prefix)
+	if err != nil {
+		return err
+	}
+	arrays := importOpts.Arrays
+	if arrays == "" {
+		arrays = ArraysStringList
+	}
+	if err := ValidateArrays(arrays); err != nil {
+		return err
+	}
+	vault, err := newVaultClient(vaultOpts)
+	if err != nil {
+		return err
+	}
+	data, err := vault.readKV(vaultPath)
+	if err != nil {
+		return err
+	}
+	// Keys are full relative names, so "db/host" (as export-vault writes it) keeps its "/".
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var params []InputParam
+	for _, key := range keys {
+		if key == "" || strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") {
+			return validationErrorf("vault %s: key %q cannot be used as a parameter name", vaultPath, key)
+		}
+		if err := flattenTree(key, data[key], arrays, &params); err != nil {
+			return err
+		}
+	}
+	if len(params) == 0 {
+		return validationErrorf("no values found in vault %s", vaultPath)
+	}
+	return putInputParams(client, prefix, params, opts)
+}
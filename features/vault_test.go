@@ -0,0 +1,98 @@
+package features
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// fakeVault serves a KV version 2 engine at secret/ and AppRole logins, and records the data written.
+type fakeVault struct {
+	secrets map[string]map[string]interface{} // By path under the mount.
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	if path == "auth/approle/login" {
+		var login map[string]string
+		json.NewDecoder(r.Body).Decode(&login)
+		if login["role_id"] != "role" || login["secret_id"] != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors": ["invalid role or secret ID"]}`))
+			return
+		}
+		w.Write([]byte(`{"auth": {"client_token": "approle-token"}}`))
+		return
+	}
+	if token := r.Header.Get("X-Vault-Token"); token != "root" && token != "approle-token" {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"errors": ["permission denied"]}`))
+		return
+	}
+	switch {
+	case strings.HasPrefix(path, "sys/internal/ui/mounts/secret/"):
+		w.Write([]byte(`{"data": {"path": "secret/", "type": "kv", "options": {"version": "2"}}}`))
+	case strings.HasPrefix(path, "secret/data/") && r.Method == http.MethodPost:
+		var body struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		v.secrets[strings.TrimPrefix(path, "secret/data/")] = body.Data
+		w.Write([]byte(`{"data": {"version": 2}}`))
+	case strings.HasPrefix(path, "secret/data/"):
+		data, ok := v.secrets[strings.TrimPrefix(path, "secret/data/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors": []}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"data": data}})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestVaultRoundTrip(t *testing.T) {
+	vault := &fakeVault{secrets: make(map[string]map[string]interface{})}
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	fake := newFakeSSM()
+	fake.set("/prod/app/DB_HOST", "db", types.ParameterTypeString)
+	fake.set("/prod/app/db/password", "pw", types.ParameterTypeSecureString)
+	if err := ExportVault(fake, "/prod/app/", "secret/prod/app", VaultOptions{Address: server.URL, Token: "root"}); err != nil {
+		t.Fatalf("ExportVault: %v", err)
+	}
+	want := map[string]interface{}{"DB_HOST": "db", "db/password": "pw"}
+	if got := vault.secrets["prod/app"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("vault secret = %v; want %v", got, want)
+	}
+
+	copied := newFakeSSM()
+	err := ImportVault(copied, "secret/prod/app", ImportOptions{Prefix: "/staging/app"}, VaultOptions{Address: server.URL, RoleID: "role", SecretID: "secret"}, PutOptions{AssumeYes: true})
+	if err != nil {
+		t.Fatalf("ImportVault: %v", err)
+	}
+	if p, ok := copied.params["/staging/app/db/password"]; !ok || aws.ToString(p.Value) != "pw" || p.Type != types.ParameterTypeSecureString {
+		t.Errorf("/staging/app/db/password = %+v; want SecureString pw", p)
+	}
+	if len(copied.params) != 2 {
+		t.Errorf("imported %d parameters; want 2", len(copied.params))
+	}
+
+	err = ImportVault(newFakeSSM(), "secret/prod/missing", ImportOptions{Prefix: "/staging/app/"}, VaultOptions{Address: server.URL, Token: "root"}, PutOptions{AssumeYes: true})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing secret: err = %v; want ErrNotFound", err)
+	}
+	err = ImportVault(newFakeSSM(), "secret/prod/app", ImportOptions{Prefix: "/staging/app/"}, VaultOptions{Address: server.URL, Token: "wrong"}, PutOptions{AssumeYes: true})
+	if !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("wrong token: err = %v; want ErrAccessDenied", err)
+	}
+}
@@ -0,0 +1,234 @@
+package features
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+// ECSAPI is the subset of the ECS API used by verify-running. *ecs.Client satisfies it.
+type ECSAPI interface {
+	ListTasks(ctx context.Context, params *ecs.ListTasksInput, optFns ...func(*ecs.Options)) (*ecs.ListTasksOutput, error)
+	DescribeTasks(ctx context.Context, params *ecs.DescribeTasksInput, optFns ...func(*ecs.Options)) (*ecs.DescribeTasksOutput, error)
+	DescribeTaskDefinition(ctx context.Context, params *ecs.DescribeTaskDefinitionInput, optFns ...func(*ecs.Options)) (*ecs.DescribeTaskDefinitionOutput, error)
+	ExecuteCommand(ctx context.Context, params *ecs.ExecuteCommandInput, optFns ...func(*ecs.Options)) (*ecs.ExecuteCommandOutput, error)
+}
+
+// VerifyRunningOptions selects the service whose running tasks verify-running checks.
+type VerifyRunningOptions struct {
+	Cluster string
+	Service string
+	Region  string // Region of the cluster, for the Session Manager connection.
+}
+
+// sessionManagerPlugin is the AWS CLI plugin that speaks the Session Manager protocol ECS Exec uses.
+const sessionManagerPlugin = "session-manager-plugin"
+
+// execCommand runs command in a container through ECS Exec and returns its output. Tests replace it.
+var execCommand = sessionManagerExec
+
+// VerifyRunning reads the environment of every running task of a service through ECS Exec and compares
+// the secrets its task definition takes from Parameter Store with their current values, printing the
+// secrets whose running value is stale, masked as fingerprints. ECS reads secrets when a task starts, so a
+// stale value stays until the next deployment; VerifyRunning then returns a StaleError (exit code
+// ExitCheckFailed), which counts secrets whose parameter was deleted apart. A service without running tasks
+// is ErrNotFound. The containers need ECS Exec enabled and an env binary.
+func VerifyRunning(ctx context.Context, client SSMClient, tasks ECSAPI, opts VerifyRunningOptions) error {
+	if opts.Cluster == "" || opts.Service == "" {
+		return validationErrorf("-cluster and -service are required")
+	}
+	var arns []string
+	pages := ecs.NewListTasksPaginator(tasks, &ecs.ListTasksInput{
+		Cluster:       aws.String(opts.Cluster),
+		ServiceName:   aws.String(opts.Service),
+		DesiredStatus: ecstypes.DesiredStatusRunning,
+	})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list tasks of %s: %w", opts.Service, err)
+		}
+		arns = append(arns, page.TaskArns...)
+	}
+	if len(arns) == 0 {
+		return fmt.Errorf("service %s in cluster %s has no running tasks: %w", opts.Service, opts.Cluster, ErrNotFound)
+	}
+
+	current := make(map[string]*string)                            // Current value of each parameter, read once; nil if deleted.
+	definitions := make(map[string][]ecstypes.ContainerDefinition) // Containers of each task definition.
+	stale, missing, total := 0, 0, 0
+	for start := 0; start < len(arns); start += 100 { // DescribeTasks takes at most 100 tasks.
+		batch := arns[start:min(start+100, len(arns))]
+		described, err := tasks.DescribeTasks(ctx, &ecs.DescribeTasksInput{Cluster: aws.String(opts.Cluster), Tasks: batch})
+		if err != nil {
+			return fmt.Errorf("failed to describe tasks of %s: %w", opts.Service, err)
+		}
+		for _, task := range described.Tasks {
+			if aws.ToString(task.LastStatus) != "RUNNING" {
+				continue // Still starting or already stopping.
+			}
+			taskDef := aws.ToString(task.TaskDefinitionArn)
+			containers, ok := definitions[taskDef]
+			if !ok {
+				out, err := tasks.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{TaskDefinition: aws.String(taskDef)})
+				if err != nil {
+					return fmt.Errorf("failed to describe task definition %s: %w", taskDef, err)
+				}
+				containers = out.TaskDefinition.ContainerDefinitions
+				definitions[taskDef] = containers
+			}
+			s, m, n, err := verifyTask(ctx, client, tasks, opts, task, containers, current)
+			if err != nil {
+				return err
+			}
+			stale += s
+			missing += m
+			total += n
+		}
+	}
+	if stale > 0 || missing > 0 {
+		return &StaleError{Stale: stale, Missing: missing, Total: total}
+	}
+	fmt.Printf("Up to date: %d secrets in %d tasks of %s match Parameter Store\n", total, len(arns), opts.Service)
+	return nil
+}
+
+// verifyTask compares the SSM secrets of each container of task with its environment and returns the
+// number of stale secrets, of secrets whose parameter was deleted and of secrets checked.
+func verifyTask(ctx context.Context, client SSMClient, tasks ECSAPI, opts VerifyRunningOptions, task ecstypes.Task, containers []ecstypes.ContainerDefinition, current map[string]*string) (int, int, int, error) {
+	taskID := lastSegment(aws.ToString(task.TaskArn))
+	started := ""
+	if task.StartedAt != nil {
+		started = ", started " + task.StartedAt.UTC().Format(time.RFC3339)
+	}
+	fmt.Printf("Task %s (%s%s)\n", taskID, lastSegment(aws.ToString(task.TaskDefinitionArn)), started)
+	if !task.EnableExecuteCommand {
+		return 0, 0, 0, validationErrorf("task %s does not have ECS Exec enabled; run aws ecs update-service --enable-execute-command and redeploy", taskID)
+	}
+	stale, missing, total := 0, 0, 0
+	for _, def := range containers {
+		var names, params []string // Secret names and the parameters they come from.
+		for _, secret := range def.Secrets {
+			if name := ExtractParameterName(aws.ToString(secret.ValueFrom)); name != "" {
+				names = append(names, aws.ToString(secret.Name))
+				params = append(params, name)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+		runtimeID := ""
+		for _, c := range task.Containers {
+			if aws.ToString(c.Name) == aws.ToString(def.Name) {
+				runtimeID = aws.ToString(c.RuntimeId)
+			}
+		}
+		if runtimeID == "" {
+			fmt.Printf("  %s container %s: not running\n", yellow("Skipped"), aws.ToString(def.Name))
+			continue
+		}
+		env, err := containerEnv(ctx, tasks, opts, task, aws.ToString(def.Name), runtimeID)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		for i, name := range names {
+			value, ok := current[params[i]]
+			if !ok {
+				v, _, err := GetParameter(client, params[i])
+				if err == nil {
+					value = &v
+				} else if !errors.Is(err, ErrNotFound) {
+					return 0, 0, 0, err
+				}
+				current[params[i]] = value
+			}
+			total++
+			running, ok := env[name]
+			switch {
+			case value == nil:
+				fmt.Printf("  %s %s/%s: %s no longer exists; the next deployment will fail\n", red("-"), aws.ToString(def.Name), name, params[i])
+				missing++
+			case !ok:
+				fmt.Printf("  %s %s/%s: not in the environment (from %s)\n", red("-"), aws.ToString(def.Name), name, params[i])
+				stale++
+			case running != *value:
+				fmt.Printf("  %s %s/%s: stale, running %s, current %s (%s)\n", yellow("~"), aws.ToString(def.Name), name, maskValue(running), maskValue(*value), params[i])
+				stale++
+			}
+		}
+	}
+	return stale, missing, total, nil
+}
+
+// containerEnv runs env in a container and parses its output. Lines that do not start a variable continue
+// the previous one, for multi-line values.
+func containerEnv(ctx context.Context, tasks ECSAPI, opts VerifyRunningOptions, task ecstypes.Task, container, runtimeID string) (map[string]string, error) {
+	out, err := tasks.ExecuteCommand(ctx, &ecs.ExecuteCommandInput{
+		Cluster:     aws.String(opts.Cluster),
+		Task:        task.TaskArn,
+		Container:   aws.String(container),
+		Command:     aws.String("env"),
+		Interactive: true, // The only mode ECS Exec supports.
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ECS Exec into %s failed: %w", container, err)
+	}
+	target := fmt.Sprintf("ecs:%s_%s_%s", lastSegment(aws.ToString(task.ClusterArn)), lastSegment(aws.ToString(task.TaskArn)), runtimeID)
+	output, err := execCommand(ctx, out.Session, opts.Region, target)
+	if err != nil {
+		return nil, err
+	}
+	env := make(map[string]string)
+	last := ""
+	for _, line := range strings.Split(strings.ReplaceAll(string(output), "\r", ""), "\n") {
+		if strings.HasPrefix(line, "Starting session with SessionId") || strings.HasPrefix(line, "Exiting session with sessionId") {
+			last = ""
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && envNamePattern.MatchString(key) {
+			env[key], last = value, key
+		} else if last != "" && line != "" {
+			env[last] += "\n" + line
+		}
+	}
+	return env, nil
+}
+
+// sessionManagerExec connects to an ECS Exec session with the Session Manager plugin, as the AWS CLI does,
+// and returns what the command printed.
+func sessionManagerExec(ctx context.Context, session *ecstypes.Session, region, target string) ([]byte, error) {
+	if _, err := exec.LookPath(sessionManagerPlugin); err != nil {
+		return nil, fmt.Errorf("%s is required for ECS Exec; install the Session Manager plugin for the AWS CLI: %w", sessionManagerPlugin, err)
+	}
+	sessionJSON, err := json.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+	targetJSON, err := json.Marshal(map[string]string{"Target": target})
+	if err != nil {
+		return nil, err
+	}
+	endpoint := fmt.Sprintf("https://ssm.%s.amazonaws.com", region)
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, sessionManagerPlugin, string(sessionJSON), region, "StartSession", "", string(targetJSON), endpoint)
+	cmd.Stdout, cmd.Stderr = &stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed: %w", sessionManagerPlugin, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// lastSegment returns what follows the last "/" of an ARN, such as the ID of a task ARN.
+func lastSegment(arn string) string {
+	return arn[strings.LastIndex(arn, "/")+1:]
+}
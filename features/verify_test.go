@@ -0,0 +1,97 @@
+package features
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// fakeECS runs one task with a web container whose secrets come from Parameter Store and Secrets Manager.
+type fakeECS struct{}
+
+func (fakeECS) ListTasks(ctx context.Context, params *ecs.ListTasksInput, optFns ...func(*ecs.Options)) (*ecs.ListTasksOutput, error) {
+	return &ecs.ListTasksOutput{TaskArns: []string{"arn:aws:ecs:eu-west-1:111111111111:task/prod/abc123"}}, nil
+}
+
+func (fakeECS) DescribeTasks(ctx context.Context, params *ecs.DescribeTasksInput, optFns ...func(*ecs.Options)) (*ecs.DescribeTasksOutput, error) {
+	return &ecs.DescribeTasksOutput{Tasks: []ecstypes.Task{{
+		TaskArn:              aws.String("arn:aws:ecs:eu-west-1:111111111111:task/prod/abc123"),
+		ClusterArn:           aws.String("arn:aws:ecs:eu-west-1:111111111111:cluster/prod"),
+		TaskDefinitionArn:    aws.String("arn:aws:ecs:eu-west-1:111111111111:task-definition/app:7"),
+		LastStatus:           aws.String("RUNNING"),
+		EnableExecuteCommand: true,
+		Containers:           []ecstypes.Container{{Name: aws.String("web"), RuntimeId: aws.String("abc123-1")}},
+	}}}, nil
+}
+
+func (fakeECS) DescribeTaskDefinition(ctx context.Context, params *ecs.DescribeTaskDefinitionInput, optFns ...func(*ecs.Options)) (*ecs.DescribeTaskDefinitionOutput, error) {
+	return &ecs.DescribeTaskDefinitionOutput{TaskDefinition: &ecstypes.TaskDefinition{ContainerDefinitions: []ecstypes.ContainerDefinition{{
+		Name: aws.String("web"),
+		Secrets: []ecstypes.Secret{
+			{Name: aws.String("DB_HOST"), ValueFrom: aws.String("/prod/app/DB_HOST")},
+			{Name: aws.String("CERT"), ValueFrom: aws.String("arn:aws:ssm:eu-west-1:111111111111:parameter/prod/app/CERT")},
+			{Name: aws.String("TOKEN"), ValueFrom: aws.String("/prod/app/TOKEN")},
+			{Name: aws.String("STRIPE"), ValueFrom: aws.String("arn:aws:secretsmanager:eu-west-1:111111111111:secret:stripe")},
+		},
+	}}}}, nil
+}
+
+func (fakeECS) ExecuteCommand(ctx context.Context, params *ecs.ExecuteCommandInput, optFns ...func(*ecs.Options)) (*ecs.ExecuteCommandOutput, error) {
+	return &ecs.ExecuteCommandOutput{Session: &ecstypes.Session{SessionId: aws.String("s-1")}}, nil
+}
+
+func TestVerifyRunning(t *testing.T) {
+	var target string
+	defer func(old func(context.Context, *ecstypes.Session, string, string) ([]byte, error)) { execCommand = old }(execCommand)
+	execCommand = func(_ context.Context, _ *ecstypes.Session, _, t string) ([]byte, error) {
+		target = t
+		return []byte("\r\nStarting session with SessionId: s-1\r\nPATH=/usr/bin\r\nDB_HOST=db-old\r\nCERT=line1\r\nline2\r\nTOKEN=t0k\r\n\r\n\r\nExiting session with sessionId: s-1.\r\n\r\n"), nil
+	}
+	fake := newFakeSSM()
+	fake.set("/prod/app/DB_HOST", "db-new", types.ParameterTypeString)
+	fake.set("/prod/app/CERT", "line1\nline2", types.ParameterTypeSecureString)
+	fake.set("/prod/app/TOKEN", "t0k", types.ParameterTypeSecureString)
+
+	err := VerifyRunning(context.Background(), fake, fakeECS{}, VerifyRunningOptions{Cluster: "prod", Service: "app", Region: "eu-west-1"})
+	var stale *StaleError
+	if !errors.As(err, &stale) || stale.Stale != 1 || stale.Missing != 0 || stale.Total != 3 {
+		t.Fatalf("err = %v; want 1 of 3 secrets stale", err)
+	}
+	if ExitCode(err) != ExitCheckFailed {
+		t.Errorf("exit code = %d; want %d", ExitCode(err), ExitCheckFailed)
+	}
+	if target != "ecs:prod_abc123_abc123-1" {
+		t.Errorf("session target = %q", target)
+	}
+
+	fake.set("/prod/app/DB_HOST", "db-old", types.ParameterTypeString)
+	if err := VerifyRunning(context.Background(), fake, fakeECS{}, VerifyRunningOptions{Cluster: "prod", Service: "app", Region: "eu-west-1"}); err != nil {
+		t.Errorf("up-to-date task: err = %v", err)
+	}
+
+	delete(fake.params, "/prod/app/TOKEN")
+	err = VerifyRunning(context.Background(), fake, fakeECS{}, VerifyRunningOptions{Cluster: "prod", Service: "app", Region: "eu-west-1"})
+	if !errors.As(err, &stale) || stale.Stale != 0 || stale.Missing != 1 || stale.Total != 3 {
+		t.Fatalf("deleted parameter: err = %v; want 1 of 3 secrets missing", err)
+	}
+	if ExitCode(err) != ExitCheckFailed {
+		t.Errorf("deleted parameter: exit code = %d; want %d", ExitCode(err), ExitCheckFailed)
+	}
+
+	err = VerifyRunning(context.Background(), fake, idleECS{}, VerifyRunningOptions{Cluster: "prod", Service: "app", Region: "eu-west-1"})
+	if !errors.Is(err, ErrNotFound) || ExitCode(err) != ExitNotFound {
+		t.Errorf("no running tasks: err = %v (exit %d); want ErrNotFound", err, ExitCode(err))
+	}
+}
+
+// idleECS is a service without running tasks.
+type idleECS struct{ fakeECS }
+
+func (idleECS) ListTasks(ctx context.Context, params *ecs.ListTasksInput, optFns ...func(*ecs.Options)) (*ecs.ListTasksOutput, error) {
+	return &ecs.ListTasksOutput{}, nil
+}
@@ -0,0 +1,304 @@
+package features
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	eventtypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// SQSAPI is the subset of the SQS API used by watch. *sqs.Client satisfies it.
+type SQSAPI interface {
+	CreateQueue(ctx context.Context, params *sqs.CreateQueueInput, optFns ...func(*sqs.Options)) (*sqs.CreateQueueOutput, error)
+	GetQueueAttributes(ctx context.Context, params *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error)
+	SetQueueAttributes(ctx context.Context, params *sqs.SetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.SetQueueAttributesOutput, error)
+	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessageBatch(ctx context.Context, params *sqs.DeleteMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error)
+}
+
+// EventsAPI is the subset of the EventBridge API used by watch. *eventbridge.Client satisfies it.
+type EventsAPI interface {
+	PutRule(ctx context.Context, params *eventbridge.PutRuleInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutRuleOutput, error)
+	PutTargets(ctx context.Context, params *eventbridge.PutTargetsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutTargetsOutput, error)
+}
+
+// LambdaAPI is the subset of the Lambda API used by watch. *lambda.Client satisfies it.
+type LambdaAPI interface {
+	GetFunctionConfiguration(ctx context.Context, params *lambda.GetFunctionConfigurationInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionConfigurationOutput, error)
+	UpdateFunctionConfiguration(ctx context.Context, params *lambda.UpdateFunctionConfigurationInput, optFns ...func(*lambda.Options)) (*lambda.UpdateFunctionConfigurationOutput, error)
+}
+
+// WatchOptions configures watch mode.
+type WatchOptions struct {
+	Prefix   string        // Parameters to watch and render.
+	Export   ExportOptions // File outputs, as for export; no formats renders none.
+	ApplyK8s bool          // Run kubectl apply on the k8s output after each render.
+	Lambda   string        // Function whose environment receives the parameters; empty updates none.
+	QueueURL string        // Existing queue receiving the change events; empty creates the queue and rule.
+}
+
+// watchTargetID is the EventBridge target ID of the queue on the rule watch creates.
+const watchTargetID = "salter-aws-watch"
+
+// lambdaEnvKeyPattern is what Lambda accepts as an environment variable name.
+var lambdaEnvKeyPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
+
+// parameterChangeEvent is the part of an SSM "Parameter Store Change" event watch reads.
+type parameterChangeEvent struct {
+	Detail struct {
+		Name      string `json:"name"`
+		Operation string `json:"operation"`
+	} `json:"detail"`
+}
+
+// watchResourceName names the queue and rule watch creates for prefix, e.g. salter-aws-watch-prod-app-1a2b3c4d.
+// The hash keeps prefixes that only differ in punctuation apart; the result fits SQS and EventBridge limits.
+func watchResourceName(prefix string) string {
+	slug := strings.Trim(k8sNameInvalid.ReplaceAllString(strings.ToLower(prefix), "-"), "-")
+	if len(slug) > 30 {
+		slug = strings.Trim(slug[:30], "-")
+	}
+	if slug == "" {
+		return "salter-aws-watch-" + shortHash(prefix)
+	}
+	return "salter-aws-watch-" + slug + "-" + shortHash(prefix)
+}
+
+// SetupWatchQueue creates, or updates in place, an SQS queue and an EventBridge rule forwarding SSM
+// Parameter Store change events under prefix to it, and returns the queue URL. Running it again is harmless.
+func SetupWatchQueue(ctx context.Context, queues SQSAPI, events EventsAPI, prefix string) (string, error) {
+	name := watchResourceName(prefix)
+	created, err := queues.CreateQueue(ctx, &sqs.CreateQueueInput{
+		QueueName:  aws.String(name),
+		Attributes: map[string]string{"MessageRetentionPeriod": "3600"}, // Older changes are covered by the next render.
+	})
+	if err != nil {
+		return "", wrapAWSError("CreateQueue", name, err)
+	}
+	queueURL := aws.ToString(created.QueueUrl)
+	attrs, err := queues.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(queueURL),
+		AttributeNames: []sqstypes.QueueAttributeName{sqstypes.QueueAttributeNameQueueArn},
+	})
+	if err != nil {
+		return "", wrapAWSError("GetQueueAttributes", queueURL, err)
+	}
+	queueARN := attrs.Attributes[string(sqstypes.QueueAttributeNameQueueArn)]
+
+	pattern, err := json.Marshal(map[string]interface{}{
+		"source":      []string{"aws.ssm"},
+		"detail-type": []string{"Parameter Store Change"},
+		"detail":      map[string]interface{}{"name": []interface{}{map[string]string{"prefix": prefix}}},
+	})
+	if err != nil {
+		return "", err
+	}
+	rule, err := events.PutRule(ctx, &eventbridge.PutRuleInput{
+		Name:         aws.String(name),
+		Description:  aws.String("salter-aws watch: Parameter Store changes under " + prefix),
+		EventPattern: aws.String(string(pattern)),
+		State:        eventtypes.RuleStateEnabled,
+	})
+	if err != nil {
+		return "", wrapAWSError("PutRule", name, err)
+	}
+
+	// Only the rule may send to the queue.
+	policy, err := json.Marshal(map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []interface{}{map[string]interface{}{
+			"Effect":    "Allow",
+			"Principal": map[string]string{"Service": "events.amazonaws.com"},
+			"Action":    "sqs:SendMessage",
+			"Resource":  queueARN,
+			"Condition": map[string]interface{}{"ArnEquals": map[string]string{"aws:SourceArn": aws.ToString(rule.RuleArn)}},
+		}},
+	})
+	if err != nil {
+		return "", err
+	}
+	_, err = queues.SetQueueAttributes(ctx, &sqs.SetQueueAttributesInput{
+		QueueUrl:   aws.String(queueURL),
+		Attributes: map[string]string{string(sqstypes.QueueAttributeNamePolicy): string(policy)},
+	})
+	if err != nil {
+		return "", wrapAWSError("SetQueueAttributes", queueURL, err)
+	}
+
+	targets, err := events.PutTargets(ctx, &eventbridge.PutTargetsInput{
+		Rule:    aws.String(name),
+		Targets: []eventtypes.Target{{Id: aws.String(watchTargetID), Arn: aws.String(queueARN)}},
+	})
+	if err != nil {
+		return "", wrapAWSError("PutTargets", name, err)
+	}
+	if targets.FailedEntryCount > 0 {
+		return "", fmt.Errorf("PutTargets %s: %s", name, aws.ToString(targets.FailedEntries[0].ErrorMessage))
+	}
+	Infof("Forwarding Parameter Store changes under %s to %s (rule %s)\n", prefix, queueURL, name)
+	return queueURL, nil
+}
+
+// Watch renders the outputs in opts once, then again within seconds of every change event arriving on
+// opts.QueueURL, until ctx is cancelled. Events are long-polled and each batch triggers one render; a batch
+// is only deleted once its render succeeded, so a failed render is retried when the events are redelivered.
+func Watch(ctx context.Context, client SSMClient, queues SQSAPI, functions LambdaAPI, opts WatchOptions) error {
+	if len(opts.Export.Formats) > 0 {
+		if opts.Export.Output == "" {
+			return validationErrorf("-o is required for 'watch'")
+		}
+		if err := checkExportOptions(opts.Export); err != nil {
+			return err
+		}
+	} else if opts.Lambda == "" {
+		return validationErrorf("nothing to render: set -format and -o, or -lambda")
+	}
+	if opts.ApplyK8s && !slices.Contains(opts.Export.Formats, FormatK8s) {
+		return validationErrorf("-kubectl-apply requires -format k8s")
+	}
+	if err := renderWatch(ctx, client, functions, opts); err != nil {
+		return err
+	}
+	Infof("Watching %s for changes\n", opts.Prefix)
+
+	for ctx.Err() == nil {
+		received, err := queues.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(opts.QueueURL),
+			MaxNumberOfMessages: 10,
+			WaitTimeSeconds:     20,
+		})
+		if ctx.Err() != nil {
+			break
+		}
+		if err != nil {
+			Infof("Warning: %v; retrying\n", wrapAWSError("ReceiveMessage", opts.QueueURL, err))
+			sleepContext(ctx, 5*time.Second)
+			continue
+		}
+		if len(received.Messages) == 0 {
+			continue
+		}
+
+		changed := false
+		for _, msg := range received.Messages {
+			var event parameterChangeEvent
+			if json.Unmarshal([]byte(aws.ToString(msg.Body)), &event) != nil || !strings.HasPrefix(event.Detail.Name, opts.Prefix) {
+				continue // Not ours; delete it with the batch so it does not come back.
+			}
+			Infof("%s %s\n", event.Detail.Operation, event.Detail.Name)
+			changed = true
+		}
+		if changed {
+			if err := renderWatch(ctx, client, functions, opts); err != nil {
+				Infof("Render failed, will retry on redelivery: %v\n", err)
+				continue
+			}
+		}
+
+		var entries []sqstypes.DeleteMessageBatchRequestEntry
+		for i, msg := range received.Messages {
+			entries = append(entries, sqstypes.DeleteMessageBatchRequestEntry{
+				Id:            aws.String(fmt.Sprint(i)),
+				ReceiptHandle: msg.ReceiptHandle,
+			})
+		}
+		if _, err := queues.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{
+			QueueUrl: aws.String(opts.QueueURL),
+			Entries:  entries,
+		}); err != nil && ctx.Err() == nil {
+			Infof("Warning: %v\n", wrapAWSError("DeleteMessageBatch", opts.QueueURL, err))
+		}
+	}
+	return nil
+}
+
+// renderWatch reads the parameters under opts.Prefix and writes every configured output.
+func renderWatch(ctx context.Context, client SSMClient, functions LambdaAPI, opts WatchOptions) error {
+	params, err := loadExportParams(client, opts.Prefix)
+	if err != nil {
+		return err
+	}
+	if len(opts.Export.Formats) > 0 {
+		if err := writeExport(params, opts.Prefix, opts.Export); err != nil {
+			return err
+		}
+	}
+	if opts.ApplyK8s {
+		cmd := exec.CommandContext(ctx, "kubectl", "apply", "-f", opts.Export.Output+formatExtension(FormatK8s))
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("kubectl apply failed: %w", err)
+		}
+	}
+	if opts.Lambda != "" {
+		return updateLambdaEnv(ctx, functions, opts.Lambda, outputVars(params))
+	}
+	return nil
+}
+
+// updateLambdaEnv sets vars in the environment of a Lambda function, keeping its other variables.
+// The update is skipped when nothing changed, and conditional on the revision read, so a concurrent
+// configuration change is not lost.
+func updateLambdaEnv(ctx context.Context, functions LambdaAPI, function string, vars []EnvVar) error {
+	for _, v := range vars {
+		if !lambdaEnvKeyPattern.MatchString(v.Key) {
+			return validationErrorf("%q is not a valid Lambda environment variable name", v.Key)
+		}
+	}
+	current, err := functions.GetFunctionConfiguration(ctx, &lambda.GetFunctionConfigurationInput{FunctionName: aws.String(function)})
+	if err != nil {
+		return wrapAWSError("GetFunctionConfiguration", function, err)
+	}
+	env := make(map[string]string)
+	if current.Environment != nil {
+		for k, v := range current.Environment.Variables {
+			env[k] = v
+		}
+	}
+	var changed []string
+	for _, v := range vars {
+		if old, ok := env[v.Key]; !ok || old != v.Value {
+			changed = append(changed, v.Key)
+			env[v.Key] = v.Value
+		}
+	}
+	if len(changed) == 0 {
+		Infof("Lambda %s environment is up to date\n", function)
+		return nil
+	}
+	sort.Strings(changed)
+	_, err = functions.UpdateFunctionConfiguration(ctx, &lambda.UpdateFunctionConfigurationInput{
+		FunctionName: aws.String(function),
+		Environment:  &lambdatypes.Environment{Variables: env},
+		RevisionId:   current.RevisionId,
+	})
+	if err != nil {
+		return wrapAWSError("UpdateFunctionConfiguration", function, err)
+	}
+	Infof("Updated Lambda %s environment: %s\n", function, strings.Join(changed, ", "))
+	return nil
+}
+
+// sleepContext waits for d or until ctx is cancelled.
+func sleepContext(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
@@ -0,0 +1,193 @@
+package features
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// fakeQueue delivers one batch of messages per ReceiveMessage, runs onReceive before each, and cancels
+// the watch once it runs out of batches.
+type fakeQueue struct {
+	batches    [][]string
+	onReceive  func(batch int)
+	cancel     context.CancelFunc
+	received   int
+	deleted    []string
+	attributes map[string]string
+}
+
+func (q *fakeQueue) CreateQueue(ctx context.Context, params *sqs.CreateQueueInput, optFns ...func(*sqs.Options)) (*sqs.CreateQueueOutput, error) {
+	return &sqs.CreateQueueOutput{QueueUrl: aws.String("https://sqs/" + aws.ToString(params.QueueName))}, nil
+}
+
+func (q *fakeQueue) GetQueueAttributes(ctx context.Context, params *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error) {
+	return &sqs.GetQueueAttributesOutput{Attributes: map[string]string{"QueueArn": "arn:aws:sqs:us-east-1:123456789012:queue"}}, nil
+}
+
+func (q *fakeQueue) SetQueueAttributes(ctx context.Context, params *sqs.SetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.SetQueueAttributesOutput, error) {
+	q.attributes = params.Attributes
+	return &sqs.SetQueueAttributesOutput{}, nil
+}
+
+func (q *fakeQueue) ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	if q.received == len(q.batches) {
+		q.cancel()
+		return nil, ctx.Err()
+	}
+	if q.onReceive != nil {
+		q.onReceive(q.received)
+	}
+	out := &sqs.ReceiveMessageOutput{}
+	for i, body := range q.batches[q.received] {
+		handle := strings.Repeat("h", q.received+1) + string(rune('0'+i))
+		out.Messages = append(out.Messages, sqstypes.Message{Body: aws.String(body), ReceiptHandle: aws.String(handle)})
+	}
+	q.received++
+	return out, nil
+}
+
+func (q *fakeQueue) DeleteMessageBatch(ctx context.Context, params *sqs.DeleteMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error) {
+	for _, entry := range params.Entries {
+		q.deleted = append(q.deleted, aws.ToString(entry.ReceiptHandle))
+	}
+	return &sqs.DeleteMessageBatchOutput{}, nil
+}
+
+type fakeEvents struct {
+	pattern string
+	targets []string
+}
+
+func (e *fakeEvents) PutRule(ctx context.Context, params *eventbridge.PutRuleInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutRuleOutput, error) {
+	e.pattern = aws.ToString(params.EventPattern)
+	return &eventbridge.PutRuleOutput{RuleArn: aws.String("arn:aws:events:us-east-1:123456789012:rule/" + aws.ToString(params.Name))}, nil
+}
+
+func (e *fakeEvents) PutTargets(ctx context.Context, params *eventbridge.PutTargetsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutTargetsOutput, error) {
+	for _, target := range params.Targets {
+		e.targets = append(e.targets, aws.ToString(target.Arn))
+	}
+	return &eventbridge.PutTargetsOutput{}, nil
+}
+
+type fakeLambda struct {
+	env     map[string]string
+	updates int
+}
+
+func (l *fakeLambda) GetFunctionConfiguration(ctx context.Context, params *lambda.GetFunctionConfigurationInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionConfigurationOutput, error) {
+	return &lambda.GetFunctionConfigurationOutput{Environment: &lambdatypes.EnvironmentResponse{Variables: l.env}}, nil
+}
+
+func (l *fakeLambda) UpdateFunctionConfiguration(ctx context.Context, params *lambda.UpdateFunctionConfigurationInput, optFns ...func(*lambda.Options)) (*lambda.UpdateFunctionConfigurationOutput, error) {
+	l.env = params.Environment.Variables
+	l.updates++
+	return &lambda.UpdateFunctionConfigurationOutput{}, nil
+}
+
+func changeEvent(name string) string {
+	return `{"source":"aws.ssm","detail-type":"Parameter Store Change","detail":{"operation":"Update","name":"` + name + `"}}`
+}
+
+func TestWatch(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/prod/app/DB_URL", "postgres://old", types.ParameterTypeString)
+	base := filepath.Join(t.TempDir(), "app")
+	functions := &fakeLambda{env: map[string]string{"OTHER": "kept"}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	queue := &fakeQueue{
+		batches: [][]string{
+			{changeEvent("/prod/app/DB_URL"), changeEvent("/prod/app/DB_URL")},
+			{changeEvent("/staging/app/DB_URL"), "not json"},
+		},
+		onReceive: func(batch int) {
+			if batch == 0 {
+				fake.set("/prod/app/DB_URL", "postgres://new", types.ParameterTypeString)
+			}
+		},
+		cancel: cancel,
+	}
+
+	err := Watch(ctx, fake, queue, functions, WatchOptions{
+		Prefix:   "/prod/app/",
+		Export:   ExportOptions{Formats: []string{FormatEnv}, Output: base},
+		Lambda:   "app",
+		QueueURL: "https://sqs/queue",
+	})
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	data, err := os.ReadFile(base + ".env")
+	if err != nil || string(data) != "DB_URL=postgres://new\n" {
+		t.Errorf("app.env = %q (err %v); want the value after the change", data, err)
+	}
+	if len(queue.deleted) != 4 {
+		t.Errorf("deleted %v; want all 4 messages, including ones for other prefixes", queue.deleted)
+	}
+	// Initial render and the first batch; the second batch has no change under the prefix.
+	if functions.updates != 2 || functions.env["DB_URL"] != "postgres://new" || functions.env["OTHER"] != "kept" {
+		t.Errorf("lambda env = %v after %d updates; want DB_URL updated twice and OTHER kept", functions.env, functions.updates)
+	}
+}
+
+func TestWatchKeepsMessagesWhenRenderFails(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/prod/app/DB_URL", "postgres://db", types.ParameterTypeString)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	queue := &fakeQueue{
+		batches: [][]string{{changeEvent("/prod/app/db-url")}},
+		onReceive: func(int) {
+			fake.set("/prod/app/db-url", "x", types.ParameterTypeString) // Not a valid Lambda variable name.
+		},
+		cancel: cancel,
+	}
+	err := Watch(ctx, fake, queue, &fakeLambda{}, WatchOptions{Prefix: "/prod/app/", Lambda: "app", QueueURL: "https://sqs/queue"})
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	if len(queue.deleted) != 0 {
+		t.Errorf("deleted %v after a failed render; want the messages left for redelivery", queue.deleted)
+	}
+}
+
+func TestSetupWatchQueue(t *testing.T) {
+	queue := &fakeQueue{}
+	events := &fakeEvents{}
+	url, err := SetupWatchQueue(context.Background(), queue, events, "/prod/app/")
+	if err != nil {
+		t.Fatalf("SetupWatchQueue: %v", err)
+	}
+	if want := "https://sqs/salter-aws-watch-prod-app-" + shortHash("/prod/app/"); url != want {
+		t.Errorf("queue URL = %q; want %q", url, want)
+	}
+	var pattern struct {
+		Source []string `json:"source"`
+		Detail struct {
+			Name []map[string]string `json:"name"`
+		} `json:"detail"`
+	}
+	if err := json.Unmarshal([]byte(events.pattern), &pattern); err != nil || pattern.Source[0] != "aws.ssm" ||
+		pattern.Detail.Name[0]["prefix"] != "/prod/app/" {
+		t.Errorf("event pattern = %s (err %v); want aws.ssm changes under /prod/app/", events.pattern, err)
+	}
+	if len(events.targets) != 1 || events.targets[0] != "arn:aws:sqs:us-east-1:123456789012:queue" {
+		t.Errorf("targets = %v; want the queue", events.targets)
+	}
+	if policy := queue.attributes["Policy"]; !strings.Contains(policy, "salter-aws-watch-prod-app-") || !strings.Contains(policy, "events.amazonaws.com") {
+		t.Errorf("queue policy = %s; want SendMessage for the rule only", policy)
+	}
+}
@@ -4,31 +4,157 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
 	"strings"
+	"syscall"
+	"time"
 
 	"go-param-store/features"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
 )
 
+// Build metadata, set with -ldflags "-X main.version=... -X main.commit=... -X main.date=..." (see the Makefile).
+var (
+	version = "dev"
+	commit  = "none"
+	date    = "unknown"
+)
+
+// printPolicy is -print-policy: fatal also prints an IAM policy allowing the calls that were denied.
+var printPolicy bool
+
+// hooks runs the hooks of config.json around writes; fatal runs the post hooks before exiting.
+var hooks *features.HookClient
+
+// events is -events: the stream every SSM client of the run reports to; fatal writes its finished event.
+var events *features.EventStream
+
+// mutatingActions lists the actions that write to Parameter Store, Kubernetes, Vault or other AWS resources
+// (watch creates an SQS queue and EventBridge rule and updates Lambda functions), refused in read-only mode.
+var mutatingActions = []string{"put", "put-from-template", "seed", "bootstrap", "import", "import-vault", "put-from-json", "secretize", "move", "reencrypt", "sync-k8s", "watch", "export-vault", "bench", "gc"}
+
+// actions lists the user-facing actions, for shell completion.
+var actions = []string{"get", "get-public", "find", "put", "put-from-template", "seed", "bootstrap", "import", "import-terraform", "import-vault", "put-from-json", "secretize", "inline", "move", "reencrypt", "audit-types", "scan-leaks", "rewrite-refs", "graph", "check-drift", "check-contract", "lint-template", "generate", "classify", "verify-roundtrip", "get-by-prefix", "export", "export-vault", "get-as-json", "list", "tree", "list-apps", "list-envs", "stale", "expiring", "serve", "agent", "watch", "sync-k8s", "agent-get", "verify-running", "bench", "gc", "doctor", "explain-config", "scaffold", "version", "self-update", "completion"}
+
 // main is the entry point. It parses command-line flags and executes the appropriate action.
 func main() {
 	// Define command-line flags for different operations.
-	action := flag.String("action", "", "Action to perform: 'get', 'put', 'put-from-template', 'generate', or 'get-by-prefix'")
+	action := flag.String("action", "", "Action to perform: 'get', 'get-public', 'find', 'put', 'put-from-template', 'seed', 'bootstrap', 'import', 'import-terraform', 'import-vault', 'put-from-json', 'secretize', 'inline', 'move', 'reencrypt', 'audit-types', 'scan-leaks', 'rewrite-refs', 'graph', 'check-drift', 'check-contract', 'lint-template', 'generate', 'classify', 'verify-roundtrip', 'get-by-prefix', 'export', 'export-vault', 'get-as-json', 'list', 'tree', 'list-apps', 'list-envs', 'stale', 'expiring', 'serve', 'agent', 'watch', 'sync-k8s', 'agent-get', 'verify-running', 'bench', 'gc', 'doctor', 'explain-config', 'scaffold', 'version', 'self-update', or 'completion'")
 	name := flag.String("name", "", "Parameter name")
 	value := flag.String("value", "", "Parameter value (required for 'put')")
-	sourceFile := flag.String("s", "", "Source file (JSON for get/put-from-template, .env for generate)")
+	sourceFile := flag.String("s", "", "Source file (JSON for get/put-from-template, .env for generate); - reads it from stdin")
 	paramType := flag.String("type", "string", "Parameter type: 'string', 'stringlist', or 'securestring' (defaults to 'string')")
-	outputPrefix := flag.String("o", "", "Output prefix for saving bulk env (e.g., 'env' saves as 'env-ddmmyy.env') or output file for generate/get-by-prefix")
+	outputPrefix := flag.String("o", "", "Output path for bulk env (e.g., 'env' saves as 'env.env' and 'env.json') or output file for generate/get-by-prefix")
+	timestamp := flag.Bool("timestamp", false, "Append the current date to bulk output file names (see -timestamp-layout)")
+	timestampLayout := flag.String("timestamp-layout", "2006-01-02", "Go time layout for -timestamp, e.g. '20060102-1504'")
 	region := flag.String("region", "", "AWS region (defaults to config or 'ap-southeast-3')")
-	prefix := flag.String("prefix", "", "Prefix for get-by-prefix action")
+	prefix := flag.String("prefix", "", "Prefix for get-by-prefix and list actions")
+	dryRun := flag.Bool("dry-run", false, "Print the API calls mutating actions would make, with diffs, without executing them")
+	readOnly := flag.Bool("read-only", false, "Refuse mutating actions, e.g. for auditors or production shells (also \"readOnly\" in config.json)")
+	flag.BoolVar(&printPolicy, "print-policy", false, "When a call is denied, also print an IAM policy document that would allow it")
+	justification := flag.String("i-know-what-i-am-doing", "", "Allow writes under the protectedPrefixes of config.json; the given justification is written to the audit log")
+	assumeYes := flag.Bool("yes", false, "Overwrite existing parameters without prompting (also set by PARAM_STORE_YES=1)")
+	noOverwrite := flag.Bool("no-overwrite", false, "Never overwrite existing parameters; skip them without prompting")
+	ifNotExists := flag.Bool("if-not-exists", false, "Create-only mode for put/put-from-template: existing parameters are skipped, not errors")
+	recreateOnTypeChange := flag.Bool("recreate-on-type-change", false, "Delete and recreate parameters that exist with another type instead of failing")
+	dataType := flag.String("data-type", "", "Data type for 'put': 'text' (default) or 'aws:ec2:image', which SSM checks names an AMI")
+	tier := flag.String("tier", "", "Parameter tier for puts: 'standard', 'advanced' (values up to 8 KB) or 'intelligent-tiering' (default: the account's default tier)")
+	expectVersion := flag.Int64("expect-version", 0, "Only 'put' if the live parameter is at this version (guards against lost updates)")
+	templateVars := keyValueFlag{}
+	flag.Var(templateVars, "var", "Template variable as key=value for {{key}} placeholders in valueFrom (repeatable)")
+	fallbackToPrefix := flag.Bool("fallback-to-prefix", false, "Derive missing template valueFrom as <parameterPrefix><name> instead of failing")
+	refFormat := flag.String("ref-format", "path", "valueFrom form in generated templates: 'path' or 'arn' (account from -var account_id or STS)")
+	allowCrossAccount := flag.Bool("allow-cross-account", false, "Warn instead of failing when template ARNs name another account or region")
+	keys := flag.String("keys", "", "Comma-separated secret names to resolve with -s, or environment entries to move with 'secretize'")
+	defaultsFile := flag.String("defaults", "", "For 'seed': file with values for missing parameters, by secret name (any -input-format)")
+	env := flag.String("env", "", "For 'bootstrap': the environment to set up, the {{env}} of the spec's prefix and the key of per-environment defaults")
+	contractFile := flag.String("contract", "", "For 'check-contract': YAML file of the required and optional keys under -prefix, with their types")
+	missing := flag.String("missing", features.MissingError, "For -s: parameters that do not exist are an 'error', 'skip'ped, or written as a 'placeholder' (the secret's default, or <MISSING:/path>)")
+	clean := flag.Bool("clean", false, "For -s -o: drop read-only fields (taskDefinitionArn, revision, status, ...) from the saved task definition")
+	preserveUnknown := flag.Bool("preserve-unknown", false, "For -s -o: keep the saved task definition byte-for-byte, only adding values and types")
+	withTags := flag.Bool("with-tags", false, "For 'export' -format taskdef: read the tags of every parameter (one API call each) and write them with the secrets")
+	canonical := flag.Bool("canonical", false, "Write generated task definition JSON canonically (sorted keys and secrets, no registration times) for clean diffs")
+	fingerprint := flag.Bool("fingerprint", false, "For 'export', 'get-by-prefix', get -s and 'watch': print a SHA-256 fingerprint of the parameter set and embed it in the written files")
+	versionVar := flag.String("version-var", "", "Add this environment variable (e.g. CONFIG_VERSION) with the parameters' fingerprint to generated task definitions")
+	checkExisting := flag.Bool("check-existing", false, "For generate: fail when a generated path exists in SSM with another type")
+	interactive := flag.Bool("interactive", false, "For put-from-template: choose the secrets to put from a checklist showing which are new or changed")
+	changedSince := flag.String("changed-since", "", "For put-from-template and import: only put the keys whose lines in -s changed since this git revision, e.g. HEAD~1")
+	skipKMSCheck := flag.Bool("skip-kms-check", false, "For put-from-template: do not check access to customer managed KMS keys before putting")
+	strict := flag.Bool("strict", false, "Fail instead of warning when a .env file or template defines the same key twice")
+	tps := flag.Float64("tps", 0, "Maximum SSM API calls per second for the whole run, shared by all regions, accounts and workers (0 is unlimited)")
+	regionsFlag := flag.String("regions", "", "Comma-separated regions to write to concurrently for put/put-from-template (first one serves reads)")
+	accountsFlag := flag.String("accounts", "", "Comma-separated account names from config.json to compare with 'get' or 'list'")
+	allAccounts := flag.Bool("all-accounts", false, "Compare 'get' or 'list' across every account in config.json")
+	shell := flag.String("shell", "bash", "Shell for 'completion': 'bash', 'zsh', or 'fish'")
+	raw := flag.Bool("raw", false, "For 'get' and 'get-public': print only the exact value, with no label or added newline")
+	field := flag.String("field", "", "For 'get-public': print only this field of a JSON value, e.g. image_id (dotted for nested fields)")
+	inspect := flag.Bool("inspect", false, "For 'get': print the details of PEM certificates instead of the value, and verify the chain across comma-separated -name parameters")
+	copyValue := flag.Bool("copy", false, "For 'get': copy the value to the clipboard instead of printing it")
+	clearAfter := flag.Duration("clear-after", 45*time.Second, "With -copy: clear the clipboard after this long if it still holds the value (0 keeps it)")
+	listen := flag.String("listen", "127.0.0.1:8099", "Address for 'serve' to listen on")
+	cacheTTL := flag.Duration("cache-ttl", 30*time.Second, "How long 'serve' and 'agent' reuse SSM responses (0 disables caching)")
+	format := flag.String("format", features.FormatEnv, "Output formats, comma-separated, for get -s, get-by-prefix and export (see -action export -h)")
+	secretName := flag.String("secret", "", "Kubernetes Secret that sync-k8s keeps in sync with -prefix")
+	namespace := flag.String("namespace", "", "Namespace of the sync-k8s Secret (default: the pod's namespace)")
+	benchCount := flag.Int("count", 100, "For 'bench': synthetic parameters to write, read and delete")
+	concurrency := flag.Int("concurrency", 10, "For 'bench': SSM calls in flight at once")
+	annotate := flag.String("annotate", "", "For 'tree': comma-separated annotations of each parameter: 'type', 'size' (decrypts values)")
+	olderThan := flag.String("older-than", "", "For 'stale' and 'gc': parameters not modified for this long, e.g. 180d, 2w or 36h")
+	gcTag := flag.String("tag", "", "For 'gc': key=value tag of the parameters to delete, e.g. "+features.EphemeralTag)
+	within := flag.String("within", "30d", "For 'expiring': report certificates and tokens expiring within this long, e.g. 30d")
+	interval := flag.Duration("interval", 60*time.Second, "Time between sync-k8s syncs")
+	cluster := flag.String("cluster", "", "ECS cluster for verify-running")
+	service := flag.String("service", "", "ECS service for verify-running")
+	vaultPath := flag.String("vault-path", "", "Vault KV secret for export-vault and import-vault, as <mount>/<path> (e.g. secret/prod/app)")
+	inputFormat := flag.String("input-format", "", "Format of the -s file for import and generate, and of -defaults for seed: "+strings.Join(features.InputFormatNames(), ", ")+" (default: from the file extension)")
+	arrays := flag.String("arrays", features.ArraysStringList, "Array mapping for import/put-from-json and get-as-json: 'stringlist', 'index', or 'json'")
+	lambdaFunction := flag.String("lambda", "", "For 'watch': Lambda function whose environment receives the parameters")
+	queueURL := flag.String("queue-url", "", "For 'watch': existing SQS queue with the change events (default: create a queue and EventBridge rule)")
+	kubectlApply := flag.Bool("kubectl-apply", false, "For 'watch': run 'kubectl apply' on the k8s output after each render")
+	kmsKeyID := flag.String("kms-key-id", "", "For 'reencrypt' and 'audit-types -fix': KMS key ID, ARN or alias to encrypt the SecureStrings with")
+	fix := flag.Bool("fix", false, "For 'audit-types': convert the String parameters that look like secrets to SecureString")
+	typesFile := flag.String("types", "", "For 'generate', 'classify' and 'verify-roundtrip': type mapping file pinning the type, KMS key or path of keys (default: "+features.TypeMapFile+" next to -s, if it exists)")
+	reportFormat := flag.String("report", features.ReportJSON, "For 'classify': report format, 'json' (gitleaks findings) or 'sarif'")
+	live := flag.Bool("live", false, "For 'verify-roundtrip': put and read back through Parameter Store under a scratch -prefix instead of an in-memory store")
+	scanPath := flag.String("path", ".", "For 'scan-leaks': directory or file to search for SecureString values")
+	moveFrom := flag.String("from", "", "For 'move': parameter to rename")
+	moveTo := flag.String("to", "", "For 'move': new name of the parameter")
+	moveFromPrefix := flag.String("from-prefix", "", "For 'move': prefix whose whole subtree is moved")
+	moveToPrefix := flag.String("to-prefix", "", "For 'move': prefix the subtree is moved to")
+	refMap := keyValueFlag{}
+	flag.Var(refMap, "map", "For 'rewrite-refs': old=new path or ARN prefix, or region:<old>=<new> / account:<old>=<new> (repeatable)")
+	socket := flag.String("socket", features.DefaultAgentSocket(), "Unix socket for 'agent' and 'agent-get'")
+	eventsFormat := flag.String("events", "", "Write one JSON event per parameter fetched, put, deleted, failed or retried as it happens: 'ndjson'")
+	eventsFile := flag.String("events-file", "-", "File for -events; - is stdout, and informational messages then go to stderr")
+	quiet := flag.Bool("q", false, "Quiet: print only the output of the action, without informational messages (which go to stderr when stdout is not a terminal)")
+	noColor := flag.Bool("no-color", false, "Do not color status lines and diffs (color is also off when stdout is not a terminal or NO_COLOR is set)")
 	helpFlag := flag.Bool("h", false, "Show help for the specified action")
 	flag.Parse()
+	features.SetColor(!*noColor && features.ColorDefault())
+	if *quiet {
+		features.SetInfoOutput(io.Discard)
+	} else {
+		features.SetInfoOutput(features.InfoOutputDefault())
+	}
 
 	// Show help if requested
 	if *helpFlag {
@@ -36,55 +162,817 @@ func main() {
 		return
 	}
 
+	// Completion must not create config.json or print anything but the script.
+	if *action == "completion" {
+		script, err := features.GenerateCompletion(*shell, "salter-aws", actions, completionFlags())
+		if err != nil {
+			fatal("Failed to generate completion", err)
+		}
+		fmt.Print(script)
+		return
+	}
+	// version and self-update need neither config.json nor AWS credentials.
+	buildInfo := features.BuildInfo{Version: version, Commit: commit, Date: date}
+	if *action == "version" || *action == "" && flag.Arg(0) == "version" {
+		fmt.Println(buildInfo)
+		return
+	}
+	if *action == "self-update" {
+		if err := features.SelfUpdate(features.SelfUpdateOptions{Current: buildInfo, DryRun: *dryRun}); err != nil {
+			fatal("Self-update failed", err)
+		}
+		return
+	}
+	// scaffold writes starter files; like doctor it must not create config.json first.
+	if *action == "scaffold" {
+		kind := flag.Arg(0)
+		if kind == "" {
+			fmt.Println("Error: 'scaffold' needs what to write: " + strings.Join(features.ScaffoldKinds(), ", "))
+			os.Exit(features.ExitValidation)
+		}
+		// Flags after the kind, as in -action scaffold spec -o billing.yaml.
+		if err := flag.CommandLine.Parse(flag.Args()[1:]); err != nil {
+			os.Exit(features.ExitValidation)
+		}
+		if err := features.Scaffold(kind, *outputPrefix); err != nil {
+			fatal("Failed to scaffold", err)
+		}
+		return
+	}
+	if *action == "clear-clipboard" {
+		// Internal: started in the background by 'get -copy' with a token of the value on stdin.
+		token, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fatal("Failed to read clipboard token", err)
+		}
+		time.Sleep(*clearAfter)
+		if err := features.ClearClipboardIfUnchanged(string(token)); err != nil {
+			fatal("Failed to clear clipboard", err)
+		}
+		return
+	}
+	if *action == "complete-names" {
+		completeNames(*region, *prefix)
+		return
+	}
+	// -raw output is for command substitution; it is neither read from -s nor written to -o.
+	if *raw && (*sourceFile != "" || *outputPrefix != "" || *copyValue) {
+		fmt.Println("Error: -raw prints the value to stdout and cannot be combined with -s, -o or -copy")
+		os.Exit(features.ExitValidation)
+	}
+	// agent-get talks only to the running agent; it never loads config or AWS credentials.
+	if *action == "agent-get" {
+		paramName := *name
+		if paramName == "" {
+			paramName = flag.Arg(0)
+		}
+		if paramName == "" {
+			fmt.Println("Error: a parameter name is required for 'agent-get'")
+			os.Exit(features.ExitValidation)
+		}
+		value, err := features.AgentGet(*socket, paramName)
+		if err != nil {
+			fatal("Failed to get parameter from agent", err)
+		}
+		fmt.Print(value)
+		return
+	}
+
+	for _, f := range splitList(*format) {
+		if err := features.ValidateFormat(f); err != nil {
+			fatal("Invalid -format", err)
+		}
+	}
+	if err := features.ValidateArrays(*arrays); err != nil {
+		fatal("Invalid -arrays", err)
+	}
+	// Bulk outputs are written exactly where -o says unless -timestamp is given.
+	bulkOutput := *outputPrefix
+	if bulkOutput != "" {
+		layout := ""
+		if *timestamp {
+			layout = *timestampLayout
+		}
+		bulkOutput = features.OutputBase(bulkOutput, layout)
+	}
+
+	// Handle doctor before loading config.json, which it diagnoses instead of failing on.
+	if *action == "doctor" {
+		err := features.RunDoctor(features.DoctorOptions{
+			ConfigFile: "config.json",
+			Region:     *region,
+			Prefix:     *prefix,
+			OutputDir:  features.DoctorOutputDir(*outputPrefix),
+		})
+		if err != nil {
+			fatal("Doctor found problems", err)
+		}
+		return
+	}
+
+	// Handle explain-config: show the effective settings without creating config.json.
+	if *action == "explain-config" {
+		flags := make(map[string]features.ConfigFlag)
+		for setting, name := range map[string]string{"region": "region", "tps": "tps", "kmsKeyId": "kms-key-id", "fallbackToPrefix": "fallback-to-prefix", "readOnly": "read-only"} {
+			if f := flag.Lookup(name); flagSet(name) {
+				flags[setting] = features.ConfigFlag{Flag: name, Value: f.Value.String()}
+			}
+		}
+		if err := features.ExplainConfig("config.json", flags); err != nil {
+			fatal("Failed to read config", err)
+		}
+		return
+	}
+
 	// Load configuration from config.json if exists.
 	toolConfig, err := features.LoadConfig()
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		fatal("Failed to load config", err)
+	}
+	if err := features.ConfigureDetection(toolConfig.Detection); err != nil {
+		fatal("Invalid detection rules in config.json", err)
+	}
+	// In read-only mode mutating actions fail before anything is read; -dry-run is still allowed.
+	*readOnly = *readOnly || toolConfig.ReadOnly
+	if *readOnly && !*dryRun && (slices.Contains(mutatingActions, *action) || *action == "audit-types" && *fix) {
+		fmt.Printf("Error: '%s' is not allowed in read-only mode (-read-only or \"readOnly\" in config.json); use -dry-run to preview it\n", *action)
+		os.Exit(features.ExitValidation)
 	}
 	// Set default region from config if not specified.
 	if *region == "" {
 		*region = toolConfig.Region
 	}
-	// Handle generate action (no AWS needed).
+	// With -regions the first region is the primary one for reads and template checks.
+	fanOutRegions := splitList(*regionsFlag)
+	if len(fanOutRegions) > 0 {
+		*region = fanOutRegions[0]
+	}
+	// Resolve template variables: config first, then built-ins, then -var flags.
+	tmplOpts := features.TemplateOptions{Vars: map[string]string{}}
+	for key, val := range toolConfig.Variables {
+		tmplOpts.Vars[key] = val
+	}
+	if _, ok := tmplOpts.Vars["region"]; !ok {
+		tmplOpts.Vars["region"] = *region
+	}
+	for key, val := range templateVars {
+		tmplOpts.Vars[key] = val
+	}
+	if *fallbackToPrefix || toolConfig.FallbackToPrefix {
+		tmplOpts.FallbackPrefix = toolConfig.ParameterPrefix
+	}
+	tmplOpts.PathPattern = toolConfig.PathPattern
+	tmplOpts.Region = *region
+	tmplOpts.AllowCrossAccount = *allowCrossAccount
+	tmplOpts.Strict = *strict
+
+	// Handle generate action (no AWS needed unless -check-existing is given).
 	if *action == "generate" {
 		if *sourceFile == "" || *outputPrefix == "" {
 			fmt.Println("Error: -s <env-file> and -o <output.json> required for 'generate'")
-			os.Exit(1)
+			os.Exit(features.ExitValidation)
+		}
+		refs, err := referenceOptions(*refFormat, *region, tmplOpts.Vars)
+		if err != nil {
+			fatal("Failed to resolve reference format", err)
+		}
+		genOpts := features.GenerateOptions{
+			Format:     *inputFormat,
+			Prefix:     toolConfig.ParameterPrefix,
+			Refs:       refs,
+			Strict:     *strict,
+			Arrays:     *arrays,
+			Canonical:  *canonical,
+			VersionVar: *versionVar,
+			Names:      toolConfig.NameRules,
+			Types:      *typesFile,
+		}
+		if *checkExisting {
+			cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(*region))
+			if err != nil {
+				fatal("Unable to load SDK config", err)
+			}
+			genOpts.Existing = ssm.NewFromConfig(cfg, ssmEndpoint(toolConfig.Endpoint))
+		}
+		if err := features.GenerateTaskDef(*sourceFile, *outputPrefix, genOpts); err != nil {
+			fatal("Failed to generate task definition", err)
 		}
-		err := features.GenerateTaskDefFromEnv(*sourceFile, *outputPrefix, toolConfig.ParameterPrefix)
+		return
+	}
+
+	// Handle classify (no AWS needed).
+	if *action == "classify" {
+		if *sourceFile == "" {
+			fmt.Println("Error: -s <env-file> is required for 'classify'")
+			os.Exit(features.ExitValidation)
+		}
+		err := features.ClassifySource(*sourceFile, features.ClassifyOptions{
+			Format:      *inputFormat,
+			Arrays:      *arrays,
+			Strict:      *strict,
+			Types:       *typesFile,
+			Report:      *reportFormat,
+			Output:      *outputPrefix,
+			ToolVersion: version,
+		})
 		if err != nil {
-			log.Fatalf("Failed to generate task definition: %v", err)
+			fatal("Failed to classify keys", err)
+		}
+		return
+	}
+
+	// Handle verify-roundtrip (no AWS needed unless -live is given).
+	if *action == "verify-roundtrip" {
+		if *sourceFile == "" {
+			fmt.Println("Error: -s <env-file> is required for 'verify-roundtrip'")
+			os.Exit(features.ExitValidation)
+		}
+		rtOpts := features.RoundTripOptions{
+			Format: *inputFormat,
+			Arrays: *arrays,
+			Strict: *strict,
+			Prefix: *prefix,
+			Names:  toolConfig.NameRules,
+			Types:  *typesFile,
+			Output: *outputPrefix,
+		}
+		var client features.SSMClient
+		if *live {
+			if *prefix == "" {
+				fmt.Println("Error: -live requires -prefix <scratch-prefix> for 'verify-roundtrip'")
+				os.Exit(features.ExitValidation)
+			}
+			if *readOnly || *dryRun {
+				fmt.Println("Error: -live puts and deletes parameters; it cannot be used with -read-only or -dry-run")
+				os.Exit(features.ExitValidation)
+			}
+			cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(*region))
+			if err != nil {
+				fatal("Unable to load SDK config", err)
+			}
+			client = ssm.NewFromConfig(cfg, ssmEndpoint(toolConfig.Endpoint))
+		}
+		if err := features.VerifyRoundTrip(client, *sourceFile, rtOpts); err != nil {
+			fatal("Failed to verify the round trip", err)
+		}
+		return
+	}
+
+	// Handle import-terraform (no AWS needed).
+	if *action == "import-terraform" {
+		if *sourceFile == "" {
+			fmt.Println("Error: -s <terraform.tfstate> is required for 'import-terraform'")
+			os.Exit(features.ExitValidation)
+		}
+		if err := features.ImportTerraformState(*sourceFile, *outputPrefix); err != nil {
+			fatal("Failed to import Terraform state", err)
+		}
+		return
+	}
+
+	// Handle graph action (no AWS needed).
+	if *action == "graph" {
+		if *sourceFile == "" {
+			fmt.Println("Error: -s <template.json> is required for 'graph'")
+			os.Exit(features.ExitValidation)
+		}
+		if err := features.WriteTemplateGraph(*sourceFile, *outputPrefix, tmplOpts); err != nil {
+			fatal("Failed to build reference graph", err)
+		}
+		return
+	}
+
+	// Handle rewrite-refs (no AWS needed).
+	if *action == "rewrite-refs" {
+		if *sourceFile == "" {
+			fmt.Println("Error: -s <task-def.json> and at least one -map old=new are required for 'rewrite-refs'")
+			os.Exit(features.ExitValidation)
+		}
+		err := features.RewriteReferences(splitList(*sourceFile), features.RewriteRefsOptions{
+			Map:    refMap,
+			Output: *outputPrefix,
+			DryRun: *dryRun,
+		})
+		if err != nil {
+			fatal("Failed to rewrite references", err)
 		}
 		return
 	}
 
 	// Load AWS configuration with the specified region for SSM operations.
-	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(*region))
+	loadOpts := []func(*config.LoadOptions) error{config.WithRegion(*region)}
+	if *action == "agent" {
+		// The agent is long-lived, so an MFA code for an assumed role is asked once on its terminal.
+		loadOpts = append(loadOpts, config.WithAssumeRoleCredentialOptions(func(o *stscreds.AssumeRoleOptions) {
+			o.TokenProvider = stscreds.StdinTokenProvider
+		}))
+	}
+	cfg, err := config.LoadDefaultConfig(context.TODO(), loadOpts...)
 	if err != nil {
-		log.Fatalf("Unable to load SDK config: %v", err)
+		fatal("Unable to load SDK config", err)
+	}
+	// {{rds-endpoint id}} and similar template values are resolved in the primary region when applied.
+	tmplOpts.Resources = features.NewResourceResolver(rds.NewFromConfig(cfg), elasticache.NewFromConfig(cfg))
+
+	// Collect options that control how existing parameters are overwritten.
+	putOpts := features.PutOptions{
+		AssumeYes:            *assumeYes || features.AssumeYesFromEnv(),
+		NoOverwrite:          *noOverwrite || *ifNotExists,
+		KeyID:                toolConfig.KMSKeyID,
+		RecreateOnTypeChange: *recreateOnTypeChange,
+	}
+	if *tier != "" {
+		putOpts.Tier, err = features.ParseTier(*tier)
+		if err != nil {
+			fatal("Invalid -tier", err)
+		}
+	}
+	if *dataType != "" {
+		if *action != "put" {
+			fmt.Println("Error: -data-type is only supported for 'put'; templates set \"dataType\" per secret")
+			os.Exit(features.ExitValidation)
+		}
+		putOpts.DataType, err = features.ParseDataType(*dataType)
+		if err != nil {
+			fatal("Invalid -data-type", err)
+		}
+	}
+	if *expectVersion != 0 {
+		if *action != "put" {
+			fmt.Println("Error: -expect-version is only supported for 'put'")
+			os.Exit(features.ExitValidation)
+		}
+		putOpts.ExpectVersion = *expectVersion
+		putOpts.AssumeYes = true // The version check replaces the interactive confirmation.
+	}
+	if *interactive {
+		if *action != "put-from-template" {
+			fmt.Println("Error: -interactive is only supported for 'put-from-template'")
+			os.Exit(features.ExitValidation)
+		}
+		putOpts.Interactive = true
+	}
+	if *changedSince != "" {
+		if *action != "put-from-template" && *action != "import" {
+			fmt.Println("Error: -changed-since is only supported for 'put-from-template' and 'import'")
+			os.Exit(features.ExitValidation)
+		}
+		putOpts.ChangedSince = *changedSince
+	}
+
+	// One limiter budgets every SSM client of the run.
+	if !flagSet("tps") {
+		*tps = toolConfig.TPS
+	}
+	var limiter *features.RateLimiter
+	if *tps < 0 {
+		fmt.Println("Error: -tps must not be negative")
+		os.Exit(features.ExitValidation)
+	} else if *tps > 0 {
+		limiter = features.NewRateLimiter(*tps)
+	}
+	limit := func(c features.SSMClient) features.SSMClient {
+		if limiter == nil {
+			return c
+		}
+		return features.NewRateLimitedClient(c, limiter)
+	}
+
+	// Writes under protected prefixes need a justification, which is audited before the write is sent.
+	protect := features.ProtectOptions{
+		Prefixes:      toolConfig.ProtectedPrefixes,
+		Justification: *justification,
+		Action:        *action,
+		AuditLog:      toolConfig.AuditLog,
+	}
+
+	// -events reports every call of each regional client, including writes only printed by -dry-run.
+	observe := func(c features.SSMClient) features.SSMClient { return c }
+	if *eventsFormat != "" {
+		if *eventsFormat != features.EventsNDJSON {
+			fmt.Printf("Error: invalid -events %q (use ndjson)\n", *eventsFormat)
+			os.Exit(features.ExitValidation)
+		}
+		out := os.Stdout
+		if *eventsFile != "-" {
+			out, err = os.Create(*eventsFile)
+			if err != nil {
+				fatal("Failed to create -events-file", err)
+			}
+			defer out.Close()
+		} else if !*quiet {
+			features.SetInfoOutput(os.Stderr)
+		}
+		events = features.NewEventStream(out, *action)
+		defer events.Finish(nil)
+		observe = func(c features.SSMClient) features.SSMClient { return features.NewEventClient(c, events) }
 	}
 
 	// Create an SSM client using the loaded configuration.
-	client := ssm.NewFromConfig(cfg)
+	client := limit(ssm.NewFromConfig(cfg, ssmEndpoint(toolConfig.Endpoint)))
+	if *readOnly {
+		// A write that slips past the action check is refused rather than sent.
+		client = features.NewReadOnlyClient(client)
+	}
+	client = features.NewProtectedClient(client, protect)
+	if *dryRun {
+		// Mutating calls are printed instead of executed.
+		client = features.NewDryRunClient(client)
+	}
+	client = observe(client)
+
+	// Fan puts out to several regions when -regions is given.
+	var multiRegion *features.MultiRegionClient
+	if len(fanOutRegions) > 0 {
+		if *action != "put" && *action != "put-from-template" && *action != "import" && *action != "import-vault" && *action != "put-from-json" && *action != "secretize" {
+			fmt.Println("Error: -regions is only supported for 'put', 'put-from-template', 'import', 'import-vault', 'put-from-json' and 'secretize'")
+			os.Exit(features.ExitValidation)
+		}
+		if !putOpts.AssumeYes && !putOpts.NoOverwrite && !putOpts.Interactive && !*dryRun {
+			fmt.Println("Error: -regions cannot prompt per region; add -yes, -if-not-exists, -interactive or -dry-run")
+			os.Exit(features.ExitValidation)
+		}
+		clients := make(map[string]features.SSMClient)
+		for _, r := range fanOutRegions {
+			regionCfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(r))
+			if err != nil {
+				fatal("Unable to load SDK config for "+r, err)
+			}
+			regionClient := limit(ssm.NewFromConfig(regionCfg, ssmEndpoint(toolConfig.Endpoint)))
+			if *readOnly {
+				regionClient = features.NewReadOnlyClient(regionClient)
+			}
+			regionClient = features.NewProtectedClient(regionClient, protect)
+			if *dryRun {
+				regionClient = features.NewDryRunClient(regionClient)
+			}
+			clients[r] = observe(regionClient)
+		}
+		multiRegion = features.NewMultiRegionClient(fanOutRegions, clients)
+		client = multiRegion
+	}
+
+	// Hooks from config.json run around the writes, once for all regions; post hooks also run when the run fails.
+	if toolConfig.Hooks != nil {
+		if err := features.CheckHooks(*toolConfig.Hooks); err != nil {
+			fatal("Invalid hooks in config.json", err)
+		}
+		hooks = features.NewHookClient(client, *toolConfig.Hooks, *action)
+		client = hooks
+		defer hooks.Finish(nil)
+	}
+
+	// One-shot actions read each parameter once per run; serve, agent and watch must see changes.
+	if *action != "serve" && *action != "agent" && *action != "watch" {
+		client = features.NewMemoClient(client)
+	}
+
+	// Read-only actions can run across several configured accounts and print one comparison table.
+	if *accountsFlag != "" || *allAccounts {
+		accounts, err := features.SelectAccounts(toolConfig.Accounts, splitList(*accountsFlag))
+		if err != nil {
+			fatal("Failed to select accounts", err)
+		}
+		var clients []features.NamedClient
+		for _, account := range accounts {
+			clients = append(clients, features.NamedClient{Name: account.Name, Client: observe(limit(features.AccountClient(cfg, account, ssmEndpoint(toolConfig.Endpoint))))})
+		}
+		switch *action {
+		case "get":
+			if *name == "" {
+				fmt.Println("Error: -name is required for 'get'")
+				os.Exit(features.ExitValidation)
+			}
+			err = features.CompareParameterAcrossAccounts(clients, *name)
+		case "list":
+			if *prefix == "" {
+				fmt.Println("Error: -prefix is required for 'list'")
+				os.Exit(features.ExitValidation)
+			}
+			err = features.CompareListAcrossAccounts(clients, *prefix)
+		default:
+			fmt.Println("Error: -accounts and -all-accounts are only supported for 'get' and 'list'")
+			os.Exit(features.ExitValidation)
+		}
+		if err != nil {
+			fatal("Failed to compare accounts", err)
+		}
+		return
+	}
+
+	// Templates are checked against the active account; without an identity only the region is checked.
+	if *sourceFile != "" && *action != "import" && *action != "put-from-json" && *action != "move" {
+		if accountID, err := features.CallerAccountID(cfg); err != nil {
+			features.Infof("Warning: could not determine active account, skipping ARN account checks: %v\n", err)
+		} else {
+			tmplOpts.AccountID = accountID
+		}
+	}
 
 	// Handle put-from-template action.
 	if *action == "put-from-template" {
 		if *sourceFile == "" {
 			fmt.Println("Error: -s <filename.json> is required for 'put-from-template'")
-			os.Exit(1)
+			os.Exit(features.ExitValidation)
+		}
+		if !*skipKMSCheck {
+			putOpts.KMS = kms.NewFromConfig(cfg) // Keys are checked in the primary region.
+		}
+		err := features.PutParametersFromTemplate(client, *sourceFile, tmplOpts, putOpts)
+		if multiRegion != nil {
+			multiRegion.PrintResults()
+		}
+		if err != nil {
+			fatal("Failed to put parameters from template", err)
+		}
+		return
+	}
+
+	// Handle seed: create the parameters a template references that do not exist.
+	if *action == "seed" {
+		if *sourceFile == "" {
+			fmt.Println("Error: -s <task-def.json> is required for 'seed'")
+			os.Exit(features.ExitValidation)
+		}
+		err := features.SeedParameters(client, *sourceFile, tmplOpts, features.SeedOptions{
+			Defaults:       *defaultsFile,
+			DefaultsFormat: *inputFormat,
+			Put:            putOpts,
+			Input:          features.InputOptions{Arrays: *arrays, Strict: *strict},
+		})
+		if err != nil {
+			fatal("Failed to seed parameters", err)
+		}
+		return
+	}
+
+	// Handle bootstrap: create the parameters an environment spec declares that do not exist.
+	if *action == "bootstrap" {
+		if *sourceFile == "" || *env == "" {
+			fmt.Println("Error: -s <spec.yaml> and -env <environment> are required for 'bootstrap'")
+			os.Exit(features.ExitValidation)
+		}
+		spec, err := features.ReadBootstrapSpec(*sourceFile)
+		if err != nil {
+			fatal("Invalid bootstrap spec", err)
+		}
+		if err := features.Bootstrap(client, spec, *env, tmplOpts, putOpts); err != nil {
+			fatal("Failed to bootstrap environment", err)
+		}
+		return
+	}
+
+	// Handle secretize: move plaintext environment entries into SSM-backed secrets.
+	if *action == "secretize" {
+		if *sourceFile == "" {
+			fmt.Println("Error: -s <task-def.json> is required for 'secretize'")
+			os.Exit(features.ExitValidation)
+		}
+		secretizeOpts := features.SecretizeOptions{Keys: splitList(*keys), Prefix: *prefix, Canonical: *canonical}
+		if secretizeOpts.Prefix == "" {
+			secretizeOpts.Prefix = toolConfig.ParameterPrefix
+		}
+		if flagSet("type") {
+			paramType, err := features.ParseParameterType(*paramType)
+			if err != nil {
+				fatal("Invalid -type", err)
+			}
+			secretizeOpts.Type = paramType
+		}
+		refs, err := referenceOptions(*refFormat, *region, tmplOpts.Vars)
+		if err != nil {
+			fatal("Failed to resolve reference format", err)
+		}
+		secretizeOpts.Refs = refs
+		output := *outputPrefix
+		if output == "" {
+			output = *sourceFile // Rewrite in place.
+		}
+		err = features.SecretizeTaskDef(client, *sourceFile, output, secretizeOpts, putOpts)
+		if multiRegion != nil {
+			multiRegion.PrintResults()
+		}
+		if err != nil {
+			fatal("Failed to secretize task definition", err)
+		}
+		return
+	}
+
+	// Handle import and put-from-json: bulk put from a structured file.
+	if *action == "import" || *action == "put-from-json" {
+		if *sourceFile == "" || *prefix == "" {
+			fmt.Printf("Error: -s <file> and -prefix are required for '%s'\n", *action)
+			os.Exit(features.ExitValidation)
+		}
+		importOpts := features.ImportOptions{
+			Format: *inputFormat,
+			Prefix: *prefix,
+			Arrays: *arrays,
+			Strict: *strict,
+		}
+		switch {
+		case *action == "put-from-json":
+			importOpts.Format = features.FormatJSON
+		case !flagSet("input-format") && flagSet("format"):
+			importOpts.Format = *format // Before -input-format existed, import took its format from -format.
+		}
+		err := features.ImportParameters(client, *sourceFile, importOpts, putOpts)
+		if multiRegion != nil {
+			multiRegion.PrintResults()
+		}
+		if err != nil {
+			fatal("Failed to import parameters", err)
 		}
-		err := features.PutParametersFromTemplate(client, *sourceFile)
+		return
+	}
+
+	// Handle import-vault: bulk put from a Vault KV secret.
+	if *action == "import-vault" {
+		if *vaultPath == "" || *prefix == "" {
+			fmt.Println("Error: -vault-path and -prefix are required for 'import-vault'")
+			os.Exit(features.ExitValidation)
+		}
+		err := features.ImportVault(client, *vaultPath, features.ImportOptions{Prefix: *prefix, Arrays: *arrays}, features.VaultOptionsFromEnv(), putOpts)
+		if multiRegion != nil {
+			multiRegion.PrintResults()
+		}
+		if err != nil {
+			fatal("Failed to import from Vault", err)
+		}
+		return
+	}
+
+	// Handle check-drift: compare live parameters with a template; exit code 2 means drift.
+	if *action == "check-drift" {
+		if *sourceFile == "" {
+			fmt.Println("Error: -s <template.json> is required for 'check-drift'")
+			os.Exit(features.ExitValidation)
+		}
+		if err := features.CheckDrift(client, *sourceFile, tmplOpts); err != nil {
+			fatal("Drift check failed", err)
+		}
+		return
+	}
+
+	// Handle check-contract: fail when the keys under a prefix break a service's contract.
+	if *action == "check-contract" {
+		if *prefix == "" || *contractFile == "" {
+			fmt.Println("Error: -prefix and -contract <contract.yaml> are required for 'check-contract'")
+			os.Exit(features.ExitValidation)
+		}
+		if err := features.CheckContract(client, *prefix, *contractFile, *strict); err != nil {
+			fatal("Contract check failed", err)
+		}
+		return
+	}
+
+	// Handle lint-template: report template problems with line numbers before anything is put.
+	if *action == "lint-template" {
+		if *sourceFile == "" {
+			fmt.Println("Error: -s <template.json> is required for 'lint-template'")
+			os.Exit(features.ExitValidation)
+		}
+		if err := features.LintTemplate(client, *sourceFile, tmplOpts); err != nil {
+			fatal("Template has problems", err)
+		}
+		return
+	}
+
+	// Handle inline: resolve secrets into plaintext environment entries for local runs.
+	if *action == "inline" {
+		if *sourceFile == "" || *outputPrefix == "" {
+			fmt.Println("Error: -s <task-def.json> and -o <output.json> are required for 'inline'")
+			os.Exit(features.ExitValidation)
+		}
+		if err := features.InlineTaskDef(client, *sourceFile, *outputPrefix, tmplOpts); err != nil {
+			fatal("Failed to inline task definition", err)
+		}
+		return
+	}
+
+	// Handle reencrypt: put every SecureString under a prefix again with another KMS key.
+	if *action == "reencrypt" {
+		if *kmsKeyID == "" {
+			*kmsKeyID = toolConfig.KMSKeyID
+		}
+		if *prefix == "" || *kmsKeyID == "" {
+			fmt.Println("Error: -prefix and -kms-key-id are required for 'reencrypt'")
+			os.Exit(features.ExitValidation)
+		}
+		if err := features.ReencryptParameters(client, *prefix, *kmsKeyID); err != nil {
+			fatal("Failed to re-encrypt parameters", err)
+		}
+		return
+	}
+
+	// Handle audit-types: find secrets stored as String, and with -fix convert them.
+	if *action == "audit-types" {
+		if *prefix == "" {
+			fmt.Println("Error: -prefix is required for 'audit-types'")
+			os.Exit(features.ExitValidation)
+		}
+		if *kmsKeyID == "" {
+			*kmsKeyID = toolConfig.KMSKeyID
+		}
+		err := features.AuditParameterTypes(client, *prefix, features.AuditOptions{Fix: *fix, AssumeYes: putOpts.AssumeYes, KeyID: *kmsKeyID})
 		if err != nil {
-			log.Fatalf("Failed to put parameters from template: %v", err)
+			fatal("Type audit failed", err)
+		}
+		return
+	}
+
+	// Handle scan-leaks: search files for the values of the SecureStrings under a prefix.
+	if *action == "scan-leaks" {
+		scanPrefix := *prefix
+		if scanPrefix == "" {
+			scanPrefix = toolConfig.ParameterPrefix
+		}
+		if scanPrefix == "" {
+			fmt.Println("Error: -prefix (or parameterPrefix in config.json) is required for 'scan-leaks'")
+			os.Exit(features.ExitValidation)
+		}
+		if err := features.ScanLeaks(client, scanPrefix, *scanPath); err != nil {
+			fatal("Leak scan failed", err)
+		}
+		return
+	}
+
+	// Handle bench: measure SSM latency and throttling with synthetic parameters.
+	if *action == "bench" {
+		if *prefix == "" {
+			fmt.Println("Error: -prefix is required for 'bench'")
+			os.Exit(features.ExitValidation)
+		}
+		err := features.RunBenchmark(client, features.BenchOptions{Prefix: *prefix, Count: *benchCount, Concurrency: *concurrency})
+		if err != nil {
+			fatal("Benchmark failed", err)
+		}
+		return
+	}
+
+	// Handle gc: delete old parameters carrying an ephemeral tag.
+	if *action == "gc" {
+		if *gcTag == "" || *olderThan == "" {
+			fmt.Println("Error: -tag and -older-than are required for 'gc'")
+			os.Exit(features.ExitValidation)
+		}
+		age, err := features.ParseAge(*olderThan)
+		if err != nil {
+			fatal("Invalid -older-than", err)
+		}
+		err = features.CollectGarbage(client, features.GCOptions{Tag: *gcTag, OlderThan: age, Prefix: *prefix, AssumeYes: putOpts.AssumeYes})
+		if err != nil {
+			fatal("Failed to collect garbage", err)
+		}
+		return
+	}
+
+	// Handle move: copy a parameter or subtree to a new name, update references, then delete the source.
+	if *action == "move" {
+		moveOpts := features.MoveOptions{AssumeYes: putOpts.AssumeYes}
+		switch {
+		case *moveFrom != "" && *moveTo != "" && *moveFromPrefix == "" && *moveToPrefix == "":
+			moveOpts.From, moveOpts.To = *moveFrom, *moveTo
+		case *moveFromPrefix != "" && *moveToPrefix != "" && *moveFrom == "" && *moveTo == "":
+			moveOpts.From, moveOpts.To, moveOpts.Prefix = *moveFromPrefix, *moveToPrefix, true
+		default:
+			fmt.Println("Error: 'move' needs either -from and -to, or -from-prefix and -to-prefix")
+			os.Exit(features.ExitValidation)
+		}
+		if *sourceFile != "" {
+			moveOpts.TaskDefs = []string{*sourceFile}
+		}
+		if err := features.MoveParameters(client, moveOpts); err != nil {
+			fatal("Failed to move parameters", err)
 		}
 		return
 	}
 
 	// If a source file is provided, retrieve parameters from the ECS task definition.
 	if *sourceFile != "" {
-		err := features.GetParametersFromFile(client, *sourceFile, *outputPrefix)
+		getOpts := features.GetFileOptions{
+			Export: features.ExportOptions{
+				Formats:     splitList(*format),
+				SecretName:  *name,
+				Fingerprint: *fingerprint,
+				Transforms:  toolConfig.Transforms,
+			},
+			Keys:      splitList(*keys),
+			Canonical: *canonical,
+			Missing:   *missing,
+		}
+		switch {
+		case *clean && *preserveUnknown:
+			fmt.Println("Error: -clean and -preserve-unknown cannot be combined")
+			os.Exit(features.ExitValidation)
+		case *clean:
+			getOpts.Rewrite = features.RewriteClean
+		case *preserveUnknown:
+			getOpts.Rewrite = features.RewritePreserve
+		}
+		err := features.GetParametersFromFile(client, *sourceFile, bulkOutput, tmplOpts, getOpts)
 		if err != nil {
-			log.Fatalf("Failed to get parameters from file: %v", err)
+			fatal("Failed to get parameters from file", err)
 		}
 		return
 	}
@@ -106,59 +994,319 @@ func main() {
 		fmt.Println("")
 		fmt.Println("  Put from template:")
 		fmt.Println("    go run main.go -action put-from-template -s <template.json>")
-		os.Exit(1)
+		fmt.Println("")
+		fmt.Println("  List parameters by prefix:")
+		fmt.Println("    go run main.go -action list -prefix <prefix> [-all-accounts | -accounts <a,b>]")
+		os.Exit(features.ExitValidation)
 	}
 	if (*action == "get" || *action == "put") && *name == "" {
 		fmt.Println("Error: -name is required for 'get' and 'put' actions")
-		os.Exit(1)
+		os.Exit(features.ExitValidation)
 	}
 	if *action == "put" && *value == "" {
 		fmt.Println("Error: -value is required for 'put' action")
-		os.Exit(1)
+		os.Exit(features.ExitValidation)
 	}
 	if *action == "generate" && (*sourceFile == "" || *outputPrefix == "") {
 		fmt.Println("Error: -s <env-file> and -o <output.json> required for 'generate'")
-		os.Exit(1)
+		os.Exit(features.ExitValidation)
 	}
 	if *action == "put-from-template" && *sourceFile == "" {
 		fmt.Println("Error: -s <filename.json> is required for 'put-from-template'")
-		os.Exit(1)
+		os.Exit(features.ExitValidation)
 	}
 	if *action == "get-by-prefix" && (*prefix == "" || *outputPrefix == "") {
 		fmt.Println("Error: -prefix and -o <output-base> required for 'get-by-prefix'")
-		os.Exit(1)
+		os.Exit(features.ExitValidation)
+	}
+	if *action == "list" && *prefix == "" {
+		fmt.Println("Error: -prefix is required for 'list'")
+		os.Exit(features.ExitValidation)
+	}
+	if *action == "stale" && (*prefix == "" || *olderThan == "") {
+		fmt.Println("Error: -prefix and -older-than are required for 'stale'")
+		os.Exit(features.ExitValidation)
+	}
+	if *action == "expiring" && *prefix == "" {
+		fmt.Println("Error: -prefix is required for 'expiring'")
+		os.Exit(features.ExitValidation)
 	}
 
 	// Execute the specified action.
 	switch *action {
-	case "get":
+	case "get", "find":
+		if *action == "find" {
+			// Pick the name with a fuzzy search, then get it as usual.
+			root := *prefix
+			if root == "" {
+				root = toolConfig.ParameterPrefix
+			}
+			if root == "" {
+				root = "/"
+			}
+			picked, err := features.FindParameter(client, root, *name)
+			if err != nil {
+				fatal("Failed to find parameters", err)
+			}
+			if picked == "" {
+				features.Infof("Nothing picked\n")
+				return
+			}
+			*name = picked
+		}
+		if *inspect {
+			if err := features.InspectCertificates(client, splitList(*name)); err != nil {
+				fatal("Certificate check failed", err)
+			}
+			break
+		}
 		// Retrieve a single parameter.
 		val, _, err := features.GetParameter(client, *name)
 		if err != nil {
-			log.Fatalf("Failed to get parameter: %v", err)
+			fatal("Failed to get parameter", err)
+		}
+		if *copyValue {
+			if err := features.CopyToClipboard(val); err != nil {
+				fatal("Failed to copy to clipboard", err)
+			}
+			features.Infof("Copied value of %s to the clipboard\n", *name)
+			if *clearAfter > 0 {
+				if err := scheduleClipboardClear(val, *clearAfter); err != nil {
+					features.Infof("Warning: clipboard will not be cleared automatically: %v\n", err)
+				} else {
+					features.Infof("The clipboard will be cleared in %s\n", *clearAfter)
+				}
+			}
+		} else {
+			features.PrintParameter(os.Stdout, *name, val, *raw)
+		}
+	case "get-public":
+		// Look up a parameter AWS publishes, such as the latest AMI IDs.
+		err := features.GetPublicParameter(client, *name, features.PublicOptions{Field: *field, Raw: *raw})
+		if err != nil {
+			fatal("Failed to get public parameter", err)
 		}
-		fmt.Printf("Parameter %s: %s\n", *name, val)
 	case "get-by-prefix":
 		// Retrieve all parameters under a prefix.
 		if *prefix == "" || *outputPrefix == "" {
 			fmt.Println("Error: -prefix and -o <output-base> required for 'get-by-prefix'")
-			os.Exit(1)
+			os.Exit(features.ExitValidation)
+		}
+		refs, err := referenceOptions(*refFormat, *region, tmplOpts.Vars)
+		if err != nil {
+			fatal("Failed to resolve reference format", err)
+		}
+		err = features.GetParametersByPrefix(client, *prefix, features.ExportOptions{
+			Formats:     splitList(*format),
+			Output:      bulkOutput,
+			Refs:        refs,
+			SecretName:  *name,
+			Canonical:   *canonical,
+			Fingerprint: *fingerprint,
+			VersionVar:  *versionVar,
+			Transforms:  toolConfig.Transforms,
+		})
+		if err != nil {
+			fatal("Failed to get parameters by prefix", err)
+		}
+	case "export":
+		// Write the parameters under a prefix in each requested format.
+		if *prefix == "" {
+			fmt.Println("Error: -prefix is required for 'export'")
+			os.Exit(features.ExitValidation)
 		}
-		err := features.GetParametersByPrefix(client, *prefix, *outputPrefix)
+		refs, err := referenceOptions(*refFormat, *region, tmplOpts.Vars)
 		if err != nil {
-			log.Fatalf("Failed to get parameters by prefix: %v", err)
+			fatal("Failed to resolve reference format", err)
+		}
+		err = features.ExportParameters(client, *prefix, features.ExportOptions{
+			Formats:     splitList(*format),
+			Output:      bulkOutput,
+			Refs:        refs,
+			SecretName:  *name,
+			Canonical:   *canonical,
+			Fingerprint: *fingerprint,
+			VersionVar:  *versionVar,
+			WithTags:    *withTags,
+			Transforms:  toolConfig.Transforms,
+		})
+		if err != nil {
+			fatal("Failed to export parameters", err)
+		}
+	case "export-vault":
+		// Mirror the parameters under a prefix into one Vault KV secret.
+		if *prefix == "" || *vaultPath == "" {
+			fmt.Println("Error: -prefix and -vault-path are required for 'export-vault'")
+			os.Exit(features.ExitValidation)
+		}
+		if err := features.ExportVault(client, *prefix, *vaultPath, features.VaultOptionsFromEnv()); err != nil {
+			fatal("Failed to export to Vault", err)
+		}
+	case "get-as-json":
+		// Rebuild the nested JSON object under a prefix.
+		if *prefix == "" {
+			fmt.Println("Error: -prefix is required for 'get-as-json'")
+			os.Exit(features.ExitValidation)
+		}
+		data, err := features.GetParametersAsJSON(client, *prefix, *arrays)
+		if err != nil {
+			fatal("Failed to get parameters as JSON", err)
+		}
+		if *outputPrefix == "" {
+			os.Stdout.Write(data)
+		} else if err := os.WriteFile(*outputPrefix, data, 0644); err != nil {
+			fatal("Failed to write JSON", err)
+		} else {
+			features.Infof("Saved JSON to %s\n", *outputPrefix)
+		}
+	case "list":
+		// List parameter names under a prefix.
+		err := features.ListParameters(client, *prefix)
+		if err != nil {
+			fatal("Failed to list parameters", err)
+		}
+	case "tree":
+		// Show the hierarchy under a prefix.
+		treeOpts, err := features.ParseTreeAnnotations(splitList(*annotate))
+		if err != nil {
+			fatal("Invalid -annotate", err)
+		}
+		if err := features.PrintTree(client, *prefix, treeOpts); err != nil {
+			fatal("Failed to print tree", err)
+		}
+	case "list-apps", "list-envs":
+		// Map the applications or environments under the root of the path pattern.
+		placeholder := features.HierarchyApp
+		if *action == "list-envs" {
+			placeholder = features.HierarchyEnv
+		}
+		if err := features.ListHierarchy(client, toolConfig.PathPattern, placeholder); err != nil {
+			fatal("Failed to list "+placeholder+"s", err)
+		}
+	case "stale":
+		// List parameters not modified within the window.
+		age, err := features.ParseAge(*olderThan)
+		if err != nil {
+			fatal("Invalid -older-than", err)
+		}
+		if err := features.ListStale(client, *prefix, age); err != nil {
+			fatal("Failed to list stale parameters", err)
+		}
+	case "expiring":
+		// Report certificates and tokens that expire soon.
+		window, err := features.ParseAge(*within)
+		if err != nil {
+			fatal("Invalid -within", err)
+		}
+		if err := features.ScanExpiring(client, *prefix, window); err != nil {
+			fatal("Expiry check failed", err)
+		}
+	case "serve":
+		// Serve parameters over HTTP until interrupted.
+		server, err := features.NewServer(client, features.ServerOptions{
+			Listen:   *listen,
+			Token:    os.Getenv(features.ServerTokenEnv),
+			CacheTTL: *cacheTTL,
+		})
+		if err != nil {
+			fatal("Failed to start server", err)
+		}
+		if os.Getenv(features.ServerTokenEnv) == "" {
+			fmt.Fprintf(os.Stderr, "Generated bearer token (set %s to choose one): %s\n", features.ServerTokenEnv, server.Token())
+		}
+		features.Infof("Serving parameters on http://%s\n", *listen)
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := server.ListenAndServe(ctx); err != nil {
+			fatal("Server failed", err)
+		}
+	case "agent":
+		// Serve parameters on a user-only unix socket for 'agent-get' and local tooling.
+		server, err := features.NewServer(client, features.ServerOptions{
+			Socket:   *socket,
+			CacheTTL: *cacheTTL,
+		})
+		if err != nil {
+			fatal("Failed to start agent", err)
+		}
+		features.Infof("Agent listening on %s\n", *socket)
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := server.ListenAndServe(ctx); err != nil {
+			fatal("Agent failed", err)
+		}
+	case "watch":
+		// Re-render outputs whenever a parameter under the prefix changes, until interrupted.
+		if *prefix == "" {
+			fmt.Println("Error: -prefix is required for 'watch'")
+			os.Exit(features.ExitValidation)
+		}
+		if *dryRun {
+			fmt.Println("Error: -dry-run is not supported for 'watch'")
+			os.Exit(features.ExitValidation)
+		}
+		refs, err := referenceOptions(*refFormat, *region, tmplOpts.Vars)
+		if err != nil {
+			fatal("Failed to resolve reference format", err)
+		}
+		watchOpts := features.WatchOptions{
+			Prefix:   *prefix,
+			ApplyK8s: *kubectlApply,
+			Lambda:   *lambdaFunction,
+			QueueURL: *queueURL,
+		}
+		if *outputPrefix != "" {
+			watchOpts.Export = features.ExportOptions{Formats: splitList(*format), Output: *outputPrefix, Refs: refs, SecretName: *name, Fingerprint: *fingerprint, VersionVar: *versionVar, Transforms: toolConfig.Transforms}
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		queues := sqs.NewFromConfig(cfg)
+		if watchOpts.QueueURL == "" {
+			watchOpts.QueueURL, err = features.SetupWatchQueue(ctx, queues, eventbridge.NewFromConfig(cfg), *prefix)
+			if err != nil {
+				fatal("Failed to set up change events", err)
+			}
+		}
+		if err := features.Watch(ctx, client, queues, lambda.NewFromConfig(cfg), watchOpts); err != nil {
+			fatal("Watch failed", err)
+		}
+	case "sync-k8s":
+		// Keep a Kubernetes Secret equal to the prefix, with the pod's service account, until interrupted.
+		if *prefix == "" || *secretName == "" {
+			fmt.Println("Error: -prefix and -secret are required for 'sync-k8s'")
+			os.Exit(features.ExitValidation)
+		}
+		if *dryRun {
+			fmt.Println("Error: -dry-run is not supported for 'sync-k8s'")
+			os.Exit(features.ExitValidation)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		err := features.SyncK8s(ctx, client, features.K8sSyncOptions{Prefix: *prefix, Secret: *secretName, Namespace: *namespace, Interval: *interval})
+		if err != nil {
+			fatal("Sync failed", err)
+		}
+	case "verify-running":
+		// Compare the environment of running ECS tasks with the current parameter values.
+		if *cluster == "" || *service == "" {
+			fmt.Println("Error: -cluster and -service are required for 'verify-running'")
+			os.Exit(features.ExitValidation)
+		}
+		opts := features.VerifyRunningOptions{Cluster: *cluster, Service: *service, Region: cfg.Region}
+		if err := features.VerifyRunning(context.TODO(), client, ecs.NewFromConfig(cfg), opts); err != nil {
+			fatal("Verification failed", err)
 		}
 	case "put":
 		// Ensure value is provided for put operation.
 		if *value == "" {
 			fmt.Println("Error: -value is required for 'put' action")
-			os.Exit(1)
+			os.Exit(features.ExitValidation)
 		}
 		// Validate type.
 		validTypes := map[string]bool{"string": true, "stringlist": true, "securestring": true}
 		if !validTypes[strings.ToLower(*paramType)] {
 			fmt.Println("Error: Invalid type. Use 'string', 'stringlist', or 'securestring'")
-			os.Exit(1)
+			os.Exit(features.ExitValidation)
 		}
 		// Capitalize type for API.
 		apiType := strings.ToLower(*paramType)
@@ -170,17 +1318,202 @@ func main() {
 		case "securestring":
 			apiType = "SecureString"
 		}
-		// Store a parameter with the specified type.
-		err := features.PutParameter(client, *name, *value, features.ParameterType(apiType))
+		// Store a parameter with the specified type; without -type an existing parameter keeps its own.
+		putOpts.KeepType = !flagSet("type")
+		err := features.PutParameter(client, *name, *value, features.ParameterType(apiType), putOpts)
+		if multiRegion != nil {
+			multiRegion.PrintResults()
+		}
+		if errors.Is(err, features.ErrOverwriteDeclined) {
+			features.Infof("Parameter %s already exists and was not overwritten\n", *name)
+			return
+		}
 		if err != nil {
-			log.Fatalf("Failed to put parameter: %v", err)
+			fatal("Failed to put parameter", err)
+		}
+		if *dryRun {
+			features.Infof("Dry run: parameter %s not modified\n", *name)
+		} else if putOpts.KeepType {
+			features.Infof("Parameter %s set successfully\n", *name)
+		} else {
+			features.Infof("Parameter %s set successfully as %s\n", *name, *paramType)
 		}
-		fmt.Printf("Parameter %s set successfully as %s\n", *name, *paramType)
 	default:
 		// Handle invalid actions.
-		fmt.Println("Invalid action. Use 'get', 'put', 'put-from-template', 'generate', or 'get-by-prefix'")
-		os.Exit(1)
+		fmt.Println("Invalid action. Use 'get', 'get-public', 'find', 'put', 'put-from-template', 'seed', 'bootstrap', 'import', 'import-terraform', 'import-vault', 'put-from-json', 'secretize', 'inline', 'move', 'reencrypt', 'audit-types', 'scan-leaks', 'rewrite-refs', 'graph', 'check-drift', 'check-contract', 'lint-template', 'generate', 'classify', 'verify-roundtrip', 'get-by-prefix', 'export', 'export-vault', 'get-as-json', 'list', 'tree', 'list-apps', 'list-envs', 'stale', 'expiring', 'serve', 'agent', 'watch', 'sync-k8s', 'agent-get', 'verify-running', 'bench', 'gc', 'doctor', 'explain-config', 'scaffold', 'version', 'self-update', or 'completion'")
+		os.Exit(features.ExitValidation)
+	}
+}
+
+// ssmEndpoint returns the SSM client option that sends requests to endpoint (kept as AWS's when empty).
+func ssmEndpoint(endpoint string) func(*ssm.Options) {
+	return func(o *ssm.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	}
+}
+
+// referenceOptions builds the valueFrom form for generated templates from -ref-format.
+// For 'arn' the account ID comes from the account_id template variable, or STS when it is not set.
+func referenceOptions(format, region string, vars map[string]string) (features.ReferenceOptions, error) {
+	switch format {
+	case "path":
+		return features.ReferenceOptions{}, nil
+	case "arn":
+	default:
+		return features.ReferenceOptions{}, fmt.Errorf("invalid -ref-format %q, use 'path' or 'arn'", format)
+	}
+	accountID := vars["account_id"]
+	if accountID == "" {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(region))
+		if err != nil {
+			return features.ReferenceOptions{}, fmt.Errorf("unable to load SDK config: %w", err)
+		}
+		accountID, err = features.CallerAccountID(cfg)
+		if err != nil {
+			return features.ReferenceOptions{}, err
+		}
+	}
+	return features.ReferenceOptions{AsARN: true, Region: region, AccountID: accountID}, nil
+}
+
+// fatal logs msg and err, then exits with the code matching the cause of err (see features.ExitCode).
+func fatal(msg string, err error) {
+	log.Printf("%s: %v", msg, err)
+	if report := features.ErrorReport(err); report != "" {
+		fmt.Fprint(os.Stderr, report)
+	}
+	if printPolicy {
+		if policy := features.PolicySnippet(err); policy != "" {
+			fmt.Fprint(os.Stderr, "IAM policy allowing the denied calls:\n"+policy)
+		}
+	}
+	hooks.Finish(err)
+	events.Finish(err)
+	os.Exit(features.ExitCode(err))
+}
+
+// scheduleClipboardClear starts a detached copy of this binary that clears the clipboard after delay,
+// so the caller returns immediately. It gets a salted token of value (see features.ClipboardToken) on
+// stdin, so nothing derived from value shows up in the process list.
+func scheduleClipboardClear(value string, delay time.Duration) error {
+	token, err := features.ClipboardToken(value)
+	if err != nil {
+		return err
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(self, "-action", "clear-clipboard", "-clear-after", delay.String())
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	_, err = io.WriteString(stdin, token)
+	if closeErr := stdin.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cmd.Process.Kill()
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// flagSet reports whether the named flag was given on the command line.
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// completionFlags describes every registered flag for the completion generator.
+func completionFlags() []features.CompletionFlag {
+	choices := map[string][]string{
+		"type":         {"string", "stringlist", "securestring"},
+		"ref-format":   {"path", "arn"},
+		"tier":         {"standard", "advanced", "intelligent-tiering"},
+		"annotate":     {features.TreeType, features.TreeSize},
+		"data-type":    {features.DataTypeText, features.DataTypeEC2Image},
+		"report":       {features.ReportJSON, features.ReportSARIF},
+		"shell":        {"bash", "zsh", "fish"},
+		"arrays":       {features.ArraysStringList, features.ArraysIndex, features.ArraysJSON},
+		"format":       features.FormatNames(),
+		"input-format": features.InputFormatNames(),
+	}
+	var flags []features.CompletionFlag
+	flag.VisitAll(func(f *flag.Flag) {
+		flags = append(flags, features.CompletionFlag{
+			Name:    f.Name,
+			Usage:   f.Usage,
+			Choices: choices[f.Name],
+			Dynamic: f.Name == "name" || f.Name == "from",
+		})
+	})
+	return flags
+}
+
+// completeNames prints parameter names starting with word, one per line, for the completion scripts.
+// Errors are silent so a missing credential never breaks the shell.
+func completeNames(region, word string) {
+	toolConfig, err := features.ReadConfig()
+	if err != nil {
+		return
+	}
+	if region == "" {
+		region = toolConfig.Region
+	}
+	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(region))
+	if err != nil {
+		return
+	}
+	names, err := features.CompleteParameterNames(ssm.NewFromConfig(cfg, ssmEndpoint(toolConfig.Endpoint)), region, toolConfig.ParameterPrefix, word)
+	if err != nil {
+		return
+	}
+	for _, name := range names {
+		fmt.Println(name)
+	}
+}
+
+// splitList splits a comma-separated flag value, trimming blanks and dropping empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// keyValueFlag collects repeated key=value flags into a map.
+type keyValueFlag map[string]string
+
+func (f keyValueFlag) String() string {
+	var pairs []string
+	for key, val := range f {
+		pairs = append(pairs, key+"="+val)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (f keyValueFlag) Set(s string) error {
+	key, val, ok := strings.Cut(s, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", s)
 	}
+	f[key] = val
+	return nil
 }
 
 func showHelp(action string) {
@@ -190,34 +1523,510 @@ func showHelp(action string) {
 		fmt.Println("  Retrieve a single parameter from AWS SSM.")
 		fmt.Println("  Usage: salter-aws -action get -name <param-name> [-region <region>]")
 		fmt.Println("  Example: salter-aws -action get -name /my/param")
+		fmt.Println("  Add -copy to put the value on the clipboard instead of the screen; it is cleared after 45s")
+		fmt.Println("  (change with -clear-after 2m, or 0 to keep it). Uses pbcopy, wl-copy, xclip, xsel or clip.")
+		fmt.Println("  Add -raw to print only the value, byte for byte, for use in scripts: DB_URL=$(salter-aws -action get -name /my/param -raw)")
+		fmt.Println("  -raw always prints to stdout and cannot be combined with -s, -o or -copy.")
+		fmt.Println("  Add -all-accounts (or -accounts staging,prod) to compare the parameter across accounts in config.json")
+		fmt.Println("  (SecureString values are shown as a short fingerprint, never in plain text).")
+		fmt.Println("  Add -inspect to print the subject, issuer, SANs and expiry of PEM certificates instead of the value;")
+		fmt.Println("  with -name /prod/tls/cert,/prod/tls/chain the certificates of every parameter are verified as one chain.")
+	case "find":
+		fmt.Println("Help for 'find' action:")
+		fmt.Println("  Pick a parameter with a fuzzy search instead of typing its exact name, then get it as 'get' does.")
+		fmt.Println("  Usage: salter-aws -action find [-prefix <prefix>] [-name <query>] [-copy | -raw] [-region <region>]")
+		fmt.Println("  Example: salter-aws -action find -prefix /prod/ -name dburl -copy")
+		fmt.Println("  Names are loaded under -prefix, or parameterPrefix from config.json, or /. The best matches of the")
+		fmt.Println("  query are listed with their types: type a number to pick one (Enter picks the first) or another")
+		fmt.Println("  query to search again. The characters of a query must appear in the name in order, so 'pdburl'")
+		fmt.Println("  finds /prod/app/DB_URL; words separated by spaces must all match. Only names and types are read")
+		fmt.Println("  until one is picked; -copy, -raw and -inspect then work as with 'get'.")
+	case "get-public":
+		fmt.Println("Help for 'get-public' action:")
+		fmt.Println("  Look up a public parameter AWS publishes, such as AMI IDs, regions and service endpoints.")
+		fmt.Println("  Usage: salter-aws -action get-public -name <public-parameter> [-field <field>] [-raw] [-region <region>]")
+		fmt.Println("  Example: salter-aws -action get-public -name /aws/service/ecs/optimized-ami/amazon-linux-2/recommended")
+		fmt.Println("  Names without a leading / are under /aws/service/, e.g. -name ecs/optimized-ami/amazon-linux-2/recommended.")
+		fmt.Println("  JSON values are pretty-printed; -field image_id prints one field (a.b for nested ones, a.0 for list")
+		fmt.Println("  items), strings without quotes: AMI=$(salter-aws -action get-public -name ... -field image_id)")
+		fmt.Println("  The version and modification date go to stderr. A path such as ami-amazon-linux-latest lists the")
+		fmt.Println("  parameters under it. Values differ per region, so pass the -region the result is used in.")
 	case "put":
 		fmt.Println("Help for 'put' action:")
 		fmt.Println("  Store or update a single parameter in AWS SSM.")
 		fmt.Println("  Usage: salter-aws -action put -name <param-name> -value <value> [-type <type>] [-region <region>]")
 		fmt.Println("  Types: string, stringlist, securestring (default: string)")
+		fmt.Println("  Without -type an existing parameter keeps its type and KMS key, so only the value changes;")
+		fmt.Println("  new parameters are created as String.")
 		fmt.Println("  Example: salter-aws -action put -name /my/param -value 'hello' -type securestring")
+		fmt.Println("  Add -dry-run to print the PutParameter call and a diff without writing.")
+		fmt.Println("  Add -expect-version <n> to refuse the put if someone else changed the parameter since version n.")
+		fmt.Println("  Add -data-type aws:ec2:image to store an AMI ID (type string); SSM checks the AMI exists after the put")
+		fmt.Println("  and does not create the version otherwise.")
+		fmt.Println("  Values over 4 KB need -tier advanced (or intelligent-tiering); names, sizes and StringList items are")
+		fmt.Println("  checked before the call, so mistakes fail with a clear message instead of AWS's ValidationException.")
+		fmt.Println("  Add -regions ap-southeast-3,ap-southeast-1 (with -yes, -if-not-exists or -dry-run) to write to several regions at once.")
+		fmt.Println("  Reads and checks use the first region, except that a type differing in another region fails the put.")
+		fmt.Println("  Overwriting an existing value asks for confirmation; use -yes (or PARAM_STORE_YES=1) to skip the prompt, -if-not-exists (or -no-overwrite) to only create missing parameters.")
+		fmt.Println("  Putting an existing parameter with another type (e.g. String as SecureString) fails; add -recreate-on-type-change")
+		fmt.Println("  to delete and recreate it with its description, tags and policies (its version history starts over).")
+		fmt.Println("  With -regions that deletes it in every region, so it is confirmed once for all of them even with -yes.")
 	case "put-from-template":
 		fmt.Println("Help for 'put-from-template' action:")
 		fmt.Println("  Push parameters from a JSON template to AWS SSM.")
 		fmt.Println("  Usage: salter-aws -action put-from-template -s <template.json> [-region <region>]")
 		fmt.Println("  Template format: ECS task definition with 'secrets' array.")
 		fmt.Println("  Example: salter-aws -action put-from-template -s template/task-definition.json")
+		fmt.Println("  valueFrom may contain placeholders like /{{env}}/app/DB_URL, filled from -var env=prod,")
+		fmt.Println("  the \"variables\" map in config.json, or the built-in {{region}}.")
+		fmt.Println("  Secrets without valueFrom are an error unless -fallback-to-prefix (or \"fallbackToPrefix\" in")
+		fmt.Println("  config.json) is set, which uses <parameterPrefix><name>.")
+		fmt.Println("  ARNs must match the active region and account; -allow-cross-account turns mismatches into warnings.")
+		fmt.Println("  A secret's optional kmsKeyId (SecureString only) selects the KMS key instead of aws/ssm.")
+		fmt.Println("  \"dataType\": \"aws:ec2:image\" (String only) stores an AMI ID that SSM checks exists, as -data-type does.")
+		fmt.Println("  Before any put, kms:Encrypt and kms:Decrypt on those keys are checked with dry-run calls and")
+		fmt.Println("  every missing permission is listed (exit code 3); -skip-kms-check turns this off.")
+		fmt.Println("  A secret with \"valueFromParameter\": \"/staging/app/KEY\" instead of a value copies that parameter")
+		fmt.Println("  (and its type, unless \"type\" is set) to valueFrom, for promotion templates without secrets in them.")
+		fmt.Println("  {{ssm:/path/KEY}} in a value is replaced by that parameter. Referenced parameters the template writes")
+		fmt.Println("  are put first, using the template's value; see 'graph'.")
+		fmt.Println("  {{rds-endpoint id}}, {{rds-reader-endpoint id}}, {{rds-port id}} and the same for elasticache")
+		fmt.Println("  are replaced by the endpoint of that RDS instance or cluster, or ElastiCache group or cluster.")
+		fmt.Println("  A value may be a number, boolean or list instead of a string: it is stored as text (a list as a")
+		fmt.Println("  StringList) with an allowed pattern recording the type, which 'export' restores in json, yaml and taskdef.")
+		fmt.Println("  A secret name defined twice is reported with its line numbers and the last one is used; -strict makes it an error.")
+		fmt.Println("  Add -regions ap-southeast-3,ap-southeast-1 (with -yes, -if-not-exists or -dry-run) to write to several regions at once.")
+		fmt.Println("  Reads and checks use the first region, except that a type differing in another region fails the put.")
+		fmt.Println("  Add -dry-run to print every PutParameter call and a diff without writing.")
+		fmt.Println("  Overwriting an existing value asks for confirmation; use -yes (or PARAM_STORE_YES=1) to skip the prompt, -if-not-exists (or -no-overwrite) to only create missing parameters.")
+		fmt.Println("  -interactive lists the secrets as new (+), changed (~) or unchanged (=), with the new and changed ones")
+		fmt.Println("  selected; toggle them by number or range (2 5-7), a for all, n for none, c for the changed, and press")
+		fmt.Println("  Enter to put the selected ones without further confirmation, or q to quit.")
+		fmt.Println("  -changed-since HEAD~1 puts only the secrets whose lines in the template changed since that git revision")
+		fmt.Println("  (git diff of the file); secrets removed from the template are not deleted.")
+	case "seed":
+		fmt.Println("Help for 'seed' action:")
+		fmt.Println("  Create the parameters a task definition or template references that do not exist yet, for standing")
+		fmt.Println("  up a new environment. Each value is asked for (hidden for SecureStrings; Enter takes the secret's")
+		fmt.Println("  \"default\", an empty answer skips it) or read from -defaults, and nothing is created until all are known.")
+		fmt.Println("  Usage: salter-aws -action seed -s <task-def.json> [-defaults <file>] [-input-format <format>] [-dry-run]")
+		fmt.Println("  -defaults takes any import format (.env, .json, ...), keyed by secret name. Secrets without a type are")
+		fmt.Println("  typed from their name and value as generate does. Existing parameters are never overwritten.")
+		fmt.Println("  valueFrom placeholders and logical names are resolved as for put-from-template.")
+		fmt.Println("  Example: salter-aws -action seed -s task-def.json -var env=staging")
+	case "bootstrap":
+		fmt.Println("Help for 'bootstrap' action:")
+		fmt.Println("  Create every parameter an environment spec declares that does not exist in the -env environment, so")
+		fmt.Println("  new environments are set up the same way each time. The spec is YAML (or JSON):")
+		fmt.Println("    prefix: /{{env}}/billing/")
+		fmt.Println("    parameters:")
+		fmt.Println("      - {name: DB_URL, type: securestring, description: Primary database, pattern: '^postgres://'}")
+		fmt.Println("      - {name: LOG_LEVEL, default: info, defaults: {prod: warn}, allowed: [debug, info, warn]}")
+		fmt.Println("      - {name: SESSION_SECRET, type: securestring, generate: {length: 48, charset: urlsafe}}")
+		fmt.Println("  Usage: salter-aws -action bootstrap -s <spec.yaml> -env <environment> [-dry-run]")
+		fmt.Println("  A missing value comes from defaults[env], default, or generate (alphanumeric, hex or urlsafe), and is")
+		fmt.Println("  otherwise asked for as in 'seed'; optional: true skips it instead. Every value is checked against")
+		fmt.Println("  pattern, allowed, minLength and maxLength before anything is created, and created with its description.")
+		fmt.Println("  Existing parameters are never overwritten; values breaking the rules are listed and fail the run.")
+		fmt.Println("  Without a prefix, names are logical names placed by pathPattern in config.json.")
+		fmt.Println("  Example: salter-aws -action bootstrap -s billing.yaml -env prod")
+	case "secretize":
+		fmt.Println("Help for 'secretize' action:")
+		fmt.Println("  Move plaintext 'environment' entries of a task definition into 'secrets': each value is put to")
+		fmt.Println("  SSM at <prefix><name> and the task definition is rewritten to reference it.")
+		fmt.Println("  Usage: salter-aws -action secretize -s <task-def.json> [-keys A,B] [-type <type>] [-prefix <prefix>] [-o <output.json>]")
+		fmt.Println("  Without -keys the entries that look like secrets (as in 'generate') are moved. Types are detected")
+		fmt.Println("  the same way unless -type is given. The prefix defaults to parameterPrefix from config.json.")
+		fmt.Println("  The file is rewritten in place unless -o is given; nothing is written with -dry-run.")
+		fmt.Println("  Existing parameters are handled as for 'put': prompt, -yes or -if-not-exists (skipped entries stay in environment).")
+		fmt.Println("  Example: salter-aws -action secretize -s task-def.json -keys DB_PASSWORD,API_TOKEN -prefix /prod/app/")
+	case "inline":
+		fmt.Println("Help for 'inline' action:")
+		fmt.Println("  The inverse of 'secretize': resolve every secret of every container and write it as a plain")
+		fmt.Println("  'environment' entry, for local ECS emulators without SSM access.")
+		fmt.Println("  Usage: salter-aws -action inline -s <task-def.json> -o <local-task-def.json>")
+		fmt.Println("  The output holds secrets in plaintext: it is created readable only by you and carries a")
+		fmt.Println("  \"_warning\" field, which also makes RegisterTaskDefinition reject it. Nothing is written")
+		fmt.Println("  unless every secret resolves. valueFrom placeholders are expanded as for -s.")
+		fmt.Println("  Example: salter-aws -action inline -s task-def.json -o local-task-def.json -var env=dev")
+	case "move":
+		fmt.Println("Help for 'move' action:")
+		fmt.Println("  Rename a parameter, or every parameter under a prefix, keeping its value, type, description,")
+		fmt.Println("  KMS key, tier, policies and tags. Version history and labels stay with the old name.")
+		fmt.Println("  Usage: salter-aws -action move -from <old-name> -to <new-name> [-s <task-def.json>]")
+		fmt.Println("         salter-aws -action move -from-prefix <old-prefix> -to-prefix <new-prefix> [-s <task-def.json>]")
+		fmt.Println("  Nothing is written if a destination already exists. With -s, valueFrom references to moved")
+		fmt.Println("  parameters in every container are rewritten in place, ARNs keeping their region and account.")
+		fmt.Println("  The sources are deleted after confirmation once everything is copied; -yes skips the prompt,")
+		fmt.Println("  -dry-run prints the calls instead.")
+		fmt.Println("  Example: salter-aws -action move -from /prod/app/OLD_KEY -to /prod/app/NEW_KEY -s task-def.json")
+	case "reencrypt":
+		fmt.Println("Help for 'reencrypt' action:")
+		fmt.Println("  Put every SecureString under a prefix again with the same value, encrypted with another KMS key,")
+		fmt.Println("  e.g. for a yearly key rotation. Other types and SecureStrings already on the key are skipped.")
+		fmt.Println("  Usage: salter-aws -action reencrypt -prefix <prefix> -kms-key-id <key-id|arn|alias>")
+		fmt.Println("  Each put is a new version, keeping the description, tier and policies; progress is printed as")
+		fmt.Println("  [n/total]. A failed put does not stop the others; the exit code is then 5. -dry-run prints the calls.")
+		fmt.Println("  Needs kms:Decrypt on the old key and kms:Encrypt (or GenerateDataKey) on the new one.")
+		fmt.Println("  Example: salter-aws -action reencrypt -prefix /prod/ -kms-key-id alias/new-key")
+	case "audit-types":
+		fmt.Println("Help for 'audit-types' action:")
+		fmt.Println("  List the String parameters under a prefix that look like secrets, using the same checks generate uses")
+		fmt.Println("  to type new keys: names with password, secret, token, key and similar words, PEM blocks, JWTs, URLs")
+		fmt.Println("  with credentials and long random-looking values. Values are only shown masked.")
+		fmt.Println("  Usage: salter-aws -action audit-types -prefix <prefix> [-fix] [-yes] [-kms-key-id <key>] [-dry-run]")
+		fmt.Println("  Example: salter-aws -action audit-types -prefix /prod/ -fix")
+		fmt.Println("  Finding any exits with code 7, so it can run in CI. -fix converts them to SecureString after")
+		fmt.Println("  confirmation: each is deleted and recreated with its description, tags and policies, so its version")
+		fmt.Println("  history starts over. The key is -kms-key-id, kmsKeyId in config.json, or aws/ssm.")
+	case "scan-leaks":
+		fmt.Println("Help for 'scan-leaks' action:")
+		fmt.Println("  Search the files under -path for the values of the SecureStrings under a prefix, to catch secrets")
+		fmt.Println("  committed by accident. Each occurrence is printed as file:line with the parameter it belongs to,")
+		fmt.Println("  never the value; values are kept only as hashes while files are read. In a git work tree the files")
+		fmt.Println("  git would commit are searched (tracked and untracked, not ignored), otherwise every file but .git.")
+		fmt.Println("  Binary files, files over 10 MB and values shorter than 8 characters are skipped.")
+		fmt.Println("  Usage: salter-aws -action scan-leaks [-prefix <prefix>] [-path <dir or file>]")
+		fmt.Println("  Example: salter-aws -action scan-leaks -prefix /prod/ -path ./repo")
+		fmt.Println("  The prefix defaults to parameterPrefix in config.json and the path to the current directory.")
+		fmt.Println("  Finding any exits with code 7, so it can run as a pre-commit hook or in CI.")
+	case "rewrite-refs":
+		fmt.Println("Help for 'rewrite-refs' action:")
+		fmt.Println("  Rewrite the valueFrom references of every container in task definitions after restructuring")
+		fmt.Println("  the parameter hierarchy. Every other byte of the files is kept.")
+		fmt.Println("  Usage: salter-aws -action rewrite-refs -s <task-def.json>[,<more.json>...] -map <old>=<new> [-o <out.json>]")
+		fmt.Println("  -map is repeatable; the longest matching prefix wins. Forms:")
+		fmt.Println("    /old/prefix/=/new/prefix/          parameter path, for bare paths and ARNs (which keep their form)")
+		fmt.Println("    arn:aws:ssm:<r>:<a>:parameter/old/=arn:aws:ssm:<r2>:<a2>:parameter/new/   ARN prefix, ARNs only")
+		fmt.Println("    region:us-east-1=eu-west-1         region of ARNs")
+		fmt.Println("    account:111111111111=222222222222  account of ARNs")
+		fmt.Println("  Files are rewritten in place unless -o is given (single file only); -dry-run only prints the changes.")
+		fmt.Println("  Example: salter-aws -action rewrite-refs -s taskdef.json -map /prod/app/=/prod/svc/app/ -o out.json")
+	case "graph":
+		fmt.Println("Help for 'graph' action:")
+		fmt.Println("  Write the parameter reference graph of a put-from-template template in Graphviz DOT format.")
+		fmt.Println("  Usage: salter-aws -action graph -s <template.json> [-o <graph.dot>] [-var key=value]")
+		fmt.Println("  A secret references a parameter through \"valueFromParameter\" or {{ssm:/path}} in its value.")
+		fmt.Println("  put-from-template writes referenced parameters first; a cycle is drawn in red and is an error.")
+		fmt.Println("  Without -o the graph is printed. No AWS access is needed.")
+		fmt.Println("  Example: salter-aws -action graph -s template.json -o graph.dot && dot -Tsvg graph.dot > graph.svg")
+	case "check-drift":
+		fmt.Println("Help for 'check-drift' action:")
+		fmt.Println("  Compare the live parameters a put-from-template template writes with the template, for scheduled CI runs.")
+		fmt.Println("  Usage: salter-aws -action check-drift -s <template.json> [-var key=value] [-region <region>]")
+		fmt.Println("  Exits 0 when every value and type matches and 2 when any parameter is missing or differs;")
+		fmt.Println("  other failures keep their usual exit codes. Values in the diff are masked as fingerprints.")
+		fmt.Println("  Example: salter-aws -action check-drift -s template/prod.json || page-oncall")
+	case "check-contract":
+		fmt.Println("Help for 'check-contract' action:")
+		fmt.Println("  Check the parameters under a prefix against a contract the service publishes, before deploying it:")
+		fmt.Println("    required: {DB_URL: securestring, PORT: string}")
+		fmt.Println("    optional: {SENTRY_DSN: string, FEATURES: any}")
+		fmt.Println("  Usage: salter-aws -action check-contract -prefix <prefix> -contract <contract.yaml> [-strict] [-region <region>]")
+		fmt.Println("  Exits 7 when a required key is missing or a declared key has another type. Keys under the prefix")
+		fmt.Println("  the contract does not declare are listed, and fail the check with -strict. Only metadata is read.")
+		fmt.Println("  Example: salter-aws -action check-contract -prefix /prod/app/ -contract app-contract.yaml")
+	case "lint-template":
+		fmt.Println("Help for 'lint-template' action:")
+		fmt.Println("  Check a put-from-template template without writing anything, printing file:line: severity: message.")
+		fmt.Println("  Usage: salter-aws -action lint-template -s <template.json> [-var key=value] [-strict]")
+		fmt.Println("  Errors: fields off the template schema (see scaffold schema), such as unknown types or values that")
+		fmt.Println("  are not strings, secrets without valueFrom, invalid ARNs, unresolved placeholders, values over")
+		fmt.Println("  8 KB, ARNs for another region or account, and live SecureStrings the template would store as plaintext.")
+		fmt.Println("  Warnings: duplicate names, empty values, values over 4 KB (advanced tier), other live type changes.")
+		fmt.Println("  Exits 6 on any error, or with -strict on any warning. Live types are read without decryption.")
+		fmt.Println("  Example: salter-aws -action lint-template -s template/task-definition.json")
 	case "generate":
 		fmt.Println("Help for 'generate' action:")
 		fmt.Println("  Generate an ECS task definition JSON from a .env file.")
 		fmt.Println("  Usage: salter-aws -action generate -s <env-file> -o <output.json>")
-		fmt.Println("  Automatically detects parameter types (string, securestring, etc.).")
+		fmt.Println("  Automatically detects parameter types (string, securestring, etc.); detection in config.json changes")
+		fmt.Println("  the rules, and classify shows which rule matched each key.")
 		fmt.Println("  Example: salter-aws -action generate -s my.env -o task-def.json")
+		fmt.Println("  Any import format works as the source, chosen by extension or -input-format, e.g. -s app.toml or -s app.csv.")
+		fmt.Println("  A key defined twice is reported with its line numbers and the last value is used; -strict makes it an error.")
+		fmt.Println("  A '# param: type=securestring kms=alias/app path=/prod/app/NAME' comment overrides the next key's")
+		fmt.Println("  type, KMS key (written as kmsKeyId, used by put-from-template) or parameter path.")
+		fmt.Println("  A type mapping file (-types, or types.yaml next to the source) pins the same settings by key or pattern,")
+		fmt.Println("  e.g. 'DB_PASSWORD: securestring' or '\"*_URL\": string', before the detection rules; # param: comments still win.")
+		fmt.Println("  Add -ref-format arn to write full SSM ARNs (account from -var account_id or STS) instead of bare paths.")
+		fmt.Println("  Add -canonical to sort keys and secrets by name for clean diffs (also for export, get-by-prefix, get -s, secretize).")
+		fmt.Println("  nameRules in config.json normalize keys in the paths (case, separators, length); changed paths are listed.")
+		fmt.Println("  Keys that map to the same path are an error; add -check-existing to also fail on paths that exist in")
+		fmt.Println("  SSM with another type (needs ssm:DescribeParameters).")
+		fmt.Println("  Add -version-var CONFIG_VERSION to set that variable to the fingerprint of the values (also for export, get-by-prefix),")
+		fmt.Println("  so registering the task definition starts a new deployment only when the configuration changed.")
+	case "classify":
+		fmt.Println("Help for 'classify' action:")
+		fmt.Println("  Report which keys of a source generate would store as SecureString, and the check that matched each")
+		fmt.Println("  one (rule and reason), for security tooling. Values are never written; secret fields read REDACTED.")
+		fmt.Println("  Usage: salter-aws -action classify -s <env-file> [-report json|sarif] [-o <report-file>]")
+		fmt.Println("  Example: salter-aws -action classify -s app.env -report sarif -o classify.sarif")
+		fmt.Println("  json writes the findings array of a gitleaks JSON report, sarif a SARIF 2.1.0 log for code scanning.")
+		fmt.Println("  The source is read as for generate: any import format, by extension or -input-format, and the type")
+		fmt.Println("  mapping file (-types, or types.yaml next to the source) is consulted before the detection rules.")
+	case "verify-roundtrip":
+		fmt.Println("Help for 'verify-roundtrip' action:")
+		fmt.Println("  Check that every key of a source survives generate, put-from-template and get -s unchanged: the")
+		fmt.Println("  values read back into a .env file are compared with the source, and lost, changed or added keys")
+		fmt.Println("  are printed with masked values (String values also around the first difference). Exits 7 if any.")
+		fmt.Println("  Usage: salter-aws -action verify-roundtrip -s <env-file> [-o <dir>] [-live -prefix <scratch-prefix>]")
+		fmt.Println("  Example: salter-aws -action verify-roundtrip -s app.env")
+		fmt.Println("  By default the parameters are put in an in-memory store, so no AWS access is needed. -live puts them")
+		fmt.Println("  in Parameter Store under -prefix, which must be empty, and deletes them afterwards.")
+		fmt.Println("  -o keeps the generated template.json and the regenerated roundtrip.env in that directory.")
 	case "get-by-prefix":
 		fmt.Println("Help for 'get-by-prefix' action:")
 		fmt.Println("  Retrieve all parameters under a prefix from AWS SSM.")
 		fmt.Println("  Usage: salter-aws -action get-by-prefix -prefix <prefix> -o <output-base> [-region <region>]")
-		fmt.Println("  Saves to <output-base>.env and <output-base>.json")
+		fmt.Println("  Saves to <output-base>.env and <output-base>.json; it is export with -format <formats>,taskdef, so")
+		fmt.Println("  several -format values, -fingerprint and the transforms of config.json work the same way.")
+		fmt.Println("  Add -timestamp to append the date to the file names (layout from -timestamp-layout, default 2006-01-02).")
 		fmt.Println("  Example: salter-aws -action get-by-prefix -prefix /prod/app/ -o app-params")
+		fmt.Println("  Add -ref-format arn to write full SSM ARNs in the JSON instead of bare paths.")
+		fmt.Println("  Add -format systemd to write an EnvironmentFile= with systemd quoting, or -format envdir to")
+		fmt.Println("  write the directory <output-base>/ with one file per variable (daemontools envdir, runit chpst -e).")
+		fmt.Println("  -format properties writes <output-base>.properties for Java (\"/\" in keys becomes \".\"), and")
+		fmt.Println("  -format appsettings writes <output-base>.appsettings.json for .NET, nesting keys on \"__\" and \"/\".")
+		fmt.Println("  -format toml and -format hcl write <output-base>.toml or .hcl, nesting keys into tables or blocks on \"/\".")
+	case "import":
+		fmt.Println("Help for 'import' action:")
+		fmt.Println("  Put every value of a TOML or HCL file under a prefix. Nested tables and blocks become path")
+		fmt.Println("  segments, so [db] host = \"x\" is written to <prefix>db/host; block labels are segments too.")
+		fmt.Println("  Arrays of plain values become StringList parameters; other types are detected as for 'generate'.")
+		fmt.Println("  Usage: salter-aws -action import -s <file.toml|file.hcl> -prefix <prefix> [-format toml|hcl] [-region <region>]")
+		fmt.Println("  Example: salter-aws -action import -s config/prod.toml -prefix /prod/app/ -dry-run")
+		fmt.Println("  Accepts -dry-run, -yes, -if-not-exists and -regions like 'put-from-template'.")
+		fmt.Println("  JSON files (.json, or -format json) are imported too; see 'put-from-json' for -arrays.")
+		fmt.Println("  -input-format reads other sources: dotenv (.env), csv (.csv: key or name, value, type, kms columns)")
+		fmt.Println("  and ecs-taskdef (template secrets with values). Names set by the source (# param: path=, the CSV")
+		fmt.Println("  name column, valueFrom) are used as-is; the other keys are put under -prefix.")
+		fmt.Println("  Terraform state files (.tfstate, -input-format terraform-state) put their aws_ssm_parameter values.")
+		fmt.Println("  -input-format 1password (op item get --format json) and bitwarden (bw list items) put each item field")
+		fmt.Println("  as <prefix><item>/<field>; concealed and hidden fields, passwords, TOTP seeds and notes are SecureStrings, the rest Strings.")
+		fmt.Println("  With dotenv and ecs-taskdef sources, -changed-since <git revision> puts only the keys whose lines changed.")
+	case "import-terraform":
+		fmt.Println("Help for 'import-terraform' action:")
+		fmt.Println("  Convert the aws_ssm_parameter resources of a Terraform state file into a put-from-template template,")
+		fmt.Println("  to audit what Terraform manages or to move ownership of the parameters to this tool.")
+		fmt.Println("  Usage: salter-aws -action import-terraform -s <terraform.tfstate> [-o <template.json>]")
+		fmt.Println("  Each secret keeps the parameter name, type, value and KMS key from the state. Parameters whose value")
+		fmt.Println("  the state does not hold get an empty value, which put-from-template skips.")
+		fmt.Println("  The template holds the values and is written with mode 0600; without -o it is printed. No AWS access is needed.")
+		fmt.Println("  Example: terraform state pull | salter-aws -action import-terraform -s - -o template/prod.json")
+	case "put-from-json":
+		fmt.Println("Help for 'put-from-json' action:")
+		fmt.Println("  Flatten a nested JSON object into parameters: {\"db\":{\"host\":\"x\"}} with -prefix /prod/app/")
+		fmt.Println("  is written to /prod/app/db/host. Numbers and booleans are stored as text.")
+		fmt.Println("  Usage: salter-aws -action put-from-json -s <config.json> -prefix <prefix> [-arrays stringlist|index|json]")
+		fmt.Println("  Arrays: stringlist (default) stores arrays of plain values as one StringList; index writes each")
+		fmt.Println("  element to <key>/0, <key>/1, ...; json stores the whole array as one JSON-encoded String.")
+		fmt.Println("  Example: salter-aws -action put-from-json -s config.json -prefix /prod/app/ -arrays index -dry-run")
+	case "export":
+		fmt.Println("Help for 'export' action:")
+		fmt.Println("  Write the parameters under a prefix in one or more formats, chosen with -format (comma-separated).")
+		fmt.Println("  Usage: salter-aws -action export -prefix <prefix> -format <formats> [-o <output-base>] [-region <region>]")
+		fmt.Println("  Formats and the file each writes with -o <base>:")
+		fmt.Println("    env          <base>.env           KEY=value lines")
+		fmt.Println("    systemd      <base>.env           systemd EnvironmentFile= with quoting")
+		fmt.Println("    envdir       <base>/              one file per variable")
+		fmt.Println("    properties   <base>.properties    Java properties")
+		fmt.Println("    appsettings  <base>.appsettings.json  .NET settings, nested on \"__\"")
+		fmt.Println("    toml, hcl    <base>.toml/.hcl     nested on \"/\"")
+		fmt.Println("    json, yaml   <base>.json/.yaml    nested on \"/\"")
+		fmt.Println("    k8s          <base>.secret.yaml   Kubernetes Secret (name from -name, or derived from the prefix)")
+		fmt.Println("    taskdef      <base>.json          ECS task definition secrets (-ref-format arn for ARNs)")
+		fmt.Println("  Without -o a single format is printed to stdout. Add -timestamp to append the date to the file names.")
+		fmt.Println("  json, yaml and taskdef write values put as numbers, booleans or lists by put-from-template with their type.")
+		fmt.Println("  taskdef also writes each secret's kmsKeyId, tier and description, and its tags with -with-tags, so")
+		fmt.Println("  put-from-template can recreate the parameters as they are.")
+		fmt.Println("  Add -fingerprint to print a SHA-256 of the keys and values and embed it as a comment (or k8s annotation).")
+		fmt.Println("  Example: salter-aws -action export -prefix /prod/app/ -format env,k8s -o deploy/app")
+	case "export-vault", "import-vault":
+		fmt.Println("Help for 'export-vault' and 'import-vault' actions:")
+		fmt.Println("  Mirror the parameters under a prefix into a HashiCorp Vault KV secret (version 1 or 2), or put a")
+		fmt.Println("  KV secret's keys under a prefix. Keys are the parameter names relative to -prefix.")
+		fmt.Println("  Usage: salter-aws -action export-vault -prefix <prefix> -vault-path <mount>/<path> [-dry-run]")
+		fmt.Println("         salter-aws -action import-vault -vault-path <mount>/<path> -prefix <prefix> [-dry-run] [-yes]")
+		fmt.Println("  export-vault replaces the secret's data (KV 2 keeps the old data as a version).")
+		fmt.Println("  import-vault detects types as 'generate' does and accepts -if-not-exists, -regions and -arrays like 'import'.")
+		fmt.Println("  Vault is reached at VAULT_ADDR (default http://127.0.0.1:8200) with VAULT_TOKEN, or with an AppRole")
+		fmt.Println("  login from VAULT_ROLE_ID and VAULT_SECRET_ID; VAULT_NAMESPACE and VAULT_CACERT are honoured.")
+		fmt.Println("  Example: salter-aws -action export-vault -prefix /prod/app/ -vault-path secret/prod/app")
+	case "get-as-json":
+		fmt.Println("Help for 'get-as-json' action:")
+		fmt.Println("  Rebuild the nested JSON object from the parameters under a prefix, the inverse of 'put-from-json'.")
+		fmt.Println("  Usage: salter-aws -action get-as-json -prefix <prefix> [-arrays stringlist|index|json] [-o <file.json>]")
+		fmt.Println("  Use the same -arrays as the import to get arrays back. Values are strings, except inside -arrays json arrays.")
+		fmt.Println("  Example: salter-aws -action get-as-json -prefix /prod/app/ -o config.json")
+	case "list":
+		fmt.Println("Help for 'list' action:")
+		fmt.Println("  List the names, types and versions of all parameters under a prefix.")
+		fmt.Println("  Usage: salter-aws -action list -prefix <prefix> [-region <region>]")
+		fmt.Println("  Add -all-accounts (or -accounts staging,prod) to print a comparison table across accounts")
+		fmt.Println("  in config.json; values are shown as short fingerprints, never in clear.")
+		fmt.Println("  Example: salter-aws -action list -prefix /prod/app/ -all-accounts")
+	case "tree":
+		fmt.Println("Help for 'tree' action:")
+		fmt.Println("  Print the parameters under a prefix as an indented tree, with the number of parameters under each path.")
+		fmt.Println("  Usage: salter-aws -action tree -prefix <prefix> [-annotate type,size] [-region <region>]")
+		fmt.Println("  Example: salter-aws -action tree -prefix /prod/ -annotate type")
+		fmt.Println("  -annotate type adds each parameter's type, read from metadata; -annotate size adds value sizes and")
+		fmt.Println("  the total of each path, which reads and decrypts the values (kms:Decrypt).")
+	case "list-apps", "list-envs":
+		fmt.Println("Help for 'list-apps' and 'list-envs' actions:")
+		fmt.Println("  List the applications (list-apps) or environments (list-envs) that have parameters, with the")
+		fmt.Println("  environments or applications each one occurs with and its number of parameters.")
+		fmt.Println("  Needs \"pathPattern\" in config.json, such as \"/{{env}}/{{app}}/{{name}}\"; {{app}} and {{env}} are")
+		fmt.Println("  read from the names of the parameters under its root. Only metadata is read (ssm:DescribeParameters).")
+		fmt.Println("  Usage: salter-aws -action list-apps [-region <region>]")
+		fmt.Println("         salter-aws -action list-envs [-region <region>]")
+		fmt.Println("  Example: salter-aws -action list-envs")
+	case "stale":
+		fmt.Println("Help for 'stale' action:")
+		fmt.Println("  List the parameters under a prefix not modified within a window, oldest first, with their")
+		fmt.Println("  last-modified date, age, version and the IAM identity that last modified them.")
+		fmt.Println("  Usage: salter-aws -action stale -prefix <prefix> -older-than <age> [-region <region>]")
+		fmt.Println("  Ages are days (180d), weeks (26w) or Go durations (36h). Only metadata is read (ssm:DescribeParameters).")
+		fmt.Println("  Example: salter-aws -action stale -prefix /prod/ -older-than 180d")
+	case "expiring":
+		fmt.Println("Help for 'expiring' action:")
+		fmt.Println("  Find the PEM certificates (every one of a chain) and JWTs in the values under a prefix and report")
+		fmt.Println("  those that have expired or expire within -within (x509 NotAfter, JWT exp claim). Values are not printed.")
+		fmt.Println("  Usage: salter-aws -action expiring -prefix <prefix> [-within 30d] [-region <region>]")
+		fmt.Println("  Exits with code 7 when anything expires within the window, for scheduled checks.")
+		fmt.Println("  Example: salter-aws -action expiring -prefix /prod/ -within 14d")
+	case "serve":
+		fmt.Println("Help for 'serve' action:")
+		fmt.Println("  Serve parameters over an authenticated HTTP/JSON API, so local tools need no AWS credentials.")
+		fmt.Println("  Usage: salter-aws -action serve [-listen 127.0.0.1:8099] [-cache-ttl 30s] [-region <region>]")
+		fmt.Println("  Clients send 'Authorization: Bearer <token>'; the token comes from PARAM_STORE_SERVER_TOKEN")
+		fmt.Println("  or is generated and printed at startup. Every request is written to an audit log line.")
+		fmt.Println("  Endpoints:")
+		fmt.Println("    GET /v1/parameter?name=/app/KEY    one parameter as JSON")
+		fmt.Println("    GET /v1/parameters?prefix=/app/    all parameters under a prefix as JSON")
+		fmt.Println("    GET /v1/env?prefix=/app/           the prefix rendered as KEY=value lines, quoted as for -format systemd")
+		fmt.Println("    GET /metrics                       Prometheus metrics: API calls, throttles, cache hits, last sync per prefix")
+		fmt.Println("    GET /healthz                       liveness (no token needed)")
+		fmt.Println("  Example: curl -H \"Authorization: Bearer $TOKEN\" 'http://127.0.0.1:8099/v1/env?prefix=/dev/app/'")
+	case "agent":
+		fmt.Println("Help for 'agent' action:")
+		fmt.Println("  Hold AWS credentials and a parameter cache in a background process, serving the same API as")
+		fmt.Println("  'serve' on a unix socket only the current user can open (no token needed). MFA prompts for")
+		fmt.Println("  assumed roles are answered once on the agent's terminal.")
+		fmt.Println("  Usage: salter-aws -action agent [-socket <path>] [-cache-ttl 30s] [-region <region>]")
+		fmt.Println("  Example: salter-aws -action agent -cache-ttl 10m &")
+		fmt.Println("           curl --unix-socket \"$XDG_RUNTIME_DIR/salter-aws/agent.sock\" 'http://agent/v1/env?prefix=/dev/app/'")
+	case "watch":
+		fmt.Println("Help for 'watch' action:")
+		fmt.Println("  Re-render outputs within seconds of a parameter under the prefix changing, instead of polling.")
+		fmt.Println("  Usage: salter-aws -action watch -prefix <prefix> [-format <formats> -o <output-base>] [-kubectl-apply]")
+		fmt.Println("                   [-lambda <function>] [-queue-url <url>] [-region <region>]")
+		fmt.Println("  Outputs are written as by 'export' (-format, -o, -name) and rendered once at startup. With -lambda the")
+		fmt.Println("  parameters are merged into the function's environment; -kubectl-apply applies the k8s Secret.")
+		fmt.Println("  Without -queue-url an SQS queue and an EventBridge rule for 'Parameter Store Change' events under")
+		fmt.Println("  the prefix are created (or updated) first; the events of each long poll trigger one render.")
+		fmt.Println("  Refused in read-only mode, since it creates AWS resources and writes to Lambda or Kubernetes.")
+		fmt.Println("  Example: salter-aws -action watch -prefix /prod/app/ -format env,k8s -o deploy/app -kubectl-apply")
+	case "sync-k8s":
+		fmt.Println("Help for 'sync-k8s' action:")
+		fmt.Println("  Keep a Kubernetes Secret equal to the parameters under a prefix, from a pod in the cluster (e.g. on EKS")
+		fmt.Println("  with IRSA or Pod Identity for the AWS side), until interrupted.")
+		fmt.Println("  Usage: salter-aws -action sync-k8s -prefix <prefix> -secret <name> [-namespace <ns>] [-interval 60s]")
+		fmt.Println("  Every -interval the parameters are read; when their fingerprint differs from the Secret's")
+		fmt.Println("  salter-aws/fingerprint annotation the Secret is server-side applied, so removed keys are removed.")
+		fmt.Println("  Keys are the names relative to the prefix with \"/\" as \".\". The service account needs get and patch")
+		fmt.Println("  on the Secret (and create, if it does not exist yet). The namespace defaults to the pod's.")
+		fmt.Println("  Example: salter-aws -action sync-k8s -prefix /prod/app/ -secret app-config -namespace prod -interval 60s")
+	case "agent-get":
+		fmt.Println("Help for 'agent-get' action:")
+		fmt.Println("  Print one parameter value from a running agent, with no newline. No AWS config is loaded.")
+		fmt.Println("  Usage: salter-aws -action agent-get [-socket <path>] <name>")
+		fmt.Println("  Example: export DB_PASSWORD=\"$(salter-aws -action agent-get /dev/app/DB_PASSWORD)\"")
+	case "verify-running":
+		fmt.Println("Help for 'verify-running' action:")
+		fmt.Println("  Read the environment of every running task of an ECS service through ECS Exec and compare the secrets")
+		fmt.Println("  its task definition takes from Parameter Store with their current values. Tasks read secrets at start,")
+		fmt.Println("  so a stale value stays until the next deployment.")
+		fmt.Println("  Usage: salter-aws -action verify-running -cluster <cluster> -service <service> [-region <region>]")
+		fmt.Println("  Exits 0 when every value is current, 7 when any is stale or its parameter was deleted, and 2")
+		fmt.Println("  when the service has no running tasks; values are shown as fingerprints.")
+		fmt.Println("  Needs ECS Exec enabled on the service, an env binary in the images and the session-manager-plugin.")
+		fmt.Println("  Example: salter-aws -action verify-running -cluster prod -service app || echo 'redeploy app'")
+	case "bench":
+		fmt.Println("Help for 'bench' action:")
+		fmt.Println("  Measure SSM throughput before a large migration: put -count synthetic String parameters under an")
+		fmt.Println("  empty -prefix with -concurrency calls in flight, read them back and delete them, then print each")
+		fmt.Println("  phase's calls per second, p50/p90/p99/max latency and the share of attempts SSM throttled.")
+		fmt.Println("  Usage: salter-aws -action bench -prefix <prefix> [-count 100] [-concurrency 10] [-tps <n>] [-region <region>]")
+		fmt.Println("  Compare runs with different -concurrency or -tps to pick settings that are not throttled.")
+		fmt.Println("  The parameters are tagged " + features.EphemeralTag + ", so 'gc' removes any an interrupted run leaves.")
+		fmt.Println("  Example: salter-aws -action bench -prefix /loadtest/ -count 500 -concurrency 20")
+	case "gc":
+		fmt.Println("Help for 'gc' action:")
+		fmt.Println("  Delete the parameters carrying a tag that were not modified for -older-than, such as those CI")
+		fmt.Println("  runs create, so test prefixes do not accumulate them. They are listed, oldest first, and deleted")
+		fmt.Println("  after confirmation. bench tags its parameters " + features.EphemeralTag + ".")
+		fmt.Println("  Usage: salter-aws -action gc -tag <key=value> -older-than <age> [-prefix <prefix>] [-yes] [-dry-run]")
+		fmt.Println("  Ages are days (7d), weeks (2w) or Go durations (36h). Only metadata is read (ssm:DescribeParameters).")
+		fmt.Println("  Example: salter-aws -action gc -tag purpose=ephemeral -older-than 24h")
+	case "doctor":
+		fmt.Println("Help for 'doctor' action:")
+		fmt.Println("  Diagnose the environment: config.json, write access to the -o directory, the region's SSM endpoint")
+		fmt.Println("  and clock skew, credentials, and the IAM permissions needed on the prefix (simulated with")
+		fmt.Println("  iam:SimulatePrincipalPolicy, or a read of the prefix when that is not allowed).")
+		fmt.Println("  Usage: salter-aws -action doctor [-prefix <prefix>] [-o <output-base>] [-region <region>]")
+		fmt.Println("  Each problem is printed with a hint. Exits 1 if a check failed; warnings do not fail.")
+		fmt.Println("  Example: salter-aws -action doctor -prefix /prod/app/ -o deploy/app")
+	case "explain-config":
+		fmt.Println("Help for 'explain-config' action:")
+		fmt.Println("  Print every setting with its effective value and where it comes from, highest precedence first:")
+		fmt.Println("  a flag (-region, -tps, -kms-key-id, -fallback-to-prefix, -read-only), a PARAM_STORE_* environment variable,")
+		fmt.Println("  config.json, or the built-in default. config.json is not created.")
+		fmt.Println("  Usage: salter-aws -action explain-config [-region <region>] [-tps <n>]")
+		fmt.Println("  Example: PARAM_STORE_REGION=eu-west-1 salter-aws -action explain-config")
+	case "scaffold":
+		fmt.Println("Help for 'scaffold' action:")
+		fmt.Println("  Write a starter file built into the binary, to begin without a checkout of the repository:")
+		fmt.Println("    taskdef  task-definition.json, a task definition with {{env}} placeholders for put-from-template and get -s")
+		fmt.Println("    spec     spec.yaml, an environment spec for bootstrap")
+		fmt.Println("    config   config.json with variables and a pathPattern")
+		fmt.Println("    schema   template.schema.json, the JSON Schema templates are checked against, for editors")
+		fmt.Println("  Usage: salter-aws -action scaffold taskdef|spec|config|schema [-o <file>]")
+		fmt.Println("  -o - prints the file. Existing files are never overwritten.")
+		fmt.Println("  Example: salter-aws -action scaffold spec -o billing.yaml")
+	case "version":
+		fmt.Println("Help for 'version' action:")
+		fmt.Println("  Print the version, commit and build date of the binary (set by make build), and the Go version and platform.")
+		fmt.Println("  Usage: salter-aws -action version (or salter-aws version)")
+		fmt.Println("  Builds made with plain go build report version dev.")
+	case "self-update":
+		fmt.Println("Help for 'self-update' action:")
+		fmt.Println("  Replace this binary with the latest GitHub release when it is newer. The download must match its")
+		fmt.Println("  SHA-256 in the release's checksums.txt, or nothing is replaced. dev builds always update.")
+		fmt.Println("  Usage: salter-aws -action self-update [-dry-run]")
+		fmt.Println("  -dry-run only reports whether a newer release exists. Set GITHUB_TOKEN to avoid the API rate limit.")
+		fmt.Println("  Example: sudo salter-aws -action self-update")
+	case "completion":
+		fmt.Println("Help for 'completion' action:")
+		fmt.Println("  Print a shell completion script. -name completes parameter paths under the configured")
+		fmt.Println("  parameterPrefix using DescribeParameters; names are cached for 5 minutes.")
+		fmt.Println("  Usage: salter-aws -action completion [-shell bash|zsh|fish]")
+		fmt.Println("  Example: salter-aws -action completion -shell zsh > ~/.zsh/completions/_salter-aws")
 	default:
 		fmt.Println("General help:")
 		fmt.Println("  Use -action <action> -h for specific help.")
-		fmt.Println("  Actions: get, put, put-from-template, generate, get-by-prefix")
+		fmt.Println("  Actions: get, get-public, find, put, put-from-template, seed, bootstrap, import, import-terraform, import-vault, put-from-json, secretize, inline, move, reencrypt, audit-types, scan-leaks, rewrite-refs, graph, check-drift, check-contract, lint-template, generate, classify, verify-roundtrip, get-by-prefix, export, export-vault, get-as-json, list, tree, list-apps, list-envs, stale, expiring, serve, agent, watch, sync-k8s, agent-get, verify-running, bench, gc, doctor, explain-config, scaffold, version, self-update, completion")
 		fmt.Println("  Example: salter-aws -action get -h")
+		fmt.Println("  Global flags: -region <region>, -dry-run (no changes are made by mutating actions),")
+		fmt.Println("                -tps <n> (cap SSM calls per second for the whole run, e.g. to leave room for deployments),")
+		fmt.Println("                -read-only (refuse actions that write to Parameter Store, Kubernetes, Vault or Lambda; see readOnly in config.json),")
+		fmt.Println("                -i-know-what-i-am-doing <justification> (allow writes under protectedPrefixes, logged to auditLog),")
+		fmt.Println("                -print-policy (on access denied, also print an IAM policy that allows the denied calls),")
+		fmt.Println("                -events ndjson [-events-file <file>] (one JSON line per parameter fetched, put, failed or retried)")
+		fmt.Println("  The \"hooks\" of config.json run commands or webhooks with the change set before and after writes.")
+		fmt.Println("  Exit codes: 0 ok, 1 other failure, 2 not found, 3 access denied, 4 throttled,")
+		fmt.Println("              5 partial failure, 6 validation error, 7 a check found problems (verify-running, expiring,")
+		fmt.Println("              check-contract, audit-types, scan-leaks, verify-roundtrip); check-drift exits 2 on drift")
 	}
 }
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+
+	"go-param-store/features"
+)
+
+// TestMain runs the CLI instead of the tests when runMain starts the test binary.
+func TestMain(m *testing.M) {
+	if os.Getenv("SALTER_AWS_RUN_MAIN") == "1" {
+		main()
+		os.Exit(features.ExitOK)
+	}
+	os.Exit(m.Run())
+}
+
+// runMain runs the CLI with args in an empty directory, without AWS credentials, and returns its output
+// and exit code.
+func runMain(t *testing.T, args ...string) (string, int) {
+	t.Helper()
+	dir := t.TempDir()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "SALTER_AWS_RUN_MAIN=1", "HOME="+dir, "AWS_CONFIG_FILE="+os.DevNull, "AWS_SHARED_CREDENTIALS_FILE="+os.DevNull)
+	out, err := cmd.CombinedOutput()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		return string(out), exit.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return string(out), features.ExitOK
+}
+
+func TestRawRejectsFileFlags(t *testing.T) {
+	for _, args := range [][]string{
+		{"-action", "get", "-name", "/app/TOKEN", "-raw", "-o", "env"},
+		{"-action", "get", "-name", "/app/TOKEN", "-raw", "-s", "task.json"},
+		{"-action", "get", "-name", "/app/TOKEN", "-raw", "-copy"},
+	} {
+		out, code := runMain(t, args...)
+		if code != features.ExitValidation || !strings.Contains(out, "-raw") {
+			t.Errorf("%v: exit %d, output %q; want a validation error", args, code, out)
+		}
+	}
+}
+
+func TestReadOnlyRefusesMutatingActions(t *testing.T) {
+	for _, action := range []string{"watch", "export-vault"} {
+		if !slices.Contains(mutatingActions, action) {
+			t.Errorf("mutatingActions lacks %s", action)
+		}
+	}
+	for _, action := range mutatingActions {
+		out, code := runMain(t, "-action", action, "-read-only")
+		if code != features.ExitValidation || !strings.Contains(out, "not allowed in read-only mode") {
+			t.Errorf("%s -read-only: exit %d, output %q; want it refused", action, code, out)
+		}
+	}
+	if out, code := runMain(t, "-action", "audit-types", "-fix", "-read-only"); code != features.ExitValidation || !strings.Contains(out, "not allowed in read-only mode") {
+		t.Errorf("audit-types -fix -read-only: exit %d, output %q; want it refused", code, out)
+	}
+}